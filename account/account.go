@@ -0,0 +1,66 @@
+// Package account defines the account types a bank holds and the rules that apply to a single account:
+// balances, overdrafts, interest and lifecycle states.
+package account
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Account defines the basic behavior of a bank account.
+type Account interface {
+	ID() string
+	Balance() Money
+	Deposit(amount Money) error
+	Withdraw(amount Money) error
+}
+
+// BalanceObserver is told about every change to an account's balance. Accounts call it while holding their own
+// mutex, so it must not call back into the account.
+type BalanceObserver func(delta Money)
+
+// notify reports a balance change if an observer is attached.
+func (o BalanceObserver) notify(delta Money) {
+	if o != nil && delta != 0 {
+		o(delta)
+	}
+}
+
+// Observable is implemented by account types that can report balance changes, so a bank can keep running totals.
+type Observable interface {
+	SetBalanceObserver(o BalanceObserver)
+}
+
+// Custom is implemented by account types defined outside this package, which a bank learns about through
+// bank.RegisterAccountType. Their type-specific fields are persisted as the JSON State returns. Custom accounts
+// must also be Observable, so the bank's running totals and reports follow their balances.
+type Custom interface {
+	Account
+	TypeName() string
+	State() (json.RawMessage, error)
+}
+
+// Accruer is implemented by accounts that earn or are charged interest. The bank's accrual engine calls Accrue at
+// least daily with today's date, and records the posts it returns.
+type Accruer interface {
+	Accrue(today time.Time) []InterestPost
+}
+
+// TypeOf names an account's type the same way its persisted record does.
+func TypeOf(acc Account) string {
+	switch a := acc.(type) {
+	case *Savings:
+		return "savings"
+	case *Checking:
+		return "checking"
+	case *RecurringDeposit:
+		return "recurring-deposit"
+	case *FixedDeposit:
+		return "fixed-deposit"
+	case *Loan:
+		return "loan"
+	case Custom:
+		return a.TypeName()
+	}
+	return "other"
+}
@@ -0,0 +1,175 @@
+package account
+
+import (
+	"math"
+	"time"
+)
+
+// accrualClock tracks how far an account's interest has been accrued and when it is next posted.
+// Posting dates are counted from the start date so monthly cycles do not drift at month ends.
+type accrualClock struct {
+	start    time.Time // First day accrued; zero until the engine first sees the account
+	through  time.Time // Interest has been accrued for every day before this one
+	postings int       // Posting cycles completed since start
+}
+
+// nextPosting returns the date of the next posting for a cycle of the given length in months.
+func (c *accrualClock) nextPosting(cycleMonths int) time.Time {
+	return AddMonths(c.start, (c.postings+1)*cycleMonths)
+}
+
+// advance walks day by day from where accrual stopped up to today, calling accrue for each day and post at each
+// posting date. The first call only starts the clock, so accounts earn from the day the engine first sees them.
+func (c *accrualClock) advance(today time.Time, cycleMonths int, accrue func(from, to time.Time), post func(on time.Time)) {
+	if c.start.IsZero() {
+		c.start, c.through = today, today
+		return
+	}
+	for day := c.through; day.Before(today); {
+		next := day.AddDate(0, 0, 1)
+		accrue(day, next)
+		day = next
+		if !day.Before(c.nextPosting(cycleMonths)) {
+			post(day)
+			c.postings++
+		}
+	}
+	if today.After(c.through) {
+		c.through = today
+	}
+}
+
+// periodStart returns the first day of the posting cycle under way.
+func (c *accrualClock) periodStart(cycleMonths int) time.Time {
+	return AddMonths(c.start, c.postings*cycleMonths)
+}
+
+// RateWindow is a promotional annual rate that applies from its start day until, but not including, its end day.
+type RateWindow struct {
+	Rate  float64 // As a fraction, e.g. 0.06 for 6%
+	Start time.Time
+	End   time.Time
+}
+
+// covers reports whether the window applies on a day.
+func (w RateWindow) covers(day time.Time) bool {
+	return !day.Before(w.Start) && day.Before(w.End)
+}
+
+// AppliedRate is the annual rate interest accrued at over part of a posting period.
+type AppliedRate struct {
+	From        time.Time
+	To          time.Time // First day after the part
+	Rate        float64   // As a fraction, including any bonus rate
+	Promotional bool
+}
+
+// appliedRates splits the days from one date to another into the parts accrued at the base rate and at the
+// window's, in order.
+func (w RateWindow) appliedRates(from, to time.Time, base, bonus float64) []AppliedRate {
+	var rates []AppliedRate
+	for day := from; day.Before(to); {
+		part := AppliedRate{From: day, To: to, Rate: base + bonus}
+		switch {
+		case w.covers(day):
+			part.Rate, part.Promotional = w.Rate+bonus, true
+			part.To = minTime(to, w.End)
+		case day.Before(w.Start):
+			part.To = minTime(to, w.Start)
+		}
+		rates = append(rates, part)
+		day = part.To
+	}
+	return rates
+}
+
+// minTime returns the earlier of two times.
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+// InterestPost is interest an account has posted to its balance during accrual.
+type InterestPost struct {
+	Date   time.Time
+	Amount Money         // Positive when credited, negative when charged
+	Rates  []AppliedRate // Rates the posted interest accrued at, for savings accounts
+}
+
+// Accrue accrues savings interest for each day up to today and credits it at the end of every
+// compounding period. Daily accruals are kept unrounded; only the posted amount is rounded, half to even,
+// and the remainder carries into the next period.
+func (sa *Savings) Accrue(today time.Time) []InterestPost {
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+	var posts []InterestPost
+	sa.accrual.advance(today, sa.compounding.months(),
+		func(from, to time.Time) {
+			rate := sa.interestRate
+			if sa.promotion.covers(from) {
+				rate = sa.promotion.Rate
+			}
+			sa.accrued += float64(sa.balance) * (rate + sa.bonusRate) * sa.dayCount.YearFraction(from, to)
+		},
+		func(on time.Time) {
+			amount := Money(math.RoundToEven(sa.accrued))
+			sa.accrued -= float64(amount)
+			if amount == 0 {
+				return
+			}
+			sa.balance += amount
+			sa.observer.notify(amount)
+			rates := sa.promotion.appliedRates(sa.accrual.periodStart(sa.compounding.months()), on, sa.interestRate, sa.bonusRate)
+			posts = append(posts, InterestPost{Date: on, Amount: amount, Rates: rates})
+		})
+	return posts
+}
+
+// AccruedInterest returns savings interest accrued but not yet posted, in minor units before rounding.
+func (sa *Savings) AccruedInterest() float64 {
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+	return sa.accrued
+}
+
+// NextInterestPosting returns when savings interest is next credited, or zero if accrual has not started.
+func (sa *Savings) NextInterestPosting() time.Time {
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+	if sa.accrual.start.IsZero() {
+		return time.Time{}
+	}
+	return sa.accrual.nextPosting(sa.compounding.months())
+}
+
+// NextInterestPosting returns when overdraft interest is next charged, or zero if accrual has not started.
+func (ca *Checking) NextInterestPosting() time.Time {
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	if ca.accrual.start.IsZero() {
+		return time.Time{}
+	}
+	return ca.accrual.nextPosting(1)
+}
+
+// Accrue accrues overdraft interest for each day up to today on an Actual/365 basis and charges it monthly. Like
+// savings interest, daily accruals are kept unrounded and only the charged amount is rounded.
+func (ca *Checking) Accrue(today time.Time) []InterestPost {
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	var posts []InterestPost
+	ca.accrual.advance(today, 1,
+		func(from, to time.Time) {
+			if ca.balance < 0 {
+				ca.overdraftInterest += float64(-ca.balance) * ca.overdraftRate * Actual365.YearFraction(from, to)
+			}
+		},
+		func(on time.Time) {
+			if posted := ca.postOverdraftInterest(); posted != 0 {
+				posts = append(posts, InterestPost{Date: on, Amount: -posted})
+			}
+		})
+	return posts
+}
@@ -0,0 +1,142 @@
+package account
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// day returns midnight UTC on a date in 2026.
+func day(month time.Month, d int) time.Time {
+	return time.Date(2026, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+// accruer is an account that accrues interest day by day.
+type accruer interface {
+	Account
+	Accrue(today time.Time) []InterestPost
+}
+
+func TestAccrue(t *testing.T) {
+	tests := []struct {
+		name        string
+		account     accruer
+		through     time.Time
+		wantPosts   []Money
+		wantBalance Money
+		wantAccrued float64
+	}{
+		{
+			name:        "savings credits a month of interest on the posting date",
+			account:     NewSavings("S1", 100000, 0.0365),
+			through:     day(time.February, 1),
+			wantPosts:   []Money{310},
+			wantBalance: 100310,
+		},
+		{
+			name:        "savings accrues without posting before the posting date",
+			account:     NewSavings("S1", 100000, 0.0365),
+			through:     day(time.January, 15),
+			wantBalance: 100000,
+			wantAccrued: 140,
+		},
+		{
+			name:        "savings keeps fractions of a minor unit across days and carries the remainder",
+			account:     NewSavings("S1", 1000, 0.0365),
+			through:     day(time.February, 1),
+			wantPosts:   []Money{3},
+			wantBalance: 1003,
+			wantAccrued: 0.1,
+		},
+		{
+			name:        "savings credits every month passed",
+			account:     NewSavings("S1", 100000, 0.0365),
+			through:     day(time.March, 1),
+			wantPosts:   []Money{310, 281},
+			wantBalance: 100591,
+			wantAccrued: -0.132,
+		},
+		{
+			name:        "checking charges overdraft interest monthly",
+			account:     NewChecking("C1", -100000, 200000, 0.0365),
+			through:     day(time.February, 1),
+			wantPosts:   []Money{-310},
+			wantBalance: -100310,
+		},
+		{
+			name:        "checking keeps fractions of a minor unit across days and carries the remainder",
+			account:     NewChecking("C1", -1000, 200000, 0.0365),
+			through:     day(time.February, 1),
+			wantPosts:   []Money{-3},
+			wantBalance: -1003,
+			wantAccrued: 0.1,
+		},
+		{
+			name:        "checking in credit is charged nothing",
+			account:     NewChecking("C1", 1000, 200000, 0.0365),
+			through:     day(time.February, 1),
+			wantBalance: 1000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if posts := tt.account.Accrue(day(time.January, 1)); len(posts) != 0 {
+				t.Fatalf("first Accrue posted %v; want it to only start the clock", posts)
+			}
+			var got []Money
+			for _, post := range tt.account.Accrue(tt.through) {
+				got = append(got, post.Amount)
+			}
+			if len(got) != len(tt.wantPosts) {
+				t.Fatalf("posted %v, want %v", got, tt.wantPosts)
+			}
+			for i := range got {
+				if got[i] != tt.wantPosts[i] {
+					t.Errorf("post %d = %s, want %s", i, got[i], tt.wantPosts[i])
+				}
+			}
+			if balance := tt.account.Balance(); balance != tt.wantBalance {
+				t.Errorf("balance = %s, want %s", balance, tt.wantBalance)
+			}
+			var accrued float64
+			switch a := tt.account.(type) {
+			case *Savings:
+				accrued = a.AccruedInterest()
+			case *Checking:
+				accrued = a.OverdraftInterest()
+			}
+			if math.Abs(accrued-tt.wantAccrued) > 1e-6 {
+				t.Errorf("accrued = %v, want %v", accrued, tt.wantAccrued)
+			}
+		})
+	}
+}
+
+func TestAccrualSurvivesRecord(t *testing.T) {
+	tests := []struct {
+		name    string
+		account accruer
+	}{
+		{name: "savings", account: NewSavings("S1", 1000, 0.0365)},
+		{name: "checking", account: NewChecking("C1", -1000, 200000, 0.0365)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.account.Accrue(day(time.January, 1))
+			tt.account.Accrue(day(time.January, 21))
+			rec, err := ToRecord(tt.account)
+			if err != nil {
+				t.Fatal(err)
+			}
+			restored, err := FromRecord(rec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tt.account.Accrue(day(time.February, 1))
+			got := restored.(accruer).Accrue(day(time.February, 1))
+			if len(got) != 1 || len(want) != 1 || got[0].Amount != want[0].Amount {
+				t.Errorf("restored account posted %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package account
+
+import (
+	"errors"
+	"math"
+	"sync"
+)
+
+// Checking represents a checking account that may be overdrawn up to an overdraft limit.
+type Checking struct {
+	id                string
+	balance           Money
+	overdraftLimit    Money   // How far below zero the balance may go
+	overdraftRate     float64 // Annual rate charged on the overdrawn balance
+	overdraftInterest float64 // Overdraft interest accrued but not yet posted, in minor units before rounding
+	accrual           accrualClock
+	observer          BalanceObserver // Reports balance changes to the bank's aggregates
+	mutex             *sync.Mutex
+}
+
+// NewChecking creates a checking account that may be overdrawn up to overdraftLimit, charging overdraftRate
+// a year on the overdrawn balance.
+func NewChecking(id string, balance, overdraftLimit Money, overdraftRate float64) *Checking {
+	return &Checking{
+		id:             id,
+		balance:        balance,
+		overdraftLimit: overdraftLimit,
+		overdraftRate:  overdraftRate,
+		mutex:          &sync.Mutex{},
+	}
+}
+
+// ID returns the ID of the checking account.
+func (ca *Checking) ID() string {
+	return ca.id
+}
+
+// Balance returns the balance of the checking account, which is negative when overdrawn.
+func (ca *Checking) Balance() Money {
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	return ca.balance
+}
+
+// Deposit adds funds to the checking account.
+func (ca *Checking) Deposit(amount Money) error {
+	if amount <= 0 {
+		return errors.New("deposit amount must be positive")
+	}
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	ca.balance += amount
+	ca.observer.notify(amount)
+	return nil
+}
+
+// Withdraw subtracts funds from the checking account, allowing it to go overdrawn up to the limit.
+func (ca *Checking) Withdraw(amount Money) error {
+	if amount <= 0 {
+		return errors.New("withdrawal amount must be positive")
+	}
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	if ca.balance-amount < -ca.overdraftLimit {
+		return errors.New("withdrawal exceeds overdraft limit")
+	}
+	ca.balance -= amount
+	ca.observer.notify(-amount)
+	return nil
+}
+
+// OverdraftLimit returns how far below zero the balance may go.
+func (ca *Checking) OverdraftLimit() Money {
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	return ca.overdraftLimit
+}
+
+// SetOverdraftLimit changes the overdraft limit. It does not affect an existing overdrawn balance.
+func (ca *Checking) SetOverdraftLimit(limit Money) error {
+	if limit < 0 {
+		return errors.New("overdraft limit must not be negative")
+	}
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	ca.overdraftLimit = limit
+	return nil
+}
+
+// OverdraftInterest returns the overdraft interest accrued but not yet posted, in minor units before rounding.
+func (ca *Checking) OverdraftInterest() float64 {
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	return ca.overdraftInterest
+}
+
+// AccrueOverdraftInterest accrues interest on the overdrawn balance for a fraction of a year.
+func (ca *Checking) AccrueOverdraftInterest(yearFraction float64) {
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	if ca.balance >= 0 {
+		return
+	}
+	ca.overdraftInterest += float64(-ca.balance) * ca.overdraftRate * yearFraction
+}
+
+// PostOverdraftInterest debits the accrued overdraft interest, rounded half to even, from the balance and returns
+// the amount posted. The remainder carries into the next posting.
+func (ca *Checking) PostOverdraftInterest() Money {
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	return ca.postOverdraftInterest()
+}
+
+// postOverdraftInterest debits the rounded accrued overdraft interest and keeps the remainder.
+// The caller must hold the account mutex.
+func (ca *Checking) postOverdraftInterest() Money {
+	posted := Money(math.RoundToEven(ca.overdraftInterest))
+	ca.overdraftInterest -= float64(posted)
+	if posted == 0 {
+		return 0
+	}
+	ca.balance -= posted
+	ca.observer.notify(-posted)
+	return posted
+}
+
+// SetBalanceObserver attaches the bank's balance observer.
+func (ca *Checking) SetBalanceObserver(o BalanceObserver) {
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	ca.observer = o
+}
+
+// Spendable returns how much can be debited from an account, including any overdraft.
+func Spendable(acc Account) Money {
+	if ca, ok := acc.(*Checking); ok {
+		return ca.Balance() + ca.OverdraftLimit()
+	}
+	return acc.Balance()
+}
@@ -0,0 +1,189 @@
+package account
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// FixedDeposit is a certificate of deposit: a principal locked for a fixed term, earning simple interest on an
+// Actual/365 basis. At maturity, or when broken early for a penalty, the funds are released for payout.
+type FixedDeposit struct {
+	id                     string
+	balance                Money
+	principal              Money
+	interestRate           float64 // Annual rate, simple interest
+	termMonths             int
+	openedAt               time.Time
+	maturityDate           time.Time
+	earlyWithdrawalPenalty float64         // Share of the principal forfeited when broken before maturity
+	payoutAccountID        string          // Savings account the funds are released into
+	released               bool            // Interest has been settled and the funds may be withdrawn
+	interestPayouts        int             // Months of interest paid out before maturity; see PayOutInterest
+	observer               BalanceObserver // Reports balance changes to the bank's aggregates
+	mutex                  *sync.Mutex
+}
+
+// NewFixedDeposit creates a fixed deposit of principal opened at openedAt, maturing after termMonths and paying
+// out into payoutID.
+func NewFixedDeposit(id string, principal Money, interestRate float64, termMonths int, earlyWithdrawalPenalty float64, payoutID string, openedAt time.Time) *FixedDeposit {
+	return &FixedDeposit{
+		id:                     id,
+		balance:                principal,
+		principal:              principal,
+		interestRate:           interestRate,
+		termMonths:             termMonths,
+		openedAt:               openedAt,
+		maturityDate:           AddMonths(openedAt, termMonths),
+		earlyWithdrawalPenalty: earlyWithdrawalPenalty,
+		payoutAccountID:        payoutID,
+		mutex:                  &sync.Mutex{},
+	}
+}
+
+// ID returns the ID of the fixed deposit account.
+func (fd *FixedDeposit) ID() string {
+	return fd.id
+}
+
+// Balance returns the balance of the fixed deposit account.
+func (fd *FixedDeposit) Balance() Money {
+	fd.mutex.Lock()
+	defer fd.mutex.Unlock()
+	return fd.balance
+}
+
+// Deposit is never allowed; the principal is fixed when the deposit is opened.
+func (fd *FixedDeposit) Deposit(amount Money) error {
+	return errors.New("fixed deposit principal cannot be added to")
+}
+
+// Withdraw is only allowed once the funds have been released at maturity or by breaking the deposit.
+func (fd *FixedDeposit) Withdraw(amount Money) error {
+	if amount <= 0 {
+		return errors.New("withdrawal amount must be positive")
+	}
+	fd.mutex.Lock()
+	defer fd.mutex.Unlock()
+	if !fd.released {
+		return errors.New("fixed deposit is locked until maturity")
+	}
+	if fd.balance < amount {
+		return errors.New("insufficient funds")
+	}
+	fd.balance -= amount
+	fd.observer.notify(-amount)
+	return nil
+}
+
+// SetBalanceObserver attaches the bank's balance observer.
+func (fd *FixedDeposit) SetBalanceObserver(o BalanceObserver) {
+	fd.mutex.Lock()
+	defer fd.mutex.Unlock()
+	fd.observer = o
+}
+
+// MaturityDate returns when the deposit matures.
+func (fd *FixedDeposit) MaturityDate() time.Time {
+	return fd.maturityDate
+}
+
+// PayoutAccountID returns the savings account the funds are released into.
+func (fd *FixedDeposit) PayoutAccountID() string {
+	return fd.payoutAccountID
+}
+
+// Released reports whether the funds have been released for payout.
+func (fd *FixedDeposit) Released() bool {
+	fd.mutex.Lock()
+	defer fd.mutex.Unlock()
+	return fd.released
+}
+
+// interestTo returns the simple interest earned on the principal from opening up to t, rounded half to even.
+// The caller must hold the mutex.
+func (fd *FixedDeposit) interestTo(t time.Time) Money {
+	if t.After(fd.maturityDate) {
+		t = fd.maturityDate
+	}
+	if !t.After(fd.openedAt) {
+		return 0
+	}
+	return fd.principal.MulRate(fd.interestRate * Actual365.YearFraction(fd.openedAt, t))
+}
+
+// paidInterest returns the interest already paid out by PayOutInterest.
+// The caller must hold the mutex.
+func (fd *FixedDeposit) paidInterest() Money {
+	if fd.interestPayouts == 0 {
+		return 0
+	}
+	return fd.interestTo(AddMonths(fd.openedAt, fd.interestPayouts))
+}
+
+// MaturityAmount returns the principal plus the interest earned over the full term and not already paid out.
+func (fd *FixedDeposit) MaturityAmount() Money {
+	fd.mutex.Lock()
+	defer fd.mutex.Unlock()
+	return fd.principal + fd.interestTo(fd.maturityDate) - fd.paidInterest()
+}
+
+// PayOutInterest settles the interest of every month since opening that has ended by today, before maturity, and
+// not been settled yet, returning it for the caller to pay out elsewhere. The balance is left unchanged, and the
+// interest settled is not credited again at maturity.
+func (fd *FixedDeposit) PayOutInterest(today time.Time) []InterestPost {
+	fd.mutex.Lock()
+	defer fd.mutex.Unlock()
+	var posts []InterestPost
+	for !fd.released {
+		periodEnd := AddMonths(fd.openedAt, fd.interestPayouts+1)
+		if periodEnd.After(today) || !periodEnd.Before(fd.maturityDate) {
+			break
+		}
+		amount := fd.interestTo(periodEnd) - fd.paidInterest()
+		fd.interestPayouts++
+		if amount != 0 {
+			posts = append(posts, InterestPost{Date: periodEnd, Amount: amount})
+		}
+	}
+	return posts
+}
+
+// Mature credits the interest for the full term and releases the funds, if the deposit has matured by now.
+// It returns the interest credited, or false if the deposit has not matured or was already released.
+func (fd *FixedDeposit) Mature(now time.Time) (Money, bool) {
+	fd.mutex.Lock()
+	defer fd.mutex.Unlock()
+	if fd.released || now.Before(fd.maturityDate) {
+		return 0, false
+	}
+	interest := fd.interestTo(fd.maturityDate) - fd.paidInterest()
+	fd.balance += interest
+	fd.observer.notify(interest)
+	fd.released = true
+	return interest, true
+}
+
+// Break releases the funds before maturity, crediting the interest earned so far and not paid out, and deducting the
+// early withdrawal penalty. The balance never goes below zero. It returns the interest credited and the penalty
+// charged.
+func (fd *FixedDeposit) Break(now time.Time) (interest, penalty Money, err error) {
+	fd.mutex.Lock()
+	defer fd.mutex.Unlock()
+	if fd.released {
+		return 0, 0, errors.New("fixed deposit has already been released")
+	}
+	if !now.Before(fd.maturityDate) {
+		return 0, 0, errors.New("fixed deposit has matured; it is paid out without penalty")
+	}
+	interest = fd.interestTo(now) - fd.paidInterest()
+	penalty = fd.principal.MulRate(fd.earlyWithdrawalPenalty)
+	if penalty > fd.balance+interest {
+		penalty = fd.balance + interest
+	}
+	before := fd.balance
+	fd.balance += interest - penalty
+	fd.observer.notify(fd.balance - before)
+	fd.released = true
+	return interest, penalty, nil
+}
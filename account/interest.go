@@ -0,0 +1,133 @@
+package account
+
+import (
+	"errors"
+	"time"
+)
+
+// CompoundingFrequency describes how often accrued interest is credited to the balance.
+type CompoundingFrequency int
+
+const (
+	CompoundMonthly CompoundingFrequency = iota
+	CompoundQuarterly
+	CompoundAnnually
+)
+
+// months returns the number of months between interest credits.
+func (cf CompoundingFrequency) months() int {
+	switch cf {
+	case CompoundQuarterly:
+		return 3
+	case CompoundAnnually:
+		return 12
+	default:
+		return 1
+	}
+}
+
+// DayCountConvention determines how a period's length is converted into a fraction of a year.
+type DayCountConvention int
+
+const (
+	Actual365 DayCountConvention = iota
+	Actual360
+	Thirty360
+)
+
+// YearFraction returns the fraction of a year between two dates under the convention.
+func (dc DayCountConvention) YearFraction(from, to time.Time) float64 {
+	switch dc {
+	case Actual360:
+		return to.Sub(from).Hours() / 24 / 360
+	case Thirty360:
+		y1, m1, d1 := from.Date()
+		y2, m2, d2 := to.Date()
+		if d1 == 31 {
+			d1 = 30
+		}
+		if d2 == 31 && d1 == 30 {
+			d2 = 30
+		}
+		days := 360*(y2-y1) + 30*(int(m2)-int(m1)) + (d2 - d1)
+		return float64(days) / 360
+	default:
+		return to.Sub(from).Hours() / 24 / 365
+	}
+}
+
+// SetInterestTerms sets how the savings account compounds and counts days for interest.
+func (sa *Savings) SetInterestTerms(compounding CompoundingFrequency, dayCount DayCountConvention) {
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+	sa.compounding = compounding
+	sa.dayCount = dayCount
+}
+
+// ProjectionRow is one month of an interest projection.
+type ProjectionRow struct {
+	Month            int
+	Date             time.Time // End of the month
+	OpeningBalance   Money
+	Deposit          Money
+	InterestAccrued  Money // Interest earned during the month
+	InterestCredited Money // Interest added to the balance at month end
+	ClosingBalance   Money
+}
+
+// ProjectInterest projects the account month by month from today over the horizon, assuming the
+// planned deposit is made at the start of each month. The interest rate is treated as an annual rate.
+func (sa *Savings) ProjectInterest(horizonMonths int, plannedMonthlyDeposit Money) ([]ProjectionRow, error) {
+	return sa.ProjectInterestFrom(time.Now(), horizonMonths, plannedMonthlyDeposit)
+}
+
+// ProjectInterestFrom is ProjectInterest with an explicit start date.
+func (sa *Savings) ProjectInterestFrom(start time.Time, horizonMonths int, plannedMonthlyDeposit Money) ([]ProjectionRow, error) {
+	if horizonMonths <= 0 {
+		return nil, errors.New("horizon must be at least one month")
+	}
+	if plannedMonthlyDeposit < 0 {
+		return nil, errors.New("planned deposit must not be negative")
+	}
+	sa.mutex.Lock()
+	balance, rate := sa.balance, sa.interestRate+sa.bonusRate
+	compounding, dayCount := sa.compounding, sa.dayCount
+	sa.mutex.Unlock()
+
+	rows := make([]ProjectionRow, 0, horizonMonths)
+	pending := Money(0) // Interest accrued but not yet credited
+	periodStart := start
+	for month := 1; month <= horizonMonths; month++ {
+		periodEnd := AddMonths(start, month)
+		row := ProjectionRow{
+			Month:          month,
+			Date:           periodEnd,
+			OpeningBalance: balance,
+			Deposit:        plannedMonthlyDeposit,
+		}
+		balance += plannedMonthlyDeposit
+		row.InterestAccrued = balance.MulRate(rate * dayCount.YearFraction(periodStart, periodEnd))
+		pending += row.InterestAccrued
+		if month%compounding.months() == 0 || month == horizonMonths {
+			row.InterestCredited = pending
+			balance += row.InterestCredited
+			pending = 0
+		}
+		row.ClosingBalance = balance
+		rows = append(rows, row)
+		periodStart = periodEnd
+	}
+	return rows, nil
+}
+
+// AddMonths adds calendar months to a date, clamping to the end of shorter months
+// so that e.g. January 31 plus one month is the last day of February.
+func AddMonths(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if d > lastDay {
+		d = lastDay
+	}
+	return first.AddDate(0, 0, d-1)
+}
@@ -0,0 +1,204 @@
+package account
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// Loan is an amortizing loan paid out into a customer's account when it is opened. Interest is charged monthly on
+// the outstanding principal at each due date, and repayments settle interest due before principal. The balance is
+// the amount owed, as a negative number.
+type Loan struct {
+	id                    string
+	principal             Money   // Amount lent
+	interestRate          float64 // Annual rate, charged monthly
+	termMonths            int
+	openedAt              time.Time
+	outstanding           Money // Principal not yet repaid
+	interestDue           Money // Interest charged and not yet repaid
+	installmentsCharged   int   // Due dates whose interest has been charged
+	disbursementAccountID string
+	observer              BalanceObserver // Reports balance changes to the bank's aggregates
+	mutex                 *sync.Mutex
+}
+
+// Installment is one row of a loan's amortization schedule.
+type Installment struct {
+	Number    int
+	DueDate   time.Time
+	Payment   Money
+	Interest  Money
+	Principal Money
+	Balance   Money // Principal outstanding after the payment
+}
+
+// LevelPayment returns the monthly payment that repays principal over the term at the annual rate.
+func LevelPayment(principal Money, annualRate float64, termMonths int) Money {
+	if termMonths <= 0 {
+		return 0
+	}
+	r := annualRate / 12
+	if r == 0 {
+		return principal.MulRate(1 / float64(termMonths))
+	}
+	return principal.MulRate(r / (1 - math.Pow(1+r, -float64(termMonths))))
+}
+
+// NewLoan creates a loan of principal opened at openedAt and repaid monthly over termMonths, whose funds were paid
+// out into disbursementID.
+func NewLoan(id string, principal Money, interestRate float64, termMonths int, disbursementID string, openedAt time.Time) *Loan {
+	return &Loan{
+		id:                    id,
+		principal:             principal,
+		interestRate:          interestRate,
+		termMonths:            termMonths,
+		openedAt:              openedAt,
+		outstanding:           principal,
+		disbursementAccountID: disbursementID,
+		mutex:                 &sync.Mutex{},
+	}
+}
+
+// ID returns the ID of the loan account.
+func (l *Loan) ID() string {
+	return l.id
+}
+
+// Balance returns the amount owed on the loan as a negative number.
+func (l *Loan) Balance() Money {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return -(l.outstanding + l.interestDue)
+}
+
+// Deposit repays the loan, settling interest due before principal. Repayments larger than the amount owed are
+// rejected.
+func (l *Loan) Deposit(amount Money) error {
+	if amount <= 0 {
+		return errors.New("repayment amount must be positive")
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if amount > l.outstanding+l.interestDue {
+		return errors.New("repayment exceeds the amount owed")
+	}
+	interest := min(amount, l.interestDue)
+	l.interestDue -= interest
+	l.outstanding -= amount - interest
+	l.observer.notify(amount)
+	return nil
+}
+
+// Withdraw is never allowed; the loan is paid out in full when it is opened.
+func (l *Loan) Withdraw(amount Money) error {
+	return errors.New("loan funds are paid out when the loan is opened")
+}
+
+// SetBalanceObserver attaches the bank's balance observer.
+func (l *Loan) SetBalanceObserver(o BalanceObserver) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.observer = o
+}
+
+// DisbursementAccountID returns the account the loan was paid out into.
+func (l *Loan) DisbursementAccountID() string {
+	return l.disbursementAccountID
+}
+
+// MonthlyPayment returns the level payment that repays the loan over its term.
+func (l *Loan) MonthlyPayment() Money {
+	return LevelPayment(l.principal, l.interestRate, l.termMonths)
+}
+
+// dueDate returns the nth due date, counted from the opening date so monthly cycles do not drift at month ends.
+func (l *Loan) dueDate(n int) time.Time {
+	return AddMonths(l.openedAt, n)
+}
+
+// NextDueDate returns the next date interest will be charged and a payment is expected.
+func (l *Loan) NextDueDate() time.Time {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.dueDate(l.installmentsCharged + 1)
+}
+
+// OutstandingPrincipal returns the principal not yet repaid.
+func (l *Loan) OutstandingPrincipal() Money {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.outstanding
+}
+
+// InterestDue returns the interest charged and not yet repaid.
+func (l *Loan) InterestDue() Money {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.interestDue
+}
+
+// AccruedInterest returns the interest owed as of now: interest charged and not yet repaid, plus the share of the
+// next charge earned since the last due date.
+func (l *Loan) AccruedInterest(now time.Time) Money {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	last, next := l.dueDate(l.installmentsCharged), l.dueDate(l.installmentsCharged+1)
+	if !now.After(last) {
+		return l.interestDue
+	}
+	elapsed := min(now.Sub(last).Seconds()/next.Sub(last).Seconds(), 1)
+	return l.interestDue + l.outstanding.MulRate(l.interestRate/12*elapsed)
+}
+
+// PaidOff reports whether the loan has been repaid in full.
+func (l *Loan) PaidOff() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.outstanding == 0 && l.interestDue == 0
+}
+
+// Schedule returns the amortization schedule for the loan's original terms. Each payment covers the month's
+// interest and repays the rest as principal; the final payment clears whatever principal rounding has left.
+func (l *Loan) Schedule() []Installment {
+	payment := l.MonthlyPayment()
+	balance := l.principal
+	schedule := make([]Installment, 0, l.termMonths)
+	for n := 1; n <= l.termMonths; n++ {
+		interest := balance.MulRate(l.interestRate / 12)
+		principal := payment - interest
+		if n == l.termMonths || principal > balance {
+			principal = balance
+		}
+		balance -= principal
+		schedule = append(schedule, Installment{
+			Number:    n,
+			DueDate:   l.dueDate(n),
+			Payment:   interest + principal,
+			Interest:  interest,
+			Principal: principal,
+			Balance:   balance,
+		})
+	}
+	return schedule
+}
+
+// Accrue charges a month's interest on the outstanding principal at every due date up to today, rounded half to
+// even. Interest keeps being charged monthly after the term ends until the loan is repaid.
+func (l *Loan) Accrue(today time.Time) []InterestPost {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	var posts []InterestPost
+	for due := l.dueDate(l.installmentsCharged + 1); !due.After(today); due = l.dueDate(l.installmentsCharged + 1) {
+		l.installmentsCharged++
+		interest := l.outstanding.MulRate(l.interestRate / 12)
+		if interest == 0 {
+			continue
+		}
+		l.interestDue += interest
+		l.observer.notify(-interest)
+		posts = append(posts, InterestPost{Date: due, Amount: -interest})
+	}
+	return posts
+}
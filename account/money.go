@@ -0,0 +1,46 @@
+package account
+
+import (
+	"math"
+	"strconv"
+)
+
+// Money is an amount in integer minor units (cents), so repeated arithmetic never drifts.
+//
+// Rounding rules: converting a float amount with NewMoney rounds half away from zero, which is what
+// a customer typing 0.005 would expect. Applying a rate with MulRate rounds half to even (banker's
+// rounding) so that interest postings do not systematically favour either side.
+type Money int64
+
+// MinorUnits is the number of minor units in one major unit.
+const MinorUnits = 100
+
+// NewMoney converts a decimal amount in major units to Money, rounding half away from zero.
+func NewMoney(amount float64) Money {
+	return Money(math.Round(amount * MinorUnits))
+}
+
+// Float64 returns the amount in major units. Use only for display or rate calculations.
+func (m Money) Float64() float64 {
+	return float64(m) / MinorUnits
+}
+
+// MulRate multiplies the amount by a rate, rounding half to even.
+func (m Money) MulRate(rate float64) Money {
+	return Money(math.RoundToEven(float64(m) * rate))
+}
+
+// String formats the amount with two decimal places, e.g. "-12.05".
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	cents := strconv.FormatInt(v%MinorUnits, 10)
+	if len(cents) < 2 {
+		cents = "0" + cents
+	}
+	return sign + strconv.FormatInt(v/MinorUnits, 10) + "." + cents
+}
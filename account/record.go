@@ -0,0 +1,285 @@
+package account
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Record is the persisted form of an account. Type-specific fields are left empty when unused.
+// Amounts are stored in minor units. State, Owner, Branch, Currency, the metadata, the daily limits, where interest
+// is paid out to, overdraft protection, the owner's tax status, segment and aliases and the product are kept by the bank
+// rather than the account, so ToRecord leaves them for the bank to fill in.
+type Record struct {
+	Type     string `json:"type"`
+	ID       string `json:"id"`
+	Balance  Money  `json:"balanceMinor"`
+	Active   bool   `json:"active"` // kept for snapshots written before account states existed
+	State    State  `json:"state,omitempty"`
+	Owner    string `json:"owner,omitempty"`
+	Branch   string `json:"branch,omitempty"`
+	Currency string `json:"currency,omitempty"` // ISO 4217 code; empty means the bank's default currency
+
+	// Who the account is for, how staff labelled it and what its owner calls it: tags are comma-separated so records
+	// stay comparable
+	OwnerName string    `json:"ownerName,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitzero"`
+	Tags      string    `json:"tags,omitempty"`
+	Nickname  string    `json:"nickname,omitempty"`
+
+	// Owners of a joint account besides Owner, comma-separated so records stay comparable, and which owners must
+	// approve its debits
+	JointOwners      string `json:"jointOwners,omitempty"`
+	SigningMode      string `json:"signingMode,omitempty"`
+	SigningThreshold Money  `json:"signingThresholdMinor,omitempty"`
+
+	// Daily limits on withdrawals and outgoing transfers; zero means none
+	DailyWithdrawalLimit Money `json:"dailyWithdrawalLimitMinor,omitempty"`
+	DailyTransferLimit   Money `json:"dailyTransferLimitMinor,omitempty"`
+	// Raised daily limits waiting out their cooling-off period, and when they take effect
+	PendingWithdrawalLimit Money     `json:"pendingWithdrawalLimitMinor,omitempty"`
+	PendingTransferLimit   Money     `json:"pendingTransferLimitMinor,omitempty"`
+	PendingLimitsAt        time.Time `json:"pendingLimitsAt,omitzero"`
+	// Account the interest is paid out to each period instead of being capitalized; empty means capitalized
+	InterestPayoutAccountID string `json:"interestPayoutAccountId,omitempty"`
+	// Savings account a checking account's withdrawals beyond its balance are covered from; empty means none
+	OverdraftProtectionID string `json:"overdraftProtectionId,omitempty"`
+	// Tax status and pricing segment of the owner, kept with each of their accounts, and a segment change waiting
+	// for the next cycle; empty means resident and standard
+	OwnerTaxStatus   string    `json:"ownerTaxStatus,omitempty"`
+	OwnerSegment     string    `json:"ownerSegment,omitempty"`
+	PendingSegment   string    `json:"pendingSegment,omitempty"`
+	PendingSegmentAt time.Time `json:"pendingSegmentAt,omitzero"`
+	// Relationship tier of the owner and when it was last reviewed
+	OwnerTier           string    `json:"ownerTier,omitempty"`
+	OwnerTierReviewedAt time.Time `json:"ownerTierReviewedAt,omitzero"`
+	// Payment aliases of the owner, kept with each of their accounts, and the customers whose incoming credits this
+	// account receives, both comma-separated
+	OwnerAliases string `json:"ownerAliases,omitempty"`
+	DefaultFor   string `json:"defaultFor,omitempty"`
+	// Product the account is on, and one it is converting to from a later date
+	Product          string    `json:"product,omitempty"`
+	PendingProduct   string    `json:"pendingProduct,omitempty"`
+	PendingProductAt time.Time `json:"pendingProductAt,omitzero"`
+	// Whether statements are posted as well as made available online, and their language; empty means the default
+	PaperStatements bool   `json:"paperStatements,omitempty"`
+	Language        string `json:"language,omitempty"`
+	// Promotional interest rate of a savings account and the days it applies from and until; a zero end means none
+	PromotionalRate float64   `json:"promotionalRate,omitempty"`
+	PromotionStart  time.Time `json:"promotionStart,omitzero"`
+	PromotionEnd    time.Time `json:"promotionEnd,omitzero"`
+
+	// Savings and recurring deposit accounts
+	InterestRate float64              `json:"interestRate,omitempty"`
+	Compounding  CompoundingFrequency `json:"compounding,omitempty"`
+	DayCount     DayCountConvention   `json:"dayCount,omitempty"`
+
+	// Savings and checking interest accrual; for checking accounts the accrued interest is overdraft interest owed
+	AccruedInterest  float64   `json:"accruedInterestMinor,omitempty"`
+	AccrualStart     time.Time `json:"accrualStart,omitzero"`
+	AccruedThrough   time.Time `json:"accruedThrough,omitzero"`
+	InterestPostings int       `json:"interestPostings,omitempty"` // also counts a fixed deposit's interest payouts
+
+	// Checking accounts. OverdraftInterest is only read, from snapshots written before overdraft interest accrued
+	// unrounded into AccruedInterest
+	OverdraftLimit    Money   `json:"overdraftLimitMinor,omitempty"`
+	OverdraftRate     float64 `json:"overdraftRate,omitempty"`
+	OverdraftInterest Money   `json:"overdraftInterestMinor,omitempty"`
+
+	// Recurring deposit accounts
+	MonthlyContribution Money     `json:"monthlyContributionMinor,omitempty"`
+	FundingAccountID    string    `json:"fundingAccountId,omitempty"`
+	TermMonths          int       `json:"termMonths,omitempty"`
+	InstallmentsDone    int       `json:"installmentsDone,omitempty"` // also counts the loan due dates charged
+	MissedInstallments  int       `json:"missedInstallments,omitempty"`
+	Penalties           Money     `json:"penaltiesMinor,omitempty"`
+	NextDue             time.Time `json:"nextDue,omitzero"`
+	FirstDue            time.Time `json:"firstDue,omitzero"`
+	Matured             bool      `json:"matured,omitempty"` // also set once a fixed deposit's funds are released
+
+	// Fixed deposit and loan accounts
+	Principal              Money     `json:"principalMinor,omitempty"`
+	OpenedAt               time.Time `json:"openedAt,omitzero"`
+	MaturityDate           time.Time `json:"maturityDate,omitzero"`
+	EarlyWithdrawalPenalty float64   `json:"earlyWithdrawalPenalty,omitempty"`
+	PayoutAccountID        string    `json:"payoutAccountId,omitempty"`
+
+	// Loan accounts
+	InterestDue           Money  `json:"interestDueMinor,omitempty"`
+	DisbursementAccountID string `json:"disbursementAccountId,omitempty"`
+
+	// Custom accounts: the JSON their State returned, kept as text so records stay comparable
+	Custom string `json:"custom,omitempty"`
+}
+
+// ToRecord converts an account into its persisted form.
+func ToRecord(acc Account) (Record, error) {
+	switch a := acc.(type) {
+	case *Savings:
+		a.mutex.Lock()
+		defer a.mutex.Unlock()
+		return Record{
+			Type:         "savings",
+			ID:           a.id,
+			Balance:      a.balance,
+			InterestRate: a.interestRate,
+			Compounding:  a.compounding,
+			DayCount:     a.dayCount,
+
+			AccruedInterest:  a.accrued,
+			AccrualStart:     a.accrual.start,
+			AccruedThrough:   a.accrual.through,
+			InterestPostings: a.accrual.postings,
+		}, nil
+	case *Checking:
+		a.mutex.Lock()
+		defer a.mutex.Unlock()
+		return Record{
+			Type:           "checking",
+			ID:             a.id,
+			Balance:        a.balance,
+			OverdraftLimit: a.overdraftLimit,
+			OverdraftRate:  a.overdraftRate,
+
+			AccruedInterest:  a.overdraftInterest,
+			AccrualStart:     a.accrual.start,
+			AccruedThrough:   a.accrual.through,
+			InterestPostings: a.accrual.postings,
+		}, nil
+	case *RecurringDeposit:
+		a.mutex.Lock()
+		defer a.mutex.Unlock()
+		return Record{
+			Type:                "recurring-deposit",
+			ID:                  a.id,
+			Balance:             a.balance,
+			InterestRate:        a.interestRate,
+			MonthlyContribution: a.monthlyContribution,
+			FundingAccountID:    a.fundingAccountID,
+			TermMonths:          a.termMonths,
+			InstallmentsDone:    a.installmentsDone,
+			MissedInstallments:  a.missedInstallments,
+			Penalties:           a.penalties,
+			NextDue:             a.nextDue,
+			FirstDue:            a.firstDue,
+			Matured:             a.matured,
+		}, nil
+	case *FixedDeposit:
+		a.mutex.Lock()
+		defer a.mutex.Unlock()
+		return Record{
+			Type:                   "fixed-deposit",
+			ID:                     a.id,
+			Balance:                a.balance,
+			InterestRate:           a.interestRate,
+			TermMonths:             a.termMonths,
+			Matured:                a.released,
+			Principal:              a.principal,
+			OpenedAt:               a.openedAt,
+			MaturityDate:           a.maturityDate,
+			EarlyWithdrawalPenalty: a.earlyWithdrawalPenalty,
+			PayoutAccountID:        a.payoutAccountID,
+			InterestPostings:       a.interestPayouts,
+		}, nil
+	case *Loan:
+		a.mutex.Lock()
+		defer a.mutex.Unlock()
+		return Record{
+			Type:                  "loan",
+			ID:                    a.id,
+			Balance:               -(a.outstanding + a.interestDue),
+			InterestRate:          a.interestRate,
+			TermMonths:            a.termMonths,
+			InstallmentsDone:      a.installmentsCharged,
+			Principal:             a.principal,
+			OpenedAt:              a.openedAt,
+			InterestDue:           a.interestDue,
+			DisbursementAccountID: a.disbursementAccountID,
+		}, nil
+	case Custom:
+		state, err := a.State()
+		if err != nil {
+			return Record{}, err
+		}
+		return Record{Type: a.TypeName(), ID: a.ID(), Balance: a.Balance(), Custom: string(state)}, nil
+	}
+	return Record{}, errors.New("cannot persist account of unknown type")
+}
+
+// FromRecord rebuilds an account of one of this package's types from its persisted form. Custom accounts are
+// rebuilt by the factory their type was registered with.
+func FromRecord(rec Record) (Account, error) {
+	switch rec.Type {
+	case "savings":
+		return &Savings{
+			id:           rec.ID,
+			balance:      rec.Balance,
+			interestRate: rec.InterestRate,
+			compounding:  rec.Compounding,
+			dayCount:     rec.DayCount,
+			accrual:      accrualClock{start: rec.AccrualStart, through: rec.AccruedThrough, postings: rec.InterestPostings},
+			accrued:      rec.AccruedInterest,
+			mutex:        &sync.Mutex{},
+		}, nil
+	case "checking":
+		return &Checking{
+			id:                rec.ID,
+			balance:           rec.Balance,
+			overdraftLimit:    rec.OverdraftLimit,
+			overdraftRate:     rec.OverdraftRate,
+			overdraftInterest: rec.AccruedInterest + float64(rec.OverdraftInterest),
+			accrual:           accrualClock{start: rec.AccrualStart, through: rec.AccruedThrough, postings: rec.InterestPostings},
+			mutex:             &sync.Mutex{},
+		}, nil
+	case "recurring-deposit":
+		firstDue := rec.FirstDue
+		if firstDue.IsZero() && !rec.NextDue.IsZero() {
+			firstDue = AddMonths(rec.NextDue, -rec.InstallmentsDone)
+		}
+		return &RecurringDeposit{
+			id:                  rec.ID,
+			balance:             rec.Balance,
+			interestRate:        rec.InterestRate,
+			monthlyContribution: rec.MonthlyContribution,
+			fundingAccountID:    rec.FundingAccountID,
+			termMonths:          rec.TermMonths,
+			installmentsDone:    rec.InstallmentsDone,
+			missedInstallments:  rec.MissedInstallments,
+			penalties:           rec.Penalties,
+			nextDue:             rec.NextDue,
+			firstDue:            firstDue,
+			matured:             rec.Matured,
+			mutex:               &sync.Mutex{},
+		}, nil
+	case "fixed-deposit":
+		return &FixedDeposit{
+			id:                     rec.ID,
+			balance:                rec.Balance,
+			principal:              rec.Principal,
+			interestRate:           rec.InterestRate,
+			termMonths:             rec.TermMonths,
+			openedAt:               rec.OpenedAt,
+			maturityDate:           rec.MaturityDate,
+			earlyWithdrawalPenalty: rec.EarlyWithdrawalPenalty,
+			payoutAccountID:        rec.PayoutAccountID,
+			released:               rec.Matured,
+			interestPayouts:        rec.InterestPostings,
+			mutex:                  &sync.Mutex{},
+		}, nil
+	case "loan":
+		return &Loan{
+			id:                    rec.ID,
+			principal:             rec.Principal,
+			interestRate:          rec.InterestRate,
+			termMonths:            rec.TermMonths,
+			openedAt:              rec.OpenedAt,
+			outstanding:           -rec.Balance - rec.InterestDue,
+			interestDue:           rec.InterestDue,
+			installmentsCharged:   rec.InstallmentsDone,
+			disbursementAccountID: rec.DisbursementAccountID,
+			mutex:                 &sync.Mutex{},
+		}, nil
+	}
+	return nil, errors.New("unknown account type " + rec.Type)
+}
@@ -0,0 +1,180 @@
+package account
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// missedContributionPenalty is charged for every monthly contribution that could not be collected.
+const missedContributionPenalty Money = 100
+
+// RecurringDeposit is a term product funded by fixed monthly contributions pulled from a funding account.
+type RecurringDeposit struct {
+	id                  string
+	balance             Money
+	monthlyContribution Money
+	interestRate        float64 // Annual rate, compounded monthly
+	fundingAccountID    string
+	termMonths          int
+	installmentsDone    int // Contribution dates processed so far, whether collected or missed
+	missedInstallments  int
+	penalties           Money // Penalties for missed contributions, deducted at maturity
+	nextDue             time.Time
+	firstDue            time.Time // Contributions fall due on its day of each month, or the month's last day
+	matured             bool
+	observer            BalanceObserver // Reports balance changes to the bank's aggregates
+	mutex               *sync.Mutex
+}
+
+// NewRecurringDeposit creates a recurring deposit whose monthly contributions are pulled from fundingID, the
+// first falling due on firstDue.
+func NewRecurringDeposit(id, fundingID string, monthlyContribution Money, interestRate float64, termMonths int, firstDue time.Time) *RecurringDeposit {
+	return &RecurringDeposit{
+		id:                  id,
+		monthlyContribution: monthlyContribution,
+		interestRate:        interestRate,
+		fundingAccountID:    fundingID,
+		termMonths:          termMonths,
+		nextDue:             firstDue,
+		firstDue:            firstDue,
+		mutex:               &sync.Mutex{},
+	}
+}
+
+// ID returns the ID of the recurring deposit account.
+func (rd *RecurringDeposit) ID() string {
+	return rd.id
+}
+
+// Balance returns the balance of the recurring deposit account.
+func (rd *RecurringDeposit) Balance() Money {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+	return rd.balance
+}
+
+// Deposit adds funds to the recurring deposit account.
+func (rd *RecurringDeposit) Deposit(amount Money) error {
+	if amount <= 0 {
+		return errors.New("deposit amount must be positive")
+	}
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+	if rd.matured {
+		return errors.New("recurring deposit has matured")
+	}
+	rd.balance += amount
+	rd.observer.notify(amount)
+	return nil
+}
+
+// Withdraw is only allowed once the recurring deposit has matured.
+func (rd *RecurringDeposit) Withdraw(amount Money) error {
+	if amount <= 0 {
+		return errors.New("withdrawal amount must be positive")
+	}
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+	if !rd.matured {
+		return errors.New("recurring deposit cannot be withdrawn before maturity")
+	}
+	if rd.balance < amount {
+		return errors.New("insufficient funds")
+	}
+	rd.balance -= amount
+	rd.observer.notify(-amount)
+	return nil
+}
+
+// SetBalanceObserver attaches the bank's balance observer.
+func (rd *RecurringDeposit) SetBalanceObserver(o BalanceObserver) {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+	rd.observer = o
+}
+
+// MaturityAmount returns the contributions plus interest earned, less penalties for missed contributions.
+func (rd *RecurringDeposit) MaturityAmount() Money {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+	if rd.matured {
+		return rd.balance
+	}
+	return rd.balance - rd.penalties
+}
+
+// Upcoming returns the dates of the contributions still to be collected and the date the deposit matures, which
+// is zero once it has matured.
+func (rd *RecurringDeposit) Upcoming() (contributions []time.Time, maturity time.Time) {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+	if rd.matured {
+		return nil, time.Time{}
+	}
+	for i := rd.installmentsDone; i < rd.termMonths; i++ {
+		contributions = append(contributions, AddMonths(rd.firstDue, i))
+	}
+	return contributions, AddMonths(rd.firstDue, rd.termMonths)
+}
+
+// MonthlyContribution returns the amount pulled from the funding account each month.
+func (rd *RecurringDeposit) MonthlyContribution() Money {
+	return rd.monthlyContribution
+}
+
+// FundingAccountID returns the account contributions are pulled from.
+func (rd *RecurringDeposit) FundingAccountID() string {
+	return rd.fundingAccountID
+}
+
+// MissedInstallments returns how many monthly contributions could not be collected.
+func (rd *RecurringDeposit) MissedInstallments() int {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+	return rd.missedInstallments
+}
+
+// DueContribution moves the deposit on to its next monthly contribution if one has fallen due by now, applying a
+// month of interest first. It returns the contribution to pull from the funding account, or false when nothing is
+// due. Once the term is over the deposit earns its final month of interest, has penalties deducted and matures.
+// Every contribution returned must be followed by RecordContribution.
+func (rd *RecurringDeposit) DueContribution(now time.Time) (Money, string, bool) {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+	if rd.matured || rd.nextDue.After(now) {
+		return 0, "", false
+	}
+	if rd.installmentsDone >= rd.termMonths {
+		// Term is over; the final month earns interest before the deposit matures
+		before := rd.balance
+		rd.balance += rd.balance.MulRate(rd.interestRate / 12)
+		rd.balance -= rd.penalties
+		if rd.balance < 0 {
+			rd.balance = 0
+		}
+		rd.observer.notify(rd.balance - before)
+		rd.penalties = 0
+		rd.matured = true
+		return 0, "", false
+	}
+	if rd.installmentsDone > 0 {
+		interest := rd.balance.MulRate(rd.interestRate / 12)
+		rd.balance += interest
+		rd.observer.notify(interest)
+	}
+	return rd.monthlyContribution, rd.fundingAccountID, true
+}
+
+// RecordContribution records whether the contribution returned by DueContribution was collected. A missed
+// contribution is penalised.
+func (rd *RecurringDeposit) RecordContribution(collected bool) {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+	if !collected {
+		rd.missedInstallments++
+		rd.penalties += missedContributionPenalty
+	}
+	rd.installmentsDone++
+	rd.nextDue = AddMonths(rd.firstDue, rd.installmentsDone)
+}
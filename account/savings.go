@@ -0,0 +1,105 @@
+package account
+
+import (
+	"errors"
+	"sync"
+)
+
+// Savings represents a savings account with interest calculation.
+type Savings struct {
+	id           string
+	balance      Money
+	interestRate float64
+	bonusRate    float64    // Preferential rate on top of interestRate, e.g. for the owner's customer segment
+	promotion    RateWindow // Promotional rate replacing interestRate for a while; the zero window never applies
+	compounding  CompoundingFrequency
+	dayCount     DayCountConvention
+	accrual      accrualClock
+	accrued      float64         // Interest accrued but not yet posted, in unrounded minor units
+	observer     BalanceObserver // Reports balance changes to the bank's aggregates
+	mutex        *sync.Mutex     // Mutex for synchronization
+}
+
+// NewSavings creates a savings account earning the given annual interest rate.
+func NewSavings(id string, balance Money, interestRate float64) *Savings {
+	return &Savings{
+		id:           id,
+		balance:      balance,
+		interestRate: interestRate,
+		mutex:        &sync.Mutex{},
+	}
+}
+
+// ID returns the ID of the savings account.
+func (sa *Savings) ID() string {
+	return sa.id
+}
+
+// Balance returns the balance of the savings account.
+func (sa *Savings) Balance() Money {
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+	return sa.balance
+}
+
+// Deposit adds funds to the savings account.
+func (sa *Savings) Deposit(amount Money) error {
+	if amount <= 0 {
+		return errors.New("deposit amount must be positive")
+	}
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+	sa.balance += amount
+	sa.observer.notify(amount)
+	return nil
+}
+
+// Withdraw subtracts funds from the savings account.
+func (sa *Savings) Withdraw(amount Money) error {
+	if amount <= 0 {
+		return errors.New("withdrawal amount must be positive")
+	}
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+	if sa.balance < amount {
+		return errors.New("insufficient funds")
+	}
+	sa.balance -= amount
+	sa.observer.notify(-amount)
+	return nil
+}
+
+// CalculateInterest calculates and applies a full year's interest on the savings account at once.
+//
+// Deprecated: use bank.Bank.AccrueInterest, which accrues daily and posts on the compounding cycle.
+func (sa *Savings) CalculateInterest() {
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+	interest := sa.balance.MulRate(sa.interestRate)
+	sa.balance += interest
+	sa.observer.notify(interest)
+}
+
+// SetBonusRate sets a preferential annual rate earned on top of the account's own from now on. The bank sets it from
+// the owner's customer segment and relationship tier, so it is not part of the account's record.
+func (sa *Savings) SetBonusRate(rate float64) {
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+	sa.bonusRate = rate
+}
+
+// SetPromotionalRate sets a rate that replaces the account's own for the days its window covers, including days
+// accrued late; the zero window removes it. Like the bonus rate, the bank keeps it, so it is not part of the
+// account's record.
+func (sa *Savings) SetPromotionalRate(w RateWindow) {
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+	sa.promotion = w
+}
+
+// SetBalanceObserver attaches the bank's balance observer.
+func (sa *Savings) SetBalanceObserver(o BalanceObserver) {
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+	sa.observer = o
+}
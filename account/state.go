@@ -0,0 +1,64 @@
+package account
+
+// State is where an account is in its lifecycle.
+type State string
+
+const (
+	StateOpen    State = "open"
+	StateFrozen  State = "frozen"  // held by the bank, e.g. suspected fraud or a legal order
+	StateDormant State = "dormant" // no customer activity for a long period
+	StateClosed  State = "closed"
+)
+
+// Operation is an operation whose availability depends on the account's state.
+type Operation string
+
+const (
+	OperationDeposit     Operation = "deposit"
+	OperationWithdraw    Operation = "withdraw"
+	OperationTransferOut Operation = "transfer out"
+	OperationTransferIn  Operation = "transfer in"
+)
+
+// statePermissions lists the operations each state allows. Frozen and dormant accounts can still
+// receive money so that salaries and refunds are not bounced, but nothing can leave them.
+var statePermissions = map[State]map[Operation]bool{
+	StateOpen: {
+		OperationDeposit:     true,
+		OperationWithdraw:    true,
+		OperationTransferOut: true,
+		OperationTransferIn:  true,
+	},
+	StateFrozen: {
+		OperationDeposit:    true,
+		OperationTransferIn: true,
+	},
+	StateDormant: {
+		OperationDeposit:    true,
+		OperationTransferIn: true,
+	},
+	StateClosed: {},
+}
+
+// stateTransitions lists the states each state may move to.
+var stateTransitions = map[State][]State{
+	StateOpen:    {StateFrozen, StateDormant, StateClosed},
+	StateFrozen:  {StateOpen},
+	StateDormant: {StateOpen, StateFrozen, StateClosed},
+	StateClosed:  {StateOpen},
+}
+
+// Allows reports whether an account in this state may perform the operation.
+func (s State) Allows(op Operation) bool {
+	return statePermissions[s][op]
+}
+
+// CanTransitionTo reports whether the lifecycle permits moving from s to next.
+func (s State) CanTransitionTo(next State) bool {
+	for _, allowed := range stateTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package bank
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// Action is something a signed-in user asks the bank to do, checked by Authorize.
+type Action string
+
+const (
+	ActionView        Action = "view"         // See an account's balance, history, statement or calendar
+	ActionDeposit     Action = "deposit"      // Credit an account
+	ActionWithdraw    Action = "withdraw"     // Debit an account
+	ActionTransfer    Action = "transfer"     // Move or schedule funds out of an account
+	ActionOpen        Action = "open"         // Open a new account
+	ActionClose       Action = "close"        // Close an account
+	ActionChangeState Action = "change-state" // Freeze, unfreeze, mark dormant or reopen an account
+	ActionReverse     Action = "reverse"      // Reverse a completed transfer
+	ActionReport      Action = "report"       // See bank-wide reports and the full transaction history
+	ActionAdminister  Action = "administer"   // Manage webhooks and runtime configuration
+)
+
+// rolePermissions lists what each role may do. Customers may only act on accounts they own or jointly own; see
+// Authorize.
+var rolePermissions = map[StaffRole]map[Action]bool{
+	StaffRoleCustomer: {ActionView: true, ActionDeposit: true, ActionWithdraw: true, ActionTransfer: true, ActionOpen: true},
+	StaffRoleTeller:   {ActionView: true, ActionDeposit: true, ActionWithdraw: true, ActionTransfer: true, ActionOpen: true, ActionReport: true},
+	StaffRoleStaff:    {ActionView: true, ActionDeposit: true, ActionWithdraw: true, ActionTransfer: true, ActionOpen: true, ActionReport: true},
+	StaffRoleManager: {ActionView: true, ActionDeposit: true, ActionWithdraw: true, ActionTransfer: true, ActionOpen: true,
+		ActionClose: true, ActionChangeState: true, ActionReverse: true, ActionReport: true},
+	StaffRoleAdmin: {ActionView: true, ActionDeposit: true, ActionWithdraw: true, ActionTransfer: true, ActionOpen: true,
+		ActionClose: true, ActionChangeState: true, ActionReverse: true, ActionReport: true, ActionAdminister: true},
+}
+
+// SetUserRole gives a user a role the bank enforces in Authorize. A customer's user ID is their customer ID.
+func (b *Bank) SetUserRole(userID string, role StaffRole) error {
+	if err := validStaffRole(role); err != nil {
+		return err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.roles[userID] = role
+	return nil
+}
+
+// isManager reports whether a staff member may make manager-level changes such as reversals.
+// The caller must hold the bank mutex.
+func (b *Bank) isManager(staffID string) bool {
+	return b.admins[staffID] || b.roles[staffID] == StaffRoleManager
+}
+
+// UserRole returns a user's role, or an error if the user is unknown.
+func (b *Bank) UserRole(userID string) (StaffRole, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	role, exists := b.roles[userID]
+	if !exists {
+		return "", errors.New("user does not exist")
+	}
+	return role, nil
+}
+
+// Authorize checks a user may carry out an action, on an account where the action concerns one. Customers may
+// only act on accounts they own, alone or jointly; staff may act on any account their role allows.
+func (b *Bank) Authorize(userID string, action Action, accountID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.authorize(userID, action, accountID)
+}
+
+// authorize is Authorize for callers already holding the bank mutex.
+// The caller must hold the bank mutex.
+func (b *Bank) authorize(userID string, action Action, accountID string) error {
+	role, exists := b.roles[userID]
+	if !exists {
+		return decline(ReasonNotAuthorized, "user is not signed in")
+	}
+	if !rolePermissions[role][action] {
+		return decline(ReasonNotAuthorized, "role "+string(role)+" may not "+string(action))
+	}
+	if role == StaffRoleCustomer && accountID != "" && !b.ownsAccount(accountID, userID) {
+		return decline(ReasonNotAuthorized, "account does not belong to the signed-in customer")
+	}
+	return nil
+}
+
+// Permits reports whether a user's role allows an action on at least some accounts, so front ends can offer only
+// what the user may do. Authorize still decides each request.
+func (b *Bank) Permits(userID string, action Action) bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	role, exists := b.roles[userID]
+	return exists && rolePermissions[role][action]
+}
+
+// AuthorizeOpen checks a user may open an account with an opening balance. Customers may only open empty accounts
+// and fund them by deposit or transfer.
+func (b *Bank) AuthorizeOpen(userID string, openingBalance account.Money) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err := b.authorize(userID, ActionOpen, ""); err != nil {
+		return err
+	}
+	if b.roles[userID] == StaffRoleCustomer && openingBalance != 0 {
+		return decline(ReasonNotAuthorized, "customers must open accounts with a zero balance")
+	}
+	return nil
+}
+
+// OpenedBy records that a user opened an account: accounts customers open belong to them.
+func (b *Bank) OpenedBy(userID, accountID string) error {
+	b.mutex.Lock()
+	customer := b.roles[userID] == StaffRoleCustomer
+	b.mutex.Unlock()
+	if !customer {
+		return nil
+	}
+	return b.AssignOwner(accountID, userID)
+}
+
+// userKey is the context key under which servers find the signed-in user.
+type userKey struct{}
+
+// ContextWithUser returns a context carrying the ID of the signed-in user, for the authentication layer in front of
+// the servers, such as ServiceHandler, to attach to each request.
+func ContextWithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+// UserFromContext returns the signed-in user carried by a context.
+func UserFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userKey{}).(string)
+	return userID, ok && userID != ""
+}
+
+// authorizeRequest checks the user carried by a request's context may carry out an action.
+func (b *Bank) authorizeRequest(ctx context.Context, action Action, accountID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	userID, ok := UserFromContext(ctx)
+	if !ok {
+		return decline(ReasonNotAuthorized, "request is not authenticated")
+	}
+	return b.Authorize(userID, action, accountID)
+}
@@ -0,0 +1,137 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultAccountPrefix starts generated account numbers when the configuration sets no prefix.
+const DefaultAccountPrefix = "AC"
+
+// accountSequenceDigits is how many digits the sequence number of a generated account number has.
+const accountSequenceDigits = 9
+
+// maxAccountIDLength is the longest account ID accepted, that of the longest IBAN.
+const maxAccountIDLength = 34
+
+// DuplicateAccountError is returned when an account is opened with the ID of an account the bank has, or had before
+// it was purged.
+type DuplicateAccountError struct {
+	AccountID string
+	Purged    bool // True if the account that had the ID was purged
+}
+
+func (e *DuplicateAccountError) Error() string {
+	if e.Purged {
+		return fmt.Sprintf("account %s was purged and its ID cannot be reused", e.AccountID)
+	}
+	return fmt.Sprintf("account %s already exists", e.AccountID)
+}
+
+// ValidateAccountID checks an account ID is 1 to 34 letters, digits, '-', '_', '.' or '@'. History entries and
+// archive file names rely on IDs holding nothing else.
+func ValidateAccountID(id string) error {
+	if id == "" {
+		return errors.New("account ID must not be empty")
+	}
+	if len(id) > maxAccountIDLength {
+		return fmt.Errorf("account ID must not be longer than %d characters", maxAccountIDLength)
+	}
+	for _, c := range id {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.ContainsRune("-_.@", c)) {
+			return fmt.Errorf("account ID %q may only hold letters, digits, '-', '_', '.' and '@'", id)
+		}
+	}
+	return nil
+}
+
+// luhnDigit returns the Luhn check digit of a string of decimal digits.
+func luhnDigit(digits string) byte {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		// Digits are doubled from the rightmost one, which the check digit will sit next to
+		if (len(digits)-i)%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// accountNumber returns the generated account number with a prefix and sequence number.
+func accountNumber(prefix string, seq uint64) string {
+	digits := fmt.Sprintf("%0*d", accountSequenceDigits, seq)
+	return prefix + digits + string(luhnDigit(digits))
+}
+
+// accountSequence returns the sequence number of an ID shaped like an account number with a prefix, and whether
+// it is one. The check digit is not checked.
+func accountSequence(prefix, id string) (uint64, bool) {
+	digits, ok := strings.CutPrefix(id, prefix)
+	if !ok || len(digits) != accountSequenceDigits+1 {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(digits[:accountSequenceDigits], 10, 64)
+	return seq, err == nil && digits[accountSequenceDigits] >= '0' && digits[accountSequenceDigits] <= '9'
+}
+
+// accountPrefix returns the prefix of generated account numbers.
+// The caller must hold the bank mutex.
+func (b *Bank) accountPrefix() string {
+	if b.config == nil || b.config.idPrefix == "" {
+		return DefaultAccountPrefix
+	}
+	return b.config.idPrefix
+}
+
+// NewAccountID returns a new account number: the configured prefix, a sequence number and a Luhn check digit, e.g.
+// AC0000000018. Each call returns a different number, and none that an account has or had.
+func (b *Bank) NewAccountID() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	prefix := b.accountPrefix()
+	if b.accountSeq == 0 {
+		// The sequence carries on from the highest number handed out, which the accounts and event log still show
+		for id := range b.accounts.all() {
+			if seq, ok := accountSequence(prefix, id); ok {
+				b.accountSeq = max(b.accountSeq, seq)
+			}
+		}
+		for _, e := range b.events {
+			if seq, ok := accountSequence(prefix, e.AccountID); ok && e.Type == EventAccountCreated {
+				b.accountSeq = max(b.accountSeq, seq)
+			}
+		}
+	}
+	for {
+		b.accountSeq++
+		id := accountNumber(prefix, b.accountSeq)
+		if b.checkNewAccountID(id) == nil {
+			return id
+		}
+	}
+}
+
+// checkNewAccountID checks an account can be opened with an ID: it must be valid, carry the right check digit if
+// it is shaped like a generated account number, and belong to no account the bank has or purged.
+// The caller must hold the bank mutex.
+func (b *Bank) checkNewAccountID(id string) error {
+	if err := ValidateAccountID(id); err != nil {
+		return err
+	}
+	if _, ok := accountSequence(b.accountPrefix(), id); ok && luhnDigit(id[len(id)-accountSequenceDigits-1:len(id)-1]) != id[len(id)-1] {
+		return errors.New("account number " + id + " has a wrong check digit")
+	}
+	if _, exists := b.accounts.get(id); exists {
+		return &DuplicateAccountError{AccountID: id}
+	}
+	if b.purgedIDs[id] {
+		return &DuplicateAccountError{AccountID: id, Purged: true}
+	}
+	return nil
+}
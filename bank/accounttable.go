@@ -0,0 +1,105 @@
+package bank
+
+import (
+	"iter"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// accountEntry is an account the bank holds with its lifecycle state and profile.
+type accountEntry struct {
+	acc     account.Account
+	state   account.State
+	profile AccountProfile
+}
+
+// accountTable holds the bank's accounts together with their lifecycle states and profiles. All live in one entry
+// per account, so an account cannot be held without a state nor a state or profile kept for an account the bank does
+// not hold.
+type accountTable struct {
+	entries map[string]*accountEntry
+}
+
+// newAccountTable returns an empty table.
+func newAccountTable() *accountTable {
+	return &accountTable{entries: make(map[string]*accountEntry)}
+}
+
+// get returns an account and whether the table holds it.
+func (t *accountTable) get(id string) (account.Account, bool) {
+	e, exists := t.entries[id]
+	if !exists {
+		return nil, false
+	}
+	return e.acc, true
+}
+
+// at returns an account, or nil if the table does not hold it.
+func (t *accountTable) at(id string) account.Account {
+	acc, _ := t.get(id)
+	return acc
+}
+
+// getState returns an account's lifecycle state and whether the table holds the account.
+func (t *accountTable) getState(id string) (account.State, bool) {
+	e, exists := t.entries[id]
+	if !exists {
+		return "", false
+	}
+	return e.state, true
+}
+
+// state returns an account's lifecycle state, or "" if the table does not hold it.
+func (t *accountTable) state(id string) account.State {
+	state, _ := t.getState(id)
+	return state
+}
+
+// profile returns an account's profile for reading or changing in place, or nil if the table does not hold it.
+func (t *accountTable) profile(id string) *AccountProfile {
+	e, exists := t.entries[id]
+	if !exists {
+		return nil
+	}
+	return &e.profile
+}
+
+// len returns how many accounts the table holds.
+func (t *accountTable) len() int {
+	return len(t.entries)
+}
+
+// all iterates over the accounts by ID, in no particular order.
+func (t *accountTable) all() iter.Seq2[string, account.Account] {
+	return func(yield func(string, account.Account) bool) {
+		for id, e := range t.entries {
+			if !yield(id, e.acc) {
+				return
+			}
+		}
+	}
+}
+
+// put adds an account in a state, or replaces the account of the same ID and its state, keeping its profile.
+func (t *accountTable) put(acc account.Account, state account.State) {
+	if e, exists := t.entries[acc.ID()]; exists {
+		e.acc, e.state = acc, state
+		return
+	}
+	t.entries[acc.ID()] = &accountEntry{acc: acc, state: state}
+}
+
+// setState changes the state of an account the table holds, reporting false for one it does not.
+func (t *accountTable) setState(id string, state account.State) bool {
+	e, exists := t.entries[id]
+	if !exists {
+		return false
+	}
+	e.state = state
+	return true
+}
+
+// remove drops an account, its state and its profile.
+func (t *accountTable) remove(id string) {
+	delete(t.entries, id)
+}
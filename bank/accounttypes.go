@@ -0,0 +1,108 @@
+package bank
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// AccountFactory builds a custom account from its ID, balance and type-specific state: the parameters it is opened
+// with, or the JSON its State last returned when it is loaded.
+type AccountFactory func(id string, balance account.Money, state json.RawMessage) (account.Custom, error)
+
+// builtinAccountTypes are the names account.TypeOf gives the account package's own types.
+var builtinAccountTypes = map[string]bool{"savings": true, "checking": true, "recurring-deposit": true, "fixed-deposit": true, "loan": true, "other": true}
+
+// accountTypes holds the registered custom account types. Types are registered process-wide, usually from an init
+// function, so every bank can load accounts of them.
+var accountTypes = struct {
+	mutex     sync.RWMutex
+	factories map[string]AccountFactory
+}{factories: make(map[string]AccountFactory)}
+
+// RegisterAccountType adds a custom account type, so accounts of it can be opened, saved, loaded, reported on and,
+// if they implement account.Accruer, accrue interest. The name must match what the type's accounts return from
+// TypeName, and the accounts must implement account.Observable; accounts that do not are refused.
+func RegisterAccountType(name string, factory AccountFactory) error {
+	if name == "" || factory == nil {
+		return errors.New("account types need a name and a factory")
+	}
+	if builtinAccountTypes[name] {
+		return errors.New("account type " + name + " is built in")
+	}
+	accountTypes.mutex.Lock()
+	defer accountTypes.mutex.Unlock()
+	if _, exists := accountTypes.factories[name]; exists {
+		return errors.New("account type " + name + " is already registered")
+	}
+	accountTypes.factories[name] = factory
+	return nil
+}
+
+// AccountTypes lists the registered custom account types by name.
+func AccountTypes() []string {
+	accountTypes.mutex.RLock()
+	names := make([]string, 0, len(accountTypes.factories))
+	for name := range accountTypes.factories {
+		names = append(names, name)
+	}
+	accountTypes.mutex.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// buildCustomAccount runs a custom type's factory and checks the account it builds.
+func buildCustomAccount(typeName, id string, balance account.Money, state json.RawMessage) (account.Custom, error) {
+	accountTypes.mutex.RLock()
+	factory, exists := accountTypes.factories[typeName]
+	accountTypes.mutex.RUnlock()
+	if !exists {
+		return nil, errors.New("unknown account type " + typeName)
+	}
+	acc, err := factory(id, balance, state)
+	if err != nil {
+		return nil, err
+	}
+	if acc == nil || acc.ID() != id || acc.TypeName() != typeName {
+		return nil, errors.New("account type " + typeName + " built an account with the wrong ID or type")
+	}
+	if _, ok := acc.(account.Observable); !ok {
+		return nil, errors.New("account type " + typeName + " does not report balance changes (account.Observable)")
+	}
+	return acc, nil
+}
+
+// accountFromRecord rebuilds an account from its persisted form, whether of a built-in or a registered type.
+func accountFromRecord(rec account.Record) (account.Account, error) {
+	if builtinAccountTypes[rec.Type] {
+		return account.FromRecord(rec)
+	}
+	var state json.RawMessage
+	if rec.Custom != "" {
+		state = json.RawMessage(rec.Custom)
+	}
+	return buildCustomAccount(rec.Type, rec.ID, rec.Balance, state)
+}
+
+// NewCustomAccount opens an account of a registered type with an opening balance and the parameters the type's
+// factory expects, and adds it to the bank.
+func (b *Bank) NewCustomAccount(typeName, id string, balance account.Money, params json.RawMessage) (account.Account, error) {
+	if err := ValidateAccountID(id); err != nil {
+		return nil, err
+	}
+	acc, err := buildCustomAccount(typeName, id, balance, params)
+	if err != nil {
+		return nil, err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err := b.checkNewAccountID(id); err != nil {
+		return nil, err
+	}
+	b.registerAccount(acc, account.StateOpen)
+	b.recordAccountEvent(EventAccountCreated, acc, Event{})
+	return acc, nil
+}
@@ -0,0 +1,131 @@
+package bank
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// InterestPosting is interest credited to or charged on an account by the accrual engine.
+type InterestPosting struct {
+	AccountID     string
+	TransactionID string
+	Date          time.Time
+	Amount        account.Money         // Positive when credited, negative when charged
+	Rates         []account.AppliedRate // Rates a savings account's interest accrued at; see SetPromotionalRate
+
+	PaidTo              string // Account the interest was paid out to, or "" if it was capitalized
+	PayoutTransactionID string
+	PayoutErr           error // Why interest meant to be paid out was capitalized instead
+
+	Withheld                 account.Money // Tax withheld from the interest; see SetTaxStatus
+	WithholdingTransactionID string
+	WithholdingErr           error // Why tax that should have been withheld was not
+}
+
+// String describes the posting for operators.
+func (p InterestPosting) String() string {
+	s := fmt.Sprintf("Interest of %s posted to %s", p.Amount, p.AccountID)
+	switch {
+	case p.Withheld > 0:
+		s += fmt.Sprintf(", %s withheld as tax", p.Withheld)
+	case p.WithholdingErr != nil:
+		s += fmt.Sprintf(", tax not withheld: %v", p.WithholdingErr)
+	}
+	switch {
+	case p.PaidTo != "":
+		s += ", paid out to " + p.PaidTo
+	case p.PayoutErr != nil:
+		s += fmt.Sprintf(", kept as the payout failed: %v", p.PayoutErr)
+	}
+	return s
+}
+
+// startOfDay truncates a time to midnight in its own location.
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// AccrueInterest runs the interest accrual engine up to today by the bank's clock. Savings accounts accrue daily under
+// their day-count convention, with any rate bonus of the owner's segment, and are credited at the end of each
+// compounding period; overdrawn checking accounts accrue overdraft interest daily and are charged monthly; loans are
+// charged a month's interest at each due date; custom account types accrue as their Accrue method says. Days missed
+// since the last run are caught up using the current balance, so the engine should run at least daily. Closed accounts
+// do not accrue. Interest on accounts that pay it out, see SetInterestPayout, is then paid to the chosen account, less
+// the tax withheld from it at the rate for the owner's tax status.
+func (b *Bank) AccrueInterest() []InterestPosting {
+	b.mutex.Lock()
+	today := startOfDay(b.now())
+	var ids, paying []string
+	for id, acc := range b.accounts.all() {
+		if !b.IsAccountActive(id) {
+			continue
+		}
+		switch acc.(type) {
+		case account.Accruer:
+			ids = append(ids, id)
+		case *account.FixedDeposit:
+			if b.interestPayouts[id] != "" {
+				paying = append(paying, id)
+			}
+		}
+	}
+	b.mutex.Unlock()
+	sort.Strings(ids)
+	sort.Strings(paying)
+
+	var postings []InterestPosting
+	for _, id := range ids {
+		for _, posting := range b.accrueAccountInterest(id, today) {
+			postings = append(postings, b.payOutInterest(b.withholdPostingTax(posting)))
+		}
+	}
+	for _, id := range paying {
+		for _, posting := range b.payOutFixedDepositInterest(id, today) {
+			postings = append(postings, b.withholdPostingTax(posting))
+		}
+	}
+	return postings
+}
+
+// accrueAccountInterest accrues and posts interest for one account while holding its lock.
+func (b *Bank) accrueAccountInterest(accountID string, today time.Time) []InterestPosting {
+	unlock := b.lockAccounts(accountID)
+	defer unlock()
+	b.mutex.Lock()
+	acc := b.accounts.at(accountID)
+	if sa, isSavings := acc.(*account.Savings); isSavings {
+		pricing, _ := b.segmentPricing(accountID)
+		tier, _ := b.relationshipTier(accountID)
+		sa.SetBonusRate((pricing.RateBonus + tier.RateBonus) / 100)
+		sa.SetPromotionalRate(b.promotions[accountID])
+	}
+	b.mutex.Unlock()
+
+	var posts []account.InterestPost
+	if a, accrues := acc.(account.Accruer); accrues {
+		posts = a.Accrue(today)
+	}
+	if len(posts) == 0 {
+		return nil
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	postings := make([]InterestPosting, 0, len(posts))
+	for _, p := range posts {
+		txnID := b.newTxnID()
+		entry := fmt.Sprintf("Transaction ID: %s, Account: %s, Interest: %s, Date: %s, Status: %s\n", txnID, accountID, p.Amount, p.Date.Format("2006-01-02"), "success")
+		if len(p.Rates) > 0 {
+			entry = strings.TrimSuffix(entry, "\n") + ", Rates: " + appliedRatesField(p.Rates) + "\n"
+		}
+		b.recordTransaction(txnID, entry)
+		b.recordAccountEvent(EventInterestPosted, acc, Event{Amount: p.Amount, TransactionID: txnID})
+		postings = append(postings, InterestPosting{AccountID: accountID, TransactionID: txnID, Date: p.Date, Amount: p.Amount, Rates: p.Rates})
+	}
+	return postings
+}
@@ -0,0 +1,256 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// AggregateTotals are the bank-wide balance totals, covering every account that is not closed.
+type AggregateTotals struct {
+	Total    account.Money
+	Accounts int                      // how many accounts the totals cover
+	ByType   map[string]account.Money // keyed by account type, e.g. "savings"
+	ByBranch map[string]account.Money // keyed by branch; accounts without a branch are under ""
+}
+
+// aggregateEntry is what the aggregates remember about one account.
+type aggregateEntry struct {
+	id          string
+	accountType string
+	branch      string
+	balance     account.Money
+	included    bool // false while the account is closed
+}
+
+// aggregates keeps running balance totals so reports do not rescan every account. Its mutex is only ever
+// taken last, after any account or bank lock.
+type aggregates struct {
+	total    account.Money
+	byType   map[string]account.Money
+	byBranch map[string]account.Money
+	accounts map[string]*aggregateEntry
+	index    *balanceIndex // Accounts in balance order for ranked queries
+	mutex    *sync.RWMutex
+}
+
+func newAggregates() *aggregates {
+	return &aggregates{
+		byType:   make(map[string]account.Money),
+		byBranch: make(map[string]account.Money),
+		accounts: make(map[string]*aggregateEntry),
+		index:    &balanceIndex{},
+		mutex:    &sync.RWMutex{},
+	}
+}
+
+// add applies an account's contribution to the totals with the given sign.
+// The caller must hold the aggregates mutex.
+func (ag *aggregates) add(e *aggregateEntry, sign account.Money) {
+	if !e.included {
+		return
+	}
+	ag.total += sign * e.balance
+	ag.byType[e.accountType] += sign * e.balance
+	ag.byBranch[e.branch] += sign * e.balance
+	if sign > 0 {
+		ag.index.insert(AccountBalance{AccountID: e.id, Balance: e.balance})
+	} else {
+		ag.index.remove(AccountBalance{AccountID: e.id, Balance: e.balance})
+	}
+}
+
+// track starts following an account, replacing any earlier account with the same ID.
+func (ag *aggregates) track(id, accountType, branch string, balance account.Money, included bool) {
+	ag.mutex.Lock()
+	defer ag.mutex.Unlock()
+	if old, exists := ag.accounts[id]; exists {
+		ag.add(old, -1)
+	}
+	e := &aggregateEntry{id: id, accountType: accountType, branch: branch, balance: balance, included: included}
+	ag.accounts[id] = e
+	ag.add(e, 1)
+}
+
+// untrack stops following an account, taking it out of the totals.
+func (ag *aggregates) untrack(id string) {
+	ag.mutex.Lock()
+	defer ag.mutex.Unlock()
+	if e, exists := ag.accounts[id]; exists {
+		ag.add(e, -1)
+		delete(ag.accounts, id)
+	}
+}
+
+// apply records a balance change on an account.
+func (ag *aggregates) apply(id string, delta account.Money) {
+	ag.mutex.Lock()
+	defer ag.mutex.Unlock()
+	e, exists := ag.accounts[id]
+	if !exists {
+		return
+	}
+	ag.add(e, -1)
+	e.balance += delta
+	ag.add(e, 1)
+}
+
+// update changes what is known about an account, moving its balance between totals as needed.
+func (ag *aggregates) update(id string, change func(e *aggregateEntry)) {
+	ag.mutex.Lock()
+	defer ag.mutex.Unlock()
+	e, exists := ag.accounts[id]
+	if !exists {
+		return
+	}
+	ag.add(e, -1)
+	change(e)
+	ag.add(e, 1)
+}
+
+// totalBalance returns the running total across all accounts that are not closed.
+func (ag *aggregates) totalBalance() account.Money {
+	ag.mutex.RLock()
+	defer ag.mutex.RUnlock()
+	return ag.total
+}
+
+// snapshot copies the current totals.
+func (ag *aggregates) snapshot() AggregateTotals {
+	ag.mutex.RLock()
+	defer ag.mutex.RUnlock()
+	return ag.copyTotals()
+}
+
+// report copies the balance of every account the totals cover, along with the totals, so they add up.
+func (ag *aggregates) report() (map[string]account.Money, AggregateTotals) {
+	ag.mutex.RLock()
+	defer ag.mutex.RUnlock()
+	balances := make(map[string]account.Money, nodeSize(ag.index.root))
+	for id, e := range ag.accounts {
+		if e.included {
+			balances[id] = e.balance
+		}
+	}
+	return balances, ag.copyTotals()
+}
+
+// copyTotals copies the current totals.
+// The caller must hold the aggregates mutex.
+func (ag *aggregates) copyTotals() AggregateTotals {
+	totals := AggregateTotals{Total: ag.total, Accounts: nodeSize(ag.index.root), ByType: make(map[string]account.Money), ByBranch: make(map[string]account.Money)}
+	for k, v := range ag.byType {
+		if v != 0 {
+			totals.ByType[k] = v
+		}
+	}
+	for k, v := range ag.byBranch {
+		if v != 0 {
+			totals.ByBranch[k] = v
+		}
+	}
+	return totals
+}
+
+// replace swaps in the totals of fresh, which must not be shared.
+func (ag *aggregates) replace(fresh *aggregates) {
+	ag.mutex.Lock()
+	defer ag.mutex.Unlock()
+	ag.total, ag.byType, ag.byBranch = fresh.total, fresh.byType, fresh.byBranch
+	ag.accounts, ag.index = fresh.accounts, fresh.index
+}
+
+// registerAccount adds an account to the bank in the given state and starts tracking it in the aggregates.
+// The caller must hold the bank mutex.
+func (b *Bank) registerAccount(acc account.Account, state account.State) {
+	id := acc.ID()
+	b.accounts.put(acc, state)
+	if o, ok := acc.(account.Observable); ok {
+		o.SetBalanceObserver(func(delta account.Money) { b.totals.apply(id, delta) })
+	}
+	b.totals.track(id, account.TypeOf(acc), b.accountBranch[id], acc.Balance(), state != account.StateClosed)
+}
+
+// SetAccountBranch assigns an account to a branch for reporting.
+func (b *Bank) SetAccountBranch(accountID, branch string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.accounts.get(accountID); !exists {
+		return errors.New("account does not exist")
+	}
+	if branch == "" {
+		delete(b.accountBranch, accountID)
+	} else {
+		b.accountBranch[accountID] = branch
+	}
+	b.totals.update(accountID, func(e *aggregateEntry) { e.branch = branch })
+	return nil
+}
+
+// Totals returns the bank-wide balance totals without scanning the accounts.
+func (b *Bank) Totals() AggregateTotals {
+	return b.totals.snapshot()
+}
+
+// VerifyAggregates recomputes the totals from every account and compares them with the running aggregates.
+// Any drift is reported and the aggregates are reset to the recomputed figures. Every account is locked for the
+// duration, so the check sees a consistent picture.
+func (b *Bank) VerifyAggregates() error {
+	b.mutex.Lock()
+	ids := make([]string, 0, b.accounts.len())
+	for id := range b.accounts.all() {
+		ids = append(ids, id)
+	}
+	b.mutex.Unlock()
+	unlock := b.lockAccounts(ids...)
+	defer unlock()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	fresh := newAggregates()
+	for id, acc := range b.accounts.all() {
+		fresh.track(id, account.TypeOf(acc), b.accountBranch[id], acc.Balance(), b.IsAccountActive(id))
+	}
+	expected := fresh.snapshot()
+	actual := b.totals.snapshot()
+
+	var drift []string
+	if expected.Total != actual.Total {
+		drift = append(drift, fmt.Sprintf("total %s, expected %s", actual.Total, expected.Total))
+	}
+	if expected.Accounts != actual.Accounts {
+		drift = append(drift, fmt.Sprintf("%d accounts, expected %d", actual.Accounts, expected.Accounts))
+	}
+	drift = append(drift, compareTotals("type", actual.ByType, expected.ByType)...)
+	drift = append(drift, compareTotals("branch", actual.ByBranch, expected.ByBranch)...)
+
+	b.totals.replace(fresh)
+
+	if len(drift) > 0 {
+		return errors.New("aggregates drifted: " + strings.Join(drift, "; "))
+	}
+	return nil
+}
+
+// compareTotals lists the keys whose running total differs from the recomputed one.
+func compareTotals(kind string, actual, expected map[string]account.Money) []string {
+	keys := make(map[string]bool)
+	for k := range actual {
+		keys[k] = true
+	}
+	for k := range expected {
+		keys[k] = true
+	}
+	var drift []string
+	for k := range keys {
+		if actual[k] != expected[k] {
+			drift = append(drift, fmt.Sprintf("%s %q %s, expected %s", kind, k, actual[k], expected[k]))
+		}
+	}
+	sort.Strings(drift)
+	return drift
+}
@@ -0,0 +1,103 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// currencyDecimals lists the currencies whose amounts have fewer than two decimal places. Money keeps two, so
+// currencies with three are held to two like every unlisted one.
+var currencyDecimals = map[string]int{"JPY": 0, "KRW": 0, "VND": 0, "CLP": 0, "ISK": 0, "PYG": 0, "UGX": 0}
+
+// CurrencyDecimals returns how many decimal places amounts in a currency may have.
+func CurrencyDecimals(currency string) int {
+	if places, listed := currencyDecimals[currency]; listed {
+		return places
+	}
+	return 2
+}
+
+// ParseAmount converts an amount a person typed into Money without going through floating point, reading it the
+// same way whatever their locale: "." is the only decimal point, and "," or spaces may only separate groups of
+// three digits, e.g. "1,234.56" or "1 234.56". Anything else is an error rather than a guess, including
+// "1.234,56" and amounts with more decimal places than the currency has. A blank currency means DefaultCurrency.
+func ParseAmount(text, currency string) (account.Money, error) {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	s := strings.TrimSpace(text)
+	if s == "" {
+		return 0, errors.New("enter an amount")
+	}
+	negative := false
+	if s[0] == '-' || s[0] == '+' {
+		negative, s = s[0] == '-', strings.TrimSpace(s[1:])
+	}
+	whole, frac, hasPoint := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("amount %q is not a number", text)
+	}
+	if hasPoint && frac == "" {
+		return 0, fmt.Errorf("amount %q ends in a decimal point", text)
+	}
+	if !allDigits(frac) {
+		return 0, fmt.Errorf("amount %q has a separator or other character after the decimal point; use . for decimals only", text)
+	}
+	digits, err := ungroup(whole)
+	if err != nil {
+		return 0, fmt.Errorf("amount %q %w", text, err)
+	}
+	if digits == "" {
+		digits = "0"
+	}
+	if places := CurrencyDecimals(currency); len(frac) > places {
+		if places == 0 {
+			return 0, fmt.Errorf("%s amounts have no decimal places", currency)
+		}
+		return 0, fmt.Errorf("%s amounts have at most %d decimal places", currency, places)
+	}
+	major, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || major > (math.MaxInt64-account.MinorUnits)/account.MinorUnits {
+		return 0, fmt.Errorf("amount %q is too large", text)
+	}
+	minor, _ := strconv.ParseInt((frac + "00")[:2], 10, 64)
+	amount := account.Money(major*account.MinorUnits + minor)
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// ungroup strips thousands separators from the whole part of an amount, checking they only ever separate groups of
+// three digits and that one amount does not mix them.
+func ungroup(whole string) (string, error) {
+	sep := strings.IndexAny(whole, ", ")
+	if sep < 0 {
+		if !allDigits(whole) {
+			return "", errors.New("is not a number")
+		}
+		return whole, nil
+	}
+	groups := strings.Split(whole, whole[sep:sep+1])
+	for i, g := range groups {
+		if !allDigits(g) || g == "" || len(g) > 3 || (i > 0 && len(g) != 3) {
+			return "", errors.New("has separators that do not split the digits into groups of three; use . for decimals")
+		}
+	}
+	return strings.Join(groups, ""), nil
+}
+
+// allDigits reports whether s consists of ASCII digits only.
+func allDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// MaxAmount is the largest amount any single deposit, withdrawal or transfer may move, whatever its policy: a
+// trillion major units, far enough inside the range of Money that balances, fees and totals cannot overflow.
+const MaxAmount account.Money = 1_000_000_000_000 * account.MinorUnits
+
+// AmountPolicy bounds the amounts one kind of operation accepts. An amount outside it is invalid and declined with
+// ReasonInvalidAmount before limits, rules or funds are checked; limits, in contrast, decline valid amounts.
+type AmountPolicy struct {
+	Min account.Money `json:"min"` // Smallest amount, in minor units; 0 means one minor unit
+	Max account.Money `json:"max"` // Largest amount, in minor units; 0 means MaxAmount
+}
+
+// validate checks a policy's bounds are in range and in order.
+func (p AmountPolicy) validate() error {
+	if p.Min < 0 || p.Max < 0 {
+		return errors.New("amount bounds must not be negative")
+	}
+	if p.Max > MaxAmount {
+		return fmt.Errorf("largest amount must not exceed %s", MaxAmount)
+	}
+	if p.Max > 0 && p.Min > p.Max {
+		return errors.New("smallest amount must not exceed the largest")
+	}
+	return nil
+}
+
+// Check returns why an amount in a currency is not valid for an operation under the policy: it is not positive, is
+// outside the policy's bounds, or has minor units the currency does not have, such as cents of yen.
+func (p AmountPolicy) Check(op transaction.OperationType, currency string, amount account.Money) error {
+	if amount <= 0 {
+		return decline(ReasonInvalidAmount, string(op)+" amount must be positive")
+	}
+	if amount < p.Min {
+		return decline(ReasonInvalidAmount, fmt.Sprintf("%s of %s is below the smallest of %s", op, amount, p.Min))
+	}
+	highest := p.Max
+	if highest == 0 {
+		highest = MaxAmount
+	}
+	if amount > highest {
+		return decline(ReasonInvalidAmount, fmt.Sprintf("%s of %s is above the largest of %s", op, amount, highest))
+	}
+	step := account.Money(1)
+	places := CurrencyDecimals(currency)
+	for d := places; d < 2; d++ {
+		step *= 10
+	}
+	if amount%step != 0 {
+		if places == 0 {
+			return decline(ReasonInvalidAmount, currency+" amounts have no decimal places")
+		}
+		return decline(ReasonInvalidAmount, fmt.Sprintf("%s amounts have at most %d decimal places", currency, places))
+	}
+	return nil
+}
+
+// checkAmount returns why an amount is not valid for an operation on an account, under the configured policy for
+// the operation and in the account's currency.
+// The caller must hold the bank mutex.
+func (b *Bank) checkAmount(op transaction.OperationType, accountID string, amount account.Money) error {
+	var policy AmountPolicy
+	if b.config != nil {
+		policy = b.config.amounts[op]
+	}
+	return policy.Check(op, b.currencyOf(accountID), amount)
+}
+
+// ValidateAmount returns why an amount is not valid for a deposit, withdrawal or transfer from an account, or nil
+// if it is. Deposits, withdrawals and customer transfers check it themselves; it lets callers check an amount
+// before they start.
+func (b *Bank) ValidateAmount(op transaction.OperationType, accountID string, amount account.Money) error {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.checkAmount(op, accountID, amount)
+}
+
+// checkTransferPolicy rejects customer transfers whose amount is not valid for a transfer from the source account.
+// The caller must hold the bank mutex.
+func (b *Bank) checkTransferPolicy(t PendingTransfer) error {
+	return b.checkAmount(transaction.OpTransfer, t.FromID, t.Amount)
+}
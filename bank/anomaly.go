@@ -0,0 +1,224 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// baselineWindow is how far back an account's transfers are learned from.
+const baselineWindow = 90 * 24 * time.Hour
+
+// DefaultAnomalyThreshold is the score from which a transfer is sent for fraud review unless SetAnomalyScorer says
+// otherwise.
+const DefaultAnomalyThreshold = 0.7
+
+// AccountBaseline is what an account's outgoing transfers usually look like.
+type AccountBaseline struct {
+	AccountID      string
+	Transfers      int // Outgoing transfers the baseline was learned from
+	MeanAmount     account.Money
+	StdDevAmount   account.Money
+	Counterparties map[string]int // Map of destination account ID to transfers sent there
+	Hours          [24]int        // Transfers by hour of day, UTC
+}
+
+// AnomalyScore is how unusual a transfer is, from 0 for ordinary to 1, and why.
+type AnomalyScore struct {
+	Score   float64
+	Reasons []string
+}
+
+// AnomalyScorer scores a transfer made at a given time against its source account's baseline, which does not yet
+// include the transfer. Scorers run while the bank mutex and both accounts' locks are held, so they must not call
+// back into the Bank.
+type AnomalyScorer interface {
+	Score(t PendingTransfer, at time.Time, baseline AccountBaseline) AnomalyScore
+}
+
+// AnomalyScorerFunc adapts a function to an AnomalyScorer.
+type AnomalyScorerFunc func(t PendingTransfer, at time.Time, baseline AccountBaseline) AnomalyScore
+
+// Score calls f.
+func (f AnomalyScorerFunc) Score(t PendingTransfer, at time.Time, baseline AccountBaseline) AnomalyScore {
+	return f(t, at, baseline)
+}
+
+// BaselineScorer is the bank's own scorer. It adds up to 0.6 for an amount far above the account's usual, 0.25 for
+// a destination the account has never paid and 0.15 for a time of day the account is rarely active.
+type BaselineScorer struct {
+	MinHistory int // Transfers an account needs before its transfers are scored; 0 means 5
+}
+
+// Score scores a transfer against the baseline.
+func (s BaselineScorer) Score(t PendingTransfer, at time.Time, baseline AccountBaseline) AnomalyScore {
+	minHistory := s.MinHistory
+	if minHistory <= 0 {
+		minHistory = 5
+	}
+	var score AnomalyScore
+	if baseline.Transfers < minHistory {
+		return score
+	}
+	// Accounts that always send the same amount still get some room before an amount counts as unusual
+	spread := max(baseline.StdDevAmount, baseline.MeanAmount/10, 1)
+	if z := float64(t.Amount-baseline.MeanAmount) / float64(spread); z > 3 {
+		score.Score += 0.3 + 0.3*math.Min((z-3)/6, 1)
+		score.Reasons = append(score.Reasons, fmt.Sprintf("amount %s is %.1f deviations above the usual %s", t.Amount, z, baseline.MeanAmount))
+	}
+	if baseline.Counterparties[t.ToID] == 0 {
+		score.Score += 0.25
+		score.Reasons = append(score.Reasons, "first transfer to "+t.ToID)
+	}
+	hour := at.UTC().Hour()
+	nearby := baseline.Hours[(hour+23)%24] + baseline.Hours[hour] + baseline.Hours[(hour+1)%24]
+	if float64(nearby) < 0.05*float64(baseline.Transfers) {
+		score.Score += 0.15
+		score.Reasons = append(score.Reasons, fmt.Sprintf("unusual time %02d:00 UTC", hour))
+	}
+	score.Score = math.Min(score.Score, 1)
+	return score
+}
+
+// SetAnomalyScorer replaces the scorer customer transfers are checked with and the score from which they are sent for
+// fraud review. A nil scorer turns anomaly scoring off. Scoring never blocks a transfer.
+func (b *Bank) SetAnomalyScorer(scorer AnomalyScorer, threshold float64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.anomalyScorer = scorer
+	b.anomalyLimit = threshold
+}
+
+// Baseline learns an account's baseline from its outgoing transfers over the last 90 days.
+func (b *Bank) Baseline(accountID string) AccountBaseline {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.baseline(accountID)
+}
+
+// baseline learns an account's baseline from the event log.
+// The caller must hold the bank mutex.
+func (b *Bank) baseline(accountID string) AccountBaseline {
+	base := AccountBaseline{AccountID: accountID, Counterparties: make(map[string]int)}
+	since := b.now().Add(-baselineWindow)
+	var sum, sumSquares float64
+	for i := len(b.events) - 1; i >= 0 && b.events[i].At.After(since); i-- {
+		e := b.events[i]
+		if e.Type != EventTransferred || e.AccountID != accountID {
+			continue
+		}
+		base.Transfers++
+		base.Counterparties[e.ToID]++
+		base.Hours[e.At.UTC().Hour()]++
+		sum += float64(e.Amount)
+		sumSquares += float64(e.Amount) * float64(e.Amount)
+	}
+	if base.Transfers > 0 {
+		n := float64(base.Transfers)
+		mean := sum / n
+		base.MeanAmount = account.Money(math.Round(mean))
+		base.StdDevAmount = account.Money(math.Round(math.Sqrt(math.Max(sumSquares/n-mean*mean, 0))))
+	}
+	return base
+}
+
+// scoreTransfer scores a customer transfer before it is made, or returns a zero score if scoring is off.
+// The caller must hold both accounts' locks and the bank mutex.
+func (b *Bank) scoreTransfer(t PendingTransfer) AnomalyScore {
+	if b.anomalyScorer == nil {
+		return AnomalyScore{}
+	}
+	return b.anomalyScorer.Score(t, b.now(), b.baseline(t.FromID))
+}
+
+// flagAnomaly sends a completed transfer for fraud review if its score reached the threshold or review rules matched
+// it, giving the rules' reasons after the scorer's.
+// The caller must hold the bank mutex.
+func (b *Bank) flagAnomaly(txnID string, score AnomalyScore, ruleReasons ...string) {
+	scored := b.anomalyScorer != nil && score.Score >= b.anomalyLimit
+	if !scored && len(ruleReasons) == 0 {
+		return
+	}
+	var reasons []string
+	if scored {
+		reasons = append(reasons, score.Reasons...)
+	}
+	reasons = append(reasons, ruleReasons...)
+	b.annotateTransaction(txnID, fmt.Sprintf("Anomaly Score: %.2f, Anomaly: %s, Fraud Review: pending", score.Score, strings.Join(reasons, "; ")))
+}
+
+// FraudReview is a transfer whose anomaly score sent it for fraud review.
+type FraudReview struct {
+	TransactionID string
+	From          string
+	To            string
+	Amount        account.Money
+	Recorded      time.Time
+	Score         float64
+	Reasons       string
+	Status        string // "pending", or the outcome and reviewer, e.g. "confirmed by m1"
+}
+
+// fraudReview picks the review out of a history entry, if it has one. A reviewed entry carries its latest outcome.
+func fraudReview(txnID, entry string) (FraudReview, bool) {
+	h := parseHistoryEntry(txnID, entry)
+	review := FraudReview{TransactionID: txnID, From: h.From, To: h.To, Amount: h.Amount, Recorded: h.Recorded}
+	for _, field := range historyFields(entry) {
+		switch field[0] {
+		case "Anomaly Score":
+			review.Score, _ = strconv.ParseFloat(field[1], 64)
+		case "Anomaly":
+			review.Reasons = field[1]
+		case "Fraud Review":
+			review.Status = field[1]
+		}
+	}
+	return review, review.Status != ""
+}
+
+// FraudReviewQueue lists the transfers awaiting fraud review, highest score first.
+func (b *Bank) FraudReviewQueue() []FraudReview {
+	b.mutex.Lock()
+	var queue []FraudReview
+	for id, entry := range b.transactionHist {
+		if review, flagged := fraudReview(id, entry); flagged && review.Status == "pending" {
+			queue = append(queue, review)
+		}
+	}
+	b.mutex.Unlock()
+	sort.Slice(queue, func(i, j int) bool {
+		if queue[i].Score != queue[j].Score {
+			return queue[i].Score > queue[j].Score
+		}
+		return queue[i].Recorded.Before(queue[j].Recorded)
+	})
+	return queue
+}
+
+// ReviewTransfer records the outcome of a fraud review: confirmed if fraud is true, cleared otherwise. Only admins
+// and managers may review transfers. Confirming does not move money; reverse the transfer or freeze the account
+// separately.
+func (b *Bank) ReviewTransfer(staffID, txnID string, fraud bool) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.isManager(staffID) {
+		return decline(ReasonNotAuthorized, "only admins and managers may review transfers")
+	}
+	review, flagged := fraudReview(txnID, b.transactionHist[txnID])
+	if !flagged || review.Status != "pending" {
+		return errors.New("transfer is not awaiting fraud review")
+	}
+	outcome := "cleared"
+	if fraud {
+		outcome = "confirmed"
+	}
+	b.annotateTransaction(txnID, fmt.Sprintf("Fraud Review: %s by %s", outcome, staffID))
+	b.auditAction(staffID, "ReviewTransfer", review.From, txnID, outcome)
+	return nil
+}
@@ -0,0 +1,234 @@
+package bank
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// APIPlan is a billing plan for API clients: how many calls of each operation a client may make per calendar month,
+// and what each call costs. Operations are the RPC method names, e.g. "Transfer"; "*" stands for every operation.
+type APIPlan struct {
+	Quotas map[string]int64         `json:"quotas"`      // Map of operation, or "*" for all together, to calls allowed per month
+	Prices map[string]account.Money `json:"pricesMinor"` // Map of operation, or "*" for any other, to the price of one call
+}
+
+// DefaultAPIPlan is the plan of API clients the configuration assigns to no plan. Without it they are unmetered.
+const DefaultAPIPlan = "default"
+
+// apiOperations are the RPC methods usage is counted for. GetAPIUsage is not, so clients over quota can see why.
+var apiOperations = map[string]bool{
+	"CreateSavingsAccount": true, "CreateCheckingAccount": true, "CreateAccount": true, "GetAccount": true, "CloseAccount": true,
+	"Deposit": true, "Withdraw": true, "PlaceHold": true, "CaptureHold": true, "ReleaseHold": true,
+	"RequestJointDebit": true, "DecideJointDebit": true, "ListJointDebits": true,
+	"Transfer": true, "ReverseTransfer": true, "ScheduleTransfer": true, "ListScheduledTransfers": true, "CancelScheduledTransfer": true,
+	"AddPayee": true, "RemovePayee": true, "ListPayees": true,
+	"Report": true, "FailureReport": true, "ListTransactions": true,
+	"CreateSubscription": true, "GetSubscription": true, "ListSubscriptions": true, "UpdateSubscription": true,
+	"DeleteSubscription": true, "RotateSecret": true, "ListDeliveries": true, "Redeliver": true,
+	"ReloadConfig": true, "GetConfig": true,
+}
+
+// validate checks a plan's quotas and prices name known operations and are not negative.
+func (p APIPlan) validate(name string) error {
+	for op, quota := range p.Quotas {
+		if op != "*" && !apiOperations[op] {
+			return fmt.Errorf("API plan %s: unknown operation %q", name, op)
+		}
+		if quota < 0 {
+			return fmt.Errorf("API plan %s: quota for %s must not be negative", name, op)
+		}
+	}
+	for op, price := range p.Prices {
+		if op != "*" && !apiOperations[op] {
+			return fmt.Errorf("API plan %s: unknown operation %q", name, op)
+		}
+		if price < 0 {
+			return fmt.Errorf("API plan %s: price of %s must not be negative", name, op)
+		}
+	}
+	return nil
+}
+
+// price returns what one call of an operation costs on the plan.
+func (p APIPlan) price(op string) account.Money {
+	if price, exists := p.Prices[op]; exists {
+		return price
+	}
+	return p.Prices["*"]
+}
+
+// APIUsage is how many calls of each operation one API client made in one calendar month.
+type APIUsage struct {
+	ClientID string           `json:"clientId"`
+	Month    string           `json:"month"` // YYYY-MM, in UTC
+	Calls    map[string]int64 `json:"calls"` // Map of operation to calls
+}
+
+// UsageStorage is implemented by storage backends that keep API usage.
+type UsageStorage interface {
+	SaveUsage(usage []APIUsage) error
+	LoadUsage() ([]APIUsage, error)
+}
+
+// apiUsageKey identifies one client's usage in one month.
+type apiUsageKey struct {
+	client string
+	month  string
+}
+
+// apiClientKey is the context key under which the API client making a request travels.
+type apiClientKey struct{}
+
+// ContextWithAPIClient returns a context carrying the API client, such as an integrator's application, a request
+// comes from. The authentication layer in front of the servers attaches it alongside the user.
+func ContextWithAPIClient(ctx context.Context, clientID string) context.Context {
+	return context.WithValue(ctx, apiClientKey{}, clientID)
+}
+
+// APIClientFromContext returns the API client a context carries, or else its signed-in user.
+func APIClientFromContext(ctx context.Context) (string, bool) {
+	if clientID, ok := ctx.Value(apiClientKey{}).(string); ok && clientID != "" {
+		return clientID, true
+	}
+	return UserFromContext(ctx)
+}
+
+// apiPlan returns the plan an API client is on and its name, reporting false if the client is unmetered.
+// The caller must hold the bank mutex.
+func (b *Bank) apiPlan(clientID string) (string, APIPlan, bool) {
+	if b.config == nil {
+		return "", APIPlan{}, false
+	}
+	name, assigned := b.config.apiClients[clientID]
+	if !assigned {
+		name = DefaultAPIPlan
+	}
+	plan, exists := b.config.apiPlans[name]
+	return name, plan, exists
+}
+
+// meterRequest counts an API call against the calling client's monthly usage, declining it with
+// ReasonQuotaExceeded once the client's plan allows no more calls of the operation this month. Requests that carry
+// no client are not counted; authorization rejects them.
+func (b *Bank) meterRequest(ctx context.Context, op string) error {
+	clientID, ok := APIClientFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	key := apiUsageKey{client: clientID, month: b.now().UTC().Format("2006-01")}
+	calls := b.apiUsage[key]
+	if _, plan, metered := b.apiPlan(clientID); metered {
+		var total int64
+		for _, n := range calls {
+			total += n
+		}
+		if quota, exists := plan.Quotas[op]; exists && calls[op] >= quota {
+			return decline(ReasonQuotaExceeded, fmt.Sprintf("monthly quota of %d %s calls used up", quota, op))
+		}
+		if quota, exists := plan.Quotas["*"]; exists && total >= quota {
+			return decline(ReasonQuotaExceeded, fmt.Sprintf("monthly quota of %d calls used up", quota))
+		}
+	}
+	if calls == nil {
+		calls = make(map[string]int64)
+		b.apiUsage[key] = calls
+	}
+	calls[op]++
+	return nil
+}
+
+// APIUsageSummary is one client's API usage in one month, with what it is billed.
+type APIUsageSummary struct {
+	ClientID string
+	Month    string
+	Plan     string           // "" for unmetered clients
+	Calls    map[string]int64 // Map of operation to calls
+	Total    int64
+	Quotas   map[string]int64 // The plan's quotas, by operation
+	Charge   account.Money    // Calls priced by the plan
+}
+
+// APIUsageReport summarizes the API usage of every client in a month, given as YYYY-MM, ordered by client.
+func (b *Bank) APIUsageReport(month string) []APIUsageSummary {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	var report []APIUsageSummary
+	for key, calls := range b.apiUsage {
+		if key.month == month {
+			report = append(report, b.usageSummary(key, calls))
+		}
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].ClientID < report[j].ClientID })
+	return report
+}
+
+// APIUsageOf summarizes one client's API usage in a month, given as YYYY-MM.
+func (b *Bank) APIUsageOf(clientID, month string) APIUsageSummary {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	key := apiUsageKey{client: clientID, month: month}
+	return b.usageSummary(key, b.apiUsage[key])
+}
+
+// usageSummary prices a client's calls in a month by the plan the client is on now.
+// The caller must hold the bank mutex.
+func (b *Bank) usageSummary(key apiUsageKey, calls map[string]int64) APIUsageSummary {
+	s := APIUsageSummary{ClientID: key.client, Month: key.month, Calls: make(map[string]int64, len(calls))}
+	name, plan, metered := b.apiPlan(key.client)
+	for op, n := range calls {
+		s.Calls[op] = n
+		s.Total += n
+		if metered {
+			s.Charge += plan.price(op) * account.Money(n)
+		}
+	}
+	if metered {
+		s.Plan = name
+		s.Quotas = make(map[string]int64, len(plan.Quotas))
+		for op, quota := range plan.Quotas {
+			s.Quotas[op] = quota
+		}
+	}
+	return s
+}
+
+// usageForStorage lists every client's usage for persistence, ordered by month and client.
+// The caller must hold the bank mutex.
+func (b *Bank) usageForStorage() []APIUsage {
+	usage := make([]APIUsage, 0, len(b.apiUsage))
+	for key, calls := range b.apiUsage {
+		u := APIUsage{ClientID: key.client, Month: key.month, Calls: make(map[string]int64, len(calls))}
+		for op, n := range calls {
+			u.Calls[op] = n
+		}
+		usage = append(usage, u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Month != usage[j].Month {
+			return usage[i].Month < usage[j].Month
+		}
+		return usage[i].ClientID < usage[j].ClientID
+	})
+	return usage
+}
+
+// restoreUsage adopts stored API usage.
+func (b *Bank) restoreUsage(usage []APIUsage) error {
+	for _, u := range usage {
+		if u.ClientID == "" || u.Month == "" {
+			return errors.New("stored API usage lacks a client or month")
+		}
+		calls := make(map[string]int64, len(u.Calls))
+		for op, n := range u.Calls {
+			calls[op] = n
+		}
+		b.apiUsage[apiUsageKey{client: u.ClientID, month: u.Month}] = calls
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package bank
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// AuditActorSystem is the actor of changes no signed-in user asked for, such as scheduled transfers and interest.
+const AuditActorSystem = "system"
+
+// AuditBalance is an account's balance either side of an audited change.
+type AuditBalance struct {
+	AccountID string        `json:"accountId"`
+	Before    account.Money `json:"beforeMinor"`
+	After     account.Money `json:"afterMinor"`
+}
+
+// AuditRecord is one entry of the audit log: who changed what, when, and how balances moved. Unlike the transaction
+// history it is for staff and auditors, never shown to customers.
+type AuditRecord struct {
+	At            time.Time      `json:"at"`
+	Actor         string         `json:"actor"` // Signed-in user who made the change, or AuditActorSystem
+	Channel       Channel        `json:"channel,omitempty"`
+	Action        string         `json:"action"` // Event type, e.g. "Transferred", or a staff action, e.g. "ReviewTransfer"
+	AccountID     string         `json:"accountId,omitempty"`
+	ToID          string         `json:"toId,omitempty"`
+	Amount        account.Money  `json:"amountMinor,omitempty"`
+	TransactionID string         `json:"transactionId,omitempty"`
+	CorrelationID string         `json:"correlationId,omitempty"`
+	Detail        string         `json:"detail,omitempty"` // New state, fee rule, decision or reason
+	Balances      []AuditBalance `json:"balances,omitempty"`
+}
+
+// AuditSink receives audit records in the order changes were made. Sinks only ever append.
+type AuditSink interface {
+	AppendAudit(r AuditRecord) error
+}
+
+// AuditStorage is implemented by storage backends that keep the audit log as well as receiving it.
+type AuditStorage interface {
+	AuditSink
+	LoadAudit() ([]AuditRecord, error)
+}
+
+// operator is who is running a correlated operation, and through which channel.
+type operator struct {
+	userID  string
+	channel Channel
+}
+
+// SetAuditSink replaces where audit records are written. A nil sink stops auditing. By default a bank audits to
+// its storage, if the storage implements AuditSink.
+func (b *Bank) SetAuditSink(sink AuditSink) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.auditSink = sink
+}
+
+// operatorOf returns who is running the operation with a correlation ID, or AuditActorSystem if nobody is.
+// The caller must hold the bank mutex.
+func (b *Bank) operatorOf(correlationID string) operator {
+	op := b.operators[correlationID]
+	if op.userID == "" {
+		op.userID = AuditActorSystem
+	}
+	return op
+}
+
+// balanceChange returns how an event changed an account's balance, as far as the event itself says.
+func balanceChange(e Event, accountID string) account.Money {
+	switch e.Type {
+	case EventDeposited, EventCompensationResolved, EventInterestPosted, EventAccountUpdated:
+		return e.Amount
+	case EventWithdrew, EventFeeCharged, EventCompensationPending:
+		return -e.Amount
+	case EventTransferred, EventTaxWithheld:
+		if accountID == e.AccountID {
+			return -e.Amount
+		}
+		if e.ToAmount != 0 {
+			return e.ToAmount
+		}
+		return e.Amount
+	}
+	return 0
+}
+
+// auditEvent writes the audit record of an event, with the balances of the accounts it touched before and after.
+// The caller must hold the bank mutex.
+func (b *Bank) auditEvent(e Event) {
+	if b.auditSink == nil {
+		return
+	}
+	op := b.operatorOf(e.CorrelationID)
+	r := AuditRecord{
+		At:            e.At,
+		Actor:         op.userID,
+		Channel:       op.channel,
+		Action:        string(e.Type),
+		AccountID:     e.AccountID,
+		ToID:          e.ToID,
+		Amount:        e.Amount,
+		TransactionID: e.TransactionID,
+		CorrelationID: e.CorrelationID,
+		Detail:        e.Reason,
+	}
+	if e.State != "" {
+		r.Detail = string(e.State)
+	}
+	for _, id := range []string{e.AccountID, e.ToID} {
+		acc, exists := b.accounts.get(id)
+		if !exists {
+			continue
+		}
+		after := acc.Balance()
+		before, known := b.auditBalances[id]
+		if !known {
+			before = after - balanceChange(e, id)
+		}
+		if e.Type == EventAccountCreated {
+			before = 0
+		}
+		b.auditBalances[id] = after
+		r.Balances = append(r.Balances, AuditBalance{AccountID: id, Before: before, After: after})
+	}
+	b.writeAudit(r)
+}
+
+// auditAction writes the audit record of a staff action that is not itself an event, such as a review decision.
+// The caller must hold the bank mutex.
+func (b *Bank) auditAction(staffID, action, accountID, txnID, detail string) {
+	if b.auditSink == nil {
+		return
+	}
+	b.writeAudit(AuditRecord{At: b.now(), Actor: staffID, Action: action, AccountID: accountID, TransactionID: txnID, Detail: detail})
+}
+
+// writeAudit appends a record to the audit sink, remembering the first failure for Save to report.
+// The caller must hold the bank mutex.
+func (b *Bank) writeAudit(r AuditRecord) {
+	if err := b.auditSink.AppendAudit(r); err != nil && b.persistErr == nil {
+		b.persistErr = err
+	}
+}
+
+// AuditTrail reads the audit log back from the sink, oldest first, optionally only the records touching one account.
+// Only staff may read it.
+func (b *Bank) AuditTrail(staffID, accountID string) ([]AuditRecord, error) {
+	b.mutex.Lock()
+	allowed := b.isStaff(staffID)
+	as, ok := b.auditSink.(AuditStorage)
+	b.mutex.Unlock()
+	if !allowed {
+		return nil, decline(ReasonNotAuthorized, "only staff may read the audit log")
+	}
+	if !ok {
+		return nil, errors.New("audit sink cannot be read back")
+	}
+	records, err := as.LoadAudit()
+	if err != nil || accountID == "" {
+		return records, err
+	}
+	var touching []AuditRecord
+	for _, r := range records {
+		if r.AccountID == accountID || r.ToID == accountID {
+			touching = append(touching, r)
+		}
+	}
+	return touching, nil
+}
+
+// FileAuditSink appends audit records to a JSON lines file, e.g. on write-once storage kept apart from the bank's
+// data.
+type FileAuditSink struct {
+	path  string
+	mutex *sync.Mutex
+}
+
+// NewFileAuditSink creates a sink appending to the file at path, creating it and its directory on first write.
+func NewFileAuditSink(path string) *FileAuditSink {
+	return &FileAuditSink{path: path, mutex: &sync.Mutex{}}
+}
+
+// AppendAudit appends a record to the file and syncs it to disk.
+func (fs *FileAuditSink) AppendAudit(r AuditRecord) error {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	return appendAuditLine(fs.path, r)
+}
+
+// LoadAudit reads the file's records in order. A missing file means nothing has been audited yet.
+func (fs *FileAuditSink) LoadAudit() ([]AuditRecord, error) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	return readAuditLines(fs.path)
+}
+
+// appendAuditLine appends a record to a JSON lines file and syncs it.
+func appendAuditLine(path string, r AuditRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readAuditLines reads the records of a JSON lines file in order.
+func readAuditLines(path string) ([]AuditRecord, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var records []AuditRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
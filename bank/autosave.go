@@ -0,0 +1,148 @@
+package bank
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// AutoSaveTrigger selects when an auto-save rule fires.
+type AutoSaveTrigger int
+
+const (
+	// OnIncomingCredit saves a percentage of every credit to the source account.
+	OnIncomingCredit AutoSaveTrigger = iota
+	// WeeklySchedule saves a fixed amount on a given weekday.
+	WeeklySchedule
+)
+
+// AutoSaveRule moves money from a source account into a savings pot.
+type AutoSaveRule struct {
+	ID       string
+	SourceID string // Account money is saved from
+	PotID    string // Savings account money is saved into
+	Trigger  AutoSaveTrigger
+	Percent  float64       // Share of each incoming credit, for OnIncomingCredit
+	Amount   account.Money // Fixed amount, for WeeklySchedule
+	Weekday  time.Weekday  // Day to save on, for WeeklySchedule
+	Saved    account.Money // Total moved into the pot by this rule
+	Runs     int           // Successful executions
+	Failures int           // Executions skipped, e.g. for insufficient funds
+	lastRun  time.Time
+}
+
+// AddAutoSaveRule validates and registers a rule, returning its ID.
+func (b *Bank) AddAutoSaveRule(rule AutoSaveRule) (string, error) {
+	switch rule.Trigger {
+	case OnIncomingCredit:
+		if rule.Percent <= 0 || rule.Percent > 100 {
+			return "", errors.New("percentage must be between 0 and 100")
+		}
+	case WeeklySchedule:
+		if rule.Amount <= 0 {
+			return "", errors.New("amount must be positive")
+		}
+	default:
+		return "", errors.New("unknown auto-save trigger")
+	}
+	if rule.SourceID == rule.PotID {
+		return "", errors.New("source and savings pot must differ")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.accounts.get(rule.SourceID); !exists {
+		return "", errors.New("source account does not exist")
+	}
+	if _, exists := b.accounts.get(rule.PotID); !exists {
+		return "", errors.New("savings pot does not exist")
+	}
+	rule.ID = b.newID("rule")
+	rule.Saved, rule.Runs, rule.Failures = 0, 0, 0
+	b.autoSaveRules[rule.ID] = &rule
+	return rule.ID, nil
+}
+
+// RemoveAutoSaveRule deletes a rule.
+func (b *Bank) RemoveAutoSaveRule(ruleID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.autoSaveRules[ruleID]; !exists {
+		return errors.New("auto-save rule does not exist")
+	}
+	delete(b.autoSaveRules, ruleID)
+	return nil
+}
+
+// runAutoSave moves the amount for a rule and updates its statistics.
+// The caller must not hold the bank mutex.
+func (b *Bank) runAutoSave(rule *AutoSaveRule, amount account.Money) {
+	if amount <= 0 {
+		return
+	}
+	_, err := b.moveFunds(rule.SourceID, rule.PotID, amount)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err != nil {
+		rule.Failures++
+		return
+	}
+	rule.Saved += amount
+	rule.Runs++
+	rule.lastRun = b.now()
+}
+
+// applyCreditRules evaluates credit-triggered rules for an account that just received funds.
+// Transfers made by the rules themselves do not trigger further rules.
+// The caller must not hold the bank mutex.
+func (b *Bank) applyCreditRules(accountID string, amount account.Money) {
+	b.mutex.Lock()
+	var due []*AutoSaveRule
+	for _, rule := range b.autoSaveRules {
+		if rule.Trigger == OnIncomingCredit && rule.SourceID == accountID {
+			due = append(due, rule)
+		}
+	}
+	b.mutex.Unlock()
+
+	for _, rule := range due {
+		b.runAutoSave(rule, amount.MulRate(rule.Percent/100))
+	}
+}
+
+// RunScheduledAutoSaves executes weekly rules due today that have not already run today.
+func (b *Bank) RunScheduledAutoSaves() {
+	b.mutex.Lock()
+	now := b.now()
+	var due []*AutoSaveRule
+	for _, rule := range b.autoSaveRules {
+		if rule.Trigger != WeeklySchedule || now.Weekday() != rule.Weekday {
+			continue
+		}
+		y, m, d := now.Date()
+		ly, lm, ld := rule.lastRun.Date()
+		if y == ly && m == lm && d == ld {
+			continue
+		}
+		// Claim today's run before releasing the lock so concurrent callers do not repeat it
+		rule.lastRun = now
+		due = append(due, rule)
+	}
+	b.mutex.Unlock()
+
+	for _, rule := range due {
+		b.runAutoSave(rule, rule.Amount)
+	}
+}
+
+// AutoSaveReport returns every rule with the amount it has saved so far.
+func (b *Bank) AutoSaveReport() []AutoSaveRule {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	report := make([]AutoSaveRule, 0, len(b.autoSaveRules))
+	for _, rule := range b.autoSaveRules {
+		report = append(report, *rule)
+	}
+	return report
+}
@@ -0,0 +1,256 @@
+package bank
+
+import (
+	"bufio"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// backupVersion is the version of the backup layout Snapshot writes. RestoreBank rejects newer versions.
+const backupVersion = 1
+
+// bankBackup is everything the bank persists, as one document: accounts with their lifecycle states, the
+// transaction history, the event log, scheduled transfers, API usage, payees and whether the bank is a sandbox.
+type bankBackup struct {
+	Version      int                 `json:"version"`
+	TakenAt      time.Time           `json:"takenAt"`
+	Accounts     []account.Record    `json:"accounts"`
+	Transactions map[string]string   `json:"transactions"`
+	Events       []Event             `json:"events"`
+	Schedules    []ScheduledTransfer `json:"schedules"`
+	Usage        []APIUsage          `json:"usage"`
+	Payees       []Payee             `json:"payees"`
+	Mode         Mode                `json:"mode,omitempty"`
+}
+
+// LoadAccounts returns the backed-up accounts, so a backup can be loaded like storage.
+func (bb *bankBackup) LoadAccounts() ([]account.Record, error) {
+	return bb.Accounts, nil
+}
+
+// SaveAccounts replaces the backed-up accounts.
+func (bb *bankBackup) SaveAccounts(records []account.Record) error {
+	bb.Accounts = records
+	return nil
+}
+
+// AppendTransaction adds a transaction history entry to the backup.
+func (bb *bankBackup) AppendTransaction(txnID, entry string) error {
+	bb.Transactions[txnID] = entry
+	return nil
+}
+
+// LoadTransactions returns the backed-up transaction history.
+func (bb *bankBackup) LoadTransactions() (map[string]string, error) {
+	return bb.Transactions, nil
+}
+
+// AppendEvent adds an event to the backed-up event log.
+func (bb *bankBackup) AppendEvent(e Event) error {
+	bb.Events = append(bb.Events, e)
+	return nil
+}
+
+// LoadEvents returns the backed-up event log.
+func (bb *bankBackup) LoadEvents() ([]Event, error) {
+	return bb.Events, nil
+}
+
+// SaveSchedules replaces the backed-up scheduled transfers.
+func (bb *bankBackup) SaveSchedules(schedules []ScheduledTransfer) error {
+	bb.Schedules = schedules
+	return nil
+}
+
+// LoadSchedules returns the backed-up scheduled transfers.
+func (bb *bankBackup) LoadSchedules() ([]ScheduledTransfer, error) {
+	return bb.Schedules, nil
+}
+
+// SaveUsage replaces the backed-up API usage.
+func (bb *bankBackup) SaveUsage(usage []APIUsage) error {
+	bb.Usage = usage
+	return nil
+}
+
+// LoadUsage returns the backed-up API usage.
+func (bb *bankBackup) LoadUsage() ([]APIUsage, error) {
+	return bb.Usage, nil
+}
+
+// SavePayees replaces the backed-up payee directory.
+func (bb *bankBackup) SavePayees(payees []Payee) error {
+	bb.Payees = payees
+	return nil
+}
+
+// LoadPayees returns the backed-up payee directory.
+func (bb *bankBackup) LoadPayees() ([]Payee, error) {
+	return bb.Payees, nil
+}
+
+// SaveMode records the backed-up bank's mode.
+func (bb *bankBackup) SaveMode(mode Mode) error {
+	bb.Mode = mode
+	return nil
+}
+
+// LoadMode returns the backed-up bank's mode.
+func (bb *bankBackup) LoadMode() (Mode, error) {
+	return bb.Mode, nil
+}
+
+// Snapshot writes the bank's whole persisted state to w as a single JSON document: every account with its balance
+// and lifecycle state, the transaction history, the event log, scheduled transfers, API usage and payees.
+// RestoreBank reads it back.
+func (b *Bank) Snapshot(w io.Writer) error {
+	return b.SnapshotWithFormat(w, FormatOptions{})
+}
+
+// SnapshotWithFormat writes a snapshot like Snapshot, encoded and compressed as requested.
+func (b *Bank) SnapshotWithFormat(w io.Writer, opts FormatOptions) error {
+	b.mutex.RLock()
+	records, err := b.accountRecords()
+	if err != nil {
+		b.mutex.RUnlock()
+		return err
+	}
+	backup := bankBackup{
+		Version:      backupVersion,
+		TakenAt:      b.now(),
+		Accounts:     records,
+		Transactions: make(map[string]string, len(b.transactionHist)),
+		Events:       append([]Event(nil), b.events...),
+		Schedules:    b.schedulesForStorage(),
+		Usage:        b.usageForStorage(),
+		Payees:       b.payeesForStorage(),
+		Mode:         b.mode,
+	}
+	for txnID, entry := range b.transactionHist {
+		backup.Transactions[txnID] = entry
+	}
+	b.mutex.RUnlock()
+	sort.Slice(backup.Accounts, func(i, j int) bool { return backup.Accounts[i].ID < backup.Accounts[j].ID })
+
+	rw, err := newRecordWriter(w, opts)
+	if err != nil {
+		return err
+	}
+	if err := rw.encode(backup); err != nil {
+		return err
+	}
+	return rw.Close()
+}
+
+// RestoreBank rebuilds a bank from a snapshot written by Snapshot in any supported format. The bank keeps its state
+// in memory only until it is given storage with PersistTo.
+func RestoreBank(r io.Reader) (*Bank, error) {
+	content, done, err := decompressed(bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	first, err := peekNonSpace(content)
+	if err == io.EOF {
+		return nil, errors.New("snapshot is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var backup bankBackup
+	if first == '{' {
+		err = json.NewDecoder(content).Decode(&backup)
+	} else {
+		err = gob.NewDecoder(content).Decode(&backup)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot: %w", err)
+	}
+	if backup.Version < 1 || backup.Version > backupVersion {
+		return nil, fmt.Errorf("snapshot version %d is not supported", backup.Version)
+	}
+	if backup.Transactions == nil {
+		backup.Transactions = make(map[string]string)
+	}
+	b, err := New(&backup)
+	if err != nil {
+		return nil, fmt.Errorf("restoring snapshot: %w", err)
+	}
+	b.storage = nil
+	return b, nil
+}
+
+// PersistTo writes the bank's whole state to storage that holds no bank yet, such as a new data directory, and
+// saves there from then on. It refuses storage that already holds accounts or transactions so a restore cannot
+// mix two banks.
+func (b *Bank) PersistTo(storage Storage) error {
+	records, err := storage.LoadAccounts()
+	if err != nil {
+		return err
+	}
+	history, err := storage.LoadTransactions()
+	if err != nil {
+		return err
+	}
+	if len(records) > 0 || len(history) > 0 {
+		return errors.New("storage already holds a bank; restore into empty storage")
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	txnIDs := make([]string, 0, len(b.transactionHist))
+	for txnID := range b.transactionHist {
+		txnIDs = append(txnIDs, txnID)
+	}
+	sort.Strings(txnIDs)
+	for _, txnID := range txnIDs {
+		if err := storage.AppendTransaction(txnID, b.transactionHist[txnID]); err != nil {
+			return err
+		}
+	}
+	if es, ok := storage.(EventStorage); ok {
+		for _, e := range b.events {
+			if err := es.AppendEvent(e); err != nil {
+				return err
+			}
+		}
+	}
+	if ss, ok := storage.(ScheduleStorage); ok {
+		if err := ss.SaveSchedules(b.schedulesForStorage()); err != nil {
+			return err
+		}
+	}
+	if us, ok := storage.(UsageStorage); ok {
+		if err := us.SaveUsage(b.usageForStorage()); err != nil {
+			return err
+		}
+	}
+	if ps, ok := storage.(PayeeStorage); ok {
+		if err := ps.SavePayees(b.payeesForStorage()); err != nil {
+			return err
+		}
+	}
+	if ms, ok := storage.(ModeStorage); ok {
+		if err := ms.SaveMode(b.mode); err != nil {
+			return err
+		}
+	}
+	if records, err = b.accountRecords(); err != nil {
+		return err
+	}
+	if err := storage.SaveAccounts(records); err != nil {
+		return err
+	}
+	b.storage = storage
+	if sink, ok := storage.(AuditSink); ok {
+		b.auditSink = sink
+	}
+	return nil
+}
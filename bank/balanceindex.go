@@ -0,0 +1,180 @@
+package bank
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// AccountBalance pairs an account with its balance in ranked query results.
+type AccountBalance struct {
+	AccountID string
+	Balance   account.Money
+}
+
+// balanceNode is a node of the balance index treap. Nodes are ordered by balance, then account ID, and
+// carry their subtree size so rank queries do not need to walk the whole tree.
+type balanceNode struct {
+	key         AccountBalance
+	priority    int64
+	size        int
+	left, right *balanceNode
+}
+
+// balanceIndex keeps accounts ordered by balance. It is updated by the aggregates on every balance change and
+// guarded by the aggregates mutex.
+type balanceIndex struct {
+	root *balanceNode
+}
+
+func nodeSize(n *balanceNode) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func (n *balanceNode) update() {
+	n.size = 1 + nodeSize(n.left) + nodeSize(n.right)
+}
+
+// lessBalance orders index keys by balance, breaking ties by account ID.
+func lessBalance(a, b AccountBalance) bool {
+	if a.Balance != b.Balance {
+		return a.Balance < b.Balance
+	}
+	return a.AccountID < b.AccountID
+}
+
+// split divides a tree into keys less than key and keys greater than or equal to it.
+func splitBalance(n *balanceNode, key AccountBalance) (*balanceNode, *balanceNode) {
+	if n == nil {
+		return nil, nil
+	}
+	if lessBalance(n.key, key) {
+		l, r := splitBalance(n.right, key)
+		n.right = l
+		n.update()
+		return n, r
+	}
+	l, r := splitBalance(n.left, key)
+	n.left = r
+	n.update()
+	return l, n
+}
+
+// mergeBalance joins two trees where every key in a is less than every key in b.
+func mergeBalance(a, b *balanceNode) *balanceNode {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if a.priority > b.priority {
+		a.right = mergeBalance(a.right, b)
+		a.update()
+		return a
+	}
+	b.left = mergeBalance(a, b.left)
+	b.update()
+	return b
+}
+
+// insert adds a key to the index.
+func (bi *balanceIndex) insert(key AccountBalance) {
+	l, r := splitBalance(bi.root, key)
+	n := &balanceNode{key: key, priority: rand.Int63(), size: 1}
+	bi.root = mergeBalance(mergeBalance(l, n), r)
+}
+
+// remove deletes a key from the index if present.
+func (bi *balanceIndex) remove(key AccountBalance) {
+	bi.root = removeBalance(bi.root, key)
+}
+
+func removeBalance(n *balanceNode, key AccountBalance) *balanceNode {
+	if n == nil {
+		return nil
+	}
+	switch {
+	case lessBalance(key, n.key):
+		n.left = removeBalance(n.left, key)
+	case lessBalance(n.key, key):
+		n.right = removeBalance(n.right, key)
+	default:
+		return mergeBalance(n.left, n.right)
+	}
+	n.update()
+	return n
+}
+
+// kth returns the key with the given zero-based rank in ascending order.
+func (bi *balanceIndex) kth(k int) AccountBalance {
+	n := bi.root
+	for {
+		leftSize := nodeSize(n.left)
+		switch {
+		case k < leftSize:
+			n = n.left
+		case k == leftSize:
+			return n.key
+		default:
+			k -= leftSize + 1
+			n = n.right
+		}
+	}
+}
+
+// top returns up to limit keys in descending order by walking the tree from its largest key.
+func (bi *balanceIndex) top(limit int) []AccountBalance {
+	result := make([]AccountBalance, 0, limit)
+	var walk func(n *balanceNode)
+	walk = func(n *balanceNode) {
+		if n == nil || len(result) == limit {
+			return
+		}
+		walk(n.right)
+		if len(result) < limit {
+			result = append(result, n.key)
+		}
+		walk(n.left)
+	}
+	walk(bi.root)
+	return result
+}
+
+// TopAccountsByBalance returns the n accounts with the highest balances, highest first. Closed accounts are
+// excluded. The query reads an index kept in balance order, so it does not scan every account.
+func (b *Bank) TopAccountsByBalance(n int) []AccountBalance {
+	if n <= 0 {
+		return nil
+	}
+	b.totals.mutex.RLock()
+	defer b.totals.mutex.RUnlock()
+	if size := nodeSize(b.totals.index.root); n > size {
+		n = size
+	}
+	return b.totals.index.top(n)
+}
+
+// BalancePercentile returns the balance at the given percentile (0 to 100) of accounts that are not closed,
+// using the nearest-rank method: the smallest balance with at least p percent of accounts at or below it.
+func (b *Bank) BalancePercentile(p float64) (account.Money, error) {
+	if p < 0 || p > 100 || math.IsNaN(p) {
+		return 0, errors.New("percentile must be between 0 and 100")
+	}
+	b.totals.mutex.RLock()
+	defer b.totals.mutex.RUnlock()
+	count := nodeSize(b.totals.index.root)
+	if count == 0 {
+		return 0, errors.New("no accounts to rank")
+	}
+	rank := int(math.Ceil(p / 100 * float64(count)))
+	if rank < 1 {
+		rank = 1
+	}
+	return b.totals.index.kth(rank - 1).Balance, nil
+}
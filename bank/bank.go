@@ -0,0 +1,585 @@
+// Package bank holds accounts and moves money between them: deposits, withdrawals and transfers with fees,
+// lifecycle checks, history, persistence and the features built on top of them.
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// Bank defines the bank structure that holds accounts and performs operations.
+type Bank struct {
+	accounts         *accountTable // Accounts with their lifecycle states and profiles
+	transactionHist  map[string]string
+	accountOwner     map[string]string // Map of account ID to owning customer ID
+	accountBranch    map[string]string // Map of account ID to the branch it reports under
+	accountCurrency  map[string]string // Map of account ID to its currency, when not DefaultCurrency
+	rates            RateProvider
+	totals           *aggregates       // Running balance totals, updated on every balance change
+	defaultAccounts  map[string]string // Map of customer ID to default account for incoming credits
+	aliases          map[string]string // Map of payment alias (email, phone, handle) to customer ID
+	paymentRequests  map[string]*PaymentRequest
+	virtualAccounts  map[string]*VirtualAccount // Map of virtual account number to the physical account it settles into
+	virtualCredits   []VirtualCredit
+	groups           map[string]*ExpenseGroup
+	hierarchy        map[string]*HierarchyNode                 // Map of node ID to corporate entity
+	zbaStructures    map[string]*ZBAStructure                  // Map of structure ID to cash concentration structure
+	accountNode      map[string]string                         // Map of account ID to the entity holding it
+	hierarchyGrants  map[string]map[string]HierarchyPermission // Map of node ID to user ID to granted access
+	apiUsage         map[apiUsageKey]map[string]int64          // Map of API client and month to calls by operation
+	jointOwners      map[string][]string                       // Map of account ID to its owners besides accountOwner
+	signingRules     map[string]SigningRule                    // Map of joint account ID to which owners must approve debits
+	jointDebits      map[string]*JointDebit                    // Map of joint debit ID to a debit waiting for every owner's approval
+	payees           map[string]map[string]*Payee              // Map of customer ID to lower-cased nickname to registered payee
+	promotions       map[string]account.RateWindow             // Map of savings account ID to its promotional interest rate
+	feeConditions    map[string]*Expression                    // Map of fee rule condition to its parsed form
+	autoSaveRules    map[string]*AutoSaveRule
+	feeSchedule      []transaction.FeeRule
+	feeUsage         map[string]*feeUsage     // Map of account ID to this month's fee-relevant activity
+	features         *FeatureFlags            // Flags gating behavior being rolled out; nil means every flag is off
+	pauseSwitch      *TransferSwitch          // Kill switch pausing outgoing transfers bank-wide
+	mode             Mode                     // Whether balances are real or test money; see SetMode
+	config           *configSnapshot          // Limits, benchmarks and holidays from ApplyConfig; nil means none
+	pendingLimits    map[string]PendingLimits // Map of account ID to raised daily limits waiting out the cooling-off period
+	admins           map[string]bool          // Staff IDs allowed to override customer consent
+	roles            map[string]StaffRole     // Map of user ID to the role Authorize enforces
+	consents         map[string]*impersonationConsent
+	impersonations   map[string]*ImpersonationSession
+	impersonationLog []ImpersonationEvent
+	cases            map[string]*Case
+	customerNames    map[string]string     // Map of customer ID to name, as screened against the sanctions lists
+	screenings       map[string]*Screening // Map of screening ID to sanctions screening result
+	customerScreens  map[string]string     // Map of customer ID to their latest screening
+	payeeScreens     map[string]string     // Map of normalised payee name to its latest screening
+	staff            map[string]bool       // Staff IDs (tellers, support) allowed to see internal notes
+	purgedIDs        map[string]bool       // IDs of purged accounts, which cannot be reused
+	staffNotes       []StaffNote
+	descriptions     map[string]transaction.Description // Map of transaction ID to raw and enriched description
+	enrichers        []transaction.Enricher
+	transferRules    []TransferRule // Extra checks customer transfers must pass; see SetTransferRules
+	anomalyScorer    AnomalyScorer  // Scores customer transfers for fraud review; nil turns scoring off
+	anomalyLimit     float64        // Score from which a transfer is sent for fraud review
+	duplicatePolicy  DuplicatePolicy
+	recentTransfers  []recentTransfer
+	idempotencyKeys  map[string]string             // Map of idempotency key to the transaction it produced
+	schedules        map[string]*ScheduledTransfer // Map of schedule ID to future-dated or recurring transfer
+	scheduleRetry    ScheduleRetryPolicy
+	notifier         Notifier
+	notifyPrefs      map[string]NotificationPreferences // Map of customer ID to delivery preferences
+	notifyInboxes    map[string]*notificationInbox      // Map of customer ID to notifications held back
+	now              func() time.Time                   // Clock used for time-based features; replaceable in tests
+	ids              transaction.IDGenerator            // Creates transaction IDs; see SetIDGenerator
+	events           []Event                            // Append-only log of account changes; see ReplayFrom
+	eventSeq         int64
+	webhooks         webhookState // Subscriptions to the event log and their delivery log
+	storage          Storage
+	persistErr       error                  // First storage error since the last successful Save
+	accountLocks     map[string]*sync.Mutex // Per-account locks held while money moves; see lockAccounts
+	correlations     map[string]string      // Map of account or session ID to the correlation ID of the operation under way
+	corrLocks        map[string]*sync.Mutex // Held by correlated operations; see RunCorrelated
+	failures         []Failure              // Recent failed operations, oldest first; see FailureReport
+	operators        map[string]operator    // Map of correlation ID to who is running the operation
+	bus              eventBus               // Handlers subscribed to events and events waiting for them; see Subscribe
+	auditSink        AuditSink
+	auditBalances    map[string]account.Money // Map of account ID to the balance last written to the audit log
+	holds            map[string]*Hold         // Map of hold ID to funds reserved without moving them
+	interestPayouts  map[string]string        // Map of account ID to the account its interest is paid out to
+	taxStatus        map[string]TaxStatus     // Map of customer ID to tax status, for those not resident
+	segments         map[string]Segment       // Map of customer ID to pricing segment, for those not standard
+	pendingSegments  map[string]SegmentChange // Map of customer ID to a segment change waiting for the next cycle
+	accountSeq       uint64                   // Sequence number of the last generated account number; see NewAccountID
+	relationships    map[string]TierStatus    // Map of customer ID to the relationship tier of their last review
+	accountProduct   map[string]string        // Map of account ID to the product it is on, for those put on one
+	pendingProducts  map[string]Conversion    // Map of account ID to a conversion waiting for its effective date
+	overdraftLinks   map[string]string        // Map of checking account ID to the savings account covering its overdrafts
+	mutex            *sync.RWMutex            // Guards the bank's maps; held only briefly, and shared by readers
+}
+
+// New initializes a new Bank instance backed by the given storage, loading any state it holds.
+// A nil storage keeps everything in memory only.
+func New(storage Storage) (*Bank, error) {
+	b := &Bank{
+		accounts:        newAccountTable(),
+		transactionHist: make(map[string]string),
+		accountOwner:    make(map[string]string),
+		jointOwners:     make(map[string][]string),
+		signingRules:    make(map[string]SigningRule),
+		accountBranch:   make(map[string]string),
+		accountCurrency: make(map[string]string),
+		totals:          newAggregates(),
+		defaultAccounts: make(map[string]string),
+		aliases:         make(map[string]string),
+		paymentRequests: make(map[string]*PaymentRequest),
+		jointDebits:     make(map[string]*JointDebit),
+		payees:          make(map[string]map[string]*Payee),
+		virtualAccounts: make(map[string]*VirtualAccount),
+		groups:          make(map[string]*ExpenseGroup),
+		hierarchy:       make(map[string]*HierarchyNode),
+		accountNode:     make(map[string]string),
+		zbaStructures:   make(map[string]*ZBAStructure),
+		hierarchyGrants: make(map[string]map[string]HierarchyPermission),
+		autoSaveRules:   make(map[string]*AutoSaveRule),
+		feeUsage:        make(map[string]*feeUsage),
+		interestPayouts: make(map[string]string),
+		taxStatus:       make(map[string]TaxStatus),
+		segments:        make(map[string]Segment),
+		pendingSegments: make(map[string]SegmentChange),
+		relationships:   make(map[string]TierStatus),
+		accountProduct:  make(map[string]string),
+		pendingProducts: make(map[string]Conversion),
+		overdraftLinks:  make(map[string]string),
+		promotions:      make(map[string]account.RateWindow),
+		pauseSwitch:     NewTransferSwitch(),
+		mode:            ModeProduction,
+		apiUsage:        make(map[apiUsageKey]map[string]int64),
+		pendingLimits:   make(map[string]PendingLimits),
+		admins:          make(map[string]bool),
+		roles:           make(map[string]StaffRole),
+		consents:        make(map[string]*impersonationConsent),
+		impersonations:  make(map[string]*ImpersonationSession),
+		cases:           make(map[string]*Case),
+		customerNames:   make(map[string]string),
+		screenings:      make(map[string]*Screening),
+		customerScreens: make(map[string]string),
+		payeeScreens:    make(map[string]string),
+		staff:           make(map[string]bool),
+		purgedIDs:       make(map[string]bool),
+		descriptions:    make(map[string]transaction.Description),
+		enrichers:       []transaction.Enricher{transaction.NormalizeCounterparty{}},
+		anomalyScorer:   BaselineScorer{},
+		anomalyLimit:    DefaultAnomalyThreshold,
+		duplicatePolicy: DuplicatePolicy{Window: 2 * time.Minute, Action: DuplicateWarn},
+		idempotencyKeys: make(map[string]string),
+		schedules:       make(map[string]*ScheduledTransfer),
+		notifier:        &ConsoleNotifier{},
+		notifyPrefs:     make(map[string]NotificationPreferences),
+		notifyInboxes:   make(map[string]*notificationInbox),
+		now:             time.Now,
+		ids:             &transaction.ULIDGenerator{},
+		storage:         storage,
+		accountLocks:    make(map[string]*sync.Mutex),
+		correlations:    make(map[string]string),
+		corrLocks:       make(map[string]*sync.Mutex),
+		operators:       make(map[string]operator),
+		auditBalances:   make(map[string]account.Money),
+		holds:           make(map[string]*Hold),
+		mutex:           &sync.RWMutex{},
+	}
+	if sink, ok := storage.(AuditSink); ok {
+		b.auditSink = sink
+	}
+	if storage != nil {
+		if err := b.load(); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// SetIDGenerator replaces the generator of transaction IDs, e.g. with a counter so tests see the same IDs on every
+// run. Set it before the bank handles any operation.
+func (b *Bank) SetIDGenerator(ids transaction.IDGenerator) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.ids = ids
+}
+
+// newTxnID returns a new transaction ID.
+func (b *Bank) newTxnID() string {
+	return b.ids.NewID()
+}
+
+// newID returns a new ID for a record that is not a transaction, such as a hold: the prefix, a dash and the rest of
+// a new transaction ID, so it never repeats either.
+func (b *Bank) newID(prefix string) string {
+	return prefix + "-" + strings.TrimPrefix(b.newTxnID(), "txn-")
+}
+
+// CreateAccount adds an account to the bank. It fails with a DuplicateAccountError if the ID is taken.
+func (b *Bank) CreateAccount(acc account.Account) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err := b.checkNewAccountID(acc.ID()); err != nil {
+		return err
+	}
+	b.registerAccount(acc, account.StateOpen)
+	b.recordAccountEvent(EventAccountCreated, acc, Event{})
+	return nil
+}
+
+// GetAccount retrieves an account from the bank.
+func (b *Bank) GetAccount(accountID string) (account.Account, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	acc, exists := b.accounts.get(accountID)
+	if !exists {
+		return nil, errors.New("account does not exist")
+	}
+	return acc, nil
+}
+
+// Deposit credits an active account with cash and lets auto-save rules react to the credit.
+func (b *Bank) Deposit(accountID string, amount account.Money) error {
+	return b.DepositVia(accountID, amount, RailCash)
+}
+
+// DepositVia credits an active account with money that came in over a rail, subject to the rail's rules, and lets
+// auto-save rules react to the credit.
+func (b *Bank) DepositVia(accountID string, amount account.Money, rail Rail) error {
+	if !rails[rail] {
+		return errors.New("unknown channel " + string(rail))
+	}
+	if _, err := b.deposit(accountID, amount, rail, transaction.Memo{}); err != nil {
+		return err
+	}
+	b.applyCreditRules(accountID, amount)
+	return nil
+}
+
+// deposit credits an account and charges deposit fees while holding the account's lock. A deposit with a memo is
+// also recorded in the transaction history, and its transaction ID returned.
+func (b *Bank) deposit(accountID string, amount account.Money, rail Rail, memo transaction.Memo) (string, error) {
+	unlock := b.lockAccounts(accountID)
+	defer unlock()
+
+	b.mutex.Lock()
+	acc := b.accounts.at(accountID)
+	allowed := b.checkOperation(accountID, account.OperationDeposit)
+	limited := b.checkAmount(transaction.OpDeposit, accountID, amount)
+	if limited == nil {
+		limited = b.checkLimit(accountID, b.channelOf(accountID), transaction.OpDeposit, amount)
+	}
+	if limited == nil {
+		limited = b.checkRail(rail, transaction.OpDeposit, accountID, amount)
+	}
+	facts := b.ruleFacts(transaction.OpDeposit, b.channelOf(accountID), rail, accountID, "", "", amount)
+	if limited == nil {
+		limited = b.checkRules(facts)
+	}
+	flagged := b.matchRules(facts, RuleAlert, RuleReview)
+	fees := b.assessFees(accountID, transaction.OpDeposit, amount, rail)
+	b.mutex.Unlock()
+	if allowed != nil {
+		return "", allowed
+	}
+	if limited != nil {
+		return "", limited
+	}
+
+	if err := acc.Deposit(amount); err != nil {
+		return "", err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	txnID := b.recordMemo(HistoryDeposit, accountID, amount, rail, memo)
+	b.recordEvent(Event{Type: EventDeposited, AccountID: accountID, Amount: amount, TransactionID: txnID, Rail: rail})
+	b.recordRepayment(acc, "")
+	b.chargeFees(accountID, transaction.OpDeposit, fees)
+	b.actOnRules(accountID, transaction.OpDeposit, amount, flagged)
+	return txnID, nil
+}
+
+// Withdraw debits an active account in cash, charging any applicable fees. Funds on hold cannot be withdrawn, and
+// withdrawals a joint account's signing rule says every owner must approve go through RequestJointDebit instead.
+func (b *Bank) Withdraw(accountID string, amount account.Money) error {
+	_, err := b.withdraw(accountID, amount, nil, false, RailCash, transaction.Memo{})
+	return err
+}
+
+// WithdrawVia debits an active account like Withdraw, with the money leaving over a rail, subject to the rail's
+// rules. Withdrawals over rails that need approval go through RequestWithdrawal instead.
+func (b *Bank) WithdrawVia(accountID string, amount account.Money, rail Rail) error {
+	if !rails[rail] {
+		return errors.New("unknown channel " + string(rail))
+	}
+	if b.NeedsApproval(rail) {
+		return decline(ReasonApprovalRequired, fmt.Sprintf("%s withdrawals need a manager's approval; request one instead", rail))
+	}
+	_, err := b.withdraw(accountID, amount, nil, false, rail, transaction.Memo{})
+	return err
+}
+
+// withdraw debits an account while holding its lock, and that of the savings account protecting it from overdrafts.
+// A withdrawal beyond a checking account's balance pulls the shortfall from that savings account once every check
+// has passed, and puts it back if the withdrawal still fails; see SetOverdraftProtection. When a hold is being
+// captured, the hold's amount counts as available and the hold is settled once the money has left. Captures, whose holds were checked when placed, and
+// withdrawals every owner of a joint account has approved (cosigned) are not checked against its signing rule.
+// Whether the rail needs approval is for the caller to check. It returns the transaction ID of the capture, or of the
+// history entry recording a withdrawal with a memo; other withdrawals have none.
+func (b *Bank) withdraw(accountID string, amount account.Money, capture *Hold, cosigned bool, rail Rail, memo transaction.Memo) (string, error) {
+	b.mutex.RLock()
+	protectionID := b.overdraftLinks[accountID]
+	b.mutex.RUnlock()
+	unlock := b.lockAccounts(accountID, protectionID)
+	defer unlock()
+
+	b.mutex.Lock()
+	acc := b.accounts.at(accountID)
+	allowed := b.checkOperation(accountID, account.OperationWithdraw)
+	if allowed == nil && capture != nil && capture.Status != HoldActive {
+		allowed = errors.New("hold is " + string(capture.Status))
+	}
+	if allowed == nil {
+		allowed = b.checkSignatures(accountID, amount, cosigned || capture != nil)
+	}
+	limited := b.checkAmount(transaction.OpWithdrawal, accountID, amount)
+	if limited == nil {
+		limited = b.checkLimit(accountID, b.channelOf(accountID), transaction.OpWithdrawal, amount)
+	}
+	if limited == nil {
+		limited = b.checkDailyLimit(accountID, transaction.OpWithdrawal, amount)
+	}
+	if limited == nil {
+		limited = b.checkRail(rail, transaction.OpWithdrawal, accountID, amount)
+	}
+	facts := b.ruleFacts(transaction.OpWithdrawal, b.channelOf(accountID), rail, accountID, "", "", amount)
+	if limited == nil {
+		limited = b.checkRules(facts)
+	}
+	flagged := b.matchRules(facts, RuleAlert, RuleReview)
+	fees := b.assessFees(accountID, transaction.OpWithdrawal, amount, rail)
+	var cover *overdraftCover
+	if allowed == nil && limited == nil && capture == nil {
+		cover = b.planOverdraftCover(accountID, protectionID, amount+fees.Total())
+	}
+	if limited == nil {
+		limited = b.checkMinimumBalance(accountID, amount+fees.Total()-cover.covered())
+	}
+	var available account.Money
+	if allowed == nil {
+		available = b.available(accountID, capture) + cover.covered()
+	}
+	b.mutex.Unlock()
+	if allowed != nil {
+		return "", allowed
+	}
+	if limited != nil {
+		return "", limited
+	}
+	if fees.Total() > 0 && available < amount+fees.Total() {
+		return "", decline(ReasonInsufficientFunds, "insufficient funds to cover amount and fees")
+	}
+	if available < amount && available < account.Spendable(acc)+cover.covered() {
+		return "", decline(ReasonInsufficientFunds, "insufficient available funds: some are on hold")
+	}
+
+	if err := cover.move(); err != nil {
+		return "", err
+	}
+	if err := acc.Withdraw(amount); err != nil {
+		cover.undo(true)
+		return "", err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.recordOverdraftCover(cover)
+	var txnID string
+	if capture != nil {
+		txnID = b.settleCapture(capture, amount)
+	} else {
+		txnID = b.recordMemo(HistoryWithdraw, accountID, amount, rail, memo)
+	}
+	b.recordEvent(Event{Type: EventWithdrew, AccountID: accountID, Amount: amount, TransactionID: txnID, Rail: rail})
+	b.chargeFees(accountID, transaction.OpWithdrawal, fees)
+	b.actOnRules(accountID, transaction.OpWithdrawal, amount, flagged)
+	return txnID, nil
+}
+
+// IsAccountActive checks if an account exists and has not been closed. Frozen and dormant accounts are
+// still active; use checkOperation to find out what they may do.
+func (b *Bank) IsAccountActive(accountID string) bool {
+	status, exists := b.accounts.getState(accountID)
+	if !exists {
+		return false // If account doesn't exist, consider it inactive
+	}
+	return status != account.StateClosed
+}
+
+// Report generates a report of all active accounts along with their balances and the bank-wide totals. Both are
+// read from the running aggregates at one moment, so the balances add up to the totals, and reporting over many
+// accounts does not hold up deposits and transfers.
+func (b *Bank) Report() (balances map[string]account.Money, totals AggregateTotals) {
+	return b.totals.report()
+}
+
+// TotalBalance returns the total balance of all active accounts in the bank from the running aggregates.
+func (b *Bank) TotalBalance() account.Money {
+	return b.totals.totalBalance()
+}
+
+// transferFunds transfers funds from one account to another.
+func (b *Bank) transferFunds(fromID, toID string, amount account.Money) error {
+	_, err := b.transfer(fromID, toID, amount)
+	return err
+}
+
+// transfer performs a customer transfer including fees and auto-save rules, returning the transaction ID.
+// Only the two accounts involved are locked while money moves, so unrelated transfers run in parallel.
+// The caller must not hold the bank mutex.
+func (b *Bank) transfer(fromID, toID string, amount account.Money) (string, error) {
+	return b.sendTransfer(PendingTransfer{FromID: fromID, ToID: toID, Amount: amount})
+}
+
+// sendTransfer performs a customer transfer like transfer, carrying the travel rule details of t, unless outgoing
+// transfers are paused.
+// The caller must not hold the bank mutex.
+func (b *Bank) sendTransfer(t PendingTransfer) (string, error) {
+	if err := b.checkTransferPause(t); err != nil {
+		return "", err
+	}
+	return b.deliverTransfer(t)
+}
+
+// deliverTransfer performs a customer transfer like sendTransfer, whether or not transfers are paused.
+// The caller must not hold the bank mutex.
+func (b *Bank) deliverTransfer(t PendingTransfer) (string, error) {
+	txnID, err := b.transferWithFees(t)
+	if err != nil {
+		return "", err
+	}
+
+	// Let auto-save rules react to the incoming credit
+	b.applyCreditRules(t.ToID, t.Amount)
+
+	return txnID, nil
+}
+
+// transferWithFees moves funds and charges transfer fees while holding both accounts' locks.
+func (b *Bank) transferWithFees(t PendingTransfer) (string, error) {
+	fromID, toID, amount := t.FromID, t.ToID, t.Amount
+	unlock := b.lockAccounts(fromID, toID)
+	defer unlock()
+
+	b.mutex.Lock()
+	fees := b.assessFees(fromID, transaction.OpTransfer, amount, RailInternal)
+	t.Fees = fees.Total()
+	rules := b.customerTransferRules()
+	// Scored before the transfer is made so the baseline reflects only what came before it
+	score := b.scoreTransfer(t)
+	flagged := b.matchRules(b.ruleFacts(transaction.OpTransfer, b.channelOf(fromID, toID), RailInternal, fromID, toID, t.Country, amount), RuleAlert, RuleReview)
+	b.mutex.Unlock()
+
+	txnID, err := b.executeValidated(t, rules)
+	if err != nil {
+		return "", err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.chargeFees(fromID, transaction.OpTransfer, fees)
+	b.rememberTransfer(txnID, fromID, toID, amount)
+	b.flagAnomaly(txnID, score, b.actOnRules(fromID, transaction.OpTransfer, amount, flagged)...)
+	return txnID, nil
+}
+
+// moveFunds moves funds between accounts without fees or auto-save rules, e.g. for corrections.
+// The caller must not hold the bank mutex.
+func (b *Bank) moveFunds(fromID, toID string, amount account.Money) (string, error) {
+	unlock := b.lockAccounts(fromID, toID)
+	defer unlock()
+	return b.executeTransfer(fromID, toID, amount)
+}
+
+// executeTransfer moves funds between accounts, after checking the core transfer rules, and records the transaction
+// history entry.
+// The caller must hold both accounts' locks but not the bank mutex.
+func (b *Bank) executeTransfer(fromID, toID string, amount account.Money) (string, error) {
+	return b.executeValidated(PendingTransfer{FromID: fromID, ToID: toID, Amount: amount}, nil)
+}
+
+// executeValidated runs a transfer through the validation pipeline with the given rules and, if it passes, moves the
+// funds and records the transaction history entry. A rejected transfer is recorded as failed, with its reason code.
+// The caller must hold both accounts' locks but not the bank mutex.
+func (b *Bank) executeValidated(t PendingTransfer, rules []TransferRule) (string, error) {
+	fromID, toID, amount := t.FromID, t.ToID, t.Amount
+	txnID := b.newTxnID()
+
+	b.mutex.Lock()
+	if err := b.validateTransfer(t, rules); err != nil {
+		b.recordTransaction(txnID, fmt.Sprintf("Transaction ID: %s, From: %s, To: %s, Amount: %s, Status: %s, Reason Code: %s\n", txnID, fromID, toID, amount, "failed", ReasonOf(err)))
+		b.mutex.Unlock()
+		return "", err
+	}
+	fromAcc, toAcc := b.accounts.at(fromID), b.accounts.at(toID)
+	fromCurrency, toCurrency, rates := b.currencyOf(fromID), b.currencyOf(toID), b.rateProvider()
+	b.mutex.Unlock()
+
+	// Create a new transfer transaction with a random transaction ID, converting between currencies if needed
+	txn := transaction.NewTransfer(txnID, fromAcc, toAcc, amount)
+	converted, rate := amount, 1.0
+	if fromCurrency != toCurrency {
+		var err error
+		if converted, rate, err = convert(rates, amount, fromCurrency, toCurrency); err != nil {
+			err = &DeclineError{Code: ReasonCurrency, Message: err.Error(), Err: err}
+			b.mutex.Lock()
+			b.recordTransaction(txnID, fmt.Sprintf("Transaction ID: %s, From: %s, To: %s, Amount: %s %s, Status: %s, Reason Code: %s\n", txnID, fromID, toID, amount, fromCurrency, "failed", ReasonOf(err)))
+			b.mutex.Unlock()
+			return "", err
+		}
+		txn = transaction.NewConversion(txnID, fromAcc, toAcc, amount, converted)
+	}
+
+	// Execute the transfer transaction
+	if err := txn.Execute(); err != nil {
+		b.mutex.Lock()
+		status := "failed"
+		if txn.Status() == transaction.StatusCompensationPending {
+			status = string(transaction.StatusCompensationPending)
+			b.compensate(txnID, fromID, toID, amount, converted, err)
+		}
+		b.recordTransaction(txnID, fmt.Sprintf("Transaction ID: %s, From: %s, To: %s, Amount: %s, Status: %s, Reason Code: %s\n", txnID, fromID, toID, amount, status, ReasonOf(err)))
+		b.mutex.Unlock()
+		return "", err
+	}
+
+	// Add the transaction to the transaction history
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if fromCurrency != toCurrency {
+		b.recordTransaction(txn.ID(), fmt.Sprintf("Transaction ID: %s, From: %s, To: %s, Amount: %s %s, Credited: %s %s, Rate: %.6f, Status: %s\n", txn.ID(), fromAcc.ID(), toAcc.ID(), amount, fromCurrency, converted, toCurrency, rate, "success"))
+		b.recordTravelRule(txnID, t.TravelRule)
+		b.recordEvent(Event{Type: EventTransferred, AccountID: fromID, ToID: toID, Amount: amount, ToAmount: converted, TransactionID: txnID, TravelRule: t.TravelRule})
+		b.recordRepayment(toAcc, txnID)
+		return txnID, nil
+	}
+	b.recordTransaction(txn.ID(), fmt.Sprintf("Transaction ID: %s, From: %s, To: %s, Amount: %s, Status: %s\n", txn.ID(), fromAcc.ID(), toAcc.ID(), amount, "success"))
+	b.recordTravelRule(txnID, t.TravelRule)
+	b.recordEvent(Event{Type: EventTransferred, AccountID: fromID, ToID: toID, Amount: amount, TransactionID: txnID, TravelRule: t.TravelRule})
+	b.recordRepayment(toAcc, txnID)
+
+	return txnID, nil
+}
+
+// NewSavingsAccount creates a savings account and adds it to the bank.
+func (b *Bank) NewSavingsAccount(id string, balance account.Money, interestRate float64) (*account.Savings, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err := b.checkNewAccountID(id); err != nil {
+		return nil, err
+	}
+	acc := account.NewSavings(id, balance, interestRate)
+	b.registerAccount(acc, account.StateOpen)
+	b.recordAccountEvent(EventAccountCreated, acc, Event{})
+	return acc, nil
+}
+
+// NewCheckingAccount creates a checking account with an overdraft limit and adds it to the bank.
+func (b *Bank) NewCheckingAccount(id string, balance, overdraftLimit account.Money, overdraftRate float64) (*account.Checking, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err := b.checkNewAccountID(id); err != nil {
+		return nil, err
+	}
+	acc := account.NewChecking(id, balance, overdraftLimit, overdraftRate)
+	b.registerAccount(acc, account.StateOpen)
+	b.recordAccountEvent(EventAccountCreated, acc, Event{})
+	return acc, nil
+}
@@ -0,0 +1,75 @@
+package bank
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// testClock is a clock tests move forward by hand.
+type testClock struct {
+	now time.Time
+}
+
+// advance moves the clock forward.
+func (c *testClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// newTestBank returns an in-memory bank with counter transaction IDs, a manager called "manager", no notifier and
+// a clock stopped at noon on a weekday, holding a savings account for each balance given, in minor units, with IDs
+// A, B, C and so on.
+func newTestBank(t *testing.T, balances ...account.Money) (*Bank, *testClock) {
+	t.Helper()
+	b, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seq atomic.Int64
+	b.SetIDGenerator(transaction.IDGeneratorFunc(func() string {
+		return fmt.Sprintf("txn-%04d", seq.Add(1))
+	}))
+	clock := &testClock{now: time.Date(2026, time.March, 4, 12, 0, 0, 0, time.UTC)}
+	b.now = func() time.Time { return clock.now }
+	b.AddAdmin("manager")
+	b.SetNotifier(nil)
+	for i, balance := range balances {
+		if err := b.CreateAccount(account.NewSavings(string(rune('A'+i)), balance, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return b, clock
+}
+
+// balanceOf returns an account's balance, failing the test if the account does not exist.
+func balanceOf(t *testing.T, b *Bank, accountID string) account.Money {
+	t.Helper()
+	acc, err := b.GetAccount(accountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return acc.Balance()
+}
+
+func TestNewIDsAreDeterministic(t *testing.T) {
+	b, _ := newTestBank(t, 1000, 0)
+	var got []string
+	for range 2 {
+		txnID, err := b.transfer("A", "B", 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, txnID)
+	}
+	got = append(got, b.newID("hold"))
+	want := []string{"txn-0001", "txn-0002", "hold-0003"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ID %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
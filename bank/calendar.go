@@ -0,0 +1,264 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// CalendarEntryKind identifies what an upcoming calendar entry is.
+type CalendarEntryKind string
+
+const (
+	CalendarScheduledTransfer CalendarEntryKind = "scheduled-transfer" // One-off future-dated transfer, or a retry of a failed one
+	CalendarStandingOrder     CalendarEntryKind = "standing-order"     // Occurrence of a recurring transfer or weekly auto-save
+	CalendarFee               CalendarEntryKind = "fee"                // Fee the fee schedule would charge on a scheduled debit
+	CalendarInterest          CalendarEntryKind = "interest"           // Interest credited, or overdraft interest charged
+	CalendarContribution      CalendarEntryKind = "contribution"       // Recurring deposit contribution
+	CalendarMaturity          CalendarEntryKind = "maturity"           // Recurring or fixed deposit maturity
+	CalendarLoanPayment       CalendarEntryKind = "loan-payment"       // Instalment due on a loan
+)
+
+// CalendarEntry is one item of upcoming activity on an account.
+type CalendarEntry struct {
+	Date         time.Time
+	Kind         CalendarEntryKind
+	AccountID    string
+	Counterparty string        // Other account involved, if any
+	Amount       account.Money // Signed from the account's point of view; zero when not known in advance
+	Summary      string
+	SourceID     string // Schedule, auto-save rule or account the entry comes from
+}
+
+// AccountCalendar lists the activity expected on an account from from up to but not including to, in date order:
+// scheduled transfers and their retries, standing orders, the fees those debits would incur under the current fee
+// schedule, interest postings, recurring deposit contributions, deposit maturities, and loan instalments.
+func (b *Bank) AccountCalendar(accountID string, from, to time.Time) ([]CalendarEntry, error) {
+	if !to.After(from) {
+		return nil, errors.New("calendar must end after it starts")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	acc, exists := b.accounts.get(accountID)
+	if !exists {
+		return nil, errors.New("account does not exist")
+	}
+	within := func(t time.Time) bool { return !t.Before(from) && t.Before(to) }
+
+	var entries []CalendarEntry
+	transferEntry := func(date time.Time, kind CalendarEntryKind, st *ScheduledTransfer, summary string) CalendarEntry {
+		e := CalendarEntry{Date: date, Kind: kind, AccountID: accountID, SourceID: st.ID, Summary: summary}
+		if st.FromID == accountID {
+			e.Counterparty, e.Amount = st.ToID, -st.Amount
+		} else {
+			e.Counterparty, e.Amount = st.FromID, st.Amount
+		}
+		return e
+	}
+	for _, st := range b.schedules {
+		if st.FromID != accountID && st.ToID != accountID {
+			continue
+		}
+		for _, r := range st.Retries {
+			if within(r.NextAttempt) {
+				summary := fmt.Sprintf("Retry of scheduled transfer %s due %s", st.ID, r.DueAt.Format("2006-01-02"))
+				entries = append(entries, transferEntry(r.NextAttempt, CalendarScheduledTransfer, st, summary))
+			}
+		}
+		if st.Status != SchedulePending {
+			continue
+		}
+		kind, summary := CalendarStandingOrder, fmt.Sprintf("Standing order %s (%s)", st.ID, st.Frequency)
+		if st.Frequency == ScheduleOnce {
+			kind, summary = CalendarScheduledTransfer, "Scheduled transfer "+st.ID
+		}
+		for n := st.Occurrences; ; n++ {
+			date := st.occurrence(n)
+			if !date.Before(to) || (!st.Until.IsZero() && date.After(st.Until)) {
+				break
+			}
+			if within(date) {
+				entries = append(entries, transferEntry(date, kind, st, summary))
+			}
+			if st.Frequency == ScheduleOnce {
+				break
+			}
+		}
+	}
+
+	for _, rule := range b.autoSaveRules {
+		if rule.Trigger != WeeklySchedule || (rule.SourceID != accountID && rule.PotID != accountID) {
+			continue
+		}
+		st := &ScheduledTransfer{ID: rule.ID, FromID: rule.SourceID, ToID: rule.PotID, Amount: rule.Amount}
+		for day := startOfDay(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+			if day.Weekday() != rule.Weekday || startOfDay(rule.lastRun).Equal(day) {
+				continue
+			}
+			date := day
+			if date.Before(from) {
+				date = from
+			}
+			entries = append(entries, transferEntry(date, CalendarStandingOrder, st, "Weekly auto-save "+rule.ID))
+		}
+	}
+
+	switch a := acc.(type) {
+	case *account.Savings:
+		if date := a.NextInterestPosting(); within(date) {
+			entries = append(entries, CalendarEntry{Date: date, Kind: CalendarInterest, AccountID: accountID, SourceID: accountID, Summary: "Interest credited"})
+		}
+	case *account.Checking:
+		rec, err := account.ToRecord(a)
+		if err != nil {
+			return nil, err
+		}
+		if date := a.NextInterestPosting(); within(date) && (rec.Balance < 0 || rec.AccruedInterest > 0) {
+			entries = append(entries, CalendarEntry{Date: date, Kind: CalendarInterest, AccountID: accountID, SourceID: accountID, Summary: "Overdraft interest charged"})
+		}
+	case *account.Loan:
+		schedule := a.Schedule()
+		for _, inst := range schedule {
+			if a.PaidOff() || inst.DueDate.Before(a.NextDueDate()) || !within(inst.DueDate) {
+				continue
+			}
+			summary := fmt.Sprintf("Loan instalment %d of %d due", inst.Number, len(schedule))
+			entries = append(entries, CalendarEntry{Date: inst.DueDate, Kind: CalendarLoanPayment, AccountID: accountID, SourceID: accountID, Amount: inst.Payment, Summary: summary})
+		}
+	}
+	for id, other := range b.accounts.all() {
+		if fd, ok := other.(*account.FixedDeposit); ok && b.IsAccountActive(id) && !fd.Released() {
+			if (id == accountID || fd.PayoutAccountID() == accountID) && within(fd.MaturityDate()) {
+				e := CalendarEntry{Date: fd.MaturityDate(), Kind: CalendarMaturity, AccountID: accountID, SourceID: id, Summary: "Fixed deposit " + id + " matures"}
+				if id != accountID {
+					e.Counterparty, e.Amount = id, fd.MaturityAmount()
+				}
+				entries = append(entries, e)
+			}
+			continue
+		}
+		rd, ok := other.(*account.RecurringDeposit)
+		if !ok || !b.IsAccountActive(id) || (id != accountID && rd.FundingAccountID() != accountID) {
+			continue
+		}
+		contributions, maturity := rd.Upcoming()
+		for _, date := range contributions {
+			if !within(date) {
+				continue
+			}
+			e := CalendarEntry{Date: date, Kind: CalendarContribution, AccountID: accountID, SourceID: id, Summary: "Recurring deposit contribution to " + id}
+			if id == accountID {
+				e.Counterparty, e.Amount = rd.FundingAccountID(), rd.MonthlyContribution()
+			} else {
+				e.Counterparty, e.Amount = id, -rd.MonthlyContribution()
+			}
+			entries = append(entries, e)
+		}
+		if id == accountID && within(maturity) {
+			entries = append(entries, CalendarEntry{Date: maturity, Kind: CalendarMaturity, AccountID: accountID, SourceID: id, Summary: "Recurring deposit matures"})
+		}
+	}
+
+	sortCalendar(entries)
+	return b.withProjectedFees(accountID, entries), nil
+}
+
+// withProjectedFees follows each of the account's scheduled debits with the transfer fees the current fee
+// schedule would charge on it, counting the debits against the monthly allowance in date order. Auto-saves and
+// contributions are not charged.
+// The caller must hold the bank mutex.
+func (b *Bank) withProjectedFees(accountID string, entries []CalendarEntry) []CalendarEntry {
+	withFees := make([]CalendarEntry, 0, len(entries))
+	counts := map[string]int{b.now().Format("2006-01"): b.usageCount(accountID, transaction.OpTransfer)}
+	for _, e := range entries {
+		withFees = append(withFees, e)
+		if e.Amount >= 0 || (e.Kind != CalendarScheduledTransfer && e.Kind != CalendarStandingOrder) {
+			continue
+		}
+		if _, isSchedule := b.schedules[e.SourceID]; !isSchedule {
+			continue
+		}
+		month := e.Date.Format("2006-01")
+		applied, _ := transaction.FeesFor(b.feeRules(accountID, transaction.OpTransfer, -e.Amount, RailInternal), transaction.OpTransfer, -e.Amount, counts[month])
+		counts[month]++
+		for _, f := range applied {
+			withFees = append(withFees, CalendarEntry{
+				Date:      e.Date,
+				Kind:      CalendarFee,
+				AccountID: accountID,
+				Amount:    -f.Amount,
+				Summary:   fmt.Sprintf("Fee %s on %s", f.Rule, e.SourceID),
+				SourceID:  e.SourceID,
+			})
+		}
+	}
+	return withFees
+}
+
+// sortCalendar orders entries by date, then kind and source so the order is stable.
+func sortCalendar(entries []CalendarEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Date.Equal(entries[j].Date) {
+			return entries[i].Date.Before(entries[j].Date)
+		}
+		if entries[i].Kind != entries[j].Kind {
+			return entries[i].Kind < entries[j].Kind
+		}
+		return entries[i].SourceID < entries[j].SourceID
+	})
+}
+
+// ExportCalendar writes the account's calendar from from up to to as an iCalendar (RFC 5545) feed.
+func (b *Bank) ExportCalendar(w io.Writer, accountID string, from, to time.Time) error {
+	entries, err := b.AccountCalendar(accountID, from, to)
+	if err != nil {
+		return err
+	}
+	b.mutex.Lock()
+	stamp := b.now().UTC().Format("20060102T150405Z")
+	b.mutex.Unlock()
+
+	var sb strings.Builder
+	line := func(s string) {
+		// Fold lines longer than 75 octets, continuing with a leading space
+		for len(s) > 75 {
+			sb.WriteString(s[:75] + "\r\n")
+			s = " " + s[75:]
+		}
+		sb.WriteString(s + "\r\n")
+	}
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//go-banking-system//Account Calendar//EN")
+	line("X-WR-CALNAME:" + icalText("Account "+accountID))
+	for i, e := range entries {
+		summary := e.Summary
+		if e.Amount != 0 {
+			summary += ": " + e.Amount.String()
+		}
+		line("BEGIN:VEVENT")
+		line(fmt.Sprintf("UID:%s-%s-%d@%s", e.SourceID, e.Date.UTC().Format("20060102T150405Z"), i, accountID))
+		line("DTSTAMP:" + stamp)
+		line("DTSTART:" + e.Date.UTC().Format("20060102T150405Z"))
+		line("SUMMARY:" + icalText(summary))
+		line("CATEGORIES:" + icalText(string(e.Kind)))
+		if e.Counterparty != "" {
+			line("DESCRIPTION:" + icalText("Counterparty: "+e.Counterparty))
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+// icalText escapes a value for an iCalendar TEXT property.
+func icalText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
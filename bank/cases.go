@@ -0,0 +1,231 @@
+package bank
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// CaseStatus tracks a case through handling.
+type CaseStatus string
+
+const (
+	CaseOpen       CaseStatus = "open"
+	CaseInProgress CaseStatus = "in-progress"
+	CaseResolved   CaseStatus = "resolved"
+	CaseClosed     CaseStatus = "closed"
+)
+
+// CaseNote is a timestamped note on a case.
+type CaseNote struct {
+	Time   time.Time
+	Author string
+	Text   string
+}
+
+// Case is a customer dispute or complaint linked to accounts and transactions.
+type Case struct {
+	ID             string
+	Kind           string // e.g. "dispute" or "complaint"
+	Subject        string
+	AccountIDs     []string
+	TransactionIDs []string
+	Assignee       string
+	Status         CaseStatus
+	OpenedAt       time.Time
+	SLADue         time.Time
+	ResolvedAt     time.Time
+	Resolution     string
+	Notes          []CaseNote
+	CorrectiveTxns []string // Transactions made to put things right
+}
+
+// Overdue reports whether the case is unresolved past its SLA.
+func (c Case) Overdue(now time.Time) bool {
+	return (c.Status == CaseOpen || c.Status == CaseInProgress) && now.After(c.SLADue)
+}
+
+// copyCase returns a copy of the case that does not share slices with the stored one.
+func copyCase(c *Case) Case {
+	out := *c
+	out.AccountIDs = append([]string(nil), c.AccountIDs...)
+	out.TransactionIDs = append([]string(nil), c.TransactionIDs...)
+	out.Notes = append([]CaseNote(nil), c.Notes...)
+	out.CorrectiveTxns = append([]string(nil), c.CorrectiveTxns...)
+	return out
+}
+
+// OpenCase opens a case linked to accounts and transactions, due for resolution within the SLA.
+func (b *Bank) OpenCase(kind, subject string, accountIDs, txnIDs []string, sla time.Duration) (Case, error) {
+	if subject == "" {
+		return Case{}, errors.New("case subject must not be empty")
+	}
+	if sla <= 0 {
+		return Case{}, errors.New("SLA must be positive")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, id := range accountIDs {
+		if _, exists := b.accounts.get(id); !exists {
+			return Case{}, errors.New("account " + id + " does not exist")
+		}
+	}
+	for _, id := range txnIDs {
+		if _, exists := b.transactionHist[id]; !exists {
+			return Case{}, errors.New("transaction " + id + " does not exist")
+		}
+	}
+	now := b.now()
+	c := &Case{
+		ID:             b.newID("case"),
+		Kind:           kind,
+		Subject:        subject,
+		AccountIDs:     append([]string(nil), accountIDs...),
+		TransactionIDs: append([]string(nil), txnIDs...),
+		Status:         CaseOpen,
+		OpenedAt:       now,
+		SLADue:         now.Add(sla),
+	}
+	b.cases[c.ID] = c
+	return copyCase(c), nil
+}
+
+// GetCase retrieves a case.
+func (b *Bank) GetCase(caseID string) (Case, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	c, exists := b.cases[caseID]
+	if !exists {
+		return Case{}, errors.New("case does not exist")
+	}
+	return copyCase(c), nil
+}
+
+// openCase looks up a case that is still being worked.
+// The caller must hold the bank mutex.
+func (b *Bank) openCase(caseID string) (*Case, error) {
+	c, exists := b.cases[caseID]
+	if !exists {
+		return nil, errors.New("case does not exist")
+	}
+	if c.Status == CaseResolved || c.Status == CaseClosed {
+		return nil, errors.New("case is " + string(c.Status))
+	}
+	return c, nil
+}
+
+// AssignCase assigns a case to a staff member and marks it in progress.
+func (b *Bank) AssignCase(caseID, staffID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	c, err := b.openCase(caseID)
+	if err != nil {
+		return err
+	}
+	c.Assignee = staffID
+	c.Status = CaseInProgress
+	c.Notes = append(c.Notes, CaseNote{Time: b.now(), Author: staffID, Text: "assigned to " + staffID})
+	return nil
+}
+
+// AddCaseNote adds a note to a case.
+func (b *Bank) AddCaseNote(caseID, author, text string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	c, exists := b.cases[caseID]
+	if !exists {
+		return errors.New("case does not exist")
+	}
+	c.Notes = append(c.Notes, CaseNote{Time: b.now(), Author: author, Text: text})
+	return nil
+}
+
+// LinkCorrectiveTransaction links an existing transaction to the case as a correction.
+func (b *Bank) LinkCorrectiveTransaction(caseID, txnID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	c, err := b.openCase(caseID)
+	if err != nil {
+		return err
+	}
+	if _, exists := b.transactionHist[txnID]; !exists {
+		return errors.New("transaction does not exist")
+	}
+	c.CorrectiveTxns = append(c.CorrectiveTxns, txnID)
+	b.annotateTransaction(txnID, "Case: "+caseID)
+	return nil
+}
+
+// CorrectiveTransfer moves funds to put a case right and links the transfer to the case.
+func (b *Bank) CorrectiveTransfer(caseID, fromID, toID string, amount account.Money) (string, error) {
+	b.mutex.Lock()
+	_, err := b.openCase(caseID)
+	b.mutex.Unlock()
+	if err != nil {
+		return "", err
+	}
+
+	txnID, err := b.moveFunds(fromID, toID, amount)
+	if err != nil {
+		return "", err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	// The case is looked up again since it may have changed while the lock was released
+	c := b.cases[caseID]
+	c.CorrectiveTxns = append(c.CorrectiveTxns, txnID)
+	b.annotateTransaction(txnID, "Case: "+caseID)
+	return txnID, nil
+}
+
+// ResolveCase records the resolution and stops the SLA timer.
+func (b *Bank) ResolveCase(caseID, author, resolution string) error {
+	if resolution == "" {
+		return errors.New("resolution must not be empty")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	c, err := b.openCase(caseID)
+	if err != nil {
+		return err
+	}
+	now := b.now()
+	c.Status = CaseResolved
+	c.ResolvedAt = now
+	c.Resolution = resolution
+	c.Notes = append(c.Notes, CaseNote{Time: now, Author: author, Text: "resolved: " + resolution})
+	return nil
+}
+
+// CloseCase closes a resolved case.
+func (b *Bank) CloseCase(caseID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	c, exists := b.cases[caseID]
+	if !exists {
+		return errors.New("case does not exist")
+	}
+	if c.Status != CaseResolved {
+		return errors.New("only resolved cases can be closed")
+	}
+	c.Status = CaseClosed
+	return nil
+}
+
+// OverdueCases lists unresolved cases past their SLA, most overdue first.
+func (b *Bank) OverdueCases() []Case {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	now := b.now()
+	var overdue []Case
+	for _, c := range b.cases {
+		if c.Overdue(now) {
+			overdue = append(overdue, copyCase(c))
+		}
+	}
+	sort.Slice(overdue, func(i, j int) bool { return overdue[i].SLADue.Before(overdue[j].SLADue) })
+	return overdue
+}
@@ -0,0 +1,345 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Contact holds the addresses a customer can be reached at.
+type Contact struct {
+	Name  string
+	Email string
+	Phone string // E.164, e.g. +15551234567
+}
+
+// ContactDirectory finds how to reach a customer.
+type ContactDirectory interface {
+	Contact(customerID string) (Contact, error)
+}
+
+// StaticContacts is a ContactDirectory backed by a map of customer ID to contact details.
+type StaticContacts map[string]Contact
+
+// Contact looks up a customer's contact details.
+func (sc StaticContacts) Contact(customerID string) (Contact, error) {
+	c, exists := sc[customerID]
+	if !exists {
+		return Contact{}, errors.New("no contact details for customer " + customerID)
+	}
+	return c, nil
+}
+
+// NotificationTemplate formats one kind of notification using text/template. Templates see the fields of
+// NotificationData. Subject is only used by channels that have one, such as email.
+type NotificationTemplate struct {
+	Subject string
+	Body    string
+}
+
+// NotificationData is what notification templates are executed against.
+type NotificationData struct {
+	CustomerID string
+	Name       string
+	Kind       NotificationKind
+	Message    string
+}
+
+// defaultNotificationTemplate is used for kinds without a template of their own.
+var defaultNotificationTemplate = NotificationTemplate{
+	Subject: "Notification from your bank",
+	Body:    "{{if .Name}}Hello {{.Name}},\n\n{{end}}{{.Message}}",
+}
+
+// NotificationTemplates parses and renders a template per notification kind.
+type NotificationTemplates struct {
+	subjects map[NotificationKind]*template.Template
+	bodies   map[NotificationKind]*template.Template
+}
+
+// NewNotificationTemplates parses templates keyed by notification kind. Kinds without a template fall back to a
+// plain greeting and the message.
+func NewNotificationTemplates(templates map[NotificationKind]NotificationTemplate) (*NotificationTemplates, error) {
+	nt := &NotificationTemplates{
+		subjects: make(map[NotificationKind]*template.Template),
+		bodies:   make(map[NotificationKind]*template.Template),
+	}
+	all := map[NotificationKind]NotificationTemplate{"": defaultNotificationTemplate}
+	for kind, t := range templates {
+		all[kind] = t
+	}
+	for kind, t := range all {
+		subject, err := template.New(string(kind) + " subject").Parse(t.Subject)
+		if err != nil {
+			return nil, err
+		}
+		body, err := template.New(string(kind) + " body").Parse(t.Body)
+		if err != nil {
+			return nil, err
+		}
+		nt.subjects[kind], nt.bodies[kind] = subject, body
+	}
+	return nt, nil
+}
+
+// Render formats a notification, returning its subject and body.
+func (nt *NotificationTemplates) Render(data NotificationData) (subject, body string, err error) {
+	kind := data.Kind
+	if _, exists := nt.bodies[kind]; !exists {
+		kind = ""
+	}
+	var sb strings.Builder
+	if err := nt.subjects[kind].Execute(&sb, data); err != nil {
+		return "", "", err
+	}
+	subject = sb.String()
+	sb.Reset()
+	if err := nt.bodies[kind].Execute(&sb, data); err != nil {
+		return "", "", err
+	}
+	return subject, sb.String(), nil
+}
+
+// DeliveryStatus is the outcome of sending a notification.
+type DeliveryStatus string
+
+const (
+	DeliverySent   DeliveryStatus = "sent"
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// Delivery records one notification sent, or attempted, through a channel.
+type Delivery struct {
+	ID         string
+	Channel    string // "email" or "sms"
+	CustomerID string
+	Kind       NotificationKind
+	To         string // Address or number the notification was sent to, once known
+	Message    string
+	Status     DeliveryStatus
+	Attempts   int
+	ProviderID string // Gateway's message ID, when it returns one
+	Err        string
+	At         time.Time // Time of the last attempt
+}
+
+// deliveryLog tracks every delivery a channel attempts and keeps failed ones as dead letters for retry.
+type deliveryLog struct {
+	channel     string
+	maxAttempts int
+	seq         int
+	deliveries  []Delivery
+	deadLetters []Delivery
+	mutex       sync.Mutex
+}
+
+// attempt sends a notification up to maxAttempts times, recording the outcome. Deliveries that still fail are
+// added to the dead letters.
+func (dl *deliveryLog) attempt(d Delivery, send func(d *Delivery) (string, error)) error {
+	attempts := dl.maxAttempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+	var err error
+	for i := 0; i < attempts; i++ {
+		d.Attempts++
+		if d.ProviderID, err = send(&d); err == nil {
+			break
+		}
+	}
+	d.At = time.Now()
+	d.Status, d.Err = DeliverySent, ""
+	if err != nil {
+		d.Status, d.Err = DeliveryFailed, err.Error()
+	}
+
+	dl.mutex.Lock()
+	defer dl.mutex.Unlock()
+	if d.ID == "" {
+		dl.seq++
+		d.ID = dl.channel + "-" + strconv.Itoa(dl.seq)
+	}
+	dl.deliveries = append(dl.deliveries, d)
+	if err != nil {
+		dl.deadLetters = append(dl.deadLetters, d)
+	}
+	return err
+}
+
+// Deliveries returns every delivery attempted, oldest first. Retried dead letters appear once per retry.
+func (dl *deliveryLog) Deliveries() []Delivery {
+	dl.mutex.Lock()
+	defer dl.mutex.Unlock()
+	return append([]Delivery(nil), dl.deliveries...)
+}
+
+// DeadLetters returns the deliveries that failed and have not been retried successfully.
+func (dl *deliveryLog) DeadLetters() []Delivery {
+	dl.mutex.Lock()
+	defer dl.mutex.Unlock()
+	return append([]Delivery(nil), dl.deadLetters...)
+}
+
+// takeDeadLetters empties the dead letters for a retry.
+func (dl *deliveryLog) takeDeadLetters() []Delivery {
+	dl.mutex.Lock()
+	defer dl.mutex.Unlock()
+	dead := dl.deadLetters
+	dl.deadLetters = nil
+	return dead
+}
+
+// SMTPNotifier emails notifications through an SMTP server.
+type SMTPNotifier struct {
+	deliveryLog
+	addr      string // host:port of the SMTP server
+	auth      smtp.Auth
+	from      string
+	contacts  ContactDirectory
+	templates *NotificationTemplates
+	sendMail  func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPNotifier creates a notifier that emails customers found in contacts through the server at addr, trying
+// each message up to maxAttempts times. Auth may be nil for servers that do not require it, and templates nil for
+// the default formatting.
+func NewSMTPNotifier(addr string, auth smtp.Auth, from string, contacts ContactDirectory, templates *NotificationTemplates, maxAttempts int) *SMTPNotifier {
+	if templates == nil {
+		templates, _ = NewNotificationTemplates(nil)
+	}
+	return &SMTPNotifier{
+		deliveryLog: deliveryLog{channel: "email", maxAttempts: maxAttempts},
+		addr:        addr,
+		auth:        auth,
+		from:        from,
+		contacts:    contacts,
+		templates:   templates,
+		sendMail:    smtp.SendMail,
+	}
+}
+
+// Notify emails an untyped notification.
+func (sn *SMTPNotifier) Notify(customerID, message string) error {
+	return sn.NotifyKind(customerID, "", message)
+}
+
+// NotifyKind emails a notification formatted with the template for its kind.
+func (sn *SMTPNotifier) NotifyKind(customerID string, kind NotificationKind, message string) error {
+	return sn.send(Delivery{Channel: "email", CustomerID: customerID, Kind: kind, Message: message})
+}
+
+// RetryDeadLetters resends every failed email, returning how many were delivered.
+func (sn *SMTPNotifier) RetryDeadLetters() int {
+	delivered := 0
+	for _, d := range sn.takeDeadLetters() {
+		if sn.send(d) == nil {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// send resolves the recipient, renders the email and sends it.
+func (sn *SMTPNotifier) send(d Delivery) error {
+	return sn.attempt(d, func(d *Delivery) (string, error) {
+		c, err := sn.contacts.Contact(d.CustomerID)
+		if err != nil {
+			return "", err
+		}
+		if c.Email == "" {
+			return "", errors.New("customer " + d.CustomerID + " has no email address")
+		}
+		d.To = c.Email
+		subject, body, err := sn.templates.Render(NotificationData{CustomerID: d.CustomerID, Name: c.Name, Kind: d.Kind, Message: d.Message})
+		if err != nil {
+			return "", err
+		}
+		msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+			sn.from, c.Email, headerValue(subject), time.Now().Format(time.RFC1123Z), strings.ReplaceAll(body, "\n", "\r\n"))
+		return "", sn.sendMail(sn.addr, sn.auth, sn.from, []string{c.Email}, []byte(msg))
+	})
+}
+
+// headerValue keeps a value on one header line.
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// SMSGateway sends text messages through an SMS provider.
+type SMSGateway interface {
+	// Send delivers text to a phone number and returns the provider's message ID.
+	Send(phone, text string) (string, error)
+}
+
+// SMSNotifier texts notifications through an SMS gateway.
+type SMSNotifier struct {
+	deliveryLog
+	gateway   SMSGateway
+	contacts  ContactDirectory
+	templates *NotificationTemplates
+	maxLength int // Longest text sent; longer bodies are truncated. 0 means no limit
+}
+
+// NewSMSNotifier creates a notifier that texts customers found in contacts through gateway, trying each message
+// up to maxAttempts times and truncating texts longer than maxLength characters. Templates may be nil for the
+// default formatting.
+func NewSMSNotifier(gateway SMSGateway, contacts ContactDirectory, templates *NotificationTemplates, maxAttempts, maxLength int) *SMSNotifier {
+	if templates == nil {
+		templates, _ = NewNotificationTemplates(nil)
+	}
+	return &SMSNotifier{
+		deliveryLog: deliveryLog{channel: "sms", maxAttempts: maxAttempts},
+		gateway:     gateway,
+		contacts:    contacts,
+		templates:   templates,
+		maxLength:   maxLength,
+	}
+}
+
+// Notify texts an untyped notification.
+func (sn *SMSNotifier) Notify(customerID, message string) error {
+	return sn.NotifyKind(customerID, "", message)
+}
+
+// NotifyKind texts a notification formatted with the template for its kind.
+func (sn *SMSNotifier) NotifyKind(customerID string, kind NotificationKind, message string) error {
+	return sn.send(Delivery{Channel: "sms", CustomerID: customerID, Kind: kind, Message: message})
+}
+
+// RetryDeadLetters resends every failed text, returning how many were delivered.
+func (sn *SMSNotifier) RetryDeadLetters() int {
+	delivered := 0
+	for _, d := range sn.takeDeadLetters() {
+		if sn.send(d) == nil {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// send resolves the recipient, renders the text and hands it to the gateway.
+func (sn *SMSNotifier) send(d Delivery) error {
+	return sn.attempt(d, func(d *Delivery) (string, error) {
+		c, err := sn.contacts.Contact(d.CustomerID)
+		if err != nil {
+			return "", err
+		}
+		if c.Phone == "" {
+			return "", errors.New("customer " + d.CustomerID + " has no phone number")
+		}
+		d.To = c.Phone
+		_, body, err := sn.templates.Render(NotificationData{CustomerID: d.CustomerID, Name: c.Name, Kind: d.Kind, Message: d.Message})
+		if err != nil {
+			return "", err
+		}
+		if runes := []rune(body); sn.maxLength > 0 && len(runes) > sn.maxLength {
+			body = string(runes[:sn.maxLength-1]) + "…"
+		}
+		return sn.gateway.Send(c.Phone, body)
+	})
+}
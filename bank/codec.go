@@ -0,0 +1,270 @@
+package bank
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// Compression selects how snapshot chunks and exports are compressed.
+type Compression string
+
+const (
+	CompressionNone Compression = "none"
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd" // needs a codec registered with RegisterCompressor
+)
+
+// Encoding selects how account records are serialized.
+type Encoding string
+
+const (
+	EncodingJSON   Encoding = "json"   // one JSON document per line; human readable
+	EncodingBinary Encoding = "binary" // gob stream; smaller and faster to decode
+)
+
+// FormatOptions controls the on-disk format of snapshots and exports. The zero value writes uncompressed JSON.
+// Readers detect the format from the data, so files written with any options can be loaded.
+type FormatOptions struct {
+	Compression Compression
+	Encoding    Encoding
+}
+
+// Compressor wraps streams in a compression format. Magic is the prefix every compressed stream starts
+// with and is used to detect the format on load.
+type Compressor struct {
+	Magic     []byte
+	NewWriter func(w io.Writer) (io.WriteCloser, error)
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	compressorsMutex = &sync.Mutex{}
+	compressors      = map[Compression]Compressor{
+		CompressionGzip: {
+			Magic:     []byte{0x1f, 0x8b},
+			NewWriter: func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil },
+			NewReader: func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+		},
+	}
+)
+
+// zstdMagic is the frame header of a zstd stream, recognised even when no zstd codec is registered so the
+// error explains what is missing.
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// RegisterCompressor makes a compression format available for writing and detection. The standard library
+// has no zstd implementation, so deployments that want zstd register one here.
+func RegisterCompressor(name Compression, c Compressor) {
+	compressorsMutex.Lock()
+	defer compressorsMutex.Unlock()
+	compressors[name] = c
+}
+
+// lookupCompressor returns the registered compressor for a format.
+func lookupCompressor(name Compression) (Compressor, error) {
+	compressorsMutex.Lock()
+	defer compressorsMutex.Unlock()
+	c, ok := compressors[name]
+	if !ok {
+		return Compressor{}, errors.New("no codec registered for " + string(name) + " compression")
+	}
+	return c, nil
+}
+
+// validate checks that the options name known formats.
+func (o FormatOptions) validate() error {
+	switch o.Encoding {
+	case "", EncodingJSON, EncodingBinary:
+	default:
+		return errors.New("unknown encoding " + string(o.Encoding))
+	}
+	if o.Compression == "" || o.Compression == CompressionNone {
+		return nil
+	}
+	_, err := lookupCompressor(o.Compression)
+	return err
+}
+
+// extension returns the file name suffix for the options, e.g. ".jsonl.gz".
+func (o FormatOptions) extension() string {
+	ext := ".jsonl"
+	if o.Encoding == EncodingBinary {
+		ext = ".gob"
+	}
+	switch o.Compression {
+	case CompressionGzip:
+		ext += ".gz"
+	case CompressionZstd:
+		ext += ".zst"
+	}
+	return ext
+}
+
+// recordWriter streams account records in a chosen format.
+type recordWriter struct {
+	compressor io.WriteCloser // nil when uncompressed
+	buffered   *bufio.Writer
+	encode     func(v any) error
+}
+
+// newRecordWriter starts a record stream on w using the given options.
+func newRecordWriter(w io.Writer, opts FormatOptions) (*recordWriter, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	rw := &recordWriter{}
+	if opts.Compression != "" && opts.Compression != CompressionNone {
+		c, err := lookupCompressor(opts.Compression)
+		if err != nil {
+			return nil, err
+		}
+		if rw.compressor, err = c.NewWriter(w); err != nil {
+			return nil, err
+		}
+		w = rw.compressor
+	}
+	rw.buffered = bufio.NewWriter(w)
+	if opts.Encoding == EncodingBinary {
+		rw.encode = gob.NewEncoder(rw.buffered).Encode
+	} else {
+		rw.encode = json.NewEncoder(rw.buffered).Encode
+	}
+	return rw, nil
+}
+
+// Write appends one record to the stream.
+func (rw *recordWriter) Write(rec account.Record) error {
+	return rw.encode(rec)
+}
+
+// Close flushes buffered data and finishes the compressed stream. It does not close the underlying writer.
+func (rw *recordWriter) Close() error {
+	if err := rw.buffered.Flush(); err != nil {
+		return err
+	}
+	if rw.compressor != nil {
+		return rw.compressor.Close()
+	}
+	return nil
+}
+
+// decompressed returns the content of a stream, decompressing it if its first bytes show it is compressed. The
+// caller must call the returned function once done reading.
+func decompressed(br *bufio.Reader) (*bufio.Reader, func(), error) {
+	head, _ := br.Peek(4)
+	if bytes.HasPrefix(head, zstdMagic) {
+		if _, err := lookupCompressor(CompressionZstd); err != nil {
+			return nil, nil, errors.New("data is zstd compressed but no zstd codec is registered")
+		}
+	}
+	compressorsMutex.Lock()
+	var detected *Compressor
+	for _, c := range compressors {
+		if len(c.Magic) > 0 && bytes.HasPrefix(head, c.Magic) {
+			detected = &c
+			break
+		}
+	}
+	compressorsMutex.Unlock()
+	if detected == nil {
+		return br, func() {}, nil
+	}
+	dr, err := detected.NewReader(br)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bufio.NewReader(dr), func() { dr.Close() }, nil
+}
+
+// readRecords decodes a record stream, detecting compression and encoding from its first bytes.
+func readRecords(r io.Reader) ([]account.Record, error) {
+	br := bufio.NewReader(r)
+	content, done, err := decompressed(br)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	first, err := peekNonSpace(content)
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// JSON records always open with a brace. A gob stream opens with a multi-byte length prefix, since the
+	// account.Record type definition is longer than 127 bytes, so it can never start with one.
+	var records []account.Record
+	if first == '{' {
+		dec := json.NewDecoder(content)
+		for {
+			var rec account.Record
+			if err := dec.Decode(&rec); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			records = append(records, rec)
+		}
+	} else {
+		dec := gob.NewDecoder(content)
+		for {
+			var rec account.Record
+			if err := dec.Decode(&rec); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			records = append(records, rec)
+		}
+	}
+	// Drain anything the decoders left so callers hashing the raw stream see all of it.
+	_, err = io.Copy(io.Discard, br)
+	return records, err
+}
+
+// peekNonSpace skips leading whitespace and returns the next byte without consuming it.
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\n' && b != '\r' && b != '\t' {
+			return b, r.UnreadByte()
+		}
+	}
+}
+
+// ExportAccounts writes every account record to w in the requested format and returns how many were written.
+func (b *Bank) ExportAccounts(w io.Writer, opts FormatOptions) (int, error) {
+	b.mutex.Lock()
+	records, err := b.accountRecords()
+	b.mutex.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	rw, err := newRecordWriter(w, opts)
+	if err != nil {
+		return 0, err
+	}
+	for _, rec := range records {
+		if err := rw.Write(rec); err != nil {
+			return 0, err
+		}
+	}
+	return len(records), rw.Close()
+}
+
+// ReadAccountExport reads an export written by ExportAccounts in any supported format.
+func ReadAccountExport(r io.Reader) ([]account.Record, error) {
+	return readRecords(r)
+}
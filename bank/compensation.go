@@ -0,0 +1,119 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// Compensation is a transfer that debited its source but neither credited its destination nor refunded the source.
+// The money is held in suspense until a manager resolves it by refunding the source or completing the credit.
+// Compensations are derived from the event log, so they survive restarts.
+type Compensation struct {
+	TransactionID string
+	FromID        string
+	ToID          string        // "" for a split transfer, which can only be refunded
+	Amount        account.Money // Debited from the source
+	Credit        account.Money // Due to the destination, in its currency
+	Err           string        // Why the credit and the refund failed
+	At            time.Time
+}
+
+// compensate records the debit of a transfer left compensation-pending in the event log, so the amount is held in
+// suspense until ResolveCompensation. The caller records the transaction history entry.
+// The caller must hold the source account's lock and the bank mutex.
+func (b *Bank) compensate(txnID, fromID, toID string, amount, credit account.Money, err error) {
+	e := Event{Type: EventCompensationPending, AccountID: fromID, ToID: toID, Amount: amount, TransactionID: txnID, Reason: err.Error()}
+	if credit != amount {
+		e.ToAmount = credit
+	}
+	b.recordEvent(e)
+}
+
+// Compensations lists the transfers awaiting manual resolution, oldest first.
+func (b *Bank) Compensations() []Compensation {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	pending := b.pendingCompensations()
+	list := make([]Compensation, 0, len(pending))
+	for _, c := range pending {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].At.Before(list[j].At) })
+	return list
+}
+
+// SuspenseBalance is the total debited by transfers awaiting compensation: money that has left customer accounts
+// but not yet reached any.
+func (b *Bank) SuspenseBalance() account.Money {
+	var total account.Money
+	for _, c := range b.Compensations() {
+		total += c.Amount
+	}
+	return total
+}
+
+// pendingCompensations replays the compensation events, returning the unresolved ones by transaction ID.
+// The caller must hold the bank mutex.
+func (b *Bank) pendingCompensations() map[string]Compensation {
+	pending := make(map[string]Compensation)
+	for _, e := range b.events {
+		switch e.Type {
+		case EventCompensationPending:
+			credit := e.Amount
+			if e.ToAmount != 0 {
+				credit = e.ToAmount
+			}
+			pending[e.TransactionID] = Compensation{TransactionID: e.TransactionID, FromID: e.AccountID, ToID: e.ToID, Amount: e.Amount, Credit: credit, Err: e.Reason, At: e.At}
+		case EventCompensationResolved:
+			delete(pending, e.TransactionID)
+		}
+	}
+	return pending
+}
+
+// ResolveCompensation settles a compensation-pending transfer by refunding its source or, if complete is true,
+// crediting its destination. Only admins and managers may resolve transfers. If the credit fails again the transfer
+// stays pending.
+func (b *Bank) ResolveCompensation(staffID, txnID string, complete bool) error {
+	b.mutex.Lock()
+	if !b.isManager(staffID) {
+		b.mutex.Unlock()
+		return decline(ReasonNotAuthorized, "only admins and managers may resolve transfers")
+	}
+	c, exists := b.pendingCompensations()[txnID]
+	b.mutex.Unlock()
+	if !exists {
+		return errors.New("transfer is not awaiting compensation")
+	}
+
+	accountID, amount, resolution := c.FromID, c.Amount, "refunded"
+	if complete && c.ToID == "" {
+		return errors.New("transfer has no single destination to complete; refund it instead")
+	}
+	if complete {
+		accountID, amount, resolution = c.ToID, c.Credit, "completed"
+	}
+	unlock := b.lockAccounts(accountID)
+	defer unlock()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	// Another manager may have resolved it while the lock was taken
+	if _, exists := b.pendingCompensations()[txnID]; !exists {
+		return errors.New("transfer is not awaiting compensation")
+	}
+	acc, exists := b.accounts.get(accountID)
+	if !exists {
+		return decline(ReasonAccountNotFound, "account "+accountID+" does not exist")
+	}
+	if err := acc.Deposit(amount); err != nil {
+		return err
+	}
+	b.recordEvent(Event{Type: EventCompensationResolved, AccountID: accountID, Amount: amount, TransactionID: txnID, Reason: resolution})
+	b.annotateTransaction(txnID, fmt.Sprintf("Resolution: %s by %s", resolution, staffID))
+	b.auditAction(staffID, "ResolveCompensation", accountID, txnID, resolution)
+	return nil
+}
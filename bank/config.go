@@ -0,0 +1,626 @@
+package bank
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// Config is the bank's runtime configuration. It can be replaced while the bank is running, e.g. on SIGHUP, without
+// interrupting operations in progress: a new configuration is validated in full and then swapped in at once, so no
+// operation sees half of it.
+type Config struct {
+	Fees       []transaction.FeeRule                       `json:"fees"`
+	Limits     map[transaction.OperationType]account.Money `json:"limits"`     // Largest single operation of each type, in minor units
+	Benchmarks map[string]float64                          `json:"benchmarks"` // Map of benchmark name, e.g. "base", to annual rate in percent
+	Holidays   []string                                    `json:"holidays"`   // Dates, as YYYY-MM-DD, that are not business days
+	Sanctions  []SanctionsList                             `json:"sanctions"`  // Lists customer and payee names are screened against
+	Corridors  CorridorRules                               `json:"corridors"`  // Restrictions on cross-border transfers
+	APIPlans   map[string]APIPlan                          `json:"apiPlans"`   // Map of plan name to API quotas and prices
+	APIClients map[string]string                           `json:"apiClients"` // Map of API client to plan name; see DefaultAPIPlan
+	// Similarity, from 0 to 1, from which a name matches a list entry; 0 means DefaultScreeningThreshold
+	ScreeningThreshold float64 `json:"screeningThreshold"`
+	// Transfers of more than this amount must carry originator and beneficiary details; 0 means none need them
+	TravelRuleThreshold account.Money `json:"travelRuleThreshold"`
+	// Map of channel to limits that replace Limits for operations coming through it, e.g. lower ones for the API
+	ChannelLimits map[Channel]map[transaction.OperationType]account.Money `json:"channelLimits"`
+	// How long daily limits a customer raises take to come into effect, e.g. "24h"; "" means DefaultLimitCoolingOff
+	LimitCoolingOff string `json:"limitCoolingOff"`
+	// Whether transfers held back by PauseTransfers are rejected or queued; "" means PauseReject
+	PausedTransfers PauseAction `json:"pausedTransfers"`
+	// Whether customer transfers to accounts their owners do not hold must go to a registered payee
+	RequirePayees bool `json:"requirePayees"`
+	// How long transfers to a newly registered payee are held back, e.g. "24h"; "" means DefaultPayeeCoolingOff
+	PayeeCoolingOff string `json:"payeeCoolingOff"`
+	// Conditions customer operations are declined, alerted on or sent for review by, checked in order
+	Rules []ExpressionRule `json:"rules"`
+	// Map of rail to its rules, e.g. an ATM daily cash limit or approval for wires
+	Rails map[Rail]RailRules `json:"rails"`
+	// Whether accounts with money in them are refused closure or swept into another account; "" means ClosureReject
+	ClosurePolicy ClosurePolicy `json:"closurePolicy"`
+	// Map of customer tax status to the percentage of interest credits withheld as tax; statuses not listed, and
+	// customers without one (TaxStatusResident), have nothing withheld
+	Withholding map[TaxStatus]float64 `json:"withholding"`
+	// Account withheld tax is moved to; required when any withholding rate is positive
+	TaxAccount string `json:"taxAccount"`
+	// Map of customer segment to its preferential fees, limits and savings rates; see SetSegment
+	Segments map[Segment]SegmentPricing `json:"segments"`
+	// Up to 8 capital letters that start generated account numbers; "" means DefaultAccountPrefix
+	AccountPrefix string `json:"accountPrefix"`
+	// Tiers customers are reviewed into each month by their total balances, for better savings rates and fee waivers
+	RelationshipTiers []RelationshipTier `json:"relationshipTiers"`
+	// Map of product name to the terms savings and checking accounts can be converted to; see ConvertAccount
+	Products map[string]Product `json:"products"`
+	// Map of account type, e.g. savings, to the balance its accounts must keep after withdrawals and transfers
+	MinimumBalances map[string]MinimumBalance `json:"minimumBalances"`
+	// Fee and limits of the transfers covering checking withdrawals from linked savings; see SetOverdraftProtection
+	OverdraftProtection OverdraftProtection `json:"overdraftProtection"`
+	// Map of operation to the smallest and largest amounts it accepts at all, whatever the limits; see AmountPolicy
+	AmountPolicies map[transaction.OperationType]AmountPolicy `json:"amountPolicies"`
+}
+
+// configSnapshot is the validated form of the parts of a Config that are not kept elsewhere.
+type configSnapshot struct {
+	limits     map[transaction.OperationType]account.Money
+	benchmarks map[string]float64
+	holidays   map[string]bool
+	lists      []SanctionsList
+	sanctions  []sanctionsEntry
+	threshold  float64                  // Sanctions screening similarity threshold
+	blocked    map[string]bool          // Countries cross-border transfers are blocked to
+	eddLimits  map[string]account.Money // Map of country, or "*", to its enhanced due diligence threshold
+	travelRule account.Money            // Travel rule threshold
+	byChannel  map[Channel]map[transaction.OperationType]account.Money
+	coolingOff time.Duration // Delay before customer-raised daily limits take effect
+	paused     PauseAction   // What happens to transfers while they are paused
+	closure    ClosurePolicy // What happens to the balance of an account being closed
+	payeesOnly bool          // Whether customer transfers must go to their own accounts or registered payees
+	payeeWait  time.Duration // Delay before transfers to a new payee are allowed
+	rules      []expressionRule
+	rails      map[Rail]RailRules
+	apiPlans   map[string]APIPlan
+	apiClients map[string]string     // Map of API client to plan name
+	taxRates   map[TaxStatus]float64 // Map of tax status to the percentage of interest withheld
+	taxAccount string                // Account withheld tax is moved to
+	segments   map[Segment]SegmentPricing
+	idPrefix   string // Prefix of generated account numbers
+	tiers      []RelationshipTier
+	products   map[string]Product
+	minimums   map[string]MinimumBalance
+	protection OverdraftProtection
+	amounts    map[transaction.OperationType]AmountPolicy
+}
+
+// LoadConfig reads a configuration file. Unknown fields are rejected so typos do not silently drop settings.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var c Config
+	if err := dec.Decode(&c); err != nil {
+		return Config{}, fmt.Errorf("config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// validFeeRules checks fee rules are for known operations, do not pay customers and have valid conditions.
+func validFeeRules(rules []transaction.FeeRule) error {
+	for _, r := range rules {
+		if r.Fixed < 0 || r.Percent < 0 {
+			return errors.New("fee rule " + r.Name + " must not be negative")
+		}
+		if !operationTypes[r.Operation] {
+			return errors.New("fee rule " + r.Name + " has unknown operation " + string(r.Operation))
+		}
+		if r.When != "" {
+			if _, err := ParseExpression(r.When); err != nil {
+				return errors.New("fee rule " + r.Name + ": " + err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// operationTypes are the operations fees and limits can apply to.
+var operationTypes = map[transaction.OperationType]bool{
+	transaction.OpDeposit:    true,
+	transaction.OpWithdrawal: true,
+	transaction.OpTransfer:   true,
+}
+
+// Validate checks the whole configuration, returning the first problem found.
+func (c Config) Validate() error {
+	_, err := c.snapshot()
+	return err
+}
+
+// snapshot validates the configuration and builds its runtime form.
+func (c Config) snapshot() (*configSnapshot, error) {
+	if err := validFeeRules(c.Fees); err != nil {
+		return nil, err
+	}
+	s := &configSnapshot{
+		limits:     make(map[transaction.OperationType]account.Money, len(c.Limits)),
+		benchmarks: make(map[string]float64, len(c.Benchmarks)),
+		holidays:   make(map[string]bool, len(c.Holidays)),
+	}
+	for op, limit := range c.Limits {
+		if !operationTypes[op] {
+			return nil, errors.New("limit for unknown operation " + string(op))
+		}
+		if limit <= 0 {
+			return nil, errors.New("limit for " + string(op) + " must be positive")
+		}
+		s.limits[op] = limit
+	}
+	s.byChannel = make(map[Channel]map[transaction.OperationType]account.Money, len(c.ChannelLimits))
+	for channel, limits := range c.ChannelLimits {
+		if !channels[channel] {
+			return nil, errors.New("limits for unknown channel " + string(channel))
+		}
+		s.byChannel[channel] = make(map[transaction.OperationType]account.Money, len(limits))
+		for op, limit := range limits {
+			if !operationTypes[op] {
+				return nil, errors.New(string(channel) + " limit for unknown operation " + string(op))
+			}
+			if limit <= 0 {
+				return nil, errors.New(string(channel) + " limit for " + string(op) + " must be positive")
+			}
+			s.byChannel[channel][op] = limit
+		}
+	}
+	for name, rate := range c.Benchmarks {
+		if name == "" {
+			return nil, errors.New("benchmark name must not be empty")
+		}
+		if math.IsNaN(rate) || math.IsInf(rate, 0) || rate <= -100 {
+			return nil, errors.New("benchmark " + name + " rate is out of range")
+		}
+		s.benchmarks[name] = rate
+	}
+	for _, day := range c.Holidays {
+		if _, err := time.Parse("2006-01-02", day); err != nil {
+			return nil, errors.New("holiday " + day + " is not a date in YYYY-MM-DD form")
+		}
+		s.holidays[day] = true
+	}
+	if c.ScreeningThreshold < 0 || c.ScreeningThreshold > 1 {
+		return nil, errors.New("screening threshold must be between 0 and 1")
+	}
+	s.threshold = c.ScreeningThreshold
+	if s.threshold == 0 {
+		s.threshold = DefaultScreeningThreshold
+	}
+	seen := make(map[string]bool, len(c.Sanctions))
+	for _, list := range c.Sanctions {
+		if list.Name == "" || seen[list.Name] {
+			return nil, errors.New("sanctions lists need distinct, non-empty names")
+		}
+		seen[list.Name] = true
+		for _, name := range list.Names {
+			normalized := normalizeName(name)
+			if normalized == "" {
+				return nil, errors.New("sanctions list " + list.Name + " has an empty name")
+			}
+			s.sanctions = append(s.sanctions, sanctionsEntry{list: list.Name, name: name, normalized: normalized})
+		}
+		s.lists = append(s.lists, SanctionsList{Name: list.Name, Names: append([]string(nil), list.Names...)})
+	}
+	s.coolingOff = DefaultLimitCoolingOff
+	if c.LimitCoolingOff != "" {
+		coolingOff, err := time.ParseDuration(c.LimitCoolingOff)
+		if err != nil || coolingOff < 0 {
+			return nil, errors.New("limit cooling-off " + c.LimitCoolingOff + " is not a non-negative duration such as 24h")
+		}
+		s.coolingOff = coolingOff
+	}
+	s.payeesOnly = c.RequirePayees
+	s.payeeWait = DefaultPayeeCoolingOff
+	if c.PayeeCoolingOff != "" {
+		wait, err := time.ParseDuration(c.PayeeCoolingOff)
+		if err != nil || wait < 0 {
+			return nil, errors.New("payee cooling-off " + c.PayeeCoolingOff + " is not a non-negative duration such as 24h")
+		}
+		s.payeeWait = wait
+	}
+	rules, err := compileRules(c.Rules)
+	if err != nil {
+		return nil, err
+	}
+	s.rules = rules
+	s.rails = make(map[Rail]RailRules, len(c.Rails))
+	for rail, rules := range c.Rails {
+		if !rails[rail] {
+			return nil, errors.New("rules for unknown rail " + string(rail))
+		}
+		if rules.Limit < 0 || rules.DailyLimit < 0 {
+			return nil, errors.New(string(rail) + " limits must not be negative")
+		}
+		s.rails[rail] = rules
+	}
+	s.apiPlans = make(map[string]APIPlan, len(c.APIPlans))
+	for name, plan := range c.APIPlans {
+		if err := plan.validate(name); err != nil {
+			return nil, err
+		}
+		copied := APIPlan{Quotas: make(map[string]int64, len(plan.Quotas)), Prices: make(map[string]account.Money, len(plan.Prices))}
+		for op, quota := range plan.Quotas {
+			copied.Quotas[op] = quota
+		}
+		for op, price := range plan.Prices {
+			copied.Prices[op] = price
+		}
+		s.apiPlans[name] = copied
+	}
+	s.apiClients = make(map[string]string, len(c.APIClients))
+	for client, plan := range c.APIClients {
+		if _, exists := s.apiPlans[plan]; !exists {
+			return nil, errors.New("API client " + client + " is on unknown plan " + plan)
+		}
+		s.apiClients[client] = plan
+	}
+	switch c.PausedTransfers {
+	case "", PauseReject:
+		s.paused = PauseReject
+	case PauseQueue:
+		s.paused = PauseQueue
+	default:
+		return nil, errors.New("paused transfers must be reject or queue, not " + string(c.PausedTransfers))
+	}
+	switch c.ClosurePolicy {
+	case "", ClosureReject:
+		s.closure = ClosureReject
+	case ClosureSweep:
+		s.closure = ClosureSweep
+	default:
+		return nil, errors.New("closure policy must be reject or sweep, not " + string(c.ClosurePolicy))
+	}
+	s.taxRates = make(map[TaxStatus]float64, len(c.Withholding))
+	for status, rate := range c.Withholding {
+		if status == "" {
+			return nil, errors.New("withholding rates need a tax status")
+		}
+		if math.IsNaN(rate) || rate < 0 || rate > 100 {
+			return nil, errors.New("withholding rate for " + string(status) + " must be between 0 and 100")
+		}
+		if rate > 0 && c.TaxAccount == "" {
+			return nil, errors.New("withholding tax needs a tax account to move it to")
+		}
+		s.taxRates[status] = rate
+	}
+	s.taxAccount = c.TaxAccount
+	if len(c.AccountPrefix) > 8 || strings.Trim(c.AccountPrefix, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return nil, errors.New("account prefix must be up to 8 capital letters")
+	}
+	s.idPrefix = c.AccountPrefix
+	feeNames := make(map[string]bool)
+	for _, r := range c.Fees {
+		feeNames[r.Name] = true
+	}
+	for _, pricing := range c.Segments {
+		for _, r := range pricing.Fees {
+			feeNames[r.Name] = true
+		}
+	}
+	tierNames := make(map[string]bool, len(c.RelationshipTiers))
+	for _, tier := range c.RelationshipTiers {
+		if tier.Name == "" || tierNames[tier.Name] {
+			return nil, errors.New("relationship tiers need distinct names")
+		}
+		tierNames[tier.Name] = true
+		if tier.MinBalance <= 0 {
+			return nil, errors.New("relationship tier " + tier.Name + " minimum balance must be positive")
+		}
+		if math.IsNaN(tier.RateBonus) || math.IsInf(tier.RateBonus, 0) || tier.RateBonus < 0 {
+			return nil, errors.New("relationship tier " + tier.Name + " rate bonus must not be negative")
+		}
+		for _, name := range tier.WaivedFees {
+			if !feeNames[name] {
+				return nil, errors.New("relationship tier " + tier.Name + " waives unknown fee " + name)
+			}
+		}
+		s.tiers = append(s.tiers, RelationshipTier{Name: tier.Name, MinBalance: tier.MinBalance, RateBonus: tier.RateBonus, WaivedFees: append([]string{}, tier.WaivedFees...)})
+	}
+	sort.Slice(s.tiers, func(i, j int) bool { return s.tiers[i].MinBalance > s.tiers[j].MinBalance })
+	s.products = make(map[string]Product, len(c.Products))
+	for name, product := range c.Products {
+		if name == "" {
+			return nil, errors.New("products need a name")
+		}
+		if product.Type != "savings" && product.Type != "checking" {
+			return nil, errors.New("product " + name + " must be a savings or checking product")
+		}
+		if math.IsNaN(product.InterestRate) || product.InterestRate < 0 || math.IsNaN(product.OverdraftRate) || product.OverdraftRate < 0 {
+			return nil, errors.New("product " + name + " rates must not be negative")
+		}
+		if product.OverdraftLimit < 0 || product.ConversionFee < 0 {
+			return nil, errors.New("product " + name + " amounts must not be negative")
+		}
+		if product.Type == "savings" && (product.OverdraftLimit != 0 || product.OverdraftRate != 0) {
+			return nil, errors.New("savings product " + name + " cannot have an overdraft")
+		}
+		if product.Type == "checking" && product.InterestRate != 0 {
+			return nil, errors.New("checking product " + name + " cannot earn interest")
+		}
+		if err := validMinimumBalance(product.MinimumBalance); err != nil {
+			return nil, errors.New("product " + name + " " + err.Error())
+		}
+		s.products[name] = product
+	}
+	s.minimums = make(map[string]MinimumBalance, len(c.MinimumBalances))
+	for accountType, minimum := range c.MinimumBalances {
+		if accountType == "" {
+			return nil, errors.New("minimum balances need an account type")
+		}
+		if err := validMinimumBalance(minimum); err != nil {
+			return nil, errors.New(accountType + " " + err.Error())
+		}
+		s.minimums[accountType] = minimum
+	}
+	if p := c.OverdraftProtection; p.Fee < 0 || p.MaxAmount < 0 || p.MaxPerDay < 0 {
+		return nil, errors.New("overdraft protection fee and limits must not be negative")
+	}
+	s.protection = c.OverdraftProtection
+	s.amounts = make(map[transaction.OperationType]AmountPolicy, len(c.AmountPolicies))
+	for op, policy := range c.AmountPolicies {
+		if !operationTypes[op] {
+			return nil, errors.New("amount policy for unknown operation " + string(op))
+		}
+		if err := policy.validate(); err != nil {
+			return nil, errors.New(string(op) + " " + err.Error())
+		}
+		s.amounts[op] = policy
+	}
+	s.segments = make(map[Segment]SegmentPricing, len(c.Segments))
+	for segment, pricing := range c.Segments {
+		if !segments[segment] || segment == SegmentStandard {
+			return nil, errors.New("pricing for unknown segment " + string(segment) + "; standard customers are priced by the fees and limits")
+		}
+		if err := validFeeRules(pricing.Fees); err != nil {
+			return nil, errors.New(string(segment) + " " + err.Error())
+		}
+		if math.IsNaN(pricing.RateBonus) || math.IsInf(pricing.RateBonus, 0) || pricing.RateBonus <= -100 {
+			return nil, errors.New(string(segment) + " rate bonus is out of range")
+		}
+		copied := SegmentPricing{RateBonus: pricing.RateBonus, Limits: make(map[transaction.OperationType]account.Money, len(pricing.Limits))}
+		if pricing.Fees != nil {
+			copied.Fees = append([]transaction.FeeRule{}, pricing.Fees...)
+		}
+		for op, limit := range pricing.Limits {
+			if !operationTypes[op] {
+				return nil, errors.New(string(segment) + " limit for unknown operation " + string(op))
+			}
+			if limit <= 0 {
+				return nil, errors.New(string(segment) + " limit for " + string(op) + " must be positive")
+			}
+			copied.Limits[op] = limit
+		}
+		s.segments[segment] = copied
+	}
+	if c.TravelRuleThreshold < 0 {
+		return nil, errors.New("travel rule threshold must not be negative")
+	}
+	s.travelRule = c.TravelRuleThreshold
+	s.blocked = make(map[string]bool, len(c.Corridors.Blocked))
+	for _, country := range c.Corridors.Blocked {
+		normalized, err := normalizeCountry(country)
+		if err != nil {
+			return nil, errors.New("blocked country " + country + ": " + err.Error())
+		}
+		s.blocked[normalized] = true
+	}
+	s.eddLimits = make(map[string]account.Money, len(c.Corridors.EDDThresholds))
+	for country, threshold := range c.Corridors.EDDThresholds {
+		normalized := country
+		if country != "*" {
+			var err error
+			if normalized, err = normalizeCountry(country); err != nil {
+				return nil, errors.New("due diligence threshold for " + country + ": " + err.Error())
+			}
+		}
+		if threshold <= 0 {
+			return nil, errors.New("due diligence threshold for " + country + " must be positive")
+		}
+		s.eddLimits[normalized] = threshold
+	}
+	return s, nil
+}
+
+// ApplyConfig validates a configuration and replaces the fee schedule, limits, benchmarks, holiday calendar,
+// sanctions lists, corridor rules, API plans, travel rule threshold, limit cooling-off, paused transfer handling,
+// payee rules, expression rules, rail rules, closure policy, withholding tax rates, segment pricing, account
+// number prefix, relationship tiers, products, minimum balances, overdraft protection and amount policies with it. If
+// anything is invalid nothing changes.
+func (b *Bank) ApplyConfig(c Config) error {
+	snapshot, err := c.snapshot()
+	if err != nil {
+		return err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.config = snapshot
+	b.setFeeSchedule(c.Fees)
+	return nil
+}
+
+// ReloadConfig reads a configuration file and applies it. On error the current configuration stays in place.
+func (b *Bank) ReloadConfig(path string) error {
+	c, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	return b.ApplyConfig(c)
+}
+
+// Config returns the configuration in effect.
+func (b *Bank) Config() Config {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	c := Config{Fees: append([]transaction.FeeRule(nil), b.feeSchedule...)}
+	if b.config == nil {
+		return c
+	}
+	c.Limits = make(map[transaction.OperationType]account.Money, len(b.config.limits))
+	for op, limit := range b.config.limits {
+		c.Limits[op] = limit
+	}
+	for channel, limits := range b.config.byChannel {
+		if c.ChannelLimits == nil {
+			c.ChannelLimits = make(map[Channel]map[transaction.OperationType]account.Money, len(b.config.byChannel))
+		}
+		c.ChannelLimits[channel] = make(map[transaction.OperationType]account.Money, len(limits))
+		for op, limit := range limits {
+			c.ChannelLimits[channel][op] = limit
+		}
+	}
+	c.Benchmarks = make(map[string]float64, len(b.config.benchmarks))
+	for name, rate := range b.config.benchmarks {
+		c.Benchmarks[name] = rate
+	}
+	for day := range b.config.holidays {
+		c.Holidays = append(c.Holidays, day)
+	}
+	sort.Strings(c.Holidays)
+	for _, list := range b.config.lists {
+		c.Sanctions = append(c.Sanctions, SanctionsList{Name: list.Name, Names: append([]string(nil), list.Names...)})
+	}
+	if b.config.threshold != DefaultScreeningThreshold {
+		c.ScreeningThreshold = b.config.threshold
+	}
+	c.TravelRuleThreshold = b.config.travelRule
+	if b.config.coolingOff != DefaultLimitCoolingOff {
+		c.LimitCoolingOff = b.config.coolingOff.String()
+	}
+	c.RequirePayees = b.config.payeesOnly
+	if b.config.payeeWait != DefaultPayeeCoolingOff {
+		c.PayeeCoolingOff = b.config.payeeWait.String()
+	}
+	c.PausedTransfers = b.config.paused
+	c.ClosurePolicy = b.config.closure
+	if len(b.config.taxRates) > 0 {
+		c.Withholding = make(map[TaxStatus]float64, len(b.config.taxRates))
+		for status, rate := range b.config.taxRates {
+			c.Withholding[status] = rate
+		}
+	}
+	c.TaxAccount = b.config.taxAccount
+	c.AccountPrefix = b.config.idPrefix
+	c.OverdraftProtection = b.config.protection
+	c.RelationshipTiers = append([]RelationshipTier(nil), b.config.tiers...)
+	if len(b.config.products) > 0 {
+		c.Products = make(map[string]Product, len(b.config.products))
+		for name, product := range b.config.products {
+			c.Products[name] = product
+		}
+	}
+	if len(b.config.amounts) > 0 {
+		c.AmountPolicies = make(map[transaction.OperationType]AmountPolicy, len(b.config.amounts))
+		for op, policy := range b.config.amounts {
+			c.AmountPolicies[op] = policy
+		}
+	}
+	if len(b.config.minimums) > 0 {
+		c.MinimumBalances = make(map[string]MinimumBalance, len(b.config.minimums))
+		for accountType, minimum := range b.config.minimums {
+			c.MinimumBalances[accountType] = minimum
+		}
+	}
+	if len(b.config.segments) > 0 {
+		c.Segments = make(map[Segment]SegmentPricing, len(b.config.segments))
+		for segment, pricing := range b.config.segments {
+			c.Segments[segment] = pricing
+		}
+	}
+	for _, r := range b.config.rules {
+		c.Rules = append(c.Rules, r.ExpressionRule)
+	}
+	if len(b.config.rails) > 0 {
+		c.Rails = make(map[Rail]RailRules, len(b.config.rails))
+		for rail, rules := range b.config.rails {
+			c.Rails[rail] = rules
+		}
+	}
+	c.APIPlans = b.config.apiPlans
+	c.APIClients = b.config.apiClients
+	for country := range b.config.blocked {
+		c.Corridors.Blocked = append(c.Corridors.Blocked, country)
+	}
+	sort.Strings(c.Corridors.Blocked)
+	if len(b.config.eddLimits) > 0 {
+		c.Corridors.EDDThresholds = make(map[string]account.Money, len(b.config.eddLimits))
+		for country, threshold := range b.config.eddLimits {
+			c.Corridors.EDDThresholds[country] = threshold
+		}
+	}
+	return c
+}
+
+// checkLimit rejects an operation larger than the configured limit for its type and the channel it came through,
+// or the account owner's segment.
+// The caller must hold the bank mutex.
+func (b *Bank) checkLimit(accountID string, channel Channel, op transaction.OperationType, amount account.Money) error {
+	if b.config == nil {
+		return nil
+	}
+	if limit, exists := b.config.byChannel[channel][op]; exists {
+		if amount > limit {
+			return decline(ReasonLimitExceeded, fmt.Sprintf("%s of %s exceeds the %s limit of %s", op, amount, channel, limit))
+		}
+		return nil
+	}
+	if pricing, exists := b.segmentPricing(accountID); exists {
+		if limit, exists := pricing.Limits[op]; exists {
+			if amount > limit {
+				return decline(ReasonLimitExceeded, fmt.Sprintf("%s of %s exceeds the %s limit of %s", op, amount, b.segmentOf(b.accountOwner[accountID]), limit))
+			}
+			return nil
+		}
+	}
+	if limit, exists := b.config.limits[op]; exists && amount > limit {
+		return decline(ReasonLimitExceeded, fmt.Sprintf("%s of %s exceeds the limit of %s", op, amount, limit))
+	}
+	return nil
+}
+
+// BenchmarkRate returns the annual rate, in percent, of a named benchmark such as the base rate.
+func (b *Bank) BenchmarkRate(name string) (float64, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	if b.config != nil {
+		if rate, exists := b.config.benchmarks[name]; exists {
+			return rate, nil
+		}
+	}
+	return 0, errors.New("benchmark " + name + " is not configured")
+}
+
+// IsBusinessDay reports whether a date is neither a weekend nor a holiday in the configured calendar.
+func (b *Bank) IsBusinessDay(t time.Time) bool {
+	if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return false
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.config == nil || !b.config.holidays[t.Format("2006-01-02")]
+}
+
+// NextBusinessDay returns the first business day on or after a date.
+func (b *Bank) NextBusinessDay(t time.Time) time.Time {
+	day := startOfDay(t)
+	for !b.IsBusinessDay(day) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
@@ -0,0 +1,113 @@
+package bank
+
+import (
+	"fmt"
+	"sort"
+)
+
+// OrphanedEntry is something the bank keeps about an account it does not hold, e.g. the owner of an account that
+// is gone or a link to one.
+type OrphanedEntry struct {
+	Kind      string // What the entry holds, e.g. "owner" or "interest payout"
+	Key       string // Account or customer the entry is kept under
+	AccountID string // Account the entry names that the bank does not hold
+}
+
+// String describes the entry for reports.
+func (o OrphanedEntry) String() string {
+	if o.Key == o.AccountID {
+		return fmt.Sprintf("%s of unknown account %s", o.Kind, o.AccountID)
+	}
+	return fmt.Sprintf("%s of %s names unknown account %s", o.Kind, o.Key, o.AccountID)
+}
+
+// accountRefs is one of the maps the bank keeps about accounts: which account each of its entries names, and how to
+// drop an entry.
+type accountRefs struct {
+	kind   string
+	refs   map[string]string // Map of entry key to the account it names
+	remove func(key string)
+}
+
+// keyedByAccount describes a map whose entries are kept under the account they are about.
+func keyedByAccount[V any](kind string, m map[string]V) accountRefs {
+	refs := make(map[string]string, len(m))
+	for id := range m {
+		refs[id] = id
+	}
+	return accountRefs{kind: kind, refs: refs, remove: func(key string) { delete(m, key) }}
+}
+
+// linkingAccount describes a map whose entries name an account as their value, e.g. where interest is paid out to.
+func linkingAccount(kind string, m map[string]string) accountRefs {
+	return accountRefs{kind: kind, refs: m, remove: func(key string) { delete(m, key) }}
+}
+
+// accountMaps returns the maps the bank keeps about accounts besides the accounts themselves, whose states and
+// profiles live with them in the account table. Purging an account drops its entries from every one of them.
+// The caller must hold the bank mutex.
+func (b *Bank) accountMaps() []accountRefs {
+	return []accountRefs{
+		keyedByAccount("owner", b.accountOwner),
+		keyedByAccount("branch", b.accountBranch),
+		keyedByAccount("currency", b.accountCurrency),
+		keyedByAccount("entity", b.accountNode),
+		keyedByAccount("joint owners", b.jointOwners),
+		keyedByAccount("signing rule", b.signingRules),
+		keyedByAccount("pending daily limits", b.pendingLimits),
+		keyedByAccount("fee usage", b.feeUsage),
+		keyedByAccount("product", b.accountProduct),
+		keyedByAccount("pending conversion", b.pendingProducts),
+		keyedByAccount("interest payout", b.interestPayouts),
+		keyedByAccount("overdraft protection", b.overdraftLinks),
+		keyedByAccount("promotional rate", b.promotions),
+		linkingAccount("interest payout", b.interestPayouts),
+		linkingAccount("overdraft protection", b.overdraftLinks),
+		linkingAccount("default account", b.defaultAccounts),
+	}
+}
+
+// orphanedEntries lists the entries of the account maps that name accounts the bank does not hold, ordered by
+// kind and key.
+// The caller must hold the bank mutex.
+func (b *Bank) orphanedEntries() []OrphanedEntry {
+	var orphaned []OrphanedEntry
+	for _, m := range b.accountMaps() {
+		for key, id := range m.refs {
+			if _, exists := b.accounts.get(id); !exists {
+				orphaned = append(orphaned, OrphanedEntry{Kind: m.kind, Key: key, AccountID: id})
+			}
+		}
+	}
+	sort.Slice(orphaned, func(i, j int) bool {
+		if orphaned[i].Kind != orphaned[j].Kind {
+			return orphaned[i].Kind < orphaned[j].Kind
+		}
+		return orphaned[i].Key < orphaned[j].Key
+	})
+	return orphaned
+}
+
+// OrphanedEntries lists what the bank keeps about accounts it does not hold, e.g. left behind by an interrupted
+// purge or a hand-edited snapshot. Accounts and their states are kept together and cannot drift apart.
+func (b *Bank) OrphanedEntries() []OrphanedEntry {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.orphanedEntries()
+}
+
+// RepairOrphanedEntries drops everything OrphanedEntries lists and returns what it dropped. Links to an account
+// that is gone are removed, so e.g. interest is capitalized again instead of paid out to nowhere.
+func (b *Bank) RepairOrphanedEntries() []OrphanedEntry {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	orphaned := b.orphanedEntries()
+	for _, m := range b.accountMaps() {
+		for key, id := range m.refs {
+			if _, exists := b.accounts.get(id); !exists {
+				m.remove(key)
+			}
+		}
+	}
+	return orphaned
+}
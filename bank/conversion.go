@@ -0,0 +1,277 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// Product is a set of terms savings and checking accounts can be converted to, e.g. basic and premium savings.
+type Product struct {
+	Type           string        `json:"type"`           // savings or checking
+	InterestRate   float64       `json:"interestRate"`   // Annual rate of a savings product, e.g. 0.03 for 3%
+	OverdraftLimit account.Money `json:"overdraftLimit"` // Of a checking product, in minor units
+	OverdraftRate  float64       `json:"overdraftRate"`  // Annual rate charged on a checking product's overdrawn balance
+	ConversionFee  account.Money `json:"conversionFee"`  // Charged to accounts converted to the product
+	// Replaces the minimum balance configured for the product's account type when its amount is positive
+	MinimumBalance MinimumBalance `json:"minimumBalance"`
+}
+
+// Conversion is an account's move from one product to another.
+type Conversion struct {
+	AccountID   string
+	From, To    string // Product names; From is empty for accounts never put on a product
+	EffectiveAt time.Time
+	Pending     bool // True while waiting for EffectiveAt
+	// Interest credited, or charged if negative, to put the account on the new terms from EffectiveAt, and to settle
+	// interest accrued on the old terms when the account changes type
+	Adjustment     account.Money
+	Fee            account.Money
+	TransactionID  string
+	Withheld       account.Money // Tax withheld from a positive adjustment
+	WithholdingErr error         // Why tax could not be withheld from the adjustment
+	Err            error         // Why a due conversion could not be applied; see ApplyConversions
+}
+
+// ProductOf returns the product an account is on, or "" if it was never put on one.
+func (b *Bank) ProductOf(accountID string) string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.accountProduct[accountID]
+}
+
+// PendingConversion returns a conversion waiting for its effective date.
+func (b *Bank) PendingConversion(accountID string) (Conversion, bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	c, exists := b.pendingProducts[accountID]
+	return c, exists
+}
+
+// ConvertAccount moves a savings or checking account to a configured product in place, keeping its ID, balance and
+// history. A conversion dated in the future waits for ApplyConversions, replacing any already waiting; one dated in
+// the past is applied now, with the difference between the interest the old and new terms give on the account's
+// balances since then credited or charged. The zero time means now. Only admins and managers may convert accounts.
+func (b *Bank) ConvertAccount(staffID, accountID, product string, effectiveAt time.Time) (Conversion, error) {
+	b.mutex.Lock()
+	if !b.isManager(staffID) {
+		b.mutex.Unlock()
+		return Conversion{}, decline(ReasonNotAuthorized, "only admins and managers may convert accounts")
+	}
+	now := b.now()
+	if effectiveAt.IsZero() {
+		effectiveAt = now
+	}
+	c := Conversion{AccountID: accountID, From: b.accountProduct[accountID], To: product, EffectiveAt: effectiveAt}
+	if err := b.checkConversion(c); err != nil {
+		b.mutex.Unlock()
+		return Conversion{}, err
+	}
+	b.auditAction(staffID, "ConvertAccount", accountID, "", product+" from "+effectiveAt.UTC().Format(time.RFC3339))
+	if effectiveAt.After(now) {
+		c.Pending = true
+		b.pendingProducts[accountID] = c
+		b.mutex.Unlock()
+		return c, nil
+	}
+	b.mutex.Unlock()
+	return b.convert(c)
+}
+
+// checkConversion checks an account can be converted to a product from a date.
+// The caller must hold the bank mutex.
+func (b *Bank) checkConversion(c Conversion) error {
+	acc, exists := b.accounts.get(c.AccountID)
+	if !exists {
+		return decline(ReasonAccountNotFound, "account does not exist")
+	}
+	switch acc.(type) {
+	case *account.Savings, *account.Checking:
+	default:
+		return errors.New("only savings and checking accounts can be converted")
+	}
+	if b.accounts.state(c.AccountID) == account.StateClosed {
+		return decline(ReasonAccountState, "closed accounts cannot be converted")
+	}
+	if b.config == nil {
+		return errors.New("unknown product " + c.To)
+	}
+	if _, exists := b.config.products[c.To]; !exists {
+		return errors.New("unknown product " + c.To)
+	}
+	if c.EffectiveAt.Before(b.profileOf(c.AccountID).Metadata.CreatedAt) {
+		return errors.New("conversion cannot take effect before the account was opened")
+	}
+	for _, e := range b.events {
+		if e.Type == EventAccountConverted && e.AccountID == c.AccountID && c.EffectiveAt.Before(e.At) {
+			return errors.New("conversion cannot take effect before the account's last conversion")
+		}
+	}
+	return nil
+}
+
+// ApplyConversions applies the waiting conversions whose effective date has come, ordered by account.
+func (b *Bank) ApplyConversions() []Conversion {
+	b.mutex.RLock()
+	now := b.now()
+	var due []Conversion
+	for _, c := range b.pendingProducts {
+		if !c.EffectiveAt.After(now) {
+			due = append(due, c)
+		}
+	}
+	b.mutex.RUnlock()
+	sort.Slice(due, func(i, j int) bool { return due[i].AccountID < due[j].AccountID })
+	applied := make([]Conversion, 0, len(due))
+	for _, c := range due {
+		c.Pending = false
+		converted, err := b.convert(c)
+		if err != nil {
+			// A conversion that can no longer be made is dropped rather than retried every day
+			b.mutex.Lock()
+			delete(b.pendingProducts, c.AccountID)
+			b.mutex.Unlock()
+			c.Err = err
+			applied = append(applied, c)
+			continue
+		}
+		applied = append(applied, converted)
+	}
+	return applied
+}
+
+// convert applies a conversion now and withholds tax from any interest it credits.
+func (b *Bank) convert(c Conversion) (Conversion, error) {
+	unlock := b.lockAccounts(c.AccountID)
+	b.mutex.Lock()
+	c, err := b.convertLocked(c)
+	b.mutex.Unlock()
+	unlock()
+	if err != nil {
+		return Conversion{}, err
+	}
+	if c.Adjustment > 0 {
+		c.Withheld, _, c.WithholdingErr = b.withholdTax(c.AccountID, c.Adjustment, c.TransactionID)
+	}
+	return c, nil
+}
+
+// convertLocked puts an account on a product's terms by replacing it with an account of the product's type built
+// from its record.
+// The caller must hold the account's lock and the bank mutex.
+func (b *Bank) convertLocked(c Conversion) (Conversion, error) {
+	if err := b.checkConversion(c); err != nil {
+		return c, err
+	}
+	product := b.config.products[c.To]
+	old, err := account.ToRecord(b.accounts.at(c.AccountID))
+	if err != nil {
+		return c, err
+	}
+	rec := account.Record{Type: product.Type, ID: old.ID, Balance: old.Balance,
+		AccrualStart: old.AccrualStart, AccruedThrough: old.AccruedThrough, InterestPostings: old.InterestPostings}
+	switch product.Type {
+	case "savings":
+		rec.InterestRate = product.InterestRate
+		if old.Type == "savings" {
+			rec.Compounding, rec.DayCount, rec.AccruedInterest = old.Compounding, old.DayCount, old.AccruedInterest
+		}
+	case "checking":
+		rec.OverdraftLimit, rec.OverdraftRate = product.OverdraftLimit, product.OverdraftRate
+		if old.Type == "checking" {
+			rec.AccruedInterest, rec.OverdraftInterest = old.AccruedInterest, old.OverdraftInterest
+		}
+	}
+	c.Adjustment = b.conversionAdjustment(c.AccountID, old, rec, c.EffectiveAt)
+	if old.Type != rec.Type {
+		// Interest accrued on the old terms is settled, and the new type's posting cycle starts where accrual stopped
+		settled := account.Money(math.RoundToEven(old.AccruedInterest))
+		if old.Type == "checking" {
+			settled = -settled - old.OverdraftInterest
+		}
+		c.Adjustment += settled
+		rec.AccrualStart, rec.InterestPostings = old.AccruedThrough, 0
+	}
+	c.Fee = product.ConversionFee
+	if final := old.Balance + c.Adjustment - c.Fee; final < 0 && (rec.Type == "savings" || -final > rec.OverdraftLimit) {
+		return c, decline(ReasonInsufficientFunds, "the balance would not cover the conversion to "+c.To)
+	}
+
+	converted, err := accountFromRecord(rec)
+	if err != nil {
+		return c, err
+	}
+	b.registerAccount(converted, b.accounts.state(c.AccountID))
+	c.From = b.accountProduct[c.AccountID]
+	b.accountProduct[c.AccountID] = c.To
+	delete(b.pendingProducts, c.AccountID)
+	c.TransactionID = b.newTxnID()
+	b.recordAccountEvent(EventAccountConverted, converted, Event{TransactionID: c.TransactionID, Reason: c.To})
+	if c.Adjustment > 0 {
+		err = converted.Deposit(c.Adjustment)
+	} else if c.Adjustment < 0 {
+		err = converted.Withdraw(-c.Adjustment)
+	}
+	if err != nil {
+		return c, err
+	}
+	if c.Adjustment != 0 {
+		b.recordAccountEvent(EventInterestPosted, converted, Event{Amount: c.Adjustment, TransactionID: c.TransactionID})
+	}
+	if c.Fee > 0 {
+		if err := converted.Withdraw(c.Fee); err != nil {
+			return c, err
+		}
+		b.recordEvent(Event{Type: EventFeeCharged, AccountID: c.AccountID, Amount: c.Fee, TransactionID: c.TransactionID, Reason: "Product conversion"})
+	}
+	from := c.From
+	if from == "" {
+		from = old.Type
+	}
+	b.recordTransaction(c.TransactionID, fmt.Sprintf("Transaction ID: %s, Account: %s, Product Conversion: %s to %s, Effective: %s, Interest Adjustment: %s, Fee: %s, Status: %s\n",
+		c.TransactionID, c.AccountID, from, c.To, c.EffectiveAt.UTC().Format(time.RFC3339), c.Adjustment, c.Fee, "success"))
+	return c, nil
+}
+
+// conversionAdjustment returns the interest the new terms give an account less what the old ones gave, from since
+// until interest was last accrued, on the balances the event log shows it held.
+// The caller must hold the bank mutex.
+func (b *Bank) conversionAdjustment(accountID string, old, rec account.Record, since time.Time) account.Money {
+	end := old.AccruedThrough
+	if end.IsZero() || !since.Before(end) {
+		return 0
+	}
+	rate := func(r account.Record, balance account.Money) float64 {
+		if r.Type == "checking" {
+			return float64(min(balance, 0)) * r.OverdraftRate
+		}
+		return float64(balance) * r.InterestRate
+	}
+	// Work back from the balance now to the balance at since, then forward through each change until end
+	balance := old.Balance
+	var changes []Event
+	for _, e := range b.events {
+		if e.At.After(since) && (e.AccountID == accountID || e.ToID == accountID) {
+			changes = append(changes, e)
+			balance -= balanceChange(e, accountID)
+		}
+	}
+	var adjustment float64
+	from := since
+	for _, e := range append(changes, Event{At: end}) {
+		to := e.At
+		if to.After(end) {
+			to = end
+		}
+		if to.After(from) {
+			adjustment += (rate(rec, balance) - rate(old, balance)) * account.Actual365.YearFraction(from, to)
+			from = to
+		}
+		balance += balanceChange(e, accountID)
+	}
+	return account.Money(math.RoundToEven(adjustment))
+}
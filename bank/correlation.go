@@ -0,0 +1,172 @@
+package bank
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+)
+
+// correlationKey is the context key under which an operation's correlation ID travels.
+type correlationKey struct{}
+
+// NewCorrelationID generates a random ID to tie together everything one incoming operation does.
+func NewCorrelationID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return "cor-" + hex.EncodeToString(buf)
+}
+
+// ContextWithCorrelationID returns a context carrying a correlation ID.
+func ContextWithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, correlationID)
+}
+
+// CorrelationIDFromContext returns the correlation ID a context carries, or "" if it has none.
+func CorrelationIDFromContext(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationKey{}).(string)
+	return correlationID
+}
+
+// ensureCorrelationID returns the context as it is if it carries a correlation ID, or carrying a new one if not.
+func ensureCorrelationID(ctx context.Context) context.Context {
+	if CorrelationIDFromContext(ctx) != "" {
+		return ctx
+	}
+	return ContextWithCorrelationID(ctx, NewCorrelationID())
+}
+
+// RunCorrelated runs an operation on behalf of an incoming request, tagging the events and audit entries it
+// records for the given subjects (account, customer or impersonation session IDs) with the context's correlation
+// ID, and the audit log with the context's user and channel. Correlated operations on the same subject wait for each other so every subject carries one ID at a time;
+// without a correlation ID the operation simply runs. If the operation fails, the failure is recorded against the
+// first subject and the context's channel for FailureReport.
+// The caller must not hold the bank mutex or any account lock.
+func (b *Bank) RunCorrelated(ctx context.Context, subjects []string, op func() error) error {
+	correlationID := CorrelationIDFromContext(ctx)
+	op = b.recordingFailures(ctx, subjects, op)
+	if correlationID == "" {
+		return op()
+	}
+	sorted := append([]string(nil), subjects...)
+	sort.Strings(sorted)
+
+	// Correlation locks are taken before any account lock and in ID order, like lockAccounts
+	b.mutex.Lock()
+	var locks []*sync.Mutex
+	for i, id := range sorted {
+		if id == "" || (i > 0 && id == sorted[i-1]) {
+			continue
+		}
+		lock, exists := b.corrLocks[id]
+		if !exists {
+			lock = &sync.Mutex{}
+			b.corrLocks[id] = lock
+		}
+		locks = append(locks, lock)
+	}
+	b.mutex.Unlock()
+	for _, lock := range locks {
+		lock.Lock()
+	}
+	defer func() {
+		for i := len(locks) - 1; i >= 0; i-- {
+			locks[i].Unlock()
+		}
+	}()
+
+	b.mutex.Lock()
+	for _, id := range sorted {
+		if id != "" {
+			b.correlations[id] = correlationID
+		}
+	}
+	userID, _ := UserFromContext(ctx)
+	b.operators[correlationID] = operator{userID: userID, channel: ChannelFromContext(ctx)}
+	startSeq := b.eventSeq
+	b.mutex.Unlock()
+	defer func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		for _, id := range sorted {
+			delete(b.correlations, id)
+		}
+		delete(b.operators, correlationID)
+		b.annotateCorrelation(correlationID, startSeq)
+	}()
+	return op()
+}
+
+// recordingFailures wraps an operation so its failure is recorded for FailureReport.
+func (b *Bank) recordingFailures(ctx context.Context, subjects []string, op func() error) func() error {
+	return func() error {
+		err := op()
+		if err != nil {
+			accountID := ""
+			if len(subjects) > 0 {
+				accountID = subjects[0]
+			}
+			b.mutex.Lock()
+			b.recordFailure(ChannelFromContext(ctx), accountID, CorrelationIDFromContext(ctx), err)
+			b.mutex.Unlock()
+		}
+		return err
+	}
+}
+
+// annotateCorrelation adds the correlation ID to the history entries of the transactions recorded under it since
+// an event sequence number.
+// The caller must hold the bank mutex.
+func (b *Bank) annotateCorrelation(correlationID string, sinceSeq int64) {
+	annotated := make(map[string]bool)
+	for i := len(b.events) - 1; i >= 0 && b.events[i].Seq > sinceSeq; i-- {
+		e := b.events[i]
+		if e.CorrelationID == correlationID && e.TransactionID != "" && !annotated[e.TransactionID] {
+			annotated[e.TransactionID] = true
+			b.annotateTransaction(e.TransactionID, "Correlation ID: "+correlationID)
+		}
+	}
+}
+
+// correlationOf returns the correlation ID of the operation under way on any of the subjects.
+// The caller must hold the bank mutex.
+func (b *Bank) correlationOf(subjects ...string) string {
+	for _, id := range subjects {
+		if correlationID := b.correlations[id]; correlationID != "" {
+			return correlationID
+		}
+	}
+	return ""
+}
+
+// Correlated is everything recorded under one correlation ID.
+type Correlated struct {
+	CorrelationID string
+	Events        []Event
+	Transactions  map[string]string // Map of transaction ID to history entry, for transactions the events name
+	Audit         []ImpersonationEvent
+}
+
+// LookupCorrelation returns the events, transaction history and impersonation audit entries recorded under a
+// correlation ID.
+func (b *Bank) LookupCorrelation(correlationID string) Correlated {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	found := Correlated{CorrelationID: correlationID, Transactions: make(map[string]string)}
+	for _, e := range b.events {
+		if e.CorrelationID != correlationID {
+			continue
+		}
+		found.Events = append(found.Events, e)
+		if entry, exists := b.transactionHist[e.TransactionID]; exists && e.TransactionID != "" {
+			found.Transactions[e.TransactionID] = entry
+		}
+	}
+	for _, e := range b.impersonationLog {
+		if e.CorrelationID == correlationID {
+			found.Audit = append(found.Audit, e)
+		}
+	}
+	return found
+}
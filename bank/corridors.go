@@ -0,0 +1,255 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// CorridorRules restrict cross-border transfers by destination country, given as ISO 3166-1 alpha-2 codes such as
+// "DE".
+type CorridorRules struct {
+	Blocked []string `json:"blocked"` // Countries transfers may only go to with a manager's override
+	// Map of country, or "*" for any country, to the amount from which a transfer needs enhanced due diligence
+	EDDThresholds map[string]account.Money `json:"eddThresholds"`
+}
+
+// CorridorRule is the corridor rule that held back a cross-border transfer.
+type CorridorRule string
+
+const (
+	CorridorBlocked CorridorRule = "blocked"                // The destination country is blocked
+	CorridorEDD     CorridorRule = "enhanced-due-diligence" // The amount reached the country's threshold
+)
+
+// CorridorReviewStatus tracks a held-back cross-border transfer through the override workflow.
+type CorridorReviewStatus string
+
+const (
+	CorridorPending  CorridorReviewStatus = "pending"
+	CorridorApproved CorridorReviewStatus = "approved" // Overridden by a manager and sent
+	CorridorRejected CorridorReviewStatus = "rejected"
+)
+
+// CorridorReview is a cross-border transfer held back by a corridor rule until a manager overrides or rejects it.
+// Reviews are derived from the transaction history, so they survive restarts.
+type CorridorReview struct {
+	ID            string // The held-back transaction
+	FromID        string
+	ToID          string
+	Amount        account.Money
+	Country       string
+	Rule          CorridorRule
+	Status        CorridorReviewStatus
+	RequestedAt   time.Time
+	DecidedBy     string
+	TransactionID string          // The transfer sent on approval
+	TravelRule    *TravelRuleData // Details the transfer carried, sent with it on approval
+}
+
+// CorridorSummary is one destination country's cross-border transfers in a CorridorReport.
+type CorridorSummary struct {
+	Country    string
+	Transfers  int           // Sent, including overridden ones
+	Total      account.Money // Sent
+	Held       int           // Held back by a corridor rule
+	Overridden int           // Sent after a manager's override
+}
+
+// normalizeCountry validates a country code and puts it in upper case.
+func normalizeCountry(country string) (string, error) {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if len(country) != 2 || country[0] < 'A' || country[0] > 'Z' || country[1] < 'A' || country[1] > 'Z' {
+		return "", errors.New("country must be a two-letter ISO 3166-1 code")
+	}
+	return country, nil
+}
+
+// corridorRule returns the rule a transfer of an amount to a country breaks, or "" if it may be sent.
+// The caller must hold the bank mutex.
+func (b *Bank) corridorRule(country string, amount account.Money) CorridorRule {
+	if b.config == nil {
+		return ""
+	}
+	if b.config.blocked[country] {
+		return CorridorBlocked
+	}
+	threshold, exists := b.config.eddLimits[country]
+	if !exists {
+		threshold, exists = b.config.eddLimits["*"]
+	}
+	if exists && amount >= threshold {
+		return CorridorEDD
+	}
+	return ""
+}
+
+// corridorReview picks a corridor review out of a transaction history entry, reporting false if the entry is not a
+// held-back transfer.
+func corridorReview(txnID, entry string) (CorridorReview, bool) {
+	fields := make(map[string]string)
+	for _, field := range historyFields(entry) {
+		fields[field[0]] = field[1]
+	}
+	rule, held := fields["Corridor Rule"]
+	if !held {
+		return CorridorReview{}, false
+	}
+	h := parseHistoryEntry(txnID, entry)
+	r := CorridorReview{
+		ID:            txnID,
+		FromID:        h.From,
+		ToID:          h.To,
+		Amount:        h.Amount,
+		Country:       fields["Country"],
+		Rule:          CorridorRule(rule),
+		Status:        CorridorPending,
+		RequestedAt:   h.Recorded,
+		TransactionID: fields["Override Transaction"],
+		TravelRule:    travelRuleFromFields(fields),
+	}
+	// Decisions read "Corridor Review: approved by ID"
+	if decision, exists := fields["Corridor Review"]; exists {
+		status, by, _ := strings.Cut(decision, " by ")
+		r.Status, r.DecidedBy = CorridorReviewStatus(status), by
+	}
+	return r, true
+}
+
+// checkCorridor holds back a cross-border transfer that breaks a corridor rule, recording it as failed so it awaits
+// review. Retrying a transfer that is already awaiting review does not hold it back again.
+func (b *Bank) checkCorridor(fromID, toID string, amount account.Money, country string, travelRule *TravelRuleData) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	rule := b.corridorRule(country, amount)
+	if rule == "" {
+		return nil
+	}
+	reviewID := ""
+	for id, entry := range b.transactionHist {
+		r, held := corridorReview(id, entry)
+		if held && r.Status == CorridorPending && r.FromID == fromID && r.ToID == toID && r.Amount == amount && r.Country == country {
+			reviewID = id
+			break
+		}
+	}
+	if reviewID == "" {
+		reviewID = b.newTxnID()
+		b.recordTransaction(reviewID, fmt.Sprintf("Transaction ID: %s, From: %s, To: %s, Amount: %s, Country: %s, Corridor Rule: %s, Status: %s, Reason Code: %s\n", reviewID, fromID, toID, amount, country, rule, "failed", ReasonCorridor))
+		b.recordTravelRule(reviewID, travelRule)
+	}
+	if rule == CorridorBlocked {
+		return decline(ReasonCorridor, fmt.Sprintf("transfers to %s are blocked; held for review as %s", country, reviewID))
+	}
+	return decline(ReasonCorridor, fmt.Sprintf("transfers of %s to %s need enhanced due diligence; held for review as %s", amount, country, reviewID))
+}
+
+// CorridorReviews lists cross-border transfer reviews with the given status, or all of them for "", oldest first.
+func (b *Bank) CorridorReviews(status CorridorReviewStatus) []CorridorReview {
+	b.mutex.Lock()
+	var list []CorridorReview
+	for id, entry := range b.transactionHist {
+		if r, held := corridorReview(id, entry); held && (status == "" || r.Status == status) {
+			list = append(list, r)
+		}
+	}
+	b.mutex.Unlock()
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].RequestedAt.Equal(list[j].RequestedAt) {
+			return list[i].RequestedAt.Before(list[j].RequestedAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// DecideCorridorReview overrides a held-back cross-border transfer, sending it, or rejects it. Only admins and
+// managers may decide. If an approved transfer fails, e.g. for lack of funds, the review stays pending. It returns
+// the ID of the transfer sent.
+func (b *Bank) DecideCorridorReview(staffID, reviewID string, approve bool) (string, error) {
+	b.mutex.Lock()
+	if !b.isManager(staffID) {
+		b.mutex.Unlock()
+		return "", decline(ReasonNotAuthorized, "only admins and managers may decide corridor reviews")
+	}
+	entry := b.transactionHist[reviewID]
+	r, held := corridorReview(reviewID, entry)
+	if !held || r.Status != CorridorPending {
+		b.mutex.Unlock()
+		return "", errors.New("transfer is not awaiting corridor review")
+	}
+	if !approve {
+		b.annotateTransaction(reviewID, fmt.Sprintf("Corridor Review: %s by %s", CorridorRejected, staffID))
+		b.auditAction(staffID, "RejectCorridorTransfer", r.FromID, reviewID, r.Country)
+		b.mutex.Unlock()
+		return "", nil
+	}
+	// Queueing the override would leave the review pending, so it could be sent twice
+	if pause := b.pauseSwitch.State(); pause.covers(r.Amount) {
+		b.mutex.Unlock()
+		return "", decline(ReasonTransfersPaused, "outgoing transfers are paused; decide the review once they resume")
+	}
+	// Decided before the transfer is sent so a second manager cannot send it again
+	b.annotateTransaction(reviewID, fmt.Sprintf("Corridor Review: %s by %s", CorridorApproved, staffID))
+	b.mutex.Unlock()
+
+	txnID, err := b.sendTransfer(PendingTransfer{FromID: r.FromID, ToID: r.ToID, Amount: r.Amount, Country: r.Country, TravelRule: r.TravelRule})
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err != nil {
+		b.recordTransaction(reviewID, entry)
+		return "", err
+	}
+	b.annotateTransaction(reviewID, "Override Transaction: "+txnID)
+	b.annotateTransaction(txnID, fmt.Sprintf("Country: %s, Corridor Override: %s by %s", r.Country, reviewID, staffID))
+	b.auditAction(staffID, "OverrideCorridorTransfer", r.FromID, txnID, reviewID+" "+r.Country)
+	return txnID, nil
+}
+
+// CorridorReport counts the cross-border transfers recorded in [from, to) by destination country: those sent, those
+// held back by corridor rules and those sent on a manager's override. Zero times do not bound the period.
+func (b *Bank) CorridorReport(from, to time.Time) []CorridorSummary {
+	b.mutex.Lock()
+	byCountry := make(map[string]*CorridorSummary)
+	for txnID, entry := range b.transactionHist {
+		fields := make(map[string]string)
+		for _, field := range historyFields(entry) {
+			fields[field[0]] = field[1]
+		}
+		country, exists := fields["Country"]
+		if !exists {
+			continue
+		}
+		h := parseHistoryEntry(txnID, entry)
+		if (!from.IsZero() && h.Recorded.Before(from)) || (!to.IsZero() && !h.Recorded.Before(to)) {
+			continue
+		}
+		s, exists := byCountry[country]
+		if !exists {
+			s = &CorridorSummary{Country: country}
+			byCountry[country] = s
+		}
+		switch {
+		case h.Status == "success":
+			s.Transfers++
+			s.Total += h.Amount
+			if _, overridden := fields["Corridor Override"]; overridden {
+				s.Overridden++
+			}
+		case fields["Reason Code"] == string(ReasonCorridor):
+			s.Held++
+		}
+	}
+	b.mutex.Unlock()
+	report := make([]CorridorSummary, 0, len(byCountry))
+	for _, s := range byCountry {
+		report = append(report, *s)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Country < report[j].Country })
+	return report
+}
@@ -0,0 +1,127 @@
+package bank
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// accountCSVHeader is the column order of ExportAccountsCSV. Append new columns; never reorder.
+var accountCSVHeader = []string{"id", "type", "state", "owner", "branch", "currency", "balance", "owner_name", "email", "created", "tags"}
+
+// transactionCSVHeader is the column order of ExportTransactionsCSV. Append new columns; never reorder.
+var transactionCSVHeader = []string{"transaction_id", "from", "to", "account", "amount", "status", "details"}
+
+// csvText guards a text cell against being read as a formula by spreadsheet applications.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// ExportAccountsCSV writes every active account to w as CSV, one row per account ordered by ID, with a header row.
+// Balances are in major units with two decimal places. It returns how many accounts were written.
+func (b *Bank) ExportAccountsCSV(w io.Writer) (int, error) {
+	b.mutex.RLock()
+	records, err := b.accountRecords()
+	b.mutex.RUnlock()
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(accountCSVHeader); err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, rec := range records {
+		if !rec.Active {
+			continue
+		}
+		currency := rec.Currency
+		if currency == "" {
+			currency = DefaultCurrency
+		}
+		created := ""
+		if !rec.CreatedAt.IsZero() {
+			created = rec.CreatedAt.UTC().Format(time.RFC3339)
+		}
+		row := []string{csvText(rec.ID), rec.Type, string(rec.State), csvText(rec.Owner), csvText(rec.Branch), currency, rec.Balance.String(),
+			csvText(rec.OwnerName), csvText(rec.Email), created, csvText(rec.Tags)}
+		if err := cw.Write(row); err != nil {
+			return count, err
+		}
+		count++
+	}
+	cw.Flush()
+	return count, cw.Error()
+}
+
+// ExportTransactionsCSV writes the whole transaction journal to w as CSV, one row per transaction ordered by ID,
+// with a header row. The common fields of each history entry get their own columns; anything else the entry
+// records is kept, in order, in the details column. It returns how many transactions were written.
+func (b *Bank) ExportTransactionsCSV(w io.Writer) (int, error) {
+	b.mutex.RLock()
+	ids := make([]string, 0, len(b.transactionHist))
+	entries := make(map[string]string, len(b.transactionHist))
+	for id, entry := range b.transactionHist {
+		ids = append(ids, id)
+		entries[id] = entry
+	}
+	b.mutex.RUnlock()
+	sort.Strings(ids)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(transactionCSVHeader); err != nil {
+		return 0, err
+	}
+	for i, id := range ids {
+		columns := map[string]string{"Transaction ID": id}
+		var details []string
+		for _, field := range historyFields(entries[id]) {
+			switch field[0] {
+			case "Transaction ID", "From", "To", "Account", "Amount", "Status":
+				columns[field[0]] = field[1]
+			default:
+				details = append(details, field[0]+": "+field[1])
+			}
+		}
+		row := []string{
+			csvText(columns["Transaction ID"]),
+			csvText(columns["From"]),
+			csvText(columns["To"]),
+			csvText(columns["Account"]),
+			columns["Amount"],
+			columns["Status"],
+			csvText(strings.Join(details, "; ")),
+		}
+		if err := cw.Write(row); err != nil {
+			return i, err
+		}
+	}
+	cw.Flush()
+	return len(ids), cw.Error()
+}
+
+// historyFields splits a history entry into its "Key: Value" fields, in order. Keys are capitalised; text that is
+// not a field of its own, such as a note or a reason containing a comma, is kept with the field before it.
+func historyFields(entry string) [][2]string {
+	var fields [][2]string
+	for _, part := range strings.Split(strings.TrimSpace(entry), ", ") {
+		if key, value, ok := strings.Cut(part, ": "); ok && key != "" && unicode.IsUpper(rune(key[0])) {
+			fields = append(fields, [2]string{key, value})
+			continue
+		}
+		if len(fields) == 0 {
+			fields = append(fields, [2]string{"Note", part})
+			continue
+		}
+		fields[len(fields)-1][1] += ", " + part
+	}
+	return fields
+}
@@ -0,0 +1,132 @@
+package bank
+
+import (
+	"errors"
+	"math"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// DefaultCurrency is the currency of accounts opened without one.
+const DefaultCurrency = "USD"
+
+// RateProvider supplies exchange rates for transfers between accounts held in different currencies.
+type RateProvider interface {
+	// Rate returns how many units of currency to one unit of currency from buys right now.
+	Rate(from, to string) (float64, error)
+}
+
+// StaticRates is a RateProvider backed by a fixed table keyed by "FROM/TO", e.g. "USD/EUR". A pair listed in
+// one direction only is inverted for the other.
+type StaticRates map[string]float64
+
+// Rate looks up the rate for a currency pair.
+func (sr StaticRates) Rate(from, to string) (float64, error) {
+	if rate, exists := sr[from+"/"+to]; exists {
+		return rate, nil
+	}
+	if rate, exists := sr[to+"/"+from]; exists && rate != 0 {
+		return 1 / rate, nil
+	}
+	return 0, errors.New("no exchange rate for " + from + "/" + to)
+}
+
+// validCurrency reports whether code looks like an ISO 4217 currency code.
+func validCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// SetRateProvider sets where exchange rates for cross-currency transfers come from.
+func (b *Bank) SetRateProvider(p RateProvider) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.rates = p
+}
+
+// CreateAccountInCurrency adds an account to the bank, holding its balance in the given currency.
+func (b *Bank) CreateAccountInCurrency(acc account.Account, currency string) error {
+	if !validCurrency(currency) {
+		return errors.New("currency must be a three-letter ISO 4217 code")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err := b.checkNewAccountID(acc.ID()); err != nil {
+		return err
+	}
+	b.registerAccount(acc, account.StateOpen)
+	if currency != DefaultCurrency {
+		b.accountCurrency[acc.ID()] = currency
+	}
+	b.recordAccountEvent(EventAccountCreated, acc, Event{})
+	return nil
+}
+
+// SetAccountCurrency changes the currency an account is held in. Only an empty account can change currency,
+// as the balance is not converted.
+func (b *Bank) SetAccountCurrency(accountID, currency string) error {
+	if !validCurrency(currency) {
+		return errors.New("currency must be a three-letter ISO 4217 code")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	acc, exists := b.accounts.get(accountID)
+	if !exists {
+		return errors.New("account does not exist")
+	}
+	if acc.Balance() != 0 {
+		return errors.New("only an account with a zero balance can change currency")
+	}
+	if currency == DefaultCurrency {
+		delete(b.accountCurrency, accountID)
+	} else {
+		b.accountCurrency[accountID] = currency
+	}
+	return nil
+}
+
+// CurrencyOf returns the currency an account is held in.
+func (b *Bank) CurrencyOf(accountID string) (string, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.accounts.get(accountID); !exists {
+		return "", errors.New("account does not exist")
+	}
+	return b.currencyOf(accountID), nil
+}
+
+// currencyOf returns the currency an account is held in.
+// The caller must hold the bank mutex.
+func (b *Bank) currencyOf(accountID string) string {
+	if currency, exists := b.accountCurrency[accountID]; exists {
+		return currency
+	}
+	return DefaultCurrency
+}
+
+// convert converts an amount between currencies at the provider's current rate, rounding half to even.
+// The caller must not hold the bank mutex, as the provider may be slow.
+func convert(rates RateProvider, amount account.Money, from, to string) (account.Money, float64, error) {
+	if rates == nil {
+		return 0, 0, errors.New("no exchange rate provider for " + from + "/" + to)
+	}
+	rate, err := rates.Rate(from, to)
+	if err != nil {
+		return 0, 0, err
+	}
+	if rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return 0, 0, errors.New("invalid exchange rate for " + from + "/" + to)
+	}
+	converted := amount.MulRate(rate)
+	if converted <= 0 {
+		return 0, 0, errors.New("amount is too small to convert")
+	}
+	return converted, rate, nil
+}
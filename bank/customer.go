@@ -0,0 +1,135 @@
+package bank
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// AssignOwner records the customer that owns an account.
+func (b *Bank) AssignOwner(accountID, customerID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.accounts.get(accountID); !exists {
+		return errors.New("account does not exist")
+	}
+	if customerID == "" {
+		return errors.New("customer ID must not be empty")
+	}
+	b.accountOwner[accountID] = customerID
+	return nil
+}
+
+// AccountsOf lists the accounts a customer owns or jointly owns, ordered by ID.
+func (b *Bank) AccountsOf(customerID string) []string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	var ids []string
+	for id := range b.accounts.all() {
+		if b.ownsAccount(id, customerID) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// SetDefaultAccount marks the account that receives credits addressed to the customer. Customers may choose their
+// own, and admins and managers any customer's.
+func (b *Bank) SetDefaultAccount(userID, customerID, accountID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.accounts.get(accountID); !exists {
+		return errors.New("account does not exist")
+	}
+	if userID != customerID && !b.isManager(userID) {
+		return decline(ReasonNotAuthorized, "customers may only choose their own default account")
+	}
+	if !b.ownsAccount(accountID, customerID) {
+		return errors.New("account is not owned by customer")
+	}
+	if !b.IsAccountActive(accountID) {
+		return errors.New("account is inactive")
+	}
+	b.defaultAccounts[customerID] = accountID
+	b.auditAction(userID, "SetDefaultAccount", accountID, "", customerID)
+	return nil
+}
+
+// RegisterAlias links a payment alias (email, phone, handle) to a customer. Customers may register their own
+// aliases, and admins and managers any customer's.
+func (b *Bank) RegisterAlias(userID, alias, customerID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if alias == "" || strings.Contains(alias, ",") {
+		return errors.New("alias must be non-empty and must not contain commas")
+	}
+	if userID != customerID && !b.isManager(userID) {
+		return decline(ReasonNotAuthorized, "customers may only register their own aliases")
+	}
+	if owner, exists := b.aliases[alias]; exists && owner != customerID {
+		return errors.New("alias is already registered to another customer")
+	}
+	b.aliases[alias] = customerID
+	b.auditAction(userID, "RegisterAlias", "", "", alias+" for "+customerID)
+	return nil
+}
+
+// resolveCreditAccount maps an account ID, alias, or customer ID to the account that should be credited.
+// The caller must hold the bank mutex.
+func (b *Bank) resolveCreditAccount(addressee string) (string, error) {
+	if _, exists := b.accounts.get(addressee); exists {
+		return addressee, nil
+	}
+	customerID := addressee
+	if owner, exists := b.aliases[addressee]; exists {
+		customerID = owner
+	}
+	accountID, exists := b.defaultAccounts[customerID]
+	if !exists {
+		return "", errors.New("no default account for addressee")
+	}
+	if !b.IsAccountActive(accountID) {
+		return "", errors.New("default account is inactive")
+	}
+	return accountID, nil
+}
+
+// DefaultAccountOf returns the account credits addressed to a customer ID or alias go to; an account ID addresses
+// itself.
+func (b *Bank) DefaultAccountOf(addressee string) (string, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.resolveCreditAccount(addressee)
+}
+
+// DepositToCustomer credits the default account of the customer or alias being addressed.
+func (b *Bank) DepositToCustomer(addressee string, amount account.Money) (string, error) {
+	b.mutex.Lock()
+	accountID, err := b.resolveCreditAccount(addressee)
+	b.mutex.Unlock()
+	if err != nil {
+		return "", err
+	}
+	if err := b.Deposit(accountID, amount); err != nil {
+		return "", err
+	}
+	return accountID, nil
+}
+
+// TransferToCustomer transfers funds to the default account of the customer or alias being addressed, returning
+// that account and the transaction ID.
+func (b *Bank) TransferToCustomer(fromID, addressee string, amount account.Money) (toID, txnID string, err error) {
+	b.mutex.Lock()
+	toID, err = b.resolveCreditAccount(addressee)
+	b.mutex.Unlock()
+	if err != nil {
+		return "", "", err
+	}
+	if txnID, err = b.transfer(fromID, toID, amount); err != nil {
+		return "", "", err
+	}
+	return toID, txnID, nil
+}
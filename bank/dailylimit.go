@@ -0,0 +1,215 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// dailyLimitWindow is how far back withdrawals and transfers count towards an account's daily limits.
+const dailyLimitWindow = 24 * time.Hour
+
+// DefaultLimitCoolingOff is how long daily limits a customer raises take to come into effect when the configuration
+// does not say.
+const DefaultLimitCoolingOff = 24 * time.Hour
+
+// DailyLimits caps how much an account may withdraw, and transfer out, in any rolling 24 hours. Zero means no
+// limit.
+type DailyLimits struct {
+	Withdrawal account.Money
+	Transfer   account.Money
+}
+
+// PendingLimits are daily limits a customer raised, waiting out the cooling-off period.
+type PendingLimits struct {
+	Limits      DailyLimits
+	EffectiveAt time.Time
+}
+
+// DailyUsage is how much an account has withdrawn and transferred out in the last 24 hours.
+type DailyUsage struct {
+	Withdrawal account.Money
+	Transfer   account.Money
+}
+
+// DailyLimitError is returned when an operation would take an account past one of its daily limits.
+type DailyLimitError struct {
+	AccountID string
+	Operation transaction.OperationType
+	Limit     account.Money
+	Used      account.Money // Already withdrawn or transferred in the last 24 hours
+	Amount    account.Money
+}
+
+func (e *DailyLimitError) Error() string {
+	return fmt.Sprintf("%s of %s would exceed the daily %s limit of %s on account %s; %s remaining",
+		e.Operation, e.Amount, e.Operation, e.Limit, e.AccountID, max(e.Limit-e.Used, 0))
+}
+
+// SetDailyLimits sets an account's daily withdrawal and transfer limits at once, replacing any a customer raised that
+// are still cooling off. Only admins and managers may change them this way.
+func (b *Bank) SetDailyLimits(staffID, accountID string, limits DailyLimits) error {
+	if limits.Withdrawal < 0 || limits.Transfer < 0 {
+		return errors.New("daily limits must not be negative")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.isManager(staffID) {
+		return errors.New("only admins and managers may change daily limits")
+	}
+	if _, exists := b.accounts.get(accountID); !exists {
+		return errors.New("account does not exist")
+	}
+	b.putDailyLimits(accountID, limits)
+	delete(b.pendingLimits, accountID)
+	b.auditAction(staffID, "SetDailyLimits", accountID, "", fmt.Sprintf("withdrawal %s, transfer %s", limits.Withdrawal, limits.Transfer))
+	return nil
+}
+
+// putDailyLimits puts an account's daily limits into effect.
+// The caller must hold the bank mutex.
+func (b *Bank) putDailyLimits(accountID string, limits DailyLimits) {
+	if p := b.accounts.profile(accountID); p != nil {
+		p.Limits = limits
+	}
+}
+
+// lowerLimit returns the stricter of two daily limits, where zero means no limit.
+func lowerLimit(a, b account.Money) account.Money {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// ChangeOwnDailyLimits sets the daily limits of an account a customer owns. Lower limits take effect at once; raised
+// ones only once the configured cooling-off period has passed, so someone who has taken over the account cannot
+// lift its limits and empty it straight away. The customer is notified of a raise immediately. It returns when the
+// new limits take effect.
+func (b *Bank) ChangeOwnDailyLimits(customerID, accountID string, limits DailyLimits) (time.Time, error) {
+	if limits.Withdrawal < 0 || limits.Transfer < 0 {
+		return time.Time{}, errors.New("daily limits must not be negative")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.accounts.get(accountID); !exists {
+		return time.Time{}, errors.New("account does not exist")
+	}
+	if !b.ownsAccount(accountID, customerID) {
+		return time.Time{}, decline(ReasonNotAuthorized, "customers may only change the limits of their own accounts")
+	}
+	b.applyPendingLimits(accountID)
+	current := b.profileOf(accountID).Limits
+	// Whatever is lowered applies now
+	immediate := DailyLimits{Withdrawal: lowerLimit(current.Withdrawal, limits.Withdrawal), Transfer: lowerLimit(current.Transfer, limits.Transfer)}
+	b.putDailyLimits(accountID, immediate)
+	delete(b.pendingLimits, accountID)
+	now := b.now()
+	detail := fmt.Sprintf("withdrawal %s, transfer %s", limits.Withdrawal, limits.Transfer)
+	if immediate == limits {
+		b.auditAction(customerID, "LowerDailyLimits", accountID, "", detail)
+		return now, nil
+	}
+	coolingOff := DefaultLimitCoolingOff
+	if b.config != nil {
+		coolingOff = b.config.coolingOff
+	}
+	effective := now.Add(coolingOff)
+	b.pendingLimits[accountID] = PendingLimits{Limits: limits, EffectiveAt: effective}
+	b.auditAction(customerID, "RaiseDailyLimits", accountID, "", detail+" from "+effective.UTC().Format(time.RFC3339))
+	_ = b.notify(customerID, NotificationLimitRaised, fmt.Sprintf("The daily limits on account %s were changed to %s. Raised limits take effect at %s. If you did not ask for this, contact us before then.",
+		accountID, describeLimits(limits), effective.Format(time.RFC1123)), NotifyUrgent)
+	return effective, nil
+}
+
+// describeLimits writes daily limits for a customer to read.
+func describeLimits(limits DailyLimits) string {
+	describe := func(limit account.Money) string {
+		if limit == 0 {
+			return "no limit"
+		}
+		return limit.String()
+	}
+	return fmt.Sprintf("withdrawals %s, transfers %s", describe(limits.Withdrawal), describe(limits.Transfer))
+}
+
+// applyPendingLimits puts raised limits into effect once their cooling-off period has passed.
+// The caller must hold the bank mutex.
+func (b *Bank) applyPendingLimits(accountID string) {
+	pending, exists := b.pendingLimits[accountID]
+	if !exists || b.now().Before(pending.EffectiveAt) {
+		return
+	}
+	b.putDailyLimits(accountID, pending.Limits)
+	delete(b.pendingLimits, accountID)
+}
+
+// DailyLimitsOf returns an account's daily limits and how much of them the last 24 hours have used.
+func (b *Bank) DailyLimitsOf(accountID string) (DailyLimits, DailyUsage, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.accounts.get(accountID); !exists {
+		return DailyLimits{}, DailyUsage{}, errors.New("account does not exist")
+	}
+	b.applyPendingLimits(accountID)
+	return b.profileOf(accountID).Limits, b.dailyUsage(accountID), nil
+}
+
+// PendingDailyLimits returns the raised daily limits of an account that are waiting out their cooling-off period.
+func (b *Bank) PendingDailyLimits(accountID string) (PendingLimits, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.applyPendingLimits(accountID)
+	pending, exists := b.pendingLimits[accountID]
+	return pending, exists
+}
+
+// dailyUsage totals an account's withdrawals and outgoing transfers in the last 24 hours from the event log, so the
+// window survives restarts.
+// The caller must hold the bank mutex.
+func (b *Bank) dailyUsage(accountID string) DailyUsage {
+	since := b.now().Add(-dailyLimitWindow)
+	var usage DailyUsage
+	for i := len(b.events) - 1; i >= 0 && b.events[i].At.After(since); i-- {
+		e := b.events[i]
+		if e.AccountID != accountID {
+			continue
+		}
+		switch e.Type {
+		case EventWithdrew:
+			usage.Withdrawal += e.Amount
+		case EventTransferred:
+			usage.Transfer += e.Amount
+		}
+	}
+	return usage
+}
+
+// checkDailyLimit rejects a withdrawal or transfer that would take the account past its daily limit.
+// The caller must hold the account's lock and the bank mutex.
+func (b *Bank) checkDailyLimit(accountID string, op transaction.OperationType, amount account.Money) error {
+	b.applyPendingLimits(accountID)
+	limits := b.profileOf(accountID).Limits
+	if limits == (DailyLimits{}) {
+		return nil
+	}
+	limit := limits.Withdrawal
+	if op == transaction.OpTransfer {
+		limit = limits.Transfer
+	}
+	if limit == 0 {
+		return nil
+	}
+	usage := b.dailyUsage(accountID)
+	used := usage.Withdrawal
+	if op == transaction.OpTransfer {
+		used = usage.Transfer
+	}
+	if used+amount > limit {
+		return &DailyLimitError{AccountID: accountID, Operation: op, Limit: limit, Used: used, Amount: amount}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package bank
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// limitStep is one transfer from A to B in a daily limit scenario, made after the clock moves on.
+type limitStep struct {
+	after  time.Duration
+	amount account.Money
+	want   ReasonCode
+}
+
+func TestDailyTransferLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []limitStep
+	}{
+		{
+			name:  "transfers up to the limit go through",
+			steps: []limitStep{{amount: 600}, {amount: 400}},
+		},
+		{
+			name:  "a transfer past the limit is declined",
+			steps: []limitStep{{amount: 600}, {amount: 401, want: ReasonDailyLimitExceeded}, {amount: 400}},
+		},
+		{
+			name:  "a single transfer over the limit is declined",
+			steps: []limitStep{{amount: 1001, want: ReasonDailyLimitExceeded}},
+		},
+		{
+			name: "the window rolls over 24 hours",
+			steps: []limitStep{
+				{amount: 1000},
+				{after: 23 * time.Hour, amount: 1, want: ReasonDailyLimitExceeded},
+				{after: time.Hour, amount: 1000},
+			},
+		},
+		{
+			name:  "declined transfers use none of the limit",
+			steps: []limitStep{{amount: 2000, want: ReasonDailyLimitExceeded}, {amount: 1000}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, clock := newTestBank(t, 10000, 0)
+			if err := b.SetDailyLimits("manager", "A", DailyLimits{Transfer: 1000}); err != nil {
+				t.Fatal(err)
+			}
+			for i, step := range tt.steps {
+				clock.advance(step.after)
+				if _, err := b.transfer("A", "B", step.amount); ReasonOf(err) != step.want {
+					t.Fatalf("step %d: transfer of %s declined with %q (%v), want %q", i, step.amount, ReasonOf(err), err, step.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSetDailyLimitsNeedsManager(t *testing.T) {
+	b, _ := newTestBank(t, 10000, 0)
+	if err := b.SetDailyLimits("alice", "A", DailyLimits{Transfer: 1000}); err == nil {
+		t.Error("SetDailyLimits by a customer succeeded, want it refused")
+	}
+}
+
+func TestChangeOwnDailyLimitsCoolingOff(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    DailyLimits
+		wantDelay time.Duration
+		steps     []limitStep
+	}{
+		{
+			name:   "lowered limits apply at once",
+			limits: DailyLimits{Transfer: 500},
+			steps:  []limitStep{{amount: 501, want: ReasonDailyLimitExceeded}, {amount: 500}},
+		},
+		{
+			name:      "raised limits wait out the cooling-off period",
+			limits:    DailyLimits{Transfer: 5000},
+			wantDelay: DefaultLimitCoolingOff,
+			steps: []limitStep{
+				{amount: 2000, want: ReasonDailyLimitExceeded},
+				{after: DefaultLimitCoolingOff - time.Minute, amount: 2000, want: ReasonDailyLimitExceeded},
+				{after: time.Minute, amount: 2000},
+			},
+		},
+		{
+			name:      "removing the limit counts as raising it",
+			limits:    DailyLimits{},
+			wantDelay: DefaultLimitCoolingOff,
+			steps: []limitStep{
+				{amount: 2000, want: ReasonDailyLimitExceeded},
+				{after: DefaultLimitCoolingOff, amount: 9000},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, clock := newTestBank(t, 10000, 0)
+			if err := b.AssignOwner("A", "alice"); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.SetDailyLimits("manager", "A", DailyLimits{Transfer: 1000}); err != nil {
+				t.Fatal(err)
+			}
+			effective, err := b.ChangeOwnDailyLimits("alice", "A", tt.limits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := clock.now.Add(tt.wantDelay); !effective.Equal(want) {
+				t.Errorf("limits take effect at %s, want %s", effective, want)
+			}
+			for i, step := range tt.steps {
+				clock.advance(step.after)
+				if _, err := b.transfer("A", "B", step.amount); ReasonOf(err) != step.want {
+					t.Fatalf("step %d: transfer of %s declined with %q (%v), want %q", i, step.amount, ReasonOf(err), err, step.want)
+				}
+			}
+		})
+	}
+}
+
+func TestChangeOwnDailyLimitsNeedsOwner(t *testing.T) {
+	b, _ := newTestBank(t, 10000, 0)
+	if err := b.AssignOwner("A", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.ChangeOwnDailyLimits("mallory", "A", DailyLimits{}); ReasonOf(err) != ReasonNotAuthorized {
+		t.Errorf("ChangeOwnDailyLimits by another customer: %v, want it refused", err)
+	}
+}
@@ -0,0 +1,122 @@
+package bank
+
+import (
+	"errors"
+	"sort"
+)
+
+// ReasonCode is a stable, machine-readable code for why the bank rejected an operation. Messages may be reworded;
+// codes are not.
+type ReasonCode string
+
+const (
+	ReasonAccountNotFound    ReasonCode = "account_not_found"
+	ReasonAccountState       ReasonCode = "account_state" // The account is frozen, dormant or closed
+	ReasonInvalidAmount      ReasonCode = "invalid_amount"
+	ReasonSameAccount        ReasonCode = "same_account"
+	ReasonLimitExceeded      ReasonCode = "limit_exceeded" // Larger than the configured single-operation limit
+	ReasonDailyLimitExceeded ReasonCode = "daily_limit_exceeded"
+	ReasonInsufficientFunds  ReasonCode = "insufficient_funds"
+	ReasonCurrency           ReasonCode = "currency" // No usable exchange rate, or currencies that cannot mix
+	ReasonDuplicate          ReasonCode = "duplicate"
+	ReasonNotAuthorized      ReasonCode = "not_authorized"
+	ReasonQuotaExceeded      ReasonCode = "quota_exceeded"
+	ReasonRuleDeclined       ReasonCode = "rule_declined" // A rule added with SetTransferRules, or a configured decline rule, rejected the operation
+	ReasonSanctions          ReasonCode = "sanctions"     // A sanctions screening match is pending or confirmed
+	ReasonCorridor           ReasonCode = "corridor"      // A cross-border transfer is held for a corridor review
+	ReasonTravelRule         ReasonCode = "travel_rule"   // Originator or beneficiary details are missing
+	ReasonTransfersPaused    ReasonCode = "paused"        // Outgoing transfers are paused bank-wide
+	ReasonSignaturesRequired ReasonCode = "signatures"    // Every owner of a joint account must approve the debit
+	ReasonUnknownPayee       ReasonCode = "payee"         // The destination is not in the customer's payee directory
+	ReasonNewPayee           ReasonCode = "new_payee"     // The payee is still in its cooling-off period
+	ReasonApprovalRequired   ReasonCode = "approval"      // Withdrawals over the rail need a manager's approval
+	ReasonAlreadyReversed    ReasonCode = "reversed"      // The transfer has already been reversed
+	ReasonUnsettled          ReasonCode = "unsettled"     // The transfer awaits compensation or a fraud review
+	ReasonBalance            ReasonCode = "balance"       // The account cannot be closed with the money in it
+	ReasonMinimumBalance     ReasonCode = "minimum"       // The debit would take the account below its minimum balance
+	ReasonOther              ReasonCode = "other"
+)
+
+// DeclineError rejects an operation with a reason code for programs and a message for people.
+type DeclineError struct {
+	Code    ReasonCode
+	Message string
+	Err     error // Underlying error, if the decline wraps one
+}
+
+func (e *DeclineError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the underlying error.
+func (e *DeclineError) Unwrap() error {
+	return e.Err
+}
+
+// ReasonCode returns the decline's code.
+func (e *DeclineError) ReasonCode() ReasonCode {
+	return e.Code
+}
+
+// decline returns an error rejecting an operation for a reason.
+func decline(code ReasonCode, message string) error {
+	return &DeclineError{Code: code, Message: message}
+}
+
+// ReasonOf returns the reason code of an error that rejected an operation, ReasonOther if it has none, or "" for a
+// nil error.
+func ReasonOf(err error) ReasonCode {
+	if err == nil {
+		return ""
+	}
+	var coded interface{ ReasonCode() ReasonCode }
+	if errors.As(err, &coded) {
+		return coded.ReasonCode()
+	}
+	// The account package reports its own rejections as plain errors
+	if insufficientFunds(err) {
+		return ReasonInsufficientFunds
+	}
+	return ReasonOther
+}
+
+// ReasonCode returns ReasonDailyLimitExceeded.
+func (e *DailyLimitError) ReasonCode() ReasonCode {
+	return ReasonDailyLimitExceeded
+}
+
+// ReasonCode returns ReasonDuplicate.
+func (e *DuplicateTransferError) ReasonCode() ReasonCode {
+	return ReasonDuplicate
+}
+
+// DeclineCount is how many failed transactions the history records for one reason.
+type DeclineCount struct {
+	Reason ReasonCode
+	Count  int
+}
+
+// DeclineSummary counts the failed transactions in the history by reason code, most frequent first.
+func (b *Bank) DeclineSummary() []DeclineCount {
+	b.mutex.RLock()
+	counts := make(map[ReasonCode]int)
+	for _, entry := range b.transactionHist {
+		for _, field := range historyFields(entry) {
+			if field[0] == "Reason Code" {
+				counts[ReasonCode(field[1])]++
+			}
+		}
+	}
+	b.mutex.RUnlock()
+	summary := make([]DeclineCount, 0, len(counts))
+	for reason, count := range counts {
+		summary = append(summary, DeclineCount{Reason: reason, Count: count})
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		if summary[i].Count != summary[j].Count {
+			return summary[i].Count > summary[j].Count
+		}
+		return summary[i].Reason < summary[j].Reason
+	})
+	return summary
+}
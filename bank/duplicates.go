@@ -0,0 +1,185 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// DuplicateAction is what happens when a transfer looks like a duplicate.
+type DuplicateAction int
+
+const (
+	DuplicateAllow   DuplicateAction = iota // Do not check for duplicates
+	DuplicateWarn                           // Execute, but report the suspected original
+	DuplicateConfirm                        // Refuse until the caller confirms
+	DuplicateBlock                          // Always refuse
+)
+
+// DuplicatePolicy configures duplicate transfer detection.
+type DuplicatePolicy struct {
+	Window time.Duration // How far back to look for an identical transfer
+	Action DuplicateAction
+}
+
+// recentTransfer is a completed transfer kept for duplicate detection.
+type recentTransfer struct {
+	txnID  string
+	fromID string
+	toID   string
+	amount account.Money
+	at     time.Time
+}
+
+// TransferOptions carries optional controls for TransferChecked.
+type TransferOptions struct {
+	IdempotencyKey   string // Retrying with the same key returns the original transaction instead of paying twice
+	ConfirmDuplicate bool   // Proceed even if the transfer looks like a duplicate
+	Country          string // Destination country of a cross-border transfer, checked against the corridor rules
+	// Originator and beneficiary details, required over the travel rule threshold. Blank accounts are taken to be the
+	// transfer's own.
+	TravelRule *TravelRuleData
+	Memo       transaction.Memo // Recorded with the transfer in the transaction history
+}
+
+// TransferResult describes a transfer made by TransferChecked.
+type TransferResult struct {
+	TxnID       string
+	DuplicateOf string // Suspected original when the transfer looked like a duplicate
+	Replayed    bool   // True when the idempotency key matched an earlier transfer
+}
+
+// DuplicateTransferError is returned when a transfer is held back as a likely duplicate.
+type DuplicateTransferError struct {
+	OriginalTxnID     string
+	NeedsConfirmation bool // True if confirming would let the transfer through
+}
+
+func (e *DuplicateTransferError) Error() string {
+	if e.NeedsConfirmation {
+		return fmt.Sprintf("transfer looks like a duplicate of %s; confirmation required", e.OriginalTxnID)
+	}
+	return fmt.Sprintf("transfer blocked as a duplicate of %s", e.OriginalTxnID)
+}
+
+// SetDuplicatePolicy configures duplicate transfer detection.
+func (b *Bank) SetDuplicatePolicy(policy DuplicatePolicy) error {
+	if policy.Action != DuplicateAllow && policy.Window <= 0 {
+		return errors.New("duplicate window must be positive")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.duplicatePolicy = policy
+	return nil
+}
+
+// rememberTransfer records a completed transfer and drops ones older than the window.
+// The caller must hold the bank mutex.
+func (b *Bank) rememberTransfer(txnID, fromID, toID string, amount account.Money) {
+	now := b.now()
+	kept := b.recentTransfers[:0]
+	for _, t := range b.recentTransfers {
+		if now.Sub(t.at) <= b.duplicatePolicy.Window {
+			kept = append(kept, t)
+		}
+	}
+	b.recentTransfers = append(kept, recentTransfer{txnID: txnID, fromID: fromID, toID: toID, amount: amount, at: now})
+}
+
+// findDuplicate returns the most recent identical transfer within the window, if any.
+// The caller must hold the bank mutex.
+func (b *Bank) findDuplicate(fromID, toID string, amount account.Money) string {
+	now := b.now()
+	for i := len(b.recentTransfers) - 1; i >= 0; i-- {
+		t := b.recentTransfers[i]
+		if now.Sub(t.at) > b.duplicatePolicy.Window {
+			break
+		}
+		if t.fromID == fromID && t.toID == toID && t.amount == amount {
+			return t.txnID
+		}
+	}
+	return ""
+}
+
+// TransferChecked transfers funds with duplicate detection and optional idempotency.
+// Transfers carrying an idempotency key are never treated as duplicates: a repeated key
+// returns the original transaction instead. Cross-border transfers a corridor rule holds back
+// are declined with ReasonCorridor and queued for a manager's review. While outgoing transfers are paused, transfers
+// are declined with ReasonTransfersPaused.
+func (b *Bank) TransferChecked(fromID, toID string, amount account.Money, opts TransferOptions) (TransferResult, error) {
+	if err := opts.Memo.Validate(); err != nil {
+		return TransferResult{}, err
+	}
+	travelRule := opts.TravelRule.forTransfer(fromID, toID)
+	if opts.Country != "" {
+		country, err := normalizeCountry(opts.Country)
+		if err != nil {
+			return TransferResult{}, err
+		}
+		if err := b.checkCorridor(fromID, toID, amount, country, travelRule); err != nil {
+			return TransferResult{}, err
+		}
+		opts.Country = country
+	}
+
+	b.mutex.Lock()
+	if opts.IdempotencyKey != "" {
+		if txnID, seen := b.idempotencyKeys[opts.IdempotencyKey]; seen {
+			b.mutex.Unlock()
+			if txnID == "" {
+				return TransferResult{}, errors.New("a transfer with this idempotency key is already in progress")
+			}
+			return TransferResult{TxnID: txnID, Replayed: true}, nil
+		}
+		// Reserve the key so a concurrent retry cannot pay twice
+		b.idempotencyKeys[opts.IdempotencyKey] = ""
+	}
+
+	var duplicateOf string
+	if opts.IdempotencyKey == "" && b.duplicatePolicy.Action != DuplicateAllow {
+		duplicateOf = b.findDuplicate(fromID, toID, amount)
+	}
+	action := b.duplicatePolicy.Action
+	b.mutex.Unlock()
+
+	if duplicateOf != "" {
+		switch action {
+		case DuplicateBlock:
+			return TransferResult{}, &DuplicateTransferError{OriginalTxnID: duplicateOf}
+		case DuplicateConfirm:
+			if !opts.ConfirmDuplicate {
+				return TransferResult{}, &DuplicateTransferError{OriginalTxnID: duplicateOf, NeedsConfirmation: true}
+			}
+		}
+	}
+
+	txnID, err := b.sendTransfer(PendingTransfer{FromID: fromID, ToID: toID, Amount: amount, Country: opts.Country, TravelRule: travelRule})
+
+	if opts.IdempotencyKey != "" {
+		b.mutex.Lock()
+		if err != nil {
+			delete(b.idempotencyKeys, opts.IdempotencyKey)
+		} else {
+			b.idempotencyKeys[opts.IdempotencyKey] = txnID
+		}
+		b.mutex.Unlock()
+	}
+	if err == nil && opts.Country != "" {
+		b.mutex.Lock()
+		b.annotateTransaction(txnID, "Country: "+opts.Country)
+		b.mutex.Unlock()
+	}
+	if err == nil && !opts.Memo.IsZero() {
+		b.mutex.Lock()
+		b.noteMemo(txnID, opts.Memo)
+		b.mutex.Unlock()
+	}
+	if err != nil {
+		return TransferResult{}, err
+	}
+	return TransferResult{TxnID: txnID, DuplicateOf: duplicateOf}, nil
+}
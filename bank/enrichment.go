@@ -0,0 +1,77 @@
+package bank
+
+import (
+	"errors"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// SetEnrichmentPipeline replaces the stages run on new transaction descriptions, in order.
+func (b *Bank) SetEnrichmentPipeline(enrichers ...transaction.Enricher) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.enrichers = append([]transaction.Enricher(nil), enrichers...)
+}
+
+// enrichDescription runs the pipeline over a raw description.
+// The caller must hold the bank mutex.
+func (b *Bank) enrichDescription(raw string) transaction.Description {
+	desc := transaction.Description{Raw: raw}
+	for _, e := range b.enrichers {
+		e.Enrich(&desc)
+	}
+	return desc
+}
+
+// describeTransaction runs the pipeline over a raw description and stores the result.
+// The caller must hold the bank mutex.
+func (b *Bank) describeTransaction(txnID, raw string) transaction.Description {
+	desc := b.enrichDescription(raw)
+	b.descriptions[txnID] = desc
+	return desc
+}
+
+// DescribeTransaction attaches a description to an existing transaction, enriching it.
+func (b *Bank) DescribeTransaction(txnID, raw string) (transaction.Description, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.transactionHist[txnID]; !exists {
+		return transaction.Description{}, errors.New("transaction does not exist")
+	}
+	return b.describeTransaction(txnID, raw), nil
+}
+
+// TransactionDescriptionOf returns the stored description of a transaction.
+func (b *Bank) TransactionDescriptionOf(txnID string) (transaction.Description, bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	desc, ok := b.descriptions[txnID]
+	return desc, ok
+}
+
+// TransferWithDescription transfers funds and records an enriched description of the payment. The counterparty the
+// description names is screened against the sanctions lists first, and the transfer is declined if it matches.
+func (b *Bank) TransferWithDescription(fromID, toID string, amount account.Money, description string) (string, error) {
+	return b.transferDescribed(PendingTransfer{FromID: fromID, ToID: toID, Amount: amount}, description)
+}
+
+// transferDescribed is TransferWithDescription for a transfer that may carry travel rule details.
+func (b *Bank) transferDescribed(t PendingTransfer, description string) (string, error) {
+	fromID := t.FromID
+	b.mutex.Lock()
+	desc := b.enrichDescription(description)
+	err := b.screenPayee(fromID, desc.Counterparty)
+	b.mutex.Unlock()
+	if err != nil {
+		return "", err
+	}
+	txnID, err := b.sendTransfer(t)
+	if err != nil {
+		return "", err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.descriptions[txnID] = desc
+	return txnID, nil
+}
@@ -0,0 +1,322 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// EventType identifies the kind of state change an event records.
+type EventType string
+
+const (
+	EventAccountCreated EventType = "AccountCreated"
+	EventDeposited      EventType = "Deposited"
+	EventWithdrew       EventType = "Withdrew"
+	EventTransferred    EventType = "Transferred"
+	EventFeeCharged     EventType = "FeeCharged"
+	EventInterestPosted EventType = "InterestPosted"
+	EventAccountUpdated EventType = "AccountUpdated" // internal account state changed, e.g. a recurring deposit instalment
+	EventStateChanged   EventType = "StateChanged"
+	EventAccountClosed  EventType = "AccountClosed"
+	EventTaxWithheld    EventType = "TaxWithheld"   // Tax withheld from interest moved from AccountID to the tax account ToID
+	EventAccountPurged  EventType = "AccountPurged" // A long-closed account removed from the bank; see PurgeClosed
+
+	EventAccountConverted     EventType = "AccountConverted"     // An account moved to another product; see ConvertAccount
+	EventCompensationPending  EventType = "CompensationPending"  // A transfer debited its source but could not credit or refund
+	EventCompensationResolved EventType = "CompensationResolved" // A pending transfer's amount was credited to AccountID
+)
+
+// Event is an immutable record of one change to the bank's accounts. Events are numbered in the order the changes
+// were applied, and replaying them in that order rebuilds the accounts.
+type Event struct {
+	Seq           int64           `json:"seq"`
+	Type          EventType       `json:"type"`
+	At            time.Time       `json:"at"`
+	AccountID     string          `json:"accountId"`
+	ToID          string          `json:"toId,omitempty"` // Destination of a transfer
+	Amount        account.Money   `json:"amountMinor,omitempty"`
+	ToAmount      account.Money   `json:"toAmountMinor,omitempty"` // Amount credited by a transfer between currencies
+	TransactionID string          `json:"transactionId,omitempty"`
+	State         account.State   `json:"state,omitempty"`         // New state for StateChanged and AccountClosed
+	Reason        string          `json:"reason,omitempty"`        // Fee rule for FeeCharged, product for AccountConverted, and overdraft protection transfers
+	Record        *account.Record `json:"record,omitempty"`        // The whole account after the change, when amounts alone cannot describe it
+	CorrelationID string          `json:"correlationId,omitempty"` // The incoming operation that caused the change; see RunCorrelated
+	TravelRule    *TravelRuleData `json:"travelRule,omitempty"`    // Originator and beneficiary of a transfer over the travel rule threshold
+	Channel       Channel         `json:"channel,omitempty"`       // Where the operation that caused the change came from
+	Rail          Rail            `json:"rail,omitempty"`          // How the money of a deposit or withdrawal came in or left
+}
+
+// EventStorage is implemented by storage backends that keep the event log.
+type EventStorage interface {
+	AppendEvent(e Event) error
+	LoadEvents() ([]Event, error)
+}
+
+// recordEvent numbers an event, adds it to the log, audits it, appends it to storage, queues it for webhook
+// subscribers and for subscribed handlers.
+// The caller must hold the bank mutex.
+func (b *Bank) recordEvent(e Event) {
+	b.eventSeq++
+	e.Seq = b.eventSeq
+	e.At = b.now()
+	if e.CorrelationID == "" {
+		e.CorrelationID = b.correlationOf(e.AccountID, e.ToID)
+	}
+	if e.Channel == "" {
+		e.Channel = b.channelOf(e.AccountID, e.ToID)
+	}
+	b.events = append(b.events, e)
+	if e.Type == EventAccountPurged {
+		b.purgedIDs[e.AccountID] = true
+	}
+	b.auditEvent(e)
+	b.queueWebhooks(e)
+	b.publishEvent(e)
+	es, ok := b.storage.(EventStorage)
+	if !ok {
+		return
+	}
+	if err := es.AppendEvent(e); err != nil && b.persistErr == nil {
+		b.persistErr = err
+	}
+}
+
+// recordAccountEvent records an event carrying a snapshot of the account after the change.
+// The caller must hold the bank mutex and the account's lock.
+func (b *Bank) recordAccountEvent(eventType EventType, acc account.Account, e Event) {
+	rec, err := account.ToRecord(acc)
+	if err != nil {
+		return
+	}
+	e.Type = eventType
+	e.AccountID = acc.ID()
+	e.Record = &rec
+	b.recordEvent(e)
+	if eventType == EventAccountCreated {
+		b.noteAccountOpened(e.AccountID, b.now())
+	}
+}
+
+// Events returns the event log, oldest first.
+func (b *Bank) Events() []Event {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return append([]Event(nil), b.events...)
+}
+
+// ReplayFrom rebuilds every account, balance and lifecycle state from an event log, replacing the accounts the bank
+// holds, and adopts the log as its own. Owners, branches, profiles and transaction history are kept as they are, and
+// interest accrued since an account's last posting is caught up on the next accrual run. Nothing is changed if the log
+// cannot be replayed.
+func (b *Bank) ReplayFrom(events []Event) error {
+	accounts, states, err := replayEvents(events)
+	if err != nil {
+		return err
+	}
+	order := make([]string, 0, len(accounts))
+	for _, acc := range accounts {
+		order = append(order, acc.ID())
+	}
+
+	// Hold every account so nothing moves while the bank's accounts are swapped out
+	b.mutex.Lock()
+	ids := make([]string, 0, b.accounts.len()+len(order))
+	for id := range b.accounts.all() {
+		ids = append(ids, id)
+	}
+	b.mutex.Unlock()
+	unlock := b.lockAccounts(append(ids, order...)...)
+	defer unlock()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	previous := b.accounts
+	b.accounts = newAccountTable()
+	b.totals.replace(newAggregates())
+	for _, acc := range accounts {
+		b.registerAccount(acc, states[acc.ID()])
+		if p := previous.profile(acc.ID()); p != nil {
+			*b.accounts.profile(acc.ID()) = *p
+		}
+	}
+	b.events = append([]Event(nil), events...)
+	b.eventSeq = 0
+	if len(events) > 0 {
+		b.eventSeq = events[len(events)-1].Seq
+	}
+	b.indexPurgedIDs()
+	return nil
+}
+
+// indexPurgedIDs rebuilds the set of purged account IDs from the event log.
+// The caller must hold the bank mutex.
+func (b *Bank) indexPurgedIDs() {
+	b.purgedIDs = make(map[string]bool)
+	for _, e := range b.events {
+		if e.Type == EventAccountPurged {
+			b.purgedIDs[e.AccountID] = true
+		}
+	}
+}
+
+// replayEvents rebuilds the accounts an event log describes, in the order they were opened, and their states.
+func replayEvents(events []Event) ([]account.Account, map[string]account.State, error) {
+	records := make(map[string]*account.Record)
+	states := make(map[string]account.State)
+	var order []string
+	lookup := func(e Event, id string) (*account.Record, error) {
+		rec, exists := records[id]
+		if !exists {
+			return nil, fmt.Errorf("event %d refers to unknown account %s", e.Seq, id)
+		}
+		return rec, nil
+	}
+	for _, e := range events {
+		switch e.Type {
+		case EventAccountCreated:
+			if e.Record == nil {
+				return nil, nil, fmt.Errorf("event %d has no account record", e.Seq)
+			}
+			if _, exists := records[e.AccountID]; exists {
+				return nil, nil, fmt.Errorf("event %d creates account %s twice", e.Seq, e.AccountID)
+			}
+			rec := *e.Record
+			records[e.AccountID] = &rec
+			states[e.AccountID] = account.StateOpen
+			order = append(order, e.AccountID)
+		case EventAccountUpdated, EventInterestPosted, EventAccountConverted:
+			if _, err := lookup(e, e.AccountID); err != nil {
+				return nil, nil, err
+			}
+			if e.Record == nil {
+				return nil, nil, fmt.Errorf("event %d has no account record", e.Seq)
+			}
+			rec := *e.Record
+			records[e.AccountID] = &rec
+		case EventDeposited, EventCompensationResolved:
+			rec, err := lookup(e, e.AccountID)
+			if err != nil {
+				return nil, nil, err
+			}
+			rec.Balance += e.Amount
+		case EventWithdrew, EventFeeCharged, EventCompensationPending:
+			rec, err := lookup(e, e.AccountID)
+			if err != nil {
+				return nil, nil, err
+			}
+			rec.Balance -= e.Amount
+		case EventTransferred, EventTaxWithheld:
+			from, err := lookup(e, e.AccountID)
+			if err != nil {
+				return nil, nil, err
+			}
+			to, err := lookup(e, e.ToID)
+			if err != nil {
+				return nil, nil, err
+			}
+			from.Balance -= e.Amount
+			if e.ToAmount != 0 {
+				to.Balance += e.ToAmount
+			} else {
+				to.Balance += e.Amount
+			}
+		case EventStateChanged, EventAccountClosed:
+			if _, err := lookup(e, e.AccountID); err != nil {
+				return nil, nil, err
+			}
+			states[e.AccountID] = e.State
+		case EventAccountPurged:
+			if _, err := lookup(e, e.AccountID); err != nil {
+				return nil, nil, err
+			}
+			delete(records, e.AccountID)
+			delete(states, e.AccountID)
+			for i, id := range order {
+				if id == e.AccountID {
+					order = append(order[:i], order[i+1:]...)
+					break
+				}
+			}
+		default:
+			return nil, nil, fmt.Errorf("event %d has unknown type %q", e.Seq, e.Type)
+		}
+	}
+
+	accounts := make([]account.Account, 0, len(order))
+	for _, id := range order {
+		acc, err := accountFromRecord(*records[id])
+		if err != nil {
+			return nil, nil, err
+		}
+		accounts = append(accounts, acc)
+	}
+	return accounts, states, nil
+}
+
+// RecoverFromEventLog rebuilds the bank from the event log held in storage, e.g. after a snapshot is lost or
+// found to be inconsistent.
+func (b *Bank) RecoverFromEventLog() error {
+	es, ok := b.storage.(EventStorage)
+	if !ok {
+		return errors.New("storage does not keep an event log")
+	}
+	events, err := es.LoadEvents()
+	if err != nil {
+		return err
+	}
+	return b.ReplayFrom(events)
+}
+
+// VerifyEventLog replays the event log and checks the balances and states it produces match the bank's accounts,
+// reporting every account that differs. Every account is locked for the duration, so the check sees a consistent
+// picture.
+func (b *Bank) VerifyEventLog() error {
+	b.mutex.Lock()
+	ids := make([]string, 0, b.accounts.len())
+	for id := range b.accounts.all() {
+		ids = append(ids, id)
+	}
+	b.mutex.Unlock()
+	unlock := b.lockAccounts(ids...)
+	defer unlock()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	accounts, states, err := replayEvents(b.events)
+	if err != nil {
+		return fmt.Errorf("event log cannot be replayed: %w", err)
+	}
+	replayed := make(map[string]account.Account, len(accounts))
+	for _, acc := range accounts {
+		replayed[acc.ID()] = acc
+	}
+	var diffs []string
+	for id, acc := range b.accounts.all() {
+		r, exists := replayed[id]
+		if !exists {
+			diffs = append(diffs, fmt.Sprintf("account %s is missing from the event log", id))
+			continue
+		}
+		if r.Balance() != acc.Balance() {
+			diffs = append(diffs, fmt.Sprintf("account %s balance %s, event log %s", id, acc.Balance(), r.Balance()))
+		}
+		if states[id] != b.accounts.state(id) {
+			diffs = append(diffs, fmt.Sprintf("account %s is %s, event log %s", id, b.accounts.state(id), states[id]))
+		}
+	}
+	for id := range replayed {
+		if _, exists := b.accounts.get(id); !exists {
+			diffs = append(diffs, fmt.Sprintf("account %s is only in the event log", id))
+		}
+	}
+	if len(diffs) > 0 {
+		sort.Strings(diffs)
+		return errors.New("accounts do not match the event log: " + strings.Join(diffs, "; "))
+	}
+	return nil
+}
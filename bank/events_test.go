@@ -0,0 +1,143 @@
+package bank
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// eventfulBank returns a test bank whose event log holds deposits, withdrawals, transfers, a reversal and state
+// changes.
+func eventfulBank(t *testing.T) *Bank {
+	t.Helper()
+	b, _ := newTestBank(t, 1000, 500, 0)
+	if err := b.CreateAccount(account.NewChecking("D", 0, 1000, 0.1)); err != nil {
+		t.Fatal(err)
+	}
+	steps := []func() error{
+		func() error { return b.Deposit("A", 250) },
+		func() error { return b.Withdraw("B", 100) },
+		func() error { _, err := b.transfer("A", "C", 300); return err },
+		func() error { _, err := b.transfer("D", "B", 700); return err },
+		func() error {
+			txnID, err := b.transfer("B", "C", 200)
+			if err != nil {
+				return err
+			}
+			_, err = b.ReverseTransaction("manager", txnID, "sent in error")
+			return err
+		},
+		func() error { return b.Freeze("B") },
+		func() error { _, err := b.moveFunds("C", "A", 300); return err },
+		func() error { return b.Close("C") },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+	}
+	return b
+}
+
+func TestVerifyEventLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func(b *Bank)
+		wantErr string // "" when the log matches the accounts
+	}{
+		{name: "log matches the accounts"},
+		{
+			name:    "balance changed outside the log",
+			tamper:  func(b *Bank) { b.accounts.at("A").Deposit(1) },
+			wantErr: "account A balance 12.51, event log 12.50",
+		},
+		{
+			name:    "state changed outside the log",
+			tamper:  func(b *Bank) { b.accounts.setState("B", account.StateOpen) },
+			wantErr: "account B is open, event log frozen",
+		},
+		{
+			name:    "account missing from the log",
+			tamper:  func(b *Bank) { b.accounts.put(account.NewSavings("E", 0, 0), account.StateOpen) },
+			wantErr: "account E is missing from the event log",
+		},
+		{
+			name:    "account only in the log",
+			tamper:  func(b *Bank) { b.accounts.remove("D") },
+			wantErr: "account D is only in the event log",
+		},
+		{
+			name:    "log that cannot be replayed",
+			tamper:  func(b *Bank) { b.events = b.events[1:] },
+			wantErr: "event log cannot be replayed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := eventfulBank(t)
+			if tt.tamper != nil {
+				tt.tamper(b)
+			}
+			err := b.VerifyEventLog()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("VerifyEventLog: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("VerifyEventLog error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReplayFrom(t *testing.T) {
+	tests := []struct {
+		name string
+		// events picks the log to replay from the original bank's
+		events  func(events []Event) []Event
+		wantErr bool
+	}{
+		{name: "whole log", events: func(events []Event) []Event { return events }},
+		{name: "log whose first event is missing", events: func(events []Event) []Event { return events[1:] }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := eventfulBank(t)
+			events := tt.events(original.Events())
+
+			// The replaying bank starts out holding other accounts, which the replay replaces
+			replayed, _ := newTestBank(t, 42)
+			err := replayed.ReplayFrom(events)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReplayFrom error = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil {
+				if got := balanceOf(t, replayed, "A"); got != 42 || len(replayed.Events()) != 1 {
+					t.Errorf("failed replay changed the bank: A holds %s, %d events", got, len(replayed.Events()))
+				}
+				return
+			}
+			if err := replayed.VerifyEventLog(); err != nil {
+				t.Errorf("VerifyEventLog after replay: %v", err)
+			}
+			for _, id := range []string{"A", "B", "C", "D"} {
+				if got, want := balanceOf(t, replayed, id), balanceOf(t, original, id); got != want {
+					t.Errorf("account %s balance %s, want %s", id, got, want)
+				}
+				got, _ := replayed.AccountStateOf(id)
+				if want, _ := original.AccountStateOf(id); got != want {
+					t.Errorf("account %s is %s, want %s", id, got, want)
+				}
+			}
+			if got, want := len(replayed.Events()), len(original.Events()); got != want {
+				t.Errorf("replayed bank has %d events, want %d", got, want)
+			}
+			if total := replayed.TotalBalance(); total != original.TotalBalance() {
+				t.Errorf("total balance %s, want %s", total, original.TotalBalance())
+			}
+		})
+	}
+}
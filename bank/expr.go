@@ -0,0 +1,511 @@
+package bank
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// exprKind is the type of a value in a rule expression.
+type exprKind int
+
+const (
+	kindNumber exprKind = iota + 1
+	kindString
+	kindBool
+	kindStrings // A list of strings, e.g. an account's tags
+	kindNumbers // A list of numbers
+)
+
+// String names the kind as error messages do.
+func (k exprKind) String() string {
+	switch k {
+	case kindNumber:
+		return "a number"
+	case kindString:
+		return "a string"
+	case kindBool:
+		return "true or false"
+	case kindStrings:
+		return "a list of strings"
+	case kindNumbers:
+		return "a list of numbers"
+	}
+	return "nothing"
+}
+
+// ruleVariables are the variables rule expressions may refer to, with their kinds. Variables an operation does not
+// have, such as the destination of a deposit, are empty strings.
+var ruleVariables = map[string]exprKind{
+	"amount":   kindNumber,  // The operation's amount in major units, e.g. 1000.5 for 1,000.50
+	"balance":  kindNumber,  // The account's balance before the operation, in major units
+	"op":       kindString,  // "deposit", "withdrawal" or "transfer"
+	"channel":  kindString,  // Where the operation came from, e.g. "cli" or "api"
+	"account":  kindString,  // The account credited by a deposit or debited otherwise
+	"to":       kindString,  // The destination of a transfer
+	"country":  kindString,  // The destination country of a cross-border transfer
+	"customer": kindString,  // The account's owning customer
+	"type":     kindString,  // The account's type, e.g. "savings"
+	"tags":     kindStrings, // The account's tags
+	"rail":     kindString,  // How the money came in or left: "cash", "atm", "wire", "ach" or "internal" for transfers
+	"hour":     kindNumber,  // The hour of the day, 0 to 23, in the bank's time zone
+	"weekday":  kindString,  // The day of the week in lower case, e.g. "monday"
+}
+
+// Expression is a parsed rule expression: a condition such as `amount > 1000 && channel == "api"` over an
+// operation's variables. Expressions support numbers, double-quoted strings, true and false, lists such as
+// ["api", "cli"], the operators || && ! == != < <= > >= in + - * / and parentheses. They are type checked when
+// parsed, so evaluating one cannot fail.
+type Expression struct {
+	source string
+	eval   func(vars map[string]any) any
+}
+
+// ParseExpression parses and type checks a rule expression, which must be true or false.
+func ParseExpression(source string) (*Expression, error) {
+	tokens, err := lexExpression(source)
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %w", source, err)
+	}
+	p := &exprParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err == nil && p.peek().text != "" {
+		err = p.fail(p.peek(), "unexpected "+p.peek().text)
+	}
+	if err == nil && node.kind != kindBool {
+		err = fmt.Errorf("must be true or false, not %s", node.kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %w", source, err)
+	}
+	return &Expression{source: source, eval: node.eval}, nil
+}
+
+// String returns the expression as it was written.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Matches evaluates the expression over an operation's variables, with numbers as float64 and lists as []string or
+// []float64. Variables missing from vars are zero: 0, "", false or an empty list.
+func (e *Expression) Matches(vars map[string]any) bool {
+	return e.eval(vars).(bool)
+}
+
+// exprToken is one token of a rule expression.
+type exprToken struct {
+	pos   int    // Byte offset in the source, for error messages
+	text  string // The operator, identifier or literal as written; "" at the end of the source
+	value any    // Value of a number or string literal, nil otherwise
+}
+
+// lexExpression splits a rule expression into tokens, ending with an empty one.
+func lexExpression(source string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(source); {
+		c := rune(source[i])
+		start := i
+		switch {
+		case unicode.IsSpace(c):
+			i++
+			continue
+		case c >= '0' && c <= '9' || c == '.':
+			for i < len(source) && (source[i] >= '0' && source[i] <= '9' || source[i] == '.') {
+				i++
+			}
+			n, err := strconv.ParseFloat(source[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("at %d: %s is not a number", start+1, source[start:i])
+			}
+			tokens = append(tokens, exprToken{pos: start, text: source[start:i], value: n})
+		case c == '"':
+			for i++; i < len(source) && source[i] != '"'; i++ {
+				if source[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(source) {
+				return nil, fmt.Errorf("at %d: string is not closed", start+1)
+			}
+			i++
+			s, err := strconv.Unquote(source[start:i])
+			if err != nil {
+				return nil, fmt.Errorf("at %d: %s is not a valid string", start+1, source[start:i])
+			}
+			tokens = append(tokens, exprToken{pos: start, text: source[start:i], value: s})
+		case isIdentStart(c):
+			for i < len(source) && (isIdentStart(rune(source[i])) || source[i] >= '0' && source[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, exprToken{pos: start, text: source[start:i]})
+		default:
+			if i+1 < len(source) {
+				switch op := source[i : i+2]; op {
+				case "&&", "||", "==", "!=", "<=", ">=":
+					tokens = append(tokens, exprToken{pos: start, text: op})
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("<>!+-*/()[],", c) {
+				return nil, fmt.Errorf("at %d: unexpected %q", start+1, c)
+			}
+			tokens = append(tokens, exprToken{pos: start, text: string(c)})
+			i++
+		}
+	}
+	return append(tokens, exprToken{pos: len(source)}), nil
+}
+
+// isIdentStart reports whether a character can begin a variable or keyword: an ASCII letter or underscore.
+func isIdentStart(c rune) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// exprNode is a type checked part of an expression.
+type exprNode struct {
+	kind exprKind
+	eval func(vars map[string]any) any
+}
+
+// exprParser parses rule expressions by recursive descent, from the loosest-binding operator to the tightest.
+type exprParser struct {
+	tokens []exprToken
+	next   int
+}
+
+// peek returns the next token without consuming it.
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.next]
+}
+
+// accept consumes the next token if it is the given operator or keyword.
+func (p *exprParser) accept(text string) bool {
+	if t := p.peek(); t.value == nil && t.text == text {
+		p.next++
+		return true
+	}
+	return false
+}
+
+// fail reports a problem at a token.
+func (p *exprParser) fail(t exprToken, message string) error {
+	return fmt.Errorf("at %d: %s", t.pos+1, message)
+}
+
+// operand parses an operand of a binary operator with the given parse function and checks its kind.
+func (p *exprParser) operand(op string, parse func() (exprNode, error), kinds ...exprKind) (exprNode, error) {
+	t := p.peek()
+	node, err := parse()
+	if err != nil {
+		return exprNode{}, err
+	}
+	for _, kind := range kinds {
+		if node.kind == kind {
+			return node, nil
+		}
+	}
+	return exprNode{}, p.fail(t, fmt.Sprintf("%s needs %s, not %s", op, kinds[0], node.kind))
+}
+
+// parseOr parses a || b.
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return exprNode{}, err
+	}
+	for p.peek().text == "||" {
+		if left.kind != kindBool {
+			return exprNode{}, p.fail(p.peek(), "|| needs true or false, not "+left.kind.String())
+		}
+		p.next++
+		right, err := p.operand("||", p.parseAnd, kindBool)
+		if err != nil {
+			return exprNode{}, err
+		}
+		l, r := left.eval, right.eval
+		left = exprNode{kind: kindBool, eval: func(vars map[string]any) any { return l(vars).(bool) || r(vars).(bool) }}
+	}
+	return left, nil
+}
+
+// parseAnd parses a && b.
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return exprNode{}, err
+	}
+	for p.peek().text == "&&" {
+		if left.kind != kindBool {
+			return exprNode{}, p.fail(p.peek(), "&& needs true or false, not "+left.kind.String())
+		}
+		p.next++
+		right, err := p.operand("&&", p.parseComparison, kindBool)
+		if err != nil {
+			return exprNode{}, err
+		}
+		l, r := left.eval, right.eval
+		left = exprNode{kind: kindBool, eval: func(vars map[string]any) any { return l(vars).(bool) && r(vars).(bool) }}
+	}
+	return left, nil
+}
+
+// parseComparison parses a comparison, a in list, or a bare sum.
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return exprNode{}, err
+	}
+	t := p.peek()
+	if t.value != nil {
+		return left, nil
+	}
+	switch t.text {
+	case "==", "!=":
+		p.next++
+		right, err := p.operand(t.text, p.parseSum, left.kind)
+		if err != nil {
+			return exprNode{}, err
+		}
+		if left.kind == kindStrings || left.kind == kindNumbers {
+			return exprNode{}, p.fail(t, "lists cannot be compared; use in")
+		}
+		l, r, equal := left.eval, right.eval, t.text == "=="
+		return exprNode{kind: kindBool, eval: func(vars map[string]any) any { return (l(vars) == r(vars)) == equal }}, nil
+	case "<", "<=", ">", ">=":
+		if left.kind != kindNumber && left.kind != kindString {
+			return exprNode{}, p.fail(t, t.text+" needs a number or a string, not "+left.kind.String())
+		}
+		p.next++
+		right, err := p.operand(t.text, p.parseSum, left.kind)
+		if err != nil {
+			return exprNode{}, err
+		}
+		l, r, op := left.eval, right.eval, t.text
+		return exprNode{kind: kindBool, eval: func(vars map[string]any) any { return compareValues(op, l(vars), r(vars)) }}, nil
+	case "in":
+		var list exprKind
+		switch left.kind {
+		case kindString:
+			list = kindStrings
+		case kindNumber:
+			list = kindNumbers
+		default:
+			return exprNode{}, p.fail(t, "in needs a number or a string, not "+left.kind.String())
+		}
+		p.next++
+		right, err := p.operand("in", p.parseSum, list)
+		if err != nil {
+			return exprNode{}, err
+		}
+		l, r := left.eval, right.eval
+		return exprNode{kind: kindBool, eval: func(vars map[string]any) any { return listContains(r(vars), l(vars)) }}, nil
+	}
+	return left, nil
+}
+
+// compareValues orders two numbers or two strings.
+func compareValues(op string, left, right any) bool {
+	var c int
+	if l, ok := left.(float64); ok {
+		r := right.(float64)
+		if l < r {
+			c = -1
+		} else if l > r {
+			c = 1
+		}
+	} else {
+		c = strings.Compare(left.(string), right.(string))
+	}
+	switch op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	}
+	return c >= 0
+}
+
+// listContains reports whether a list of strings or numbers holds a value.
+func listContains(list, value any) bool {
+	switch l := list.(type) {
+	case []string:
+		for _, s := range l {
+			if s == value {
+				return true
+			}
+		}
+	case []float64:
+		for _, n := range l {
+			if n == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseSum parses a + b and a - b.
+func (p *exprParser) parseSum() (exprNode, error) {
+	return p.parseArithmetic("+-", p.parseProduct)
+}
+
+// parseProduct parses a * b and a / b.
+func (p *exprParser) parseProduct() (exprNode, error) {
+	return p.parseArithmetic("*/", p.parseUnary)
+}
+
+// parseArithmetic parses a left-associative chain of the given single-character number operators.
+func (p *exprParser) parseArithmetic(ops string, parse func() (exprNode, error)) (exprNode, error) {
+	left, err := parse()
+	if err != nil {
+		return exprNode{}, err
+	}
+	for t := p.peek(); t.value == nil && len(t.text) == 1 && strings.Contains(ops, t.text); t = p.peek() {
+		if left.kind != kindNumber {
+			return exprNode{}, p.fail(t, t.text+" needs a number, not "+left.kind.String())
+		}
+		p.next++
+		right, err := p.operand(t.text, parse, kindNumber)
+		if err != nil {
+			return exprNode{}, err
+		}
+		l, r, op := left.eval, right.eval, t.text
+		left = exprNode{kind: kindNumber, eval: func(vars map[string]any) any {
+			a, b := l(vars).(float64), r(vars).(float64)
+			switch op {
+			case "+":
+				return a + b
+			case "-":
+				return a - b
+			case "*":
+				return a * b
+			}
+			return a / b
+		}}
+	}
+	return left, nil
+}
+
+// parseUnary parses !a and -a.
+func (p *exprParser) parseUnary() (exprNode, error) {
+	switch {
+	case p.accept("!"):
+		operand, err := p.operand("!", p.parseUnary, kindBool)
+		if err != nil {
+			return exprNode{}, err
+		}
+		return exprNode{kind: kindBool, eval: func(vars map[string]any) any { return !operand.eval(vars).(bool) }}, nil
+	case p.accept("-"):
+		operand, err := p.operand("-", p.parseUnary, kindNumber)
+		if err != nil {
+			return exprNode{}, err
+		}
+		return exprNode{kind: kindNumber, eval: func(vars map[string]any) any { return -operand.eval(vars).(float64) }}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary parses a literal, a variable, a list or a parenthesized expression.
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	t := p.peek()
+	switch v := t.value.(type) {
+	case float64:
+		p.next++
+		return exprNode{kind: kindNumber, eval: func(map[string]any) any { return v }}, nil
+	case string:
+		p.next++
+		return exprNode{kind: kindString, eval: func(map[string]any) any { return v }}, nil
+	}
+	switch {
+	case t.text == "":
+		return exprNode{}, p.fail(t, "expected a value at the end")
+	case p.accept("("):
+		node, err := p.parseOr()
+		if err != nil {
+			return exprNode{}, err
+		}
+		if !p.accept(")") {
+			return exprNode{}, p.fail(p.peek(), "expected )")
+		}
+		return node, nil
+	case p.accept("["):
+		return p.parseList(t)
+	case t.text == "true" || t.text == "false":
+		p.next++
+		value := t.text == "true"
+		return exprNode{kind: kindBool, eval: func(map[string]any) any { return value }}, nil
+	}
+	kind, known := ruleVariables[t.text]
+	if !known {
+		if isIdentStart(rune(t.text[0])) {
+			return exprNode{}, p.fail(t, "unknown variable "+t.text)
+		}
+		return exprNode{}, p.fail(t, "unexpected "+t.text)
+	}
+	p.next++
+	name := t.text
+	return exprNode{kind: kind, eval: func(vars map[string]any) any {
+		if v, exists := vars[name]; exists {
+			return v
+		}
+		return zeroValue(kind)
+	}}, nil
+}
+
+// parseList parses the elements of a list whose opening bracket, at open, was just consumed. The elements must be
+// all numbers or all strings.
+func (p *exprParser) parseList(open exprToken) (exprNode, error) {
+	var elements []exprNode
+	for !p.accept("]") {
+		if len(elements) > 0 && !p.accept(",") {
+			return exprNode{}, p.fail(p.peek(), "expected , or ]")
+		}
+		kinds := []exprKind{kindNumber, kindString}
+		if len(elements) > 0 {
+			kinds = []exprKind{elements[0].kind}
+		}
+		element, err := p.operand("a list", p.parseSum, kinds...)
+		if err != nil {
+			return exprNode{}, err
+		}
+		elements = append(elements, element)
+	}
+	if len(elements) == 0 {
+		return exprNode{}, p.fail(open, "a list must not be empty")
+	}
+	if elements[0].kind == kindString {
+		return exprNode{kind: kindStrings, eval: func(vars map[string]any) any {
+			list := make([]string, len(elements))
+			for i, e := range elements {
+				list[i] = e.eval(vars).(string)
+			}
+			return list
+		}}, nil
+	}
+	return exprNode{kind: kindNumbers, eval: func(vars map[string]any) any {
+		list := make([]float64, len(elements))
+		for i, e := range elements {
+			list[i] = e.eval(vars).(float64)
+		}
+		return list
+	}}, nil
+}
+
+// zeroValue returns the value of a variable of the given kind an operation does not have.
+func zeroValue(kind exprKind) any {
+	switch kind {
+	case kindNumber:
+		return float64(0)
+	case kindString:
+		return ""
+	case kindBool:
+		return false
+	case kindStrings:
+		return []string(nil)
+	}
+	return []float64(nil)
+}
@@ -0,0 +1,143 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// RuleAction is what an expression rule does to the operations it matches.
+type RuleAction string
+
+const (
+	RuleDecline RuleAction = "decline" // Reject the operation, as a limit would
+	RuleAlert   RuleAction = "alert"   // Let it through and notify the account's owners
+	RuleReview  RuleAction = "review"  // Let a transfer through and send it for fraud review; alerts on other operations
+)
+
+// ExpressionRule applies an action to the customer deposits, withdrawals and transfers its condition matches, so
+// limits, alerts and fraud checks can be changed through the configuration. See ParseExpression for the language
+// and ruleVariables for what conditions can refer to.
+type ExpressionRule struct {
+	Name    string     `json:"name"`
+	When    string     `json:"when"` // Condition, e.g. `amount > 1000 && channel == "api"`
+	Action  RuleAction `json:"action"`
+	Message string     `json:"message,omitempty"` // Why the rule fired, as customers are told; "" names the rule
+}
+
+// expressionRule is an ExpressionRule with its condition parsed.
+type expressionRule struct {
+	ExpressionRule
+	when *Expression
+}
+
+// message returns why the rule fired.
+func (r expressionRule) message() string {
+	if r.Message != "" {
+		return r.Message
+	}
+	return "matched rule " + r.Name
+}
+
+// compileRules validates expression rules and parses their conditions.
+func compileRules(rules []ExpressionRule) ([]expressionRule, error) {
+	compiled := make([]expressionRule, 0, len(rules))
+	seen := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		if r.Name == "" || seen[r.Name] {
+			return nil, errors.New("expression rules need distinct, non-empty names")
+		}
+		seen[r.Name] = true
+		switch r.Action {
+		case RuleDecline, RuleAlert, RuleReview:
+		default:
+			return nil, errors.New("rule " + r.Name + " action must be decline, alert or review, not " + string(r.Action))
+		}
+		when, err := ParseExpression(r.When)
+		if err != nil {
+			return nil, errors.New("rule " + r.Name + ": " + err.Error())
+		}
+		compiled = append(compiled, expressionRule{ExpressionRule: r, when: when})
+	}
+	return compiled, nil
+}
+
+// ruleFacts returns the variables rule expressions and fee conditions see for an operation on an account, with toID
+// and country the destination of a transfer.
+// The caller must hold the bank mutex.
+func (b *Bank) ruleFacts(op transaction.OperationType, channel Channel, rail Rail, accountID, toID, country string, amount account.Money) map[string]any {
+	now := b.now()
+	facts := map[string]any{
+		"amount":   float64(amount) / account.MinorUnits,
+		"op":       string(op),
+		"channel":  string(channel),
+		"account":  accountID,
+		"to":       toID,
+		"country":  country,
+		"rail":     string(rail),
+		"customer": b.accountOwner[accountID],
+		"tags":     b.profileOf(accountID).Metadata.Tags,
+		"hour":     float64(now.Hour()),
+		"weekday":  strings.ToLower(now.Weekday().String()),
+	}
+	if acc, exists := b.accounts.get(accountID); exists {
+		facts["type"] = account.TypeOf(acc)
+		facts["balance"] = float64(acc.Balance()) / account.MinorUnits
+	}
+	return facts
+}
+
+// matchRules returns the configured rules with one of the given actions whose conditions an operation's facts
+// match, in configured order.
+// The caller must hold the bank mutex.
+func (b *Bank) matchRules(facts map[string]any, actions ...RuleAction) []expressionRule {
+	if b.config == nil {
+		return nil
+	}
+	var matched []expressionRule
+	for _, r := range b.config.rules {
+		for _, action := range actions {
+			if r.Action == action && r.when.Matches(facts) {
+				matched = append(matched, r)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// checkRules declines an operation the first matching decline rule applies to.
+// The caller must hold the bank mutex.
+func (b *Bank) checkRules(facts map[string]any) error {
+	if matched := b.matchRules(facts, RuleDecline); len(matched) > 0 {
+		return decline(ReasonRuleDeclined, fmt.Sprintf("%s declined: %s", facts["op"], matched[0].message()))
+	}
+	return nil
+}
+
+// checkTransferExpressions declines customer transfers a configured decline rule matches.
+// The caller must hold the bank mutex.
+func (b *Bank) checkTransferExpressions(t PendingTransfer) error {
+	return b.checkRules(b.ruleFacts(transaction.OpTransfer, t.Channel, RailInternal, t.FromID, t.ToID, t.Country, t.Amount))
+}
+
+// actOnRules notifies the owners of an account of a completed operation that matched alert rules before it was
+// made, and returns the reasons to send it for fraud review from the review rules it matched. Only transfers are
+// reviewed, so review rules alert on other operations.
+// The caller must hold the bank mutex.
+func (b *Bank) actOnRules(accountID string, op transaction.OperationType, amount account.Money, matched []expressionRule) []string {
+	var reviews []string
+	for _, r := range matched {
+		if r.Action == RuleReview && op == transaction.OpTransfer {
+			reviews = append(reviews, "rule "+r.Name)
+			continue
+		}
+		for _, owner := range b.ownersOf(accountID) {
+			_ = b.notify(owner, NotificationRuleAlert, fmt.Sprintf("Account %s, %s of %s: %s", accountID, op, amount, r.message()), NotifyNormal)
+		}
+	}
+	return reviews
+}
@@ -0,0 +1,135 @@
+package bank
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Channel is where an operation came from.
+type Channel string
+
+const (
+	ChannelCLI       Channel = "cli"
+	ChannelAPI       Channel = "api"
+	ChannelScheduler Channel = "scheduler"
+	ChannelOther     Channel = "other" // Operations whose caller did not say
+)
+
+// channels are the channels limits can be configured for.
+var channels = map[Channel]bool{ChannelCLI: true, ChannelAPI: true, ChannelScheduler: true, ChannelOther: true}
+
+// maxFailures is how many failed operations the bank remembers for FailureReport; older ones are dropped.
+const maxFailures = 10000
+
+// channelKey is the context key under which an operation's channel travels.
+type channelKey struct{}
+
+// ContextWithChannel returns a context carrying the channel an operation came from.
+func ContextWithChannel(ctx context.Context, channel Channel) context.Context {
+	return context.WithValue(ctx, channelKey{}, channel)
+}
+
+// ChannelFromContext returns the channel a context carries, or ChannelOther if it has none.
+func ChannelFromContext(ctx context.Context) Channel {
+	if channel, ok := ctx.Value(channelKey{}).(Channel); ok && channel != "" {
+		return channel
+	}
+	return ChannelOther
+}
+
+// channelOf returns the channel of the operation under way on any of the subjects, or ChannelOther if no correlated
+// operation is.
+// The caller must hold the bank mutex.
+func (b *Bank) channelOf(subjects ...string) Channel {
+	if channel := b.operators[b.correlationOf(subjects...)].channel; channel != "" {
+		return channel
+	}
+	return ChannelOther
+}
+
+// Failure is one rejected or failed operation.
+type Failure struct {
+	At            time.Time
+	Channel       Channel
+	AccountID     string
+	Reason        ReasonCode
+	Message       string
+	CorrelationID string
+}
+
+// recordFailure remembers a failed operation for FailureReport.
+// The caller must hold the bank mutex.
+func (b *Bank) recordFailure(channel Channel, accountID, correlationID string, err error) {
+	if len(b.failures) >= maxFailures {
+		b.failures = append(b.failures[:0], b.failures[len(b.failures)-maxFailures+1:]...)
+	}
+	b.failures = append(b.failures, Failure{
+		At:            b.now(),
+		Channel:       channel,
+		AccountID:     accountID,
+		Reason:        ReasonOf(err),
+		Message:       err.Error(),
+		CorrelationID: correlationID,
+	})
+}
+
+// FailureBucket counts the failures in one period of a FailureReport.
+type FailureBucket struct {
+	Start    time.Time
+	Count    int
+	ByReason map[ReasonCode]int
+}
+
+// FailureReport aggregates failed operations over a period.
+type FailureReport struct {
+	From, To  time.Time
+	Total     int
+	ByReason  map[ReasonCode]int
+	ByChannel map[Channel]int
+	ByAccount map[string]int
+	Buckets   []FailureBucket // Periods with failures, oldest first
+}
+
+// FailureReport aggregates the operations that failed in [from, to) by reason code, channel, account and period,
+// so a spike, such as a misconfigured limit blocking legitimate transfers, stands out. Periods are bucket long and
+// aligned to the report's start.
+func (b *Bank) FailureReport(from, to time.Time, bucket time.Duration) FailureReport {
+	if bucket <= 0 {
+		bucket = time.Hour
+	}
+	report := FailureReport{
+		From:      from,
+		To:        to,
+		ByReason:  make(map[ReasonCode]int),
+		ByChannel: make(map[Channel]int),
+		ByAccount: make(map[string]int),
+	}
+	buckets := make(map[time.Time]*FailureBucket)
+	b.mutex.RLock()
+	for _, f := range b.failures {
+		if f.At.Before(from) || !f.At.Before(to) {
+			continue
+		}
+		report.Total++
+		report.ByReason[f.Reason]++
+		report.ByChannel[f.Channel]++
+		if f.AccountID != "" {
+			report.ByAccount[f.AccountID]++
+		}
+		start := from.Add(f.At.Sub(from) / bucket * bucket)
+		fb, exists := buckets[start]
+		if !exists {
+			fb = &FailureBucket{Start: start, ByReason: make(map[ReasonCode]int)}
+			buckets[start] = fb
+		}
+		fb.Count++
+		fb.ByReason[f.Reason]++
+	}
+	b.mutex.RUnlock()
+	for _, fb := range buckets {
+		report.Buckets = append(report.Buckets, *fb)
+	}
+	sort.Slice(report.Buckets, func(i, j int) bool { return report.Buckets[i].Start.Before(report.Buckets[j].Start) })
+	return report
+}
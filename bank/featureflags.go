@@ -0,0 +1,312 @@
+package bank
+
+import (
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// FeatureFlag gates a behavior so it can be rolled out gradually. A disabled flag is off for everyone. An enabled
+// flag is on for tenants listed in Tenants according to their entry, and for everyone else in the Percent of
+// tenants picked by a stable hash of the flag name and tenant.
+type FeatureFlag struct {
+	Name    string          `json:"name"`
+	Enabled bool            `json:"enabled"`
+	Percent int             `json:"percent"`           // Share of tenants the flag is on for, 0 to 100
+	Tenants map[string]bool `json:"tenants,omitempty"` // Map of tenant to an override of the rollout
+}
+
+// FeatureFlags holds feature flags, optionally kept in a JSON file so operators can change them while the bank is
+// running.
+type FeatureFlags struct {
+	path     string
+	modified time.Time
+	flags    map[string]FeatureFlag
+	mutex    *sync.Mutex
+}
+
+// NewFeatureFlags returns an in-memory set of feature flags.
+func NewFeatureFlags(flags []FeatureFlag) (*FeatureFlags, error) {
+	ff := &FeatureFlags{flags: make(map[string]FeatureFlag), mutex: &sync.Mutex{}}
+	for _, f := range flags {
+		if err := validFeatureFlag(f); err != nil {
+			return nil, err
+		}
+		ff.flags[f.Name] = f.copy()
+	}
+	return ff, nil
+}
+
+// LoadFeatureFlags reads the feature flags at path. A missing file means no flags are set.
+func LoadFeatureFlags(path string) (*FeatureFlags, error) {
+	ff := &FeatureFlags{path: path, flags: make(map[string]FeatureFlag), mutex: &sync.Mutex{}}
+	if err := ff.Reload(); err != nil {
+		return nil, err
+	}
+	return ff, nil
+}
+
+// Reload rereads the flags file if it has changed since it was last read.
+func (ff *FeatureFlags) Reload() error {
+	if ff.path == "" {
+		return nil
+	}
+	ff.mutex.Lock()
+	defer ff.mutex.Unlock()
+	info, err := os.Stat(ff.path)
+	if errors.Is(err, os.ErrNotExist) {
+		ff.flags, ff.modified = make(map[string]FeatureFlag), time.Time{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(ff.modified) {
+		return nil
+	}
+	data, err := os.ReadFile(ff.path)
+	if err != nil {
+		return err
+	}
+	var flags []FeatureFlag
+	if err := json.Unmarshal(data, &flags); err != nil {
+		return err
+	}
+	loaded := make(map[string]FeatureFlag, len(flags))
+	for _, f := range flags {
+		if err := validFeatureFlag(f); err != nil {
+			return err
+		}
+		loaded[f.Name] = f
+	}
+	ff.flags, ff.modified = loaded, info.ModTime()
+	return nil
+}
+
+// save writes the flags through a temporary file, if they are kept in one.
+// The caller must hold the flags' mutex.
+func (ff *FeatureFlags) save() error {
+	if ff.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(ff.list(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ff.path), 0o755); err != nil {
+		return err
+	}
+	tmp := ff.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, ff.path); err != nil {
+		return err
+	}
+	if info, err := os.Stat(ff.path); err == nil {
+		ff.modified = info.ModTime()
+	}
+	return nil
+}
+
+// validFeatureFlag checks a flag has a name and a percentage in range.
+func validFeatureFlag(f FeatureFlag) error {
+	if f.Name == "" {
+		return errors.New("feature flag name must not be empty")
+	}
+	if f.Percent < 0 || f.Percent > 100 {
+		return errors.New("feature flag " + f.Name + " percent must be between 0 and 100")
+	}
+	return nil
+}
+
+// copy returns the flag with its own map of tenant overrides.
+func (f FeatureFlag) copy() FeatureFlag {
+	if f.Tenants != nil {
+		tenants := make(map[string]bool, len(f.Tenants))
+		for t, on := range f.Tenants {
+			tenants[t] = on
+		}
+		f.Tenants = tenants
+	}
+	return f
+}
+
+// list returns every flag, ordered by name.
+// The caller must hold the flags' mutex.
+func (ff *FeatureFlags) list() []FeatureFlag {
+	flags := make([]FeatureFlag, 0, len(ff.flags))
+	for _, f := range ff.flags {
+		flags = append(flags, f.copy())
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags
+}
+
+// Flags lists every flag, ordered by name.
+func (ff *FeatureFlags) Flags() []FeatureFlag {
+	ff.mutex.Lock()
+	defer ff.mutex.Unlock()
+	return ff.list()
+}
+
+// Set adds or replaces a flag.
+func (ff *FeatureFlags) Set(f FeatureFlag) error {
+	if err := validFeatureFlag(f); err != nil {
+		return err
+	}
+	ff.mutex.Lock()
+	defer ff.mutex.Unlock()
+	ff.flags[f.Name] = f.copy()
+	return ff.save()
+}
+
+// SetRollout turns a flag on for a percentage of tenants, or off for everyone, keeping its tenant overrides.
+// The flag is created if it does not exist.
+func (ff *FeatureFlags) SetRollout(name string, enabled bool, percent int) error {
+	ff.mutex.Lock()
+	defer ff.mutex.Unlock()
+	f := ff.flags[name].copy()
+	f.Name, f.Enabled, f.Percent = name, enabled, percent
+	if err := validFeatureFlag(f); err != nil {
+		return err
+	}
+	ff.flags[name] = f
+	return ff.save()
+}
+
+// SetTenant overrides a flag's rollout for one tenant. A nil override removes the tenant's override.
+func (ff *FeatureFlags) SetTenant(name, tenant string, override *bool) error {
+	if tenant == "" {
+		return errors.New("tenant must not be empty")
+	}
+	ff.mutex.Lock()
+	defer ff.mutex.Unlock()
+	f, exists := ff.flags[name]
+	if !exists {
+		return errors.New("feature flag does not exist")
+	}
+	f = f.copy()
+	if override == nil {
+		delete(f.Tenants, tenant)
+	} else {
+		if f.Tenants == nil {
+			f.Tenants = make(map[string]bool)
+		}
+		f.Tenants[tenant] = *override
+	}
+	ff.flags[name] = f
+	return ff.save()
+}
+
+// Remove deletes a flag, turning its behavior off for everyone.
+func (ff *FeatureFlags) Remove(name string) error {
+	ff.mutex.Lock()
+	defer ff.mutex.Unlock()
+	if _, exists := ff.flags[name]; !exists {
+		return errors.New("feature flag does not exist")
+	}
+	delete(ff.flags, name)
+	return ff.save()
+}
+
+// Enabled reports whether a flag is on for a tenant. Flags that are not set are off.
+func (ff *FeatureFlags) Enabled(name, tenant string) bool {
+	ff.mutex.Lock()
+	f, exists := ff.flags[name]
+	ff.mutex.Unlock()
+	if !exists || !f.Enabled {
+		return false
+	}
+	if on, overridden := f.Tenants[tenant]; overridden {
+		return on
+	}
+	return rolloutBucket(name, tenant) < f.Percent
+}
+
+// rolloutBucket places a tenant in one of 100 buckets for a flag. Each flag spreads tenants differently, so the
+// same tenants are not always first to get new behavior, and raising a flag's percentage only adds tenants.
+func rolloutBucket(name, tenant string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name + "\x00" + tenant))
+	return int(h.Sum32() % 100)
+}
+
+// String describes the flag's rollout, e.g. "on for 25%, tenant overrides: 2".
+func (f FeatureFlag) String() string {
+	if !f.Enabled {
+		return "off"
+	}
+	s := "on for " + strconv.Itoa(f.Percent) + "%"
+	if len(f.Tenants) > 0 {
+		s += ", tenant overrides: " + strconv.Itoa(len(f.Tenants))
+	}
+	return s
+}
+
+// SetFeatureFlags sets the flags the bank consults for gated behavior such as fee rules.
+func (b *Bank) SetFeatureFlags(flags *FeatureFlags) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.features = flags
+}
+
+// FeatureEnabled reports whether a flag is on for a tenant. Without feature flags every flag is off.
+func (b *Bank) FeatureEnabled(name, tenant string) bool {
+	b.mutex.Lock()
+	flags := b.features
+	b.mutex.Unlock()
+	return flags != nil && flags.Enabled(name, tenant)
+}
+
+// accountTenant returns the tenant an account's gated behavior is rolled out to: its owning customer, or the
+// account itself when it has no owner.
+// The caller must hold the bank mutex.
+func (b *Bank) accountTenant(accountID string) string {
+	if owner := b.accountOwner[accountID]; owner != "" {
+		return owner
+	}
+	return accountID
+}
+
+// feeRules returns the fee rules that apply to an operation on an account: those of the schedule for the owner's
+// segment, or the bank's if it has none, without a feature flag or whose flag is on for the account's tenant, that
+// have no condition or whose condition the operation matches.
+// The caller must hold the bank mutex.
+func (b *Bank) feeRules(accountID string, op transaction.OperationType, amount account.Money, rail Rail) []transaction.FeeRule {
+	schedule := b.feeSchedule
+	if pricing, exists := b.segmentPricing(accountID); exists && pricing.Fees != nil {
+		schedule = pricing.Fees
+	}
+	tier, _ := b.relationshipTier(accountID)
+	rules := make([]transaction.FeeRule, 0, len(schedule))
+	var facts map[string]any
+	for _, r := range schedule {
+		if slices.Contains(tier.WaivedFees, r.Name) {
+			continue
+		}
+		if r.Feature != "" && (b.features == nil || !b.features.Enabled(r.Feature, b.accountTenant(accountID))) {
+			continue
+		}
+		if r.When != "" {
+			if facts == nil {
+				facts = b.ruleFacts(op, b.channelOf(accountID), rail, accountID, "", "", amount)
+			}
+			if !b.feeConditions[r.When].Matches(facts) {
+				continue
+			}
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
@@ -0,0 +1,143 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// feeUsage counts an account's operations in the current month for free-allowance purposes.
+type feeUsage struct {
+	month  string
+	counts map[transaction.OperationType]int
+}
+
+// SetFeeSchedule replaces the fee rules applied to customer operations.
+func (b *Bank) SetFeeSchedule(rules []transaction.FeeRule) error {
+	if err := validFeeRules(rules); err != nil {
+		return err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.setFeeSchedule(rules)
+	return nil
+}
+
+// setFeeSchedule adopts validated fee rules, parsing their conditions and those of the segments' fee schedules.
+// The caller must hold the bank mutex.
+func (b *Bank) setFeeSchedule(rules []transaction.FeeRule) {
+	b.feeSchedule = append([]transaction.FeeRule(nil), rules...)
+	b.feeConditions = make(map[string]*Expression)
+	all := append([]transaction.FeeRule(nil), rules...)
+	if b.config != nil {
+		for _, pricing := range b.config.segments {
+			all = append(all, pricing.Fees...)
+		}
+	}
+	for _, r := range all {
+		if r.When != "" {
+			b.feeConditions[r.When], _ = ParseExpression(r.When)
+		}
+	}
+}
+
+// usageCount returns how many operations of the type the account has made this month.
+// The caller must hold the bank mutex.
+func (b *Bank) usageCount(accountID string, op transaction.OperationType) int {
+	u, exists := b.feeUsage[accountID]
+	if !exists || u.month != b.now().Format("2006-01") {
+		return 0
+	}
+	return u.counts[op]
+}
+
+// assessFees returns the fees an operation would incur right now, including any for breaching a minimum balance.
+// The caller must hold the bank mutex.
+func (b *Bank) assessFees(accountID string, op transaction.OperationType, amount account.Money, rail Rail) transaction.Fees {
+	fees, _ := transaction.FeesFor(b.feeRules(accountID, op, amount, rail), op, amount, b.usageCount(accountID, op))
+	if op != transaction.OpDeposit {
+		fees = append(fees, b.minimumBalanceFee(accountID, op, amount+fees.Total())...)
+	}
+	return fees
+}
+
+// chargeFees records the operation against the monthly allowance and debits the fees.
+// The caller must hold the account's lock and the bank mutex.
+func (b *Bank) chargeFees(accountID string, op transaction.OperationType, fees transaction.Fees) {
+	month := b.now().Format("2006-01")
+	u, exists := b.feeUsage[accountID]
+	if !exists || u.month != month {
+		u = &feeUsage{month: month, counts: make(map[transaction.OperationType]int)}
+		b.feeUsage[accountID] = u
+	}
+	u.counts[op]++
+
+	acc := b.accounts.at(accountID)
+	for _, f := range fees {
+		txnID := b.newTxnID()
+		if err := acc.Withdraw(f.Amount); err != nil {
+			b.recordTransaction(txnID, fmt.Sprintf("Transaction ID: %s, Fee: %s, Account: %s, Amount: %s, Status: %s, Reason Code: %s\n", txnID, f.Rule, accountID, f.Amount, "failed", ReasonOf(err)))
+			continue
+		}
+		b.recordEvent(Event{Type: EventFeeCharged, AccountID: accountID, Amount: f.Amount, TransactionID: txnID, Reason: f.Rule})
+		b.recordTransaction(txnID, fmt.Sprintf("Transaction ID: %s, Fee: %s, Account: %s, Amount: %s, Status: %s\n", txnID, f.Rule, accountID, f.Amount, "success"))
+	}
+}
+
+// ProposedOperation is an operation to run through the fee simulator.
+type ProposedOperation struct {
+	Operation transaction.OperationType
+	Amount    account.Money
+}
+
+// FeeSimulation explains the outcome of simulating one or more operations.
+type FeeSimulation struct {
+	Fees     transaction.Fees
+	Waived   []string // Rules that matched but did not charge, and why
+	Warnings []string // Limits the activity would run into, e.g. insufficient funds
+	Total    account.Money
+}
+
+// SimulateFees explains which fees a proposed operation would incur, without changing anything.
+func (b *Bank) SimulateFees(accountID string, op transaction.OperationType, amount account.Money) (FeeSimulation, error) {
+	return b.SimulateActivity(accountID, []ProposedOperation{{Operation: op, Amount: amount}})
+}
+
+// SimulateActivity runs a sequence of operations, e.g. a month of typical activity, through the fee
+// schedule starting from the account's current balance and allowance usage, without changing anything.
+func (b *Bank) SimulateActivity(accountID string, ops []ProposedOperation) (FeeSimulation, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	acc, exists := b.accounts.get(accountID)
+	if !exists {
+		return FeeSimulation{}, errors.New("account does not exist")
+	}
+	var sim FeeSimulation
+	if !b.IsAccountActive(accountID) {
+		sim.Warnings = append(sim.Warnings, "account is inactive; operations would be rejected")
+	}
+	balance := account.Spendable(acc)
+	counts := make(map[transaction.OperationType]int)
+	for i, p := range ops {
+		if p.Amount <= 0 {
+			return FeeSimulation{}, fmt.Errorf("operation %d: amount must be positive", i+1)
+		}
+		fees, waived := transaction.FeesFor(b.feeRules(accountID, p.Operation, p.Amount, defaultRail(p.Operation)), p.Operation, p.Amount, b.usageCount(accountID, p.Operation)+counts[p.Operation])
+		if p.Operation == transaction.OpDeposit {
+			balance += p.Amount - fees.Total()
+		} else {
+			if balance < p.Amount+fees.Total() {
+				sim.Warnings = append(sim.Warnings, fmt.Sprintf("operation %d: %s of %s would be rejected for insufficient funds", i+1, p.Operation, p.Amount))
+				continue
+			}
+			balance -= p.Amount + fees.Total()
+		}
+		counts[p.Operation]++
+		sim.Fees = append(sim.Fees, fees...)
+		sim.Waived = append(sim.Waived, waived...)
+	}
+	sim.Total = sim.Fees.Total()
+	return sim, nil
+}
@@ -0,0 +1,177 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// FixedDepositPayout is the release of a fixed deposit's funds into its linked savings account.
+type FixedDepositPayout struct {
+	AccountID     string
+	SavingsID     string
+	Interest      account.Money // Interest credited when the funds were released
+	Penalty       account.Money // Early withdrawal penalty, when broken before maturity
+	Amount        account.Money // Amount paid into the savings account
+	TransactionID string
+	Err           error
+
+	Withheld                 account.Money // Tax withheld from the interest, out of the savings account
+	WithholdingTransactionID string
+	WithholdingErr           error
+}
+
+// NewFixedDepositAccount opens a fixed deposit, moving the principal out of a linked savings account. At maturity
+// the principal and interest are paid back into the same savings account.
+func (b *Bank) NewFixedDepositAccount(id, savingsID string, principal account.Money, interestRate float64, termMonths int, earlyWithdrawalPenalty float64) (*account.FixedDeposit, error) {
+	if principal <= 0 {
+		return nil, errors.New("principal must be positive")
+	}
+	if termMonths <= 0 {
+		return nil, errors.New("term must be at least one month")
+	}
+	if interestRate < 0 || math.IsNaN(interestRate) {
+		return nil, errors.New("interest rate must not be negative")
+	}
+	if earlyWithdrawalPenalty < 0 || earlyWithdrawalPenalty > 1 || math.IsNaN(earlyWithdrawalPenalty) {
+		return nil, errors.New("early withdrawal penalty must be between 0 and 1")
+	}
+	unlock := b.lockAccounts(savingsID)
+	defer unlock()
+
+	b.mutex.Lock()
+	savings, isSavings := b.accounts.at(savingsID).(*account.Savings)
+	allowed := b.checkOperation(savingsID, account.OperationTransferOut)
+	if allowed == nil && isSavings {
+		allowed = b.checkTransferHolds(PendingTransfer{FromID: savingsID, Amount: principal})
+	}
+	idErr := b.checkNewAccountID(id)
+	b.mutex.Unlock()
+	if !isSavings {
+		return nil, errors.New("linked account must be a savings account")
+	}
+	if allowed != nil {
+		return nil, allowed
+	}
+	if idErr != nil {
+		return nil, idErr
+	}
+	if err := savings.Withdraw(principal); err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err := b.checkNewAccountID(id); err != nil {
+		_ = savings.Deposit(principal)
+		return nil, err
+	}
+	fd := account.NewFixedDeposit(id, principal, interestRate, termMonths, earlyWithdrawalPenalty, savingsID, b.now())
+	b.registerAccount(fd, account.StateOpen)
+	if currency := b.currencyOf(savingsID); currency != DefaultCurrency {
+		b.accountCurrency[id] = currency
+	}
+	txnID := b.newTxnID()
+	b.recordTransaction(txnID, fmt.Sprintf("Transaction ID: %s, From: %s, To: %s, Amount: %s, Status: %s, Fixed deposit opened, Matures: %s\n", txnID, savingsID, id, principal, "success", fd.MaturityDate().Format("2006-01-02")))
+	b.recordEvent(Event{Type: EventWithdrew, AccountID: savingsID, Amount: principal, TransactionID: txnID, Rail: RailInternal})
+	b.recordAccountEvent(EventAccountCreated, fd, Event{TransactionID: txnID})
+	return fd, nil
+}
+
+// ProcessFixedDepositMaturities credits the interest on every fixed deposit that has matured by the bank's clock
+// and pays the funds into its linked savings account, closing the deposit. A payout that fails, e.g. because the
+// savings account is frozen, is retried on the next run.
+func (b *Bank) ProcessFixedDepositMaturities() []FixedDepositPayout {
+	b.mutex.Lock()
+	now := b.now()
+	var due []*account.FixedDeposit
+	for id, acc := range b.accounts.all() {
+		fd, ok := acc.(*account.FixedDeposit)
+		if !ok || !b.IsAccountActive(id) {
+			continue
+		}
+		if fd.Released() || !now.Before(fd.MaturityDate()) {
+			due = append(due, fd)
+		}
+	}
+	b.mutex.Unlock()
+	sort.Slice(due, func(i, j int) bool { return due[i].ID() < due[j].ID() })
+
+	var payouts []FixedDepositPayout
+	for _, fd := range due {
+		payout := FixedDepositPayout{AccountID: fd.ID(), SavingsID: fd.PayoutAccountID()}
+		unlock := b.lockAccounts(fd.ID())
+		if interest, matured := fd.Mature(now); matured {
+			payout.Interest = interest
+			b.mutex.Lock()
+			b.recordAccountEvent(EventInterestPosted, fd, Event{Amount: interest})
+			b.mutex.Unlock()
+		}
+		unlock()
+		payouts = append(payouts, b.payOutFixedDeposit(fd, payout))
+	}
+	return payouts
+}
+
+// BreakFixedDeposit releases a fixed deposit before maturity, crediting the interest earned so far less the early
+// withdrawal penalty, and pays the funds into its linked savings account.
+func (b *Bank) BreakFixedDeposit(id string) (FixedDepositPayout, error) {
+	unlock := b.lockAccounts(id)
+	b.mutex.Lock()
+	fd, ok := b.accounts.at(id).(*account.FixedDeposit)
+	allowed := b.checkOperation(id, account.OperationTransferOut)
+	now := b.now()
+	b.mutex.Unlock()
+	if !ok {
+		unlock()
+		return FixedDepositPayout{}, errors.New("account is not a fixed deposit")
+	}
+	if allowed != nil {
+		unlock()
+		return FixedDepositPayout{}, allowed
+	}
+	interest, penalty, err := fd.Break(now)
+	if err != nil {
+		unlock()
+		return FixedDepositPayout{}, err
+	}
+	eventType := EventAccountUpdated
+	if interest > penalty {
+		eventType = EventInterestPosted
+	}
+	b.mutex.Lock()
+	b.recordAccountEvent(eventType, fd, Event{Amount: interest - penalty})
+	b.mutex.Unlock()
+	unlock()
+
+	payout := b.payOutFixedDeposit(fd, FixedDepositPayout{AccountID: id, SavingsID: fd.PayoutAccountID(), Interest: interest, Penalty: penalty})
+	return payout, payout.Err
+}
+
+// payOutFixedDeposit moves a released fixed deposit's balance into its savings account, withholds tax on the interest
+// from there, and closes the deposit.
+// The caller must not hold the bank mutex or the deposit's lock.
+func (b *Bank) payOutFixedDeposit(fd *account.FixedDeposit, payout FixedDepositPayout) FixedDepositPayout {
+	payout.Amount = fd.Balance()
+	if payout.Amount == 0 {
+		payout.Err = b.Close(fd.ID())
+		return payout
+	}
+	payout.TransactionID, payout.Err = b.moveFunds(fd.ID(), payout.SavingsID, payout.Amount)
+	if payout.Err != nil {
+		return payout
+	}
+	b.mutex.Lock()
+	note := fmt.Sprintf("Fixed deposit payout, Interest: %s", payout.Interest)
+	if payout.Penalty > 0 {
+		note += fmt.Sprintf(", Early withdrawal penalty: %s", payout.Penalty)
+	}
+	b.annotateTransaction(payout.TransactionID, note)
+	b.mutex.Unlock()
+	payout.Withheld, payout.WithholdingTransactionID, payout.WithholdingErr = b.withholdTax(payout.SavingsID, payout.Interest-payout.Penalty, payout.TransactionID)
+	payout.Err = b.Close(fd.ID())
+	return payout
+}
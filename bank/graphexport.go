@@ -0,0 +1,221 @@
+package bank
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// GraphFormat is a file format ExportTransferGraph can write.
+type GraphFormat string
+
+const (
+	GraphDOT     GraphFormat = "dot"     // Graphviz
+	GraphGraphML GraphFormat = "graphml" // GraphML XML, read by Gephi, yEd and NetworkX
+	GraphJSON    GraphFormat = "json"    // {"nodes": [...], "edges": [...]}
+)
+
+// GraphFilter narrows the transfers a graph is built from. Zero values do not filter.
+type GraphFilter struct {
+	From      time.Time     // Earliest transfer included
+	To        time.Time     // Transfers at or after this are left out
+	MinAmount account.Money // Edges whose aggregated flow is smaller are left out, with any nodes only they touch
+}
+
+// GraphNode is an account in a transfer graph.
+type GraphNode struct {
+	ID    string `json:"id"`
+	Type  string `json:"type,omitempty"`  // Empty for accounts the bank no longer holds
+	Owner string `json:"owner,omitempty"` // Owning customer, if any
+}
+
+// GraphEdge is every transfer from one account to another, aggregated. Totals are in the source account's currency.
+type GraphEdge struct {
+	From  string        `json:"from"`
+	To    string        `json:"to"`
+	Total account.Money `json:"totalMinor"`
+	Count int           `json:"count"`
+}
+
+// TransferGraph is the network of transfers between accounts.
+type TransferGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// TransferGraph builds the transfer network from the event log: one node per account that sent or received a
+// transfer, and one edge per ordered pair of accounts carrying the total and number of transfers between them.
+// Nodes and edges are ordered by ID.
+func (b *Bank) TransferGraph(filter GraphFilter) TransferGraph {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	edges := make(map[[2]string]*GraphEdge)
+	for _, e := range b.events {
+		if e.Type != EventTransferred {
+			continue
+		}
+		if (!filter.From.IsZero() && e.At.Before(filter.From)) || (!filter.To.IsZero() && !e.At.Before(filter.To)) {
+			continue
+		}
+		key := [2]string{e.AccountID, e.ToID}
+		edge, exists := edges[key]
+		if !exists {
+			edge = &GraphEdge{From: e.AccountID, To: e.ToID}
+			edges[key] = edge
+		}
+		edge.Total += e.Amount
+		edge.Count++
+	}
+
+	var g TransferGraph
+	nodes := make(map[string]bool)
+	for _, edge := range edges {
+		if edge.Total < filter.MinAmount {
+			continue
+		}
+		g.Edges = append(g.Edges, *edge)
+		nodes[edge.From], nodes[edge.To] = true, true
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	for id := range nodes {
+		node := GraphNode{ID: id, Owner: b.accountOwner[id]}
+		if acc, exists := b.accounts.get(id); exists {
+			node.Type = account.TypeOf(acc)
+		}
+		g.Nodes = append(g.Nodes, node)
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	return g
+}
+
+// ExportTransferGraph writes the transfer network to w in the given format.
+func (b *Bank) ExportTransferGraph(w io.Writer, format GraphFormat, filter GraphFilter) error {
+	g := b.TransferGraph(filter)
+	switch format {
+	case GraphDOT:
+		return g.writeDOT(w)
+	case GraphGraphML:
+		return g.writeGraphML(w)
+	case GraphJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(g)
+	}
+	return errors.New("unknown graph format " + string(format) + "; use dot, graphml or json")
+}
+
+// dotID quotes an identifier for Graphviz.
+func dotID(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// writeDOT writes the graph in Graphviz DOT, labelling edges with their total and count.
+func (g TransferGraph) writeDOT(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("digraph transfers {\n")
+	for _, n := range g.Nodes {
+		label := n.ID
+		if n.Type != "" {
+			label += "\n" + n.Type
+		}
+		fmt.Fprintf(&sb, "  %s [label=%s];\n", dotID(n.ID), dotID(label))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&sb, "  %s -> %s [label=%s, total=%d, count=%d];\n", dotID(e.From), dotID(e.To), dotID(fmt.Sprintf("%s (%d)", e.Total, e.Count)), e.Total, e.Count)
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// graphML is the GraphML document layout.
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLItem `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+// graphMLKey declares an attribute of nodes or edges.
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+// graphMLData is one attribute value.
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphMLItem is a node and its attributes.
+type graphMLItem struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+// graphMLEdge is an edge and its attributes.
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+// writeGraphML writes the graph as GraphML, with node type and owner and edge total (in minor units) and count as
+// attributes.
+func (g TransferGraph) writeGraphML(w io.Writer) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "type", For: "node", Name: "type", Type: "string"},
+			{ID: "owner", For: "node", Name: "owner", Type: "string"},
+			{ID: "total", For: "edge", Name: "total_minor", Type: "long"},
+			{ID: "count", For: "edge", Name: "count", Type: "int"},
+		},
+	}
+	doc.Graph.EdgeDefault = "directed"
+	for _, n := range g.Nodes {
+		node := graphMLItem{ID: n.ID}
+		for _, d := range []graphMLData{{Key: "type", Value: n.Type}, {Key: "owner", Value: n.Owner}} {
+			if d.Value != "" {
+				node.Data = append(node.Data, d)
+			}
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: e.From, Target: e.To, Data: []graphMLData{
+			{Key: "total", Value: strconv.FormatInt(int64(e.Total), 10)},
+			{Key: "count", Value: strconv.Itoa(e.Count)},
+		}})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,288 @@
+package bank
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// GroupExpense is a shared expense paid by one member on behalf of several.
+type GroupExpense struct {
+	PayerID      string
+	Amount       account.Money
+	Participants []string // Members sharing the expense equally, including the payer if applicable
+	Description  string
+}
+
+// Settlement is a single transfer needed to settle a group.
+type Settlement struct {
+	FromMember string
+	ToMember   string
+	Amount     account.Money
+}
+
+// ExpenseGroup is a shared ledger between members who split expenses.
+type ExpenseGroup struct {
+	ID        string
+	Members   map[string]string // Map of member (customer) ID to the account used for settlement
+	Expenses  []GroupExpense
+	approvals map[string]bool
+	pending   []Settlement // Legs of an approved settlement not yet paid, in order; see ExecuteSettlement
+	running   bool         // ExecuteSettlement is paying the pending legs
+}
+
+// CreateGroup creates an expense group; members maps each customer ID to their settlement account.
+func (b *Bank) CreateGroup(groupID string, members map[string]string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.groups[groupID]; exists {
+		return errors.New("group already exists")
+	}
+	if len(members) < 2 {
+		return errors.New("group needs at least two members")
+	}
+	memberAccounts := make(map[string]string)
+	for memberID, accountID := range members {
+		if _, exists := b.accounts.get(accountID); !exists {
+			return errors.New("account " + accountID + " does not exist")
+		}
+		memberAccounts[memberID] = accountID
+	}
+	b.groups[groupID] = &ExpenseGroup{
+		ID:        groupID,
+		Members:   memberAccounts,
+		approvals: make(map[string]bool),
+	}
+	return nil
+}
+
+// RecordGroupExpense adds a shared expense to the group. Any pending settlement approvals are reset.
+func (b *Bank) RecordGroupExpense(groupID, payerID string, amount account.Money, participants []string, description string) error {
+	if amount <= 0 {
+		return errors.New("expense amount must be positive")
+	}
+	if len(participants) == 0 {
+		return errors.New("expense needs at least one participant")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	group, exists := b.groups[groupID]
+	if !exists {
+		return errors.New("group does not exist")
+	}
+	if _, ok := group.Members[payerID]; !ok {
+		return errors.New("payer is not a member of the group")
+	}
+	for _, p := range participants {
+		if _, ok := group.Members[p]; !ok {
+			return errors.New(p + " is not a member of the group")
+		}
+	}
+	group.Expenses = append(group.Expenses, GroupExpense{
+		PayerID:      payerID,
+		Amount:       amount,
+		Participants: append([]string(nil), participants...),
+		Description:  description,
+	})
+	group.approvals = make(map[string]bool)
+	return nil
+}
+
+// groupBalances returns each member's net position, counting settlement legs still to be paid; positive means they
+// are owed money.
+func groupBalances(group *ExpenseGroup) map[string]account.Money {
+	balances := make(map[string]account.Money)
+	for memberID := range group.Members {
+		balances[memberID] = 0
+	}
+	for _, s := range group.pending {
+		balances[s.FromMember] -= s.Amount
+		balances[s.ToMember] += s.Amount
+	}
+	for _, exp := range group.Expenses {
+		share := exp.Amount / account.Money(len(exp.Participants))
+		remainder := exp.Amount - share*account.Money(len(exp.Participants))
+		balances[exp.PayerID] += exp.Amount
+		for i, p := range exp.Participants {
+			owed := share
+			// Spread leftover cents over the first participants
+			if account.Money(i) < remainder {
+				owed++
+			}
+			balances[p] -= owed
+		}
+	}
+	return balances
+}
+
+// GroupBalances returns each member's net position; positive means they are owed money.
+func (b *Bank) GroupBalances(groupID string) (map[string]account.Money, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	group, exists := b.groups[groupID]
+	if !exists {
+		return nil, errors.New("group does not exist")
+	}
+	return groupBalances(group), nil
+}
+
+// settlementPlan computes a minimal set of transfers by repeatedly matching the largest debtor
+// with the largest creditor.
+func settlementPlan(balances map[string]account.Money) []Settlement {
+	type position struct {
+		member string
+		cents  account.Money
+	}
+	var debtors, creditors []position
+	for member, cents := range balances {
+		if cents < 0 {
+			debtors = append(debtors, position{member, -cents})
+		} else if cents > 0 {
+			creditors = append(creditors, position{member, cents})
+		}
+	}
+	byAmount := func(p []position) func(i, j int) bool {
+		return func(i, j int) bool {
+			if p[i].cents != p[j].cents {
+				return p[i].cents > p[j].cents
+			}
+			return p[i].member < p[j].member
+		}
+	}
+	sort.Slice(debtors, byAmount(debtors))
+	sort.Slice(creditors, byAmount(creditors))
+
+	var plan []Settlement
+	for len(debtors) > 0 && len(creditors) > 0 {
+		d, c := &debtors[0], &creditors[0]
+		amount := d.cents
+		if c.cents < amount {
+			amount = c.cents
+		}
+		plan = append(plan, Settlement{FromMember: d.member, ToMember: c.member, Amount: amount})
+		d.cents -= amount
+		c.cents -= amount
+		if d.cents == 0 {
+			debtors = debtors[1:]
+		}
+		if c.cents == 0 {
+			creditors = creditors[1:]
+		}
+		sort.Slice(debtors, byAmount(debtors))
+		sort.Slice(creditors, byAmount(creditors))
+	}
+	return plan
+}
+
+// SettleUpPlan returns the transfers needed to settle the group, or, while an approved settlement is under way or
+// was interrupted, the legs of it still to be paid.
+func (b *Bank) SettleUpPlan(groupID string) ([]Settlement, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	group, exists := b.groups[groupID]
+	if !exists {
+		return nil, errors.New("group does not exist")
+	}
+	if len(group.pending) > 0 {
+		return append([]Settlement(nil), group.pending...), nil
+	}
+	return settlementPlan(groupBalances(group)), nil
+}
+
+// ApproveSettlement records a member's approval to execute the current settlement plan.
+func (b *Bank) ApproveSettlement(groupID, memberID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	group, exists := b.groups[groupID]
+	if !exists {
+		return errors.New("group does not exist")
+	}
+	if _, ok := group.Members[memberID]; !ok {
+		return errors.New("not a member of the group")
+	}
+	group.approvals[memberID] = true
+	return nil
+}
+
+// ExecuteSettlement runs the settlement plan as a batch of transfers once every member has approved. The plan is
+// claimed before any money moves: the group's expenses and approvals are cleared and its legs kept as pending, so a
+// concurrent call cannot pay them again. Each leg is struck off once paid; if one fails, calling ExecuteSettlement
+// again resumes with it rather than starting over. It returns the transfers executed by this call.
+func (b *Bank) ExecuteSettlement(groupID string) ([]Settlement, error) {
+	b.mutex.Lock()
+	group, exists := b.groups[groupID]
+	if !exists {
+		b.mutex.Unlock()
+		return nil, errors.New("group does not exist")
+	}
+	if group.running {
+		b.mutex.Unlock()
+		return nil, errors.New("settlement is already being executed")
+	}
+	if len(group.pending) == 0 {
+		plan, err := b.claimSettlement(group)
+		if err != nil {
+			b.mutex.Unlock()
+			return nil, err
+		}
+		group.pending = plan
+	}
+	group.running = true
+	members := group.Members
+	plan := append([]Settlement(nil), group.pending...)
+	b.mutex.Unlock()
+
+	var executed []Settlement
+	for _, s := range plan {
+		err := b.transferFunds(members[s.FromMember], members[s.ToMember], s.Amount)
+		b.mutex.Lock()
+		if err != nil {
+			group.running = false
+			b.mutex.Unlock()
+			return executed, err
+		}
+		group.pending = group.pending[1:]
+		b.mutex.Unlock()
+		executed = append(executed, s)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	group.pending = nil
+	group.running = false
+	return executed, nil
+}
+
+// claimSettlement checks every member has approved the group's settlement and every debtor can cover their legs,
+// then clears the group's expenses and approvals and returns the plan to pay.
+// The caller must hold the bank mutex.
+func (b *Bank) claimSettlement(group *ExpenseGroup) ([]Settlement, error) {
+	for memberID := range group.Members {
+		if !group.approvals[memberID] {
+			return nil, errors.New(memberID + " has not approved the settlement")
+		}
+	}
+	plan := settlementPlan(groupBalances(group))
+	// Check every debtor can cover their legs before moving any money
+	owed := make(map[string]account.Money)
+	for _, s := range plan {
+		owed[s.FromMember] += s.Amount
+	}
+	for memberID, amount := range owed {
+		accountID := group.Members[memberID]
+		acc, exists := b.accounts.get(accountID)
+		if !exists || !b.IsAccountActive(accountID) {
+			return nil, errors.New("settlement account " + accountID + " is not active")
+		}
+		if err := b.checkOperation(accountID, account.OperationTransferOut); err != nil {
+			return nil, err
+		}
+		if account.Spendable(acc) < amount {
+			return nil, errors.New(memberID + " has insufficient funds to settle")
+		}
+	}
+	group.Expenses = nil
+	group.approvals = make(map[string]bool)
+	return plan, nil
+}
@@ -0,0 +1,235 @@
+package bank
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// HierarchyNode is one entity in a corporate structure, such as a parent company, subsidiary or department.
+type HierarchyNode struct {
+	ID       string
+	Name     string
+	ParentID string   // Empty for a top-level entity
+	Children []string // Child node IDs
+	Accounts []string // Accounts held directly by this entity
+}
+
+// HierarchyPermission is a level of access granted on a node. Each level includes the ones below it, and a
+// grant applies to the node and everything beneath it.
+type HierarchyPermission int
+
+const (
+	PermissionNone HierarchyPermission = iota
+	PermissionView
+	PermissionTransact
+	PermissionManage
+)
+
+// String returns the name of the permission level.
+func (p HierarchyPermission) String() string {
+	switch p {
+	case PermissionView:
+		return "view"
+	case PermissionTransact:
+		return "transact"
+	case PermissionManage:
+		return "manage"
+	}
+	return "none"
+}
+
+// HierarchyRollUp is the balance of a node including everything beneath it.
+type HierarchyRollUp struct {
+	NodeID   string
+	Name     string
+	Own      account.Money // Balance of accounts held directly by the node
+	Total    account.Money // Own plus every descendant's total
+	Children []HierarchyRollUp
+}
+
+// CreateHierarchyNode adds an entity under parentID, or at the top level when parentID is empty.
+func (b *Bank) CreateHierarchyNode(id, name, parentID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if id == "" {
+		return errors.New("node ID must not be empty")
+	}
+	if _, exists := b.hierarchy[id]; exists {
+		return errors.New("node already exists")
+	}
+	if parentID != "" {
+		parent, exists := b.hierarchy[parentID]
+		if !exists {
+			return errors.New("parent node does not exist")
+		}
+		parent.Children = append(parent.Children, id)
+	}
+	b.hierarchy[id] = &HierarchyNode{ID: id, Name: name, ParentID: parentID}
+	return nil
+}
+
+// MoveHierarchyNode re-parents a node and its subtree. A node cannot be moved beneath itself.
+func (b *Bank) MoveHierarchyNode(id, newParentID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	node, exists := b.hierarchy[id]
+	if !exists {
+		return errors.New("node does not exist")
+	}
+	if newParentID != "" {
+		if _, exists := b.hierarchy[newParentID]; !exists {
+			return errors.New("parent node does not exist")
+		}
+		for ancestor := newParentID; ancestor != ""; ancestor = b.hierarchy[ancestor].ParentID {
+			if ancestor == id {
+				return errors.New("cannot move a node beneath itself")
+			}
+		}
+	}
+	if node.ParentID != "" {
+		old := b.hierarchy[node.ParentID]
+		old.Children = removeString(old.Children, id)
+	}
+	if newParentID != "" {
+		parent := b.hierarchy[newParentID]
+		parent.Children = append(parent.Children, id)
+	}
+	node.ParentID = newParentID
+	return nil
+}
+
+// removeString returns the slice without the first occurrence of s.
+func removeString(list []string, s string) []string {
+	for i, v := range list {
+		if v == s {
+			return append(list[:i], list[i+1:]...)
+		}
+	}
+	return list
+}
+
+// AttachAccountToNode places an account under an entity, moving it from any entity it was under before.
+func (b *Bank) AttachAccountToNode(accountID, nodeID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.accounts.get(accountID); !exists {
+		return errors.New("account does not exist")
+	}
+	node, exists := b.hierarchy[nodeID]
+	if !exists {
+		return errors.New("node does not exist")
+	}
+	if previous, attached := b.accountNode[accountID]; attached {
+		old := b.hierarchy[previous]
+		old.Accounts = removeString(old.Accounts, accountID)
+	}
+	node.Accounts = append(node.Accounts, accountID)
+	b.accountNode[accountID] = nodeID
+	return nil
+}
+
+// HierarchyRollUpOf returns the balance of a node and each of its descendants. Closed accounts are excluded.
+func (b *Bank) HierarchyRollUpOf(nodeID string) (HierarchyRollUp, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.hierarchy[nodeID]; !exists {
+		return HierarchyRollUp{}, errors.New("node does not exist")
+	}
+	return b.rollUp(nodeID), nil
+}
+
+// rollUp sums the balances beneath a node.
+// The caller must hold the bank mutex.
+func (b *Bank) rollUp(nodeID string) HierarchyRollUp {
+	node := b.hierarchy[nodeID]
+	r := HierarchyRollUp{NodeID: node.ID, Name: node.Name}
+	for _, accountID := range node.Accounts {
+		if b.IsAccountActive(accountID) {
+			r.Own += b.accounts.at(accountID).Balance()
+		}
+	}
+	r.Total = r.Own
+	children := append([]string(nil), node.Children...)
+	sort.Strings(children)
+	for _, childID := range children {
+		child := b.rollUp(childID)
+		r.Total += child.Total
+		r.Children = append(r.Children, child)
+	}
+	return r
+}
+
+// GrantHierarchyPermission gives a user a level of access on a node and everything beneath it.
+// Granting PermissionNone removes the user's grant on that node.
+func (b *Bank) GrantHierarchyPermission(nodeID, userID string, permission HierarchyPermission) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.hierarchy[nodeID]; !exists {
+		return errors.New("node does not exist")
+	}
+	if permission < PermissionNone || permission > PermissionManage {
+		return errors.New("unknown permission")
+	}
+	grants := b.hierarchyGrants[nodeID]
+	if grants == nil {
+		grants = make(map[string]HierarchyPermission)
+		b.hierarchyGrants[nodeID] = grants
+	}
+	if permission == PermissionNone {
+		delete(grants, userID)
+	} else {
+		grants[userID] = permission
+	}
+	return nil
+}
+
+// EffectivePermission returns the highest level of access a user holds on a node, including grants made on
+// any of its ancestors.
+func (b *Bank) EffectivePermission(userID, nodeID string) HierarchyPermission {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.effectivePermission(userID, nodeID)
+}
+
+// effectivePermission walks from the node to the top of the tree collecting grants.
+// The caller must hold the bank mutex.
+func (b *Bank) effectivePermission(userID, nodeID string) HierarchyPermission {
+	best := PermissionNone
+	for id := nodeID; id != ""; {
+		node, exists := b.hierarchy[id]
+		if !exists {
+			break
+		}
+		if p := b.hierarchyGrants[id][userID]; p > best {
+			best = p
+		}
+		id = node.ParentID
+	}
+	return best
+}
+
+// CanAccessAccount reports whether a user holds at least the given permission on an account through the
+// hierarchy. Accounts outside any hierarchy grant nothing.
+func (b *Bank) CanAccessAccount(userID, accountID string, permission HierarchyPermission) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	nodeID, attached := b.accountNode[accountID]
+	if !attached {
+		return false
+	}
+	return b.effectivePermission(userID, nodeID) >= permission
+}
+
+// HierarchyTransfer moves funds on behalf of a user who must hold transact permission on the source account and
+// view permission on the destination.
+func (b *Bank) HierarchyTransfer(userID, fromID, toID string, amount account.Money) (string, error) {
+	if !b.CanAccessAccount(userID, fromID, PermissionTransact) {
+		return "", errors.New("user may not transact on source account")
+	}
+	if !b.CanAccessAccount(userID, toID, PermissionView) {
+		return "", errors.New("user may not see destination account")
+	}
+	return b.transfer(fromID, toID, amount)
+}
@@ -0,0 +1,236 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// HistoryType is the kind of operation a transaction history entry records.
+type HistoryType string
+
+const (
+	HistoryTransfer  HistoryType = "transfer"
+	HistoryReversal  HistoryType = "reversal"
+	HistorySplit     HistoryType = "split"     // A split transfer or one of its legs
+	HistoryScheduled HistoryType = "scheduled" // A run of a scheduled transfer
+	HistoryFee       HistoryType = "fee"
+	HistoryInterest  HistoryType = "interest"
+	HistoryState     HistoryType = "state" // An account lifecycle state change
+	HistoryVirtual   HistoryType = "virtual"
+	HistoryCapture   HistoryType = "capture" // A captured hold
+	HistoryMigration HistoryType = "migration"
+	HistoryDeposit   HistoryType = "deposit"    // A deposit recorded with a memo
+	HistoryWithdraw  HistoryType = "withdrawal" // A withdrawal recorded with a memo
+	HistoryOther     HistoryType = "other"
+)
+
+// maxHistoryPage is the most entries one History page returns.
+const maxHistoryPage = 500
+
+// HistoryQuery selects and pages through the transaction history. Zero values do not filter.
+type HistoryQuery struct {
+	AccountID string        // Entries from, to or about this account
+	From      time.Time     // Earliest entry included
+	To        time.Time     // Entries recorded at or after this are left out
+	MinAmount account.Money // Smallest amount included
+	MaxAmount account.Money // Largest amount included
+	Status    string        // e.g. "success", "failed" or "compensation-pending"
+	Channel   Channel       // Where the operation came from
+	Memo      string        // Entries whose memo contains this text, ignoring case
+	Reference string        // Entries carrying this external reference
+	Category  string        // Entries tagged with this category, ignoring case
+	Type      HistoryType
+	Newest    bool // Newest entries first instead of oldest
+	Offset    int  // Matching entries to skip
+	Limit     int  // Entries per page; 0 means 50
+}
+
+// HistoryEntry is one transaction history entry with its common fields picked out.
+type HistoryEntry struct {
+	TransactionID string
+	Recorded      time.Time // Zero for entries recorded before history was timestamped
+	Type          HistoryType
+	From          string
+	To            string
+	Account       string        // Account a fee, interest posting or state change applied to
+	Amount        account.Money // In the currency of the account it left
+	Status        string
+	Channel       Channel // "" for entries recorded before channels were
+	ReversedBy    string  // Transaction that reversed this transfer, if any
+	ReversalOf    string  // Transfer this transaction reversed, if it is a reversal
+	Memo          string  // Customer's memo on a deposit, withdrawal or transfer
+	Reference     string  // External reference number, such as an invoice number, or a captured hold's reference
+	Category      string  // Customer's category tag
+	Entry         string  // The entry as recorded
+}
+
+// HistoryPage is one page of a history query.
+type HistoryPage struct {
+	Entries    []HistoryEntry
+	Total      int // Entries matching the query across all pages
+	NextOffset int // Offset of the next page, or 0 if this is the last
+}
+
+// parseHistoryEntry picks the common fields out of a history entry.
+func parseHistoryEntry(txnID, entry string) HistoryEntry {
+	h := HistoryEntry{TransactionID: txnID, Entry: entry}
+	fields := make(map[string]string)
+	for _, field := range historyFields(entry) {
+		if _, exists := fields[field[0]]; !exists {
+			fields[field[0]] = field[1]
+		}
+	}
+	h.From, h.To, h.Account, h.Status = fields["From"], fields["To"], fields["Account"], fields["Status"]
+	h.Channel = Channel(fields["Channel"])
+	h.ReversedBy, h.ReversalOf = fields["Reversed by"], fields["Reversal of"]
+	h.Memo, h.Reference, h.Category = fields["Memo"], fields["Reference"], fields["Category"]
+	h.Recorded, _ = time.Parse(time.RFC3339Nano, fields["Recorded"])
+	// Migrated entries read "Migration: Account: ID, Opening Balance: X"
+	migration, migrated := fields["Migration"]
+	if migrated {
+		h.Account = strings.TrimPrefix(migration, "Account: ")
+	}
+	for _, key := range []string{"Amount", "Deposit", "Withdrawal", "Interest", "Opening Balance"} {
+		amount, _, _ := strings.Cut(fields[key], " ")
+		if v, err := strconv.ParseFloat(amount, 64); err == nil {
+			h.Amount = account.NewMoney(v)
+			break
+		}
+	}
+	h.Type = historyType(fields, migrated)
+	return h
+}
+
+// historyType works out the kind of operation from an entry's fields.
+func historyType(fields map[string]string, migrated bool) HistoryType {
+	has := func(key string) bool {
+		_, exists := fields[key]
+		return exists
+	}
+	switch {
+	case migrated:
+		return HistoryMigration
+	case has("Deposit"):
+		return HistoryDeposit
+	case has("Withdrawal"):
+		return HistoryWithdraw
+	case has("Fee"):
+		return HistoryFee
+	case has("Interest"):
+		return HistoryInterest
+	case has("State"):
+		return HistoryState
+	case has("Schedule"):
+		return HistoryScheduled
+	case has("Parent") || strings.HasSuffix(fields["To"], " recipients"):
+		return HistorySplit
+	case has("Hold"):
+		return HistoryCapture
+	case has("Virtual Account"):
+		return HistoryVirtual
+	case has("Reversal of"):
+		return HistoryReversal
+	case has("From") && has("To"):
+		return HistoryTransfer
+	}
+	return HistoryOther
+}
+
+// matches reports whether an entry passes the query's filters.
+func (q HistoryQuery) matches(h HistoryEntry) bool {
+	if q.AccountID != "" && h.From != q.AccountID && h.To != q.AccountID && h.Account != q.AccountID {
+		return false
+	}
+	if !q.From.IsZero() && h.Recorded.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !h.Recorded.Before(q.To) {
+		return false
+	}
+	if (q.MinAmount != 0 && h.Amount < q.MinAmount) || (q.MaxAmount != 0 && h.Amount > q.MaxAmount) {
+		return false
+	}
+	if q.Channel != "" && h.Channel != q.Channel {
+		return false
+	}
+	if q.Status != "" && h.Status != q.Status {
+		return false
+	}
+	if q.Memo != "" && !strings.Contains(strings.ToLower(h.Memo), strings.ToLower(q.Memo)) {
+		return false
+	}
+	if (q.Reference != "" && h.Reference != q.Reference) || (q.Category != "" && !strings.EqualFold(h.Category, q.Category)) {
+		return false
+	}
+	return q.Type == "" || h.Type == q.Type
+}
+
+// History returns a page of the transaction history entries matching a query, ordered by when they were recorded
+// and then by transaction ID. Entries recorded before history was timestamped come first and are left out of
+// date-range queries.
+func (b *Bank) History(q HistoryQuery) (HistoryPage, error) {
+	if q.Offset < 0 || q.Limit < 0 {
+		return HistoryPage{}, errors.New("offset and limit must not be negative")
+	}
+	if q.MaxAmount != 0 && q.MinAmount > q.MaxAmount {
+		return HistoryPage{}, errors.New("minimum amount is larger than maximum amount")
+	}
+	if q.Limit == 0 {
+		q.Limit = 50
+	}
+	if q.Limit > maxHistoryPage {
+		q.Limit = maxHistoryPage
+	}
+
+	b.mutex.RLock()
+	var matching []HistoryEntry
+	for id, entry := range b.transactionHist {
+		if h := parseHistoryEntry(id, entry); q.matches(h) {
+			matching = append(matching, h)
+		}
+	}
+	b.mutex.RUnlock()
+	sort.Slice(matching, func(i, j int) bool {
+		a, c := matching[i], matching[j]
+		if q.Newest {
+			a, c = c, a
+		}
+		if !a.Recorded.Equal(c.Recorded) {
+			return a.Recorded.Before(c.Recorded)
+		}
+		return a.TransactionID < c.TransactionID
+	})
+
+	page := HistoryPage{Total: len(matching)}
+	if q.Offset >= len(matching) {
+		return page, nil
+	}
+	end := min(q.Offset+q.Limit, len(matching))
+	page.Entries = matching[q.Offset:end]
+	if end < len(matching) {
+		page.NextOffset = end
+	}
+	return page, nil
+}
+
+// DisplayTransactionHistory prints the transaction history, oldest first.
+func (b *Bank) DisplayTransactionHistory() {
+	fmt.Println("Transaction History:")
+	for q := (HistoryQuery{Limit: maxHistoryPage}); ; {
+		page, _ := b.History(q)
+		for _, h := range page.Entries {
+			fmt.Println(h.Entry)
+		}
+		if page.NextOffset == 0 {
+			break
+		}
+		q.Offset = page.NextOffset
+	}
+	fmt.Println("END")
+}
@@ -0,0 +1,230 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// HoldStatus tracks a hold from placement to capture, release or expiry.
+type HoldStatus string
+
+const (
+	HoldActive   HoldStatus = "active"
+	HoldCaptured HoldStatus = "captured"
+	HoldReleased HoldStatus = "released"
+	HoldExpired  HoldStatus = "expired"
+)
+
+// Hold reserves funds in an account, e.g. for a card authorization, without moving them. Held funds cannot be spent
+// until the hold is captured, released or expires.
+type Hold struct {
+	ID            string
+	AccountID     string
+	Amount        account.Money
+	Reference     string // e.g. the card authorization code
+	PlacedAt      time.Time
+	ExpiresAt     time.Time
+	Status        HoldStatus
+	Captured      account.Money // Amount debited on capture, at most the held amount
+	TransactionID string        // The capture's transaction
+}
+
+// AccountBalances are an account's ledger and available balances.
+type AccountBalances struct {
+	Ledger    account.Money // Money in the account
+	Held      account.Money // Reserved by active holds
+	Available account.Money // What can be spent now: the ledger balance plus any overdraft, less holds
+}
+
+// expireHolds marks active holds past their expiry as expired.
+// The caller must hold the bank mutex.
+func (b *Bank) expireHolds() {
+	now := b.now()
+	for _, h := range b.holds {
+		if h.Status == HoldActive && !now.Before(h.ExpiresAt) {
+			h.Status = HoldExpired
+			b.auditHold("HoldExpired", h)
+		}
+	}
+}
+
+// heldAmount returns the total of an account's active holds.
+// The caller must hold the bank mutex.
+func (b *Bank) heldAmount(accountID string) account.Money {
+	b.expireHolds()
+	var held account.Money
+	for _, h := range b.holds {
+		if h.AccountID == accountID && h.Status == HoldActive {
+			held += h.Amount
+		}
+	}
+	return held
+}
+
+// available returns what can be spent from an account, counting a hold being captured as spendable.
+// The caller must hold the bank mutex.
+func (b *Bank) available(accountID string, capture *Hold) account.Money {
+	acc, exists := b.accounts.get(accountID)
+	if !exists {
+		return 0
+	}
+	available := account.Spendable(acc) - b.heldAmount(accountID)
+	if capture != nil && capture.Status == HoldActive {
+		available += capture.Amount
+	}
+	return available
+}
+
+// auditHold writes the audit record of a change to a hold, attributed to the operation under way on its account.
+// The caller must hold the bank mutex.
+func (b *Bank) auditHold(action string, h *Hold) {
+	if b.auditSink == nil {
+		return
+	}
+	correlationID := b.correlationOf(h.AccountID)
+	op := b.operatorOf(correlationID)
+	b.writeAudit(AuditRecord{At: b.now(), Actor: op.userID, Channel: op.channel, Action: action, AccountID: h.AccountID, Amount: h.Amount, TransactionID: h.TransactionID, CorrelationID: correlationID, Detail: h.ID})
+}
+
+// PlaceHold reserves an amount of an account's available funds until the hold is captured or released, or ttl
+// passes.
+func (b *Bank) PlaceHold(accountID string, amount account.Money, reference string, ttl time.Duration) (Hold, error) {
+	if ttl <= 0 {
+		return Hold{}, errors.New("hold expiry must be in the future")
+	}
+	unlock := b.lockAccounts(accountID)
+	defer unlock()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err := b.checkOperation(accountID, account.OperationWithdraw); err != nil {
+		return Hold{}, err
+	}
+	if err := b.checkAmount(transaction.OpWithdrawal, accountID, amount); err != nil {
+		return Hold{}, err
+	}
+	if err := b.checkSignatures(accountID, amount, false); err != nil {
+		return Hold{}, err
+	}
+	if available := b.available(accountID, nil); available < amount {
+		return Hold{}, decline(ReasonInsufficientFunds, fmt.Sprintf("insufficient available funds: %s available", available))
+	}
+	now := b.now()
+	h := &Hold{
+		ID:        b.newID("hold"),
+		AccountID: accountID,
+		Amount:    amount,
+		Reference: reference,
+		PlacedAt:  now,
+		ExpiresAt: now.Add(ttl),
+		Status:    HoldActive,
+	}
+	b.holds[h.ID] = h
+	b.auditHold("HoldPlaced", h)
+	return *h, nil
+}
+
+// activeHold looks up a hold that can still be captured or released.
+// The caller must hold the bank mutex.
+func (b *Bank) activeHold(holdID string) (*Hold, error) {
+	b.expireHolds()
+	h, exists := b.holds[holdID]
+	if !exists {
+		return nil, errors.New("hold does not exist")
+	}
+	if h.Status != HoldActive {
+		return nil, errors.New("hold is " + string(h.Status))
+	}
+	return h, nil
+}
+
+// CaptureHold debits a held amount, or part of it, as a withdrawal and ends the hold; anything not captured becomes
+// available again. A zero amount captures the whole hold. It returns the capture's transaction ID.
+func (b *Bank) CaptureHold(holdID string, amount account.Money) (string, error) {
+	b.mutex.Lock()
+	h, err := b.activeHold(holdID)
+	b.mutex.Unlock()
+	if err != nil {
+		return "", err
+	}
+	if amount == 0 {
+		amount = h.Amount
+	}
+	if amount < 0 || amount > h.Amount {
+		return "", decline(ReasonInvalidAmount, fmt.Sprintf("capture must be between 0 and the held %s", h.Amount))
+	}
+	if _, err := b.withdraw(h.AccountID, amount, h, false, RailInternal, transaction.Memo{}); err != nil {
+		return "", err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return h.TransactionID, nil
+}
+
+// settleCapture ends a captured hold and records the capture in the transaction history.
+// The caller must hold the account's lock and the bank mutex.
+func (b *Bank) settleCapture(h *Hold, amount account.Money) string {
+	txnID := b.newTxnID()
+	h.Status, h.Captured, h.TransactionID = HoldCaptured, amount, txnID
+	b.recordTransaction(txnID, fmt.Sprintf("Transaction ID: %s, Hold: %s, Account: %s, Amount: %s, Reference: %s, Status: %s\n", txnID, h.ID, h.AccountID, amount, h.Reference, "success"))
+	b.auditHold("HoldCaptured", h)
+	return txnID
+}
+
+// ReleaseHold ends a hold without debiting anything, making its funds available again.
+func (b *Bank) ReleaseHold(holdID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	h, err := b.activeHold(holdID)
+	if err != nil {
+		return err
+	}
+	h.Status = HoldReleased
+	b.auditHold("HoldReleased", h)
+	return nil
+}
+
+// GetHold retrieves a hold.
+func (b *Bank) GetHold(holdID string) (Hold, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.expireHolds()
+	h, exists := b.holds[holdID]
+	if !exists {
+		return Hold{}, errors.New("hold does not exist")
+	}
+	return *h, nil
+}
+
+// Holds lists an account's holds, oldest first.
+func (b *Bank) Holds(accountID string) []Hold {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.expireHolds()
+	var list []Hold
+	for _, h := range b.holds {
+		if h.AccountID == accountID {
+			list = append(list, *h)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].PlacedAt.Before(list[j].PlacedAt) })
+	return list
+}
+
+// Balances returns an account's ledger balance, the amount on hold and the balance available to spend.
+func (b *Bank) Balances(accountID string) (AccountBalances, error) {
+	unlock := b.lockAccounts(accountID)
+	defer unlock()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	acc, exists := b.accounts.get(accountID)
+	if !exists {
+		return AccountBalances{}, decline(ReasonAccountNotFound, "account does not exist")
+	}
+	held := b.heldAmount(accountID)
+	return AccountBalances{Ledger: acc.Balance(), Held: held, Available: account.Spendable(acc) - held}, nil
+}
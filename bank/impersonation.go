@@ -0,0 +1,231 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// maxImpersonationDuration caps how long a support session may last.
+const maxImpersonationDuration = time.Hour
+
+// ImpersonationSession lets a staff member view, and optionally act on, a customer's accounts.
+type ImpersonationSession struct {
+	ID         string
+	StaffID    string
+	CustomerID string
+	Reason     string
+	CanAct     bool   // Whether the session may move money, not just view
+	Consent    string // "customer" or "override by <admin ID>"
+	StartedAt  time.Time
+	ExpiresAt  time.Time
+	Ended      bool
+}
+
+// Watermark is the label attached to every action taken in the session.
+func (s ImpersonationSession) Watermark() string {
+	return fmt.Sprintf("Via: impersonation %s by %s", s.ID, s.StaffID)
+}
+
+// ImpersonationEvent is an audit record of something that happened in or around a session.
+type ImpersonationEvent struct {
+	Time          time.Time
+	SessionID     string
+	StaffID       string
+	CustomerID    string
+	Action        string
+	Detail        string
+	CorrelationID string // The incoming operation that caused the event, if any
+}
+
+// impersonationConsent is a customer's time-limited permission for a staff member to impersonate them.
+type impersonationConsent struct {
+	staffID   string
+	expiresAt time.Time
+}
+
+// AddAdmin registers a staff member who may override customer consent.
+func (b *Bank) AddAdmin(staffID string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.admins[staffID] = true
+}
+
+// auditImpersonation appends an event to the impersonation audit log.
+// The caller must hold the bank mutex.
+func (b *Bank) auditImpersonation(sessionID, staffID, customerID, action, detail string) {
+	b.impersonationLog = append(b.impersonationLog, ImpersonationEvent{
+		Time:       b.now(),
+		SessionID:  sessionID,
+		StaffID:    staffID,
+		CustomerID: customerID,
+		Action:     action,
+		Detail:     detail,
+
+		CorrelationID: b.correlationOf(sessionID, customerID),
+	})
+}
+
+// GrantImpersonationConsent records a customer's consent for a staff member to start a session within the window.
+func (b *Bank) GrantImpersonationConsent(customerID, staffID string, window time.Duration) error {
+	if window <= 0 {
+		return errors.New("consent window must be positive")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.consents[customerID] = &impersonationConsent{staffID: staffID, expiresAt: b.now().Add(window)}
+	b.auditImpersonation("", staffID, customerID, "consent-granted", "window "+window.String())
+	return nil
+}
+
+// StartImpersonation opens a session using the customer's consent. Consent is single use.
+func (b *Bank) StartImpersonation(staffID, customerID, reason string, canAct bool, duration time.Duration) (ImpersonationSession, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	consent, exists := b.consents[customerID]
+	if !exists || consent.staffID != staffID || !b.now().Before(consent.expiresAt) {
+		b.auditImpersonation("", staffID, customerID, "start-denied", "no valid customer consent")
+		return ImpersonationSession{}, errors.New("customer has not consented to impersonation")
+	}
+	delete(b.consents, customerID)
+	return b.startImpersonation(staffID, customerID, reason, canAct, duration, "customer")
+}
+
+// StartImpersonationWithOverride opens a session without customer consent on an admin's authority.
+func (b *Bank) StartImpersonationWithOverride(staffID, customerID, adminID, reason string, canAct bool, duration time.Duration) (ImpersonationSession, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.admins[adminID] {
+		b.auditImpersonation("", staffID, customerID, "start-denied", adminID+" is not an admin")
+		return ImpersonationSession{}, errors.New("override requires an admin")
+	}
+	if reason == "" {
+		return ImpersonationSession{}, errors.New("override requires a reason")
+	}
+	session, err := b.startImpersonation(staffID, customerID, reason, canAct, duration, "override by "+adminID)
+	if err == nil {
+		_ = b.notify(customerID, NotificationSecurityAlert, fmt.Sprintf("Security alert: staff member %s opened a session on your accounts without your consent, authorised by %s: %s", staffID, adminID, reason), NotifyUrgent)
+	}
+	return session, err
+}
+
+// startImpersonation creates the session.
+// The caller must hold the bank mutex.
+func (b *Bank) startImpersonation(staffID, customerID, reason string, canAct bool, duration time.Duration, consent string) (ImpersonationSession, error) {
+	if duration <= 0 || duration > maxImpersonationDuration {
+		return ImpersonationSession{}, errors.New("session duration must be between 0 and " + maxImpersonationDuration.String())
+	}
+	now := b.now()
+	session := &ImpersonationSession{
+		ID:         b.newID("imp"),
+		StaffID:    staffID,
+		CustomerID: customerID,
+		Reason:     reason,
+		CanAct:     canAct,
+		Consent:    consent,
+		StartedAt:  now,
+		ExpiresAt:  now.Add(duration),
+	}
+	b.impersonations[session.ID] = session
+	b.auditImpersonation(session.ID, staffID, customerID, "start", fmt.Sprintf("consent: %s, can act: %t, reason: %s", consent, canAct, reason))
+	return *session, nil
+}
+
+// activeImpersonation returns a live session.
+// The caller must hold the bank mutex.
+func (b *Bank) activeImpersonation(sessionID string) (*ImpersonationSession, error) {
+	session, exists := b.impersonations[sessionID]
+	if !exists {
+		return nil, errors.New("impersonation session does not exist")
+	}
+	if session.Ended {
+		return nil, errors.New("impersonation session has ended")
+	}
+	if !b.now().Before(session.ExpiresAt) {
+		session.Ended = true
+		b.auditImpersonation(session.ID, session.StaffID, session.CustomerID, "expired", "")
+		return nil, errors.New("impersonation session has expired")
+	}
+	return session, nil
+}
+
+// EndImpersonation closes a session.
+func (b *Bank) EndImpersonation(sessionID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	session, err := b.activeImpersonation(sessionID)
+	if err != nil {
+		return err
+	}
+	session.Ended = true
+	b.auditImpersonation(session.ID, session.StaffID, session.CustomerID, "end", "")
+	return nil
+}
+
+// ImpersonatedAccounts returns the balances of the impersonated customer's active accounts.
+func (b *Bank) ImpersonatedAccounts(sessionID string) (map[string]account.Money, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	session, err := b.activeImpersonation(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	view := make(map[string]account.Money)
+	for id := range b.accounts.all() {
+		if b.ownsAccount(id, session.CustomerID) && b.IsAccountActive(id) {
+			view[id] = b.accounts.at(id).Balance()
+		}
+	}
+	b.auditImpersonation(session.ID, session.StaffID, session.CustomerID, "view-accounts", session.Watermark())
+	return view, nil
+}
+
+// ImpersonatedTransfer transfers funds from one of the impersonated customer's accounts.
+// The transaction history entry is watermarked with the session.
+func (b *Bank) ImpersonatedTransfer(sessionID, fromID, toID string, amount account.Money) (string, error) {
+	b.mutex.Lock()
+	session, err := b.activeImpersonation(sessionID)
+	if err != nil {
+		b.mutex.Unlock()
+		return "", err
+	}
+	detail := fmt.Sprintf("transfer %s from %s to %s; %s", amount, fromID, toID, session.Watermark())
+	if !session.CanAct {
+		b.auditImpersonation(session.ID, session.StaffID, session.CustomerID, "action-denied", detail)
+		b.mutex.Unlock()
+		return "", errors.New("impersonation session is view-only")
+	}
+	if !b.ownsAccount(fromID, session.CustomerID) {
+		b.auditImpersonation(session.ID, session.StaffID, session.CustomerID, "action-denied", detail)
+		b.mutex.Unlock()
+		return "", errors.New("account is not owned by the impersonated customer")
+	}
+	b.mutex.Unlock()
+
+	txnID, err := b.transfer(fromID, toID, amount)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err != nil {
+		b.auditImpersonation(session.ID, session.StaffID, session.CustomerID, "transfer-failed", detail+": "+err.Error())
+		return "", err
+	}
+	b.annotateTransaction(txnID, session.Watermark())
+	b.auditImpersonation(session.ID, session.StaffID, session.CustomerID, "transfer", detail+", txn "+txnID)
+	return txnID, nil
+}
+
+// ImpersonationAuditLog returns every impersonation event concerning the customer.
+func (b *Bank) ImpersonationAuditLog(customerID string) []ImpersonationEvent {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	var events []ImpersonationEvent
+	for _, e := range b.impersonationLog {
+		if e.CustomerID == customerID {
+			events = append(events, e)
+		}
+	}
+	return events
+}
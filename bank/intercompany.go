@@ -0,0 +1,161 @@
+package bank
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// IntercompanySettlement is a payment of the interest accrued on one subsidiary's intercompany position.
+type IntercompanySettlement struct {
+	StructureID   string
+	AccountID     string
+	Amount        account.Money // Positive when paid by the master to the subsidiary, negative when paid to the master
+	TransactionID string
+	Err           error
+}
+
+// IntercompanyParticipant is one subsidiary's line in a cash pool participants report.
+type IntercompanyParticipant struct {
+	AccountID       string
+	Target          account.Money
+	Balance         account.Money
+	Position        account.Money // Positive when the master owes the subsidiary
+	AccruedInterest account.Money // Interest accrued but not yet settled, signed like the position
+	SettledInterest account.Money // Interest settled to date, signed like the position
+}
+
+// SetIntercompanyRate sets the annual rate charged on a structure's intercompany positions. Interest up to today
+// stays accrued at the old rate.
+func (b *Bank) SetIntercompanyRate(structureID string, rate float64) error {
+	if rate < 0 || math.IsNaN(rate) {
+		return errors.New("interest rate must not be negative")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	s, exists := b.zbaStructures[structureID]
+	if !exists {
+		return errors.New("structure does not exist")
+	}
+	b.accrueIntercompany(s, startOfDay(b.now()))
+	s.InterestRate = rate
+	return nil
+}
+
+// accrueIntercompany accrues interest on each subsidiary's position for every day up to today, moving a month's
+// interest to pending settlement when the month ends. The first call only starts the clock.
+// The caller must hold the bank mutex.
+func (b *Bank) accrueIntercompany(s *ZBAStructure, today time.Time) {
+	if s.interestThrough.IsZero() {
+		s.interestThrough = today
+		return
+	}
+	for day := s.interestThrough; day.Before(today); {
+		next := day.AddDate(0, 0, 1)
+		fraction := account.Actual365.YearFraction(day, next)
+		for id := range s.Targets {
+			if position := s.positions[id]; position != 0 && s.InterestRate != 0 {
+				s.accruedInterest[id] += float64(position) * s.InterestRate * fraction
+			}
+		}
+		day = next
+		if day.Day() == 1 {
+			for id, accrued := range s.accruedInterest {
+				s.pendingInterest[id] += accrued
+				delete(s.accruedInterest, id)
+			}
+		}
+	}
+	if today.After(s.interestThrough) {
+		s.interestThrough = today
+	}
+}
+
+// AccrueIntercompanyInterest accrues interest on intercompany positions up to today by the bank's clock and
+// settles each completed month's interest between the master and its subsidiaries. Settled amounts are rounded
+// half to even and the remainder carries forward; a settlement that fails is retried on the next run.
+func (b *Bank) AccrueIntercompanyInterest() []IntercompanySettlement {
+	type due struct {
+		s         *ZBAStructure
+		accountID string
+		amount    account.Money
+	}
+
+	b.mutex.Lock()
+	today := startOfDay(b.now())
+	ids := make([]string, 0, len(b.zbaStructures))
+	for id := range b.zbaStructures {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var dues []due
+	for _, id := range ids {
+		s := b.zbaStructures[id]
+		b.accrueIntercompany(s, today)
+		subsidiaries := make([]string, 0, len(s.pendingInterest))
+		for accountID := range s.pendingInterest {
+			subsidiaries = append(subsidiaries, accountID)
+		}
+		sort.Strings(subsidiaries)
+		for _, accountID := range subsidiaries {
+			if amount := account.Money(math.RoundToEven(s.pendingInterest[accountID])); amount != 0 {
+				dues = append(dues, due{s: s, accountID: accountID, amount: amount})
+			}
+		}
+	}
+	b.mutex.Unlock()
+
+	settlements := make([]IntercompanySettlement, 0, len(dues))
+	for _, d := range dues {
+		fromID, toID, amount := d.s.MasterID, d.accountID, d.amount
+		if amount < 0 {
+			fromID, toID, amount = toID, fromID, -amount
+		}
+		txnID, err := b.moveFunds(fromID, toID, amount)
+
+		b.mutex.Lock()
+		if err == nil {
+			d.s.pendingInterest[d.accountID] -= float64(d.amount)
+			d.s.settledInterest[d.accountID] += d.amount
+			b.annotateTransaction(txnID, "Intercompany interest: "+d.s.ID)
+		}
+		b.mutex.Unlock()
+		settlements = append(settlements, IntercompanySettlement{
+			StructureID:   d.s.ID,
+			AccountID:     d.accountID,
+			Amount:        d.amount,
+			TransactionID: txnID,
+			Err:           err,
+		})
+	}
+	return settlements
+}
+
+// IntercompanyReport lists each subsidiary of a structure with its balance, intercompany position and interest.
+func (b *Bank) IntercompanyReport(structureID string) ([]IntercompanyParticipant, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	s, exists := b.zbaStructures[structureID]
+	if !exists {
+		return nil, errors.New("structure does not exist")
+	}
+	report := make([]IntercompanyParticipant, 0, len(s.Targets))
+	for id, target := range s.Targets {
+		p := IntercompanyParticipant{
+			AccountID:       id,
+			Target:          target,
+			Position:        s.positions[id],
+			AccruedInterest: account.Money(math.RoundToEven(s.accruedInterest[id] + s.pendingInterest[id])),
+			SettledInterest: s.settledInterest[id],
+		}
+		if acc, exists := b.accounts.get(id); exists {
+			p.Balance = acc.Balance()
+		}
+		report = append(report, p)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].AccountID < report[j].AccountID })
+	return report, nil
+}
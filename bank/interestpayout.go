@@ -0,0 +1,127 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// SetInterestPayout chooses whether a savings account or fixed deposit capitalizes its interest or pays it out each
+// period to another account of the same owner; an empty payoutID capitalizes it. Savings interest is paid out as it
+// is posted, and a fixed deposit's monthly instead of at maturity. Customers may choose for their own accounts, and
+// admins and managers for any.
+func (b *Bank) SetInterestPayout(userID, accountID, payoutID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	acc, exists := b.accounts.get(accountID)
+	if !exists {
+		return errors.New("account does not exist")
+	}
+	if !b.ownsAccount(accountID, userID) && !b.isManager(userID) {
+		return decline(ReasonNotAuthorized, "customers may only choose how their own accounts' interest is paid")
+	}
+	switch acc.(type) {
+	case *account.Savings, *account.FixedDeposit:
+	default:
+		return errors.New("only savings accounts and fixed deposits can pay their interest out")
+	}
+	if payoutID == "" {
+		delete(b.interestPayouts, accountID)
+		b.auditAction(userID, "CapitalizeInterest", accountID, "", "")
+		return nil
+	}
+	target, exists := b.accounts.get(payoutID)
+	if !exists {
+		return errors.New("payout account does not exist")
+	}
+	if payoutID == accountID {
+		return errors.New("interest cannot be paid out to the account earning it")
+	}
+	if owner := b.accountOwner[accountID]; owner == "" || !b.ownsAccount(payoutID, owner) {
+		return errors.New("interest may only be paid out to an account of the same owner")
+	}
+	switch target.(type) {
+	case *account.FixedDeposit, *account.Loan:
+		return errors.New("interest can only be paid out to an account that takes deposits")
+	}
+	if !b.IsAccountActive(payoutID) {
+		return errors.New("payout account is inactive")
+	}
+	if b.currencyOf(payoutID) != b.currencyOf(accountID) {
+		return errors.New("interest must be paid out to an account in the same currency")
+	}
+	b.interestPayouts[accountID] = payoutID
+	b.auditAction(userID, "PayOutInterest", accountID, "", "to "+payoutID)
+	return nil
+}
+
+// InterestPayoutOf returns the account an account's interest is paid out to, or "" if it is capitalized.
+func (b *Bank) InterestPayoutOf(accountID string) string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.interestPayouts[accountID]
+}
+
+// payOutInterest moves interest posted to an account, less any tax withheld from it, on to the account it is paid out
+// to, if it has one. Interest that cannot be moved, e.g. because that account is frozen, stays capitalized.
+// The caller must not hold the bank mutex or the account's lock.
+func (b *Bank) payOutInterest(posting InterestPosting) InterestPosting {
+	b.mutex.RLock()
+	payoutID := b.interestPayouts[posting.AccountID]
+	b.mutex.RUnlock()
+	if payoutID == "" || posting.Amount-posting.Withheld <= 0 {
+		return posting
+	}
+	txnID, err := b.moveFunds(posting.AccountID, payoutID, posting.Amount-posting.Withheld)
+	if err != nil {
+		posting.PayoutErr = err
+		return posting
+	}
+	b.mutex.Lock()
+	b.annotateTransaction(txnID, "Interest payout")
+	b.mutex.Unlock()
+	posting.PaidTo, posting.PayoutTransactionID = payoutID, txnID
+	return posting
+}
+
+// payOutFixedDepositInterest credits the account a fixed deposit's interest is paid out to with the interest of each
+// month that has ended. While that account cannot take deposits the interest waits, to be paid once it can or
+// credited to the deposit at maturity.
+// The caller must not hold the bank mutex or either account's lock.
+func (b *Bank) payOutFixedDepositInterest(id string, today time.Time) []InterestPosting {
+	b.mutex.RLock()
+	payoutID := b.interestPayouts[id]
+	b.mutex.RUnlock()
+	unlock := b.lockAccounts(id, payoutID)
+	defer unlock()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	fd, isFixedDeposit := b.accounts.at(id).(*account.FixedDeposit)
+	target, exists := b.accounts.get(payoutID)
+	if !isFixedDeposit || !exists || b.interestPayouts[id] != payoutID || b.checkOperation(payoutID, account.OperationDeposit) != nil {
+		return nil
+	}
+	posts := fd.PayOutInterest(today)
+	if len(posts) == 0 {
+		return nil
+	}
+	b.recordAccountEvent(EventAccountUpdated, fd, Event{})
+	postings := make([]InterestPosting, 0, len(posts))
+	for _, p := range posts {
+		posting := InterestPosting{AccountID: id, Date: p.Date, Amount: p.Amount}
+		if err := target.Deposit(p.Amount); err != nil {
+			posting.PayoutErr = err
+			postings = append(postings, posting)
+			continue
+		}
+		txnID := b.newTxnID()
+		b.recordTransaction(txnID, fmt.Sprintf("Transaction ID: %s, Account: %s, Interest: %s, Date: %s, Status: %s, Interest payout from fixed deposit %s\n", txnID, payoutID, p.Amount, p.Date.Format("2006-01-02"), "success", id))
+		b.recordAccountEvent(EventInterestPosted, target, Event{Amount: p.Amount, TransactionID: txnID})
+		posting.TransactionID, posting.PaidTo, posting.PayoutTransactionID = txnID, payoutID, txnID
+		postings = append(postings, posting)
+	}
+	return postings
+}
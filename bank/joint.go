@@ -0,0 +1,358 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// SigningMode says which owners of a joint account must approve a withdrawal or outgoing transfer.
+type SigningMode string
+
+const (
+	SignAnyOwner  SigningMode = "any"       // Any one owner may debit the account alone
+	SignAllOwners SigningMode = "all"       // Every owner must approve each debit
+	SignAllAbove  SigningMode = "all-above" // Every owner must approve debits above the rule's threshold
+)
+
+// SigningRule is the signing rule of a joint account. Accounts without one let any owner act alone.
+type SigningRule struct {
+	Mode      SigningMode
+	Threshold account.Money // Largest debit one owner may make alone, for SignAllAbove
+}
+
+// validate checks the rule names a known mode and, where one applies, a threshold that is not negative.
+func (r SigningRule) validate() error {
+	switch r.Mode {
+	case SignAnyOwner, SignAllOwners:
+		if r.Threshold != 0 {
+			return fmt.Errorf("signing mode %s takes no threshold", r.Mode)
+		}
+	case SignAllAbove:
+		if r.Threshold < 0 {
+			return errors.New("signing threshold must not be negative")
+		}
+	default:
+		return fmt.Errorf("unknown signing mode %q", r.Mode)
+	}
+	return nil
+}
+
+// needsAllOwners reports whether a debit of an amount needs every owner's approval under the rule.
+func (r SigningRule) needsAllOwners(amount account.Money) bool {
+	return r.Mode == SignAllOwners || (r.Mode == SignAllAbove && amount > r.Threshold)
+}
+
+// String describes the rule for people.
+func (r SigningRule) String() string {
+	switch r.Mode {
+	case SignAllOwners:
+		return "every owner approves each withdrawal and transfer"
+	case SignAllAbove:
+		return fmt.Sprintf("every owner approves withdrawals and transfers above %s", r.Threshold)
+	}
+	return "any owner may withdraw or transfer alone"
+}
+
+// AddJointOwner makes a customer an owner of an account alongside its owner, with the same access. The account
+// must already have an owner; see AssignOwner.
+func (b *Bank) AddJointOwner(accountID, customerID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.accounts.get(accountID); !exists {
+		return errors.New("account does not exist")
+	}
+	if customerID == "" || strings.Contains(customerID, ",") {
+		return errors.New("customer ID must not be empty or contain commas")
+	}
+	if b.accountOwner[accountID] == "" {
+		return errors.New("account has no owner to share it with")
+	}
+	if b.ownsAccount(accountID, customerID) {
+		return errors.New("customer already owns the account")
+	}
+	b.jointOwners[accountID] = append(b.jointOwners[accountID], customerID)
+	return nil
+}
+
+// RemoveJointOwner takes a joint owner off an account. The owner recorded by AssignOwner cannot be removed this
+// way. Once one owner is left the account's signing rule no longer matters and is dropped.
+func (b *Bank) RemoveJointOwner(accountID, customerID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	owners := b.jointOwners[accountID]
+	for i, owner := range owners {
+		if owner == customerID {
+			owners = append(owners[:i:i], owners[i+1:]...)
+			if len(owners) == 0 {
+				delete(b.jointOwners, accountID)
+				delete(b.signingRules, accountID)
+			} else {
+				b.jointOwners[accountID] = owners
+			}
+			return nil
+		}
+	}
+	return errors.New("customer is not a joint owner of the account")
+}
+
+// OwnersOf lists every owner of an account, the owner recorded by AssignOwner first. It is empty for accounts no
+// customer owns.
+func (b *Bank) OwnersOf(accountID string) []string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.ownersOf(accountID)
+}
+
+// ownersOf is OwnersOf for callers already holding the bank mutex.
+// The caller must hold the bank mutex.
+func (b *Bank) ownersOf(accountID string) []string {
+	owner := b.accountOwner[accountID]
+	if owner == "" {
+		return nil
+	}
+	return append([]string{owner}, b.jointOwners[accountID]...)
+}
+
+// ownsAccount reports whether a customer is an owner, joint or not, of an account.
+// The caller must hold the bank mutex.
+func (b *Bank) ownsAccount(accountID, customerID string) bool {
+	if customerID == "" {
+		return false
+	}
+	for _, owner := range b.ownersOf(accountID) {
+		if owner == customerID {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSigningRule sets which owners of a joint account must approve its withdrawals and outgoing transfers.
+func (b *Bank) SetSigningRule(accountID string, rule SigningRule) error {
+	if err := rule.validate(); err != nil {
+		return err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.accounts.get(accountID); !exists {
+		return errors.New("account does not exist")
+	}
+	if len(b.jointOwners[accountID]) == 0 {
+		return errors.New("signing rules only apply to joint accounts")
+	}
+	if rule.Mode == SignAnyOwner {
+		delete(b.signingRules, accountID)
+	} else {
+		b.signingRules[accountID] = rule
+	}
+	return nil
+}
+
+// SigningRuleOf returns an account's signing rule.
+func (b *Bank) SigningRuleOf(accountID string) SigningRule {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	if rule, exists := b.signingRules[accountID]; exists {
+		return rule
+	}
+	return SigningRule{Mode: SignAnyOwner}
+}
+
+// checkSignatures declines a debit the account's signing rule says every owner must approve, unless they have.
+// The caller must hold the bank mutex.
+func (b *Bank) checkSignatures(accountID string, amount account.Money, cosigned bool) error {
+	rule, exists := b.signingRules[accountID]
+	if !exists || cosigned || !rule.needsAllOwners(amount) {
+		return nil
+	}
+	return decline(ReasonSignaturesRequired, fmt.Sprintf("joint account %s: %s; request a joint debit", accountID, rule))
+}
+
+// checkTransferSignatures declines transfers out of a joint account that need every owner's approval and lack it.
+// The caller must hold the bank mutex.
+func (b *Bank) checkTransferSignatures(t PendingTransfer) error {
+	return b.checkSignatures(t.FromID, t.Amount, t.CoSigned)
+}
+
+// JointDebitStatus describes where a joint debit is in its lifecycle.
+type JointDebitStatus string
+
+const (
+	JointDebitPending  JointDebitStatus = "pending"
+	JointDebitExecuted JointDebitStatus = "executed"
+	JointDebitFailed   JointDebitStatus = "failed"
+	JointDebitRejected JointDebitStatus = "rejected"
+	JointDebitExpired  JointDebitStatus = "expired"
+)
+
+// jointDebitTTL is how long a joint debit waits for the other owners' approval.
+const jointDebitTTL = 7 * 24 * time.Hour
+
+// JointDebit is a withdrawal or transfer out of a joint account waiting for every owner to approve it.
+type JointDebit struct {
+	ID            string
+	AccountID     string
+	ToID          string // Destination of a transfer, "" for a withdrawal
+	Amount        account.Money
+	RequestedBy   string
+	Approvals     []string // Owners who approved, the requester first
+	Status        JointDebitStatus
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
+	TransactionID string // Set once an executed transfer has one
+	Err           string // Why execution failed
+}
+
+// RequestJointDebit asks the other owners of a joint account to approve a withdrawal, or a transfer if toID is set,
+// that its signing rule does not let one owner make alone. The requester's approval is counted at once; every
+// other owner is notified and the debit runs when the last of them approves.
+func (b *Bank) RequestJointDebit(ownerID, accountID, toID string, amount account.Money) (JointDebit, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.ownsAccount(accountID, ownerID) {
+		return JointDebit{}, decline(ReasonNotAuthorized, "account does not belong to the customer")
+	}
+	if len(b.jointOwners[accountID]) == 0 {
+		return JointDebit{}, errors.New("account is not a joint account")
+	}
+	op := transaction.OpWithdrawal
+	if toID != "" {
+		op = transaction.OpTransfer
+	}
+	if err := b.checkAmount(op, accountID, amount); err != nil {
+		return JointDebit{}, err
+	}
+	now := b.now()
+	d := &JointDebit{
+		ID:          b.newID("jd"),
+		AccountID:   accountID,
+		ToID:        toID,
+		Amount:      amount,
+		RequestedBy: ownerID,
+		Approvals:   []string{ownerID},
+		Status:      JointDebitPending,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(jointDebitTTL),
+	}
+	b.jointDebits[d.ID] = d
+	for _, owner := range b.ownersOf(accountID) {
+		if owner != ownerID {
+			_ = b.notify(owner, NotificationJointDebitRequested, fmt.Sprintf("%s asks you to approve %s of %s from joint account %s (request %s)",
+				ownerID, d.kind(), amount, accountID, d.ID), NotifyUrgent)
+		}
+	}
+	return *d, nil
+}
+
+// kind names what the debit does, for notifications.
+func (d *JointDebit) kind() string {
+	if d.ToID == "" {
+		return "a withdrawal"
+	}
+	return "a transfer to " + d.ToID
+}
+
+// expireJointDebits marks pending joint debits past their expiry as expired.
+// The caller must hold the bank mutex.
+func (b *Bank) expireJointDebits() {
+	now := b.now()
+	for _, d := range b.jointDebits {
+		if d.Status == JointDebitPending && !now.Before(d.ExpiresAt) {
+			d.Status = JointDebitExpired
+		}
+	}
+}
+
+// pendingJointDebit looks up a pending joint debit on an account the customer owns.
+// The caller must hold the bank mutex.
+func (b *Bank) pendingJointDebit(debitID, ownerID string) (*JointDebit, error) {
+	b.expireJointDebits()
+	d, exists := b.jointDebits[debitID]
+	if !exists || !b.ownsAccount(d.AccountID, ownerID) {
+		return nil, errors.New("joint debit does not exist")
+	}
+	if d.Status != JointDebitPending {
+		return nil, errors.New("joint debit is " + string(d.Status))
+	}
+	return d, nil
+}
+
+// PendingJointDebits lists the pending joint debits on accounts a customer owns, oldest first.
+func (b *Bank) PendingJointDebits(customerID string) []JointDebit {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.expireJointDebits()
+	var pending []JointDebit
+	for _, d := range b.jointDebits {
+		if d.Status == JointDebitPending && b.ownsAccount(d.AccountID, customerID) {
+			pending = append(pending, *d)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].CreatedAt.Before(pending[j].CreatedAt) })
+	return pending
+}
+
+// ApproveJointDebit records an owner's approval of a joint debit. Once every current owner has approved, the debit
+// runs, still subject to every other check withdrawals and transfers pass, and the returned debit says how it went.
+func (b *Bank) ApproveJointDebit(debitID, ownerID string) (JointDebit, error) {
+	b.mutex.Lock()
+	d, err := b.pendingJointDebit(debitID, ownerID)
+	if err != nil {
+		b.mutex.Unlock()
+		return JointDebit{}, err
+	}
+	approved := make(map[string]bool, len(d.Approvals)+1)
+	for _, owner := range d.Approvals {
+		approved[owner] = true
+	}
+	if !approved[ownerID] {
+		d.Approvals = append(d.Approvals, ownerID)
+		approved[ownerID] = true
+	}
+	for _, owner := range b.ownersOf(d.AccountID) {
+		if !approved[owner] {
+			pending := *d
+			b.mutex.Unlock()
+			return pending, nil
+		}
+	}
+	// Claim the debit before releasing the lock so it cannot run twice
+	d.Status = JointDebitExecuted
+	b.mutex.Unlock()
+
+	var txnID string
+	if d.ToID == "" {
+		_, err = b.withdraw(d.AccountID, d.Amount, nil, true, RailCash, transaction.Memo{})
+	} else {
+		txnID, err = b.sendTransfer(PendingTransfer{FromID: d.AccountID, ToID: d.ToID, Amount: d.Amount, CoSigned: true})
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	d.TransactionID = txnID
+	if err != nil {
+		d.Status, d.Err = JointDebitFailed, err.Error()
+	}
+	return *d, err
+}
+
+// RejectJointDebit lets any owner of the account turn a pending joint debit down.
+func (b *Bank) RejectJointDebit(debitID, ownerID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	d, err := b.pendingJointDebit(debitID, ownerID)
+	if err != nil {
+		return err
+	}
+	d.Status = JointDebitRejected
+	if d.RequestedBy != ownerID {
+		_ = b.notify(d.RequestedBy, NotificationJointDebitRejected, fmt.Sprintf("%s rejected %s of %s from joint account %s", ownerID, d.kind(), d.Amount, d.AccountID), NotifyNormal)
+	}
+	return nil
+}
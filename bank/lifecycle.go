@@ -0,0 +1,220 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// AccountStateOf returns the lifecycle state of an account.
+func (b *Bank) AccountStateOf(accountID string) (account.State, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	state, exists := b.accounts.getState(accountID)
+	if !exists {
+		return "", errors.New("account does not exist")
+	}
+	return state, nil
+}
+
+// checkOperation returns an error if the account does not exist, its state forbids the operation or a sanctions
+// screening blocks it.
+// The caller must hold the bank mutex.
+func (b *Bank) checkOperation(accountID string, op account.Operation) error {
+	state, exists := b.accounts.getState(accountID)
+	if !exists {
+		return decline(ReasonAccountNotFound, "account does not exist")
+	}
+	if !state.Allows(op) {
+		return decline(ReasonAccountState, fmt.Sprintf("account %s is %s: %s not allowed", accountID, state, op))
+	}
+	return b.checkSanctionsHold(accountID)
+}
+
+// setAccountState moves an account from one of the given states to a new state if the lifecycle allows it,
+// recording the change in the history.
+func (b *Bank) setAccountState(accountID string, next account.State, from ...account.State) error {
+	unlock := b.lockAccounts(accountID)
+	defer unlock()
+	return b.changeAccountState(accountID, next, from...)
+}
+
+// changeAccountState is setAccountState for a caller that already holds the account's lock.
+// The caller must hold the account's lock but not the bank mutex.
+func (b *Bank) changeAccountState(accountID string, next account.State, from ...account.State) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	current, exists := b.accounts.getState(accountID)
+	if !exists {
+		return errors.New("account does not exist")
+	}
+	if !current.CanTransitionTo(next) || !containsState(from, current) {
+		return fmt.Errorf("cannot move account from %s to %s", current, next)
+	}
+	b.accounts.setState(accountID, next)
+	b.totals.update(accountID, func(e *aggregateEntry) { e.included = next != account.StateClosed })
+	txnID := b.newTxnID()
+	b.recordTransaction(txnID, fmt.Sprintf("Transaction ID: %s, Account: %s, State: %s -> %s\n", txnID, accountID, current, next))
+	eventType := EventStateChanged
+	if next == account.StateClosed {
+		eventType = EventAccountClosed
+	}
+	b.recordEvent(Event{Type: eventType, AccountID: accountID, State: next, TransactionID: txnID})
+	return nil
+}
+
+// containsState reports whether state is one of states.
+func containsState(states []account.State, state account.State) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// Freeze blocks money leaving an open or dormant account.
+func (b *Bank) Freeze(accountID string) error {
+	return b.setAccountState(accountID, account.StateFrozen, account.StateOpen, account.StateDormant)
+}
+
+// Unfreeze returns a frozen account to normal operation.
+func (b *Bank) Unfreeze(accountID string) error {
+	return b.setAccountState(accountID, account.StateOpen, account.StateFrozen)
+}
+
+// MarkDormant flags an open account as dormant after a long period without customer activity.
+func (b *Bank) MarkDormant(accountID string) error {
+	return b.setAccountState(accountID, account.StateDormant, account.StateOpen)
+}
+
+// Reopen returns a dormant or closed account to normal operation. Frozen accounts must be unfrozen instead.
+func (b *Bank) Reopen(accountID string) error {
+	return b.reopen(accountID, account.StateDormant, account.StateClosed)
+}
+
+// reopen returns an account in one of the given states to normal operation, refusing accounts that have been purged.
+func (b *Bank) reopen(accountID string, from ...account.State) error {
+	b.mutex.RLock()
+	_, exists := b.accounts.get(accountID)
+	b.mutex.RUnlock()
+	if !exists {
+		if archive, err := b.ArchivedAccount(accountID); err == nil && archive.Record.ID != "" {
+			return errors.New("account " + accountID + " was purged and cannot be reopened")
+		}
+		return errors.New("account does not exist")
+	}
+	return b.setAccountState(accountID, account.StateOpen, from...)
+}
+
+// ClosurePolicy is what happens to the money in an account being closed.
+type ClosurePolicy string
+
+const (
+	ClosureReject ClosurePolicy = "reject" // Accounts must be emptied before they are closed
+	ClosureSweep  ClosurePolicy = "sweep"  // The balance is moved into a designated account as the account closes
+)
+
+// closurePolicy returns the configured closure policy.
+// The caller must hold the bank mutex.
+func (b *Bank) closurePolicy() ClosurePolicy {
+	if b.config == nil {
+		return ClosureReject
+	}
+	return b.config.closure
+}
+
+// Close closes an open or dormant account. Frozen accounts must be unfrozen first. An account with money in it is
+// only closed under the sweep closure policy, which moves the money into the owner's default account; see CloseTo.
+func (b *Bank) Close(accountID string) error {
+	_, err := b.CloseTo(accountID, "")
+	return err
+}
+
+// CloseTo closes an open or dormant account like Close, sweeping any balance into sweepTo, or the owner's default
+// account if it is "", when the closure policy is ClosureSweep. Under ClosureReject accounts with money in them
+// are refused. Accounts that owe money, or have funds on hold, are refused under either policy. It returns the
+// sweep's transaction ID, or "" if nothing was swept.
+func (b *Bank) CloseTo(accountID, sweepTo string) (string, error) {
+	b.mutex.RLock()
+	if sweepTo == "" {
+		sweepTo = b.defaultAccounts[b.accountOwner[accountID]]
+	}
+	b.mutex.RUnlock()
+	locked := []string{accountID}
+	if sweepTo != "" && sweepTo != accountID {
+		locked = append(locked, sweepTo)
+	}
+	unlock := b.lockAccounts(locked...)
+	defer unlock()
+
+	b.mutex.Lock()
+	acc, exists := b.accounts.get(accountID)
+	if !exists {
+		b.mutex.Unlock()
+		return "", errors.New("account does not exist")
+	}
+	if state := b.accounts.state(accountID); state != account.StateOpen && state != account.StateDormant {
+		b.mutex.Unlock()
+		return "", fmt.Errorf("cannot move account from %s to %s", state, account.StateClosed)
+	}
+	balance := acc.Balance()
+	var err error
+	switch {
+	case balance < 0:
+		err = decline(ReasonBalance, fmt.Sprintf("account %s owes %s; repay it before closing", accountID, -balance))
+	case balance == 0:
+	case b.heldAmount(accountID) > 0:
+		err = decline(ReasonBalance, fmt.Sprintf("account %s has funds on hold; capture or release them before closing", accountID))
+	case b.closurePolicy() != ClosureSweep:
+		err = decline(ReasonBalance, fmt.Sprintf("account %s has a balance of %s; empty it before closing", accountID, balance))
+	case sweepTo == "" || sweepTo == accountID:
+		err = decline(ReasonBalance, fmt.Sprintf("account %s has a balance of %s and no account to sweep it into", accountID, balance))
+	default:
+		err = b.checkSweep(accountID, sweepTo)
+	}
+	if err != nil || balance == 0 {
+		b.mutex.Unlock()
+		if err != nil {
+			return "", err
+		}
+		return "", b.changeAccountState(accountID, account.StateClosed, account.StateOpen, account.StateDormant)
+	}
+	target := b.accounts.at(sweepTo)
+	b.mutex.Unlock()
+
+	if err := acc.Withdraw(balance); err != nil {
+		return "", err
+	}
+	if err := target.Deposit(balance); err != nil {
+		_ = acc.Deposit(balance)
+		return "", err
+	}
+	b.mutex.Lock()
+	txnID := b.newTxnID()
+	b.recordTransaction(txnID, fmt.Sprintf("Transaction ID: %s, From: %s, To: %s, Amount: %s, Status: %s, Closure sweep\n", txnID, accountID, sweepTo, balance, "success"))
+	b.recordEvent(Event{Type: EventTransferred, AccountID: accountID, ToID: sweepTo, Amount: balance, TransactionID: txnID})
+	b.mutex.Unlock()
+	return txnID, b.changeAccountState(accountID, account.StateClosed, account.StateOpen, account.StateDormant)
+}
+
+// checkSweep checks a closing account's balance can be swept into another account. The sweep is the bank's, so the
+// closing account's state does not stop the money leaving, but the other account must take it in the same currency.
+// The caller must hold the bank mutex.
+func (b *Bank) checkSweep(accountID, sweepTo string) error {
+	if err := b.checkOperation(sweepTo, account.OperationTransferIn); err != nil {
+		return err
+	}
+	switch b.accounts.at(sweepTo).(type) {
+	case *account.FixedDeposit, *account.Loan:
+		return errors.New("closing balances can only be swept into an account that takes deposits")
+	}
+	if b.currencyOf(sweepTo) != b.currencyOf(accountID) {
+		return decline(ReasonCurrency, "closing balances can only be swept into an account in the same currency")
+	}
+	if !b.ownsAccount(sweepTo, b.accountOwner[accountID]) {
+		return decline(ReasonNotAuthorized, "closing balances can only be swept into another account of the same owner")
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// LoanRepayment is a payment made towards a loan.
+type LoanRepayment struct {
+	LoanID        string
+	FromID        string
+	TransactionID string
+	Interest      account.Money // Part of the payment that settled interest due
+	Principal     account.Money // Part of the payment that repaid principal
+	Outstanding   account.Money // Principal still owed after the payment
+	PaidOff       bool          // The loan was repaid in full and has been closed
+}
+
+// NewLoanAccount opens a loan and pays the principal out into a savings or checking account. Interest is charged
+// by the accrual engine at each monthly due date, and the loan is repaid by transfers into it.
+func (b *Bank) NewLoanAccount(id, accountID string, principal account.Money, interestRate float64, termMonths int) (*account.Loan, error) {
+	if principal <= 0 {
+		return nil, errors.New("principal must be positive")
+	}
+	if termMonths <= 0 {
+		return nil, errors.New("term must be at least one month")
+	}
+	if interestRate < 0 || math.IsNaN(interestRate) {
+		return nil, errors.New("interest rate must not be negative")
+	}
+	unlock := b.lockAccounts(accountID)
+	defer unlock()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	acc := b.accounts.at(accountID)
+	switch acc.(type) {
+	case *account.Savings, *account.Checking:
+	default:
+		return nil, errors.New("loan must be paid out into a savings or checking account")
+	}
+	if err := b.checkOperation(accountID, account.OperationTransferIn); err != nil {
+		return nil, err
+	}
+	if err := b.checkNewAccountID(id); err != nil {
+		return nil, err
+	}
+	if err := acc.Deposit(principal); err != nil {
+		return nil, err
+	}
+
+	// Due dates fall on the day the loan was opened so they line up with the accrual engine's days
+	loan := account.NewLoan(id, principal, interestRate, termMonths, accountID, startOfDay(b.now()))
+	b.registerAccount(loan, account.StateOpen)
+	if currency := b.currencyOf(accountID); currency != DefaultCurrency {
+		b.accountCurrency[id] = currency
+	}
+	txnID := b.newTxnID()
+	b.recordTransaction(txnID, fmt.Sprintf("Transaction ID: %s, From: %s, To: %s, Amount: %s, Status: %s, Loan disbursed, Term: %d months, Monthly payment: %s\n", txnID, id, accountID, principal, "success", termMonths, loan.MonthlyPayment()))
+	b.recordAccountEvent(EventAccountCreated, loan, Event{TransactionID: txnID})
+	b.recordEvent(Event{Type: EventDeposited, AccountID: accountID, Amount: principal, TransactionID: txnID, Rail: RailInternal})
+	return loan, nil
+}
+
+// RepayLoan moves a repayment from an account into a loan, without fees. The payment settles interest due before
+// principal, and a loan repaid in full is closed.
+func (b *Bank) RepayLoan(loanID, fromID string, amount account.Money) (LoanRepayment, error) {
+	if amount <= 0 {
+		return LoanRepayment{}, errors.New("repayment amount must be positive")
+	}
+	unlock := b.lockAccounts(fromID, loanID)
+	b.mutex.Lock()
+	loan, isLoan := b.accounts.at(loanID).(*account.Loan)
+	sameCurrency := b.currencyOf(fromID) == b.currencyOf(loanID)
+	b.mutex.Unlock()
+	if !isLoan {
+		unlock()
+		return LoanRepayment{}, errors.New("account is not a loan")
+	}
+	if !sameCurrency {
+		unlock()
+		return LoanRepayment{}, errors.New("loan must be repaid from an account in the same currency")
+	}
+
+	interestDue := loan.InterestDue()
+	txnID, err := b.executeTransfer(fromID, loanID, amount)
+	if err != nil {
+		unlock()
+		return LoanRepayment{}, err
+	}
+	repayment := LoanRepayment{LoanID: loanID, FromID: fromID, TransactionID: txnID, Interest: min(amount, interestDue)}
+	repayment.Principal = amount - repayment.Interest
+	repayment.Outstanding = loan.OutstandingPrincipal()
+	repayment.PaidOff = loan.PaidOff()
+	b.mutex.Lock()
+	b.annotateTransaction(txnID, fmt.Sprintf("Loan repayment, Interest: %s, Principal: %s", repayment.Interest, repayment.Principal))
+	b.mutex.Unlock()
+	unlock()
+
+	if repayment.PaidOff {
+		return repayment, b.Close(loanID)
+	}
+	return repayment, nil
+}
+
+// recordRepayment records the state a credit leaves a loan in, since how a repayment splits between interest and
+// principal cannot be replayed from its amount alone. Credits to other accounts are ignored.
+// The caller must hold the bank mutex and the account's lock.
+func (b *Bank) recordRepayment(acc account.Account, txnID string) {
+	if _, isLoan := acc.(*account.Loan); isLoan {
+		b.recordAccountEvent(EventAccountUpdated, acc, Event{TransactionID: txnID})
+	}
+}
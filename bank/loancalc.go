@@ -0,0 +1,101 @@
+package bank
+
+import (
+	"errors"
+	"math"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// defaultMaxDebtToIncome caps total monthly debt payments as a share of monthly income.
+const defaultMaxDebtToIncome = 0.36
+
+// AffordabilityInput describes a borrower and the loan terms being considered.
+type AffordabilityInput struct {
+	MonthlyIncome      account.Money // Declared or detected gross monthly income
+	MonthlyObligations account.Money // Existing monthly debt payments
+	AnnualRate         float64       // e.g. 0.07 for 7%
+	TermMonths         int
+	MaxDebtToIncome    float64 // Optional; defaults to 36%
+}
+
+// AffordabilityResult is the outcome of an affordability calculation.
+type AffordabilityResult struct {
+	MaxLoanAmount  account.Money
+	MonthlyPayment account.Money // Payment on the maximum loan, or on the requested amount when pre-qualifying
+	DebtToIncome   float64       // Total obligations including the new payment, as a share of income
+	Qualified      bool
+}
+
+// MonthlyPayment returns the level payment that repays principal over the term at the annual rate.
+func MonthlyPayment(principal account.Money, annualRate float64, termMonths int) account.Money {
+	return account.LevelPayment(principal, annualRate, termMonths)
+}
+
+// principalForPayment returns the principal a level payment can repay over the term at the annual rate.
+func principalForPayment(payment account.Money, annualRate float64, termMonths int) account.Money {
+	r := annualRate / 12
+	if r == 0 {
+		return payment * account.Money(termMonths)
+	}
+	return payment.MulRate((1 - math.Pow(1+r, -float64(termMonths))) / r)
+}
+
+func (in AffordabilityInput) validate() error {
+	if in.MonthlyIncome <= 0 {
+		return errors.New("monthly income must be positive")
+	}
+	if in.MonthlyObligations < 0 {
+		return errors.New("monthly obligations must not be negative")
+	}
+	if in.AnnualRate < 0 {
+		return errors.New("rate must not be negative")
+	}
+	if in.TermMonths <= 0 {
+		return errors.New("term must be at least one month")
+	}
+	if in.MaxDebtToIncome < 0 || in.MaxDebtToIncome > 1 {
+		return errors.New("debt-to-income cap must be between 0 and 1")
+	}
+	return nil
+}
+
+func (in AffordabilityInput) maxDebtToIncome() float64 {
+	if in.MaxDebtToIncome == 0 {
+		return defaultMaxDebtToIncome
+	}
+	return in.MaxDebtToIncome
+}
+
+// CalculateAffordability returns the largest loan the borrower can afford and its monthly payment.
+func CalculateAffordability(in AffordabilityInput) (AffordabilityResult, error) {
+	if err := in.validate(); err != nil {
+		return AffordabilityResult{}, err
+	}
+	payment := in.MonthlyIncome.MulRate(in.maxDebtToIncome()) - in.MonthlyObligations
+	if payment <= 0 {
+		return AffordabilityResult{DebtToIncome: float64(in.MonthlyObligations) / float64(in.MonthlyIncome)}, nil
+	}
+	return AffordabilityResult{
+		MaxLoanAmount:  principalForPayment(payment, in.AnnualRate, in.TermMonths),
+		MonthlyPayment: payment,
+		DebtToIncome:   in.maxDebtToIncome(),
+		Qualified:      true,
+	}, nil
+}
+
+// PreQualify checks whether the borrower can afford the requested amount.
+func PreQualify(in AffordabilityInput, requestedAmount account.Money) (AffordabilityResult, error) {
+	if requestedAmount <= 0 {
+		return AffordabilityResult{}, errors.New("requested amount must be positive")
+	}
+	result, err := CalculateAffordability(in)
+	if err != nil {
+		return AffordabilityResult{}, err
+	}
+	payment := MonthlyPayment(requestedAmount, in.AnnualRate, in.TermMonths)
+	result.MonthlyPayment = payment
+	result.DebtToIncome = float64(in.MonthlyObligations+payment) / float64(in.MonthlyIncome)
+	result.Qualified = result.DebtToIncome <= in.maxDebtToIncome()
+	return result, nil
+}
@@ -0,0 +1,46 @@
+package bank
+
+import (
+	"sort"
+	"sync"
+)
+
+// Lock ordering: per-account locks are always acquired before the bank mutex, and several account
+// locks are always acquired in ascending ID order. Code holding the bank mutex must never wait on
+// an account lock. The bank mutex is a read-write lock: code that only reads the bank's maps takes
+// it shared, so reports and lookups run alongside one another and wait only for brief writes.
+
+// lockAccounts locks the given accounts in a deterministic ID order and returns a function that
+// unlocks them. IDs that do not name an account are skipped, as are repeats.
+// The caller must not hold the bank mutex.
+func (b *Bank) lockAccounts(ids ...string) func() {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+
+	b.mutex.Lock()
+	var locks []*sync.Mutex
+	for i, id := range sorted {
+		if i > 0 && id == sorted[i-1] {
+			continue
+		}
+		if _, exists := b.accounts.get(id); !exists {
+			continue
+		}
+		lock, exists := b.accountLocks[id]
+		if !exists {
+			lock = &sync.Mutex{}
+			b.accountLocks[id] = lock
+		}
+		locks = append(locks, lock)
+	}
+	b.mutex.Unlock()
+
+	for _, lock := range locks {
+		lock.Lock()
+	}
+	return func() {
+		for i := len(locks) - 1; i >= 0; i-- {
+			locks[i].Unlock()
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// memoFields formats a memo as history entry fields, or returns "" if the memo notes nothing.
+func memoFields(memo transaction.Memo) string {
+	var fields []string
+	if memo.Text != "" {
+		fields = append(fields, "Memo: "+memo.Text)
+	}
+	if memo.Reference != "" {
+		fields = append(fields, "Reference: "+memo.Reference)
+	}
+	if memo.Category != "" {
+		fields = append(fields, "Category: "+memo.Category)
+	}
+	return strings.Join(fields, ", ")
+}
+
+// recordMemo records a deposit or withdrawal with a memo in the transaction history, returning its transaction ID.
+// Deposits and withdrawals without one are only in the event log, and get no transaction ID.
+// The caller must hold the bank mutex.
+func (b *Bank) recordMemo(kind HistoryType, accountID string, amount account.Money, rail Rail, memo transaction.Memo) string {
+	if memo.IsZero() {
+		return ""
+	}
+	key := "Deposit"
+	if kind == HistoryWithdraw {
+		key = "Withdrawal"
+	}
+	txnID := b.newTxnID()
+	b.recordTransaction(txnID, fmt.Sprintf("Transaction ID: %s, Account: %s, %s: %s, Rail: %s, %s, Status: %s\n", txnID, accountID, key, amount, rail, memoFields(memo), "success"))
+	return txnID
+}
+
+// noteMemo adds a memo to the history entry of a transaction.
+// The caller must hold the bank mutex.
+func (b *Bank) noteMemo(txnID string, memo transaction.Memo) {
+	if fields := memoFields(memo); fields != "" {
+		b.annotateTransaction(txnID, fields)
+	}
+}
+
+// DepositWithMemo credits an account like DepositVia and records the deposit in the transaction history with the
+// memo, so it can be found by History. It returns the deposit's transaction ID, or "" if the memo is empty.
+func (b *Bank) DepositWithMemo(accountID string, amount account.Money, rail Rail, memo transaction.Memo) (string, error) {
+	if !rails[rail] {
+		return "", errors.New("unknown channel " + string(rail))
+	}
+	if err := memo.Validate(); err != nil {
+		return "", err
+	}
+	txnID, err := b.deposit(accountID, amount, rail, memo)
+	if err != nil {
+		return "", err
+	}
+	b.applyCreditRules(accountID, amount)
+	return txnID, nil
+}
+
+// WithdrawWithMemo debits an account like WithdrawVia and records the withdrawal in the transaction history with
+// the memo, so it can be found by History. It returns the withdrawal's transaction ID, or "" if the memo is empty.
+func (b *Bank) WithdrawWithMemo(accountID string, amount account.Money, rail Rail, memo transaction.Memo) (string, error) {
+	if !rails[rail] {
+		return "", errors.New("unknown channel " + string(rail))
+	}
+	if b.NeedsApproval(rail) {
+		return "", decline(ReasonApprovalRequired, fmt.Sprintf("%s withdrawals need a manager's approval; request one instead", rail))
+	}
+	if err := memo.Validate(); err != nil {
+		return "", err
+	}
+	return b.withdraw(accountID, amount, nil, false, rail, memo)
+}
@@ -0,0 +1,221 @@
+package bank
+
+import (
+	"errors"
+	"net/mail"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// AccountMetadata describes who an account is for and how staff have labelled it, beyond what the account itself
+// holds.
+type AccountMetadata struct {
+	OwnerName string
+	Email     string
+	CreatedAt time.Time // When the account was opened; kept by the bank, so SetAccountMetadata ignores it
+	Tags      []string  // Free-form labels, lower-cased and sorted
+}
+
+// AccountFilter selects accounts for FindAccounts. Zero fields match every account.
+type AccountFilter struct {
+	OwnerName     string        // Part of the owner's name, in any case
+	Email         string        // The contact email address, in any case
+	Tags          []string      // Tags an account must all carry
+	Customer      string        // Customer ID of an owner, joint or not
+	Type          string        // Account type as account.TypeOf names it, e.g. "savings"
+	State         account.State // Lifecycle state
+	CreatedAfter  time.Time     // Opened at or after this time
+	CreatedBefore time.Time     // Opened before this time
+}
+
+// AccountMatch is an account FindAccounts found, with what it was matched on.
+type AccountMatch struct {
+	AccountID string
+	Type      string
+	State     account.State
+	Owner     string
+	Balance   account.Money
+	Metadata  AccountMetadata
+}
+
+// normalizeTags lower-cases and trims tags, sorts them and drops blanks and duplicates. Tags may not contain commas,
+// since records keep them comma-separated.
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if strings.Contains(tag, ",") {
+			return nil, errors.New("tag " + tag + " must not contain a comma")
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// SetAccountMetadata replaces an account's owner name, email address and tags. An empty email is allowed; any other
+// must be a valid address.
+func (b *Bank) SetAccountMetadata(accountID string, meta AccountMetadata) error {
+	meta.OwnerName = strings.TrimSpace(meta.OwnerName)
+	meta.Email = strings.TrimSpace(meta.Email)
+	if meta.Email != "" {
+		if addr, err := mail.ParseAddress(meta.Email); err != nil || addr.Address != meta.Email {
+			return errors.New("email " + meta.Email + " is not a valid address")
+		}
+	}
+	tags, err := normalizeTags(meta.Tags)
+	if err != nil {
+		return err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.accounts.get(accountID); !exists {
+		return errors.New("account does not exist")
+	}
+	current := b.profileOf(accountID).Metadata
+	b.putAccountMetadata(accountID, AccountMetadata{OwnerName: meta.OwnerName, Email: meta.Email, CreatedAt: current.CreatedAt, Tags: tags})
+	return nil
+}
+
+// TagAccount adds tags to an account, keeping those it already has.
+func (b *Bank) TagAccount(accountID string, tags ...string) error {
+	return b.retagAccount(accountID, tags, true)
+}
+
+// UntagAccount removes tags from an account. Tags it does not carry are ignored.
+func (b *Bank) UntagAccount(accountID string, tags ...string) error {
+	return b.retagAccount(accountID, tags, false)
+}
+
+// retagAccount adds or removes an account's tags.
+func (b *Bank) retagAccount(accountID string, tags []string, add bool) error {
+	changed, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.accounts.get(accountID); !exists {
+		return errors.New("account does not exist")
+	}
+	meta := b.profileOf(accountID).Metadata
+	if add {
+		meta.Tags, _ = normalizeTags(append(append([]string(nil), meta.Tags...), changed...))
+	} else {
+		var kept []string
+		for _, tag := range meta.Tags {
+			if !slices.Contains(changed, tag) {
+				kept = append(kept, tag)
+			}
+		}
+		meta.Tags = kept
+	}
+	b.putAccountMetadata(accountID, meta)
+	return nil
+}
+
+// putAccountMetadata stores an account's metadata in its profile.
+// The caller must hold the bank mutex.
+func (b *Bank) putAccountMetadata(accountID string, meta AccountMetadata) {
+	if p := b.accounts.profile(accountID); p != nil {
+		p.Metadata = meta
+	}
+}
+
+// noteAccountOpened records when an account was opened.
+// The caller must hold the bank mutex.
+func (b *Bank) noteAccountOpened(accountID string, at time.Time) {
+	if p := b.accounts.profile(accountID); p != nil && p.Metadata.CreatedAt.IsZero() {
+		p.Metadata.CreatedAt = at
+	}
+}
+
+// AccountMetadataOf returns an account's metadata.
+func (b *Bank) AccountMetadataOf(accountID string) (AccountMetadata, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	if _, exists := b.accounts.get(accountID); !exists {
+		return AccountMetadata{}, errors.New("account does not exist")
+	}
+	meta := b.profileOf(accountID).Metadata
+	meta.Tags = append([]string(nil), meta.Tags...)
+	return meta, nil
+}
+
+// matches reports whether an account passes every condition of a filter.
+// The caller must hold the bank mutex.
+func (f AccountFilter) matches(b *Bank, id string, acc account.Account) bool {
+	meta := b.profileOf(id).Metadata
+	if f.OwnerName != "" && !strings.Contains(strings.ToLower(meta.OwnerName), strings.ToLower(strings.TrimSpace(f.OwnerName))) {
+		return false
+	}
+	if f.Email != "" && !strings.EqualFold(meta.Email, strings.TrimSpace(f.Email)) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(meta.Tags, strings.ToLower(strings.TrimSpace(tag))) {
+			return false
+		}
+	}
+	if f.Customer != "" && !b.ownsAccount(id, f.Customer) {
+		return false
+	}
+	if f.Type != "" && account.TypeOf(acc) != f.Type {
+		return false
+	}
+	if f.State != "" && b.accounts.state(id) != f.State {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && meta.CreatedAt.Before(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !meta.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// FindAccounts returns the accounts matching a filter, ordered by ID.
+func (b *Bank) FindAccounts(filter AccountFilter) []AccountMatch {
+	b.mutex.RLock()
+	var matches []AccountMatch
+	var accounts []account.Account
+	for id, acc := range b.accounts.all() {
+		if !filter.matches(b, id, acc) {
+			continue
+		}
+		meta := b.profileOf(id).Metadata
+		meta.Tags = append([]string(nil), meta.Tags...)
+		matches = append(matches, AccountMatch{AccountID: id, Type: account.TypeOf(acc), State: b.accounts.state(id), Owner: b.accountOwner[id], Metadata: meta})
+		accounts = append(accounts, acc)
+	}
+	b.mutex.RUnlock()
+	// Balances are read once the bank mutex is released, like Report
+	for i, acc := range accounts {
+		matches[i].Balance = acc.Balance()
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].AccountID < matches[j].AccountID })
+	return matches
+}
+
+// backfillCreatedAt takes the opening time of accounts saved before it was recorded from their AccountCreated
+// events.
+func (b *Bank) backfillCreatedAt() {
+	for _, e := range b.events {
+		if e.Type != EventAccountCreated {
+			continue
+		}
+		if _, exists := b.accounts.get(e.AccountID); exists {
+			b.noteAccountOpened(e.AccountID, e.At)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package bank
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// Legacy export layouts understood by the migration tool.
+const (
+	LayoutCSV        = "csv"
+	LayoutFixedWidth = "fixed-width"
+)
+
+// FieldMapping locates one field in a legacy record.
+// CSV fields are found by header name or by 1-based column index; fixed-width fields by 1-based start column and width.
+type FieldMapping struct {
+	Column string `json:"column,omitempty"` // CSV header name
+	Index  int    `json:"index,omitempty"`  // 1-based CSV column
+	Start  int    `json:"start,omitempty"`  // 1-based fixed-width start column
+	Width  int    `json:"width,omitempty"`  // fixed-width field length
+}
+
+// MigrationMapping describes how a legacy export maps onto account records. It is usually loaded from a JSON file.
+type MigrationMapping struct {
+	Layout           string                  `json:"layout"`
+	Delimiter        string                  `json:"delimiter,omitempty"`        // CSV only, defaults to ","
+	Header           bool                    `json:"header,omitempty"`           // CSV only, first line holds column names
+	SkipLines        int                     `json:"skipLines,omitempty"`        // leading lines to ignore (banners, headers)
+	TrailerPrefix    string                  `json:"trailerPrefix,omitempty"`    // lines starting with this are ignored
+	ImpliedDecimals  int                     `json:"impliedDecimals,omitempty"`  // digits after an implied decimal point in amounts
+	DefaultType      string                  `json:"defaultType,omitempty"`      // account type when no type field is mapped
+	TypeCodes        map[string]string       `json:"typeCodes,omitempty"`        // legacy product code -> account type
+	Fields           map[string]FieldMapping `json:"fields"`                     // keyed by id, type, balance, owner, interestRate, overdraftLimit
+	RatesInPercent   bool                    `json:"ratesInPercent,omitempty"`   // interest rates exported as 2.5 rather than 0.025
+	SkipInvalidLines bool                    `json:"skipInvalidLines,omitempty"` // reject bad lines instead of aborting the cut-over
+}
+
+// ControlTotals are the figures the legacy system reports for an export, used to prove nothing was lost.
+type ControlTotals struct {
+	RecordCount  int
+	TotalBalance account.Money
+}
+
+// MigrationIssue describes a legacy line that was not imported.
+type MigrationIssue struct {
+	Line   int
+	Reason string
+}
+
+// MigrationReport summarises a cut-over run.
+type MigrationReport struct {
+	LinesRead     int
+	Imported      []string // account IDs created
+	Rejected      []MigrationIssue
+	ImportedTotal account.Money
+	RejectedTotal account.Money // balances on rejected lines that could still be parsed
+	Control       ControlTotals
+	CountMatches  bool
+	TotalMatches  bool
+}
+
+// Verified reports whether the import reconciles with the legacy control totals.
+func (r *MigrationReport) Verified() bool {
+	return r.CountMatches && r.TotalMatches && len(r.Rejected) == 0
+}
+
+// Print writes a human-readable migration report.
+func (r *MigrationReport) Print(w io.Writer) {
+	fmt.Fprintln(w, "Migration Report:")
+	fmt.Fprintf(w, "Lines read: %d\n", r.LinesRead)
+	fmt.Fprintf(w, "Accounts imported: %d (control %d)\n", len(r.Imported), r.Control.RecordCount)
+	fmt.Fprintf(w, "Balance imported: %s (control %s)\n", r.ImportedTotal, r.Control.TotalBalance)
+	for _, issue := range r.Rejected {
+		fmt.Fprintf(w, "Rejected line %d: %s\n", issue.Line, issue.Reason)
+	}
+	if r.Verified() {
+		fmt.Fprintln(w, "Control totals verified")
+	} else {
+		fmt.Fprintln(w, "Control totals NOT verified")
+	}
+}
+
+// LoadMigrationMapping reads a mapping file.
+func LoadMigrationMapping(path string) (MigrationMapping, error) {
+	var mapping MigrationMapping
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return mapping, err
+	}
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return mapping, err
+	}
+	return mapping, mapping.validate()
+}
+
+// validate checks that the mapping is usable for its layout.
+func (m MigrationMapping) validate() error {
+	if m.Layout != LayoutCSV && m.Layout != LayoutFixedWidth {
+		return errors.New("unknown layout " + m.Layout)
+	}
+	if _, ok := m.Fields["id"]; !ok {
+		return errors.New("mapping must define an id field")
+	}
+	if _, ok := m.Fields["balance"]; !ok {
+		return errors.New("mapping must define a balance field")
+	}
+	_, hasType := m.Fields["type"]
+	if !hasType && m.DefaultType == "" {
+		return errors.New("mapping must define a type field or a default type")
+	}
+	for name, f := range m.Fields {
+		switch m.Layout {
+		case LayoutCSV:
+			if f.Column == "" && f.Index <= 0 {
+				return errors.New("csv field " + name + " needs a column or index")
+			}
+			if f.Column != "" && !m.Header {
+				return errors.New("csv field " + name + " is mapped by column name but the export has no header")
+			}
+		case LayoutFixedWidth:
+			if f.Start <= 0 || f.Width <= 0 {
+				return errors.New("fixed-width field " + name + " needs a start and width")
+			}
+		}
+	}
+	if len(m.Delimiter) > 1 {
+		return errors.New("delimiter must be a single character")
+	}
+	return nil
+}
+
+// legacyLine is one raw record with its position in the export.
+type legacyLine struct {
+	number int
+	values map[string]string
+}
+
+// readLegacyLines splits the export into field values according to the mapping.
+func readLegacyLines(r io.Reader, m MigrationMapping) ([]legacyLine, error) {
+	scanner := bufio.NewScanner(r)
+	var lines []legacyLine
+	var header map[string]int
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if number <= m.SkipLines || strings.TrimSpace(text) == "" {
+			continue
+		}
+		if m.TrailerPrefix != "" && strings.HasPrefix(text, m.TrailerPrefix) {
+			continue
+		}
+		switch m.Layout {
+		case LayoutCSV:
+			cells, err := splitCSVLine(text, m.Delimiter)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", number, err)
+			}
+			if m.Header && header == nil {
+				header = make(map[string]int, len(cells))
+				for i, name := range cells {
+					header[strings.TrimSpace(name)] = i
+				}
+				continue
+			}
+			values := make(map[string]string, len(m.Fields))
+			for name, f := range m.Fields {
+				i := f.Index - 1
+				if f.Column != "" {
+					col, ok := header[f.Column]
+					if !ok {
+						return nil, errors.New("column " + f.Column + " not found in header")
+					}
+					i = col
+				}
+				if i < len(cells) {
+					values[name] = strings.TrimSpace(cells[i])
+				}
+			}
+			lines = append(lines, legacyLine{number: number, values: values})
+		case LayoutFixedWidth:
+			values := make(map[string]string, len(m.Fields))
+			for name, f := range m.Fields {
+				start := f.Start - 1
+				if start >= len(text) {
+					continue
+				}
+				end := start + f.Width
+				if end > len(text) {
+					end = len(text)
+				}
+				values[name] = strings.TrimSpace(text[start:end])
+			}
+			lines = append(lines, legacyLine{number: number, values: values})
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// splitCSVLine parses a single CSV line, honouring quoted fields.
+func splitCSVLine(text, delimiter string) ([]string, error) {
+	reader := csv.NewReader(strings.NewReader(text))
+	if delimiter != "" {
+		reader.Comma = rune(delimiter[0])
+	}
+	reader.FieldsPerRecord = -1
+	return reader.Read()
+}
+
+// ParseLegacyAmount converts an exported amount into Money without going through floating point.
+// It accepts leading or trailing signs, thousands separators, and implied decimals such as "0001234" for 12.34.
+func ParseLegacyAmount(text string, impliedDecimals int) (account.Money, error) {
+	s := strings.ReplaceAll(strings.TrimSpace(text), ",", "")
+	if s == "" {
+		return 0, errors.New("empty amount")
+	}
+	negative := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		negative, s = true, s[1:]
+	case strings.HasSuffix(s, "-"):
+		negative, s = true, s[:len(s)-1]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	case strings.HasSuffix(s, "+"):
+		s = s[:len(s)-1]
+	}
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	} else if impliedDecimals > 0 {
+		if len(s) < impliedDecimals {
+			s = strings.Repeat("0", impliedDecimals-len(s)) + s
+		}
+		whole, frac = s[:len(s)-impliedDecimals], s[len(s)-impliedDecimals:]
+	}
+	if len(frac) > 2 {
+		if strings.Trim(frac[2:], "0") != "" {
+			return 0, errors.New("amount " + text + " has more than two decimal places")
+		}
+		frac = frac[:2]
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	major, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid amount " + text)
+	}
+	minor, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid amount " + text)
+	}
+	amount := account.Money(major*account.MinorUnits + minor)
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// toRecord converts a legacy line into an account record.
+func (m MigrationMapping) toRecord(line legacyLine) (account.Record, error) {
+	rec := account.Record{ID: line.values["id"], Active: true, State: account.StateOpen, Owner: line.values["owner"], Type: m.DefaultType}
+	if rec.ID == "" {
+		return rec, errors.New("missing account ID")
+	}
+	if code, ok := line.values["type"]; ok && code != "" {
+		rec.Type = code
+		if mapped, ok := m.TypeCodes[code]; ok {
+			rec.Type = mapped
+		}
+	}
+	balance, err := ParseLegacyAmount(line.values["balance"], m.ImpliedDecimals)
+	if err != nil {
+		return rec, err
+	}
+	rec.Balance = balance
+	if text := line.values["interestRate"]; text != "" {
+		rate, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return rec, errors.New("invalid interest rate " + text)
+		}
+		if m.RatesInPercent {
+			rate /= 100
+		}
+		rec.InterestRate = rate
+	}
+	if text := line.values["overdraftLimit"]; text != "" {
+		limit, err := ParseLegacyAmount(text, m.ImpliedDecimals)
+		if err != nil {
+			return rec, err
+		}
+		rec.OverdraftLimit = limit
+	}
+	switch rec.Type {
+	case "savings":
+		if rec.Balance < 0 {
+			return rec, errors.New("savings account has a negative balance")
+		}
+	case "checking":
+		if rec.Balance < -rec.OverdraftLimit {
+			return rec, errors.New("checking account is beyond its overdraft limit")
+		}
+	default:
+		return rec, errors.New("unsupported account type " + rec.Type)
+	}
+	return rec, nil
+}
+
+// ImportLegacy migrates accounts from a legacy export and reconciles them against the control totals.
+// Nothing is imported unless every line parses (or SkipInvalidLines is set) and the parsed figures match the control
+// totals, so a failed cut-over leaves the bank untouched. The report is returned in every case.
+func (b *Bank) ImportLegacy(r io.Reader, m MigrationMapping, control ControlTotals) (*MigrationReport, error) {
+	report := &MigrationReport{Control: control}
+	if err := m.validate(); err != nil {
+		return report, err
+	}
+	lines, err := readLegacyLines(r, m)
+	if err != nil {
+		return report, err
+	}
+	report.LinesRead = len(lines)
+
+	var records []account.Record
+	var parsedTotal account.Money
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		rec, err := m.toRecord(line)
+		if err == nil && seen[rec.ID] {
+			err = errors.New("duplicate account ID " + rec.ID)
+		}
+		if err != nil {
+			report.Rejected = append(report.Rejected, MigrationIssue{Line: line.number, Reason: err.Error()})
+			if amount, perr := ParseLegacyAmount(line.values["balance"], m.ImpliedDecimals); perr == nil {
+				report.RejectedTotal += amount
+			}
+			continue
+		}
+		seen[rec.ID] = true
+		records = append(records, rec)
+		parsedTotal += rec.Balance
+	}
+
+	report.CountMatches = len(records) == control.RecordCount
+	report.TotalMatches = parsedTotal == control.TotalBalance
+	if len(report.Rejected) > 0 && !m.SkipInvalidLines {
+		return report, fmt.Errorf("%d legacy lines could not be migrated", len(report.Rejected))
+	}
+	if !report.CountMatches || !report.TotalMatches {
+		return report, errors.New("export does not reconcile with control totals")
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, rec := range records {
+		if err := b.checkNewAccountID(rec.ID); err != nil {
+			return report, err
+		}
+	}
+	for _, rec := range records {
+		acc, err := accountFromRecord(rec)
+		if err != nil {
+			return report, err
+		}
+		if rec.Owner != "" {
+			b.accountOwner[rec.ID] = rec.Owner
+		}
+		b.registerAccount(acc, account.StateOpen)
+		b.recordAccountEvent(EventAccountCreated, acc, Event{})
+		b.recordTransaction("migration-"+rec.ID, fmt.Sprintf("Migration: Account: %s, Opening Balance: %s\n", rec.ID, rec.Balance))
+		report.Imported = append(report.Imported, rec.ID)
+	}
+
+	// Re-read the balances from the live accounts so the report proves what was actually booked.
+	for _, id := range report.Imported {
+		report.ImportedTotal += b.accounts.at(id).Balance()
+	}
+	report.TotalMatches = report.ImportedTotal == control.TotalBalance
+	return report, nil
+}
+
+// ImportLegacyFile runs ImportLegacy against an export file using a mapping file.
+func (b *Bank) ImportLegacyFile(exportPath, mappingPath string, control ControlTotals) (*MigrationReport, error) {
+	mapping, err := LoadMigrationMapping(mappingPath)
+	if err != nil {
+		return &MigrationReport{Control: control}, err
+	}
+	f, err := os.Open(exportPath)
+	if err != nil {
+		return &MigrationReport{Control: control}, err
+	}
+	defer f.Close()
+	return b.ImportLegacy(f, mapping, control)
+}
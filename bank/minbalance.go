@@ -0,0 +1,92 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// MinimumBalanceAction is what happens to a withdrawal or transfer that would take an account below its minimum
+// balance.
+type MinimumBalanceAction string
+
+const (
+	MinimumReject MinimumBalanceAction = "reject" // The debit is declined
+	MinimumFee    MinimumBalanceAction = "fee"    // The debit goes through and a fee is charged for breaching the minimum
+)
+
+// minimumBalanceFeeRule names the fee charged for breaching a minimum balance.
+const minimumBalanceFeeRule = "Minimum balance"
+
+// MinimumBalance is the balance an account must keep after withdrawals and transfers.
+type MinimumBalance struct {
+	Amount account.Money        `json:"amount"` // In minor units; zero means no minimum
+	Action MinimumBalanceAction `json:"action"` // "" means MinimumReject
+	Fee    account.Money        `json:"fee"`    // Charged per breaching debit when Action is MinimumFee
+}
+
+// minimumBalance returns the minimum an account must keep: its product's if the product sets one, otherwise its
+// account type's. It reports false when the account has none.
+// The caller must hold the bank mutex.
+func (b *Bank) minimumBalance(accountID string) (MinimumBalance, bool) {
+	acc, exists := b.accounts.get(accountID)
+	if !exists || b.config == nil {
+		return MinimumBalance{}, false
+	}
+	if product, onProduct := b.config.products[b.accountProduct[accountID]]; onProduct && product.MinimumBalance.Amount > 0 {
+		return product.MinimumBalance, true
+	}
+	minimum, exists := b.config.minimums[account.TypeOf(acc)]
+	return minimum, exists && minimum.Amount > 0
+}
+
+// checkMinimumBalance declines a debit, including its fees, that would take an account whose minimum is enforced by
+// rejection below it.
+// The caller must hold the bank mutex.
+func (b *Bank) checkMinimumBalance(accountID string, debit account.Money) error {
+	minimum, exists := b.minimumBalance(accountID)
+	if !exists || minimum.Action == MinimumFee {
+		return nil
+	}
+	if b.accounts.at(accountID).Balance()-debit < minimum.Amount {
+		return decline(ReasonMinimumBalance, fmt.Sprintf("the balance cannot fall below the account's %s minimum", minimum.Amount))
+	}
+	return nil
+}
+
+// minimumBalanceFee returns the fee for a debit, including its other fees, that would take an account whose minimum
+// is enforced by a fee below it.
+// The caller must hold the bank mutex.
+func (b *Bank) minimumBalanceFee(accountID string, op transaction.OperationType, debit account.Money) transaction.Fees {
+	minimum, exists := b.minimumBalance(accountID)
+	if !exists || minimum.Action != MinimumFee {
+		return nil
+	}
+	if b.accounts.at(accountID).Balance()-debit >= minimum.Amount {
+		return nil
+	}
+	reason := fmt.Sprintf("%s takes the balance below the %s minimum", op, minimum.Amount)
+	return transaction.Fees{{Rule: minimumBalanceFeeRule, Amount: minimum.Fee, Reason: reason}}
+}
+
+// validMinimumBalance checks a configured minimum balance.
+func validMinimumBalance(m MinimumBalance) error {
+	if m.Amount < 0 || m.Fee < 0 {
+		return errors.New("minimum balance amounts must not be negative")
+	}
+	switch m.Action {
+	case "", MinimumReject:
+		if m.Fee != 0 {
+			return errors.New("minimum balance fee needs the fee action")
+		}
+	case MinimumFee:
+		if m.Fee == 0 && m.Amount > 0 {
+			return errors.New("minimum balance fee action needs a fee")
+		}
+	default:
+		return errors.New("minimum balance action must be reject or fee, not " + string(m.Action))
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// Mode says whether a bank holds real money or test money.
+type Mode string
+
+const (
+	ModeProduction Mode = "production"
+	ModeSandbox    Mode = "sandbox" // Balances are test money and external integrations are stubbed
+)
+
+// ErrRealConnector is returned when a sandbox bank is asked to reach a real external system.
+var ErrRealConnector = errors.New("sandbox mode: real connectors are disabled")
+
+// SandboxStub is implemented by connectors that never leave the process, such as ConsoleNotifier and StaticRates.
+// A sandbox bank refuses to use any other notifier, rate provider or webhook transport.
+type SandboxStub interface {
+	SandboxStub()
+}
+
+// ModeStorage is implemented by storage backends that record the mode of the bank they hold, so test money and
+// real money never share a store.
+type ModeStorage interface {
+	SaveMode(mode Mode) error
+	LoadMode() (Mode, error) // "" if no mode has been recorded
+}
+
+// Mode returns the mode the bank runs in.
+func (b *Bank) Mode() Mode {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.mode
+}
+
+// SetMode puts the bank in a mode and records it in storage. Banks start in production mode, or in the mode their
+// storage recorded. A sandbox bank cannot go into production, and a production bank can only go into sandbox mode
+// while it holds no accounts or transactions, so test money never turns into real money or the other way round.
+func (b *Bank) SetMode(mode Mode) error {
+	if mode != ModeProduction && mode != ModeSandbox {
+		return fmt.Errorf("unknown mode %q", mode)
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if mode != b.mode {
+		if b.mode == ModeSandbox {
+			return errors.New("bank holds test money; run production on fresh storage")
+		}
+		if b.accounts.len() > 0 || len(b.transactionHist) > 0 {
+			return errors.New("bank holds real money; run a sandbox on fresh storage")
+		}
+	}
+	b.mode = mode
+	if ms, ok := b.storage.(ModeStorage); ok {
+		return ms.SaveMode(mode)
+	}
+	return nil
+}
+
+// SandboxStub marks the console notifier as safe for sandbox banks.
+func (cn *ConsoleNotifier) SandboxStub() {}
+
+// SandboxStub marks static rates as safe for sandbox banks.
+func (sr StaticRates) SandboxStub() {}
+
+// refusedRates stands in for a real rate provider in a sandbox bank.
+type refusedRates struct{}
+
+// Rate refuses every lookup.
+func (refusedRates) Rate(from, to string) (float64, error) {
+	return 0, ErrRealConnector
+}
+
+// rateProvider returns where exchange rates come from, refusing real providers in sandbox mode.
+// The caller must hold the bank mutex.
+func (b *Bank) rateProvider() RateProvider {
+	if _, stub := b.rates.(SandboxStub); b.mode == ModeSandbox && b.rates != nil && !stub {
+		return refusedRates{}
+	}
+	return b.rates
+}
+
+// sandboxTransport accepts every webhook delivery without touching the network.
+type sandboxTransport struct{}
+
+// RoundTrip answers 202 Accepted.
+func (sandboxTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusAccepted, Status: "202 Accepted", Body: http.NoBody, Request: r}, nil
+}
+
+// SandboxStub marks the transport as safe for sandbox banks.
+func (sandboxTransport) SandboxStub() {}
+
+// refusedTransport stands in for a real HTTP transport in a sandbox bank.
+type refusedTransport struct{}
+
+// RoundTrip refuses every request.
+func (refusedTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return nil, ErrRealConnector
+}
+
+// webhookClient returns the HTTP client webhooks are delivered with. Sandbox banks deliver to a stub that accepts
+// everything unless they were given a client with a stub transport of its own; any other client is refused.
+// The caller must hold the bank mutex.
+func (b *Bank) webhookClient() *http.Client {
+	client := b.webhooks.client
+	if b.mode == ModeSandbox {
+		if client == nil {
+			return &http.Client{Transport: sandboxTransport{}}
+		}
+		if _, stub := client.Transport.(SandboxStub); !stub {
+			return &http.Client{Transport: refusedTransport{}}
+		}
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return client
+}
+
+// SeedDemoData opens a savings and a checking account full of test money for a customer to try the bank with,
+// skipping any already open, and returns the IDs of those it opened. It only works in sandbox mode.
+func (b *Bank) SeedDemoData(customerID string) ([]string, error) {
+	if b.Mode() != ModeSandbox {
+		return nil, errors.New("demo data can only be seeded in sandbox mode")
+	}
+	if customerID == "" {
+		return nil, errors.New("customer ID must not be empty")
+	}
+	savingsID, checkingID := "DEMO-"+customerID+"-SAV", "DEMO-"+customerID+"-CHK"
+	var opened []string
+	if _, err := b.GetAccount(savingsID); err != nil {
+		if _, err := b.NewSavingsAccount(savingsID, account.NewMoney(1000), 0.02); err != nil {
+			return opened, err
+		}
+		opened = append(opened, savingsID)
+	}
+	if _, err := b.GetAccount(checkingID); err != nil {
+		if _, err := b.NewCheckingAccount(checkingID, account.NewMoney(500), account.NewMoney(100), 0.18); err != nil {
+			return opened, err
+		}
+		opened = append(opened, checkingID)
+	}
+	for _, id := range opened {
+		if err := b.AssignOwner(id, customerID); err != nil {
+			return opened, err
+		}
+	}
+	return opened, nil
+}
@@ -0,0 +1,132 @@
+package bank
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// NoteTarget is the kind of record a staff note is attached to.
+type NoteTarget string
+
+const (
+	NoteOnAccount     NoteTarget = "account"
+	NoteOnTransaction NoteTarget = "transaction"
+)
+
+// StaffNote is an internal note visible only to staff.
+type StaffNote struct {
+	Time     time.Time  `json:"time"`
+	Target   NoteTarget `json:"target"`
+	TargetID string     `json:"targetId"`
+	Author   string     `json:"author"`
+	Text     string     `json:"text"`
+}
+
+// AddStaff registers a staff member (teller or support) who may read and write internal notes.
+func (b *Bank) AddStaff(staffID string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.staff[staffID] = true
+}
+
+// isStaff reports whether the ID belongs to a staff member or admin.
+// The caller must hold the bank mutex.
+func (b *Bank) isStaff(staffID string) bool {
+	return b.staff[staffID] || b.admins[staffID]
+}
+
+// AddNote attaches an internal note to an account or transaction.
+func (b *Bank) AddNote(staffID string, target NoteTarget, targetID, text string) error {
+	if strings.TrimSpace(text) == "" {
+		return errors.New("note must not be empty")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.isStaff(staffID) {
+		return errors.New("only staff may add notes")
+	}
+	switch target {
+	case NoteOnAccount:
+		if _, exists := b.accounts.get(targetID); !exists {
+			return errors.New("account does not exist")
+		}
+	case NoteOnTransaction:
+		if _, exists := b.transactionHist[targetID]; !exists {
+			return errors.New("transaction does not exist")
+		}
+	default:
+		return errors.New("unknown note target")
+	}
+	b.staffNotes = append(b.staffNotes, StaffNote{
+		Time:     b.now(),
+		Target:   target,
+		TargetID: targetID,
+		Author:   staffID,
+		Text:     text,
+	})
+	return nil
+}
+
+// Notes returns the notes attached to an account or transaction, oldest first.
+func (b *Bank) Notes(staffID string, target NoteTarget, targetID string) ([]StaffNote, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.isStaff(staffID) {
+		return nil, errors.New("only staff may view notes")
+	}
+	var notes []StaffNote
+	for _, n := range b.staffNotes {
+		if n.Target == target && n.TargetID == targetID {
+			notes = append(notes, n)
+		}
+	}
+	return notes, nil
+}
+
+// SearchNotes returns notes whose text or author contains the query, ignoring case.
+func (b *Bank) SearchNotes(staffID, query string) ([]StaffNote, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.isStaff(staffID) {
+		return nil, errors.New("only staff may search notes")
+	}
+	q := strings.ToLower(query)
+	var notes []StaffNote
+	for _, n := range b.staffNotes {
+		if strings.Contains(strings.ToLower(n.Text), q) || strings.Contains(strings.ToLower(n.Author), q) {
+			notes = append(notes, n)
+		}
+	}
+	return notes, nil
+}
+
+// auditExportLine is one JSON line of an audit export.
+type auditExportLine struct {
+	Kind          string              `json:"kind"`
+	Note          *StaffNote          `json:"note,omitempty"`
+	Impersonation *ImpersonationEvent `json:"impersonation,omitempty"`
+}
+
+// ExportAudit writes staff notes and impersonation events to w as JSON lines, for staff only.
+func (b *Bank) ExportAudit(staffID string, w io.Writer) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.isStaff(staffID) {
+		return errors.New("only staff may export the audit trail")
+	}
+	enc := json.NewEncoder(w)
+	for i := range b.staffNotes {
+		if err := enc.Encode(auditExportLine{Kind: "note", Note: &b.staffNotes[i]}); err != nil {
+			return err
+		}
+	}
+	for i := range b.impersonationLog {
+		if err := enc.Encode(auditExportLine{Kind: "impersonation", Impersonation: &b.impersonationLog[i]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Notifier delivers messages to customers.
+type Notifier interface {
+	Notify(customerID, message string) error
+}
+
+// NotificationKind identifies the event a notification is about, so channels can format each kind differently.
+type NotificationKind string
+
+const (
+	NotificationPaymentRequested        NotificationKind = "payment-requested"
+	NotificationPaymentRequestPaid      NotificationKind = "payment-request-paid"
+	NotificationPaymentRequestDeclined  NotificationKind = "payment-request-declined"
+	NotificationPaymentRequestReminder  NotificationKind = "payment-request-reminder"
+	NotificationScheduledTransferFailed NotificationKind = "scheduled-transfer-failed"
+	NotificationSecurityAlert           NotificationKind = "security-alert"
+	NotificationLimitRaised             NotificationKind = "limit-raised"
+	NotificationDigest                  NotificationKind = "digest"
+	NotificationJointDebitRequested     NotificationKind = "joint-debit-requested"
+	NotificationJointDebitRejected      NotificationKind = "joint-debit-rejected"
+	NotificationPayeeAdded              NotificationKind = "payee-added"
+	NotificationRuleAlert               NotificationKind = "rule-alert"
+	NotificationTierChanged             NotificationKind = "tier-changed"
+)
+
+// KindNotifier is implemented by notifiers that format notifications by kind. The bank prefers it to Notify.
+type KindNotifier interface {
+	NotifyKind(customerID string, kind NotificationKind, message string) error
+}
+
+// ConsoleNotifier prints notifications to standard output.
+type ConsoleNotifier struct{}
+
+// Notify prints the message for the customer.
+func (cn *ConsoleNotifier) Notify(customerID, message string) error {
+	fmt.Printf("[notification to %s] %s\n", customerID, message)
+	return nil
+}
+
+// SetNotifier replaces the notifier used by the bank.
+func (b *Bank) SetNotifier(notifier Notifier) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.notifier = notifier
+}
+
+// NotificationUrgency says whether a notification may be held back.
+type NotificationUrgency int
+
+const (
+	// NotifyNormal notifications may be held for quiet hours or batched into a digest.
+	NotifyNormal NotificationUrgency = iota
+	// NotifyUrgent notifications are security alerts, delivered at once regardless of preferences.
+	NotifyUrgent
+)
+
+// NotificationPreferences controls when a customer receives non-urgent notifications. Times are offsets from
+// midnight in Location, or UTC when it is nil. Quiet hours may wrap past midnight, e.g. 22:00 to 07:00; equal start
+// and end means no quiet hours.
+type NotificationPreferences struct {
+	Digest     bool          // Batch non-urgent notifications into one message a day
+	DigestAt   time.Duration // When the daily digest is sent
+	QuietStart time.Duration // Start of quiet hours, when nothing non-urgent is delivered
+	QuietEnd   time.Duration
+	Location   *time.Location
+}
+
+// timeOfDay returns how far t is past midnight in the preferences' location, and that midnight.
+func (np NotificationPreferences) timeOfDay(t time.Time) (time.Duration, time.Time) {
+	if np.Location != nil {
+		t = t.In(np.Location)
+	} else {
+		t = t.UTC()
+	}
+	midnight := startOfDay(t)
+	return t.Sub(midnight), midnight
+}
+
+// quiet reports whether t falls within quiet hours.
+func (np NotificationPreferences) quiet(t time.Time) bool {
+	if np.QuietStart == np.QuietEnd {
+		return false
+	}
+	tod, _ := np.timeOfDay(t)
+	if np.QuietStart < np.QuietEnd {
+		return tod >= np.QuietStart && tod < np.QuietEnd
+	}
+	return tod >= np.QuietStart || tod < np.QuietEnd
+}
+
+// heldNotification is a non-urgent notification waiting for quiet hours to end or for the daily digest.
+type heldNotification struct {
+	at      time.Time
+	kind    NotificationKind
+	message string
+}
+
+// notificationInbox holds a customer's undelivered notifications.
+type notificationInbox struct {
+	held       []heldNotification
+	lastDigest time.Time // Midnight, in the customer's location, of the day the last digest was sent
+}
+
+// SetNotificationPreferences sets when a customer receives non-urgent notifications.
+func (b *Bank) SetNotificationPreferences(customerID string, prefs NotificationPreferences) error {
+	for _, d := range []time.Duration{prefs.DigestAt, prefs.QuietStart, prefs.QuietEnd} {
+		if d < 0 || d >= 24*time.Hour {
+			return errors.New("notification times must be within a day")
+		}
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.notifyPrefs[customerID] = prefs
+	return nil
+}
+
+// NotificationPreferencesOf returns a customer's notification preferences. Customers without any receive every
+// notification at once.
+func (b *Bank) NotificationPreferencesOf(customerID string) NotificationPreferences {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.notifyPrefs[customerID]
+}
+
+// notify delivers a notification to a customer, or holds it for quiet hours or the daily digest.
+// The caller must hold the bank mutex.
+func (b *Bank) notify(customerID string, kind NotificationKind, message string, urgency NotificationUrgency) error {
+	if b.notifier == nil {
+		return nil
+	}
+	prefs, exists := b.notifyPrefs[customerID]
+	now := b.now()
+	if urgency == NotifyUrgent || !exists || (!prefs.Digest && !prefs.quiet(now)) {
+		return b.deliver(customerID, kind, message)
+	}
+	inbox, exists := b.notifyInboxes[customerID]
+	if !exists {
+		inbox = &notificationInbox{}
+		b.notifyInboxes[customerID] = inbox
+	}
+	inbox.held = append(inbox.held, heldNotification{at: now, kind: kind, message: message})
+	return nil
+}
+
+// deliver hands a notification to the notifier, with its kind if the notifier uses it.
+// The caller must hold the bank mutex.
+func (b *Bank) deliver(customerID string, kind NotificationKind, message string) error {
+	if _, stub := b.notifier.(SandboxStub); b.mode == ModeSandbox && !stub {
+		return ErrRealConnector
+	}
+	if kn, ok := b.notifier.(KindNotifier); ok {
+		return kn.NotifyKind(customerID, kind, message)
+	}
+	return b.notifier.Notify(customerID, message)
+}
+
+// DeliverHeldNotifications sends notifications held back by customers' preferences once they may be delivered:
+// after quiet hours, and for digest subscribers as a single message once the day's digest time has passed.
+// Notifications that fail to send stay held. It returns the number of messages sent.
+func (b *Bank) DeliverHeldNotifications() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.notifier == nil {
+		return 0
+	}
+	now := b.now()
+	customers := make([]string, 0, len(b.notifyInboxes))
+	for customerID := range b.notifyInboxes {
+		customers = append(customers, customerID)
+	}
+	sort.Strings(customers)
+
+	sent := 0
+	for _, customerID := range customers {
+		inbox := b.notifyInboxes[customerID]
+		prefs := b.notifyPrefs[customerID]
+		if prefs.quiet(now) {
+			continue
+		}
+		if !prefs.Digest {
+			for len(inbox.held) > 0 && b.deliver(customerID, inbox.held[0].kind, inbox.held[0].message) == nil {
+				inbox.held = inbox.held[1:]
+				sent++
+			}
+		} else if tod, midnight := prefs.timeOfDay(now); tod >= prefs.DigestAt && !midnight.Equal(inbox.lastDigest) {
+			var sb strings.Builder
+			fmt.Fprintf(&sb, "Daily digest: %d notifications", len(inbox.held))
+			for _, n := range inbox.held {
+				fmt.Fprintf(&sb, "\n- %s %s", n.at.Format("15:04"), n.message)
+			}
+			if b.deliver(customerID, NotificationDigest, sb.String()) == nil {
+				inbox.held = nil
+				inbox.lastDigest = midnight
+				sent++
+			}
+		}
+		if len(inbox.held) == 0 {
+			delete(b.notifyInboxes, customerID)
+		}
+	}
+	return sent
+}
@@ -0,0 +1,193 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// overdraftProtectionReason marks the transfers and fees of overdraft protection in the event log.
+const overdraftProtectionReason = "Overdraft protection"
+
+// OverdraftProtection is the pricing and limits of the transfers that cover checking withdrawals from linked
+// savings accounts.
+type OverdraftProtection struct {
+	Fee       account.Money `json:"fee"`       // Charged to the checking account for each protection transfer
+	MaxAmount account.Money `json:"maxAmount"` // Largest shortfall one transfer covers; zero means no limit
+	MaxPerDay int           `json:"maxPerDay"` // Protection transfers per checking account a day; zero means no limit
+}
+
+// SetOverdraftProtection links a checking account to a savings account of the same owner, so withdrawals beyond
+// the checking balance pull the shortfall from the savings; an empty savingsID removes the link. Customers may
+// choose for their own accounts, and admins and managers for any.
+func (b *Bank) SetOverdraftProtection(userID, checkingID, savingsID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	acc, exists := b.accounts.get(checkingID)
+	if !exists {
+		return errors.New("account does not exist")
+	}
+	if !b.ownsAccount(checkingID, userID) && !b.isManager(userID) {
+		return decline(ReasonNotAuthorized, "customers may only protect their own accounts")
+	}
+	if _, isChecking := acc.(*account.Checking); !isChecking {
+		return errors.New("only checking accounts have overdraft protection")
+	}
+	if savingsID == "" {
+		delete(b.overdraftLinks, checkingID)
+		b.auditAction(userID, "RemoveOverdraftProtection", checkingID, "", "")
+		return nil
+	}
+	savings, exists := b.accounts.get(savingsID)
+	if !exists {
+		return errors.New("savings account does not exist")
+	}
+	if _, isSavings := savings.(*account.Savings); !isSavings {
+		return errors.New("overdraft protection must come from a savings account")
+	}
+	if owner := b.accountOwner[checkingID]; owner == "" || !b.ownsAccount(savingsID, owner) {
+		return errors.New("overdraft protection must come from an account of the same owner")
+	}
+	if !b.IsAccountActive(savingsID) {
+		return errors.New("savings account is inactive")
+	}
+	if b.currencyOf(savingsID) != b.currencyOf(checkingID) {
+		return errors.New("overdraft protection must come from an account in the same currency")
+	}
+	b.overdraftLinks[checkingID] = savingsID
+	b.auditAction(userID, "SetOverdraftProtection", checkingID, "", "from "+savingsID)
+	return nil
+}
+
+// OverdraftProtectionOf returns the savings account covering a checking account's withdrawals, or "" if it has none.
+func (b *Bank) OverdraftProtectionOf(checkingID string) string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.overdraftLinks[checkingID]
+}
+
+// overdraftProtection returns the configured overdraft protection terms.
+// The caller must hold the bank mutex.
+func (b *Bank) overdraftProtection() OverdraftProtection {
+	if b.config == nil {
+		return OverdraftProtection{}
+	}
+	return b.config.protection
+}
+
+// protectionsToday counts the overdraft protection transfers made into a checking account today.
+// The caller must hold the bank mutex.
+func (b *Bank) protectionsToday(checkingID string) int {
+	today := startOfDay(b.now())
+	n := 0
+	for _, e := range b.events {
+		if e.Type == EventTransferred && e.ToID == checkingID && e.Reason == overdraftProtectionReason && !e.At.Before(today) {
+			n++
+		}
+	}
+	return n
+}
+
+// overdraftCover is a transfer from a linked savings account covering a checking debit. It is planned while the
+// debit is checked, made at the account level just before the debit and recorded only once the debit succeeds, so
+// a debit that is declined or fails leaves both accounts, the event log and the history as they were.
+type overdraftCover struct {
+	savingsID  string
+	checkingID string
+	savings    account.Account
+	checking   account.Account
+	shortfall  account.Money // Part of the debit beyond the checking account's balance less holds
+	fee        account.Money // Protection fee, pulled from savings on top of the shortfall and charged to checking
+}
+
+// planOverdraftCover plans the transfer covering the part of a debit, including its fees, beyond a checking
+// account's balance less holds from its linked savings account, with enough on top to pay the protection fee.
+// It returns nil when the account is not linked to savingsID, there is no shortfall, the shortfall is over the
+// configured limits, or the savings account cannot cover it in full; the debit then relies on the checking account's
+// own overdraft.
+// The caller must hold both accounts' locks and the bank mutex.
+func (b *Bank) planOverdraftCover(checkingID, savingsID string, debit account.Money) *overdraftCover {
+	if savingsID == "" || b.overdraftLinks[checkingID] != savingsID {
+		return nil
+	}
+	checking := b.accounts.at(checkingID)
+	shortfall := debit - max(checking.Balance()-b.heldAmount(checkingID), 0)
+	if shortfall <= 0 {
+		return nil
+	}
+	terms := b.overdraftProtection()
+	if terms.MaxAmount > 0 && shortfall > terms.MaxAmount {
+		return nil
+	}
+	if terms.MaxPerDay > 0 && b.protectionsToday(checkingID) >= terms.MaxPerDay {
+		return nil
+	}
+	pull := shortfall + terms.Fee
+	if b.checkOperation(savingsID, account.OperationWithdraw) != nil || b.available(savingsID, nil) < pull || b.checkMinimumBalance(savingsID, pull) != nil {
+		return nil
+	}
+	return &overdraftCover{savingsID: savingsID, checkingID: checkingID, savings: b.accounts.at(savingsID), checking: checking, shortfall: shortfall, fee: terms.Fee}
+}
+
+// covered returns how much the cover adds to the checking account, or zero for no cover.
+func (c *overdraftCover) covered() account.Money {
+	if c == nil {
+		return 0
+	}
+	return c.shortfall
+}
+
+// move pulls the shortfall and fee from savings into checking and takes the fee back out, putting everything back if
+// any step fails. It records nothing; see record.
+// The caller must hold both accounts' locks.
+func (c *overdraftCover) move() error {
+	if c == nil {
+		return nil
+	}
+	pull := c.shortfall + c.fee
+	if err := c.savings.Withdraw(pull); err != nil {
+		return err
+	}
+	if err := c.checking.Deposit(pull); err != nil {
+		// Put the money back rather than leave it in neither account
+		_ = c.savings.Deposit(pull)
+		return err
+	}
+	if c.fee > 0 {
+		if err := c.checking.Withdraw(c.fee); err != nil {
+			c.undo(false)
+			return err
+		}
+	}
+	return nil
+}
+
+// undo reverses a cover that was moved, returning the fee to checking first if it was taken.
+// The caller must hold both accounts' locks.
+func (c *overdraftCover) undo(feeTaken bool) {
+	if c == nil {
+		return
+	}
+	pull := c.shortfall + c.fee
+	if feeTaken && c.fee > 0 {
+		_ = c.checking.Deposit(c.fee)
+	}
+	_ = c.checking.Withdraw(pull)
+	_ = c.savings.Deposit(pull)
+}
+
+// recordOverdraftCover records a cover that was moved: the transfer, its history entry and the fee.
+// The caller must hold the bank mutex.
+func (b *Bank) recordOverdraftCover(c *overdraftCover) {
+	if c == nil {
+		return
+	}
+	pull := c.shortfall + c.fee
+	txnID := b.newTxnID()
+	b.recordEvent(Event{Type: EventTransferred, AccountID: c.savingsID, ToID: c.checkingID, Amount: pull, TransactionID: txnID, Reason: overdraftProtectionReason})
+	b.recordTransaction(txnID, fmt.Sprintf("Transaction ID: %s, From: %s, To: %s, Amount: %s, Overdraft Protection Fee: %s, Status: %s\n", txnID, c.savingsID, c.checkingID, pull, c.fee, "success"))
+	if c.fee > 0 {
+		b.recordEvent(Event{Type: EventFeeCharged, AccountID: c.checkingID, Amount: c.fee, TransactionID: txnID, Reason: overdraftProtectionReason})
+	}
+}
@@ -0,0 +1,196 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultPayeeCoolingOff is how long transfers to a newly registered payee are held back when the configuration does
+// not say.
+const DefaultPayeeCoolingOff = 24 * time.Hour
+
+// Payee is an account a customer registered, under a nickname, as somewhere they send money.
+type Payee struct {
+	CustomerID string    `json:"customerId"`
+	Nickname   string    `json:"nickname"`
+	AccountID  string    `json:"accountId"`
+	AddedAt    time.Time `json:"addedAt"`
+}
+
+// PayeeStorage is implemented by storage backends that keep the payee directory.
+type PayeeStorage interface {
+	SavePayees(payees []Payee) error
+	LoadPayees() ([]Payee, error)
+}
+
+// payeeCoolingOff returns how long transfers to a new payee are held back.
+// The caller must hold the bank mutex.
+func (b *Bank) payeeCoolingOff() time.Duration {
+	if b.config == nil {
+		return DefaultPayeeCoolingOff
+	}
+	return b.config.payeeWait
+}
+
+// AddPayee registers an account in a customer's payee directory under a nickname. Until the cooling-off period has
+// passed no transfer may be made to it, so someone who has taken over the customer's access cannot add their own
+// account and empty the customer's straight away; the customer is notified at once. It returns the new payee.
+func (b *Bank) AddPayee(customerID, nickname, accountID string) (Payee, error) {
+	nickname = strings.TrimSpace(nickname)
+	if customerID == "" {
+		return Payee{}, errors.New("customer ID must not be empty")
+	}
+	if nickname == "" {
+		return Payee{}, errors.New("payee nickname must not be empty")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.accounts.get(accountID); !exists {
+		return Payee{}, errors.New("account does not exist")
+	}
+	if b.ownsAccount(accountID, customerID) {
+		return Payee{}, errors.New("transfers between your own accounts need no payee")
+	}
+	for _, p := range b.payees[customerID] {
+		if strings.EqualFold(p.Nickname, nickname) {
+			return Payee{}, errors.New("a payee is already called " + p.Nickname)
+		}
+		if p.AccountID == accountID {
+			return Payee{}, errors.New("account " + accountID + " is already a payee, as " + p.Nickname)
+		}
+	}
+	p := &Payee{CustomerID: customerID, Nickname: nickname, AccountID: accountID, AddedAt: b.now()}
+	if b.payees[customerID] == nil {
+		b.payees[customerID] = make(map[string]*Payee)
+	}
+	b.payees[customerID][strings.ToLower(nickname)] = p
+	b.auditAction(customerID, "AddPayee", accountID, "", nickname)
+	_ = b.notify(customerID, NotificationPayeeAdded, fmt.Sprintf("Account %s was added to your payees as %s. Transfers to it can be made from %s. If you did not add it, contact us before then.",
+		accountID, nickname, p.AddedAt.Add(b.payeeCoolingOff()).Format(time.RFC1123)), NotifyUrgent)
+	return *p, nil
+}
+
+// RemovePayee deletes a payee from a customer's directory.
+func (b *Bank) RemovePayee(customerID, nickname string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	key := strings.ToLower(strings.TrimSpace(nickname))
+	p, exists := b.payees[customerID][key]
+	if !exists {
+		return errors.New("no payee called " + nickname)
+	}
+	delete(b.payees[customerID], key)
+	if len(b.payees[customerID]) == 0 {
+		delete(b.payees, customerID)
+	}
+	b.auditAction(customerID, "RemovePayee", p.AccountID, "", p.Nickname)
+	return nil
+}
+
+// Payees lists a customer's payee directory, ordered by nickname.
+func (b *Bank) Payees(customerID string) []Payee {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	payees := make([]Payee, 0, len(b.payees[customerID]))
+	for _, p := range b.payees[customerID] {
+		payees = append(payees, *p)
+	}
+	sort.Slice(payees, func(i, j int) bool { return strings.ToLower(payees[i].Nickname) < strings.ToLower(payees[j].Nickname) })
+	return payees
+}
+
+// PayeeAccount returns the account a customer registered under a nickname, matched regardless of case.
+func (b *Bank) PayeeAccount(customerID, nickname string) (string, bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	p, exists := b.payees[customerID][strings.ToLower(strings.TrimSpace(nickname))]
+	if !exists {
+		return "", false
+	}
+	return p.AccountID, true
+}
+
+// PayeeCleared reports when transfers to a payee may first be made, once its cooling-off period has passed.
+func (b *Bank) PayeeCleared(p Payee) time.Time {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return p.AddedAt.Add(b.payeeCoolingOff())
+}
+
+// payeeOf returns the longest-standing registration of an account as a payee by any of the owners of another,
+// or nil if none of them registered it.
+// The caller must hold the bank mutex.
+func (b *Bank) payeeOf(owners []string, accountID string) *Payee {
+	var found *Payee
+	for _, owner := range owners {
+		for _, p := range b.payees[owner] {
+			if p.AccountID == accountID && (found == nil || p.AddedAt.Before(found.AddedAt)) {
+				found = p
+			}
+		}
+	}
+	return found
+}
+
+// checkTransferPayee holds back transfers from a customer's account to a payee still cooling off and, when the
+// configuration requires payees, declines transfers to accounts that are neither the owners' own nor in their
+// payee directory. Accounts without an owner are not checked.
+// The caller must hold the bank mutex.
+func (b *Bank) checkTransferPayee(t PendingTransfer) error {
+	owners := b.ownersOf(t.FromID)
+	if len(owners) == 0 {
+		return nil
+	}
+	for _, owner := range owners {
+		if b.ownsAccount(t.ToID, owner) {
+			return nil
+		}
+	}
+	p := b.payeeOf(owners, t.ToID)
+	if p == nil {
+		if b.config != nil && b.config.payeesOnly {
+			return decline(ReasonUnknownPayee, fmt.Sprintf("account %s is not a registered payee; add it to your payees first", t.ToID))
+		}
+		return nil
+	}
+	if cleared := p.AddedAt.Add(b.payeeCoolingOff()); b.now().Before(cleared) {
+		return decline(ReasonNewPayee, fmt.Sprintf("payee %s was added recently; transfers to it can be made from %s", p.Nickname, cleared.Format(time.RFC1123)))
+	}
+	return nil
+}
+
+// payeesForStorage lists every customer's payees for persistence, ordered by customer and nickname.
+// The caller must hold the bank mutex.
+func (b *Bank) payeesForStorage() []Payee {
+	var payees []Payee
+	for _, byNickname := range b.payees {
+		for _, p := range byNickname {
+			payees = append(payees, *p)
+		}
+	}
+	sort.Slice(payees, func(i, j int) bool {
+		if payees[i].CustomerID != payees[j].CustomerID {
+			return payees[i].CustomerID < payees[j].CustomerID
+		}
+		return strings.ToLower(payees[i].Nickname) < strings.ToLower(payees[j].Nickname)
+	})
+	return payees
+}
+
+// restorePayees adopts a stored payee directory.
+func (b *Bank) restorePayees(payees []Payee) error {
+	for i := range payees {
+		p := payees[i]
+		if p.CustomerID == "" || p.Nickname == "" || p.AccountID == "" {
+			return errors.New("stored payee lacks a customer, nickname or account")
+		}
+		if b.payees[p.CustomerID] == nil {
+			b.payees[p.CustomerID] = make(map[string]*Payee)
+		}
+		b.payees[p.CustomerID][strings.ToLower(p.Nickname)] = &p
+	}
+	return nil
+}
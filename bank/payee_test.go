@@ -0,0 +1,77 @@
+package bank
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPayeeCoolingOff(t *testing.T) {
+	tests := []struct {
+		name          string
+		requirePayees bool
+		addPayee      bool
+		after         time.Duration
+		to            string
+		want          ReasonCode
+	}{
+		{name: "unregistered accounts are allowed by default", to: "B"},
+		{name: "unregistered accounts are declined when payees are required", requirePayees: true, to: "B", want: ReasonUnknownPayee},
+		{name: "a new payee is held back", addPayee: true, to: "B", want: ReasonNewPayee},
+		{name: "a payee is held back until the cooling-off period ends", addPayee: true, after: DefaultPayeeCoolingOff - time.Second, to: "B", want: ReasonNewPayee},
+		{name: "a payee can be paid once the cooling-off period ends", addPayee: true, after: DefaultPayeeCoolingOff, to: "B"},
+		{name: "cleared payees are allowed when payees are required", requirePayees: true, addPayee: true, after: DefaultPayeeCoolingOff, to: "B"},
+		{name: "the customer's own accounts need no payee", requirePayees: true, to: "C"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, clock := newTestBank(t, 1000, 0, 0)
+			for _, id := range []string{"A", "C"} {
+				if err := b.AssignOwner(id, "alice"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := b.ApplyConfig(Config{RequirePayees: tt.requirePayees}); err != nil {
+				t.Fatal(err)
+			}
+			if tt.addPayee {
+				if _, err := b.AddPayee("alice", "Bob", "B"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			clock.advance(tt.after)
+			if _, err := b.transfer("A", tt.to, 100); ReasonOf(err) != tt.want {
+				t.Errorf("transfer declined with %q (%v), want %q", ReasonOf(err), err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddPayee(t *testing.T) {
+	tests := []struct {
+		name      string
+		nickname  string
+		accountID string
+		wantErr   bool
+	}{
+		{name: "another customer's account", nickname: "Carol", accountID: "C"},
+		{name: "nickname taken regardless of case", nickname: "bob", accountID: "C", wantErr: true},
+		{name: "account already a payee", nickname: "Robert", accountID: "B", wantErr: true},
+		{name: "own account", nickname: "Savings", accountID: "A", wantErr: true},
+		{name: "unknown account", nickname: "Dave", accountID: "X", wantErr: true},
+		{name: "blank nickname", nickname: " ", accountID: "C", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBank(t, 1000, 0, 0)
+			if err := b.AssignOwner("A", "alice"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := b.AddPayee("alice", "Bob", "B"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := b.AddPayee("alice", tt.nickname, tt.accountID); (err != nil) != tt.wantErr {
+				t.Errorf("AddPayee(%q, %q) error = %v, want error %t", tt.nickname, tt.accountID, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// PaymentRequestStatus describes where a payment request is in its lifecycle.
+type PaymentRequestStatus string
+
+const (
+	PaymentRequestPending  PaymentRequestStatus = "pending"
+	PaymentRequestApproved PaymentRequestStatus = "approved"
+	PaymentRequestDeclined PaymentRequestStatus = "declined"
+	PaymentRequestExpired  PaymentRequestStatus = "expired"
+)
+
+// PaymentRequest represents a request from one customer to be paid by another.
+type PaymentRequest struct {
+	ID           string
+	RequesterID  string // Customer asking to be paid
+	PayerID      string // Customer being asked to pay
+	Amount       account.Money
+	Note         string
+	Status       PaymentRequestStatus
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	LastReminder time.Time
+}
+
+// RequestPayment creates a pending request for the payer to send an amount to the requester.
+func (b *Bank) RequestPayment(requesterID, payerID string, amount account.Money, note string, ttl time.Duration) (PaymentRequest, error) {
+	if amount <= 0 {
+		return PaymentRequest{}, errors.New("requested amount must be positive")
+	}
+	if ttl <= 0 {
+		return PaymentRequest{}, errors.New("request expiry must be positive")
+	}
+	if requesterID == payerID {
+		return PaymentRequest{}, errors.New("cannot request payment from yourself")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, err := b.resolveCreditAccount(requesterID); err != nil {
+		return PaymentRequest{}, err
+	}
+	now := b.now()
+	req := &PaymentRequest{
+		ID:          b.newID("req"),
+		RequesterID: requesterID,
+		PayerID:     payerID,
+		Amount:      amount,
+		Note:        note,
+		Status:      PaymentRequestPending,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	b.paymentRequests[req.ID] = req
+	_ = b.notify(payerID, NotificationPaymentRequested, fmt.Sprintf("%s requested %s: %s", requesterID, amount, note), NotifyNormal)
+	return *req, nil
+}
+
+// expirePaymentRequests marks overdue pending requests as expired.
+// The caller must hold the bank mutex.
+func (b *Bank) expirePaymentRequests() {
+	now := b.now()
+	for _, req := range b.paymentRequests {
+		if req.Status == PaymentRequestPending && !now.Before(req.ExpiresAt) {
+			req.Status = PaymentRequestExpired
+		}
+	}
+}
+
+// PendingPaymentRequests lists the pending requests addressed to a payer, oldest first.
+func (b *Bank) PendingPaymentRequests(payerID string) []PaymentRequest {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.expirePaymentRequests()
+	var pending []PaymentRequest
+	for _, req := range b.paymentRequests {
+		if req.PayerID == payerID && req.Status == PaymentRequestPending {
+			pending = append(pending, *req)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		if !pending[i].CreatedAt.Equal(pending[j].CreatedAt) {
+			return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+		}
+		return pending[i].ID < pending[j].ID
+	})
+	return pending
+}
+
+// pendingPaymentRequest looks up a pending request addressed to the payer.
+// The caller must hold the bank mutex.
+func (b *Bank) pendingPaymentRequest(requestID, payerID string) (*PaymentRequest, error) {
+	b.expirePaymentRequests()
+	req, exists := b.paymentRequests[requestID]
+	if !exists || req.PayerID != payerID {
+		return nil, errors.New("payment request does not exist")
+	}
+	if req.Status != PaymentRequestPending {
+		return nil, errors.New("payment request is " + string(req.Status))
+	}
+	return req, nil
+}
+
+// ApprovePaymentRequest pays a pending request from one of the payer's accounts.
+func (b *Bank) ApprovePaymentRequest(requestID, payerID, fromID string) error {
+	b.mutex.Lock()
+	req, err := b.pendingPaymentRequest(requestID, payerID)
+	if err == nil && !b.ownsAccount(fromID, payerID) {
+		err = errors.New("account is not owned by payer")
+	}
+	var toID string
+	if err == nil {
+		toID, err = b.resolveCreditAccount(req.RequesterID)
+	}
+	if err == nil {
+		// Claim the request before releasing the lock so it cannot be paid twice
+		req.Status = PaymentRequestApproved
+	}
+	b.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	err = b.transferFunds(fromID, toID, req.Amount)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err != nil {
+		// The request may have expired while it was being paid
+		req.Status = PaymentRequestPending
+		b.expirePaymentRequests()
+		return err
+	}
+	_ = b.notify(req.RequesterID, NotificationPaymentRequestPaid, fmt.Sprintf("%s paid your request for %s", payerID, req.Amount), NotifyNormal)
+	return nil
+}
+
+// DeclinePaymentRequest rejects a pending request.
+func (b *Bank) DeclinePaymentRequest(requestID, payerID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	req, err := b.pendingPaymentRequest(requestID, payerID)
+	if err != nil {
+		return err
+	}
+	req.Status = PaymentRequestDeclined
+	_ = b.notify(req.RequesterID, NotificationPaymentRequestDeclined, fmt.Sprintf("%s declined your request for %s", payerID, req.Amount), NotifyNormal)
+	return nil
+}
+
+// RemindPaymentRequests notifies payers of pending requests not reminded within the interval.
+// It returns the number of reminders sent.
+func (b *Bank) RemindPaymentRequests(interval time.Duration) int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.expirePaymentRequests()
+	if b.notifier == nil {
+		return 0
+	}
+	now := b.now()
+	sent := 0
+	for _, req := range b.paymentRequests {
+		if req.Status != PaymentRequestPending {
+			continue
+		}
+		last := req.LastReminder
+		if last.IsZero() {
+			last = req.CreatedAt
+		}
+		if now.Sub(last) < interval {
+			continue
+		}
+		msg := fmt.Sprintf("Reminder: %s requested %s (%s), expires %s", req.RequesterID, req.Amount, req.Note, req.ExpiresAt.Format(time.RFC1123))
+		if err := b.notify(req.PayerID, NotificationPaymentRequestReminder, msg, NotifyNormal); err == nil {
+			req.LastReminder = now
+			sent++
+		}
+	}
+	return sent
+}
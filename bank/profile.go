@@ -0,0 +1,96 @@
+package bank
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// maxNicknameLength is the longest account nickname, in characters.
+const maxNicknameLength = 40
+
+// languageTag matches the language tags preferences accept, such as "en" or "pt-BR".
+var languageTag = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// AccountPreferences is how an account's owner wants it presented.
+type AccountPreferences struct {
+	PaperStatements bool   // Statements are posted as well as made available online
+	Language        string // Language tag of statements and notices, e.g. "en"; "" means the bank's default
+}
+
+// AccountProfile is what the bank keeps about an account besides its identity, balance and lifecycle state: what
+// its owner calls it, its daily limits, its owner's preferences and its metadata. Profiles live with their accounts
+// in the bank and change under the bank's mutex, so accounts themselves only ever move money.
+type AccountProfile struct {
+	Nickname    string
+	Limits      DailyLimits // Daily limits in effect; raised ones still cooling off are in PendingDailyLimits
+	Preferences AccountPreferences
+	Metadata    AccountMetadata
+}
+
+// profileOf returns an account's profile, or the zero profile if the bank does not hold the account.
+// The caller must hold the bank mutex.
+func (b *Bank) profileOf(accountID string) AccountProfile {
+	if p := b.accounts.profile(accountID); p != nil {
+		return *p
+	}
+	return AccountProfile{}
+}
+
+// AccountProfileOf returns an account's profile, with any daily limits that finished cooling off in effect.
+func (b *Bank) AccountProfileOf(accountID string) (AccountProfile, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.accounts.get(accountID); !exists {
+		return AccountProfile{}, errors.New("account does not exist")
+	}
+	b.applyPendingLimits(accountID)
+	p := b.profileOf(accountID)
+	p.Metadata.Tags = append([]string(nil), p.Metadata.Tags...)
+	return p, nil
+}
+
+// SetAccountNickname names an account the way its owner wants to see it; an empty nickname removes it. Customers
+// may name their own accounts, and admins and managers any.
+func (b *Bank) SetAccountNickname(userID, accountID, nickname string) error {
+	nickname = strings.TrimSpace(nickname)
+	if len([]rune(nickname)) > maxNicknameLength {
+		return errors.New("nickname is too long")
+	}
+	if strings.ContainsFunc(nickname, unicode.IsControl) {
+		return errors.New("nickname must not contain control characters")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	p := b.accounts.profile(accountID)
+	if p == nil {
+		return errors.New("account does not exist")
+	}
+	if !b.ownsAccount(accountID, userID) && !b.isManager(userID) {
+		return decline(ReasonNotAuthorized, "customers may only name their own accounts")
+	}
+	p.Nickname = nickname
+	b.auditAction(userID, "SetAccountNickname", accountID, "", nickname)
+	return nil
+}
+
+// SetAccountPreferences replaces an account's preferences. Customers may change their own accounts', and admins and
+// managers any.
+func (b *Bank) SetAccountPreferences(userID, accountID string, prefs AccountPreferences) error {
+	if prefs.Language != "" && !languageTag.MatchString(prefs.Language) {
+		return errors.New("language " + prefs.Language + " is not a language tag")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	p := b.accounts.profile(accountID)
+	if p == nil {
+		return errors.New("account does not exist")
+	}
+	if !b.ownsAccount(accountID, userID) && !b.isManager(userID) {
+		return decline(ReasonNotAuthorized, "customers may only change the preferences of their own accounts")
+	}
+	p.Preferences = prefs
+	b.auditAction(userID, "SetAccountPreferences", accountID, "", "")
+	return nil
+}
@@ -0,0 +1,80 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// SetPromotionalRate attaches a promotional annual rate, as a fraction, to a savings account from the start day until
+// the end day, replacing any it had. The accrual engine accrues at it instead of the account's own rate over those
+// days, including days before today it has yet to catch up on, and at the account's own rate again afterwards. Only
+// admins and managers may set one.
+func (b *Bank) SetPromotionalRate(staffID, accountID string, rate float64, start, end time.Time) error {
+	start, end = startOfDay(start), startOfDay(end)
+	if rate < 0 {
+		return errors.New("promotional rate must not be negative")
+	}
+	if !end.After(start) {
+		return errors.New("promotional rate must end after it starts")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.isManager(staffID) {
+		return decline(ReasonNotAuthorized, "only admins and managers may set promotional rates")
+	}
+	acc, exists := b.accounts.get(accountID)
+	if !exists {
+		return errors.New("account does not exist")
+	}
+	if _, isSavings := acc.(*account.Savings); !isSavings {
+		return errors.New("only savings accounts have promotional rates")
+	}
+	if !b.IsAccountActive(accountID) {
+		return errors.New("account is inactive")
+	}
+	b.promotions[accountID] = account.RateWindow{Rate: rate, Start: start, End: end}
+	b.auditAction(staffID, "SetPromotionalRate", accountID, "", fmt.Sprintf("%g%% from %s until %s", rate*100, start.Format("2006-01-02"), end.Format("2006-01-02")))
+	return nil
+}
+
+// RemovePromotionalRate ends a savings account's promotional rate, so it accrues at its own rate from the next accrual
+// run on. Only admins and managers may remove one.
+func (b *Bank) RemovePromotionalRate(staffID, accountID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.isManager(staffID) {
+		return decline(ReasonNotAuthorized, "only admins and managers may remove promotional rates")
+	}
+	if _, exists := b.promotions[accountID]; !exists {
+		return errors.New("account has no promotional rate")
+	}
+	delete(b.promotions, accountID)
+	b.auditAction(staffID, "RemovePromotionalRate", accountID, "", "")
+	return nil
+}
+
+// PromotionalRateOf returns a savings account's promotional rate, and whether it has one. The window may have passed.
+func (b *Bank) PromotionalRateOf(accountID string) (account.RateWindow, bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	w, exists := b.promotions[accountID]
+	return w, exists
+}
+
+// appliedRatesField describes the rates a posting's interest accrued at for its history entry, e.g.
+// "4.500% from 2025-01-01 to 2025-01-15; 6.000% promotional from 2025-01-15 to 2025-02-01".
+func appliedRatesField(rates []account.AppliedRate) string {
+	parts := make([]string, 0, len(rates))
+	for _, r := range rates {
+		kind := ""
+		if r.Promotional {
+			kind = " promotional"
+		}
+		parts = append(parts, fmt.Sprintf("%.3f%%%s from %s to %s", r.Rate*100, kind, r.From.Format("2006-01-02"), r.To.Format("2006-01-02")))
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,224 @@
+package bank
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// AccountArchive is what the bank knew about an account when it was purged.
+type AccountArchive struct {
+	Record       account.Record    `json:"record"`
+	ClosedAt     time.Time         `json:"closedAt"`
+	PurgedAt     time.Time         `json:"purgedAt"`
+	Transactions map[string]string `json:"transactions"` // Map of transaction ID to history entry
+	Events       []Event           `json:"events"`
+}
+
+// ArchiveStorage is implemented by storage backends that can take purged accounts' history out of the live state.
+type ArchiveStorage interface {
+	ArchiveAccount(a AccountArchive) error
+	LoadArchive(accountID string) (AccountArchive, error) // A zero archive if the account was never purged
+	DropTransactions(txnIDs []string) error               // Removes history entries from the journal
+}
+
+// ReopenAccount reopens an account that was closed by mistake. Unlike Reopen it leaves dormant accounts alone, and
+// it cannot bring back an account that has been purged.
+func (b *Bank) ReopenAccount(accountID string) error {
+	return b.reopen(accountID, account.StateClosed)
+}
+
+// ArchivedAccount returns the archive of a purged account, or a zero archive if it was never purged.
+func (b *Bank) ArchivedAccount(accountID string) (AccountArchive, error) {
+	archiver, ok := b.storage.(ArchiveStorage)
+	if !ok {
+		return AccountArchive{}, errors.New("storage does not keep archives")
+	}
+	return archiver.LoadArchive(accountID)
+}
+
+// PurgeClosed permanently removes accounts that have been closed for longer than olderThan, archiving each
+// account's record, history and events to the storage backend first. History entries that involve no account left
+// in the bank leave the journal; the event log keeps everything, with an AccountPurged event so replays leave the
+// account out. Accounts other features still point at, e.g. as the target of a schedule, auto-save rule, virtual
+// account or cash pool, are kept. It returns the IDs of the purged accounts.
+func (b *Bank) PurgeClosed(olderThan time.Duration) ([]string, error) {
+	if olderThan < 0 {
+		return nil, errors.New("age must not be negative")
+	}
+	archiver, ok := b.storage.(ArchiveStorage)
+	if !ok {
+		return nil, errors.New("storage cannot archive accounts, so none can be purged")
+	}
+	b.mutex.RLock()
+	closedAt := b.closingTimes()
+	cutoff := b.now().Add(-olderThan)
+	var ids []string
+	for id, at := range closedAt {
+		if b.accounts.state(id) == account.StateClosed && !at.After(cutoff) && !b.accountReferenced(id) {
+			ids = append(ids, id)
+		}
+	}
+	b.mutex.RUnlock()
+	sort.Strings(ids)
+	unlock := b.lockAccounts(ids...)
+	defer unlock()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	purged := make(map[string]bool, len(ids))
+	var list []string
+	var err error
+	for _, id := range ids {
+		// Reopened while the locks were being taken
+		if b.accounts.state(id) != account.StateClosed {
+			continue
+		}
+		if err = b.purgeAccount(archiver, id, closedAt[id]); err != nil {
+			break
+		}
+		purged[id] = true
+		list = append(list, id)
+	}
+	if len(list) == 0 {
+		return nil, err
+	}
+
+	var dropped []string
+	for txnID, entry := range b.transactionHist {
+		if b.historyOfPurged(entry, purged) {
+			dropped = append(dropped, txnID)
+		}
+	}
+	sort.Strings(dropped)
+	if dropErr := archiver.DropTransactions(dropped); dropErr != nil {
+		return list, dropErr
+	}
+	for _, txnID := range dropped {
+		delete(b.transactionHist, txnID)
+		delete(b.descriptions, txnID)
+	}
+	records, recordsErr := b.accountRecords()
+	if recordsErr != nil {
+		return list, recordsErr
+	}
+	if saveErr := b.storage.SaveAccounts(records); saveErr != nil {
+		return list, saveErr
+	}
+	return list, err
+}
+
+// closingTimes returns when each account that is closed now was closed, from the event log.
+// The caller must hold the bank mutex.
+func (b *Bank) closingTimes() map[string]time.Time {
+	closedAt := make(map[string]time.Time)
+	for _, e := range b.events {
+		switch e.Type {
+		case EventAccountClosed:
+			closedAt[e.AccountID] = e.At
+		case EventStateChanged:
+			delete(closedAt, e.AccountID)
+		}
+	}
+	return closedAt
+}
+
+// accountReferenced reports whether another feature still points at an account, so it must not be purged.
+// The caller must hold the bank mutex.
+func (b *Bank) accountReferenced(accountID string) bool {
+	for _, s := range b.schedules {
+		if s.Status == SchedulePending && (s.FromID == accountID || s.ToID == accountID) {
+			return true
+		}
+	}
+	for _, r := range b.autoSaveRules {
+		if r.SourceID == accountID || r.PotID == accountID {
+			return true
+		}
+	}
+	for _, va := range b.virtualAccounts {
+		if va.Active && va.PhysicalID == accountID {
+			return true
+		}
+	}
+	for _, s := range b.zbaStructures {
+		if _, target := s.Targets[accountID]; target || s.MasterID == accountID {
+			return true
+		}
+	}
+	for _, payoutID := range b.interestPayouts {
+		if payoutID == accountID {
+			return true
+		}
+	}
+	for _, savingsID := range b.overdraftLinks {
+		if savingsID == accountID {
+			return true
+		}
+	}
+	return b.taxAccountID() == accountID
+}
+
+// purgeAccount archives one closed account and removes it from the bank.
+// The caller must hold the account's lock and the bank mutex.
+func (b *Bank) purgeAccount(archiver ArchiveStorage, accountID string, closedAt time.Time) error {
+	rec, err := b.accountRecord(accountID, b.accounts.at(accountID))
+	if err != nil {
+		return err
+	}
+	archive := AccountArchive{Record: rec, ClosedAt: closedAt, PurgedAt: b.now(), Transactions: make(map[string]string)}
+	for txnID, entry := range b.transactionHist {
+		if historyMentions(entry, accountID) {
+			archive.Transactions[txnID] = entry
+		}
+	}
+	for _, e := range b.events {
+		if e.AccountID == accountID || e.ToID == accountID {
+			archive.Events = append(archive.Events, e)
+		}
+	}
+	if err := archiver.ArchiveAccount(archive); err != nil {
+		return err
+	}
+
+	b.totals.untrack(accountID)
+	b.accounts.remove(accountID)
+	for _, m := range b.accountMaps() {
+		for key, id := range m.refs {
+			if id == accountID {
+				m.remove(key)
+			}
+		}
+	}
+	delete(b.auditBalances, accountID)
+	b.recordEvent(Event{Type: EventAccountPurged, AccountID: accountID})
+	return nil
+}
+
+// historyMentions reports whether a history entry names an account.
+func historyMentions(entry, accountID string) bool {
+	for _, field := range historyFields(entry) {
+		if (field[0] == "From" || field[0] == "To" || field[0] == "Account") && field[1] == accountID {
+			return true
+		}
+	}
+	return false
+}
+
+// historyOfPurged reports whether a history entry names a purged account and no account still in the bank.
+// The caller must hold the bank mutex.
+func (b *Bank) historyOfPurged(entry string, purged map[string]bool) bool {
+	mentionsPurged := false
+	for _, field := range historyFields(entry) {
+		if field[0] != "From" && field[0] != "To" && field[0] != "Account" {
+			continue
+		}
+		if _, exists := b.accounts.get(field[1]); exists {
+			return false
+		}
+		mentionsPurged = mentionsPurged || purged[field[1]]
+	}
+	return mentionsPurged
+}
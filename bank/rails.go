@@ -0,0 +1,267 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// Rail is how the money of a deposit or withdrawal came into or left the bank.
+type Rail string
+
+const (
+	RailCash     Rail = "cash" // Over the counter
+	RailATM      Rail = "atm"
+	RailWire     Rail = "wire"
+	RailACH      Rail = "ach"
+	RailInternal Rail = "internal" // Booked by the bank itself, e.g. loan disbursements and captured holds
+)
+
+// rails are the rails the bank knows.
+var rails = map[Rail]bool{RailCash: true, RailATM: true, RailWire: true, RailACH: true, RailInternal: true}
+
+// ParseRail checks a rail name, accepting any case.
+func ParseRail(name string) (Rail, error) {
+	rail := Rail(strings.ToLower(strings.TrimSpace(name)))
+	if !rails[rail] {
+		return "", errors.New("unknown channel " + name + "; use cash, atm, wire, ach or internal")
+	}
+	return rail, nil
+}
+
+// defaultRail returns the rail of an operation that does not say: internal for transfers, cash otherwise.
+func defaultRail(op transaction.OperationType) Rail {
+	if op == transaction.OpTransfer {
+		return RailInternal
+	}
+	return RailCash
+}
+
+// railLabel describes the rail of a deposit or withdrawal for statements, or returns "" for one recorded before
+// rails were.
+func railLabel(rail Rail) string {
+	if rail == "" {
+		return ""
+	}
+	return " (" + string(rail) + ")"
+}
+
+// RailRules are the extra checks on deposits and withdrawals over one rail.
+type RailRules struct {
+	Limit      account.Money `json:"limit"`      // Largest single deposit or withdrawal, in minor units; 0 means none
+	DailyLimit account.Money `json:"dailyLimit"` // Most an account may withdraw over the rail in any 24 hours; 0 means none
+	Approval   bool          `json:"approval"`   // Withdrawals need a manager's approval; see RequestWithdrawal
+}
+
+// railRules returns the configured rules for a rail.
+// The caller must hold the bank mutex.
+func (b *Bank) railRules(rail Rail) RailRules {
+	if b.config == nil {
+		return RailRules{}
+	}
+	return b.config.rails[rail]
+}
+
+// checkRail rejects a deposit or withdrawal over a rail larger than the rail's single-operation limit or, for
+// withdrawals, one that would take the account past the rail's daily limit.
+// The caller must hold the account's lock and the bank mutex.
+func (b *Bank) checkRail(rail Rail, op transaction.OperationType, accountID string, amount account.Money) error {
+	rules := b.railRules(rail)
+	if rules.Limit > 0 && amount > rules.Limit {
+		return decline(ReasonLimitExceeded, fmt.Sprintf("%s %s of %s exceeds the limit of %s", rail, op, amount, rules.Limit))
+	}
+	if op != transaction.OpWithdrawal {
+		return nil
+	}
+	if rules.DailyLimit > 0 {
+		used := b.railUsage(accountID, rail)
+		if used+amount > rules.DailyLimit {
+			return decline(ReasonDailyLimitExceeded, fmt.Sprintf("%s withdrawal of %s would exceed the daily %s limit of %s on account %s; %s remaining",
+				rail, amount, rail, rules.DailyLimit, accountID, max(rules.DailyLimit-used, 0)))
+		}
+	}
+	return nil
+}
+
+// railUsage totals an account's withdrawals over a rail in the last 24 hours from the event log.
+// The caller must hold the bank mutex.
+func (b *Bank) railUsage(accountID string, rail Rail) account.Money {
+	since := b.now().Add(-dailyLimitWindow)
+	var used account.Money
+	for i := len(b.events) - 1; i >= 0 && b.events[i].At.After(since); i-- {
+		if e := b.events[i]; e.Type == EventWithdrew && e.AccountID == accountID && e.Rail == rail {
+			used += e.Amount
+		}
+	}
+	return used
+}
+
+// NeedsApproval reports whether withdrawals over a rail must be approved by a manager before they are made.
+func (b *Bank) NeedsApproval(rail Rail) bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.railRules(rail).Approval
+}
+
+// WithdrawalStatus tracks a withdrawal waiting for approval.
+type WithdrawalStatus string
+
+const (
+	WithdrawalPending  WithdrawalStatus = "pending"
+	WithdrawalApproved WithdrawalStatus = "approved" // Approved by a manager and made
+	WithdrawalRejected WithdrawalStatus = "rejected"
+)
+
+// WithdrawalApproval is a withdrawal over a rail that needs a manager's approval before it is made. Approvals are
+// derived from the transaction history, so they survive restarts.
+type WithdrawalApproval struct {
+	ID          string // The request's transaction
+	AccountID   string
+	Amount      account.Money
+	Rail        Rail
+	Status      WithdrawalStatus
+	RequestedAt time.Time
+	DecidedBy   string
+}
+
+// withdrawalApproval picks a withdrawal approval out of a transaction history entry, reporting false if the entry is
+// not a withdrawal request.
+func withdrawalApproval(txnID, entry string) (WithdrawalApproval, bool) {
+	fields := make(map[string]string)
+	for _, field := range historyFields(entry) {
+		fields[field[0]] = field[1]
+	}
+	// Requests read "Approval: pending", and decisions add "Approval: approved by ID"
+	decision, requested := fields["Approval"]
+	if !requested {
+		return WithdrawalApproval{}, false
+	}
+	h := parseHistoryEntry(txnID, entry)
+	w := WithdrawalApproval{ID: txnID, AccountID: h.Account, Amount: h.Amount, Rail: Rail(fields["Rail"]), RequestedAt: h.Recorded}
+	status, by, _ := strings.Cut(decision, " by ")
+	w.Status, w.DecidedBy = WithdrawalStatus(status), by
+	return w, true
+}
+
+// RequestWithdrawal asks for a withdrawal over a rail whose withdrawals need a manager's approval. The account's
+// funds, limits and rules are checked when the withdrawal is approved and made.
+func (b *Bank) RequestWithdrawal(accountID string, amount account.Money, rail Rail) (WithdrawalApproval, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.railRules(rail).Approval {
+		return WithdrawalApproval{}, errors.New(string(rail) + " withdrawals need no approval")
+	}
+	if err := b.checkOperation(accountID, account.OperationWithdraw); err != nil {
+		return WithdrawalApproval{}, err
+	}
+	if err := b.checkAmount(transaction.OpWithdrawal, accountID, amount); err != nil {
+		return WithdrawalApproval{}, err
+	}
+	txnID := b.newTxnID()
+	b.recordTransaction(txnID, fmt.Sprintf("Transaction ID: %s, Account: %s, Amount: %s, Rail: %s, Approval: %s\n", txnID, accountID, amount, rail, WithdrawalPending))
+	w, _ := withdrawalApproval(txnID, b.transactionHist[txnID])
+	return w, nil
+}
+
+// WithdrawalApprovals lists the withdrawal requests with the given status, or all of them for "", oldest first.
+func (b *Bank) WithdrawalApprovals(status WithdrawalStatus) []WithdrawalApproval {
+	b.mutex.RLock()
+	var list []WithdrawalApproval
+	for id, entry := range b.transactionHist {
+		if w, requested := withdrawalApproval(id, entry); requested && (status == "" || w.Status == status) {
+			list = append(list, w)
+		}
+	}
+	b.mutex.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].RequestedAt.Equal(list[j].RequestedAt) {
+			return list[i].RequestedAt.Before(list[j].RequestedAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// DecideWithdrawal approves a withdrawal request, making the withdrawal, or rejects it. Only admins and managers may
+// decide. If an approved withdrawal fails, e.g. for lack of funds, the request stays pending.
+func (b *Bank) DecideWithdrawal(staffID, requestID string, approve bool) error {
+	b.mutex.Lock()
+	if !b.isManager(staffID) {
+		b.mutex.Unlock()
+		return decline(ReasonNotAuthorized, "only admins and managers may decide withdrawals")
+	}
+	entry := b.transactionHist[requestID]
+	w, requested := withdrawalApproval(requestID, entry)
+	if !requested || w.Status != WithdrawalPending {
+		b.mutex.Unlock()
+		return errors.New("withdrawal is not awaiting approval")
+	}
+	if !approve {
+		b.annotateTransaction(requestID, fmt.Sprintf("Approval: %s by %s", WithdrawalRejected, staffID))
+		b.auditAction(staffID, "RejectWithdrawal", w.AccountID, requestID, string(w.Rail))
+		b.mutex.Unlock()
+		return nil
+	}
+	// Decided before the withdrawal is made so a second manager cannot make it again
+	b.annotateTransaction(requestID, fmt.Sprintf("Approval: %s by %s", WithdrawalApproved, staffID))
+	b.mutex.Unlock()
+
+	_, err := b.withdraw(w.AccountID, w.Amount, nil, false, w.Rail, transaction.Memo{})
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err != nil {
+		b.recordTransaction(requestID, entry)
+		return err
+	}
+	b.auditAction(staffID, "ApproveWithdrawal", w.AccountID, requestID, string(w.Rail))
+	return nil
+}
+
+// RailTotals counts the deposits and withdrawals made over one rail.
+type RailTotals struct {
+	Rail        Rail // "" for those recorded before rails were
+	Deposits    int
+	Deposited   account.Money
+	Withdrawals int
+	Withdrawn   account.Money
+}
+
+// RailReport totals the deposits and withdrawals recorded from since until before until, grouped by rail and ordered
+// by rail. A zero until means up to now.
+func (b *Bank) RailReport(since, until time.Time) []RailTotals {
+	b.mutex.RLock()
+	byRail := make(map[Rail]*RailTotals)
+	for _, e := range b.events {
+		if e.At.Before(since) || (!until.IsZero() && !e.At.Before(until)) {
+			continue
+		}
+		if e.Type != EventDeposited && e.Type != EventWithdrew {
+			continue
+		}
+		totals, exists := byRail[e.Rail]
+		if !exists {
+			totals = &RailTotals{Rail: e.Rail}
+			byRail[e.Rail] = totals
+		}
+		if e.Type == EventDeposited {
+			totals.Deposits++
+			totals.Deposited += e.Amount
+		} else {
+			totals.Withdrawals++
+			totals.Withdrawn += e.Amount
+		}
+	}
+	b.mutex.RUnlock()
+	report := make([]RailTotals, 0, len(byRail))
+	for _, totals := range byRail {
+		report = append(report, *totals)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Rail < report[j].Rail })
+	return report
+}
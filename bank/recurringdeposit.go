@@ -0,0 +1,90 @@
+package bank
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// NewRecurringDepositAccount opens a recurring deposit whose monthly contributions are pulled from fundingID. It
+// belongs to the funding account's owner. The first contribution is collected immediately.
+func (b *Bank) NewRecurringDepositAccount(id, fundingID string, monthlyContribution account.Money, interestRate float64, termMonths int) (*account.RecurringDeposit, error) {
+	if monthlyContribution <= 0 {
+		return nil, errors.New("monthly contribution must be positive")
+	}
+	if termMonths <= 0 {
+		return nil, errors.New("term must be at least one month")
+	}
+	b.mutex.Lock()
+	if err := b.checkNewAccountID(id); err != nil {
+		b.mutex.Unlock()
+		return nil, err
+	}
+	if _, exists := b.accounts.get(fundingID); !exists || !b.IsAccountActive(fundingID) {
+		b.mutex.Unlock()
+		return nil, errors.New("funding account does not exist")
+	}
+	newAcc := account.NewRecurringDeposit(id, fundingID, monthlyContribution, interestRate, termMonths, b.now())
+	if owner := b.accountOwner[fundingID]; owner != "" {
+		b.accountOwner[id] = owner
+	}
+	b.registerAccount(newAcc, account.StateOpen)
+	b.recordAccountEvent(EventAccountCreated, newAcc, Event{})
+	b.mutex.Unlock()
+
+	b.ProcessRecurringDeposits()
+	return newAcc, nil
+}
+
+// ProcessRecurringDeposits collects every monthly contribution that has fallen due, applying a month
+// of interest before each one. Contributions move between the customer's own accounts, so they are not charged
+// fees or held to limits and customer transfer rules; those that cannot be pulled from the funding account are
+// counted as missed and penalised. Deposits whose term has ended are matured.
+func (b *Bank) ProcessRecurringDeposits() {
+	b.mutex.Lock()
+	now := b.now()
+	var due []*account.RecurringDeposit
+	for id, acc := range b.accounts.all() {
+		if rd, ok := acc.(*account.RecurringDeposit); ok && b.IsAccountActive(id) {
+			due = append(due, rd)
+		}
+	}
+	b.mutex.Unlock()
+
+	for _, rd := range due {
+		for {
+			amount, fundingID, ok := b.dueContribution(rd, now)
+			if !ok {
+				break
+			}
+			_, err := b.moveFunds(fundingID, rd.ID(), amount)
+			b.recordContribution(rd, err == nil)
+		}
+	}
+}
+
+// dueContribution returns the next contribution due on a recurring deposit, logging any interest or maturity
+// applied on the way.
+func (b *Bank) dueContribution(rd *account.RecurringDeposit, now time.Time) (account.Money, string, bool) {
+	unlock := b.lockAccounts(rd.ID())
+	defer unlock()
+	before, _ := account.ToRecord(rd)
+	amount, fundingID, ok := rd.DueContribution(now)
+	if after, _ := account.ToRecord(rd); after != before {
+		b.mutex.Lock()
+		b.recordAccountEvent(EventAccountUpdated, rd, Event{})
+		b.mutex.Unlock()
+	}
+	return amount, fundingID, ok
+}
+
+// recordContribution counts a contribution as collected or missed and logs the result.
+func (b *Bank) recordContribution(rd *account.RecurringDeposit, collected bool) {
+	unlock := b.lockAccounts(rd.ID())
+	defer unlock()
+	rd.RecordContribution(collected)
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.recordAccountEvent(EventAccountUpdated, rd, Event{})
+}
@@ -0,0 +1,136 @@
+package bank
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// RelationshipTier is a level of preferential pricing customers reach by keeping enough money with the bank.
+type RelationshipTier struct {
+	Name       string        `json:"name"`
+	MinBalance account.Money `json:"minBalance"` // Relationship balance, in minor units, from which customers qualify
+	// Percentage points added to the interest rate of the customers' savings accounts, on top of any segment bonus
+	RateBonus  float64  `json:"rateBonus"`
+	WaivedFees []string `json:"waivedFees"` // Names of fee rules the customers do not pay
+}
+
+// TierStatus is the relationship tier a customer was placed in at their last review.
+type TierStatus struct {
+	Tier       string // Empty if the customer qualified for none
+	ReviewedAt time.Time
+}
+
+// TierChange is a customer moving between relationship tiers at a review.
+type TierChange struct {
+	CustomerID string
+	From, To   string        // Tier names; empty for no tier
+	Balance    account.Money // Relationship balance the customer was reviewed on
+}
+
+// String describes the change for end-of-day output.
+func (c TierChange) String() string {
+	from, to := c.From, c.To
+	if from == "" {
+		from = "no tier"
+	}
+	if to == "" {
+		to = "no tier"
+	}
+	return fmt.Sprintf("%s moved from %s to %s on a relationship balance of %s", c.CustomerID, from, to, c.Balance)
+}
+
+// relationshipBalances totals each customer's relationship balance: the balances of the open, frozen and dormant
+// accounts they own, alone or jointly, in the default currency. Loans do not count.
+// The caller must hold the bank mutex.
+func (b *Bank) relationshipBalances() map[string]account.Money {
+	balances := make(map[string]account.Money)
+	for id, acc := range b.accounts.all() {
+		if b.accounts.state(id) == account.StateClosed || b.currencyOf(id) != DefaultCurrency {
+			continue
+		}
+		if _, isLoan := acc.(*account.Loan); isLoan {
+			continue
+		}
+		owners := b.ownersOf(id)
+		if len(owners) == 0 {
+			continue
+		}
+		balance := acc.Balance()
+		for _, owner := range owners {
+			balances[owner] += balance
+		}
+	}
+	return balances
+}
+
+// qualifyingTier returns the highest configured tier a relationship balance reaches, or "" for none.
+// The caller must hold the bank mutex.
+func (b *Bank) qualifyingTier(balance account.Money) string {
+	if b.config == nil {
+		return ""
+	}
+	for _, tier := range b.config.tiers {
+		if balance >= tier.MinBalance {
+			return tier.Name
+		}
+	}
+	return ""
+}
+
+// RelationshipOf returns a customer's relationship balance now and the tier their last review placed them in.
+func (b *Bank) RelationshipOf(customerID string) (account.Money, TierStatus) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.relationshipBalances()[customerID], b.relationships[customerID]
+}
+
+// ReviewRelationshipTiers places every customer not yet reviewed this month in the tier their relationship balance
+// reaches, so tiers change at most once a month. Customers whose tier changes are notified. It returns the changes,
+// ordered by customer.
+func (b *Bank) ReviewRelationshipTiers() []TierChange {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	now := b.now()
+	balances := b.relationshipBalances()
+	var changes []TierChange
+	for customerID, balance := range balances {
+		status, reviewed := b.relationships[customerID]
+		if reviewed && now.Before(nextCycle(status.ReviewedAt)) {
+			continue
+		}
+		tier := b.qualifyingTier(balance)
+		b.relationships[customerID] = TierStatus{Tier: tier, ReviewedAt: now}
+		if tier == status.Tier {
+			continue
+		}
+		changes = append(changes, TierChange{CustomerID: customerID, From: status.Tier, To: tier, Balance: balance})
+		message := fmt.Sprintf("Your balances with us total %s, so you no longer qualify for the %s relationship tier.", balance, status.Tier)
+		if tier != "" {
+			message = fmt.Sprintf("Your balances with us total %s, which qualifies you for the %s relationship tier and its rates and fee waivers.", balance, tier)
+		}
+		_ = b.notify(customerID, NotificationTierChanged, message, NotifyNormal)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].CustomerID < changes[j].CustomerID })
+	return changes
+}
+
+// relationshipTier returns the configured tier of an account's owner, and whether they are in one.
+// The caller must hold the bank mutex.
+func (b *Bank) relationshipTier(accountID string) (RelationshipTier, bool) {
+	if b.config == nil {
+		return RelationshipTier{}, false
+	}
+	name := b.relationships[b.accountOwner[accountID]].Tier
+	if name == "" {
+		return RelationshipTier{}, false
+	}
+	i := slices.IndexFunc(b.config.tiers, func(t RelationshipTier) bool { return t.Name == name })
+	if i < 0 {
+		return RelationshipTier{}, false
+	}
+	return b.config.tiers[i], true
+}
@@ -0,0 +1,71 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ReverseTransaction refunds a completed transfer by moving its funds back from its destination to its source in a
+// compensating transfer, and cross-links the two history entries, marking the original reversed. Only admins and
+// managers may reverse transactions. Fees charged on the original are not refunded, and a transfer can only be
+// reversed once. Transfers that have not settled, because they await compensation or a fraud review, are refused
+// until they are resolved. It returns the ID of the reversing transaction.
+func (b *Bank) ReverseTransaction(staffID, txnID, reason string) (string, error) {
+	if strings.TrimSpace(reason) == "" {
+		return "", errors.New("reason must not be empty")
+	}
+	b.mutex.Lock()
+	if !b.isManager(staffID) {
+		b.mutex.Unlock()
+		return "", decline(ReasonNotAuthorized, "only admins and managers may reverse transactions")
+	}
+	var original *Event
+	for i := len(b.events) - 1; i >= 0; i-- {
+		if e := b.events[i]; e.Type == EventTransferred && e.TransactionID == txnID {
+			original = &e
+			break
+		}
+	}
+	_, compensating := b.pendingCompensations()[txnID]
+	b.mutex.Unlock()
+	if compensating {
+		return "", decline(ReasonUnsettled, "transfer is awaiting compensation; resolve it instead")
+	}
+	if original == nil {
+		return "", errors.New("transaction is not a completed transfer")
+	}
+
+	// Holding both accounts keeps a concurrent reversal of the same transfer out until this one is recorded
+	unlock := b.lockAccounts(original.AccountID, original.ToID)
+	defer unlock()
+	b.mutex.Lock()
+	entry := b.transactionHist[txnID]
+	b.mutex.Unlock()
+	if strings.Contains(entry, "Reversed by: ") {
+		return "", decline(ReasonAlreadyReversed, "transaction has already been reversed")
+	}
+	if strings.Contains(entry, "Reversal of: ") {
+		return "", errors.New("a reversal cannot itself be reversed")
+	}
+	if review, flagged := fraudReview(txnID, entry); flagged && review.Status == "pending" {
+		return "", decline(ReasonUnsettled, "transfer is awaiting fraud review; review it first")
+	}
+
+	// Transfers between currencies are reversed by returning the amount credited, converted at today's rate
+	amount := original.Amount
+	if original.ToAmount != 0 {
+		amount = original.ToAmount
+	}
+	reversalID, err := b.executeTransfer(original.ToID, original.AccountID, amount)
+	if err != nil {
+		return "", err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.annotateTransaction(reversalID, fmt.Sprintf("Reversal of: %s, By: %s, Reason: %s", txnID, staffID, reason))
+	b.annotateTransaction(txnID, "Reversed by: "+reversalID)
+	b.auditAction(staffID, "ReverseTransaction", original.AccountID, txnID, reason)
+	return reversalID, nil
+}
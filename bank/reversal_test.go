@@ -0,0 +1,118 @@
+package bank
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+func TestReverseTransaction(t *testing.T) {
+	tests := []struct {
+		name    string
+		staffID string
+		reason  string
+		// prepare runs after A pays B 400 in transfer txnID and returns the transaction to reverse
+		prepare    func(t *testing.T, b *Bank, txnID string) string
+		wantErr    bool
+		wantReason ReasonCode
+		wantA      account.Money
+		wantB      account.Money
+	}{
+		{
+			name:  "a manager reverses a transfer",
+			wantA: 1000, wantB: 0,
+		},
+		{
+			name:    "customers may not reverse",
+			staffID: "alice",
+			wantErr: true, wantReason: ReasonNotAuthorized,
+			wantA: 600, wantB: 400,
+		},
+		{
+			name:    "a reason is required",
+			reason:  " ",
+			wantErr: true,
+			wantA:   600, wantB: 400,
+		},
+		{
+			name:    "unknown transactions cannot be reversed",
+			prepare: func(*testing.T, *Bank, string) string { return "txn-9999" },
+			wantErr: true,
+			wantA:   600, wantB: 400,
+		},
+		{
+			name: "a transfer is reversed only once",
+			prepare: func(t *testing.T, b *Bank, txnID string) string {
+				if _, err := b.ReverseTransaction("manager", txnID, "duplicate"); err != nil {
+					t.Fatal(err)
+				}
+				return txnID
+			},
+			wantErr: true, wantReason: ReasonAlreadyReversed,
+			wantA: 1000, wantB: 0,
+		},
+		{
+			name: "a reversal cannot itself be reversed",
+			prepare: func(t *testing.T, b *Bank, txnID string) string {
+				reversalID, err := b.ReverseTransaction("manager", txnID, "duplicate")
+				if err != nil {
+					t.Fatal(err)
+				}
+				return reversalID
+			},
+			wantErr: true,
+			wantA:   1000, wantB: 0,
+		},
+		{
+			name: "the destination must still hold the funds",
+			prepare: func(t *testing.T, b *Bank, txnID string) string {
+				if err := b.Withdraw("B", 300); err != nil {
+					t.Fatal(err)
+				}
+				return txnID
+			},
+			wantErr: true, wantReason: ReasonInsufficientFunds,
+			wantA: 600, wantB: 100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBank(t, 1000, 0)
+			txnID, err := b.transfer("A", "B", 400)
+			if err != nil {
+				t.Fatal(err)
+			}
+			target := txnID
+			if tt.prepare != nil {
+				target = tt.prepare(t, b, txnID)
+			}
+			staffID, reason := "manager", "sent in error"
+			if tt.staffID != "" {
+				staffID = tt.staffID
+			}
+			if tt.reason != "" {
+				reason = tt.reason
+			}
+			reversalID, err := b.ReverseTransaction(staffID, target, reason)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReverseTransaction error = %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantReason != "" && ReasonOf(err) != tt.wantReason {
+				t.Errorf("ReverseTransaction declined with %q (%v), want %q", ReasonOf(err), err, tt.wantReason)
+			}
+			if gotA, gotB := balanceOf(t, b, "A"), balanceOf(t, b, "B"); gotA != tt.wantA || gotB != tt.wantB {
+				t.Errorf("balances A %s, B %s; want %s, %s", gotA, gotB, tt.wantA, tt.wantB)
+			}
+			if err != nil {
+				return
+			}
+			if entry := b.transactionHist[txnID]; !strings.Contains(entry, "Reversed by: "+reversalID) {
+				t.Errorf("original entry %q does not name reversal %s", entry, reversalID)
+			}
+			if entry := b.transactionHist[reversalID]; !strings.Contains(entry, "Reversal of: "+txnID) {
+				t.Errorf("reversal entry %q does not name original %s", entry, txnID)
+			}
+		})
+	}
+}
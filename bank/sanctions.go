@@ -0,0 +1,300 @@
+package bank
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DefaultScreeningThreshold is the similarity from which a name matches a sanctions list entry unless the config
+// sets screeningThreshold.
+const DefaultScreeningThreshold = 0.9
+
+// SanctionsList is a named list of sanctioned people and organisations, e.g. a government consolidated list.
+type SanctionsList struct {
+	Name  string   `json:"name"`
+	Names []string `json:"names"`
+}
+
+// sanctionsEntry is one listed name, normalised for matching.
+type sanctionsEntry struct {
+	list       string
+	name       string
+	normalized string
+}
+
+// ScreeningStatus is the outcome of screening a name.
+type ScreeningStatus string
+
+const (
+	ScreeningClear     ScreeningStatus = "clear"     // No list entry matched
+	ScreeningPending   ScreeningStatus = "pending"   // Matched; blocked until compliance reviews it
+	ScreeningCleared   ScreeningStatus = "cleared"   // Matched, but compliance found it a false positive
+	ScreeningConfirmed ScreeningStatus = "confirmed" // Matched, and compliance confirmed it; stays blocked
+)
+
+// blocks reports whether a screening with this status blocks its subject.
+func (s ScreeningStatus) blocks() bool {
+	return s == ScreeningPending || s == ScreeningConfirmed
+}
+
+// SanctionsMatch is a list entry a screened name resembles.
+type SanctionsMatch struct {
+	List  string
+	Entry string
+	Score float64 // Similarity of the normalised names, from 0 to 1
+}
+
+// Screening records the result of screening a customer's name or an external payee against the sanctions lists.
+type Screening struct {
+	ID         string
+	Subject    string // "customer" or "payee"
+	SubjectID  string // Customer ID, or the account paying an external payee
+	Name       string
+	Matches    []SanctionsMatch // Best first
+	Status     ScreeningStatus
+	At         time.Time
+	ReviewedBy string
+	ReviewedAt time.Time
+}
+
+// foldAccents replaces accented Latin letters with their plain forms, so "José" and "Jose" compare equal.
+var foldAccents = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a", "æ", "ae", "ç", "c",
+	"è", "e", "é", "e", "ê", "e", "ë", "e", "ì", "i", "í", "i", "î", "i", "ï", "i",
+	"ñ", "n", "ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o", "œ", "oe",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ý", "y", "ÿ", "y", "ß", "ss",
+)
+
+// nameNoise are titles and company forms that say nothing about who a name refers to.
+var nameNoise = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "the": true,
+	"ltd": true, "llc": true, "inc": true, "co": true, "corp": true, "plc": true, "sa": true, "gmbh": true,
+}
+
+// normalizeName lower-cases a name, folds accents, drops punctuation, titles and company forms, and sorts the words
+// so "DOE, John" and "John Doe" normalise the same.
+func normalizeName(name string) string {
+	folded := foldAccents.Replace(strings.ToLower(name))
+	words := strings.FieldsFunc(folded, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	kept := words[:0]
+	for _, w := range words {
+		if !nameNoise[w] {
+			kept = append(kept, w)
+		}
+	}
+	sort.Strings(kept)
+	return strings.Join(kept, " ")
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity of two strings, from 0 for nothing in common to 1 for equal.
+func jaroWinkler(a, b string) float64 {
+	s, t := []rune(a), []rune(b)
+	if len(s) == 0 || len(t) == 0 {
+		if len(s) == len(t) {
+			return 1
+		}
+		return 0
+	}
+	window := max(len(s), len(t))/2 - 1
+	window = max(window, 0)
+	sMatched, tMatched := make([]bool, len(s)), make([]bool, len(t))
+	matches := 0
+	for i := range s {
+		for j := max(0, i-window); j < min(len(t), i+window+1); j++ {
+			if !tMatched[j] && s[i] == t[j] {
+				sMatched[i], tMatched[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+	transpositions, j := 0, 0
+	for i := range s {
+		if !sMatched[i] {
+			continue
+		}
+		for !tMatched[j] {
+			j++
+		}
+		if s[i] != t[j] {
+			transpositions++
+		}
+		j++
+	}
+	m := float64(matches)
+	jaro := (m/float64(len(s)) + m/float64(len(t)) + (m-float64(transpositions)/2)/m) / 3
+	prefix := 0
+	for prefix < min(4, len(s), len(t)) && s[prefix] == t[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// screenName compares a name with every configured list entry, returning the entries at or above the threshold,
+// best first.
+// The caller must hold the bank mutex.
+func (b *Bank) screenName(name string) []SanctionsMatch {
+	if b.config == nil {
+		return nil
+	}
+	normalized := normalizeName(name)
+	var matches []SanctionsMatch
+	for _, e := range b.config.sanctions {
+		if score := jaroWinkler(normalized, e.normalized); score >= b.config.threshold {
+			matches = append(matches, SanctionsMatch{List: e.list, Entry: e.name, Score: score})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches
+}
+
+// recordScreening records the result of screening a name, pending review if anything matched.
+// The caller must hold the bank mutex.
+func (b *Bank) recordScreening(subject, subjectID, name string, matches []SanctionsMatch) *Screening {
+	s := &Screening{
+		ID:        b.newID("scr"),
+		Subject:   subject,
+		SubjectID: subjectID,
+		Name:      name,
+		Matches:   matches,
+		Status:    ScreeningClear,
+		At:        b.now(),
+	}
+	if len(matches) > 0 {
+		s.Status = ScreeningPending
+	}
+	b.screenings[s.ID] = s
+	return s
+}
+
+// SetCustomerName records a customer's name and screens it against the sanctions lists. If it matches, every
+// account the customer owns is blocked until compliance clears the screening with ReviewScreening.
+func (b *Bank) SetCustomerName(customerID, name string) (Screening, error) {
+	if customerID == "" || strings.TrimSpace(name) == "" {
+		return Screening{}, errors.New("customer ID and name must not be empty")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.customerNames[customerID] = name
+	s := b.recordScreening("customer", customerID, name, b.screenName(name))
+	b.customerScreens[customerID] = s.ID
+	return *s, nil
+}
+
+// RescreenCustomers screens every named customer again, e.g. after the sanctions lists were updated, and returns
+// the screenings that newly matched. Customers already blocked, or cleared for the same name, are skipped.
+func (b *Bank) RescreenCustomers() []Screening {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	var matched []Screening
+	for customerID, name := range b.customerNames {
+		if last, exists := b.screenings[b.customerScreens[customerID]]; exists && last.Status != ScreeningClear {
+			continue
+		}
+		matches := b.screenName(name)
+		if len(matches) == 0 {
+			continue
+		}
+		s := b.recordScreening("customer", customerID, name, matches)
+		b.customerScreens[customerID] = s.ID
+		matched = append(matched, *s)
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].SubjectID < matched[j].SubjectID })
+	return matched
+}
+
+// checkSanctionsHold rejects operations on accounts any of whose owners' screening blocks them.
+// The caller must hold the bank mutex.
+func (b *Bank) checkSanctionsHold(accountID string) error {
+	for _, owner := range b.ownersOf(accountID) {
+		if s, exists := b.screenings[b.customerScreens[owner]]; exists && s.Status.blocks() {
+			return decline(ReasonSanctions, "account "+accountID+" is blocked pending compliance review")
+		}
+	}
+	return nil
+}
+
+// checkTransferSanctions rejects transfers from or to accounts blocked by a sanctions screening.
+// The caller must hold the bank mutex.
+func (b *Bank) checkTransferSanctions(t PendingTransfer) error {
+	if err := b.checkSanctionsHold(t.FromID); err != nil {
+		return err
+	}
+	return b.checkSanctionsHold(t.ToID)
+}
+
+// screenPayee screens the external payee of a payment, recording a new screening unless the payee was already found
+// clear. A payee compliance has cleared is not screened again; one that matched stays blocked.
+// The caller must hold the bank mutex.
+func (b *Bank) screenPayee(fromID, payee string) error {
+	normalized := normalizeName(payee)
+	if normalized == "" {
+		return nil
+	}
+	last, screened := b.screenings[b.payeeScreens[normalized]]
+	if screened && last.Status == ScreeningCleared {
+		return nil
+	}
+	if !screened || last.Status == ScreeningClear {
+		matches := b.screenName(payee)
+		if len(matches) == 0 && screened {
+			return nil
+		}
+		last = b.recordScreening("payee", fromID, payee, matches)
+		b.payeeScreens[normalized] = last.ID
+	}
+	if last.Status.blocks() {
+		return decline(ReasonSanctions, "payee "+payee+" is blocked pending compliance review")
+	}
+	return nil
+}
+
+// Screenings lists recorded screening results with the given status, or all of them for "", oldest first.
+func (b *Bank) Screenings(status ScreeningStatus) []Screening {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	var list []Screening
+	for _, s := range b.screenings {
+		if status == "" || s.Status == status {
+			list = append(list, *s)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].At.Equal(list[j].At) {
+			return list[i].At.Before(list[j].At)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// ReviewScreening records compliance's decision on a pending screening: confirmed if match is true, which keeps the
+// customer or payee blocked, or cleared as a false positive, which lifts the block. Only admins and managers may
+// review screenings.
+func (b *Bank) ReviewScreening(staffID, screeningID string, match bool) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.isManager(staffID) {
+		return decline(ReasonNotAuthorized, "only admins and managers may review screenings")
+	}
+	s, exists := b.screenings[screeningID]
+	if !exists {
+		return errors.New("screening does not exist")
+	}
+	if s.Status != ScreeningPending {
+		return errors.New("screening is " + string(s.Status))
+	}
+	s.Status = ScreeningCleared
+	if match {
+		s.Status = ScreeningConfirmed
+	}
+	s.ReviewedBy, s.ReviewedAt = staffID, b.now()
+	b.auditAction(staffID, "ReviewScreening", "", "", s.ID+" "+string(s.Status))
+	return nil
+}
@@ -0,0 +1,351 @@
+package bank
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// ScheduleFrequency is how often a scheduled transfer repeats.
+type ScheduleFrequency string
+
+const (
+	ScheduleOnce    ScheduleFrequency = "once"
+	ScheduleDaily   ScheduleFrequency = "daily"
+	ScheduleWeekly  ScheduleFrequency = "weekly"
+	ScheduleMonthly ScheduleFrequency = "monthly"
+)
+
+// ScheduleStatus describes where a scheduled transfer is in its lifecycle.
+type ScheduleStatus string
+
+const (
+	SchedulePending   ScheduleStatus = "pending"
+	ScheduleCompleted ScheduleStatus = "completed"
+	ScheduleCancelled ScheduleStatus = "cancelled"
+)
+
+// ScheduledTransfer is a future-dated or recurring transfer instruction.
+type ScheduledTransfer struct {
+	ID          string            `json:"id"`
+	FromID      string            `json:"fromId"`
+	ToID        string            `json:"toId"`
+	Amount      account.Money     `json:"amountMinor"`
+	Frequency   ScheduleFrequency `json:"frequency"`
+	Start       time.Time         `json:"start"`              // first execution; later runs are counted from here so monthly dates do not drift
+	Until       time.Time         `json:"until,omitzero"`     // no runs after this time; zero means indefinitely
+	NextRun     time.Time         `json:"nextRun"`            // when the next occurrence is due
+	Occurrences int               `json:"occurrences"`        // occurrences processed so far, successful or not
+	Status      ScheduleStatus    `json:"status"`             // pending until the last occurrence runs or it is cancelled
+	Priority    int               `json:"priority,omitempty"` // order among debits due the same day; lower runs first
+	Retries     []ScheduleRetry   `json:"retries,omitempty"`  // failed occurrences still being retried
+	LastTxnID   string            `json:"lastTxnId,omitempty"`
+	LastError   string            `json:"lastError,omitempty"`
+}
+
+// ScheduleRetry is an occurrence the source account could not cover, waiting for its next attempt.
+type ScheduleRetry struct {
+	DueAt       time.Time `json:"dueAt"` // when the occurrence was originally due
+	NextAttempt time.Time `json:"nextAttempt"`
+	Attempts    int       `json:"attempts"` // attempts made so far
+}
+
+// ScheduleRetryPolicy configures how scheduled transfers that fail for insufficient funds are retried.
+type ScheduleRetryPolicy struct {
+	RetryDays int // Days after the due date to keep retrying once a day; 0 fails the occurrence at once
+}
+
+// ScheduledRun is the outcome of one attempt at an occurrence.
+type ScheduledRun struct {
+	ScheduleID string
+	DueAt      time.Time
+	Attempt    int    // 1 for the first attempt at the occurrence
+	TxnID      string // the transfer, or the history entry recording a failed attempt
+	Err        error
+	Retrying   bool // the attempt failed and the occurrence will be retried
+}
+
+// scheduledAttempt is an occurrence or retry that has fallen due.
+type scheduledAttempt struct {
+	st       *ScheduledTransfer
+	dueAt    time.Time // when the occurrence was originally due
+	at       time.Time // when this attempt became due
+	attempts int       // attempts made before this one
+	retry    int       // index into st.Retries, or -1 for the schedule's next occurrence
+}
+
+// before reports whether a should run before other: attempts due on an earlier day run first, and attempts due
+// the same day run in priority order, lowest first.
+func (a scheduledAttempt) before(other scheduledAttempt) bool {
+	if day, otherDay := startOfDay(a.at), startOfDay(other.at); !day.Equal(otherDay) {
+		return day.Before(otherDay)
+	}
+	if a.st.Priority != other.st.Priority {
+		return a.st.Priority < other.st.Priority
+	}
+	if !a.at.Equal(other.at) {
+		return a.at.Before(other.at)
+	}
+	return a.st.ID < other.st.ID
+}
+
+// insufficientFunds reports whether err means the source account could not cover a debit.
+func insufficientFunds(err error) bool {
+	msg := err.Error()
+	return strings.HasPrefix(msg, "insufficient funds") || msg == "withdrawal exceeds overdraft limit"
+}
+
+// ScheduleStorage is implemented by storage backends that can persist scheduled transfers.
+type ScheduleStorage interface {
+	SaveSchedules(schedules []ScheduledTransfer) error
+	LoadSchedules() ([]ScheduledTransfer, error)
+}
+
+// occurrence returns when the nth occurrence (counting from zero) of a schedule is due.
+func (st *ScheduledTransfer) occurrence(n int) time.Time {
+	switch st.Frequency {
+	case ScheduleDaily:
+		return st.Start.AddDate(0, 0, n)
+	case ScheduleWeekly:
+		return st.Start.AddDate(0, 0, 7*n)
+	case ScheduleMonthly:
+		return account.AddMonths(st.Start, n)
+	}
+	return st.Start
+}
+
+// advance moves the schedule past the occurrence that just ran, completing it when none are left.
+func (st *ScheduledTransfer) advance() {
+	st.Occurrences++
+	if st.Frequency == ScheduleOnce {
+		st.Status = ScheduleCompleted
+		return
+	}
+	st.NextRun = st.occurrence(st.Occurrences)
+	if !st.Until.IsZero() && st.NextRun.After(st.Until) {
+		st.Status = ScheduleCompleted
+	}
+}
+
+// ScheduleTransfer registers a transfer to run at start and then repeat at the given frequency until the optional end time.
+func (b *Bank) ScheduleTransfer(fromID, toID string, amount account.Money, start time.Time, frequency ScheduleFrequency, until time.Time) (ScheduledTransfer, error) {
+	if amount <= 0 {
+		return ScheduledTransfer{}, errors.New("scheduled amount must be positive")
+	}
+	if fromID == toID {
+		return ScheduledTransfer{}, errors.New("cannot schedule a transfer to the same account")
+	}
+	switch frequency {
+	case ScheduleOnce, ScheduleDaily, ScheduleWeekly, ScheduleMonthly:
+	default:
+		return ScheduledTransfer{}, errors.New("unknown schedule frequency " + string(frequency))
+	}
+	if !until.IsZero() && until.Before(start) {
+		return ScheduledTransfer{}, errors.New("schedule ends before it starts")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if start.Before(b.now()) {
+		return ScheduledTransfer{}, errors.New("schedule start is in the past")
+	}
+	for _, id := range []string{fromID, toID} {
+		if _, exists := b.accounts.get(id); !exists {
+			return ScheduledTransfer{}, errors.New("account does not exist")
+		}
+		if !b.IsAccountActive(id) {
+			return ScheduledTransfer{}, errors.New("account is inactive")
+		}
+	}
+	st := &ScheduledTransfer{
+		ID:        b.newID("sched"),
+		FromID:    fromID,
+		ToID:      toID,
+		Amount:    amount,
+		Frequency: frequency,
+		Start:     start,
+		Until:     until,
+		NextRun:   start,
+		Status:    SchedulePending,
+	}
+	b.schedules[st.ID] = st
+	return *st, nil
+}
+
+// ScheduledTransfers lists pending schedules and those with an occurrence awaiting retry, soonest first. An empty account ID lists every account's schedules.
+func (b *Bank) ScheduledTransfers(accountID string) []ScheduledTransfer {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	var pending []ScheduledTransfer
+	for _, st := range b.schedules {
+		if st.Status != SchedulePending && len(st.Retries) == 0 {
+			continue
+		}
+		if accountID != "" && st.FromID != accountID && st.ToID != accountID {
+			continue
+		}
+		pending = append(pending, *st)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		if pending[i].NextRun.Equal(pending[j].NextRun) {
+			return pending[i].ID < pending[j].ID
+		}
+		return pending[i].NextRun.Before(pending[j].NextRun)
+	})
+	return pending
+}
+
+// CancelScheduledTransfer stops a pending schedule from running again, dropping any occurrences awaiting retry.
+func (b *Bank) CancelScheduledTransfer(scheduleID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	st, exists := b.schedules[scheduleID]
+	if !exists {
+		return errors.New("scheduled transfer does not exist")
+	}
+	if st.Status != SchedulePending && len(st.Retries) == 0 {
+		return errors.New("scheduled transfer is already " + string(st.Status))
+	}
+	st.Status = ScheduleCancelled
+	st.Retries = nil
+	return nil
+}
+
+// scheduleSource returns the account a schedule debits, or "" if the schedule does not exist.
+func (b *Bank) scheduleSource(scheduleID string) string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if st, exists := b.schedules[scheduleID]; exists {
+		return st.FromID
+	}
+	return ""
+}
+
+// SetSchedulePriority sets the order in which a schedule runs among debits due the same day; lower runs first.
+// When an account cannot cover all of them, the higher priority debits are paid.
+func (b *Bank) SetSchedulePriority(scheduleID string, priority int) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	st, exists := b.schedules[scheduleID]
+	if !exists {
+		return errors.New("scheduled transfer does not exist")
+	}
+	st.Priority = priority
+	return nil
+}
+
+// SetScheduleRetryPolicy configures how scheduled transfers that fail for insufficient funds are retried.
+func (b *Bank) SetScheduleRetryPolicy(policy ScheduleRetryPolicy) error {
+	if policy.RetryDays < 0 {
+		return errors.New("retry days must not be negative")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.scheduleRetry = policy
+	return nil
+}
+
+// dueAttempts returns the schedule's occurrence and retries that have fallen due by now.
+func (st *ScheduledTransfer) dueAttempts(now time.Time) []scheduledAttempt {
+	var due []scheduledAttempt
+	if st.Status == SchedulePending && !st.NextRun.After(now) {
+		due = append(due, scheduledAttempt{st: st, dueAt: st.NextRun, at: st.NextRun, retry: -1})
+	}
+	for i, r := range st.Retries {
+		if !r.NextAttempt.After(now) {
+			due = append(due, scheduledAttempt{st: st, dueAt: r.DueAt, at: r.NextAttempt, attempts: r.Attempts, retry: i})
+		}
+	}
+	return due
+}
+
+// RunDueTransfers executes every occurrence that has fallen due by the bank's clock, oldest first. Debits due
+// the same day run in schedule priority order. Occurrences missed while the scheduler was not running are
+// executed in turn. An occurrence the source account cannot cover is retried daily for as long as the retry
+// policy allows. Every failed attempt is recorded in the history; when an occurrence finally fails it is
+// recorded on the schedule and the account owner is notified.
+func (b *Bank) RunDueTransfers() []ScheduledRun {
+	var runs []ScheduledRun
+	for {
+		b.mutex.Lock()
+		now := b.now()
+		var due *scheduledAttempt
+		for _, st := range b.schedules {
+			for _, a := range st.dueAttempts(now) {
+				if due == nil || a.before(*due) {
+					due = &a
+				}
+			}
+		}
+		if due == nil {
+			b.mutex.Unlock()
+			return runs
+		}
+		// Claim the attempt before releasing the lock so a concurrent run cannot execute it twice.
+		st := due.st
+		run := ScheduledRun{ScheduleID: st.ID, DueAt: due.dueAt, Attempt: due.attempts + 1}
+		fromID, toID, amount := st.FromID, st.ToID, st.Amount
+		if due.retry >= 0 {
+			st.Retries = append(st.Retries[:due.retry], st.Retries[due.retry+1:]...)
+		} else {
+			st.advance()
+		}
+		b.mutex.Unlock()
+
+		ctx := ContextWithCorrelationID(ContextWithChannel(context.Background(), ChannelScheduler), NewCorrelationID())
+		run.Err = b.RunCorrelated(ctx, []string{fromID, toID}, func() error {
+			var err error
+			run.TxnID, err = b.transfer(fromID, toID, amount)
+			return err
+		})
+
+		b.mutex.Lock()
+		if run.Err != nil {
+			next := due.dueAt.AddDate(0, 0, 1)
+			for !next.After(now) {
+				next = next.AddDate(0, 0, 1)
+			}
+			status := "failed"
+			if insufficientFunds(run.Err) && st.Status != ScheduleCancelled && !next.After(due.dueAt.AddDate(0, 0, b.scheduleRetry.RetryDays)) {
+				st.Retries = append(st.Retries, ScheduleRetry{DueAt: due.dueAt, NextAttempt: next, Attempts: run.Attempt})
+				run.Retrying = true
+				status = "retrying"
+			}
+			run.TxnID = b.newTxnID()
+			b.recordTransaction(run.TxnID, fmt.Sprintf("Transaction ID: %s, Schedule: %s, From: %s, To: %s, Amount: %s, Attempt: %d, Status: %s, Reason Code: %s, Error: %v, Channel: %s\n", run.TxnID, st.ID, fromID, toID, amount, run.Attempt, status, ReasonOf(run.Err), run.Err, ChannelScheduler))
+			st.LastError = run.Err.Error()
+			if !run.Retrying {
+				owner := b.accountOwner[fromID]
+				if owner == "" {
+					owner = fromID
+				}
+				_ = b.notify(owner, NotificationScheduledTransferFailed, fmt.Sprintf("scheduled transfer %s of %s to %s failed: %v", st.ID, amount, toID, run.Err), NotifyNormal)
+			}
+		} else {
+			st.LastTxnID = run.TxnID
+			st.LastError = ""
+			note := "Schedule: " + st.ID
+			if run.Attempt > 1 {
+				note += fmt.Sprintf(", Attempt: %d", run.Attempt)
+			}
+			b.annotateTransaction(run.TxnID, note)
+		}
+		b.mutex.Unlock()
+		runs = append(runs, run)
+	}
+}
+
+// schedulesForStorage copies every schedule for persistence.
+// The caller must hold the bank mutex.
+func (b *Bank) schedulesForStorage() []ScheduledTransfer {
+	schedules := make([]ScheduledTransfer, 0, len(b.schedules))
+	for _, st := range b.schedules {
+		schedules = append(schedules, *st)
+	}
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].ID < schedules[j].ID })
+	return schedules
+}
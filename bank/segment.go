@@ -0,0 +1,121 @@
+package bank
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// Segment groups customers who are priced alike, e.g. students with lower fees.
+type Segment string
+
+const (
+	SegmentStandard Segment = "standard" // Customers who have not been given a segment
+	SegmentStudent  Segment = "student"
+	SegmentSenior   Segment = "senior"
+	SegmentPremium  Segment = "premium"
+)
+
+// segments are the segments the bank knows.
+var segments = map[Segment]bool{SegmentStandard: true, SegmentStudent: true, SegmentSenior: true, SegmentPremium: true}
+
+// ParseSegment checks a segment name.
+func ParseSegment(name string) (Segment, error) {
+	segment := Segment(name)
+	if !segments[segment] {
+		return "", errors.New("unknown segment " + name + "; use standard, student, senior or premium")
+	}
+	return segment, nil
+}
+
+// SegmentPricing is how a segment's customers are priced differently from the rest. Anything left empty is priced
+// as for standard customers.
+type SegmentPricing struct {
+	Fees   []transaction.FeeRule                       `json:"fees"`   // Replaces the fee schedule; nil keeps it
+	Limits map[transaction.OperationType]account.Money `json:"limits"` // Replace the single-operation limits of these types
+	// Percentage points added to the interest rate of the customers' savings accounts
+	RateBonus float64 `json:"rateBonus"`
+}
+
+// SegmentChange is a customer's move to another segment, waiting for the next cycle.
+type SegmentChange struct {
+	Segment     Segment
+	EffectiveAt time.Time
+}
+
+// nextCycle returns the start of the fee cycle after the one t falls in: the first of the next month.
+func nextCycle(t time.Time) time.Time {
+	y, m, _ := t.Date()
+	return time.Date(y, m+1, 1, 0, 0, 0, 0, t.Location())
+}
+
+// SetSegment moves a customer to a segment from the start of the next cycle, so the fees, limits and rates of the
+// current one stay as they were. Only admins and managers may change segments.
+func (b *Bank) SetSegment(staffID, customerID string, segment Segment) (SegmentChange, error) {
+	if _, err := ParseSegment(string(segment)); err != nil {
+		return SegmentChange{}, err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.isManager(staffID) {
+		return SegmentChange{}, decline(ReasonNotAuthorized, "only admins and managers may change segments")
+	}
+	if customerID == "" {
+		return SegmentChange{}, errors.New("customer ID must not be empty")
+	}
+	// A change whose cycle has started is settled, and one still waiting is replaced
+	current := b.segmentOf(customerID)
+	b.setCurrentSegment(customerID, current)
+	delete(b.pendingSegments, customerID)
+	change := SegmentChange{Segment: segment, EffectiveAt: nextCycle(b.now())}
+	if segment != current {
+		b.pendingSegments[customerID] = change
+	}
+	b.auditAction(staffID, "SetSegment", "", "", customerID+" "+string(current)+" to "+string(segment)+" from "+change.EffectiveAt.UTC().Format(time.RFC3339))
+	return change, nil
+}
+
+// setCurrentSegment records the segment a customer is in.
+// The caller must hold the bank mutex.
+func (b *Bank) setCurrentSegment(customerID string, segment Segment) {
+	if segment == SegmentStandard {
+		delete(b.segments, customerID)
+		return
+	}
+	b.segments[customerID] = segment
+}
+
+// SegmentOf returns the segment a customer is in and any change waiting for the next cycle.
+func (b *Bank) SegmentOf(customerID string) (Segment, SegmentChange, bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	pending, exists := b.pendingSegments[customerID]
+	if !exists || !b.now().Before(pending.EffectiveAt) {
+		return b.segmentOf(customerID), SegmentChange{}, false
+	}
+	return b.segmentOf(customerID), pending, true
+}
+
+// segmentOf returns the segment a customer is in now, counting a change whose cycle has started.
+// The caller must hold the bank mutex.
+func (b *Bank) segmentOf(customerID string) Segment {
+	if pending, exists := b.pendingSegments[customerID]; exists && !b.now().Before(pending.EffectiveAt) {
+		return pending.Segment
+	}
+	if segment, exists := b.segments[customerID]; exists {
+		return segment
+	}
+	return SegmentStandard
+}
+
+// segmentPricing returns the configured pricing of the segment an account's owner is in, and whether there is any.
+// The caller must hold the bank mutex.
+func (b *Bank) segmentPricing(accountID string) (SegmentPricing, bool) {
+	if b.config == nil {
+		return SegmentPricing{}, false
+	}
+	pricing, exists := b.config.segments[b.segmentOf(b.accountOwner[accountID])]
+	return pricing, exists
+}
@@ -0,0 +1,1381 @@
+package bank
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// The servers in this file implement the services defined in proto/bank.proto on top of the Bank, and
+// ServiceHandler serves them as JSON over HTTP; cmd/bankd runs it. The module has no dependencies, so there is no
+// gRPC transport: the request and reply structs are hand-written mirrors of the proto messages, carrying the field
+// names of the proto3 JSON mapping. Every call is authorized against the user the authentication layer attaches with
+// ContextWithUser; calls without one are rejected. Calls that change anything are recorded under the correlation ID
+// it attaches with ContextWithCorrelationID, or under a new one if it attaches none. Every call but GetAPIUsage
+// counts against the monthly usage of the API client it attaches with ContextWithAPIClient, or else of the user, and
+// is declined once the client's plan quota is used up. Rejections carry a reason code, from ReasonOf, for the
+// transport to return to the caller.
+
+// CreateSavingsAccountRequest mirrors bank.v1.CreateSavingsAccountRequest.
+type CreateSavingsAccountRequest struct {
+	ID           string  `json:"id,omitempty"`
+	BalanceMinor int64   `json:"balanceMinor,omitempty"`
+	InterestRate float64 `json:"interestRate,omitempty"`
+}
+
+// CreateCheckingAccountRequest mirrors bank.v1.CreateCheckingAccountRequest.
+type CreateCheckingAccountRequest struct {
+	ID                  string  `json:"id,omitempty"`
+	BalanceMinor        int64   `json:"balanceMinor,omitempty"`
+	OverdraftLimitMinor int64   `json:"overdraftLimitMinor,omitempty"`
+	OverdraftRate       float64 `json:"overdraftRate,omitempty"`
+}
+
+// CreateAccountRequest mirrors bank.v1.CreateAccountRequest.
+type CreateAccountRequest struct {
+	ID           string `json:"id,omitempty"`
+	Type         string `json:"type,omitempty"`
+	BalanceMinor int64  `json:"balanceMinor,omitempty"`
+	ParamsJSON   string `json:"paramsJson,omitempty"`
+}
+
+// GetAccountRequest mirrors bank.v1.GetAccountRequest.
+type GetAccountRequest struct {
+	ID string `json:"id,omitempty"`
+}
+
+// CloseAccountRequest mirrors bank.v1.CloseAccountRequest.
+type CloseAccountRequest struct {
+	ID        string `json:"id,omitempty"`
+	SweepToID string `json:"sweepToId,omitempty"` // "" means the owner's default account
+}
+
+// AmountRequest mirrors bank.v1.AmountRequest.
+type AmountRequest struct {
+	ID          string `json:"id,omitempty"`
+	AmountMinor int64  `json:"amountMinor,omitempty"`
+	Rail        string `json:"rail,omitempty"` // "" means cash
+	Memo        string `json:"memo,omitempty"`
+	Reference   string `json:"reference,omitempty"` // Reference number of the payment outside the bank
+	Category    string `json:"category,omitempty"`
+}
+
+// memo returns the memo the request carries.
+func (r *AmountRequest) memo() transaction.Memo {
+	return transaction.Memo{Text: r.Memo, Reference: r.Reference, Category: r.Category}
+}
+
+// rail returns the rail the request names, cash if it names none.
+func (r *AmountRequest) rail() (Rail, error) {
+	if r.Rail == "" {
+		return RailCash, nil
+	}
+	return ParseRail(r.Rail)
+}
+
+// AccountReply mirrors bank.v1.AccountReply.
+type AccountReply struct {
+	ID             string `json:"id,omitempty"`
+	BalanceMinor   int64  `json:"balanceMinor,omitempty"`
+	Active         bool   `json:"active,omitempty"`
+	State          string `json:"state,omitempty"`
+	AvailableMinor int64  `json:"availableMinor,omitempty"`
+	HeldMinor      int64  `json:"heldMinor,omitempty"`
+	TransactionID  string `json:"transactionId,omitempty"` // Of a deposit or withdrawal recorded with a memo
+}
+
+// PlaceHoldRequest mirrors bank.v1.PlaceHoldRequest.
+type PlaceHoldRequest struct {
+	AccountID   string `json:"accountId,omitempty"`
+	AmountMinor int64  `json:"amountMinor,omitempty"`
+	Reference   string `json:"reference,omitempty"`
+	TTLSeconds  int64  `json:"ttlSeconds,omitempty"`
+}
+
+// HoldRequest mirrors bank.v1.HoldRequest.
+type HoldRequest struct {
+	HoldID      string `json:"holdId,omitempty"`
+	AmountMinor int64  `json:"amountMinor,omitempty"` // Amount to capture; 0 captures the whole hold. Ignored on release.
+}
+
+// HoldReply mirrors bank.v1.HoldReply.
+type HoldReply struct {
+	ID            string `json:"id,omitempty"`
+	AccountID     string `json:"accountId,omitempty"`
+	AmountMinor   int64  `json:"amountMinor,omitempty"`
+	Reference     string `json:"reference,omitempty"`
+	ExpiresUnix   int64  `json:"expiresUnix,omitempty"`
+	Status        string `json:"status,omitempty"`
+	CapturedMinor int64  `json:"capturedMinor,omitempty"`
+	TransactionID string `json:"transactionId,omitempty"`
+}
+
+// JointDebitRequest mirrors bank.v1.JointDebitRequest.
+type JointDebitRequest struct {
+	AccountID   string `json:"accountId,omitempty"`
+	ToID        string `json:"toId,omitempty"` // Blank for a withdrawal
+	AmountMinor int64  `json:"amountMinor,omitempty"`
+}
+
+// DecideJointDebitRequest mirrors bank.v1.DecideJointDebitRequest.
+type DecideJointDebitRequest struct {
+	ID      string `json:"id,omitempty"`
+	Approve bool   `json:"approve,omitempty"` // false rejects the debit
+}
+
+// ListJointDebitsRequest mirrors bank.v1.ListJointDebitsRequest.
+type ListJointDebitsRequest struct{}
+
+// JointDebitReply mirrors bank.v1.JointDebitReply.
+type JointDebitReply struct {
+	ID            string   `json:"id,omitempty"`
+	AccountID     string   `json:"accountId,omitempty"`
+	ToID          string   `json:"toId,omitempty"`
+	AmountMinor   int64    `json:"amountMinor,omitempty"`
+	RequestedBy   string   `json:"requestedBy,omitempty"`
+	Approvals     []string `json:"approvals,omitempty"`
+	Status        string   `json:"status,omitempty"`
+	ExpiresUnix   int64    `json:"expiresUnix,omitempty"`
+	TransactionID string   `json:"transactionId,omitempty"`
+	Error         string   `json:"error,omitempty"`
+}
+
+// ListJointDebitsReply mirrors bank.v1.ListJointDebitsReply.
+type ListJointDebitsReply struct {
+	Debits []*JointDebitReply `json:"debits,omitempty"`
+}
+
+// TransferRequest mirrors bank.v1.TransferRequest.
+type TransferRequest struct {
+	FromID      string           `json:"fromId,omitempty"`
+	ToID        string           `json:"toId,omitempty"`
+	AmountMinor int64            `json:"amountMinor,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Originator  *TravelRuleParty `json:"originator,omitempty"`  // Required over the travel rule threshold; a blank account means FromID
+	Beneficiary *TravelRuleParty `json:"beneficiary,omitempty"` // Required over the travel rule threshold; a blank account means ToID
+	Memo        string           `json:"memo,omitempty"`
+	Reference   string           `json:"reference,omitempty"` // Reference number of the payment outside the bank
+	Category    string           `json:"category,omitempty"`
+}
+
+// TransferReply mirrors bank.v1.TransferReply.
+type TransferReply struct {
+	TransactionID string `json:"transactionId,omitempty"`
+}
+
+// ReverseTransferRequest mirrors bank.v1.ReverseTransferRequest.
+type ReverseTransferRequest struct {
+	TransactionID string `json:"transactionId,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// ScheduleTransferRequest mirrors bank.v1.ScheduleTransferRequest. Times are Unix seconds; a zero end means no end.
+type ScheduleTransferRequest struct {
+	FromID      string `json:"fromId,omitempty"`
+	ToID        string `json:"toId,omitempty"`
+	AmountMinor int64  `json:"amountMinor,omitempty"`
+	StartUnix   int64  `json:"startUnix,omitempty"`
+	Frequency   string `json:"frequency,omitempty"`
+	UntilUnix   int64  `json:"untilUnix,omitempty"`
+}
+
+// ScheduledTransferReply mirrors bank.v1.ScheduledTransferReply.
+type ScheduledTransferReply struct {
+	ID          string `json:"id,omitempty"`
+	FromID      string `json:"fromId,omitempty"`
+	ToID        string `json:"toId,omitempty"`
+	AmountMinor int64  `json:"amountMinor,omitempty"`
+	Frequency   string `json:"frequency,omitempty"`
+	NextRunUnix int64  `json:"nextRunUnix,omitempty"`
+	Status      string `json:"status,omitempty"`
+}
+
+// ListScheduledTransfersRequest mirrors bank.v1.ListScheduledTransfersRequest.
+type ListScheduledTransfersRequest struct {
+	AccountID string `json:"accountId,omitempty"`
+}
+
+// ListScheduledTransfersReply mirrors bank.v1.ListScheduledTransfersReply.
+type ListScheduledTransfersReply struct {
+	Schedules []*ScheduledTransferReply `json:"schedules,omitempty"`
+}
+
+// CancelScheduledTransferRequest mirrors bank.v1.CancelScheduledTransferRequest.
+type CancelScheduledTransferRequest struct {
+	ID string `json:"id,omitempty"`
+}
+
+// AddPayeeRequest mirrors bank.v1.AddPayeeRequest.
+type AddPayeeRequest struct {
+	Nickname  string `json:"nickname,omitempty"`
+	AccountID string `json:"accountId,omitempty"`
+}
+
+// RemovePayeeRequest mirrors bank.v1.RemovePayeeRequest.
+type RemovePayeeRequest struct {
+	Nickname string `json:"nickname,omitempty"`
+}
+
+// RemovePayeeReply mirrors bank.v1.RemovePayeeReply.
+type RemovePayeeReply struct{}
+
+// ListPayeesRequest mirrors bank.v1.ListPayeesRequest.
+type ListPayeesRequest struct{}
+
+// PayeeReply mirrors bank.v1.PayeeReply. Times are Unix seconds.
+type PayeeReply struct {
+	Nickname    string `json:"nickname,omitempty"`
+	AccountID   string `json:"accountId,omitempty"`
+	AddedUnix   int64  `json:"addedUnix,omitempty"`
+	ClearedUnix int64  `json:"clearedUnix,omitempty"` // When transfers to the payee may first be made
+}
+
+// ListPayeesReply mirrors bank.v1.ListPayeesReply.
+type ListPayeesReply struct {
+	Payees []*PayeeReply `json:"payees,omitempty"`
+}
+
+// ReportRequest mirrors bank.v1.ReportRequest.
+type ReportRequest struct{}
+
+// ReportReply mirrors bank.v1.ReportReply.
+type ReportReply struct {
+	BalancesMinor map[string]int64 `json:"balancesMinor,omitempty"`
+	TotalMinor    int64            `json:"totalMinor,omitempty"`
+}
+
+// FailureReportRequest mirrors bank.v1.FailureReportRequest. A zero end means now; zero bucket seconds means hourly.
+type FailureReportRequest struct {
+	FromUnix      int64 `json:"fromUnix,omitempty"`
+	ToUnix        int64 `json:"toUnix,omitempty"`
+	BucketSeconds int64 `json:"bucketSeconds,omitempty"`
+}
+
+// FailureBucketReply mirrors bank.v1.FailureBucketReply.
+type FailureBucketReply struct {
+	StartUnix int64            `json:"startUnix,omitempty"`
+	Count     int32            `json:"count,omitempty"`
+	ByReason  map[string]int32 `json:"byReason,omitempty"`
+}
+
+// FailureReportReply mirrors bank.v1.FailureReportReply.
+type FailureReportReply struct {
+	Total     int32                 `json:"total,omitempty"`
+	ByReason  map[string]int32      `json:"byReason,omitempty"`
+	ByChannel map[string]int32      `json:"byChannel,omitempty"`
+	ByAccount map[string]int32      `json:"byAccount,omitempty"`
+	Buckets   []*FailureBucketReply `json:"buckets,omitempty"`
+}
+
+// ListTransactionsRequest mirrors bank.v1.ListTransactionsRequest.
+type ListTransactionsRequest struct {
+	AccountID      string `json:"accountId,omitempty"`
+	FromUnix       int64  `json:"fromUnix,omitempty"`
+	ToUnix         int64  `json:"toUnix,omitempty"`
+	MinAmountMinor int64  `json:"minAmountMinor,omitempty"`
+	MaxAmountMinor int64  `json:"maxAmountMinor,omitempty"`
+	Status         string `json:"status,omitempty"`
+	Type           string `json:"type,omitempty"`
+	NewestFirst    bool   `json:"newestFirst,omitempty"`
+	Offset         int32  `json:"offset,omitempty"`
+	Limit          int32  `json:"limit,omitempty"`
+	Memo           string `json:"memo,omitempty"`
+	Reference      string `json:"reference,omitempty"`
+	Category       string `json:"category,omitempty"`
+}
+
+// TransactionEntryReply mirrors bank.v1.TransactionEntryReply.
+type TransactionEntryReply struct {
+	TransactionID string `json:"transactionId,omitempty"`
+	RecordedUnix  int64  `json:"recordedUnix,omitempty"`
+	Type          string `json:"type,omitempty"`
+	FromID        string `json:"fromId,omitempty"`
+	ToID          string `json:"toId,omitempty"`
+	AccountID     string `json:"accountId,omitempty"`
+	AmountMinor   int64  `json:"amountMinor,omitempty"`
+	Status        string `json:"status,omitempty"`
+	Entry         string `json:"entry,omitempty"`
+	Memo          string `json:"memo,omitempty"`
+	Reference     string `json:"reference,omitempty"`
+	Category      string `json:"category,omitempty"`
+}
+
+// ListTransactionsReply mirrors bank.v1.ListTransactionsReply.
+type ListTransactionsReply struct {
+	Entries    []*TransactionEntryReply `json:"entries,omitempty"`
+	Total      int32                    `json:"total,omitempty"`
+	NextOffset int32                    `json:"nextOffset,omitempty"`
+}
+
+// CreateWebhookSubscriptionRequest mirrors bank.v1.CreateWebhookSubscriptionRequest.
+type CreateWebhookSubscriptionRequest struct {
+	URL        string   `json:"url,omitempty"`
+	EventTypes []string `json:"eventTypes,omitempty"`
+	AccountIDs []string `json:"accountIds,omitempty"`
+}
+
+// GetWebhookSubscriptionRequest mirrors bank.v1.GetWebhookSubscriptionRequest.
+type GetWebhookSubscriptionRequest struct {
+	ID string `json:"id,omitempty"`
+}
+
+// ListWebhookSubscriptionsRequest mirrors bank.v1.ListWebhookSubscriptionsRequest.
+type ListWebhookSubscriptionsRequest struct{}
+
+// ListWebhookSubscriptionsReply mirrors bank.v1.ListWebhookSubscriptionsReply.
+type ListWebhookSubscriptionsReply struct {
+	Subscriptions []*WebhookSubscriptionReply `json:"subscriptions,omitempty"`
+}
+
+// UpdateWebhookSubscriptionRequest mirrors bank.v1.UpdateWebhookSubscriptionRequest.
+type UpdateWebhookSubscriptionRequest struct {
+	ID         string   `json:"id,omitempty"`
+	URL        string   `json:"url,omitempty"`
+	EventTypes []string `json:"eventTypes,omitempty"`
+	AccountIDs []string `json:"accountIds,omitempty"`
+	Active     bool     `json:"active,omitempty"`
+}
+
+// DeleteWebhookSubscriptionRequest mirrors bank.v1.DeleteWebhookSubscriptionRequest.
+type DeleteWebhookSubscriptionRequest struct {
+	ID string `json:"id,omitempty"`
+}
+
+// DeleteWebhookSubscriptionReply mirrors bank.v1.DeleteWebhookSubscriptionReply.
+type DeleteWebhookSubscriptionReply struct{}
+
+// RotateWebhookSecretRequest mirrors bank.v1.RotateWebhookSecretRequest.
+type RotateWebhookSecretRequest struct {
+	ID           string `json:"id,omitempty"`
+	GraceSeconds int64  `json:"graceSeconds,omitempty"`
+}
+
+// WebhookSubscriptionReply mirrors bank.v1.WebhookSubscriptionReply. Secret is only set on creation and rotation.
+type WebhookSubscriptionReply struct {
+	ID          string   `json:"id,omitempty"`
+	URL         string   `json:"url,omitempty"`
+	EventTypes  []string `json:"eventTypes,omitempty"`
+	AccountIDs  []string `json:"accountIds,omitempty"`
+	Active      bool     `json:"active,omitempty"`
+	CreatedUnix int64    `json:"createdUnix,omitempty"`
+	Secret      string   `json:"secret,omitempty"`
+}
+
+// ListWebhookDeliveriesRequest mirrors bank.v1.ListWebhookDeliveriesRequest.
+type ListWebhookDeliveriesRequest struct {
+	SubscriptionID string `json:"subscriptionId,omitempty"`
+}
+
+// ListWebhookDeliveriesReply mirrors bank.v1.ListWebhookDeliveriesReply.
+type ListWebhookDeliveriesReply struct {
+	Deliveries []*WebhookDeliveryReply `json:"deliveries,omitempty"`
+}
+
+// RedeliverWebhookRequest mirrors bank.v1.RedeliverWebhookRequest.
+type RedeliverWebhookRequest struct {
+	DeliveryID string `json:"deliveryId,omitempty"`
+}
+
+// WebhookDeliveryReply mirrors bank.v1.WebhookDeliveryReply.
+type WebhookDeliveryReply struct {
+	ID             string `json:"id,omitempty"`
+	SubscriptionID string `json:"subscriptionId,omitempty"`
+	EventSeq       int64  `json:"eventSeq,omitempty"`
+	EventType      string `json:"eventType,omitempty"`
+	Status         string `json:"status,omitempty"`
+	Attempts       int32  `json:"attempts,omitempty"`
+	StatusCode     int32  `json:"statusCode,omitempty"`
+	Error          string `json:"error,omitempty"`
+	AtUnix         int64  `json:"atUnix,omitempty"`
+}
+
+// ReloadConfigRequest mirrors bank.v1.ReloadConfigRequest.
+type ReloadConfigRequest struct{}
+
+// GetConfigRequest mirrors bank.v1.GetConfigRequest.
+type GetConfigRequest struct{}
+
+// ConfigReply mirrors bank.v1.ConfigReply.
+type ConfigReply struct {
+	FeeRules    int32              `json:"feeRules,omitempty"`
+	LimitsMinor map[string]int64   `json:"limitsMinor,omitempty"`
+	Benchmarks  map[string]float64 `json:"benchmarks,omitempty"`
+	Holidays    []string           `json:"holidays,omitempty"`
+}
+
+// GetAPIUsageRequest mirrors bank.v1.GetAPIUsageRequest. A blank client means the caller's own, a blank month the
+// current one.
+type GetAPIUsageRequest struct {
+	ClientID string `json:"clientId,omitempty"`
+	Month    string `json:"month,omitempty"` // YYYY-MM, in UTC
+}
+
+// APIUsageReply mirrors bank.v1.APIUsageReply.
+type APIUsageReply struct {
+	ClientID    string           `json:"clientId,omitempty"`
+	Month       string           `json:"month,omitempty"`
+	Plan        string           `json:"plan,omitempty"`
+	Calls       map[string]int64 `json:"calls,omitempty"`
+	TotalCalls  int64            `json:"totalCalls,omitempty"`
+	Quotas      map[string]int64 `json:"quotas,omitempty"`
+	ChargeMinor int64            `json:"chargeMinor,omitempty"`
+}
+
+// apiContext returns a request's context marked as coming from the API and carrying a correlation ID, generating
+// one if the authentication layer attached none.
+func apiContext(ctx context.Context) context.Context {
+	if ChannelFromContext(ctx) == ChannelOther {
+		ctx = ContextWithChannel(ctx, ChannelAPI)
+	}
+	return ensureCorrelationID(ctx)
+}
+
+// AccountsServer implements the Accounts service on top of a Bank.
+type AccountsServer struct {
+	Bank *Bank
+}
+
+// accountReply builds the reply describing an account's current state.
+func (s *AccountsServer) accountReply(id string) (*AccountReply, error) {
+	acc, err := s.Bank.GetAccount(id)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := s.Bank.Balances(id)
+	if err != nil {
+		return nil, err
+	}
+	s.Bank.mutex.RLock()
+	active := s.Bank.IsAccountActive(id)
+	state := s.Bank.accounts.state(id)
+	s.Bank.mutex.RUnlock()
+	return &AccountReply{
+		ID:             id,
+		BalanceMinor:   int64(acc.Balance()),
+		Active:         active,
+		State:          string(state),
+		AvailableMinor: int64(balances.Available),
+		HeldMinor:      int64(balances.Held),
+	}, nil
+}
+
+// authorizeOpen checks the request's user may open an account with the opening balance.
+func (s *AccountsServer) authorizeOpen(ctx context.Context, openingBalance account.Money) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	userID, ok := UserFromContext(ctx)
+	if !ok {
+		return decline(ReasonNotAuthorized, "request is not authenticated")
+	}
+	return s.Bank.AuthorizeOpen(userID, openingBalance)
+}
+
+// openedBy gives an account opened by a customer to them and describes it.
+func (s *AccountsServer) openedBy(ctx context.Context, id string) (*AccountReply, error) {
+	userID, _ := UserFromContext(ctx)
+	if err := s.Bank.OpenedBy(userID, id); err != nil {
+		return nil, err
+	}
+	return s.accountReply(id)
+}
+
+// CreateSavingsAccount opens a savings account, with a new account number if the request has no ID.
+func (s *AccountsServer) CreateSavingsAccount(ctx context.Context, req *CreateSavingsAccountRequest) (*AccountReply, error) {
+	if err := s.Bank.meterRequest(ctx, "CreateSavingsAccount"); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeOpen(ctx, account.Money(req.BalanceMinor)); err != nil {
+		return nil, err
+	}
+	id := req.ID
+	if id == "" {
+		id = s.Bank.NewAccountID()
+	}
+	var reply *AccountReply
+	err := s.Bank.RunCorrelated(apiContext(ctx), []string{id}, func() error {
+		if _, err := s.Bank.NewSavingsAccount(id, account.Money(req.BalanceMinor), req.InterestRate); err != nil {
+			return err
+		}
+		var err error
+		reply, err = s.openedBy(ctx, id)
+		return err
+	})
+	return reply, err
+}
+
+// CreateCheckingAccount opens a checking account, with a new account number if the request has no ID.
+func (s *AccountsServer) CreateCheckingAccount(ctx context.Context, req *CreateCheckingAccountRequest) (*AccountReply, error) {
+	if err := s.Bank.meterRequest(ctx, "CreateCheckingAccount"); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeOpen(ctx, account.Money(req.BalanceMinor)); err != nil {
+		return nil, err
+	}
+	id := req.ID
+	if id == "" {
+		id = s.Bank.NewAccountID()
+	}
+	var reply *AccountReply
+	err := s.Bank.RunCorrelated(apiContext(ctx), []string{id}, func() error {
+		if _, err := s.Bank.NewCheckingAccount(id, account.Money(req.BalanceMinor), account.Money(req.OverdraftLimitMinor), req.OverdraftRate); err != nil {
+			return err
+		}
+		var err error
+		reply, err = s.openedBy(ctx, id)
+		return err
+	})
+	return reply, err
+}
+
+// CreateAccount opens an account of a registered custom type.
+func (s *AccountsServer) CreateAccount(ctx context.Context, req *CreateAccountRequest) (*AccountReply, error) {
+	if err := s.Bank.meterRequest(ctx, "CreateAccount"); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeOpen(ctx, account.Money(req.BalanceMinor)); err != nil {
+		return nil, err
+	}
+	var params json.RawMessage
+	if req.ParamsJSON != "" {
+		if !json.Valid([]byte(req.ParamsJSON)) {
+			return nil, errors.New("params must be a JSON document")
+		}
+		params = json.RawMessage(req.ParamsJSON)
+	}
+	var reply *AccountReply
+	err := s.Bank.RunCorrelated(apiContext(ctx), []string{req.ID}, func() error {
+		if _, err := s.Bank.NewCustomAccount(req.Type, req.ID, account.Money(req.BalanceMinor), params); err != nil {
+			return err
+		}
+		var err error
+		reply, err = s.openedBy(ctx, req.ID)
+		return err
+	})
+	return reply, err
+}
+
+// GetAccount returns an account's balance and status.
+func (s *AccountsServer) GetAccount(ctx context.Context, req *GetAccountRequest) (*AccountReply, error) {
+	if err := s.Bank.meterRequest(ctx, "GetAccount"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionView, req.ID); err != nil {
+		return nil, err
+	}
+	return s.accountReply(req.ID)
+}
+
+// CloseAccount closes an account.
+func (s *AccountsServer) CloseAccount(ctx context.Context, req *CloseAccountRequest) (*AccountReply, error) {
+	if err := s.Bank.meterRequest(ctx, "CloseAccount"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionClose, req.ID); err != nil {
+		return nil, err
+	}
+	err := s.Bank.RunCorrelated(apiContext(ctx), []string{req.ID, req.SweepToID}, func() error {
+		_, err := s.Bank.CloseTo(req.ID, req.SweepToID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.accountReply(req.ID)
+}
+
+// Deposit credits an account.
+func (s *AccountsServer) Deposit(ctx context.Context, req *AmountRequest) (*AccountReply, error) {
+	if err := s.Bank.meterRequest(ctx, "Deposit"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionDeposit, req.ID); err != nil {
+		return nil, err
+	}
+	rail, err := req.rail()
+	if err != nil {
+		return nil, err
+	}
+	var txnID string
+	err = s.Bank.RunCorrelated(apiContext(ctx), []string{req.ID}, func() error {
+		var err error
+		txnID, err = s.Bank.DepositWithMemo(req.ID, account.Money(req.AmountMinor), rail, req.memo())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.cashReply(req.ID, txnID)
+}
+
+// Withdraw debits an account.
+func (s *AccountsServer) Withdraw(ctx context.Context, req *AmountRequest) (*AccountReply, error) {
+	if err := s.Bank.meterRequest(ctx, "Withdraw"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionWithdraw, req.ID); err != nil {
+		return nil, err
+	}
+	rail, err := req.rail()
+	if err != nil {
+		return nil, err
+	}
+	var txnID string
+	err = s.Bank.RunCorrelated(apiContext(ctx), []string{req.ID}, func() error {
+		var err error
+		txnID, err = s.Bank.WithdrawWithMemo(req.ID, account.Money(req.AmountMinor), rail, req.memo())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.cashReply(req.ID, txnID)
+}
+
+// cashReply describes an account after a deposit or withdrawal recorded under txnID, if it was.
+func (s *AccountsServer) cashReply(accountID, txnID string) (*AccountReply, error) {
+	reply, err := s.accountReply(accountID)
+	if err != nil {
+		return nil, err
+	}
+	reply.TransactionID = txnID
+	return reply, nil
+}
+
+// holdReply converts a hold for the wire.
+func holdReply(h Hold) *HoldReply {
+	return &HoldReply{
+		ID:            h.ID,
+		AccountID:     h.AccountID,
+		AmountMinor:   int64(h.Amount),
+		Reference:     h.Reference,
+		ExpiresUnix:   h.ExpiresAt.Unix(),
+		Status:        string(h.Status),
+		CapturedMinor: int64(h.Captured),
+		TransactionID: h.TransactionID,
+	}
+}
+
+// PlaceHold reserves funds in an account, such as for a card authorization.
+func (s *AccountsServer) PlaceHold(ctx context.Context, req *PlaceHoldRequest) (*HoldReply, error) {
+	if err := s.Bank.meterRequest(ctx, "PlaceHold"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionWithdraw, req.AccountID); err != nil {
+		return nil, err
+	}
+	var h Hold
+	err := s.Bank.RunCorrelated(apiContext(ctx), []string{req.AccountID}, func() error {
+		var err error
+		h, err = s.Bank.PlaceHold(req.AccountID, account.Money(req.AmountMinor), req.Reference, time.Duration(req.TTLSeconds)*time.Second)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return holdReply(h), nil
+}
+
+// authorizedHold looks up a hold the request's user may withdraw from the account of.
+func (s *AccountsServer) authorizedHold(ctx context.Context, holdID string) (Hold, error) {
+	h, err := s.Bank.GetHold(holdID)
+	if err != nil {
+		return Hold{}, err
+	}
+	return h, s.Bank.authorizeRequest(ctx, ActionWithdraw, h.AccountID)
+}
+
+// CaptureHold debits all or part of a hold.
+func (s *AccountsServer) CaptureHold(ctx context.Context, req *HoldRequest) (*HoldReply, error) {
+	if err := s.Bank.meterRequest(ctx, "CaptureHold"); err != nil {
+		return nil, err
+	}
+	h, err := s.authorizedHold(ctx, req.HoldID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.Bank.RunCorrelated(apiContext(ctx), []string{h.AccountID}, func() error {
+		_, err := s.Bank.CaptureHold(req.HoldID, account.Money(req.AmountMinor))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	h, err = s.Bank.GetHold(req.HoldID)
+	return holdReply(h), err
+}
+
+// ReleaseHold ends a hold without debiting anything.
+func (s *AccountsServer) ReleaseHold(ctx context.Context, req *HoldRequest) (*HoldReply, error) {
+	if err := s.Bank.meterRequest(ctx, "ReleaseHold"); err != nil {
+		return nil, err
+	}
+	h, err := s.authorizedHold(ctx, req.HoldID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.Bank.RunCorrelated(apiContext(ctx), []string{h.AccountID}, func() error {
+		return s.Bank.ReleaseHold(req.HoldID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	h, err = s.Bank.GetHold(req.HoldID)
+	return holdReply(h), err
+}
+
+// jointDebitReply converts a joint debit for the wire.
+func jointDebitReply(d JointDebit) *JointDebitReply {
+	return &JointDebitReply{
+		ID:            d.ID,
+		AccountID:     d.AccountID,
+		ToID:          d.ToID,
+		AmountMinor:   int64(d.Amount),
+		RequestedBy:   d.RequestedBy,
+		Approvals:     d.Approvals,
+		Status:        string(d.Status),
+		ExpiresUnix:   d.ExpiresAt.Unix(),
+		TransactionID: d.TransactionID,
+		Error:         d.Err,
+	}
+}
+
+// RequestJointDebit asks the other owners of a joint account to approve a withdrawal or transfer the signed-in
+// owner may not make alone.
+func (s *AccountsServer) RequestJointDebit(ctx context.Context, req *JointDebitRequest) (*JointDebitReply, error) {
+	if err := s.Bank.meterRequest(ctx, "RequestJointDebit"); err != nil {
+		return nil, err
+	}
+	action := ActionWithdraw
+	if req.ToID != "" {
+		action = ActionTransfer
+	}
+	if err := s.Bank.authorizeRequest(ctx, action, req.AccountID); err != nil {
+		return nil, err
+	}
+	userID, _ := UserFromContext(ctx)
+	d, err := s.Bank.RequestJointDebit(userID, req.AccountID, req.ToID, account.Money(req.AmountMinor))
+	if err != nil {
+		return nil, err
+	}
+	return jointDebitReply(d), nil
+}
+
+// DecideJointDebit approves or rejects a joint debit on one of the signed-in customer's accounts. The reply of the
+// last approval says whether the debit then went through.
+func (s *AccountsServer) DecideJointDebit(ctx context.Context, req *DecideJointDebitRequest) (*JointDebitReply, error) {
+	if err := s.Bank.meterRequest(ctx, "DecideJointDebit"); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	userID, ok := UserFromContext(ctx)
+	if !ok {
+		return nil, decline(ReasonNotAuthorized, "request is not authenticated")
+	}
+	if !req.Approve {
+		if err := s.Bank.RejectJointDebit(req.ID, userID); err != nil {
+			return nil, err
+		}
+		return &JointDebitReply{ID: req.ID, Status: string(JointDebitRejected)}, nil
+	}
+	var subjects []string
+	for _, pending := range s.Bank.PendingJointDebits(userID) {
+		if pending.ID == req.ID {
+			subjects = []string{pending.AccountID, pending.ToID}
+		}
+	}
+	var d JointDebit
+	err := s.Bank.RunCorrelated(apiContext(ctx), subjects, func() error {
+		var err error
+		d, err = s.Bank.ApproveJointDebit(req.ID, userID)
+		return err
+	})
+	if err != nil && d.ID == "" {
+		return nil, err
+	}
+	return jointDebitReply(d), nil
+}
+
+// ListJointDebits lists the pending joint debits on the signed-in customer's accounts.
+func (s *AccountsServer) ListJointDebits(ctx context.Context, req *ListJointDebitsRequest) (*ListJointDebitsReply, error) {
+	if err := s.Bank.meterRequest(ctx, "ListJointDebits"); err != nil {
+		return nil, err
+	}
+	userID, ok := UserFromContext(ctx)
+	if !ok {
+		return nil, decline(ReasonNotAuthorized, "request is not authenticated")
+	}
+	reply := &ListJointDebitsReply{}
+	for _, d := range s.Bank.PendingJointDebits(userID) {
+		reply.Debits = append(reply.Debits, jointDebitReply(d))
+	}
+	return reply, nil
+}
+
+// TransfersServer implements the Transfers service on top of a Bank.
+type TransfersServer struct {
+	Bank *Bank
+}
+
+// Transfer moves funds between accounts.
+func (s *TransfersServer) Transfer(ctx context.Context, req *TransferRequest) (*TransferReply, error) {
+	if err := s.Bank.meterRequest(ctx, "Transfer"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionTransfer, req.FromID); err != nil {
+		return nil, err
+	}
+	memo := transaction.Memo{Text: req.Memo, Reference: req.Reference, Category: req.Category}
+	if err := memo.Validate(); err != nil {
+		return nil, err
+	}
+	var txnID string
+	err := s.Bank.RunCorrelated(apiContext(ctx), []string{req.FromID, req.ToID}, func() error {
+		var err error
+		t := PendingTransfer{FromID: req.FromID, ToID: req.ToID, Amount: account.Money(req.AmountMinor)}
+		if req.Originator != nil || req.Beneficiary != nil {
+			var d TravelRuleData
+			if req.Originator != nil {
+				d.Originator = *req.Originator
+			}
+			if req.Beneficiary != nil {
+				d.Beneficiary = *req.Beneficiary
+			}
+			t.TravelRule = d.forTransfer(req.FromID, req.ToID)
+		}
+		txnID, err = s.Bank.transferDescribed(t, req.Description)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.Bank.mutex.Lock()
+	s.Bank.noteMemo(txnID, memo)
+	s.Bank.mutex.Unlock()
+	return &TransferReply{TransactionID: txnID}, nil
+}
+
+// ReverseTransfer refunds a completed transfer; see Bank.ReverseTransaction.
+func (s *TransfersServer) ReverseTransfer(ctx context.Context, req *ReverseTransferRequest) (*TransferReply, error) {
+	if err := s.Bank.meterRequest(ctx, "ReverseTransfer"); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	userID, ok := UserFromContext(ctx)
+	if !ok {
+		return nil, decline(ReasonNotAuthorized, "request is not authenticated")
+	}
+	var reversalID string
+	err := s.Bank.RunCorrelated(apiContext(ctx), nil, func() error {
+		var err error
+		reversalID, err = s.Bank.ReverseTransaction(userID, req.TransactionID, req.Reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &TransferReply{TransactionID: reversalID}, nil
+}
+
+// scheduledTransferReply converts a schedule into its wire form.
+func scheduledTransferReply(st ScheduledTransfer) *ScheduledTransferReply {
+	return &ScheduledTransferReply{
+		ID:          st.ID,
+		FromID:      st.FromID,
+		ToID:        st.ToID,
+		AmountMinor: int64(st.Amount),
+		Frequency:   string(st.Frequency),
+		NextRunUnix: st.NextRun.Unix(),
+		Status:      string(st.Status),
+	}
+}
+
+// ScheduleTransfer registers a future-dated or recurring transfer.
+func (s *TransfersServer) ScheduleTransfer(ctx context.Context, req *ScheduleTransferRequest) (*ScheduledTransferReply, error) {
+	if err := s.Bank.meterRequest(ctx, "ScheduleTransfer"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionTransfer, req.FromID); err != nil {
+		return nil, err
+	}
+	var until time.Time
+	if req.UntilUnix != 0 {
+		until = time.Unix(req.UntilUnix, 0)
+	}
+	var st ScheduledTransfer
+	err := s.Bank.RunCorrelated(apiContext(ctx), []string{req.FromID, req.ToID}, func() error {
+		var err error
+		st, err = s.Bank.ScheduleTransfer(req.FromID, req.ToID, account.Money(req.AmountMinor), time.Unix(req.StartUnix, 0), ScheduleFrequency(req.Frequency), until)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scheduledTransferReply(st), nil
+}
+
+// ListScheduledTransfers returns pending schedules, optionally only those touching one account.
+func (s *TransfersServer) ListScheduledTransfers(ctx context.Context, req *ListScheduledTransfersRequest) (*ListScheduledTransfersReply, error) {
+	if err := s.Bank.meterRequest(ctx, "ListScheduledTransfers"); err != nil {
+		return nil, err
+	}
+	action := ActionView
+	if req.AccountID == "" {
+		action = ActionReport
+	}
+	if err := s.Bank.authorizeRequest(ctx, action, req.AccountID); err != nil {
+		return nil, err
+	}
+	reply := &ListScheduledTransfersReply{}
+	for _, st := range s.Bank.ScheduledTransfers(req.AccountID) {
+		reply.Schedules = append(reply.Schedules, scheduledTransferReply(st))
+	}
+	return reply, nil
+}
+
+// CancelScheduledTransfer stops a pending schedule.
+func (s *TransfersServer) CancelScheduledTransfer(ctx context.Context, req *CancelScheduledTransferRequest) (*ScheduledTransferReply, error) {
+	if err := s.Bank.meterRequest(ctx, "CancelScheduledTransfer"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionTransfer, s.Bank.scheduleSource(req.ID)); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.CancelScheduledTransfer(req.ID); err != nil {
+		return nil, err
+	}
+	s.Bank.mutex.Lock()
+	st := *s.Bank.schedules[req.ID]
+	s.Bank.mutex.Unlock()
+	return scheduledTransferReply(st), nil
+}
+
+// payeeReply converts a payee into its wire form.
+func (s *TransfersServer) payeeReply(p Payee) *PayeeReply {
+	return &PayeeReply{Nickname: p.Nickname, AccountID: p.AccountID, AddedUnix: p.AddedAt.Unix(), ClearedUnix: s.Bank.PayeeCleared(p).Unix()}
+}
+
+// AddPayee registers an account in the signed-in customer's payee directory.
+func (s *TransfersServer) AddPayee(ctx context.Context, req *AddPayeeRequest) (*PayeeReply, error) {
+	if err := s.Bank.meterRequest(ctx, "AddPayee"); err != nil {
+		return nil, err
+	}
+	userID, ok := UserFromContext(ctx)
+	if !ok {
+		return nil, decline(ReasonNotAuthorized, "request is not authenticated")
+	}
+	p, err := s.Bank.AddPayee(userID, req.Nickname, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	return s.payeeReply(p), nil
+}
+
+// RemovePayee deletes a payee from the signed-in customer's directory.
+func (s *TransfersServer) RemovePayee(ctx context.Context, req *RemovePayeeRequest) (*RemovePayeeReply, error) {
+	if err := s.Bank.meterRequest(ctx, "RemovePayee"); err != nil {
+		return nil, err
+	}
+	userID, ok := UserFromContext(ctx)
+	if !ok {
+		return nil, decline(ReasonNotAuthorized, "request is not authenticated")
+	}
+	if err := s.Bank.RemovePayee(userID, req.Nickname); err != nil {
+		return nil, err
+	}
+	return &RemovePayeeReply{}, nil
+}
+
+// ListPayees lists the signed-in customer's payee directory.
+func (s *TransfersServer) ListPayees(ctx context.Context, req *ListPayeesRequest) (*ListPayeesReply, error) {
+	if err := s.Bank.meterRequest(ctx, "ListPayees"); err != nil {
+		return nil, err
+	}
+	userID, ok := UserFromContext(ctx)
+	if !ok {
+		return nil, decline(ReasonNotAuthorized, "request is not authenticated")
+	}
+	reply := &ListPayeesReply{}
+	for _, p := range s.Bank.Payees(userID) {
+		reply.Payees = append(reply.Payees, s.payeeReply(p))
+	}
+	return reply, nil
+}
+
+// ReportsServer implements the Reports service on top of a Bank.
+type ReportsServer struct {
+	Bank *Bank
+}
+
+// Report returns the balances of all active accounts and their total.
+func (s *ReportsServer) Report(ctx context.Context, req *ReportRequest) (*ReportReply, error) {
+	if err := s.Bank.meterRequest(ctx, "Report"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionReport, ""); err != nil {
+		return nil, err
+	}
+	balances, totals := s.Bank.Report()
+	reply := &ReportReply{BalancesMinor: make(map[string]int64, len(balances)), TotalMinor: int64(totals.Total)}
+	for id, balance := range balances {
+		reply.BalancesMinor[id] = int64(balance)
+	}
+	return reply, nil
+}
+
+// FailureReport aggregates failed operations by reason code, channel, account and period.
+func (s *ReportsServer) FailureReport(ctx context.Context, req *FailureReportRequest) (*FailureReportReply, error) {
+	if err := s.Bank.meterRequest(ctx, "FailureReport"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionReport, ""); err != nil {
+		return nil, err
+	}
+	to := time.Unix(req.ToUnix, 0)
+	if req.ToUnix == 0 {
+		to = s.Bank.now()
+	}
+	report := s.Bank.FailureReport(time.Unix(req.FromUnix, 0), to, time.Duration(req.BucketSeconds)*time.Second)
+	reply := &FailureReportReply{
+		Total:     int32(report.Total),
+		ByReason:  make(map[string]int32, len(report.ByReason)),
+		ByChannel: make(map[string]int32, len(report.ByChannel)),
+		ByAccount: make(map[string]int32, len(report.ByAccount)),
+	}
+	for reason, n := range report.ByReason {
+		reply.ByReason[string(reason)] = int32(n)
+	}
+	for channel, n := range report.ByChannel {
+		reply.ByChannel[string(channel)] = int32(n)
+	}
+	for id, n := range report.ByAccount {
+		reply.ByAccount[id] = int32(n)
+	}
+	for _, fb := range report.Buckets {
+		bucket := &FailureBucketReply{StartUnix: fb.Start.Unix(), Count: int32(fb.Count), ByReason: make(map[string]int32, len(fb.ByReason))}
+		for reason, n := range fb.ByReason {
+			bucket.ByReason[string(reason)] = int32(n)
+		}
+		reply.Buckets = append(reply.Buckets, bucket)
+	}
+	return reply, nil
+}
+
+// ListTransactions returns a page of the transaction history, oldest first unless asked otherwise. Customers may
+// list their own accounts' transactions; the whole history needs the report permission.
+func (s *ReportsServer) ListTransactions(ctx context.Context, req *ListTransactionsRequest) (*ListTransactionsReply, error) {
+	if err := s.Bank.meterRequest(ctx, "ListTransactions"); err != nil {
+		return nil, err
+	}
+	action := ActionReport
+	if req.AccountID != "" {
+		action = ActionView
+	}
+	if err := s.Bank.authorizeRequest(ctx, action, req.AccountID); err != nil {
+		return nil, err
+	}
+	q := HistoryQuery{
+		AccountID: req.AccountID,
+		MinAmount: account.Money(req.MinAmountMinor),
+		MaxAmount: account.Money(req.MaxAmountMinor),
+		Status:    req.Status,
+		Type:      HistoryType(req.Type),
+		Memo:      req.Memo,
+		Reference: req.Reference,
+		Category:  req.Category,
+		Newest:    req.NewestFirst,
+		Offset:    int(req.Offset),
+		Limit:     int(req.Limit),
+	}
+	if req.FromUnix != 0 {
+		q.From = time.Unix(req.FromUnix, 0)
+	}
+	if req.ToUnix != 0 {
+		q.To = time.Unix(req.ToUnix, 0)
+	}
+	page, err := s.Bank.History(q)
+	if err != nil {
+		return nil, err
+	}
+	reply := &ListTransactionsReply{Total: int32(page.Total), NextOffset: int32(page.NextOffset)}
+	for _, h := range page.Entries {
+		entry := &TransactionEntryReply{
+			TransactionID: h.TransactionID,
+			Type:          string(h.Type),
+			FromID:        h.From,
+			ToID:          h.To,
+			AccountID:     h.Account,
+			AmountMinor:   int64(h.Amount),
+			Status:        h.Status,
+			Entry:         strings.TrimSuffix(h.Entry, "\n"),
+			Memo:          h.Memo,
+			Reference:     h.Reference,
+			Category:      h.Category,
+		}
+		if !h.Recorded.IsZero() {
+			entry.RecordedUnix = h.Recorded.Unix()
+		}
+		reply.Entries = append(reply.Entries, entry)
+	}
+	return reply, nil
+}
+
+// WebhooksServer implements the Webhooks service on top of a Bank.
+type WebhooksServer struct {
+	Bank *Bank
+}
+
+// eventTypes converts event type names from the wire.
+func eventTypes(names []string) []EventType {
+	types := make([]EventType, 0, len(names))
+	for _, name := range names {
+		types = append(types, EventType(name))
+	}
+	return types
+}
+
+// webhookSubscriptionReply converts a subscription into its wire form.
+func webhookSubscriptionReply(sub WebhookSubscription, secret string) *WebhookSubscriptionReply {
+	reply := &WebhookSubscriptionReply{
+		ID:          sub.ID,
+		URL:         sub.URL,
+		AccountIDs:  sub.AccountIDs,
+		Active:      sub.Active,
+		CreatedUnix: sub.CreatedAt.Unix(),
+		Secret:      secret,
+	}
+	for _, t := range sub.EventTypes {
+		reply.EventTypes = append(reply.EventTypes, string(t))
+	}
+	return reply
+}
+
+// webhookDeliveryReply converts a delivery into its wire form.
+func webhookDeliveryReply(d WebhookDelivery) *WebhookDeliveryReply {
+	return &WebhookDeliveryReply{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventSeq:       d.EventSeq,
+		EventType:      string(d.EventType),
+		Status:         string(d.Status),
+		Attempts:       int32(d.Attempts),
+		StatusCode:     int32(d.StatusCode),
+		Error:          d.Err,
+		AtUnix:         d.At.Unix(),
+	}
+}
+
+// CreateSubscription subscribes an endpoint to events.
+func (s *WebhooksServer) CreateSubscription(ctx context.Context, req *CreateWebhookSubscriptionRequest) (*WebhookSubscriptionReply, error) {
+	if err := s.Bank.meterRequest(ctx, "CreateSubscription"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionAdminister, ""); err != nil {
+		return nil, err
+	}
+	sub, secret, err := s.Bank.CreateWebhookSubscription(req.URL, eventTypes(req.EventTypes), req.AccountIDs)
+	if err != nil {
+		return nil, err
+	}
+	return webhookSubscriptionReply(sub, secret), nil
+}
+
+// GetSubscription returns a subscription.
+func (s *WebhooksServer) GetSubscription(ctx context.Context, req *GetWebhookSubscriptionRequest) (*WebhookSubscriptionReply, error) {
+	if err := s.Bank.meterRequest(ctx, "GetSubscription"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionAdminister, ""); err != nil {
+		return nil, err
+	}
+	sub, err := s.Bank.WebhookSubscriptionByID(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	return webhookSubscriptionReply(sub, ""), nil
+}
+
+// ListSubscriptions returns every subscription.
+func (s *WebhooksServer) ListSubscriptions(ctx context.Context, req *ListWebhookSubscriptionsRequest) (*ListWebhookSubscriptionsReply, error) {
+	if err := s.Bank.meterRequest(ctx, "ListSubscriptions"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionAdminister, ""); err != nil {
+		return nil, err
+	}
+	reply := &ListWebhookSubscriptionsReply{}
+	for _, sub := range s.Bank.WebhookSubscriptions() {
+		reply.Subscriptions = append(reply.Subscriptions, webhookSubscriptionReply(sub, ""))
+	}
+	return reply, nil
+}
+
+// UpdateSubscription changes a subscription's endpoint, filters and whether it is active.
+func (s *WebhooksServer) UpdateSubscription(ctx context.Context, req *UpdateWebhookSubscriptionRequest) (*WebhookSubscriptionReply, error) {
+	if err := s.Bank.meterRequest(ctx, "UpdateSubscription"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionAdminister, ""); err != nil {
+		return nil, err
+	}
+	sub, err := s.Bank.UpdateWebhookSubscription(req.ID, req.URL, eventTypes(req.EventTypes), req.AccountIDs, req.Active)
+	if err != nil {
+		return nil, err
+	}
+	return webhookSubscriptionReply(sub, ""), nil
+}
+
+// DeleteSubscription removes a subscription.
+func (s *WebhooksServer) DeleteSubscription(ctx context.Context, req *DeleteWebhookSubscriptionRequest) (*DeleteWebhookSubscriptionReply, error) {
+	if err := s.Bank.meterRequest(ctx, "DeleteSubscription"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionAdminister, ""); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.DeleteWebhookSubscription(req.ID); err != nil {
+		return nil, err
+	}
+	return &DeleteWebhookSubscriptionReply{}, nil
+}
+
+// RotateSecret gives a subscription a new signing secret.
+func (s *WebhooksServer) RotateSecret(ctx context.Context, req *RotateWebhookSecretRequest) (*WebhookSubscriptionReply, error) {
+	if err := s.Bank.meterRequest(ctx, "RotateSecret"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionAdminister, ""); err != nil {
+		return nil, err
+	}
+	secret, err := s.Bank.RotateWebhookSecret(req.ID, time.Duration(req.GraceSeconds)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := s.Bank.WebhookSubscriptionByID(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	return webhookSubscriptionReply(sub, secret), nil
+}
+
+// ListDeliveries returns the delivery log, optionally for one subscription.
+func (s *WebhooksServer) ListDeliveries(ctx context.Context, req *ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesReply, error) {
+	if err := s.Bank.meterRequest(ctx, "ListDeliveries"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionAdminister, ""); err != nil {
+		return nil, err
+	}
+	reply := &ListWebhookDeliveriesReply{}
+	for _, d := range s.Bank.WebhookDeliveries(req.SubscriptionID) {
+		reply.Deliveries = append(reply.Deliveries, webhookDeliveryReply(d))
+	}
+	return reply, nil
+}
+
+// Redeliver queues a delivery to be sent again.
+func (s *WebhooksServer) Redeliver(ctx context.Context, req *RedeliverWebhookRequest) (*WebhookDeliveryReply, error) {
+	if err := s.Bank.meterRequest(ctx, "Redeliver"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionAdminister, ""); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.RedeliverWebhook(req.DeliveryID); err != nil {
+		return nil, err
+	}
+	for _, d := range s.Bank.WebhookDeliveries("") {
+		if d.ID == req.DeliveryID {
+			return webhookDeliveryReply(d), nil
+		}
+	}
+	return nil, errors.New("webhook delivery does not exist")
+}
+
+// AdminServer implements the Admin service on top of a Bank.
+type AdminServer struct {
+	Bank       *Bank
+	ConfigPath string // File ReloadConfig reads
+}
+
+// configReply describes the configuration in effect.
+func configReply(c Config) *ConfigReply {
+	reply := &ConfigReply{
+		FeeRules:    int32(len(c.Fees)),
+		LimitsMinor: make(map[string]int64, len(c.Limits)),
+		Benchmarks:  c.Benchmarks,
+		Holidays:    c.Holidays,
+	}
+	for op, limit := range c.Limits {
+		reply.LimitsMinor[string(op)] = int64(limit)
+	}
+	return reply
+}
+
+// ReloadConfig rereads the config file and swaps it in, keeping the current config if the file is invalid.
+func (s *AdminServer) ReloadConfig(ctx context.Context, req *ReloadConfigRequest) (*ConfigReply, error) {
+	if err := s.Bank.meterRequest(ctx, "ReloadConfig"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionAdminister, ""); err != nil {
+		return nil, err
+	}
+	if s.ConfigPath == "" {
+		return nil, errors.New("no config file to reload")
+	}
+	if err := s.Bank.ReloadConfig(s.ConfigPath); err != nil {
+		return nil, err
+	}
+	return configReply(s.Bank.Config()), nil
+}
+
+// GetConfig returns the configuration in effect.
+func (s *AdminServer) GetConfig(ctx context.Context, req *GetConfigRequest) (*ConfigReply, error) {
+	if err := s.Bank.meterRequest(ctx, "GetConfig"); err != nil {
+		return nil, err
+	}
+	if err := s.Bank.authorizeRequest(ctx, ActionAdminister, ""); err != nil {
+		return nil, err
+	}
+	return configReply(s.Bank.Config()), nil
+}
+
+// GetAPIUsage returns an API client's calls in a month and what they are billed. Clients may see their own usage;
+// only administrators may see another client's. The call itself is free and never counted.
+func (s *AdminServer) GetAPIUsage(ctx context.Context, req *GetAPIUsageRequest) (*APIUsageReply, error) {
+	clientID, ok := APIClientFromContext(ctx)
+	if !ok {
+		return nil, decline(ReasonNotAuthorized, "request is not authenticated")
+	}
+	if req.ClientID != "" && req.ClientID != clientID {
+		if err := s.Bank.authorizeRequest(ctx, ActionAdminister, ""); err != nil {
+			return nil, err
+		}
+		clientID = req.ClientID
+	}
+	month := req.Month
+	if month == "" {
+		month = s.Bank.now().UTC().Format("2006-01")
+	} else if _, err := time.Parse("2006-01", month); err != nil {
+		return nil, errors.New("month must be YYYY-MM")
+	}
+	usage := s.Bank.APIUsageOf(clientID, month)
+	return &APIUsageReply{
+		ClientID:    usage.ClientID,
+		Month:       usage.Month,
+		Plan:        usage.Plan,
+		Calls:       usage.Calls,
+		TotalCalls:  usage.Total,
+		Quotas:      usage.Quotas,
+		ChargeMinor: int64(usage.Charge),
+	}, nil
+}
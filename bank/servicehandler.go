@@ -0,0 +1,172 @@
+package bank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxServiceRequest is the largest request body ServiceHandler reads.
+const maxServiceRequest = 1 << 20
+
+// serviceMethod decodes a request message, calls a server method with it and returns the reply message.
+type serviceMethod func(ctx context.Context, body []byte) (any, error)
+
+// malformedRequest is a request body that is not the method's request message.
+type malformedRequest struct {
+	err error
+}
+
+func (e *malformedRequest) Error() string {
+	return "malformed request: " + e.err.Error()
+}
+
+// serviceMethodOf adapts a server method to a serviceMethod. An empty body is the empty request message.
+func serviceMethodOf[Req, Reply any](call func(context.Context, *Req) (*Reply, error)) serviceMethod {
+	return func(ctx context.Context, body []byte) (any, error) {
+		req := new(Req)
+		if len(bytes.TrimSpace(body)) > 0 {
+			decoder := json.NewDecoder(bytes.NewReader(body))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(req); err != nil {
+				return nil, &malformedRequest{err: err}
+			}
+		}
+		return call(ctx, req)
+	}
+}
+
+// ServiceHandler serves the services defined in proto/bank.proto as JSON over HTTP. Each method is a POST to
+// /bank.v1.SERVICE/METHOD, e.g. /bank.v1.Transfers/Transfer, whose body is the request message and whose reply is
+// the reply message. Callers sign in with HTTP basic authentication as a user of the staff directory, and their calls
+// are metered to them. A caller may name the correlation ID a call is recorded under in an X-Correlation-ID header;
+// the reply carries it back, or the one the call was given. Rejected calls reply with an error status and a JSON
+// object carrying the error, its reason code and the correlation ID.
+type ServiceHandler struct {
+	users   *StaffDirectory
+	methods map[string]serviceMethod
+}
+
+// NewServiceHandler returns a handler serving a bank's services to the users of a staff directory. configPath is
+// the file the Admin service's ReloadConfig reads.
+func NewServiceHandler(b *Bank, users *StaffDirectory, configPath string) *ServiceHandler {
+	accounts := &AccountsServer{Bank: b}
+	transfers := &TransfersServer{Bank: b}
+	reports := &ReportsServer{Bank: b}
+	webhooks := &WebhooksServer{Bank: b}
+	admin := &AdminServer{Bank: b, ConfigPath: configPath}
+	return &ServiceHandler{users: users, methods: map[string]serviceMethod{
+		"/bank.v1.Accounts/CreateSavingsAccount":     serviceMethodOf(accounts.CreateSavingsAccount),
+		"/bank.v1.Accounts/CreateCheckingAccount":    serviceMethodOf(accounts.CreateCheckingAccount),
+		"/bank.v1.Accounts/CreateAccount":            serviceMethodOf(accounts.CreateAccount),
+		"/bank.v1.Accounts/GetAccount":               serviceMethodOf(accounts.GetAccount),
+		"/bank.v1.Accounts/CloseAccount":             serviceMethodOf(accounts.CloseAccount),
+		"/bank.v1.Accounts/Deposit":                  serviceMethodOf(accounts.Deposit),
+		"/bank.v1.Accounts/Withdraw":                 serviceMethodOf(accounts.Withdraw),
+		"/bank.v1.Accounts/PlaceHold":                serviceMethodOf(accounts.PlaceHold),
+		"/bank.v1.Accounts/CaptureHold":              serviceMethodOf(accounts.CaptureHold),
+		"/bank.v1.Accounts/ReleaseHold":              serviceMethodOf(accounts.ReleaseHold),
+		"/bank.v1.Accounts/RequestJointDebit":        serviceMethodOf(accounts.RequestJointDebit),
+		"/bank.v1.Accounts/DecideJointDebit":         serviceMethodOf(accounts.DecideJointDebit),
+		"/bank.v1.Accounts/ListJointDebits":          serviceMethodOf(accounts.ListJointDebits),
+		"/bank.v1.Transfers/Transfer":                serviceMethodOf(transfers.Transfer),
+		"/bank.v1.Transfers/ReverseTransfer":         serviceMethodOf(transfers.ReverseTransfer),
+		"/bank.v1.Transfers/ScheduleTransfer":        serviceMethodOf(transfers.ScheduleTransfer),
+		"/bank.v1.Transfers/ListScheduledTransfers":  serviceMethodOf(transfers.ListScheduledTransfers),
+		"/bank.v1.Transfers/CancelScheduledTransfer": serviceMethodOf(transfers.CancelScheduledTransfer),
+		"/bank.v1.Transfers/AddPayee":                serviceMethodOf(transfers.AddPayee),
+		"/bank.v1.Transfers/RemovePayee":             serviceMethodOf(transfers.RemovePayee),
+		"/bank.v1.Transfers/ListPayees":              serviceMethodOf(transfers.ListPayees),
+		"/bank.v1.Reports/Report":                    serviceMethodOf(reports.Report),
+		"/bank.v1.Reports/FailureReport":             serviceMethodOf(reports.FailureReport),
+		"/bank.v1.Reports/ListTransactions":          serviceMethodOf(reports.ListTransactions),
+		"/bank.v1.Webhooks/CreateSubscription":       serviceMethodOf(webhooks.CreateSubscription),
+		"/bank.v1.Webhooks/GetSubscription":          serviceMethodOf(webhooks.GetSubscription),
+		"/bank.v1.Webhooks/ListSubscriptions":        serviceMethodOf(webhooks.ListSubscriptions),
+		"/bank.v1.Webhooks/UpdateSubscription":       serviceMethodOf(webhooks.UpdateSubscription),
+		"/bank.v1.Webhooks/DeleteSubscription":       serviceMethodOf(webhooks.DeleteSubscription),
+		"/bank.v1.Webhooks/RotateSecret":             serviceMethodOf(webhooks.RotateSecret),
+		"/bank.v1.Webhooks/ListDeliveries":           serviceMethodOf(webhooks.ListDeliveries),
+		"/bank.v1.Webhooks/Redeliver":                serviceMethodOf(webhooks.Redeliver),
+		"/bank.v1.Admin/ReloadConfig":                serviceMethodOf(admin.ReloadConfig),
+		"/bank.v1.Admin/GetConfig":                   serviceMethodOf(admin.GetConfig),
+		"/bank.v1.Admin/GetAPIUsage":                 serviceMethodOf(admin.GetAPIUsage),
+	}}
+}
+
+// ServeHTTP authenticates a call, runs the method it names and writes the reply.
+func (h *ServiceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	correlationID := r.Header.Get("X-Correlation-ID")
+	if correlationID == "" {
+		correlationID = NewCorrelationID()
+	}
+	w.Header().Set("X-Correlation-ID", correlationID)
+	call, exists := h.methods[r.URL.Path]
+	if !exists {
+		writeServiceError(w, http.StatusNotFound, correlationID, fmt.Errorf("no method %s", r.URL.Path))
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeServiceError(w, http.StatusMethodNotAllowed, correlationID, errors.New("methods are called with POST"))
+		return
+	}
+	userID, password, ok := r.BasicAuth()
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="bank"`)
+		writeServiceError(w, http.StatusUnauthorized, correlationID, errors.New("request is not authenticated"))
+		return
+	}
+	if _, err := h.users.Authenticate(userID, password); err != nil {
+		w.Header().Set("WWW-Authenticate", `Basic realm="bank"`)
+		writeServiceError(w, http.StatusUnauthorized, correlationID, err)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxServiceRequest))
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, correlationID, err)
+		return
+	}
+	ctx := ContextWithCorrelationID(ContextWithChannel(ContextWithUser(r.Context(), userID), ChannelAPI), correlationID)
+	reply, err := call(ctx, body)
+	if err != nil {
+		writeServiceError(w, serviceStatus(err), correlationID, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// serviceStatus returns the HTTP status a method's error is replied with.
+func serviceStatus(err error) int {
+	var malformed *malformedRequest
+	if errors.As(err, &malformed) {
+		return http.StatusBadRequest
+	}
+	switch ReasonOf(err) {
+	case ReasonNotAuthorized:
+		return http.StatusForbidden
+	case ReasonQuotaExceeded:
+		return http.StatusTooManyRequests
+	case ReasonAccountNotFound:
+		return http.StatusNotFound
+	case ReasonOther:
+		return http.StatusBadRequest
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// writeServiceError replies to a call with an error status and the error, its reason code and the correlation ID.
+func writeServiceError(w http.ResponseWriter, status int, correlationID string, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(struct {
+		Error         string `json:"error"`
+		Reason        string `json:"reason,omitempty"`
+		CorrelationID string `json:"correlationId"`
+	}{Error: err.Error(), Reason: string(ReasonOf(err)), CorrelationID: correlationID})
+}
@@ -0,0 +1,284 @@
+package bank
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// Default snapshot tuning. Chunks are written and read concurrently, so large banks save in a fraction of the
+// time a single document takes.
+const (
+	defaultSnapshotChunkSize = 50000
+	snapshotManifestName     = "manifest.json"
+)
+
+// SnapshotManifest lists the chunk files that make up one account snapshot. Writing the manifest is the commit
+// point: chunks that are not listed in it are ignored and cleaned up.
+type SnapshotManifest struct {
+	Generation   int64           `json:"generation"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	AccountCount int             `json:"accountCount"`
+	Chunks       []SnapshotChunk `json:"chunks"`
+}
+
+// SnapshotChunk describes one chunk file of a snapshot. The checksum covers the file as stored, after compression.
+type SnapshotChunk struct {
+	File        string      `json:"file"`
+	Count       int         `json:"count"`
+	SHA256      string      `json:"sha256"`
+	Encoding    Encoding    `json:"encoding,omitempty"`
+	Compression Compression `json:"compression,omitempty"`
+}
+
+// SetSnapshotParallelism tunes how many accounts go in each chunk and how many chunks are processed at once.
+// Zero values keep the defaults.
+func (js *JSONFileStorage) SetSnapshotParallelism(chunkSize, workers int) {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	js.chunkSize = chunkSize
+	js.workers = workers
+}
+
+// SetSnapshotFormat selects the encoding and compression of future snapshots. Existing snapshots in any
+// format remain readable.
+func (js *JSONFileStorage) SetSnapshotFormat(opts FormatOptions) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	js.format = opts
+	return nil
+}
+
+func (js *JSONFileStorage) manifestPath() string {
+	return filepath.Join(js.dir, snapshotManifestName)
+}
+
+// snapshotSettings returns the effective chunk size and worker count.
+func (js *JSONFileStorage) snapshotSettings() (int, int) {
+	chunkSize, workers := js.chunkSize, js.workers
+	if chunkSize <= 0 {
+		chunkSize = defaultSnapshotChunkSize
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return chunkSize, workers
+}
+
+// runParallel calls fn for every index in [0, n) using at most workers goroutines and returns the first error.
+func runParallel(n, workers int, fn func(i int) error) error {
+	if workers > n {
+		workers = n
+	}
+	next := make(chan int)
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := fn(i); err != nil {
+					errs <- err
+					for range next {
+					}
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+// writeSnapshot streams the records into chunk files in parallel, then commits them with a new manifest.
+// The caller must hold the storage mutex.
+func (js *JSONFileStorage) writeSnapshot(records []account.Record) error {
+	if err := os.MkdirAll(js.dir, 0o755); err != nil {
+		return err
+	}
+	chunkSize, workers := js.snapshotSettings()
+	manifest := SnapshotManifest{
+		Generation:   time.Now().UnixNano(),
+		CreatedAt:    time.Now().UTC(),
+		AccountCount: len(records),
+	}
+	chunkCount := (len(records) + chunkSize - 1) / chunkSize
+	manifest.Chunks = make([]SnapshotChunk, chunkCount)
+	err := runParallel(chunkCount, workers, func(i int) error {
+		end := (i + 1) * chunkSize
+		if end > len(records) {
+			end = len(records)
+		}
+		name := fmt.Sprintf("accounts-%d-%05d%s", manifest.Generation, i, js.format.extension())
+		sum, err := writeSnapshotChunk(filepath.Join(js.dir, name), records[i*chunkSize:end], js.format)
+		if err != nil {
+			return err
+		}
+		manifest.Chunks[i] = SnapshotChunk{
+			File:        name,
+			Count:       end - i*chunkSize,
+			SHA256:      sum,
+			Encoding:    js.format.Encoding,
+			Compression: js.format.Compression,
+		}
+		return nil
+	})
+	if err != nil {
+		js.removeUnlistedChunks(nil)
+		return err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := js.manifestPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, js.manifestPath()); err != nil {
+		return err
+	}
+	js.removeUnlistedChunks(&manifest)
+	// The single-document format is superseded once a manifest exists.
+	os.Remove(js.accountsPath())
+	return nil
+}
+
+// writeSnapshotChunk streams records to a file in the given format and returns the file's SHA-256.
+func writeSnapshotChunk(path string, records []account.Record, opts FormatOptions) (string, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	w, err := newRecordWriter(io.MultiWriter(f, hash), opts)
+	if err != nil {
+		f.Close()
+		return "", err
+	}
+	for _, rec := range records {
+		if err := w.Write(rec); err != nil {
+			f.Close()
+			return "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// removeUnlistedChunks deletes chunk files that do not belong to the given manifest, such as chunks of older
+// generations or of a save that failed part way. A nil manifest keeps the chunks of the current manifest on disk.
+func (js *JSONFileStorage) removeUnlistedChunks(manifest *SnapshotManifest) {
+	if manifest == nil {
+		current, err := js.readManifest()
+		if err != nil || current == nil {
+			current = &SnapshotManifest{}
+		}
+		manifest = current
+	}
+	keep := make(map[string]bool, len(manifest.Chunks))
+	for _, chunk := range manifest.Chunks {
+		keep[chunk.File] = true
+	}
+	entries, err := os.ReadDir(js.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, "accounts-") && !keep[name] {
+			os.Remove(filepath.Join(js.dir, name))
+		}
+	}
+}
+
+// readManifest loads the current manifest. It returns nil when no snapshot has been written.
+func (js *JSONFileStorage) readManifest() (*SnapshotManifest, error) {
+	data, err := os.ReadFile(js.manifestPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest SnapshotManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// readSnapshot loads every chunk listed in the manifest in parallel, verifying counts and checksums.
+// The caller must hold the storage mutex.
+func (js *JSONFileStorage) readSnapshot(manifest *SnapshotManifest) ([]account.Record, error) {
+	_, workers := js.snapshotSettings()
+	chunks := make([][]account.Record, len(manifest.Chunks))
+	err := runParallel(len(manifest.Chunks), workers, func(i int) error {
+		records, err := readSnapshotChunk(filepath.Join(js.dir, manifest.Chunks[i].File), manifest.Chunks[i])
+		chunks[i] = records
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	records := make([]account.Record, 0, manifest.AccountCount)
+	for _, chunk := range chunks {
+		records = append(records, chunk...)
+	}
+	if len(records) != manifest.AccountCount {
+		return nil, errors.New("snapshot account count does not match manifest")
+	}
+	return records, nil
+}
+
+// readSnapshotChunk reads one chunk file in whatever format it was written and checks it against its manifest entry.
+func readSnapshotChunk(path string, chunk SnapshotChunk) ([]account.Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	records, err := readRecords(io.TeeReader(f, hash))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", chunk.File, err)
+	}
+	if len(records) != chunk.Count {
+		return nil, errors.New(chunk.File + ": record count does not match manifest")
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != chunk.SHA256 {
+		return nil, errors.New(chunk.File + ": checksum does not match manifest")
+	}
+	return records, nil
+}
@@ -0,0 +1,144 @@
+package bank
+
+import (
+	"fmt"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// SplitTransfer debits the total from one account and divides it among several destinations
+// by fixed amounts or percentages. Either every leg succeeds or none do; all legs are recorded
+// in the transaction history under a single parent transaction ID, which is returned. Each leg must pass the rules
+// of a customer transfer, and the total those checking the source, as it leaves in one debit. Splits are declined
+// while outgoing transfers are paused, whatever PausedTransfers says.
+func (b *Bank) SplitTransfer(fromID string, total account.Money, splits []transaction.Split) (string, error) {
+	amounts, err := transaction.SplitAmounts(total, splits)
+	if err != nil {
+		return "", err
+	}
+	if err := b.checkSplitPause(total); err != nil {
+		return "", err
+	}
+
+	// Hold every account involved so no leg can interleave with another operation
+	ids := []string{fromID}
+	for _, split := range splits {
+		ids = append(ids, split.ToID)
+	}
+	unlock := b.lockAccounts(ids...)
+	defer unlock()
+
+	parentID := b.newTxnID()
+
+	b.mutex.Lock()
+	fromAcc, exists := b.accounts.get(fromID)
+	if !exists || !b.IsAccountActive(fromID) {
+		b.mutex.Unlock()
+		return "", decline(ReasonAccountNotFound, "source account does not exist")
+	}
+	if err := b.checkOperation(fromID, account.OperationTransferOut); err != nil {
+		b.mutex.Unlock()
+		return "", err
+	}
+	toAccs := make([]account.Account, len(splits))
+	for i, split := range splits {
+		toAcc, exists := b.accounts.get(split.ToID)
+		if !exists || !b.IsAccountActive(split.ToID) {
+			b.mutex.Unlock()
+			return "", decline(ReasonAccountNotFound, "destination account "+split.ToID+" does not exist")
+		}
+		if err := b.checkOperation(split.ToID, account.OperationTransferIn); err != nil {
+			b.mutex.Unlock()
+			return "", err
+		}
+		if b.currencyOf(split.ToID) != b.currencyOf(fromID) {
+			b.mutex.Unlock()
+			return "", decline(ReasonCurrency, "cannot split a transfer across currencies")
+		}
+		if split.ToID == fromID {
+			b.mutex.Unlock()
+			return "", decline(ReasonSameAccount, "cannot split a transfer back to the source account")
+		}
+		toAccs[i] = toAcc
+	}
+	if err := b.validateSplit(parentID, fromID, total, splits, amounts); err != nil {
+		b.mutex.Unlock()
+		return "", err
+	}
+	b.mutex.Unlock()
+
+	// Money moves without the bank mutex; history is written under it afterwards
+	record := func(txnID, entry string) {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		b.recordTransaction(txnID, entry)
+	}
+
+	if err := fromAcc.Withdraw(total); err != nil {
+		record(parentID, fmt.Sprintf("Transaction ID: %s, From: %s, To: %d recipients, Amount: %s, Status: %s, Reason Code: %s\n", parentID, fromID, len(splits), total, "failed", ReasonOf(err)))
+		return "", err
+	}
+	for i, toAcc := range toAccs {
+		if err := toAcc.Deposit(amounts[i]); err != nil {
+			b.mutex.Lock()
+			defer b.mutex.Unlock()
+
+			// Roll back the legs already credited and refund the source. A leg that cannot be taken back stays
+			// paid and is recorded as such; whatever cannot be refunded is held for manual compensation.
+			refund := total
+			for j := 0; j < i; j++ {
+				if rollbackErr := toAccs[j].Withdraw(amounts[j]); rollbackErr != nil {
+					legID := fmt.Sprintf("%s-%d", parentID, j+1)
+					b.recordTransaction(legID, fmt.Sprintf("Transaction ID: %s, Parent: %s, From: %s, To: %s, Amount: %s, Status: %s, Error: could not be rolled back: %v\n", legID, parentID, fromID, splits[j].ToID, amounts[j], "success", rollbackErr))
+					b.recordEvent(Event{Type: EventTransferred, AccountID: fromID, ToID: splits[j].ToID, Amount: amounts[j], TransactionID: legID})
+					refund -= amounts[j]
+				}
+			}
+			status := "failed"
+			if refundErr := fromAcc.Deposit(refund); refundErr != nil {
+				status = string(transaction.StatusCompensationPending)
+				err = &transaction.CompensationError{TransactionID: parentID, Amount: refund, CreditErr: err, RefundErr: refundErr}
+				b.compensate(parentID, fromID, "", refund, refund, err)
+			}
+			b.recordTransaction(parentID, fmt.Sprintf("Transaction ID: %s, From: %s, To: %d recipients, Amount: %s, Status: %s, Reason Code: %s\n", parentID, fromID, len(splits), total, status, ReasonOf(err)))
+			return "", err
+		}
+	}
+
+	record(parentID, fmt.Sprintf("Transaction ID: %s, From: %s, To: %d recipients, Amount: %s, Status: %s\n", parentID, fromID, len(splits), total, "success"))
+	for i, split := range splits {
+		legID := fmt.Sprintf("%s-%d", parentID, i+1)
+		record(legID, fmt.Sprintf("Transaction ID: %s, Parent: %s, From: %s, To: %s, Amount: %s, Status: %s\n", legID, parentID, fromID, split.ToID, amounts[i], "success"))
+		b.mutex.Lock()
+		b.recordEvent(Event{Type: EventTransferred, AccountID: fromID, ToID: split.ToID, Amount: amounts[i], TransactionID: legID})
+		b.mutex.Unlock()
+	}
+	return parentID, nil
+}
+
+// validateSplit runs each leg of a split through the customer transfer rules, and the total through the rules
+// checking the source, which legs only pass one at a time: amount policy, funds on hold, limits, fees and minimum
+// balance. A rejected split is recorded as failed under its parent transaction ID, with its reason code.
+// The caller must hold every account's lock and the bank mutex.
+func (b *Bank) validateSplit(parentID, fromID string, total account.Money, splits []transaction.Split, amounts []account.Money) error {
+	rules := b.customerTransferRules()
+	var err error
+	for i, split := range splits {
+		if err = b.validateTransfer(PendingTransfer{FromID: fromID, ToID: split.ToID, Amount: amounts[i]}, rules); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		t := PendingTransfer{FromID: fromID, Amount: total, Channel: b.channelOf(fromID)}
+		for _, rule := range []TransferRule{TransferRuleFunc(b.checkTransferPolicy), TransferRuleFunc(b.checkTransferHolds), TransferRuleFunc(b.checkTransferLimits), TransferRuleFunc(b.checkTransferFunds)} {
+			if err = rule.Check(t); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		b.recordTransaction(parentID, fmt.Sprintf("Transaction ID: %s, From: %s, To: %d recipients, Amount: %s, Status: %s, Reason Code: %s\n", parentID, fromID, len(splits), total, "failed", ReasonOf(err)))
+	}
+	return err
+}
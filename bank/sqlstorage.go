@@ -0,0 +1,460 @@
+package bank
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// sqlMigrations build the SQL storage schema. Each entry is applied once, in order, and its position (starting at 1)
+// is recorded as the schema version. Append new migrations; never edit one that has shipped.
+var sqlMigrations = []string{
+	`CREATE TABLE accounts (
+		id            TEXT PRIMARY KEY,
+		type          TEXT NOT NULL,
+		state         TEXT NOT NULL,
+		owner         TEXT NOT NULL DEFAULT '',
+		branch        TEXT NOT NULL DEFAULT '',
+		balance_minor INTEGER NOT NULL,
+		record        TEXT NOT NULL
+	);
+	CREATE TABLE transactions (
+		seq   INTEGER PRIMARY KEY AUTOINCREMENT,
+		id    TEXT NOT NULL,
+		entry TEXT NOT NULL
+	);
+	CREATE TABLE schedules (
+		id       TEXT PRIMARY KEY,
+		schedule TEXT NOT NULL
+	)`,
+	`CREATE INDEX accounts_owner ON accounts (owner);
+	CREATE INDEX accounts_branch ON accounts (branch);
+	CREATE INDEX transactions_id ON transactions (id)`,
+	`CREATE TABLE events (
+		seq        INTEGER PRIMARY KEY,
+		type       TEXT NOT NULL,
+		account_id TEXT NOT NULL,
+		event      TEXT NOT NULL
+	);
+	CREATE INDEX events_account_id ON events (account_id)`,
+	`CREATE TABLE audit (
+		seq        INTEGER PRIMARY KEY AUTOINCREMENT,
+		actor      TEXT NOT NULL,
+		account_id TEXT NOT NULL,
+		record     TEXT NOT NULL
+	);
+	CREATE INDEX audit_account_id ON audit (account_id)`,
+	`CREATE TABLE api_usage (
+		client_id TEXT NOT NULL,
+		month     TEXT NOT NULL,
+		calls     TEXT NOT NULL,
+		PRIMARY KEY (client_id, month)
+	)`,
+	`CREATE TABLE bank_mode (
+		id   INTEGER PRIMARY KEY CHECK (id = 1),
+		mode TEXT NOT NULL
+	)`,
+	`CREATE TABLE payees (
+		customer_id TEXT NOT NULL,
+		nickname    TEXT NOT NULL,
+		payee       TEXT NOT NULL,
+		PRIMARY KEY (customer_id, nickname)
+	)`,
+	`CREATE TABLE account_archive (
+		account_id TEXT PRIMARY KEY,
+		purged_at  TEXT NOT NULL,
+		archive    TEXT NOT NULL
+	)`,
+}
+
+// SQLStorage stores bank state in a SQLite database through database/sql. Every save runs in a single database
+// transaction and every history entry is committed as it is appended, so a crash loses at most the operation in
+// progress. The program must register a SQLite driver, e.g. modernc.org/sqlite or github.com/mattn/go-sqlite3,
+// and open the database itself.
+//
+// Accounts are stored one row each, with the full persisted record alongside copies of the fields worth
+// querying directly. The transaction journal keeps every entry in order, like the JSON storage's journal.
+type SQLStorage struct {
+	db *sql.DB
+}
+
+// NewSQLStorage prepares db for use as bank storage, applying any schema migrations it has not seen yet.
+func NewSQLStorage(db *sql.DB) (*SQLStorage, error) {
+	ss := &SQLStorage{db: db}
+	if err := ss.migrate(); err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+// SchemaVersion returns the number of migrations applied to the database.
+func (ss *SQLStorage) SchemaVersion() (int, error) {
+	var version int
+	err := ss.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+// migrate applies pending migrations, each in its own transaction together with its version row.
+func (ss *SQLStorage) migrate() error {
+	if _, err := ss.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return err
+	}
+	version, err := ss.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version > len(sqlMigrations) {
+		return errors.New("database schema is newer than this version of the bank supports")
+	}
+	for i := version; i < len(sqlMigrations); i++ {
+		tx, err := ss.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqlMigrations[i]); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, i+1); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveAccounts replaces the stored accounts in one database transaction.
+func (ss *SQLStorage) SaveAccounts(records []account.Record) error {
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM accounts`); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT INTO accounts (id, type, state, owner, branch, balance_minor, record) VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, rec := range records {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.Exec(rec.ID, rec.Type, string(rec.State), rec.Owner, rec.Branch, int64(rec.Balance), string(data)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadAccounts reads the stored accounts.
+func (ss *SQLStorage) LoadAccounts() ([]account.Record, error) {
+	rows, err := ss.db.Query(`SELECT record FROM accounts ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []account.Record
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var rec account.Record
+		if err := json.Unmarshal([]byte(data), &rec); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// AppendTransaction appends a history entry to the journal.
+func (ss *SQLStorage) AppendTransaction(txnID, entry string) error {
+	_, err := ss.db.Exec(`INSERT INTO transactions (id, entry) VALUES (?, ?)`, txnID, entry)
+	return err
+}
+
+// LoadTransactions reads the journal. Later entries with the same ID replace earlier ones.
+func (ss *SQLStorage) LoadTransactions() (map[string]string, error) {
+	rows, err := ss.db.Query(`SELECT id, entry FROM transactions ORDER BY seq`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	history := make(map[string]string)
+	for rows.Next() {
+		var id, entry string
+		if err := rows.Scan(&id, &entry); err != nil {
+			return nil, err
+		}
+		history[id] = entry
+	}
+	return history, rows.Err()
+}
+
+// AppendEvent appends an event to the event log.
+func (ss *SQLStorage) AppendEvent(e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = ss.db.Exec(`INSERT INTO events (seq, type, account_id, event) VALUES (?, ?, ?, ?)`, e.Seq, string(e.Type), e.AccountID, string(data))
+	return err
+}
+
+// LoadEvents reads the event log in order.
+func (ss *SQLStorage) LoadEvents() ([]Event, error) {
+	rows, err := ss.db.Query(`SELECT event FROM events ORDER BY seq`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []Event
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// AppendAudit appends a record to the audit log.
+func (ss *SQLStorage) AppendAudit(r AuditRecord) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = ss.db.Exec(`INSERT INTO audit (actor, account_id, record) VALUES (?, ?, ?)`, r.Actor, r.AccountID, string(data))
+	return err
+}
+
+// LoadAudit reads the audit log in order.
+func (ss *SQLStorage) LoadAudit() ([]AuditRecord, error) {
+	rows, err := ss.db.Query(`SELECT record FROM audit ORDER BY seq`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []AuditRecord
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var r AuditRecord
+		if err := json.Unmarshal([]byte(data), &r); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// SaveSchedules replaces the stored scheduled transfers in one database transaction.
+func (ss *SQLStorage) SaveSchedules(schedules []ScheduledTransfer) error {
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM schedules`); err != nil {
+		return err
+	}
+	for _, st := range schedules {
+		data, err := json.Marshal(st)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO schedules (id, schedule) VALUES (?, ?)`, st.ID, string(data)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadSchedules reads the stored scheduled transfers.
+func (ss *SQLStorage) LoadSchedules() ([]ScheduledTransfer, error) {
+	rows, err := ss.db.Query(`SELECT schedule FROM schedules ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var schedules []ScheduledTransfer
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var st ScheduledTransfer
+		if err := json.Unmarshal([]byte(data), &st); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, st)
+	}
+	return schedules, rows.Err()
+}
+
+// SaveUsage replaces the stored API usage in one database transaction.
+func (ss *SQLStorage) SaveUsage(usage []APIUsage) error {
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM api_usage`); err != nil {
+		return err
+	}
+	for _, u := range usage {
+		data, err := json.Marshal(u.Calls)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO api_usage (client_id, month, calls) VALUES (?, ?, ?)`, u.ClientID, u.Month, string(data)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadUsage reads the stored API usage.
+func (ss *SQLStorage) LoadUsage() ([]APIUsage, error) {
+	rows, err := ss.db.Query(`SELECT client_id, month, calls FROM api_usage ORDER BY month, client_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var usage []APIUsage
+	for rows.Next() {
+		var u APIUsage
+		var data string
+		if err := rows.Scan(&u.ClientID, &u.Month, &data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &u.Calls); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+// SavePayees replaces the stored payee directory in one database transaction.
+func (ss *SQLStorage) SavePayees(payees []Payee) error {
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM payees`); err != nil {
+		return err
+	}
+	for _, p := range payees {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO payees (customer_id, nickname, payee) VALUES (?, ?, ?)`, p.CustomerID, strings.ToLower(p.Nickname), string(data)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadPayees reads the stored payee directory.
+func (ss *SQLStorage) LoadPayees() ([]Payee, error) {
+	rows, err := ss.db.Query(`SELECT payee FROM payees ORDER BY customer_id, nickname`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var payees []Payee
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var p Payee
+		if err := json.Unmarshal([]byte(data), &p); err != nil {
+			return nil, err
+		}
+		payees = append(payees, p)
+	}
+	return payees, rows.Err()
+}
+
+// SaveMode records the bank's mode.
+func (ss *SQLStorage) SaveMode(mode Mode) error {
+	_, err := ss.db.Exec(`INSERT INTO bank_mode (id, mode) VALUES (1, ?) ON CONFLICT (id) DO UPDATE SET mode = excluded.mode`, string(mode))
+	return err
+}
+
+// LoadMode reads the bank's recorded mode, "" if none has been recorded.
+func (ss *SQLStorage) LoadMode() (Mode, error) {
+	var mode string
+	err := ss.db.QueryRow(`SELECT mode FROM bank_mode WHERE id = 1`).Scan(&mode)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return Mode(mode), err
+}
+
+// ArchiveAccount keeps a purged account's archive, replacing any earlier archive of the same ID.
+func (ss *SQLStorage) ArchiveAccount(a AccountArchive) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	_, err = ss.db.Exec(`INSERT INTO account_archive (account_id, purged_at, archive) VALUES (?, ?, ?)
+		ON CONFLICT (account_id) DO UPDATE SET purged_at = excluded.purged_at, archive = excluded.archive`,
+		a.Record.ID, a.PurgedAt.UTC().Format(time.RFC3339Nano), string(data))
+	return err
+}
+
+// LoadArchive reads a purged account's archive, a zero archive if the account was never purged.
+func (ss *SQLStorage) LoadArchive(accountID string) (AccountArchive, error) {
+	var data string
+	err := ss.db.QueryRow(`SELECT archive FROM account_archive WHERE account_id = ?`, accountID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return AccountArchive{}, nil
+	}
+	if err != nil {
+		return AccountArchive{}, err
+	}
+	var a AccountArchive
+	if err := json.Unmarshal([]byte(data), &a); err != nil {
+		return AccountArchive{}, err
+	}
+	return a, nil
+}
+
+// DropTransactions removes history entries from the journal in one database transaction.
+func (ss *SQLStorage) DropTransactions(txnIDs []string) error {
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, id := range txnIDs {
+		if _, err := tx.Exec(`DELETE FROM transactions WHERE id = ?`, id); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,263 @@
+package bank
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StaffRole is what a user may do in the CLI, the API servers and operational tooling; see Authorize.
+type StaffRole string
+
+const (
+	StaffRoleAdmin    StaffRole = "admin"    // May override consent, reverse transactions and manage users
+	StaffRoleStaff    StaffRole = "staff"    // Tellers and support; may read and write internal notes
+	StaffRoleManager  StaffRole = "manager"  // Branch managers; may also close and freeze accounts and reverse transactions
+	StaffRoleTeller   StaffRole = "teller"   // May serve any customer's accounts and read and write internal notes
+	StaffRoleCustomer StaffRole = "customer" // May act only on accounts they own; the user ID is their customer ID
+)
+
+// staffPasswordIterations is the PBKDF2-SHA256 work factor for new password hashes.
+const staffPasswordIterations = 600000
+
+// minStaffPasswordLength is the shortest password a staff user may have.
+const minStaffPasswordLength = 12
+
+// StaffUser is a staff member who can sign in to operational tooling.
+type StaffUser struct {
+	ID           string    `json:"id"`
+	Role         StaffRole `json:"role"`
+	Salt         string    `json:"salt,omitempty"`
+	PasswordHash string    `json:"passwordHash,omitempty"`
+	Iterations   int       `json:"iterations,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// StaffDirectory keeps the users who can sign in, staff and customers alike, and their password hashes in a JSON
+// file readable only by its owner.
+type StaffDirectory struct {
+	path  string
+	users map[string]*StaffUser
+	mutex *sync.Mutex
+}
+
+// LoadStaffDirectory reads the staff directory at path. A missing file means no users have been added yet.
+func LoadStaffDirectory(path string) (*StaffDirectory, error) {
+	sd := &StaffDirectory{path: path, users: make(map[string]*StaffUser), mutex: &sync.Mutex{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return sd, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var users []*StaffUser
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		sd.users[u.ID] = u
+	}
+	return sd, nil
+}
+
+// save writes the directory through a temporary file.
+// The caller must hold the directory's mutex.
+func (sd *StaffDirectory) save() error {
+	users := make([]*StaffUser, 0, len(sd.users))
+	for _, u := range sd.users {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	data, err := json.MarshalIndent(users, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(sd.path), 0o755); err != nil {
+		return err
+	}
+	tmp := sd.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, sd.path)
+}
+
+// hashStaffPassword derives the stored hash of a password.
+func hashStaffPassword(password, salt string, iterations int) (string, error) {
+	key, err := pbkdf2.Key(sha256.New, password, []byte(salt), iterations, 32)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// setPassword gives a user a new salt and password hash.
+func (u *StaffUser) setPassword(password string) error {
+	if len(password) < minStaffPasswordLength {
+		return errors.New("password must be at least 12 characters")
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	hash, err := hashStaffPassword(password, hex.EncodeToString(salt), staffPasswordIterations)
+	if err != nil {
+		return err
+	}
+	u.Salt, u.PasswordHash, u.Iterations = hex.EncodeToString(salt), hash, staffPasswordIterations
+	return nil
+}
+
+// validStaffRole checks a role is one the directory knows.
+func validStaffRole(role StaffRole) error {
+	if _, known := rolePermissions[role]; !known {
+		return errors.New("role must be admin, manager, teller, staff or customer")
+	}
+	return nil
+}
+
+// Empty reports whether the directory has no users, e.g. before the first admin is added.
+func (sd *StaffDirectory) Empty() bool {
+	sd.mutex.Lock()
+	defer sd.mutex.Unlock()
+	return len(sd.users) == 0
+}
+
+// AddUser adds a staff user with a password and role.
+func (sd *StaffDirectory) AddUser(id, password string, role StaffRole) error {
+	if id == "" {
+		return errors.New("user ID must not be empty")
+	}
+	if err := validStaffRole(role); err != nil {
+		return err
+	}
+	sd.mutex.Lock()
+	defer sd.mutex.Unlock()
+	if _, exists := sd.users[id]; exists {
+		return errors.New("user already exists")
+	}
+	u := &StaffUser{ID: id, Role: role, CreatedAt: time.Now()}
+	if err := u.setPassword(password); err != nil {
+		return err
+	}
+	sd.users[id] = u
+	return sd.save()
+}
+
+// lastAdmin reports whether id is the only admin left.
+// The caller must hold the directory's mutex.
+func (sd *StaffDirectory) lastAdmin(id string) bool {
+	for other, u := range sd.users {
+		if other != id && u.Role == StaffRoleAdmin {
+			return false
+		}
+	}
+	return sd.users[id].Role == StaffRoleAdmin
+}
+
+// RemoveUser removes a staff user. The last admin cannot be removed.
+func (sd *StaffDirectory) RemoveUser(id string) error {
+	sd.mutex.Lock()
+	defer sd.mutex.Unlock()
+	if _, exists := sd.users[id]; !exists {
+		return errors.New("user does not exist")
+	}
+	if sd.lastAdmin(id) {
+		return errors.New("cannot remove the last admin")
+	}
+	delete(sd.users, id)
+	return sd.save()
+}
+
+// SetRole changes a staff user's role. The last admin cannot be demoted.
+func (sd *StaffDirectory) SetRole(id string, role StaffRole) error {
+	if err := validStaffRole(role); err != nil {
+		return err
+	}
+	sd.mutex.Lock()
+	defer sd.mutex.Unlock()
+	u, exists := sd.users[id]
+	if !exists {
+		return errors.New("user does not exist")
+	}
+	if role != StaffRoleAdmin && sd.lastAdmin(id) {
+		return errors.New("cannot demote the last admin")
+	}
+	u.Role = role
+	return sd.save()
+}
+
+// SetPassword replaces a staff user's password.
+func (sd *StaffDirectory) SetPassword(id, password string) error {
+	sd.mutex.Lock()
+	defer sd.mutex.Unlock()
+	u, exists := sd.users[id]
+	if !exists {
+		return errors.New("user does not exist")
+	}
+	if err := u.setPassword(password); err != nil {
+		return err
+	}
+	return sd.save()
+}
+
+// Authenticate checks a staff user's password and returns their role. Unknown users and wrong passwords get the
+// same error.
+func (sd *StaffDirectory) Authenticate(id, password string) (StaffRole, error) {
+	sd.mutex.Lock()
+	u, exists := sd.users[id]
+	var user StaffUser
+	if exists {
+		user = *u
+	}
+	sd.mutex.Unlock()
+	invalid := errors.New("invalid user ID or password")
+	if !exists {
+		return "", invalid
+	}
+	hash, err := hashStaffPassword(password, user.Salt, user.Iterations)
+	if err != nil {
+		return "", err
+	}
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(user.PasswordHash)) != 1 {
+		return "", invalid
+	}
+	return user.Role, nil
+}
+
+// Users lists the staff users, ordered by ID, without their password hashes.
+func (sd *StaffDirectory) Users() []StaffUser {
+	sd.mutex.Lock()
+	defer sd.mutex.Unlock()
+	users := make([]StaffUser, 0, len(sd.users))
+	for _, u := range sd.users {
+		users = append(users, StaffUser{ID: u.ID, Role: u.Role, CreatedAt: u.CreatedAt})
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users
+}
+
+// RegisterWith registers every user's role with the bank, and staff as admins or staff members, so Authorize and
+// the bank's staff-only operations accept them.
+func (sd *StaffDirectory) RegisterWith(b *Bank) {
+	for _, u := range sd.Users() {
+		b.SetUserRole(u.ID, u.Role)
+		switch u.Role {
+		case StaffRoleAdmin:
+			b.AddAdmin(u.ID)
+		case StaffRoleCustomer:
+		default:
+			b.AddStaff(u.ID)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package bank
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// StatementLine is one movement on an account statement.
+type StatementLine struct {
+	Date          time.Time
+	TransactionID string
+	Description   string
+	Counterparty  string        // Other account involved, if any
+	Amount        account.Money // Positive for credits, negative for debits
+	Balance       account.Money // Balance after the movement
+}
+
+// Statement lists the movements on an account over a period.
+type Statement struct {
+	AccountID      string
+	From, To       time.Time
+	OpeningBalance account.Money
+	Lines          []StatementLine
+	TotalCredits   account.Money
+	TotalDebits    account.Money // Sum of the debits, as a positive amount
+	TaxWithheld    account.Money // Tax withheld from the account's interest, part of TotalDebits
+	ClosingBalance account.Money
+}
+
+// Statement returns the account's opening balance at from, every movement from from up to but not including to,
+// and the closing balance at to. It is built from the event log, which must go back to the account's opening.
+func (b *Bank) Statement(accountID string, from, to time.Time) (Statement, error) {
+	if !to.After(from) {
+		return Statement{}, errors.New("statement must end after it starts")
+	}
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	if _, exists := b.accounts.get(accountID); !exists {
+		return Statement{}, errors.New("account does not exist")
+	}
+
+	st := Statement{AccountID: accountID, From: from, To: to}
+	var balance account.Money
+	opened := false
+	for _, e := range b.events {
+		if !e.At.Before(to) {
+			break
+		}
+		line, ok := statementLine(e, accountID, balance)
+		if !ok {
+			continue
+		}
+		if e.Type == EventAccountCreated {
+			opened = true
+		}
+		balance = line.Balance
+		if e.At.Before(from) {
+			st.OpeningBalance = balance
+			continue
+		}
+		if line.Amount == 0 {
+			continue
+		}
+		if line.Amount > 0 {
+			st.TotalCredits += line.Amount
+		} else {
+			st.TotalDebits -= line.Amount
+		}
+		if e.Type == EventTaxWithheld && e.AccountID == accountID {
+			st.TaxWithheld += e.Amount
+		}
+		st.Lines = append(st.Lines, line)
+	}
+	if !opened {
+		return Statement{}, errors.New("event log does not go back to the account's opening")
+	}
+	st.ClosingBalance = balance
+	return st, nil
+}
+
+// statementLine describes how an event moved an account's balance, given the balance before it. It returns false
+// for events that do not involve the account or leave its balance unchanged.
+func statementLine(e Event, accountID string, balance account.Money) (StatementLine, bool) {
+	line := StatementLine{Date: e.At, TransactionID: e.TransactionID}
+	switch {
+	case e.Type == EventTransferred && e.Reason == overdraftProtectionReason && e.AccountID == accountID:
+		line.Description, line.Counterparty, line.Amount = "Overdraft protection transfer to "+e.ToID, e.ToID, -e.Amount
+	case e.Type == EventTransferred && e.Reason == overdraftProtectionReason && e.ToID == accountID:
+		line.Description, line.Counterparty, line.Amount = "Overdraft protection transfer from "+e.AccountID, e.AccountID, e.Amount
+	case e.Type == EventTransferred && e.AccountID == accountID:
+		line.Description, line.Counterparty, line.Amount = "Transfer to "+e.ToID, e.ToID, -e.Amount
+	case e.Type == EventTransferred && e.ToID == accountID:
+		line.Description, line.Counterparty, line.Amount = "Transfer from "+e.AccountID, e.AccountID, e.Amount
+		if e.ToAmount != 0 {
+			line.Amount = e.ToAmount
+		}
+	case e.Type == EventTaxWithheld && e.AccountID == accountID:
+		line.Description, line.Counterparty, line.Amount = "Withholding tax", e.ToID, -e.Amount
+	case e.Type == EventTaxWithheld && e.ToID == accountID:
+		line.Description, line.Counterparty, line.Amount = "Withholding tax from "+e.AccountID, e.AccountID, e.Amount
+	case e.AccountID != accountID:
+		return StatementLine{}, false
+	case e.Type == EventDeposited:
+		line.Description, line.Amount = "Deposit"+railLabel(e.Rail), e.Amount
+	case e.Type == EventWithdrew:
+		line.Description, line.Amount = "Withdrawal"+railLabel(e.Rail), -e.Amount
+	case e.Type == EventFeeCharged:
+		line.Description, line.Amount = "Fee: "+e.Reason, -e.Amount
+	case e.Record != nil:
+		// Account snapshots carry the balance after the change rather than the change itself
+		line.Amount = e.Record.Balance - balance
+		switch e.Type {
+		case EventAccountCreated:
+			line.Description = "Opening balance"
+		case EventInterestPosted:
+			line.Description = "Interest"
+		default:
+			line.Description = "Adjustment"
+		}
+	default:
+		return StatementLine{}, false
+	}
+	line.Balance = balance + line.Amount
+	return line, line.Amount != 0 || e.Type == EventAccountCreated
+}
@@ -0,0 +1,737 @@
+package bank
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// Storage persists bank state between runs.
+type Storage interface {
+	SaveAccounts(records []account.Record) error
+	LoadAccounts() ([]account.Record, error)
+	AppendTransaction(txnID, entry string) error
+	LoadTransactions() (map[string]string, error)
+}
+
+// load restores accounts and transaction history from storage.
+func (b *Bank) load() error {
+	records, err := b.storage.LoadAccounts()
+	if err != nil {
+		return err
+	}
+	for _, rec := range records {
+		acc, err := accountFromRecord(rec)
+		if err != nil {
+			return err
+		}
+		state := rec.State
+		if state == "" {
+			state = account.StateClosed
+			if rec.Active {
+				state = account.StateOpen
+			}
+		}
+		if rec.Owner != "" {
+			b.accountOwner[rec.ID] = rec.Owner
+		}
+		if rec.JointOwners != "" {
+			b.jointOwners[rec.ID] = strings.Split(rec.JointOwners, ",")
+		}
+		if rec.SigningMode != "" {
+			b.signingRules[rec.ID] = SigningRule{Mode: SigningMode(rec.SigningMode), Threshold: rec.SigningThreshold}
+		}
+		if rec.Branch != "" {
+			b.accountBranch[rec.ID] = rec.Branch
+		}
+		if rec.Currency != "" && rec.Currency != DefaultCurrency {
+			b.accountCurrency[rec.ID] = rec.Currency
+		}
+		if rec.InterestPayoutAccountID != "" {
+			b.interestPayouts[rec.ID] = rec.InterestPayoutAccountID
+		}
+		if rec.OverdraftProtectionID != "" {
+			b.overdraftLinks[rec.ID] = rec.OverdraftProtectionID
+		}
+		if !rec.PromotionEnd.IsZero() {
+			b.promotions[rec.ID] = account.RateWindow{Rate: rec.PromotionalRate, Start: rec.PromotionStart, End: rec.PromotionEnd}
+		}
+		if rec.OwnerTaxStatus != "" && rec.Owner != "" {
+			b.taxStatus[rec.Owner] = TaxStatus(rec.OwnerTaxStatus)
+		}
+		if rec.OwnerSegment != "" && rec.Owner != "" {
+			b.segments[rec.Owner] = Segment(rec.OwnerSegment)
+		}
+		if rec.PendingSegment != "" && rec.Owner != "" {
+			b.pendingSegments[rec.Owner] = SegmentChange{Segment: Segment(rec.PendingSegment), EffectiveAt: rec.PendingSegmentAt}
+		}
+		if rec.Product != "" {
+			b.accountProduct[rec.ID] = rec.Product
+		}
+		if rec.PendingProduct != "" {
+			b.pendingProducts[rec.ID] = Conversion{AccountID: rec.ID, From: rec.Product, To: rec.PendingProduct, EffectiveAt: rec.PendingProductAt, Pending: true}
+		}
+		if !rec.OwnerTierReviewedAt.IsZero() && rec.Owner != "" {
+			b.relationships[rec.Owner] = TierStatus{Tier: rec.OwnerTier, ReviewedAt: rec.OwnerTierReviewedAt}
+		}
+		if rec.OwnerAliases != "" && rec.Owner != "" {
+			for _, alias := range strings.Split(rec.OwnerAliases, ",") {
+				b.aliases[alias] = rec.Owner
+			}
+		}
+		if rec.DefaultFor != "" {
+			for _, customerID := range strings.Split(rec.DefaultFor, ",") {
+				b.defaultAccounts[customerID] = rec.ID
+			}
+		}
+		if !rec.PendingLimitsAt.IsZero() {
+			b.pendingLimits[rec.ID] = PendingLimits{
+				Limits:      DailyLimits{Withdrawal: rec.PendingWithdrawalLimit, Transfer: rec.PendingTransferLimit},
+				EffectiveAt: rec.PendingLimitsAt,
+			}
+		}
+		b.registerAccount(acc, state)
+		profile := b.accounts.profile(rec.ID)
+		profile.Nickname = rec.Nickname
+		profile.Limits = DailyLimits{Withdrawal: rec.DailyWithdrawalLimit, Transfer: rec.DailyTransferLimit}
+		profile.Preferences = AccountPreferences{PaperStatements: rec.PaperStatements, Language: rec.Language}
+		profile.Metadata = AccountMetadata{OwnerName: rec.OwnerName, Email: rec.Email, CreatedAt: rec.CreatedAt}
+		if rec.Tags != "" {
+			profile.Metadata.Tags = strings.Split(rec.Tags, ",")
+		}
+	}
+	history, err := b.storage.LoadTransactions()
+	if err != nil {
+		return err
+	}
+	for txnID, entry := range history {
+		b.transactionHist[txnID] = entry
+	}
+	if ss, ok := b.storage.(ScheduleStorage); ok {
+		schedules, err := ss.LoadSchedules()
+		if err != nil {
+			return err
+		}
+		for i := range schedules {
+			b.schedules[schedules[i].ID] = &schedules[i]
+		}
+	}
+	if ms, ok := b.storage.(ModeStorage); ok {
+		mode, err := ms.LoadMode()
+		if err != nil {
+			return err
+		}
+		if mode != "" {
+			b.mode = mode
+		}
+	}
+	if us, ok := b.storage.(UsageStorage); ok {
+		usage, err := us.LoadUsage()
+		if err != nil {
+			return err
+		}
+		if err := b.restoreUsage(usage); err != nil {
+			return err
+		}
+	}
+	if ps, ok := b.storage.(PayeeStorage); ok {
+		payees, err := ps.LoadPayees()
+		if err != nil {
+			return err
+		}
+		if err := b.restorePayees(payees); err != nil {
+			return err
+		}
+	}
+	if es, ok := b.storage.(EventStorage); ok {
+		events, err := es.LoadEvents()
+		if err != nil {
+			return err
+		}
+		b.events = events
+		if len(events) > 0 {
+			b.eventSeq = events[len(events)-1].Seq
+		}
+		b.indexPurgedIDs()
+		b.backfillCreatedAt()
+	}
+	return nil
+}
+
+// recordTransaction adds an entry to the transaction history, stamped with when it was first recorded and the channel
+// of the operation under way on the accounts it names, and appends it to storage.
+// The caller must hold the bank mutex.
+func (b *Bank) recordTransaction(txnID, entry string) {
+	if !strings.Contains(entry, ", Recorded: ") {
+		entry = strings.TrimSuffix(entry, "\n") + ", Recorded: " + b.now().UTC().Format(time.RFC3339Nano) + "\n"
+		if !strings.Contains(entry, ", Channel: ") {
+			var subjects []string
+			for _, field := range historyFields(entry) {
+				if field[0] == "From" || field[0] == "To" || field[0] == "Account" {
+					subjects = append(subjects, field[1])
+				}
+			}
+			entry = strings.TrimSuffix(entry, "\n") + ", Channel: " + string(b.channelOf(subjects...)) + "\n"
+		}
+	}
+	b.transactionHist[txnID] = entry
+	if b.storage == nil {
+		return
+	}
+	if err := b.storage.AppendTransaction(txnID, entry); err != nil && b.persistErr == nil {
+		b.persistErr = err
+	}
+}
+
+// annotateTransaction appends a note to an existing history entry and re-journals it.
+// The caller must hold the bank mutex.
+func (b *Bank) annotateTransaction(txnID, note string) {
+	entry, exists := b.transactionHist[txnID]
+	if !exists {
+		return
+	}
+	b.recordTransaction(txnID, strings.TrimSuffix(entry, "\n")+", "+note+"\n")
+}
+
+// Save writes all accounts to storage. It also reports any transaction that failed to persist since the last save.
+func (b *Bank) Save() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.storage == nil {
+		return nil
+	}
+	records, err := b.accountRecords()
+	if err != nil {
+		return err
+	}
+	if err := b.storage.SaveAccounts(records); err != nil {
+		return err
+	}
+	if ss, ok := b.storage.(ScheduleStorage); ok {
+		if err := ss.SaveSchedules(b.schedulesForStorage()); err != nil {
+			return err
+		}
+	}
+	if us, ok := b.storage.(UsageStorage); ok {
+		if err := us.SaveUsage(b.usageForStorage()); err != nil {
+			return err
+		}
+	}
+	if ps, ok := b.storage.(PayeeStorage); ok {
+		if err := ps.SavePayees(b.payeesForStorage()); err != nil {
+			return err
+		}
+	}
+	err = b.persistErr
+	b.persistErr = nil
+	return err
+}
+
+// accountRecords converts every account into its persisted form, along with the customers' aliases and default
+// accounts.
+// The caller must hold the bank mutex.
+func (b *Bank) accountRecords() ([]account.Record, error) {
+	aliases := make(map[string][]string)
+	for alias, customerID := range b.aliases {
+		aliases[customerID] = append(aliases[customerID], alias)
+	}
+	defaultFor := make(map[string][]string)
+	for customerID, accountID := range b.defaultAccounts {
+		defaultFor[accountID] = append(defaultFor[accountID], customerID)
+	}
+	records := make([]account.Record, 0, b.accounts.len())
+	for id, acc := range b.accounts.all() {
+		rec, err := b.accountRecord(id, acc)
+		if err != nil {
+			return nil, err
+		}
+		if rec.Owner != "" {
+			sort.Strings(aliases[rec.Owner])
+			rec.OwnerAliases = strings.Join(aliases[rec.Owner], ",")
+		}
+		sort.Strings(defaultFor[id])
+		rec.DefaultFor = strings.Join(defaultFor[id], ",")
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// accountRecord converts an account into its persisted form, with what the bank keeps about it.
+// The caller must hold the bank mutex.
+func (b *Bank) accountRecord(id string, acc account.Account) (account.Record, error) {
+	rec, err := account.ToRecord(acc)
+	if err != nil {
+		return account.Record{}, err
+	}
+	rec.Active = b.IsAccountActive(id)
+	rec.State = b.accounts.state(id)
+	rec.Owner = b.accountOwner[id]
+	rec.JointOwners = strings.Join(b.jointOwners[id], ",")
+	if rule, exists := b.signingRules[id]; exists {
+		rec.SigningMode, rec.SigningThreshold = string(rule.Mode), rule.Threshold
+	}
+	rec.Branch = b.accountBranch[id]
+	profile := b.profileOf(id)
+	meta := profile.Metadata
+	rec.OwnerName, rec.Email, rec.CreatedAt, rec.Tags = meta.OwnerName, meta.Email, meta.CreatedAt, strings.Join(meta.Tags, ",")
+	rec.Nickname = profile.Nickname
+	rec.Currency = b.accountCurrency[id]
+	rec.DailyWithdrawalLimit = profile.Limits.Withdrawal
+	rec.DailyTransferLimit = profile.Limits.Transfer
+	rec.PaperStatements, rec.Language = profile.Preferences.PaperStatements, profile.Preferences.Language
+	if pending, exists := b.pendingLimits[id]; exists {
+		rec.PendingWithdrawalLimit, rec.PendingTransferLimit = pending.Limits.Withdrawal, pending.Limits.Transfer
+		rec.PendingLimitsAt = pending.EffectiveAt
+	}
+	rec.InterestPayoutAccountID = b.interestPayouts[id]
+	rec.OverdraftProtectionID = b.overdraftLinks[id]
+	if promo, exists := b.promotions[id]; exists {
+		rec.PromotionalRate, rec.PromotionStart, rec.PromotionEnd = promo.Rate, promo.Start, promo.End
+	}
+	rec.Product = b.accountProduct[id]
+	if pending, exists := b.pendingProducts[id]; exists {
+		rec.PendingProduct, rec.PendingProductAt = pending.To, pending.EffectiveAt
+	}
+	rec.OwnerTaxStatus = string(b.taxStatus[rec.Owner])
+	if rec.Owner != "" {
+		if segment := b.segmentOf(rec.Owner); segment != SegmentStandard {
+			rec.OwnerSegment = string(segment)
+		}
+		if pending, exists := b.pendingSegments[rec.Owner]; exists && b.now().Before(pending.EffectiveAt) {
+			rec.PendingSegment, rec.PendingSegmentAt = string(pending.Segment), pending.EffectiveAt
+		}
+		status := b.relationships[rec.Owner]
+		rec.OwnerTier, rec.OwnerTierReviewedAt = status.Tier, status.ReviewedAt
+	}
+	return rec, nil
+}
+
+// JSONFileStorage stores accounts as a JSON document and transactions as a JSON-lines journal in a directory.
+type JSONFileStorage struct {
+	dir       string
+	chunkSize int           // accounts per snapshot chunk, 0 for the default
+	workers   int           // chunks processed concurrently, 0 for GOMAXPROCS
+	format    FormatOptions // encoding and compression of snapshot chunks
+	mutex     *sync.Mutex
+}
+
+// transactionLine is one line of the transaction journal.
+type transactionLine struct {
+	ID    string `json:"id"`
+	Entry string `json:"entry"`
+}
+
+// NewJSONFileStorage creates a storage backend rooted at dir. The directory is created on first write.
+func NewJSONFileStorage(dir string) *JSONFileStorage {
+	return &JSONFileStorage{dir: dir, mutex: &sync.Mutex{}}
+}
+
+func (js *JSONFileStorage) accountsPath() string {
+	return filepath.Join(js.dir, "accounts.json")
+}
+
+func (js *JSONFileStorage) transactionsPath() string {
+	return filepath.Join(js.dir, "transactions.jsonl")
+}
+
+func (js *JSONFileStorage) eventsPath() string {
+	return filepath.Join(js.dir, "events.jsonl")
+}
+
+func (js *JSONFileStorage) auditPath() string {
+	return filepath.Join(js.dir, "audit.jsonl")
+}
+
+func (js *JSONFileStorage) schedulesPath() string {
+	return filepath.Join(js.dir, "schedules.json")
+}
+
+func (js *JSONFileStorage) usagePath() string {
+	return filepath.Join(js.dir, "usage.json")
+}
+
+func (js *JSONFileStorage) payeesPath() string {
+	return filepath.Join(js.dir, "payees.json")
+}
+
+func (js *JSONFileStorage) modePath() string {
+	return filepath.Join(js.dir, "mode.json")
+}
+
+// SaveAccounts replaces the stored accounts with a chunked snapshot. Chunks are streamed to disk in parallel and
+// committed by atomically replacing the manifest, so a crash never leaves a half-written snapshot behind.
+func (js *JSONFileStorage) SaveAccounts(records []account.Record) error {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	return js.writeSnapshot(records)
+}
+
+// LoadAccounts reads the stored accounts. Snapshots written before chunking are read from the single
+// accounts document. Nothing on disk means no accounts have been saved yet.
+func (js *JSONFileStorage) LoadAccounts() ([]account.Record, error) {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	manifest, err := js.readManifest()
+	if err != nil {
+		return nil, err
+	}
+	if manifest != nil {
+		return js.readSnapshot(manifest)
+	}
+	data, err := os.ReadFile(js.accountsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []account.Record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// AppendTransaction appends a history entry to the journal.
+func (js *JSONFileStorage) AppendTransaction(txnID, entry string) error {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	if err := os.MkdirAll(js.dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(js.transactionsPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(transactionLine{ID: txnID, Entry: entry})
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadTransactions reads the journal. Later entries with the same ID replace earlier ones.
+func (js *JSONFileStorage) LoadTransactions() (map[string]string, error) {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	history := make(map[string]string)
+	f, err := os.Open(js.transactionsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line transactionLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return nil, err
+		}
+		history[line.ID] = line.Entry
+	}
+	return history, scanner.Err()
+}
+
+// AppendEvent appends an event to the event log.
+func (js *JSONFileStorage) AppendEvent(e Event) error {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	if err := os.MkdirAll(js.dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(js.eventsPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadEvents reads the event log in order. A missing file means no events have been recorded yet.
+func (js *JSONFileStorage) LoadEvents() ([]Event, error) {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	f, err := os.Open(js.eventsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
+
+// AppendAudit appends a record to the audit log and syncs it to disk.
+func (js *JSONFileStorage) AppendAudit(r AuditRecord) error {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	return appendAuditLine(js.auditPath(), r)
+}
+
+// LoadAudit reads the audit log in order. A missing file means nothing has been audited yet.
+func (js *JSONFileStorage) LoadAudit() ([]AuditRecord, error) {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	return readAuditLines(js.auditPath())
+}
+
+// SaveSchedules replaces the stored scheduled transfers, writing through a temporary file like SaveAccounts.
+func (js *JSONFileStorage) SaveSchedules(schedules []ScheduledTransfer) error {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	if err := os.MkdirAll(js.dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(schedules, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := js.schedulesPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, js.schedulesPath())
+}
+
+// LoadSchedules reads the stored scheduled transfers. A missing file means none have been saved yet.
+func (js *JSONFileStorage) LoadSchedules() ([]ScheduledTransfer, error) {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	data, err := os.ReadFile(js.schedulesPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var schedules []ScheduledTransfer
+	if err := json.Unmarshal(data, &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// SaveUsage replaces the stored API usage, writing through a temporary file like SaveAccounts.
+func (js *JSONFileStorage) SaveUsage(usage []APIUsage) error {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	if err := os.MkdirAll(js.dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(usage, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := js.usagePath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, js.usagePath())
+}
+
+// LoadUsage reads the stored API usage. A missing file means none has been saved yet.
+func (js *JSONFileStorage) LoadUsage() ([]APIUsage, error) {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	data, err := os.ReadFile(js.usagePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var usage []APIUsage
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// SavePayees replaces the stored payee directory, writing through a temporary file like SaveAccounts.
+func (js *JSONFileStorage) SavePayees(payees []Payee) error {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	if err := os.MkdirAll(js.dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(payees, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := js.payeesPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, js.payeesPath())
+}
+
+// LoadPayees reads the stored payee directory. A missing file means none has been saved yet.
+func (js *JSONFileStorage) LoadPayees() ([]Payee, error) {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	data, err := os.ReadFile(js.payeesPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var payees []Payee
+	if err := json.Unmarshal(data, &payees); err != nil {
+		return nil, err
+	}
+	return payees, nil
+}
+
+// SaveMode records the bank's mode, writing through a temporary file like SaveAccounts.
+func (js *JSONFileStorage) SaveMode(mode Mode) error {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	if err := os.MkdirAll(js.dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(mode)
+	if err != nil {
+		return err
+	}
+	tmp := js.modePath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, js.modePath())
+}
+
+// LoadMode reads the bank's recorded mode. A missing file means none has been recorded.
+func (js *JSONFileStorage) LoadMode() (Mode, error) {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	data, err := os.ReadFile(js.modePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var mode Mode
+	if err := json.Unmarshal(data, &mode); err != nil {
+		return "", err
+	}
+	return mode, nil
+}
+
+// archivePath returns where a purged account's archive is kept.
+func (js *JSONFileStorage) archivePath(accountID string) string {
+	return filepath.Join(js.dir, "archive", url.PathEscape(accountID)+".json")
+}
+
+// ArchiveAccount keeps a purged account's archive in its own file, writing through a temporary file like
+// SaveAccounts.
+func (js *JSONFileStorage) ArchiveAccount(a AccountArchive) error {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	path := js.archivePath(a.Record.ID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadArchive reads a purged account's archive. A missing file means the account was never purged.
+func (js *JSONFileStorage) LoadArchive(accountID string) (AccountArchive, error) {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	data, err := os.ReadFile(js.archivePath(accountID))
+	if errors.Is(err, os.ErrNotExist) {
+		return AccountArchive{}, nil
+	}
+	if err != nil {
+		return AccountArchive{}, err
+	}
+	var a AccountArchive
+	if err := json.Unmarshal(data, &a); err != nil {
+		return AccountArchive{}, err
+	}
+	return a, nil
+}
+
+// DropTransactions rewrites the journal without the given history entries, through a temporary file so a crash
+// leaves either the old journal or the new one.
+func (js *JSONFileStorage) DropTransactions(txnIDs []string) error {
+	if len(txnIDs) == 0 {
+		return nil
+	}
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	drop := make(map[string]bool, len(txnIDs))
+	for _, id := range txnIDs {
+		drop[id] = true
+	}
+	data, err := os.ReadFile(js.transactionsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var kept []byte
+	for _, raw := range bytes.SplitAfter(data, []byte("\n")) {
+		var line transactionLine
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(raw, &line); err != nil {
+			return err
+		}
+		if !drop[line.ID] {
+			kept = append(append(kept, bytes.TrimSuffix(raw, []byte("\n"))...), '\n')
+		}
+	}
+	tmp := js.transactionsPath() + ".tmp"
+	if err := os.WriteFile(tmp, kept, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, js.transactionsPath())
+}
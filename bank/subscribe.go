@@ -0,0 +1,97 @@
+package bank
+
+import (
+	"slices"
+	"sync"
+)
+
+// EventHandler reacts to an event the bank has recorded, e.g. to notify a customer, run a fraud check or count a
+// metric. Handlers run one at a time on a goroutine of the bank's, in the order of the event log, once the change is
+// made and the bank's locks are released, so they may call back into the Bank. A slow handler delays the events
+// after it, but not the operations recording them.
+type EventHandler func(e Event)
+
+// eventSubscription is a handler and the events it is handed.
+type eventSubscription struct {
+	eventType EventType // "" means every event
+	handler   EventHandler
+}
+
+// eventDelivery is an event waiting to be handed to a handler.
+type eventDelivery struct {
+	handler EventHandler
+	event   Event
+}
+
+// eventBus holds the handlers subscribed to events and the events waiting to be handed to them.
+type eventBus struct {
+	subscriptions []*eventSubscription
+	queue         []eventDelivery
+	running       bool       // A goroutine is handing queued events to their handlers
+	idle          *sync.Cond // Signalled when the queue has been emptied; nil until FlushEvents first waits
+}
+
+// Subscribe hands every event of the given type recorded from now on to handler, such as each EventDeposited,
+// EventWithdrew, EventTransferred or EventAccountClosed; "" subscribes to every event. Events replayed by ReplayFrom
+// are not handed on, as they record changes made before. It returns a function that ends the subscription; events
+// recorded before it is called may still reach the handler.
+func (b *Bank) Subscribe(eventType EventType, handler EventHandler) (unsubscribe func()) {
+	sub := &eventSubscription{eventType: eventType, handler: handler}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.bus.subscriptions = append(b.bus.subscriptions, sub)
+	return func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		b.bus.subscriptions = slices.DeleteFunc(b.bus.subscriptions, func(s *eventSubscription) bool { return s == sub })
+	}
+}
+
+// FlushEvents waits until every event recorded so far has been handed to its handlers, e.g. before shutting down.
+// It must not be called from a handler.
+func (b *Bank) FlushEvents() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.bus.idle == nil {
+		b.bus.idle = sync.NewCond(b.mutex)
+	}
+	for b.bus.running {
+		b.bus.idle.Wait()
+	}
+}
+
+// publishEvent queues an event for the handlers subscribed to it, in the order they subscribed, and starts handing
+// queued events on if nothing is.
+// The caller must hold the bank mutex.
+func (b *Bank) publishEvent(e Event) {
+	for _, sub := range b.bus.subscriptions {
+		if sub.eventType == "" || sub.eventType == e.Type {
+			b.bus.queue = append(b.bus.queue, eventDelivery{handler: sub.handler, event: e})
+		}
+	}
+	if len(b.bus.queue) > 0 && !b.bus.running {
+		b.bus.running = true
+		go b.deliverEvents()
+	}
+}
+
+// deliverEvents hands queued events to their handlers, without the bank mutex, until the queue is empty.
+func (b *Bank) deliverEvents() {
+	for {
+		b.mutex.Lock()
+		batch := b.bus.queue
+		b.bus.queue = nil
+		if len(batch) == 0 {
+			b.bus.running = false
+			if b.bus.idle != nil {
+				b.bus.idle.Broadcast()
+			}
+			b.mutex.Unlock()
+			return
+		}
+		b.mutex.Unlock()
+		for _, d := range batch {
+			d.handler(d.event)
+		}
+	}
+}
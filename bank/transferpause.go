@@ -0,0 +1,359 @@
+package bank
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// PauseAction is what happens to outgoing transfers while they are paused.
+type PauseAction string
+
+const (
+	PauseReject PauseAction = "reject" // Paused transfers are declined; the default
+	PauseQueue  PauseAction = "queue"  // Paused transfers wait in a queue sent on once transfers resume
+)
+
+// TransferPause is the state of the bank-wide kill switch for outgoing transfers.
+type TransferPause struct {
+	Paused    bool          `json:"paused"`
+	Threshold account.Money `json:"thresholdMinor,omitempty"` // Only transfers over this are paused; 0 pauses every transfer
+	By        string        `json:"by,omitempty"`             // Admin who last paused or resumed transfers
+	Reason    string        `json:"reason,omitempty"`
+	Since     time.Time     `json:"since,omitzero"`
+}
+
+// covers reports whether the pause holds back a transfer of an amount.
+func (p TransferPause) covers(amount account.Money) bool {
+	return p.Paused && amount > p.Threshold
+}
+
+// TransferSwitch holds the kill switch for outgoing transfers, optionally kept in a JSON file so an admin can pause
+// transfers while the bank is running.
+type TransferSwitch struct {
+	path     string
+	modified time.Time
+	state    TransferPause
+	mutex    *sync.Mutex
+}
+
+// NewTransferSwitch returns an in-memory kill switch with transfers flowing.
+func NewTransferSwitch() *TransferSwitch {
+	return &TransferSwitch{mutex: &sync.Mutex{}}
+}
+
+// LoadTransferSwitch reads the kill switch at path. A missing file means transfers are flowing.
+func LoadTransferSwitch(path string) (*TransferSwitch, error) {
+	ts := &TransferSwitch{path: path, mutex: &sync.Mutex{}}
+	if err := ts.Reload(); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// Reload rereads the kill switch file if it has changed since it was last read.
+func (ts *TransferSwitch) Reload() error {
+	if ts.path == "" {
+		return nil
+	}
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	info, err := os.Stat(ts.path)
+	if errors.Is(err, os.ErrNotExist) {
+		ts.state, ts.modified = TransferPause{}, time.Time{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(ts.modified) {
+		return nil
+	}
+	data, err := os.ReadFile(ts.path)
+	if err != nil {
+		return err
+	}
+	var state TransferPause
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	ts.state, ts.modified = state, info.ModTime()
+	return nil
+}
+
+// State returns the kill switch's state.
+func (ts *TransferSwitch) State() TransferPause {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	return ts.state
+}
+
+// set changes the kill switch's state, writing it through a temporary file if it is kept in one.
+func (ts *TransferSwitch) set(state TransferPause) error {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	ts.state = state
+	if ts.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ts.path), 0o755); err != nil {
+		return err
+	}
+	tmp := ts.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, ts.path); err != nil {
+		return err
+	}
+	if info, err := os.Stat(ts.path); err == nil {
+		ts.modified = info.ModTime()
+	}
+	return nil
+}
+
+// SetTransferSwitch replaces the kill switch the bank consults before every outgoing transfer.
+func (b *Bank) SetTransferSwitch(ts *TransferSwitch) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.pauseSwitch = ts
+}
+
+// PauseTransfers stops outgoing transfers bank-wide at once, e.g. during an incident: every transfer, or only those
+// over threshold if it is positive. Depending on the configuration paused transfers are declined or queued until
+// ResumeTransfers. Only admins may pause transfers.
+func (b *Bank) PauseTransfers(staffID string, threshold account.Money, reason string) error {
+	if threshold < 0 {
+		return errors.New("pause threshold must not be negative")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.admins[staffID] {
+		return decline(ReasonNotAuthorized, "only admins may pause transfers")
+	}
+	state := TransferPause{Paused: true, Threshold: threshold, By: staffID, Reason: reason, Since: b.now()}
+	if err := b.pauseSwitch.set(state); err != nil {
+		return err
+	}
+	detail := "all transfers"
+	if threshold > 0 {
+		detail = "transfers over " + threshold.String()
+	}
+	if reason != "" {
+		detail += ": " + reason
+	}
+	b.auditAction(staffID, "PauseTransfers", "", "", detail)
+	return nil
+}
+
+// ResumeTransfers lets outgoing transfers flow again. Queued transfers are sent by ReleaseQueuedTransfers. Only
+// admins may resume transfers.
+func (b *Bank) ResumeTransfers(staffID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.admins[staffID] {
+		return decline(ReasonNotAuthorized, "only admins may resume transfers")
+	}
+	if err := b.pauseSwitch.set(TransferPause{By: staffID, Since: b.now()}); err != nil {
+		return err
+	}
+	b.auditAction(staffID, "ResumeTransfers", "", "", "")
+	return nil
+}
+
+// TransferPauseState returns whether outgoing transfers are paused, picking up a change an admin made from another
+// process.
+func (b *Bank) TransferPauseState() (TransferPause, error) {
+	b.mutex.Lock()
+	ts := b.pauseSwitch
+	b.mutex.Unlock()
+	err := ts.Reload()
+	return ts.State(), err
+}
+
+// QueuedTransferStatus tracks a transfer queued while transfers were paused.
+type QueuedTransferStatus string
+
+const (
+	QueuedWaiting QueuedTransferStatus = "queued"
+	QueuedSent    QueuedTransferStatus = "sent"
+	QueuedFailed  QueuedTransferStatus = "failed" // Rejected when released, e.g. for lack of funds
+)
+
+// QueuedTransfer is a transfer held back by the kill switch until transfers resume. Queued transfers are derived
+// from the transaction history, so they survive restarts.
+type QueuedTransfer struct {
+	ID            string // The queued transaction
+	FromID        string
+	ToID          string
+	Amount        account.Money
+	Country       string
+	QueuedAt      time.Time
+	Status        QueuedTransferStatus
+	TransactionID string // The transfer sent on release
+	Err           string // Why the transfer failed on release
+	TravelRule    *TravelRuleData
+}
+
+// queuedTransfer picks a queued transfer out of a transaction history entry, reporting false if the entry is not one.
+func queuedTransfer(txnID, entry string) (QueuedTransfer, bool) {
+	fields := make(map[string]string)
+	for _, field := range historyFields(entry) {
+		fields[field[0]] = field[1]
+	}
+	if fields["Reason Code"] != string(ReasonTransfersPaused) || fields["Queued"] != "yes" {
+		return QueuedTransfer{}, false
+	}
+	h := parseHistoryEntry(txnID, entry)
+	q := QueuedTransfer{
+		ID:            txnID,
+		FromID:        h.From,
+		ToID:          h.To,
+		Amount:        h.Amount,
+		Country:       fields["Country"],
+		QueuedAt:      h.Recorded,
+		Status:        QueuedWaiting,
+		TransactionID: fields["Released Transaction"],
+		Err:           fields["Release Error"],
+		TravelRule:    travelRuleFromFields(fields),
+	}
+	if release, exists := fields["Queue Release"]; exists {
+		q.Status = QueuedTransferStatus(release)
+	}
+	return q, true
+}
+
+// pausedFor reports whether the kill switch covers an outgoing transfer of the amount.
+// The caller must not hold the bank mutex.
+func (b *Bank) pausedFor(amount account.Money) (bool, error) {
+	b.mutex.Lock()
+	ts := b.pauseSwitch
+	b.mutex.Unlock()
+	// Picks up an admin pausing transfers from bankadmin
+	if err := ts.Reload(); err != nil {
+		return false, err
+	}
+	return ts.State().covers(amount), nil
+}
+
+// checkSplitPause declines a split transfer of the given total while the kill switch covers it. Splits are never
+// queued, since a queued transfer has a single destination.
+// The caller must not hold the bank mutex.
+func (b *Bank) checkSplitPause(total account.Money) error {
+	if paused, err := b.pausedFor(total); err != nil || !paused {
+		return err
+	}
+	return decline(ReasonTransfersPaused, "outgoing transfers are paused; try again later")
+}
+
+// checkTransferPause holds back an outgoing transfer while the kill switch covers it, declining it or queueing it
+// as configured. Scheduled transfers are always declined, since the scheduler retries them itself, and retrying a
+// transfer that is already queued does not queue it again.
+// The caller must not hold the bank mutex.
+func (b *Bank) checkTransferPause(t PendingTransfer) error {
+	if paused, err := b.pausedFor(t.Amount); err != nil || !paused {
+		return err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.config == nil || b.config.paused != PauseQueue || b.channelOf(t.FromID, t.ToID) == ChannelScheduler {
+		return decline(ReasonTransfersPaused, "outgoing transfers are paused; try again later")
+	}
+	queueID := ""
+	for id, entry := range b.transactionHist {
+		q, queued := queuedTransfer(id, entry)
+		if queued && q.Status == QueuedWaiting && q.FromID == t.FromID && q.ToID == t.ToID && q.Amount == t.Amount && q.Country == t.Country {
+			queueID = id
+			break
+		}
+	}
+	if queueID == "" {
+		queueID = b.newTxnID()
+		entry := fmt.Sprintf("Transaction ID: %s, From: %s, To: %s, Amount: %s", queueID, t.FromID, t.ToID, t.Amount)
+		if t.Country != "" {
+			entry += ", Country: " + t.Country
+		}
+		b.recordTransaction(queueID, entry+fmt.Sprintf(", Queued: yes, Status: %s, Reason Code: %s\n", "failed", ReasonTransfersPaused))
+		b.recordTravelRule(queueID, t.TravelRule)
+	}
+	return decline(ReasonTransfersPaused, "outgoing transfers are paused; queued as "+queueID+" to be sent once they resume")
+}
+
+// QueuedTransfers lists transfers queued while transfers were paused with the given status, or all of them for "",
+// oldest first.
+func (b *Bank) QueuedTransfers(status QueuedTransferStatus) []QueuedTransfer {
+	b.mutex.Lock()
+	var list []QueuedTransfer
+	for id, entry := range b.transactionHist {
+		if q, queued := queuedTransfer(id, entry); queued && (status == "" || q.Status == status) {
+			list = append(list, q)
+		}
+	}
+	b.mutex.Unlock()
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].QueuedAt.Equal(list[j].QueuedAt) {
+			return list[i].QueuedAt.Before(list[j].QueuedAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// ReleaseQueuedTransfers sends the queued transfers the kill switch no longer covers, oldest first, and returns them
+// with the outcome. Each transfer is marked released before it is sent, so an interrupted run picks up where it left
+// off without sending any transfer twice. Transfers that fail on release are not retried.
+func (b *Bank) ReleaseQueuedTransfers() []QueuedTransfer {
+	pause, err := b.TransferPauseState()
+	if err != nil {
+		return nil
+	}
+	var released []QueuedTransfer
+	for _, q := range b.QueuedTransfers(QueuedWaiting) {
+		if pause.covers(q.Amount) {
+			continue
+		}
+		b.mutex.Lock()
+		entry := b.transactionHist[q.ID]
+		if current, queued := queuedTransfer(q.ID, entry); !queued || current.Status != QueuedWaiting {
+			b.mutex.Unlock()
+			continue
+		}
+		b.annotateTransaction(q.ID, "Queue Release: "+string(QueuedSent))
+		b.mutex.Unlock()
+
+		txnID, err := b.deliverTransfer(PendingTransfer{FromID: q.FromID, ToID: q.ToID, Amount: q.Amount, Country: q.Country, TravelRule: q.TravelRule})
+
+		b.mutex.Lock()
+		if err != nil {
+			q.Status, q.Err = QueuedFailed, strings.ReplaceAll(err.Error(), "\n", " ")
+			b.recordTransaction(q.ID, entry)
+			b.annotateTransaction(q.ID, fmt.Sprintf("Queue Release: %s, Release Error: %s", q.Status, q.Err))
+			b.auditAction(AuditActorSystem, "ReleaseQueuedTransfer", q.FromID, q.ID, "failed: "+q.Err)
+		} else {
+			q.Status, q.TransactionID = QueuedSent, txnID
+			b.annotateTransaction(q.ID, "Released Transaction: "+txnID)
+			note := "Released From Queue: " + q.ID
+			if q.Country != "" {
+				note = "Country: " + q.Country + ", " + note
+			}
+			b.annotateTransaction(txnID, note)
+			b.auditAction(AuditActorSystem, "ReleaseQueuedTransfer", q.FromID, txnID, q.ID)
+		}
+		b.mutex.Unlock()
+		released = append(released, q)
+	}
+	return released
+}
@@ -0,0 +1,157 @@
+package bank
+
+import (
+	"fmt"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// PendingTransfer is a transfer about to be executed, as transfer rules see it.
+type PendingTransfer struct {
+	FromID     string
+	ToID       string
+	Amount     account.Money
+	Fees       account.Money   // Fees the transfer will be charged; zero for corrections and internal movements
+	FromState  account.State   // Lifecycle state of the source account, "" if it does not exist
+	ToState    account.State   // Lifecycle state of the destination account, "" if it does not exist
+	TravelRule *TravelRuleData // Originator and beneficiary details; required over the travel rule threshold
+	Channel    Channel         // Where the transfer came from
+	Country    string          // Destination country of a cross-border transfer, "" if domestic
+	CoSigned   bool            // Every owner of a joint source account approved it; see RequestJointDebit
+}
+
+// TransferRule is one stage of the transfer validation pipeline. A rule rejects a transfer by returning an error.
+// Rules run while the bank mutex and both accounts' locks are held, so they must not call back into the Bank.
+type TransferRule interface {
+	Check(t PendingTransfer) error
+}
+
+// TransferRuleFunc adapts a function to a TransferRule.
+type TransferRuleFunc func(t PendingTransfer) error
+
+// Check calls f.
+func (f TransferRuleFunc) Check(t PendingTransfer) error {
+	return f(t)
+}
+
+// SetTransferRules replaces the extra rules customer transfers must pass, in order. They run after the bank's own
+// checks of existence, account state, amount, funds on hold, amount policy, sanctions holds, travel rule details,
+// joint account signing rules, payees, limits, configured decline rules and funds.
+func (b *Bank) SetTransferRules(rules ...TransferRule) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.transferRules = append([]TransferRule(nil), rules...)
+}
+
+// coreTransferRules returns the rules checked before every movement of funds between accounts, including
+// corrections and sweeps.
+// The caller must hold the bank mutex.
+func (b *Bank) coreTransferRules() []TransferRule {
+	return []TransferRule{
+		TransferRuleFunc(checkTransferAccountsExist),
+		TransferRuleFunc(checkTransferStates),
+		TransferRuleFunc(checkTransferAmount),
+		TransferRuleFunc(b.checkTransferHolds),
+	}
+}
+
+// customerTransferRules returns the rules checked, after the core rules, before a customer transfer.
+// The caller must hold the bank mutex.
+func (b *Bank) customerTransferRules() []TransferRule {
+	rules := []TransferRule{
+		TransferRuleFunc(b.checkTransferPolicy),
+		TransferRuleFunc(b.checkTransferSanctions),
+		TransferRuleFunc(b.checkTravelRule),
+		TransferRuleFunc(b.checkTransferSignatures),
+		TransferRuleFunc(b.checkTransferPayee),
+		TransferRuleFunc(b.checkTransferLimits),
+		TransferRuleFunc(b.checkTransferExpressions),
+		TransferRuleFunc(b.checkTransferFunds),
+	}
+	return append(rules, b.transferRules...)
+}
+
+// validateTransfer runs a transfer through the core rules and then the given rules, returning the first rejection.
+// Rejections from rules that give no reason code are declined with ReasonRuleDeclined.
+// The caller must hold both accounts' locks and the bank mutex.
+func (b *Bank) validateTransfer(t PendingTransfer, rules []TransferRule) error {
+	t.FromState, t.ToState = b.accounts.state(t.FromID), b.accounts.state(t.ToID)
+	t.Channel = b.channelOf(t.FromID, t.ToID)
+	for _, stages := range [][]TransferRule{b.coreTransferRules(), rules} {
+		for _, rule := range stages {
+			if err := rule.Check(t); err != nil {
+				if ReasonOf(err) == ReasonOther {
+					err = &DeclineError{Code: ReasonRuleDeclined, Message: err.Error(), Err: err}
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkTransferAccountsExist rejects transfers from or to accounts the bank does not hold.
+func checkTransferAccountsExist(t PendingTransfer) error {
+	if t.FromState == "" {
+		return decline(ReasonAccountNotFound, "source account does not exist")
+	}
+	if t.ToState == "" {
+		return decline(ReasonAccountNotFound, "destination account does not exist")
+	}
+	return nil
+}
+
+// checkTransferStates rejects transfers the lifecycle state of either account does not allow, such as out of a
+// frozen account or into a closed one.
+func checkTransferStates(t PendingTransfer) error {
+	if !t.FromState.Allows(account.OperationTransferOut) {
+		return decline(ReasonAccountState, fmt.Sprintf("account %s is %s: %s not allowed", t.FromID, t.FromState, account.OperationTransferOut))
+	}
+	if !t.ToState.Allows(account.OperationTransferIn) {
+		return decline(ReasonAccountState, fmt.Sprintf("account %s is %s: %s not allowed", t.ToID, t.ToState, account.OperationTransferIn))
+	}
+	return nil
+}
+
+// checkTransferAmount rejects transfers of nothing, of negative amounts and from an account to itself.
+func checkTransferAmount(t PendingTransfer) error {
+	if t.Amount <= 0 {
+		return decline(ReasonInvalidAmount, "transfer amount must be positive")
+	}
+	if t.FromID == t.ToID {
+		return decline(ReasonSameAccount, "cannot transfer an account's funds to itself")
+	}
+	return nil
+}
+
+// checkTransferHolds rejects transfers that would spend funds on hold on the source account.
+// The caller must hold the bank mutex.
+func (b *Bank) checkTransferHolds(t PendingTransfer) error {
+	if available := b.available(t.FromID, nil); available < t.Amount && available < account.Spendable(b.accounts.at(t.FromID)) {
+		return decline(ReasonInsufficientFunds, "insufficient available funds: some are on hold")
+	}
+	return nil
+}
+
+// checkTransferLimits rejects transfers over the configured single-transfer limit for their channel or the source's
+// daily limit.
+// The caller must hold the bank mutex.
+func (b *Bank) checkTransferLimits(t PendingTransfer) error {
+	if err := b.checkLimit(t.FromID, t.Channel, transaction.OpTransfer, t.Amount); err != nil {
+		return err
+	}
+	return b.checkDailyLimit(t.FromID, transaction.OpTransfer, t.Amount)
+}
+
+// checkTransferFunds rejects transfers whose fees the source cannot cover on top of the amount and transfers that
+// would take the source below its minimum balance; funds on hold are left to checkTransferHolds. Otherwise the source
+// account itself decides whether it has the funds.
+// The caller must hold the source account's lock and the bank mutex.
+func (b *Bank) checkTransferFunds(t PendingTransfer) error {
+	available := b.available(t.FromID, nil)
+	if t.Fees > 0 && available < t.Amount+t.Fees {
+		return decline(ReasonInsufficientFunds, "insufficient funds to cover amount and fees")
+	}
+	return b.checkMinimumBalance(t.FromID, t.Amount+t.Fees)
+}
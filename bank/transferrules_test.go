@@ -0,0 +1,90 @@
+package bank
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+func TestTransferRules(t *testing.T) {
+	capAt500 := TransferRuleFunc(func(t PendingTransfer) error {
+		if t.Amount > 500 {
+			return errors.New("transfers over 5.00 are not allowed")
+		}
+		return nil
+	})
+	tests := []struct {
+		name     string
+		setup    func(b *Bank)
+		from, to string
+		amount   account.Money
+		want     ReasonCode // "" for a transfer that goes through
+	}{
+		{name: "transfer goes through", from: "A", to: "B", amount: 400},
+		{name: "unknown source", from: "X", to: "B", amount: 400, want: ReasonAccountNotFound},
+		{name: "unknown destination", from: "A", to: "X", amount: 400, want: ReasonAccountNotFound},
+		{name: "zero amount", from: "A", to: "B", amount: 0, want: ReasonInvalidAmount},
+		{name: "negative amount", from: "A", to: "B", amount: -100, want: ReasonInvalidAmount},
+		{name: "same account", from: "A", to: "A", amount: 400, want: ReasonSameAccount},
+		{
+			name:  "frozen source",
+			setup: func(b *Bank) { b.Freeze("A") },
+			from:  "A", to: "B", amount: 400, want: ReasonAccountState,
+		},
+		{
+			name:  "closed destination",
+			setup: func(b *Bank) { b.Close("B") },
+			from:  "A", to: "B", amount: 400, want: ReasonAccountState,
+		},
+		{name: "insufficient funds", from: "A", to: "B", amount: 1001, want: ReasonInsufficientFunds},
+		{
+			name:  "extra rule passes",
+			setup: func(b *Bank) { b.SetTransferRules(capAt500) },
+			from:  "A", to: "B", amount: 500,
+		},
+		{
+			name:  "extra rule without a reason code declines as a rule",
+			setup: func(b *Bank) { b.SetTransferRules(capAt500) },
+			from:  "A", to: "B", amount: 501, want: ReasonRuleDeclined,
+		},
+		{
+			name:  "core rules run before extra rules",
+			setup: func(b *Bank) { b.SetTransferRules(capAt500) },
+			from:  "A", to: "A", amount: 501, want: ReasonSameAccount,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBank(t, 1000, 0)
+			if tt.setup != nil {
+				tt.setup(b)
+			}
+			_, err := b.transfer(tt.from, tt.to, tt.amount)
+			if got := ReasonOf(err); got != tt.want {
+				t.Fatalf("transfer declined with %q (%v), want %q", got, err, tt.want)
+			}
+			wantA, wantB := account.Money(1000), account.Money(0)
+			if err == nil {
+				wantA, wantB = wantA-tt.amount, wantB+tt.amount
+			}
+			if gotA, gotB := balanceOf(t, b, "A"), balanceOf(t, b, "B"); gotA != wantA || gotB != wantB {
+				t.Errorf("balances A %s, B %s; want %s, %s", gotA, gotB, wantA, wantB)
+			}
+		})
+	}
+}
+
+func TestInternalMovesSkipCustomerRules(t *testing.T) {
+	b, _ := newTestBank(t, 1000, 0)
+	b.SetTransferRules(TransferRuleFunc(func(PendingTransfer) error { return errors.New("no transfers") }))
+	if _, err := b.transfer("A", "B", 100); ReasonOf(err) != ReasonRuleDeclined {
+		t.Fatalf("customer transfer: %v, want it declined by the rule", err)
+	}
+	if _, err := b.moveFunds("A", "B", 100); err != nil {
+		t.Fatalf("internal move: %v, want it to skip customer rules", err)
+	}
+	if _, err := b.moveFunds("A", "A", 100); ReasonOf(err) != ReasonSameAccount {
+		t.Errorf("internal move to the same account: %v, want it declined by the core rules", err)
+	}
+}
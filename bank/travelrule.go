@@ -0,0 +1,126 @@
+package bank
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// TravelRuleParty identifies the originator or beneficiary of a transfer.
+type TravelRuleParty struct {
+	Name      string `json:"name"`
+	Address   string `json:"address,omitempty"`
+	AccountID string `json:"accountId"`
+}
+
+// TravelRuleData is the originator and beneficiary information a transfer over the travel rule threshold must carry.
+// It is stored in the transaction history and on the transfer's event, so it goes out with the event log and
+// webhook deliveries.
+type TravelRuleData struct {
+	Originator  TravelRuleParty `json:"originator"`
+	Beneficiary TravelRuleParty `json:"beneficiary"`
+}
+
+// forTransfer returns a copy of the details with blank accounts filled in from the transfer's source and
+// destination, or nil if there are no details.
+func (d *TravelRuleData) forTransfer(fromID, toID string) *TravelRuleData {
+	if d == nil {
+		return nil
+	}
+	filled := *d
+	if filled.Originator.AccountID == "" {
+		filled.Originator.AccountID = fromID
+	}
+	if filled.Beneficiary.AccountID == "" {
+		filled.Beneficiary.AccountID = toID
+	}
+	return &filled
+}
+
+// missing lists the details a transfer between two accounts lacks: the originator's name, address and account and
+// the beneficiary's name and account, which must be the transfer's own.
+func (d *TravelRuleData) missing(fromID, toID string) []string {
+	if d == nil {
+		return []string{"originator and beneficiary details"}
+	}
+	var missing []string
+	for _, f := range []struct{ name, value string }{
+		{"originator name", d.Originator.Name},
+		{"originator address", d.Originator.Address},
+		{"beneficiary name", d.Beneficiary.Name},
+	} {
+		if strings.TrimSpace(f.value) == "" {
+			missing = append(missing, f.name)
+		}
+	}
+	if d.Originator.AccountID != fromID {
+		missing = append(missing, "originator account "+fromID)
+	}
+	if d.Beneficiary.AccountID != toID {
+		missing = append(missing, "beneficiary account "+toID)
+	}
+	return missing
+}
+
+// historyNote writes the details as transaction history fields.
+func (d *TravelRuleData) historyNote() string {
+	note := fmt.Sprintf("Originator: %s, Originator Address: %s, Originator Account: %s, Beneficiary: %s, Beneficiary Account: %s",
+		d.Originator.Name, d.Originator.Address, d.Originator.AccountID, d.Beneficiary.Name, d.Beneficiary.AccountID)
+	if d.Beneficiary.Address != "" {
+		note += ", Beneficiary Address: " + d.Beneficiary.Address
+	}
+	return note
+}
+
+// travelRuleFromFields reads the details back out of a history entry's fields, or returns nil if it has none.
+func travelRuleFromFields(fields map[string]string) *TravelRuleData {
+	name, exists := fields["Originator"]
+	if !exists {
+		return nil
+	}
+	return &TravelRuleData{
+		Originator:  TravelRuleParty{Name: name, Address: fields["Originator Address"], AccountID: fields["Originator Account"]},
+		Beneficiary: TravelRuleParty{Name: fields["Beneficiary"], Address: fields["Beneficiary Address"], AccountID: fields["Beneficiary Account"]},
+	}
+}
+
+// TravelRuleRequired reports whether a transfer of amount must carry originator and beneficiary details.
+func (b *Bank) TravelRuleRequired(amount account.Money) bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.config != nil && b.config.travelRule > 0 && amount > b.config.travelRule
+}
+
+// checkTravelRule rejects transfers over the travel rule threshold whose originator or beneficiary details are
+// incomplete.
+// The caller must hold the bank mutex.
+func (b *Bank) checkTravelRule(t PendingTransfer) error {
+	if b.config == nil || b.config.travelRule == 0 || t.Amount <= b.config.travelRule {
+		return nil
+	}
+	if missing := t.TravelRule.missing(t.FromID, t.ToID); len(missing) > 0 {
+		return decline(ReasonTravelRule, fmt.Sprintf("transfers over %s need travel rule details; missing %s", b.config.travelRule, strings.Join(missing, ", ")))
+	}
+	return nil
+}
+
+// recordTravelRule adds a transfer's travel rule details to its history entry.
+// The caller must hold the bank mutex.
+func (b *Bank) recordTravelRule(txnID string, d *TravelRuleData) {
+	if d != nil {
+		b.annotateTransaction(txnID, d.historyNote())
+	}
+}
+
+// TravelRuleOf returns the travel rule details a transfer carried.
+func (b *Bank) TravelRuleOf(txnID string) (TravelRuleData, bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for i := len(b.events) - 1; i >= 0; i-- {
+		if e := b.events[i]; e.Type == EventTransferred && e.TransactionID == txnID && e.TravelRule != nil {
+			return *e.TravelRule, true
+		}
+	}
+	return TravelRuleData{}, false
+}
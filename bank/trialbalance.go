@@ -0,0 +1,236 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// GL accounts the trial balance posts the other side of every change to customer balances to, worked out from the
+// event log.
+const (
+	GLOpeningBalances = "GL-OPENING"  // Balances accounts were opened or migrated with
+	GLCash            = "GL-CASH"     // Deposits and withdrawals
+	GLFeeIncome       = "GL-FEES"     // Fees charged
+	GLInterest        = "GL-INTEREST" // Interest credited less interest charged, penalties and other recalculations
+	GLSuspense        = "GL-SUSPENSE" // Transfers that debited their source and await compensation
+	GLForeignExchange = "GL-FX"       // The two currencies of transfers between currencies
+)
+
+// TrialBalanceLine is one account's balance in one currency, on the debit or the credit side. Customer balances
+// are owed to customers, so money in an account is a credit and an overdrawn or lent balance a debit.
+type TrialBalanceLine struct {
+	AccountID string // A customer account, or one of the GL accounts
+	GL        bool
+	Currency  string
+	Debit     account.Money
+	Credit    account.Money
+}
+
+// TrialBalanceTotal is the sum of each side of a trial balance in one currency.
+type TrialBalanceTotal struct {
+	Currency string
+	Debits   account.Money
+	Credits  account.Money
+}
+
+// TrialBalance lists the balances of the customer accounts as they stand against the GL accounts as the event log
+// builds them, and what the ledger check found besides.
+type TrialBalance struct {
+	At           time.Time
+	Lines        []TrialBalanceLine  // Customer accounts by ID, then GL accounts by name; zero balances are left out
+	Totals       []TrialBalanceTotal // By currency
+	Orphaned     []string            // Successful transactions naming accounts the bank neither holds nor purged
+	Inconsistent []string            // What the bank keeps about accounts it does not hold; see OrphanedEntries
+}
+
+// Balanced reports whether debits equal credits in every currency.
+func (tb TrialBalance) Balanced() bool {
+	for _, t := range tb.Totals {
+		if t.Debits != t.Credits {
+			return false
+		}
+	}
+	return true
+}
+
+// glKey identifies a GL account's balance in one currency.
+type glKey struct {
+	account  string
+	currency string
+}
+
+// TrialBalance produces a trial balance of every customer and GL account and checks the ledger: debits must equal
+// credits in every currency, every successful transaction must name accounts the bank holds or has purged, and
+// what the bank keeps per account must be about accounts it holds. It returns the trial balance with an error
+// describing whatever failed. Every account is locked for the duration, so the check sees a consistent picture.
+func (b *Bank) TrialBalance() (TrialBalance, error) {
+	b.mutex.Lock()
+	ids := make([]string, 0, b.accounts.len())
+	for id := range b.accounts.all() {
+		ids = append(ids, id)
+	}
+	b.mutex.Unlock()
+	unlock := b.lockAccounts(ids...)
+	defer unlock()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	tb := TrialBalance{At: b.now()}
+	totals := make(map[string]*TrialBalanceTotal)
+	add := func(line TrialBalanceLine) {
+		if line.Debit == 0 && line.Credit == 0 {
+			return
+		}
+		tb.Lines = append(tb.Lines, line)
+		t, exists := totals[line.Currency]
+		if !exists {
+			t = &TrialBalanceTotal{Currency: line.Currency}
+			totals[line.Currency] = t
+		}
+		t.Debits += line.Debit
+		t.Credits += line.Credit
+	}
+
+	sort.Strings(ids)
+	for _, id := range ids {
+		line := TrialBalanceLine{AccountID: id, Currency: b.currencyOf(id)}
+		if balance := b.accounts.at(id).Balance(); balance >= 0 {
+			line.Credit = balance
+		} else {
+			line.Debit = -balance
+		}
+		add(line)
+	}
+	gl, purged := b.generalLedger()
+	keys := slices.Collect(maps.Keys(gl))
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].account != keys[j].account {
+			return keys[i].account < keys[j].account
+		}
+		return keys[i].currency < keys[j].currency
+	})
+	for _, k := range keys {
+		line := TrialBalanceLine{AccountID: k.account, GL: true, Currency: k.currency}
+		if net := gl[k]; net >= 0 {
+			line.Debit = net
+		} else {
+			line.Credit = -net
+		}
+		add(line)
+	}
+	for _, t := range totals {
+		tb.Totals = append(tb.Totals, *t)
+	}
+	sort.Slice(tb.Totals, func(i, j int) bool { return tb.Totals[i].Currency < tb.Totals[j].Currency })
+
+	tb.Orphaned = b.orphanedTransactions(purged)
+	for _, o := range b.orphanedEntries() {
+		tb.Inconsistent = append(tb.Inconsistent, o.String())
+	}
+
+	var problems []string
+	for _, t := range tb.Totals {
+		if t.Debits != t.Credits {
+			problems = append(problems, fmt.Sprintf("%s debits %s, credits %s", t.Currency, t.Debits, t.Credits))
+		}
+	}
+	if len(tb.Orphaned) > 0 {
+		problems = append(problems, fmt.Sprintf("%d orphaned transactions", len(tb.Orphaned)))
+	}
+	problems = append(problems, tb.Inconsistent...)
+	if len(problems) > 0 {
+		return tb, errors.New("ledger check failed: " + strings.Join(problems, "; "))
+	}
+	return tb, nil
+}
+
+// generalLedger posts the other side of every balance change in the event log to a GL account, returning each GL
+// account's net debit by currency and the accounts that were purged. Transfers between customer accounts need no
+// GL account unless they change currency.
+// The caller must hold the bank mutex.
+func (b *Bank) generalLedger() (map[glKey]account.Money, map[string]bool) {
+	gl := make(map[glKey]account.Money)
+	balances := make(map[string]account.Money)
+	currencies := make(map[string]string) // Purged accounts are no longer in accountCurrency
+	purged := make(map[string]bool)
+	currency := func(id string) string {
+		c, exists := currencies[id]
+		if !exists {
+			c = b.currencyOf(id)
+			currencies[id] = c
+		}
+		return c
+	}
+	post := func(glAccount, accountID string, change account.Money) {
+		balances[accountID] += change
+		gl[glKey{glAccount, currency(accountID)}] += change
+	}
+	for _, e := range b.events {
+		switch e.Type {
+		case EventAccountCreated:
+			if e.Record != nil {
+				post(GLOpeningBalances, e.AccountID, e.Record.Balance)
+			}
+		case EventDeposited:
+			post(GLCash, e.AccountID, e.Amount)
+		case EventWithdrew:
+			post(GLCash, e.AccountID, -e.Amount)
+		case EventFeeCharged:
+			post(GLFeeIncome, e.AccountID, -e.Amount)
+		case EventCompensationPending:
+			post(GLSuspense, e.AccountID, -e.Amount)
+		case EventCompensationResolved:
+			post(GLSuspense, e.AccountID, e.Amount)
+		case EventInterestPosted, EventAccountUpdated, EventAccountConverted:
+			// These carry the whole account, so the change is whatever moved its balance since the last event
+			if e.Record != nil {
+				post(GLInterest, e.AccountID, e.Record.Balance-balances[e.AccountID])
+			}
+		case EventTransferred, EventTaxWithheld:
+			credited := e.Amount
+			if e.ToAmount != 0 {
+				credited = e.ToAmount
+			}
+			balances[e.AccountID] -= e.Amount
+			balances[e.ToID] += credited
+			if from, to := currency(e.AccountID), currency(e.ToID); from != to {
+				gl[glKey{GLForeignExchange, from}] -= e.Amount
+				gl[glKey{GLForeignExchange, to}] += credited
+			}
+		case EventAccountPurged:
+			purged[e.AccountID] = true
+		}
+	}
+	return gl, purged
+}
+
+// orphanedTransactions lists the successful transactions whose history names an account the bank neither holds nor
+// purged. Declined operations are left out, since they may name accounts that never existed.
+// The caller must hold the bank mutex.
+func (b *Bank) orphanedTransactions(purged map[string]bool) []string {
+	var orphaned []string
+	for txnID, entry := range b.transactionHist {
+		h := parseHistoryEntry(txnID, entry)
+		if h.Status == "failed" {
+			continue
+		}
+		for _, id := range []string{h.From, h.To, h.Account} {
+			if id == "" || strings.HasSuffix(id, " recipients") || purged[id] {
+				continue
+			}
+			if _, exists := b.accounts.get(id); !exists {
+				orphaned = append(orphaned, txnID)
+				break
+			}
+		}
+	}
+	sort.Strings(orphaned)
+	return orphaned
+}
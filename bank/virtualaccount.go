@@ -0,0 +1,193 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// VirtualAccount is an account number that can be handed to a payer but settles into a physical account.
+type VirtualAccount struct {
+	Number     string
+	PhysicalID string // Account that receives the funds
+	Label      string // Business reference, e.g. the customer or invoice the number was issued for
+	CreatedAt  time.Time
+	Active     bool
+}
+
+// VirtualCredit is one payment received through a virtual account number.
+type VirtualCredit struct {
+	TransactionID string
+	Number        string
+	PhysicalID    string
+	Amount        account.Money
+	PayerRef      string // Payer's reference, if supplied
+	ReceivedAt    time.Time
+}
+
+// VirtualReconciliation summarises the credits received through one virtual account number.
+type VirtualReconciliation struct {
+	Number string
+	Label  string
+	Count  int
+	Total  account.Money
+}
+
+// generateVirtualAccountNumber generates a random virtual account number string.
+func generateVirtualAccountNumber() string {
+	return "VA" + strconv.Itoa(10000000+rand.Intn(90000000))
+}
+
+// IssueVirtualAccount creates a new virtual account number settling into the physical account.
+func (b *Bank) IssueVirtualAccount(physicalID, label string) (VirtualAccount, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err := b.checkOperation(physicalID, account.OperationTransferIn); err != nil {
+		return VirtualAccount{}, err
+	}
+	number := generateVirtualAccountNumber()
+	for {
+		if _, taken := b.virtualAccounts[number]; !taken {
+			if _, clash := b.accounts.get(number); !clash {
+				break
+			}
+		}
+		number = generateVirtualAccountNumber()
+	}
+	va := &VirtualAccount{Number: number, PhysicalID: physicalID, Label: label, CreatedAt: b.now(), Active: true}
+	b.virtualAccounts[number] = va
+	return *va, nil
+}
+
+// CloseVirtualAccount stops a virtual account number from accepting further credits. Past credits are kept.
+func (b *Bank) CloseVirtualAccount(number string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	va, exists := b.virtualAccounts[number]
+	if !exists {
+		return errors.New("virtual account does not exist")
+	}
+	va.Active = false
+	return nil
+}
+
+// VirtualAccounts lists the virtual account numbers issued for a physical account.
+func (b *Bank) VirtualAccounts(physicalID string) []VirtualAccount {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	var list []VirtualAccount
+	for _, va := range b.virtualAccounts {
+		if va.PhysicalID == physicalID {
+			list = append(list, *va)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Number < list[j].Number })
+	return list
+}
+
+// activeVirtualAccount returns the physical account behind an active virtual number.
+// The caller must hold the bank mutex.
+func (b *Bank) activeVirtualAccount(number string) (*VirtualAccount, error) {
+	va, exists := b.virtualAccounts[number]
+	if !exists {
+		return nil, errors.New("virtual account does not exist")
+	}
+	if !va.Active {
+		return nil, errors.New("virtual account is closed")
+	}
+	return va, nil
+}
+
+// recordVirtualCredit keeps the virtual number against a credit that has settled.
+// The caller must hold the bank mutex.
+func (b *Bank) recordVirtualCredit(txnID string, va *VirtualAccount, amount account.Money, payerRef string) {
+	b.virtualCredits = append(b.virtualCredits, VirtualCredit{
+		TransactionID: txnID,
+		Number:        va.Number,
+		PhysicalID:    va.PhysicalID,
+		Amount:        amount,
+		PayerRef:      payerRef,
+		ReceivedAt:    b.now(),
+	})
+}
+
+// CreditVirtualAccount deposits an incoming payment addressed to a virtual account number into its physical account.
+func (b *Bank) CreditVirtualAccount(number string, amount account.Money, payerRef string) (string, error) {
+	b.mutex.Lock()
+	va, err := b.activeVirtualAccount(number)
+	b.mutex.Unlock()
+	if err != nil {
+		return "", err
+	}
+	if err := b.DepositVia(va.PhysicalID, amount, RailACH); err != nil {
+		return "", err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	txnID := b.newTxnID()
+	b.recordTransaction(txnID, fmt.Sprintf("Transaction ID: %s, Virtual Account: %s, To: %s, Amount: %s, Payer: %s, Status: %s\n", txnID, number, va.PhysicalID, amount, payerRef, "success"))
+	b.recordVirtualCredit(txnID, va, amount, payerRef)
+	return txnID, nil
+}
+
+// TransferToVirtualAccount transfers funds from an account held at this bank to a virtual account number.
+func (b *Bank) TransferToVirtualAccount(fromID, number string, amount account.Money) (string, error) {
+	b.mutex.Lock()
+	va, err := b.activeVirtualAccount(number)
+	b.mutex.Unlock()
+	if err != nil {
+		return "", err
+	}
+	txnID, err := b.transfer(fromID, va.PhysicalID, amount)
+	if err != nil {
+		return "", err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.annotateTransaction(txnID, "Virtual Account: "+number)
+	b.recordVirtualCredit(txnID, va, amount, fromID)
+	return txnID, nil
+}
+
+// VirtualCreditsFor lists the credits received through a virtual account number, oldest first.
+func (b *Bank) VirtualCreditsFor(number string) []VirtualCredit {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	var credits []VirtualCredit
+	for _, c := range b.virtualCredits {
+		if c.Number == number {
+			credits = append(credits, c)
+		}
+	}
+	return credits
+}
+
+// ReconcileVirtualAccounts totals the credits received through each virtual number issued for a physical account,
+// so a business can match payments to payers.
+func (b *Bank) ReconcileVirtualAccounts(physicalID string) []VirtualReconciliation {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	byNumber := make(map[string]*VirtualReconciliation)
+	for number, va := range b.virtualAccounts {
+		if va.PhysicalID == physicalID {
+			byNumber[number] = &VirtualReconciliation{Number: number, Label: va.Label}
+		}
+	}
+	for _, c := range b.virtualCredits {
+		if r, ok := byNumber[c.Number]; ok {
+			r.Count++
+			r.Total += c.Amount
+		}
+	}
+	result := make([]VirtualReconciliation, 0, len(byNumber))
+	for _, r := range byNumber {
+		result = append(result, *r)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Number < result[j].Number })
+	return result
+}
@@ -0,0 +1,409 @@
+package bank
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every webhook delivery. The signature header holds one "v1=<hex>" entry per secret that is
+// currently valid, so subscribers can rotate secrets without missing deliveries.
+const (
+	WebhookIDHeader        = "Webhook-Id"
+	WebhookTimestampHeader = "Webhook-Timestamp"
+	WebhookSignatureHeader = "Webhook-Signature"
+)
+
+// WebhookDeliveryStatus is where a webhook delivery stands.
+type WebhookDeliveryStatus string
+
+const (
+	WebhookPending   WebhookDeliveryStatus = "pending"
+	WebhookDelivered WebhookDeliveryStatus = "delivered"
+	WebhookFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookSubscription is an endpoint that receives events from the event log as signed HTTP POSTs.
+type WebhookSubscription struct {
+	ID         string
+	URL        string
+	EventTypes []EventType // Event types delivered; empty means all
+	AccountIDs []string    // Accounts whose events are delivered; empty means all
+	Active     bool        // Inactive subscriptions keep their settings but receive nothing
+	CreatedAt  time.Time
+	secrets    []webhookSecret // Newest first
+}
+
+// webhookSecret is a signing secret, valid until it expires. The current secret never expires.
+type webhookSecret struct {
+	value     string
+	expiresAt time.Time
+}
+
+// WebhookDelivery is one event sent, or to be sent, to a subscription.
+type WebhookDelivery struct {
+	ID             string
+	SubscriptionID string
+	EventSeq       int64
+	EventType      EventType
+	Status         WebhookDeliveryStatus
+	Attempts       int
+	StatusCode     int    // HTTP status of the last attempt, 0 if no response was received
+	Err            string // Why the last attempt failed
+	At             time.Time
+	payload        []byte
+}
+
+// webhookPayload is the JSON body of a delivery.
+type webhookPayload struct {
+	DeliveryID string    `json:"deliveryId"`
+	Type       EventType `json:"type"`
+	Event      Event     `json:"event"`
+}
+
+// webhookState holds the bank's webhook subscriptions and delivery log.
+type webhookState struct {
+	subscriptions map[string]*WebhookSubscription
+	deliveries    []*WebhookDelivery // Oldest first
+	seq           int
+	client        *http.Client
+}
+
+// newWebhookSecret generates a random signing secret.
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// validWebhookURL checks a subscription endpoint is an absolute HTTP or HTTPS URL, and HTTPS in production.
+func validWebhookURL(rawURL string, mode Mode) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return errors.New("webhook URL must be an absolute http or https URL")
+	}
+	if mode == ModeProduction && u.Scheme != "https" {
+		return errors.New("webhook URL must use https in production")
+	}
+	return nil
+}
+
+// SignWebhookPayload returns the signature of a delivery body sent at the given Unix timestamp: the hex HMAC-SHA256
+// of the timestamp, a dot and the body, keyed with the secret.
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature checks a delivery's signature header against a subscriber's secret, rejecting deliveries
+// whose timestamp is further than tolerance from now to stop replays.
+func VerifyWebhookSignature(secret, timestamp, signatureHeader string, body []byte, tolerance time.Duration, now time.Time) bool {
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := now.Sub(time.Unix(sent, 0)); age > tolerance || age < -tolerance {
+		return false
+	}
+	expected := SignWebhookPayload(secret, timestamp, body)
+	for _, part := range strings.Split(signatureHeader, ",") {
+		if sig, ok := strings.CutPrefix(strings.TrimSpace(part), "v1="); ok && hmac.Equal([]byte(sig), []byte(expected)) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetWebhookClient sets the HTTP client used to deliver webhooks. By default deliveries time out after ten seconds;
+// in sandbox mode they go to a stub instead.
+func (b *Bank) SetWebhookClient(client *http.Client) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.webhooks.client = client
+}
+
+// CreateWebhookSubscription subscribes an endpoint to events, optionally only those of the given types or touching
+// the given accounts. It returns the subscription and its signing secret, which is not shown again.
+func (b *Bank) CreateWebhookSubscription(rawURL string, eventTypes []EventType, accountIDs []string) (WebhookSubscription, string, error) {
+	if err := validWebhookURL(rawURL, b.Mode()); err != nil {
+		return WebhookSubscription{}, "", err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return WebhookSubscription{}, "", err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.webhooks.subscriptions == nil {
+		b.webhooks.subscriptions = make(map[string]*WebhookSubscription)
+	}
+	sub := &WebhookSubscription{
+		ID:         b.newID("whk"),
+		URL:        rawURL,
+		EventTypes: slices.Clone(eventTypes),
+		AccountIDs: slices.Clone(accountIDs),
+		Active:     true,
+		CreatedAt:  b.now(),
+		secrets:    []webhookSecret{{value: secret}},
+	}
+	b.webhooks.subscriptions[sub.ID] = sub
+	return sub.copy(), secret, nil
+}
+
+// copy returns the subscription without its secrets, safe to hand to callers.
+func (sub *WebhookSubscription) copy() WebhookSubscription {
+	c := *sub
+	c.EventTypes = slices.Clone(sub.EventTypes)
+	c.AccountIDs = slices.Clone(sub.AccountIDs)
+	c.secrets = nil
+	return c
+}
+
+// WebhookSubscriptionByID returns a subscription.
+func (b *Bank) WebhookSubscriptionByID(id string) (WebhookSubscription, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	sub, exists := b.webhooks.subscriptions[id]
+	if !exists {
+		return WebhookSubscription{}, errors.New("webhook subscription does not exist")
+	}
+	return sub.copy(), nil
+}
+
+// WebhookSubscriptions lists every subscription, ordered by ID.
+func (b *Bank) WebhookSubscriptions() []WebhookSubscription {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	subs := make([]WebhookSubscription, 0, len(b.webhooks.subscriptions))
+	for _, sub := range b.webhooks.subscriptions {
+		subs = append(subs, sub.copy())
+	}
+	slices.SortFunc(subs, func(a, c WebhookSubscription) int { return strings.Compare(a.ID, c.ID) })
+	return subs
+}
+
+// UpdateWebhookSubscription changes a subscription's endpoint, filters and whether it is active. Deliveries
+// already queued go to the new endpoint.
+func (b *Bank) UpdateWebhookSubscription(id, rawURL string, eventTypes []EventType, accountIDs []string, active bool) (WebhookSubscription, error) {
+	if err := validWebhookURL(rawURL, b.Mode()); err != nil {
+		return WebhookSubscription{}, err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	sub, exists := b.webhooks.subscriptions[id]
+	if !exists {
+		return WebhookSubscription{}, errors.New("webhook subscription does not exist")
+	}
+	sub.URL = rawURL
+	sub.EventTypes = slices.Clone(eventTypes)
+	sub.AccountIDs = slices.Clone(accountIDs)
+	sub.Active = active
+	return sub.copy(), nil
+}
+
+// DeleteWebhookSubscription removes a subscription and drops its queued deliveries. Its delivery log is kept.
+func (b *Bank) DeleteWebhookSubscription(id string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.webhooks.subscriptions[id]; !exists {
+		return errors.New("webhook subscription does not exist")
+	}
+	delete(b.webhooks.subscriptions, id)
+	for _, d := range b.webhooks.deliveries {
+		if d.SubscriptionID == id && d.Status == WebhookPending {
+			d.Status, d.Err = WebhookFailed, "subscription deleted"
+		}
+	}
+	return nil
+}
+
+// RotateWebhookSecret gives a subscription a new signing secret and returns it. Deliveries are signed with both
+// the new and the previous secrets until grace has passed, so the subscriber can switch over without missing any.
+func (b *Bank) RotateWebhookSecret(id string, grace time.Duration) (string, error) {
+	if grace < 0 {
+		return "", errors.New("grace period must not be negative")
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return "", err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	sub, exists := b.webhooks.subscriptions[id]
+	if !exists {
+		return "", errors.New("webhook subscription does not exist")
+	}
+	now := b.now()
+	expiresAt := now.Add(grace)
+	secrets := []webhookSecret{{value: secret}}
+	for _, s := range sub.secrets {
+		if s.expiresAt.IsZero() {
+			s.expiresAt = expiresAt
+		}
+		if s.expiresAt.After(now) {
+			secrets = append(secrets, s)
+		}
+	}
+	sub.secrets = secrets
+	return secret, nil
+}
+
+// matches reports whether a subscription wants an event.
+func (sub *WebhookSubscription) matches(e Event) bool {
+	if !sub.Active {
+		return false
+	}
+	if len(sub.EventTypes) > 0 && !slices.Contains(sub.EventTypes, e.Type) {
+		return false
+	}
+	if len(sub.AccountIDs) > 0 && !slices.Contains(sub.AccountIDs, e.AccountID) && (e.ToID == "" || !slices.Contains(sub.AccountIDs, e.ToID)) {
+		return false
+	}
+	return true
+}
+
+// queueWebhooks queues a delivery of an event for every subscription that wants it.
+// The caller must hold the bank mutex.
+func (b *Bank) queueWebhooks(e Event) {
+	for _, sub := range b.webhooks.subscriptions {
+		if !sub.matches(e) {
+			continue
+		}
+		b.webhooks.seq++
+		d := &WebhookDelivery{
+			ID:             "whd-" + strconv.Itoa(b.webhooks.seq),
+			SubscriptionID: sub.ID,
+			EventSeq:       e.Seq,
+			EventType:      e.Type,
+			Status:         WebhookPending,
+			At:             e.At,
+		}
+		payload, err := json.Marshal(webhookPayload{DeliveryID: d.ID, Type: e.Type, Event: e})
+		if err != nil {
+			d.Status, d.Err = WebhookFailed, err.Error()
+		}
+		d.payload = payload
+		b.webhooks.deliveries = append(b.webhooks.deliveries, d)
+	}
+}
+
+// WebhookDeliveries returns the delivery log, oldest first, optionally only for one subscription.
+func (b *Bank) WebhookDeliveries(subscriptionID string) []WebhookDelivery {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	var deliveries []WebhookDelivery
+	for _, d := range b.webhooks.deliveries {
+		if subscriptionID == "" || d.SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, *d)
+		}
+	}
+	return deliveries
+}
+
+// RedeliverWebhook queues a delivery to be sent again on the next DeliverWebhooks run, signed with the
+// subscription's current secrets.
+func (b *Bank) RedeliverWebhook(deliveryID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, d := range b.webhooks.deliveries {
+		if d.ID != deliveryID {
+			continue
+		}
+		if _, exists := b.webhooks.subscriptions[d.SubscriptionID]; !exists {
+			return errors.New("webhook subscription does not exist")
+		}
+		if d.payload == nil {
+			return errors.New("delivery has no payload to send")
+		}
+		d.Status, d.Err = WebhookPending, ""
+		return nil
+	}
+	return errors.New("webhook delivery does not exist")
+}
+
+// webhookRequest is a queued delivery ready to send outside the bank mutex.
+type webhookRequest struct {
+	delivery *WebhookDelivery
+	url      string
+	secrets  []string
+}
+
+// DeliverWebhooks sends every queued delivery and returns the outcome of each. Deliveries that fail are marked
+// failed and can be sent again with RedeliverWebhook.
+func (b *Bank) DeliverWebhooks() []WebhookDelivery {
+	b.mutex.Lock()
+	now := b.now()
+	client := b.webhookClient()
+	var queue []webhookRequest
+	for _, d := range b.webhooks.deliveries {
+		sub, exists := b.webhooks.subscriptions[d.SubscriptionID]
+		if d.Status != WebhookPending || !exists {
+			continue
+		}
+		req := webhookRequest{delivery: d, url: sub.URL}
+		for _, s := range sub.secrets {
+			if s.expiresAt.IsZero() || s.expiresAt.After(now) {
+				req.secrets = append(req.secrets, s.value)
+			}
+		}
+		queue = append(queue, req)
+	}
+	b.mutex.Unlock()
+
+	results := make([]WebhookDelivery, 0, len(queue))
+	for _, req := range queue {
+		statusCode, err := sendWebhook(client, req, now)
+
+		b.mutex.Lock()
+		d := req.delivery
+		d.Attempts++
+		d.At, d.StatusCode = now, statusCode
+		d.Status, d.Err = WebhookDelivered, ""
+		if err != nil {
+			d.Status, d.Err = WebhookFailed, err.Error()
+		}
+		results = append(results, *d)
+		b.mutex.Unlock()
+	}
+	return results
+}
+
+// sendWebhook POSTs a delivery's payload, signed with each secret, and returns the response status.
+func sendWebhook(client *http.Client, req webhookRequest, now time.Time) (int, error) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signatures := make([]string, 0, len(req.secrets))
+	for _, secret := range req.secrets {
+		signatures = append(signatures, "v1="+SignWebhookPayload(secret, timestamp, req.delivery.payload))
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, req.url, bytes.NewReader(req.delivery.payload))
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(WebhookIDHeader, req.delivery.ID)
+	httpReq.Header.Set(WebhookTimestampHeader, timestamp)
+	httpReq.Header.Set(WebhookSignatureHeader, strings.Join(signatures, ","))
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, errors.New("endpoint responded " + resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,181 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// TaxStatus is how a customer's interest is taxed, e.g. resident, non-resident or exempt. The configured withholding
+// rates say how much of each status's interest is withheld.
+type TaxStatus string
+
+// TaxStatusResident is the tax status of customers who have not been given one.
+const TaxStatusResident TaxStatus = "resident"
+
+// SetTaxStatus records a customer's tax status, which decides the rate tax is withheld from their interest at.
+// Only admins and managers may set it; "" makes the customer resident again.
+func (b *Bank) SetTaxStatus(staffID, customerID string, status TaxStatus) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.isManager(staffID) {
+		return decline(ReasonNotAuthorized, "only admins and managers may set tax statuses")
+	}
+	if customerID == "" {
+		return errors.New("customer ID must not be empty")
+	}
+	if status == "" || status == TaxStatusResident {
+		delete(b.taxStatus, customerID)
+		status = TaxStatusResident
+	} else {
+		b.taxStatus[customerID] = status
+	}
+	b.auditAction(staffID, "SetTaxStatus", "", "", customerID+" "+string(status))
+	return nil
+}
+
+// TaxStatusOf returns a customer's tax status.
+func (b *Bank) TaxStatusOf(customerID string) TaxStatus {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.taxStatusOf(customerID)
+}
+
+// taxStatusOf returns a customer's tax status.
+// The caller must hold the bank mutex.
+func (b *Bank) taxStatusOf(customerID string) TaxStatus {
+	if status, exists := b.taxStatus[customerID]; exists {
+		return status
+	}
+	return TaxStatusResident
+}
+
+// withholdingRate returns the percentage of interest withheld from an account's owner.
+// The caller must hold the bank mutex.
+func (b *Bank) withholdingRate(accountID string) float64 {
+	if b.config == nil {
+		return 0
+	}
+	return b.config.taxRates[b.taxStatusOf(b.accountOwner[accountID])]
+}
+
+// taxAccountID returns the account withheld tax is moved to.
+// The caller must hold the bank mutex.
+func (b *Bank) taxAccountID() string {
+	if b.config == nil {
+		return ""
+	}
+	return b.config.taxAccount
+}
+
+// withholdTax moves the tax withheld from interest credited to an account into the tax account, at the rate for
+// its owner's tax status. It returns the amount withheld and the transaction that moved it.
+// The caller must not hold the bank mutex or either account's lock.
+func (b *Bank) withholdTax(accountID string, interest account.Money, interestTxnID string) (account.Money, string, error) {
+	if interest <= 0 {
+		return 0, "", nil
+	}
+	b.mutex.RLock()
+	taxID := b.taxAccountID()
+	b.mutex.RUnlock()
+	unlock := b.lockAccounts(accountID, taxID)
+	defer unlock()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	rate := b.withholdingRate(accountID)
+	tax := account.Money(math.Round(float64(interest) * rate / 100))
+	if tax <= 0 {
+		return 0, "", nil
+	}
+	acc, exists := b.accounts.get(accountID)
+	if !exists {
+		return 0, "", errors.New("account does not exist")
+	}
+	taxAccount, exists := b.accounts.get(taxID)
+	if !exists || taxID == accountID {
+		return 0, "", errors.New("tax account " + taxID + " does not exist")
+	}
+	if b.currencyOf(taxID) != b.currencyOf(accountID) {
+		return 0, "", decline(ReasonCurrency, "tax account "+taxID+" is not in the currency of account "+accountID)
+	}
+	if err := acc.Withdraw(tax); err != nil {
+		return 0, "", err
+	}
+	if err := taxAccount.Deposit(tax); err != nil {
+		_ = acc.Deposit(tax)
+		return 0, "", err
+	}
+	txnID := b.newTxnID()
+	b.recordTransaction(txnID, fmt.Sprintf("Transaction ID: %s, Account: %s, Withholding Tax: %s, Rate: %g%%, Interest Transaction: %s, Tax Account: %s, Status: %s\n", txnID, accountID, tax, rate, interestTxnID, taxID, "success"))
+	b.recordEvent(Event{Type: EventTaxWithheld, AccountID: accountID, ToID: taxID, Amount: tax, TransactionID: txnID})
+	return tax, txnID, nil
+}
+
+// withholdPostingTax withholds tax from an interest posting, from the account it was paid straight into if it was.
+func (b *Bank) withholdPostingTax(posting InterestPosting) InterestPosting {
+	accountID := posting.AccountID
+	if posting.PaidTo != "" {
+		accountID = posting.PaidTo
+	}
+	posting.Withheld, posting.WithholdingTransactionID, posting.WithholdingErr = b.withholdTax(accountID, posting.Amount, posting.TransactionID)
+	return posting
+}
+
+// TaxReportLine is the interest one account earned in a tax year and the tax withheld from it.
+type TaxReportLine struct {
+	CustomerID string
+	TaxStatus  TaxStatus
+	AccountID  string
+	Interest   account.Money // Interest credited over the year
+	Withheld   account.Money
+}
+
+// TaxReport totals, for each account credited with interest or withheld tax from in a calendar year, the interest
+// and the tax withheld, ordered by customer and account. An empty customerID reports on every customer. It is built
+// from the event log.
+func (b *Bank) TaxReport(year int, customerID string) []TaxReportLine {
+	b.mutex.RLock()
+	since := time.Date(year, time.January, 1, 0, 0, 0, 0, b.now().Location())
+	until := since.AddDate(1, 0, 0)
+	byAccount := make(map[string]*TaxReportLine)
+	line := func(accountID string) *TaxReportLine {
+		l, exists := byAccount[accountID]
+		if !exists {
+			owner := b.accountOwner[accountID]
+			l = &TaxReportLine{CustomerID: owner, TaxStatus: b.taxStatusOf(owner), AccountID: accountID}
+			byAccount[accountID] = l
+		}
+		return l
+	}
+	for _, e := range b.events {
+		if e.At.Before(since) || !e.At.Before(until) {
+			continue
+		}
+		if customerID != "" && b.accountOwner[e.AccountID] != customerID {
+			continue
+		}
+		switch {
+		case e.Type == EventInterestPosted && e.Amount > 0:
+			line(e.AccountID).Interest += e.Amount
+		case e.Type == EventTaxWithheld:
+			line(e.AccountID).Withheld += e.Amount
+		}
+	}
+	b.mutex.RUnlock()
+	report := make([]TaxReportLine, 0, len(byAccount))
+	for _, l := range byAccount {
+		report = append(report, *l)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].CustomerID != report[j].CustomerID {
+			return report[i].CustomerID < report[j].CustomerID
+		}
+		return report[i].AccountID < report[j].AccountID
+	})
+	return report
+}
@@ -0,0 +1,199 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// ZBAStructure is a cash concentration structure: at end of day every subsidiary account is swept to its target
+// balance, with the excess moved up to the master account or a shortfall funded from it.
+type ZBAStructure struct {
+	ID           string
+	MasterID     string
+	Targets      map[string]account.Money // Map of subsidiary account ID to the balance it is left with; zero for a true ZBA
+	Cutoff       time.Duration            // Time after midnight from which the day's sweep may run
+	LastSweepDay time.Time                // Day of the most recent end-of-day sweep
+	InterestRate float64                  // Annual rate charged on intercompany positions, Actual/365
+	positions    map[string]account.Money // Intercompany position per subsidiary; positive when the master owes it
+
+	interestThrough time.Time                // Intercompany interest has been accrued for every day before this one
+	accruedInterest map[string]float64       // Interest accrued this month per subsidiary in unrounded minor units, signed like positions
+	pendingInterest map[string]float64       // Interest from past months awaiting settlement, in unrounded minor units
+	settledInterest map[string]account.Money // Interest settled per subsidiary so far, signed like positions
+}
+
+// ZBASweep is one movement made by a sweep.
+type ZBASweep struct {
+	StructureID   string
+	AccountID     string
+	Amount        account.Money // Positive when swept up to the master, negative when funded from it
+	TransactionID string
+	Err           error
+}
+
+// CreateZBAStructure creates an empty structure concentrating cash in the master account.
+func (b *Bank) CreateZBAStructure(id, masterID string, cutoff time.Duration) error {
+	if cutoff < 0 || cutoff >= 24*time.Hour {
+		return errors.New("cutoff must be within the day")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, exists := b.zbaStructures[id]; exists {
+		return errors.New("structure already exists")
+	}
+	if _, exists := b.accounts.get(masterID); !exists {
+		return errors.New("master account does not exist")
+	}
+	b.zbaStructures[id] = &ZBAStructure{
+		ID:              id,
+		MasterID:        masterID,
+		Targets:         make(map[string]account.Money),
+		Cutoff:          cutoff,
+		positions:       make(map[string]account.Money),
+		accruedInterest: make(map[string]float64),
+		pendingInterest: make(map[string]float64),
+		settledInterest: make(map[string]account.Money),
+	}
+	return nil
+}
+
+// AddZBASubsidiary adds an account to a structure, to be swept to the target balance each day.
+func (b *Bank) AddZBASubsidiary(structureID, accountID string, target account.Money) error {
+	if target < 0 {
+		return errors.New("target balance must not be negative")
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	s, exists := b.zbaStructures[structureID]
+	if !exists {
+		return errors.New("structure does not exist")
+	}
+	if _, exists := b.accounts.get(accountID); !exists {
+		return errors.New("account does not exist")
+	}
+	if accountID == s.MasterID {
+		return errors.New("master account cannot be its own subsidiary")
+	}
+	for _, other := range b.zbaStructures {
+		if _, member := other.Targets[accountID]; member && other.ID != structureID {
+			return errors.New("account already belongs to structure " + other.ID)
+		}
+	}
+	s.Targets[accountID] = target
+	return nil
+}
+
+// SweepZBA sweeps every subsidiary of a structure to its target balance now.
+func (b *Bank) SweepZBA(structureID string) ([]ZBASweep, error) {
+	b.mutex.Lock()
+	s, exists := b.zbaStructures[structureID]
+	if !exists {
+		b.mutex.Unlock()
+		return nil, errors.New("structure does not exist")
+	}
+	masterID := s.MasterID
+	subsidiaries := make([]string, 0, len(s.Targets))
+	for id := range s.Targets {
+		subsidiaries = append(subsidiaries, id)
+	}
+	b.mutex.Unlock()
+	sort.Strings(subsidiaries)
+
+	// Sweep excess up first so the master holds as much as possible before funding shortfalls
+	var sweeps []ZBASweep
+	for _, pass := range []bool{true, false} {
+		for _, accountID := range subsidiaries {
+			if sweep, ok := b.sweepSubsidiary(s, masterID, accountID, pass); ok {
+				sweeps = append(sweeps, sweep)
+			}
+		}
+	}
+	return sweeps, nil
+}
+
+// sweepSubsidiary moves one subsidiary to its target if its position is in the direction of this pass.
+func (b *Bank) sweepSubsidiary(s *ZBAStructure, masterID, accountID string, sweepUp bool) (ZBASweep, bool) {
+	unlock := b.lockAccounts(masterID, accountID)
+	defer unlock()
+
+	b.mutex.Lock()
+	acc, exists := b.accounts.get(accountID)
+	target, member := s.Targets[accountID]
+	b.mutex.Unlock()
+	if !exists || !member {
+		return ZBASweep{}, false
+	}
+	excess := acc.Balance() - target
+	if excess == 0 || (excess > 0) != sweepUp {
+		return ZBASweep{}, false
+	}
+
+	sweep := ZBASweep{StructureID: s.ID, AccountID: accountID, Amount: excess}
+	if excess > 0 {
+		sweep.TransactionID, sweep.Err = b.executeTransfer(accountID, masterID, excess)
+	} else {
+		sweep.TransactionID, sweep.Err = b.executeTransfer(masterID, accountID, -excess)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if sweep.Err == nil {
+		s.positions[accountID] += excess
+		b.annotateTransaction(sweep.TransactionID, "Sweep: "+s.ID)
+	}
+	return sweep, true
+}
+
+// RunEndOfDaySweeps sweeps every structure whose cutoff has passed today and that has not yet been swept today.
+func (b *Bank) RunEndOfDaySweeps() []ZBASweep {
+	b.mutex.Lock()
+	now := b.now()
+	today := startOfDay(now)
+	var due []string
+	for id, s := range b.zbaStructures {
+		if s.LastSweepDay.Before(today) && !now.Before(today.Add(s.Cutoff)) {
+			s.LastSweepDay = today
+			due = append(due, id)
+		}
+	}
+	b.mutex.Unlock()
+	sort.Strings(due)
+
+	var sweeps []ZBASweep
+	for _, id := range due {
+		done, _ := b.SweepZBA(id)
+		sweeps = append(sweeps, done...)
+	}
+	return sweeps
+}
+
+// IntercompanyPositions returns what the master owes each subsidiary from sweeps so far. A negative position
+// means the subsidiary has borrowed from the master.
+func (b *Bank) IntercompanyPositions(structureID string) (map[string]account.Money, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	s, exists := b.zbaStructures[structureID]
+	if !exists {
+		return nil, errors.New("structure does not exist")
+	}
+	positions := make(map[string]account.Money, len(s.Targets))
+	for id := range s.Targets {
+		positions[id] = s.positions[id]
+	}
+	return positions, nil
+}
+
+// String describes a sweep for logs.
+func (s ZBASweep) String() string {
+	if s.Err != nil {
+		return fmt.Sprintf("sweep of %s in %s failed: %v", s.AccountID, s.StructureID, s.Err)
+	}
+	if s.Amount > 0 {
+		return fmt.Sprintf("swept %s from %s to master (%s)", s.Amount, s.AccountID, s.StructureID)
+	}
+	return fmt.Sprintf("funded %s with %s from master (%s)", s.AccountID, -s.Amount, s.StructureID)
+}
@@ -0,0 +1,1432 @@
+// Command bankadmin runs operational tasks against a bank's persisted state: freezing accounts, reversing
+// transactions, setting daily limits, running end-of-day processing, verifying the ledger, tracing operations by
+// reference, exporting the audit trail and CSV reports,
+// rolling out feature flags, checking config files and managing staff users. Every command needs an admin's credentials; the password is read from BANKADMIN_PASSWORD or prompted for.
+// It must not run while the customer CLI has the same data directory open, except to pause and resume transfers.
+//
+// Usage:
+//
+//	bankadmin [flags] -user ID command [arguments]
+//
+// Commands:
+//
+//	freeze ACCOUNT             freeze an account
+//	unfreeze ACCOUNT           unfreeze an account
+//	reverse TXN REASON...      reverse a completed transfer
+//	compensations              list transfers that debited their source but could not credit or refund it
+//	resolve TXN refund|complete
+//	                           settle such a transfer by refunding the source or crediting the destination
+//	reviews                    list transfers awaiting fraud review, highest anomaly score first
+//	review TXN clear|confirm   record the outcome of a fraud review
+//	baseline ACCOUNT           show the usual amounts, payees and hours transfers are scored against
+//	corridors [FROM TO]        count cross-border transfers sent, held back and overridden by destination
+//	                           country, optionally only between the dates FROM and TO (YYYY-MM-DD, TO exclusive)
+//	corridor-reviews           list cross-border transfers held back by corridor rules
+//	corridor ID approve|reject override a held-back cross-border transfer, sending it, or reject it
+//	channels [FROM TO]         count and total deposits and withdrawals by channel (cash, atm, wire, ach,
+//	                           internal), optionally only between the dates FROM and TO (YYYY-MM-DD, TO exclusive)
+//	withdrawals                list withdrawals waiting for approval because their channel needs it
+//	withdrawal ID approve|reject
+//	                           approve a waiting withdrawal, making it, or reject it
+//	pause [THRESHOLD [REASON...]]
+//	                           stop outgoing transfers bank-wide, or only those over THRESHOLD (0 for all),
+//	                           rejecting or queueing them as configured; takes effect in running customer CLIs
+//	resume                     let outgoing transfers flow again
+//	queued                     list transfers queued while transfers were paused and not yet sent
+//	release                    send the queued transfers transfers are no longer paused for
+//	credit CUSTOMER|ALIAS AMOUNT
+//	                           deposit an incoming payment addressed to a customer into their default account
+//	limits ACCOUNT [WITHDRAWAL TRANSFER]
+//	                           show an account's daily limits and usage, or set them (0 for no limit)
+//	payout ACCOUNT [TO|capitalize]
+//	                           show where a savings account or fixed deposit's interest goes, or pay it out to
+//	                           another account of the owner each period, or add it to the account again
+//	protect CHECKING [SAVINGS|off]
+//	                           show which savings account covers a checking account's withdrawals beyond its
+//	                           balance, or link one of the owner's savings accounts, or remove the link
+//	tax-status CUSTOMER [STATUS]
+//	                           show a customer's tax status, which sets the rate tax is withheld from their
+//	                           interest at, or change it (resident, or any status the configuration lists)
+//	tax-report YEAR [CUSTOMER] list the interest each account earned in a year and the tax withheld from it
+//	segment CUSTOMER [SEGMENT] show a customer's pricing segment, or move them to standard, student, senior or
+//	                           premium from the start of next month
+//	tier CUSTOMER              show a customer's relationship balance and the tier their last monthly review
+//	                           placed them in
+//	joint ACCOUNT [add|remove CUSTOMER]
+//	                           show an account's owners and signing rule, or add or remove a joint owner
+//	joint ACCOUNT signing any|all|all-above [AMOUNT]
+//	                           let any owner debit the account alone, or require every owner's approval for all
+//	                           debits or those above AMOUNT
+//	meta ACCOUNT [owner NAME...|email ADDRESS|tag TAG...|untag TAG...]
+//	                           show an account's owner name, email address, opening time and tags, or change one
+//	profile ACCOUNT [nickname [NAME...]|paper on|off|language [TAG]]
+//	                           show an account's nickname, daily limits, preferences and metadata, or change its
+//	                           nickname, whether statements are posted or their language
+//	find [FIELD=VALUE]...      list accounts matching every condition; fields are owner (part of the owner's name),
+//	                           email, tag (repeatable), customer, type, state, since and before (YYYY-MM-DD, when
+//	                           the account was opened)
+//	promo ACCOUNT [RATE FROM TO|off]
+//	                           show a savings account's promotional interest rate, or have it earn RATE percent
+//	                           instead of its own rate from FROM until TO (YYYY-MM-DD, TO exclusive), or remove it
+//	reopen ACCOUNT             reopen an account that was closed by mistake
+//	convert ACCOUNT [PRODUCT [YYYY-MM-DD]]
+//	                           show an account's product, or convert it to another configured product from a date
+//	                           (today by default); past dates adjust the interest since then
+//	purge DAYS                 archive and remove accounts closed more than DAYS days ago
+//	eod                        run end-of-day processing, e.g. daily from cron: interest, conversions, maturities,
+//	                           scheduled and queued transfers, sweeps, held notifications and webhook deliveries
+//	verify                     print a trial balance of the customer and GL accounts, and check that it balances,
+//	                           that running totals and the event log match the accounts and that no transaction
+//	                           or account state is orphaned
+//	orphans [repair]           list what the bank keeps about accounts it does not hold, e.g. owners or interest
+//	                           payouts of accounts that are gone, or drop all of it
+//	trace REFERENCE            show the events, transactions and audit entries of one operation, by the
+//	                           reference (correlation ID) the customer CLI printed
+//	declines                   count failed transactions by reason code
+//	usage [MONTH]              show each API client's calls in MONTH (YYYY-MM, default this month) and their charge
+//	                           under the plans in the data directory's config.json
+//	auditlog [ACCOUNT]         show the audit log of state changes, with who made them and the balances
+//	                           before and after, optionally only those touching one account
+//	audit [FILE]               export staff notes and impersonation events as JSON lines
+//	export DIR                 write active accounts and the transaction journal to accounts.csv and
+//	                           transactions.csv in DIR
+//	graph FORMAT FILE [FROM TO [MIN]]
+//	                           write the transfer network as dot, graphml or json, optionally only transfers
+//	                           between the dates FROM and TO (YYYY-MM-DD, TO exclusive, - for open) and flows of
+//	                           at least MIN
+//	backup FILE                write the whole bank state to FILE, in the -snapshot-encoding and
+//	                           -snapshot-compression format
+//	restore FILE               rebuild the bank from a backup into a data directory that holds no bank yet
+//	config check FILE          validate a config file before the customer CLI reloads it
+//	flags list                 list feature flags
+//	flags set NAME PERCENT     turn a flag on for a percentage of tenants (0 to 100)
+//	flags off NAME             turn a flag off for everyone
+//	flags tenant NAME TENANT on|off|default
+//	                           override a flag for one tenant, or remove the override
+//	flags remove NAME          delete a flag
+//	users list                 list users
+//	users add ID ROLE          add a user with role admin, manager, teller, staff or customer
+//	users remove ID            remove a user
+//	users passwd ID            set a user's password
+//	users role ID ROLE         change a user's role
+//
+// New passwords are read from BANKADMIN_NEW_PASSWORD or prompted for. While no users exist, "users add ID admin"
+// runs without credentials to create the first admin.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/bank"
+)
+
+func main() {
+	dataDir := flag.String("data", "bank-data", "directory where bank state is persisted")
+	usersPath := flag.String("users", "", "staff directory file (default staff.json in the data directory)")
+	flagsPath := flag.String("flags", "", "feature flags file (default flags.json in the data directory)")
+	pausePath := flag.String("pause", "", "transfer kill switch file (default pause.json in the data directory)")
+	userID := flag.String("user", "", "admin user ID to sign in as")
+	snapshotEncoding := flag.String("snapshot-encoding", "json", "encoding of saved snapshots: json or binary")
+	snapshotCompression := flag.String("snapshot-compression", "none", "compression of saved snapshots: none or gzip")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: bankadmin [flags] -user ID command [arguments]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *usersPath == "" {
+		*usersPath = filepath.Join(*dataDir, "staff.json")
+	}
+	if *flagsPath == "" {
+		*flagsPath = filepath.Join(*dataDir, "flags.json")
+	}
+	if *pausePath == "" {
+		*pausePath = filepath.Join(*dataDir, "pause.json")
+	}
+	if err := run(*dataDir, *usersPath, *flagsPath, *pausePath, *userID, bank.FormatOptions{Encoding: bank.Encoding(*snapshotEncoding), Compression: bank.Compression(*snapshotCompression)}, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+// run signs in and carries out one command.
+func run(dataDir, usersPath, flagsPath, pausePath, userID string, format bank.FormatOptions, args []string) error {
+	stdin := bufio.NewReader(os.Stdin)
+	storage := bank.NewJSONFileStorage(dataDir)
+	if err := storage.SetSnapshotFormat(format); err != nil {
+		return err
+	}
+	staff, err := bank.LoadStaffDirectory(usersPath)
+	if err != nil {
+		return err
+	}
+
+	// The first admin can be created before anyone can sign in
+	if staff.Empty() {
+		if len(args) != 4 || args[0] != "users" || args[1] != "add" || bank.StaffRole(args[3]) != bank.StaffRoleAdmin {
+			return errors.New(`no staff users exist yet; create the first admin with "users add ID admin"`)
+		}
+		return addUser(staff, stdin, args[2], args[3])
+	}
+
+	if userID == "" {
+		return errors.New("-user is required")
+	}
+	password, err := secret(stdin, "BANKADMIN_PASSWORD", "Password for "+userID+": ")
+	if err != nil {
+		return err
+	}
+	role, err := staff.Authenticate(userID, password)
+	if err != nil {
+		return err
+	}
+	if role != bank.StaffRoleAdmin {
+		return errors.New("admin credentials required")
+	}
+
+	if args[0] == "users" {
+		return manageUsers(staff, stdin, args[1:])
+	}
+	if args[0] == "config" {
+		if len(args) != 3 || args[1] != "check" {
+			return errors.New("usage: config check FILE")
+		}
+		c, err := bank.LoadConfig(args[2])
+		if err != nil {
+			return err
+		}
+		if err := c.Validate(); err != nil {
+			return err
+		}
+		limits := len(c.Limits)
+		for _, channelLimits := range c.ChannelLimits {
+			limits += len(channelLimits)
+		}
+		fmt.Printf("Config OK: %d fee rules, %d limits, %d benchmarks, %d holidays, %d sanctions lists.\n", len(c.Fees), limits, len(c.Benchmarks), len(c.Holidays), len(c.Sanctions))
+		return nil
+	}
+	features, err := bank.LoadFeatureFlags(flagsPath)
+	if err != nil {
+		return err
+	}
+	if args[0] == "flags" {
+		return manageFlags(features, args[1:])
+	}
+
+	if args[0] == "restore" {
+		return restore(storage, dataDir, args)
+	}
+	b, err := bank.New(storage)
+	if err != nil {
+		return fmt.Errorf("loading bank state: %w", err)
+	}
+	staff.RegisterWith(b)
+	b.SetFeatureFlags(features)
+	pause, err := bank.LoadTransferSwitch(pausePath)
+	if err != nil {
+		return err
+	}
+	b.SetTransferSwitch(pause)
+	// Pausing is for incidents, so it works while the customer CLI runs; the bank state is left unsaved
+	if args[0] == "pause" || args[0] == "resume" {
+		return switchTransfers(b, userID, args)
+	}
+	if args[0] == "backup" {
+		return backup(b, format, args)
+	}
+	// API usage is billed under the plans the customer CLI runs with
+	if args[0] == "usage" {
+		if err := b.ReloadConfig(filepath.Join(dataDir, "config.json")); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if err := runBankCommand(b, userID, args); err != nil {
+		return err
+	}
+	return b.Save()
+}
+
+// backup carries out the backup command.
+func backup(b *bank.Bank, format bank.FormatOptions, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: backup FILE")
+	}
+	f, err := os.Create(args[1])
+	if err != nil {
+		return err
+	}
+	err = b.SnapshotWithFormat(f, format)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("Bank state backed up to", args[1])
+	return nil
+}
+
+// restore carries out the restore command.
+func restore(storage *bank.JSONFileStorage, dataDir string, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: restore FILE")
+	}
+	f, err := os.Open(args[1])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	b, err := bank.RestoreBank(f)
+	if err != nil {
+		return err
+	}
+	if err := b.PersistTo(storage); err != nil {
+		return err
+	}
+	fmt.Printf("Restored %s from %s; total balance %s.\n", dataDir, args[1], b.TotalBalance())
+	return b.Save()
+}
+
+// switchTransfers carries out the pause and resume commands.
+func switchTransfers(b *bank.Bank, userID string, args []string) error {
+	if args[0] == "resume" {
+		if len(args) != 1 {
+			return errors.New("usage: resume")
+		}
+		if err := b.ResumeTransfers(userID); err != nil {
+			return err
+		}
+		fmt.Println("Outgoing transfers resumed; the customer CLI, or release, sends queued ones.")
+		return nil
+	}
+	var threshold account.Money
+	if len(args) > 1 {
+		var err error
+		if threshold, err = bank.ParseAmount(args[1], ""); err != nil {
+			return err
+		}
+	}
+	reason := ""
+	if len(args) > 2 {
+		reason = strings.Join(args[2:], " ")
+	}
+	if err := b.PauseTransfers(userID, threshold, reason); err != nil {
+		return err
+	}
+	if threshold > 0 {
+		fmt.Printf("Outgoing transfers over %s paused.\n", threshold)
+	} else {
+		fmt.Println("All outgoing transfers paused.")
+	}
+	return nil
+}
+
+// adminContext returns the context a bankadmin operation runs under, so the audit log records who ran it.
+func adminContext(userID string) context.Context {
+	return bank.ContextWithCorrelationID(bank.ContextWithChannel(bank.ContextWithUser(context.Background(), userID), bank.ChannelCLI), bank.NewCorrelationID())
+}
+
+// runBankCommand carries out a command that works on the bank's state.
+func runBankCommand(b *bank.Bank, userID string, args []string) error {
+	switch args[0] {
+	case "freeze", "unfreeze":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s ACCOUNT", args[0])
+		}
+		change := b.Freeze
+		if args[0] == "unfreeze" {
+			change = b.Unfreeze
+		}
+		if err := b.RunCorrelated(adminContext(userID), []string{args[1]}, func() error { return change(args[1]) }); err != nil {
+			return err
+		}
+		state, _ := b.AccountStateOf(args[1])
+		fmt.Printf("Account %s is now %s.\n", args[1], state)
+
+	case "reverse":
+		if len(args) < 3 {
+			return errors.New("usage: reverse TXN REASON...")
+		}
+		reversalID, err := b.ReverseTransaction(userID, args[1], strings.Join(args[2:], " "))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Transaction %s reversed by %s.\n", args[1], reversalID)
+
+	case "compensations":
+		pending := b.Compensations()
+		if len(pending) == 0 {
+			fmt.Println("No transfers awaiting compensation.")
+		}
+		for _, c := range pending {
+			to := c.ToID
+			if to == "" {
+				to = "split recipients"
+			}
+			fmt.Printf("%s  %s  %s from %s to %s: %s\n", c.TransactionID, c.At.Format(time.RFC3339), c.Amount, c.FromID, to, c.Err)
+		}
+		if len(pending) > 0 {
+			fmt.Printf("Held in suspense: %s\n", b.SuspenseBalance())
+		}
+
+	case "resolve":
+		if len(args) != 3 || (args[2] != "refund" && args[2] != "complete") {
+			return errors.New("usage: resolve TXN refund|complete")
+		}
+		if err := b.ResolveCompensation(userID, args[1], args[2] == "complete"); err != nil {
+			return err
+		}
+		fmt.Printf("Transaction %s resolved.\n", args[1])
+
+	case "reviews":
+		queue := b.FraudReviewQueue()
+		if len(queue) == 0 {
+			fmt.Println("No transfers awaiting fraud review.")
+		}
+		for _, r := range queue {
+			fmt.Printf("%s  %.2f  %s  %s from %s to %s: %s\n", r.TransactionID, r.Score, r.Recorded.Format(time.RFC3339), r.Amount, r.From, r.To, r.Reasons)
+		}
+
+	case "review":
+		if len(args) != 3 || (args[2] != "clear" && args[2] != "confirm") {
+			return errors.New("usage: review TXN clear|confirm")
+		}
+		if err := b.ReviewTransfer(userID, args[1], args[2] == "confirm"); err != nil {
+			return err
+		}
+		fmt.Printf("Transaction %s reviewed.\n", args[1])
+
+	case "corridors":
+		if len(args) != 1 && len(args) != 3 {
+			return errors.New("usage: corridors [FROM TO]")
+		}
+		var from, to time.Time
+		if len(args) == 3 {
+			for i, t := range []*time.Time{&from, &to} {
+				day, err := time.ParseInLocation("2006-01-02", args[1+i], time.Local)
+				if err != nil {
+					return fmt.Errorf("invalid date %q", args[1+i])
+				}
+				*t = day
+			}
+		}
+		report := b.CorridorReport(from, to)
+		if len(report) == 0 {
+			fmt.Println("No cross-border transfers.")
+		}
+		for _, s := range report {
+			fmt.Printf("%s  sent %d (%s), held %d, overridden %d\n", s.Country, s.Transfers, s.Total, s.Held, s.Overridden)
+		}
+
+	case "queued":
+		queued := b.QueuedTransfers(bank.QueuedWaiting)
+		if len(queued) == 0 {
+			fmt.Println("No transfers queued.")
+		}
+		for _, q := range queued {
+			fmt.Printf("%s  %s  %s from %s to %s\n", q.ID, q.QueuedAt.Format(time.RFC3339), q.Amount, q.FromID, q.ToID)
+		}
+
+	case "release":
+		released := b.ReleaseQueuedTransfers()
+		if len(released) == 0 {
+			fmt.Println("No queued transfers to send.")
+		}
+		for _, q := range released {
+			if q.Status == bank.QueuedSent {
+				fmt.Printf("%s sent as %s.\n", q.ID, q.TransactionID)
+			} else {
+				fmt.Printf("%s failed: %s\n", q.ID, q.Err)
+			}
+		}
+
+	case "corridor-reviews":
+		pending := b.CorridorReviews(bank.CorridorPending)
+		if len(pending) == 0 {
+			fmt.Println("No cross-border transfers awaiting review.")
+		}
+		for _, r := range pending {
+			fmt.Printf("%s  %s  %s from %s to %s in %s: %s\n", r.ID, r.RequestedAt.Format(time.RFC3339), r.Amount, r.FromID, r.ToID, r.Country, r.Rule)
+		}
+
+	case "corridor":
+		if len(args) != 3 || (args[2] != "approve" && args[2] != "reject") {
+			return errors.New("usage: corridor ID approve|reject")
+		}
+		txnID, err := b.DecideCorridorReview(userID, args[1], args[2] == "approve")
+		if err != nil {
+			return err
+		}
+		if txnID == "" {
+			fmt.Printf("Transfer %s rejected.\n", args[1])
+		} else {
+			fmt.Printf("Transfer %s sent as %s.\n", args[1], txnID)
+		}
+
+	case "channels":
+		if len(args) != 1 && len(args) != 3 {
+			return errors.New("usage: channels [FROM TO]")
+		}
+		var from, to time.Time
+		if len(args) == 3 {
+			for i, t := range []*time.Time{&from, &to} {
+				day, err := time.ParseInLocation("2006-01-02", args[1+i], time.Local)
+				if err != nil {
+					return fmt.Errorf("invalid date %q", args[1+i])
+				}
+				*t = day
+			}
+		}
+		report := b.RailReport(from, to)
+		if len(report) == 0 {
+			fmt.Println("No deposits or withdrawals.")
+		}
+		for _, r := range report {
+			rail := string(r.Rail)
+			if rail == "" {
+				rail = "untagged"
+			}
+			fmt.Printf("%-9s deposits %d (%s), withdrawals %d (%s)\n", rail, r.Deposits, r.Deposited, r.Withdrawals, r.Withdrawn)
+		}
+
+	case "withdrawals":
+		pending := b.WithdrawalApprovals(bank.WithdrawalPending)
+		if len(pending) == 0 {
+			fmt.Println("No withdrawals awaiting approval.")
+		}
+		for _, w := range pending {
+			fmt.Printf("%s  %s  %s from %s by %s\n", w.ID, w.RequestedAt.Format(time.RFC3339), w.Amount, w.AccountID, w.Rail)
+		}
+
+	case "withdrawal":
+		if len(args) != 3 || (args[2] != "approve" && args[2] != "reject") {
+			return errors.New("usage: withdrawal ID approve|reject")
+		}
+		if err := b.RunCorrelated(adminContext(userID), nil, func() error { return b.DecideWithdrawal(userID, args[1], args[2] == "approve") }); err != nil {
+			return err
+		}
+		fmt.Printf("Withdrawal %s %sd.\n", args[1], args[2])
+
+	case "baseline":
+		if len(args) != 2 {
+			return errors.New("usage: baseline ACCOUNT")
+		}
+		base := b.Baseline(args[1])
+		fmt.Printf("Transfers (90 days): %d\n", base.Transfers)
+		if base.Transfers == 0 {
+			break
+		}
+		fmt.Printf("Usual amount: %s (deviation %s)\n", base.MeanAmount, base.StdDevAmount)
+		payees := make([]string, 0, len(base.Counterparties))
+		for id := range base.Counterparties {
+			payees = append(payees, id)
+		}
+		sort.Strings(payees)
+		for _, id := range payees {
+			fmt.Printf("  to %s: %d\n", id, base.Counterparties[id])
+		}
+		for hour, n := range base.Hours {
+			if n > 0 {
+				fmt.Printf("  %02d:00 UTC: %d\n", hour, n)
+			}
+		}
+
+	case "payout":
+		if len(args) != 2 && len(args) != 3 {
+			return errors.New("usage: payout ACCOUNT [TO|capitalize]")
+		}
+		if len(args) == 3 {
+			payoutID := args[2]
+			if payoutID == "capitalize" {
+				payoutID = ""
+			}
+			if err := b.SetInterestPayout(userID, args[1], payoutID); err != nil {
+				return err
+			}
+		}
+		if payoutID := b.InterestPayoutOf(args[1]); payoutID != "" {
+			fmt.Printf("Interest on %s is paid out to %s.\n", args[1], payoutID)
+		} else {
+			fmt.Printf("Interest on %s is added to the account.\n", args[1])
+		}
+
+	case "protect":
+		if len(args) != 2 && len(args) != 3 {
+			return errors.New("usage: protect CHECKING [SAVINGS|off]")
+		}
+		if len(args) == 3 {
+			savingsID := args[2]
+			if savingsID == "off" {
+				savingsID = ""
+			}
+			if err := b.SetOverdraftProtection(userID, args[1], savingsID); err != nil {
+				return err
+			}
+		}
+		if savingsID := b.OverdraftProtectionOf(args[1]); savingsID != "" {
+			fmt.Printf("Withdrawals beyond the balance of %s are covered from %s.\n", args[1], savingsID)
+		} else {
+			fmt.Printf("%s has no overdraft protection.\n", args[1])
+		}
+
+	case "tax-status":
+		if len(args) != 2 && len(args) != 3 {
+			return errors.New("usage: tax-status CUSTOMER [STATUS]")
+		}
+		if len(args) == 3 {
+			if err := b.SetTaxStatus(userID, args[1], bank.TaxStatus(args[2])); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("Customer %s's tax status is %s.\n", args[1], b.TaxStatusOf(args[1]))
+
+	case "segment":
+		if len(args) != 2 && len(args) != 3 {
+			return errors.New("usage: segment CUSTOMER [SEGMENT]")
+		}
+		if len(args) == 3 {
+			segment, err := bank.ParseSegment(args[2])
+			if err != nil {
+				return err
+			}
+			if _, err := b.SetSegment(userID, args[1], segment); err != nil {
+				return err
+			}
+		}
+		current, pending, changing := b.SegmentOf(args[1])
+		fmt.Printf("Customer %s is in the %s segment.\n", args[1], current)
+		if changing {
+			fmt.Printf("Moving to %s from %s.\n", pending.Segment, pending.EffectiveAt.Format("2006-01-02"))
+		}
+
+	case "tier":
+		if len(args) != 2 {
+			return errors.New("usage: tier CUSTOMER")
+		}
+		if err := b.Authorize(userID, bank.ActionReport, ""); err != nil {
+			return err
+		}
+		balance, status := b.RelationshipOf(args[1])
+		fmt.Printf("Relationship balance: %s\n", balance)
+		switch {
+		case status.ReviewedAt.IsZero():
+			fmt.Println("Not reviewed yet.")
+		case status.Tier == "":
+			fmt.Printf("No tier since the review of %s.\n", status.ReviewedAt.Format("2006-01-02"))
+		default:
+			fmt.Printf("Tier %s since the review of %s.\n", status.Tier, status.ReviewedAt.Format("2006-01-02"))
+		}
+
+	case "tax-report":
+		if len(args) != 2 && len(args) != 3 {
+			return errors.New("usage: tax-report YEAR [CUSTOMER]")
+		}
+		year, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid year %q", args[1])
+		}
+		customerID := ""
+		if len(args) == 3 {
+			customerID = args[2]
+		}
+		report := b.TaxReport(year, customerID)
+		if len(report) == 0 {
+			fmt.Println("No interest credited.")
+		}
+		var interest, withheld account.Money
+		for _, l := range report {
+			fmt.Printf("%-12s %-12s %-12s interest %12s, withheld %12s\n", l.CustomerID, l.TaxStatus, l.AccountID, l.Interest, l.Withheld)
+			interest += l.Interest
+			withheld += l.Withheld
+		}
+		if len(report) > 1 {
+			fmt.Printf("Total interest %s, withheld %s\n", interest, withheld)
+		}
+
+	case "credit":
+		if len(args) != 3 {
+			return errors.New("usage: credit CUSTOMER|ALIAS AMOUNT")
+		}
+		accountID, err := b.DefaultAccountOf(args[1])
+		if err != nil {
+			return err
+		}
+		currency, err := b.CurrencyOf(accountID)
+		if err != nil {
+			return err
+		}
+		amount, err := bank.ParseAmount(args[2], currency)
+		if err != nil {
+			return err
+		}
+		if err := b.Authorize(userID, bank.ActionDeposit, accountID); err != nil {
+			return err
+		}
+		if accountID, err = b.DepositToCustomer(args[1], amount); err != nil {
+			return err
+		}
+		fmt.Printf("Credited %s to %s, the default account of %s\n", amount, accountID, args[1])
+
+	case "limits":
+		if len(args) != 2 && len(args) != 4 {
+			return errors.New("usage: limits ACCOUNT [WITHDRAWAL TRANSFER]")
+		}
+		if len(args) == 4 {
+			currency, err := b.CurrencyOf(args[1])
+			if err != nil {
+				return err
+			}
+			var amounts [2]account.Money
+			for i, arg := range args[2:] {
+				if amounts[i], err = bank.ParseAmount(arg, currency); err != nil {
+					return err
+				}
+			}
+			if err := b.SetDailyLimits(userID, args[1], bank.DailyLimits{Withdrawal: amounts[0], Transfer: amounts[1]}); err != nil {
+				return err
+			}
+		}
+		limits, usage, err := b.DailyLimitsOf(args[1])
+		if err != nil {
+			return err
+		}
+		for _, l := range []struct {
+			name        string
+			limit, used account.Money
+		}{{"Withdrawal", limits.Withdrawal, usage.Withdrawal}, {"Transfer", limits.Transfer, usage.Transfer}} {
+			if l.limit == 0 {
+				fmt.Printf("%s: no daily limit, %s used in the last 24 hours\n", l.name, l.used)
+			} else {
+				fmt.Printf("%s: daily limit %s, %s used in the last 24 hours\n", l.name, l.limit, l.used)
+			}
+		}
+		if pending, exists := b.PendingDailyLimits(args[1]); exists {
+			fmt.Printf("Raised by the customer from %s: withdrawal %s, transfer %s\n", pending.EffectiveAt.Format(time.RFC3339), pending.Limits.Withdrawal, pending.Limits.Transfer)
+		}
+
+	case "joint":
+		if len(args) < 2 || len(args) > 5 {
+			return errors.New("usage: joint ACCOUNT [add|remove CUSTOMER | signing any|all|all-above [AMOUNT]]")
+		}
+		if err := b.Authorize(userID, bank.ActionChangeState, args[1]); err != nil {
+			return err
+		}
+		if len(args) > 2 {
+			var err error
+			switch {
+			case args[2] == "add" && len(args) == 4:
+				err = b.AddJointOwner(args[1], args[3])
+			case args[2] == "remove" && len(args) == 4:
+				err = b.RemoveJointOwner(args[1], args[3])
+			case args[2] == "signing" && len(args) >= 4:
+				rule := bank.SigningRule{Mode: bank.SigningMode(args[3])}
+				if len(args) == 5 {
+					if rule.Threshold, err = bank.ParseAmount(args[4], ""); err != nil {
+						return err
+					}
+				} else if rule.Mode == bank.SignAllAbove {
+					return errors.New("usage: joint ACCOUNT signing all-above AMOUNT")
+				}
+				err = b.SetSigningRule(args[1], rule)
+			default:
+				return errors.New("usage: joint ACCOUNT [add|remove CUSTOMER | signing any|all|all-above [AMOUNT]]")
+			}
+			if err != nil {
+				return err
+			}
+		}
+		owners := b.OwnersOf(args[1])
+		if len(owners) == 0 {
+			fmt.Println("Owners: none")
+		} else {
+			fmt.Println("Owners:", strings.Join(owners, ", "))
+		}
+		fmt.Println("Signing rule:", b.SigningRuleOf(args[1]))
+
+	case "meta":
+		if len(args) < 2 || len(args) == 3 {
+			return errors.New("usage: meta ACCOUNT [owner NAME...|email ADDRESS|tag TAG...|untag TAG...]")
+		}
+		if len(args) > 2 {
+			if err := b.Authorize(userID, bank.ActionChangeState, args[1]); err != nil {
+				return err
+			}
+			meta, err := b.AccountMetadataOf(args[1])
+			if err != nil {
+				return err
+			}
+			switch args[2] {
+			case "owner":
+				meta.OwnerName = strings.Join(args[3:], " ")
+				err = b.SetAccountMetadata(args[1], meta)
+			case "email":
+				meta.Email = args[3]
+				err = b.SetAccountMetadata(args[1], meta)
+			case "tag":
+				err = b.TagAccount(args[1], args[3:]...)
+			case "untag":
+				err = b.UntagAccount(args[1], args[3:]...)
+			default:
+				return errors.New("usage: meta ACCOUNT [owner NAME...|email ADDRESS|tag TAG...|untag TAG...]")
+			}
+			if err != nil {
+				return err
+			}
+		}
+		meta, err := b.AccountMetadataOf(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Println("Owner name:", meta.OwnerName)
+		fmt.Println("Email:", meta.Email)
+		if meta.CreatedAt.IsZero() {
+			fmt.Println("Opened: unknown")
+		} else {
+			fmt.Println("Opened:", meta.CreatedAt.Format(time.RFC3339))
+		}
+		fmt.Println("Tags:", strings.Join(meta.Tags, ", "))
+
+	case "profile":
+		if len(args) < 2 {
+			return errors.New("usage: profile ACCOUNT [nickname [NAME...]|paper on|off|language [TAG]]")
+		}
+		if len(args) > 2 {
+			if err := b.Authorize(userID, bank.ActionChangeState, args[1]); err != nil {
+				return err
+			}
+			profile, err := b.AccountProfileOf(args[1])
+			if err != nil {
+				return err
+			}
+			prefs := profile.Preferences
+			switch {
+			case args[2] == "nickname":
+				err = b.SetAccountNickname(userID, args[1], strings.Join(args[3:], " "))
+			case args[2] == "paper" && len(args) == 4 && (args[3] == "on" || args[3] == "off"):
+				prefs.PaperStatements = args[3] == "on"
+				err = b.SetAccountPreferences(userID, args[1], prefs)
+			case args[2] == "language" && len(args) <= 4:
+				prefs.Language = strings.Join(args[3:], "")
+				err = b.SetAccountPreferences(userID, args[1], prefs)
+			default:
+				return errors.New("usage: profile ACCOUNT [nickname [NAME...]|paper on|off|language [TAG]]")
+			}
+			if err != nil {
+				return err
+			}
+		}
+		profile, err := b.AccountProfileOf(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Println("Nickname:", profile.Nickname)
+		fmt.Printf("Daily limits: withdrawal %s, transfer %s\n", profile.Limits.Withdrawal, profile.Limits.Transfer)
+		fmt.Println("Paper statements:", profile.Preferences.PaperStatements)
+		language := profile.Preferences.Language
+		if language == "" {
+			language = "default"
+		}
+		fmt.Println("Language:", language)
+		fmt.Println("Owner name:", profile.Metadata.OwnerName)
+		fmt.Println("Email:", profile.Metadata.Email)
+		fmt.Println("Tags:", strings.Join(profile.Metadata.Tags, ", "))
+
+	case "find":
+		if err := b.Authorize(userID, bank.ActionReport, ""); err != nil {
+			return err
+		}
+		var filter bank.AccountFilter
+		for _, arg := range args[1:] {
+			field, value, ok := strings.Cut(arg, "=")
+			if !ok {
+				return errors.New("usage: find [FIELD=VALUE]...")
+			}
+			switch field {
+			case "owner":
+				filter.OwnerName = value
+			case "email":
+				filter.Email = value
+			case "tag":
+				filter.Tags = append(filter.Tags, value)
+			case "customer":
+				filter.Customer = value
+			case "type":
+				filter.Type = value
+			case "state":
+				filter.State = account.State(value)
+			case "since", "before":
+				day, err := time.ParseInLocation("2006-01-02", value, time.Local)
+				if err != nil {
+					return fmt.Errorf("invalid date %q", value)
+				}
+				if field == "since" {
+					filter.CreatedAfter = day
+				} else {
+					filter.CreatedBefore = day
+				}
+			default:
+				return fmt.Errorf("unknown field %q", field)
+			}
+		}
+		matches := b.FindAccounts(filter)
+		for _, m := range matches {
+			opened := ""
+			if !m.Metadata.CreatedAt.IsZero() {
+				opened = m.Metadata.CreatedAt.Format("2006-01-02")
+			}
+			line := fmt.Sprintf("%-14s %-18s %-8s %12s  %-10s  %-20s  %-24s  %s", m.AccountID, m.Type, m.State, m.Balance, opened,
+				m.Metadata.OwnerName, m.Metadata.Email, strings.Join(m.Metadata.Tags, ","))
+			fmt.Println(strings.TrimRight(line, " "))
+		}
+		fmt.Printf("%d accounts\n", len(matches))
+
+	case "convert":
+		if len(args) < 2 || len(args) > 4 {
+			return errors.New("usage: convert ACCOUNT [PRODUCT [YYYY-MM-DD]]")
+		}
+		if len(args) >= 3 {
+			var effectiveAt time.Time
+			if len(args) == 4 {
+				day, err := time.ParseInLocation("2006-01-02", args[3], time.Local)
+				if err != nil {
+					return fmt.Errorf("invalid date %q", args[3])
+				}
+				effectiveAt = day
+			}
+			c, err := b.ConvertAccount(userID, args[1], args[2], effectiveAt)
+			if err != nil {
+				return err
+			}
+			if c.Pending {
+				fmt.Printf("Account %s converts to %s on %s.\n", args[1], c.To, c.EffectiveAt.Format("2006-01-02"))
+				break
+			}
+			fmt.Printf("Account %s converted to %s as %s: interest adjustment %s, fee %s\n", args[1], c.To, c.TransactionID, c.Adjustment, c.Fee)
+			if c.WithholdingErr != nil {
+				fmt.Println("Withholding tax failed:", c.WithholdingErr)
+			}
+			break
+		}
+		product := b.ProductOf(args[1])
+		if product == "" {
+			product = "none"
+		}
+		fmt.Println("Product:", product)
+		if c, pending := b.PendingConversion(args[1]); pending {
+			fmt.Printf("Converting to %s on %s\n", c.To, c.EffectiveAt.Format("2006-01-02"))
+		}
+
+	case "promo":
+		if len(args) != 2 && len(args) != 3 && len(args) != 5 {
+			return errors.New("usage: promo ACCOUNT [RATE FROM TO|off]")
+		}
+		switch len(args) {
+		case 3:
+			if args[2] != "off" {
+				return errors.New("usage: promo ACCOUNT [RATE FROM TO|off]")
+			}
+			if err := b.RemovePromotionalRate(userID, args[1]); err != nil {
+				return err
+			}
+			fmt.Printf("Account %s earns its own rate again.\n", args[1])
+			return nil
+		case 5:
+			rate, err := strconv.ParseFloat(args[2], 64)
+			if err != nil {
+				return fmt.Errorf("invalid rate %q", args[2])
+			}
+			var days [2]time.Time
+			for i, arg := range args[3:] {
+				if days[i], err = time.ParseInLocation("2006-01-02", arg, time.Local); err != nil {
+					return fmt.Errorf("invalid date %q", arg)
+				}
+			}
+			if err := b.SetPromotionalRate(userID, args[1], rate/100, days[0], days[1]); err != nil {
+				return err
+			}
+		}
+		promo, exists := b.PromotionalRateOf(args[1])
+		if !exists {
+			fmt.Println("No promotional rate")
+			break
+		}
+		fmt.Printf("Promotional rate: %g%% from %s until %s\n", promo.Rate*100, promo.Start.Format("2006-01-02"), promo.End.Format("2006-01-02"))
+
+	case "reopen":
+		if len(args) != 2 {
+			return errors.New("usage: reopen ACCOUNT")
+		}
+		if err := b.Authorize(userID, bank.ActionChangeState, args[1]); err != nil {
+			return err
+		}
+		if err := b.ReopenAccount(args[1]); err != nil {
+			return err
+		}
+		fmt.Println("Account", args[1], "reopened")
+
+	case "purge":
+		if len(args) != 2 {
+			return errors.New("usage: purge DAYS")
+		}
+		days, err := strconv.Atoi(args[1])
+		if err != nil || days < 0 {
+			return fmt.Errorf("invalid number of days %q", args[1])
+		}
+		if err := b.Authorize(userID, bank.ActionAdminister, ""); err != nil {
+			return err
+		}
+		purged, err := b.PurgeClosed(time.Duration(days) * 24 * time.Hour)
+		for _, id := range purged {
+			fmt.Println("Purged", id)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d accounts purged\n", len(purged))
+
+	case "eod":
+		runEndOfDay(b)
+
+	case "verify":
+		tb, tbErr := b.TrialBalance()
+		fmt.Printf("Trial balance at %s\n", tb.At.Format(time.RFC1123))
+		fmt.Printf("  %-24s %-8s %14s %14s\n", "Account", "Currency", "Debit", "Credit")
+		for _, line := range tb.Lines {
+			debit, credit := "", ""
+			if line.Debit != 0 {
+				debit = line.Debit.String()
+			}
+			if line.Credit != 0 {
+				credit = line.Credit.String()
+			}
+			fmt.Printf("  %-24s %-8s %14s %14s\n", line.AccountID, line.Currency, debit, credit)
+		}
+		for _, total := range tb.Totals {
+			fmt.Printf("  %-24s %-8s %14s %14s\n", "Total", total.Currency, total.Debits, total.Credits)
+		}
+		for _, txnID := range tb.Orphaned {
+			fmt.Println("Orphaned transaction:", txnID)
+		}
+		failed := false
+		for _, check := range []struct {
+			name string
+			run  func() error
+		}{{"Trial balance", func() error { return tbErr }}, {"Running totals", b.VerifyAggregates}, {"Event log", b.VerifyEventLog}} {
+			if err := check.run(); err != nil {
+				fmt.Printf("%s: FAILED: %v\n", check.name, err)
+				failed = true
+			} else {
+				fmt.Printf("%s: OK\n", check.name)
+			}
+		}
+		if failed {
+			return errors.New("ledger verification failed")
+		}
+
+	case "orphans":
+		if len(args) > 2 || (len(args) == 2 && args[1] != "repair") {
+			return errors.New("usage: orphans [repair]")
+		}
+		orphaned := b.OrphanedEntries()
+		if len(args) == 2 {
+			if err := b.Authorize(userID, bank.ActionAdminister, ""); err != nil {
+				return err
+			}
+			orphaned = b.RepairOrphanedEntries()
+		}
+		for _, o := range orphaned {
+			fmt.Println(o)
+		}
+		if len(args) == 2 {
+			fmt.Printf("%d orphaned entries dropped\n", len(orphaned))
+		} else {
+			fmt.Printf("%d orphaned entries\n", len(orphaned))
+		}
+
+	case "trace":
+		if len(args) != 2 {
+			return errors.New("usage: trace REFERENCE")
+		}
+		found := b.LookupCorrelation(args[1])
+		if len(found.Events) == 0 && len(found.Audit) == 0 {
+			return errors.New("nothing was recorded under " + args[1])
+		}
+		for _, e := range found.Events {
+			fmt.Printf("Event %d: %s %s %s on %s", e.Seq, e.At.Format(time.RFC3339), e.Type, e.Amount, e.AccountID)
+			if e.ToID != "" {
+				fmt.Printf(" to %s", e.ToID)
+			}
+			fmt.Println()
+		}
+		txnIDs := make([]string, 0, len(found.Transactions))
+		for id := range found.Transactions {
+			txnIDs = append(txnIDs, id)
+		}
+		sort.Strings(txnIDs)
+		for _, id := range txnIDs {
+			fmt.Printf("Transaction %s: %s\n", id, found.Transactions[id])
+		}
+		for _, e := range found.Audit {
+			fmt.Printf("Audit: %s %s by %s for %s: %s %s\n", e.Time.Format(time.RFC3339), e.SessionID, e.StaffID, e.CustomerID, e.Action, e.Detail)
+		}
+
+	case "declines":
+		summary := b.DeclineSummary()
+		if len(summary) == 0 {
+			fmt.Println("No failed transactions.")
+		}
+		for _, d := range summary {
+			fmt.Printf("%-22s %d\n", d.Reason, d.Count)
+		}
+
+	case "usage":
+		if len(args) > 2 {
+			return errors.New("usage: usage [MONTH]")
+		}
+		month := time.Now().UTC().Format("2006-01")
+		if len(args) == 2 {
+			if _, err := time.Parse("2006-01", args[1]); err != nil {
+				return fmt.Errorf("invalid month %q", args[1])
+			}
+			month = args[1]
+		}
+		report := b.APIUsageReport(month)
+		if len(report) == 0 {
+			fmt.Println("No API usage.")
+		}
+		for _, u := range report {
+			plan := u.Plan
+			if plan == "" {
+				plan = "unmetered"
+			}
+			fmt.Printf("%s  %d calls on %s, charge %s\n", u.ClientID, u.Total, plan, u.Charge)
+			ops := make([]string, 0, len(u.Calls))
+			for op := range u.Calls {
+				ops = append(ops, op)
+			}
+			sort.Strings(ops)
+			for _, op := range ops {
+				if quota, exists := u.Quotas[op]; exists {
+					fmt.Printf("  %-24s %d of %d\n", op, u.Calls[op], quota)
+				} else {
+					fmt.Printf("  %-24s %d\n", op, u.Calls[op])
+				}
+			}
+		}
+
+	case "auditlog":
+		if len(args) > 2 {
+			return errors.New("usage: auditlog [ACCOUNT]")
+		}
+		accountID := ""
+		if len(args) == 2 {
+			accountID = args[1]
+		}
+		records, err := b.AuditTrail(userID, accountID)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			fmt.Printf("%s  %-10s %-20s %s", r.At.Format(time.RFC3339), r.Actor, r.Action, r.AccountID)
+			if r.ToID != "" {
+				fmt.Printf(" -> %s", r.ToID)
+			}
+			if r.Amount != 0 {
+				fmt.Printf("  %s", r.Amount)
+			}
+			if r.Detail != "" {
+				fmt.Printf("  (%s)", r.Detail)
+			}
+			for _, bal := range r.Balances {
+				fmt.Printf("  %s: %s -> %s", bal.AccountID, bal.Before, bal.After)
+			}
+			fmt.Println()
+		}
+
+	case "audit":
+		var w io.Writer = os.Stdout
+		if len(args) > 1 {
+			f, err := os.Create(args[1])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := b.ExportAudit(userID, w); err != nil {
+			return err
+		}
+
+	case "export":
+		if len(args) != 2 {
+			return errors.New("usage: export DIR")
+		}
+		if err := os.MkdirAll(args[1], 0o755); err != nil {
+			return err
+		}
+		for _, export := range []struct {
+			file string
+			what string
+			run  func(io.Writer) (int, error)
+		}{{"accounts.csv", "accounts", b.ExportAccountsCSV}, {"transactions.csv", "transactions", b.ExportTransactionsCSV}} {
+			path := filepath.Join(args[1], export.file)
+			n, err := writeFile(path, export.run)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Wrote %d %s to %s\n", n, export.what, path)
+		}
+
+	case "graph":
+		if len(args) < 3 || len(args) == 4 || len(args) > 6 {
+			return errors.New("usage: graph FORMAT FILE [FROM TO [MIN]]")
+		}
+		var filter bank.GraphFilter
+		if len(args) >= 5 {
+			for i, t := range []*time.Time{&filter.From, &filter.To} {
+				if args[3+i] == "-" {
+					continue
+				}
+				day, err := time.ParseInLocation("2006-01-02", args[3+i], time.Local)
+				if err != nil {
+					return fmt.Errorf("invalid date %q", args[3+i])
+				}
+				*t = day
+			}
+		}
+		if len(args) == 6 {
+			var err error
+			if filter.MinAmount, err = bank.ParseAmount(args[5], ""); err != nil {
+				return err
+			}
+		}
+		f, err := os.Create(args[2])
+		if err != nil {
+			return err
+		}
+		err = b.ExportTransferGraph(f, bank.GraphFormat(args[1]), filter)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Wrote the transfer graph to %s\n", args[2])
+
+	default:
+		return errors.New("unknown command " + args[0])
+	}
+	return nil
+}
+
+// writeFile creates a file and fills it with write, reporting any error from closing it.
+func writeFile(path string, write func(io.Writer) (int, error)) (int, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	n, err := write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
+
+// runEndOfDay runs the day's batch processing, reporting what each step did.
+func runEndOfDay(b *bank.Bank) {
+	for _, change := range b.ReviewRelationshipTiers() {
+		fmt.Println("Relationship tier:", change)
+	}
+	for _, posting := range b.AccrueInterest() {
+		fmt.Println(posting)
+	}
+	b.ProcessRecurringDeposits()
+	for _, c := range b.ApplyConversions() {
+		if c.Err != nil {
+			fmt.Printf("Conversion of %s to %s failed: %v\n", c.AccountID, c.To, c.Err)
+		} else {
+			fmt.Printf("Account %s converted to %s: interest adjustment %s, fee %s\n", c.AccountID, c.To, c.Adjustment, c.Fee)
+		}
+	}
+	for _, payout := range b.ProcessFixedDepositMaturities() {
+		if payout.Err != nil {
+			fmt.Printf("Fixed deposit %s payout failed: %v\n", payout.AccountID, payout.Err)
+		} else {
+			fmt.Printf("Fixed deposit %s matured: %s paid into %s\n", payout.AccountID, payout.Amount, payout.SavingsID)
+		}
+	}
+	for _, run := range b.RunDueTransfers() {
+		if run.Retrying {
+			fmt.Printf("Scheduled transfer %s attempt %d failed, will retry: %v\n", run.ScheduleID, run.Attempt, run.Err)
+		} else if run.Err != nil {
+			fmt.Printf("Scheduled transfer %s failed: %v\n", run.ScheduleID, run.Err)
+		} else {
+			fmt.Printf("Scheduled transfer %s executed as %s\n", run.ScheduleID, run.TxnID)
+		}
+	}
+	for _, q := range b.ReleaseQueuedTransfers() {
+		if q.Status == bank.QueuedSent {
+			fmt.Printf("Queued transfer %s sent as %s\n", q.ID, q.TransactionID)
+		} else {
+			fmt.Printf("Queued transfer %s failed: %s\n", q.ID, q.Err)
+		}
+	}
+	b.RunScheduledAutoSaves()
+	for _, sweep := range b.RunEndOfDaySweeps() {
+		fmt.Println("Cash concentration:", sweep)
+	}
+	for _, st := range b.AccrueIntercompanyInterest() {
+		if st.Err != nil {
+			fmt.Printf("Intercompany interest for %s in %s failed: %v\n", st.AccountID, st.StructureID, st.Err)
+		} else {
+			fmt.Printf("Intercompany interest of %s settled for %s in %s\n", st.Amount, st.AccountID, st.StructureID)
+		}
+	}
+	b.DeliverHeldNotifications()
+	for _, d := range b.DeliverWebhooks() {
+		if d.Status == bank.WebhookFailed {
+			fmt.Printf("Webhook delivery %s to subscription %s failed: %s\n", d.ID, d.SubscriptionID, d.Err)
+		}
+	}
+	fmt.Println("End-of-day processing complete.")
+}
+
+// manageUsers carries out a users subcommand.
+func manageUsers(staff *bank.StaffDirectory, stdin *bufio.Reader, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: users list|add|remove|passwd|role")
+	}
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		for _, u := range staff.Users() {
+			fmt.Printf("%-20s %-6s added %s\n", u.ID, u.Role, u.CreatedAt.Format("2006-01-02"))
+		}
+	case args[0] == "add" && len(args) == 3:
+		return addUser(staff, stdin, args[1], args[2])
+	case args[0] == "remove" && len(args) == 2:
+		if err := staff.RemoveUser(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("User %s removed.\n", args[1])
+	case args[0] == "passwd" && len(args) == 2:
+		password, err := secret(stdin, "BANKADMIN_NEW_PASSWORD", "New password for "+args[1]+": ")
+		if err != nil {
+			return err
+		}
+		if err := staff.SetPassword(args[1], password); err != nil {
+			return err
+		}
+		fmt.Printf("Password for %s changed.\n", args[1])
+	case args[0] == "role" && len(args) == 3:
+		if err := staff.SetRole(args[1], bank.StaffRole(args[2])); err != nil {
+			return err
+		}
+		fmt.Printf("User %s is now %s.\n", args[1], args[2])
+	default:
+		return errors.New("usage: users list | add ID ROLE | remove ID | passwd ID | role ID ROLE")
+	}
+	return nil
+}
+
+// manageFlags carries out a flags subcommand. Running customer CLIs pick up the change on their next prompt.
+func manageFlags(features *bank.FeatureFlags, args []string) error {
+	usage := errors.New("usage: flags list | set NAME PERCENT | off NAME | tenant NAME TENANT on|off|default | remove NAME")
+	if len(args) == 0 {
+		return usage
+	}
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		for _, f := range features.Flags() {
+			fmt.Printf("%-24s %s\n", f.Name, f)
+			tenants := make([]string, 0, len(f.Tenants))
+			for t := range f.Tenants {
+				tenants = append(tenants, t)
+			}
+			sort.Strings(tenants)
+			for _, t := range tenants {
+				state := "off"
+				if f.Tenants[t] {
+					state = "on"
+				}
+				fmt.Printf("  %-22s %s\n", t, state)
+			}
+		}
+	case args[0] == "set" && len(args) == 3:
+		percent, err := strconv.Atoi(strings.TrimSuffix(args[2], "%"))
+		if err != nil {
+			return errors.New("percent must be a whole number from 0 to 100")
+		}
+		if err := features.SetRollout(args[1], true, percent); err != nil {
+			return err
+		}
+		fmt.Printf("Feature %s is now on for %d%% of tenants.\n", args[1], percent)
+	case args[0] == "off" && len(args) == 2:
+		if err := features.SetRollout(args[1], false, 0); err != nil {
+			return err
+		}
+		fmt.Printf("Feature %s is now off.\n", args[1])
+	case args[0] == "tenant" && len(args) == 4:
+		var override *bool
+		switch args[3] {
+		case "on", "off":
+			on := args[3] == "on"
+			override = &on
+		case "default":
+		default:
+			return usage
+		}
+		if err := features.SetTenant(args[1], args[2], override); err != nil {
+			return err
+		}
+		fmt.Printf("Feature %s for tenant %s is now %s.\n", args[1], args[2], args[3])
+	case args[0] == "remove" && len(args) == 2:
+		if err := features.Remove(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Feature %s removed.\n", args[1])
+	default:
+		return usage
+	}
+	return nil
+}
+
+// addUser adds a staff user, asking for their password.
+func addUser(staff *bank.StaffDirectory, stdin *bufio.Reader, id, role string) error {
+	password, err := secret(stdin, "BANKADMIN_NEW_PASSWORD", "Password for new user "+id+": ")
+	if err != nil {
+		return err
+	}
+	if err := staff.AddUser(id, password, bank.StaffRole(role)); err != nil {
+		return err
+	}
+	fmt.Printf("User %s added as %s.\n", id, role)
+	return nil
+}
+
+// secret reads a password from an environment variable, or prompts for it on standard input.
+func secret(stdin *bufio.Reader, envVar, prompt string) (string, error) {
+	if value := os.Getenv(envVar); value != "" {
+		return value, nil
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.New("no password given")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
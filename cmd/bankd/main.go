@@ -0,0 +1,118 @@
+// Command bankd serves the bank's Accounts, Transfers, Reports, Webhooks and Admin services, defined in
+// proto/bank.proto, as JSON over HTTP so other services can call the banking core without the CLI; see
+// bank.ServiceHandler for the protocol. Callers sign in with HTTP basic authentication as users of the staff
+// directory bankadmin manages; users added while it runs can call it once it is restarted. The bank state is saved
+// after every call and on shutdown. It must not run while the customer CLI has the same data directory open, nor
+// bankadmin except to pause and resume transfers.
+//
+// Usage:
+//
+//	bankd [flags]
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/bank"
+)
+
+func main() {
+	dataDir := flag.String("data", "bank-data", "directory where bank state is persisted")
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	usersPath := flag.String("users", "", "staff directory file (default staff.json in the data directory)")
+	flagsPath := flag.String("flags", "", "feature flags file (default flags.json in the data directory)")
+	pausePath := flag.String("pause", "", "transfer kill switch file (default pause.json in the data directory)")
+	configPath := flag.String("config", "", "fees, limits, benchmarks and holidays (default config.json in the data directory)")
+	snapshotEncoding := flag.String("snapshot-encoding", "json", "encoding of saved snapshots: json or binary")
+	snapshotCompression := flag.String("snapshot-compression", "none", "compression of saved snapshots: none or gzip")
+	flag.Parse()
+	if *usersPath == "" {
+		*usersPath = filepath.Join(*dataDir, "staff.json")
+	}
+	if *flagsPath == "" {
+		*flagsPath = filepath.Join(*dataDir, "flags.json")
+	}
+	if *pausePath == "" {
+		*pausePath = filepath.Join(*dataDir, "pause.json")
+	}
+	if *configPath == "" {
+		*configPath = filepath.Join(*dataDir, "config.json")
+	}
+	format := bank.FormatOptions{Encoding: bank.Encoding(*snapshotEncoding), Compression: bank.Compression(*snapshotCompression)}
+	if err := serve(*addr, *dataDir, *usersPath, *flagsPath, *pausePath, *configPath, format); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+// serve loads the bank and serves its services until interrupted.
+func serve(addr, dataDir, usersPath, flagsPath, pausePath, configPath string, format bank.FormatOptions) error {
+	storage := bank.NewJSONFileStorage(dataDir)
+	if err := storage.SetSnapshotFormat(format); err != nil {
+		return err
+	}
+	b, err := bank.New(storage)
+	if err != nil {
+		return fmt.Errorf("loading bank state: %w", err)
+	}
+	users, err := bank.LoadStaffDirectory(usersPath)
+	if err != nil {
+		return err
+	}
+	if users.Empty() {
+		return errors.New("no users exist yet; add them with bankadmin")
+	}
+	users.RegisterWith(b)
+	features, err := bank.LoadFeatureFlags(flagsPath)
+	if err != nil {
+		return err
+	}
+	b.SetFeatureFlags(features)
+	pause, err := bank.LoadTransferSwitch(pausePath)
+	if err != nil {
+		return err
+	}
+	b.SetTransferSwitch(pause)
+	if err := b.ReloadConfig(configPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	services := bank.NewServiceHandler(b, users, configPath)
+	server := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			services.ServeHTTP(w, r)
+			if err := b.Save(); err != nil {
+				log.Println("Error saving bank state:", err)
+			}
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	served := make(chan error, 1)
+	go func() { served <- server.ListenAndServe() }()
+	log.Println("Serving the bank on", addr)
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdown); err != nil {
+		return err
+	}
+	b.FlushEvents()
+	return b.Save()
+}
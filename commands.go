@@ -0,0 +1,836 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/bank"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// errUsage marks errors in how a command was invoked rather than in what it asked the bank to do.
+var errUsage = errors.New("usage")
+
+// commandResult is what a command reports: a value for -json output and the lines printed otherwise.
+type commandResult struct {
+	value any
+	text  string
+}
+
+// command is one non-interactive subcommand.
+type command struct {
+	usage string // Arguments, shown in help
+	about string
+	run   func(b *bank.Bank, ctx context.Context, user string, args []string) (commandResult, error)
+}
+
+// commands are the subcommands scripts run instead of the interactive menu, e.g.
+// "go-banking-system -user U transfer --from A1 --to A2 --amount 50".
+var commands = map[string]command{
+	"create-account": {"[--id ID] --balance AMOUNT [--type savings|checking|TYPE] [--rate RATE] [--overdraft AMOUNT] [--params JSON]",
+		"open an account, with a new account number unless --id is given; --rate is the interest rate, or the overdraft rate for checking accounts; --params configures a registered custom type", runCreateAccount},
+	"deposit":       {"--account ID --amount AMOUNT [--via CHANNEL] [memo flags]", "deposit into an account; channels are cash (the default), atm, wire, ach and internal", runDeposit},
+	"withdraw":      {"--account ID --amount AMOUNT [--via CHANNEL] [memo flags]", "withdraw from an account; channels needing approval make a request instead", runWithdraw},
+	"balance":       {"--account ID", "show an account's balance", runBalance},
+	"transfer":      {"--from ID --to ID|PAYEE --amount AMOUNT [--country CC] [--yes] [--confirm-duplicate] [memo flags] [travel rule flags]", "move money between accounts; transfers of 10000.00 or more need --yes", runTransfer},
+	"close-account": {"--id ID [--sweep-to ID] --yes", "close an account; under the sweep closure policy its balance moves to --sweep-to, or the owner's default account", runCloseAccount},
+	"history":       {"[--account ID] [--status STATUS] [--type TYPE] [--memo TEXT] [--external-ref REF] [--category TAG] [--limit N] [--offset N]", "list transactions", runHistory},
+	"report":        {"", "show every active account's balance and the totals", runReport},
+	"payees":        {"[--add NICKNAME --account ID | --remove NICKNAME]", "list, add or remove payees", runPayees},
+	"pay":           {"--from ID --to CUSTOMER|ALIAS --amount AMOUNT [--yes]", "transfer to the default account of a customer, by customer ID or alias", runPay},
+	"alias":         {"--alias ALIAS [--customer ID]", "register a payment alias, such as an email address or phone number, for yourself or, for managers, a customer", runAlias},
+	"verify":        {"", "print a trial balance of the customer and GL accounts, failing unless debits equal credits and no transaction or account state is orphaned", runVerify},
+	"default-account": {"--account ID [--customer ID]",
+		"choose the account that receives payments addressed to you or, for managers, a customer", runDefaultAccount},
+}
+
+// printCommandHelp lists the subcommands.
+func printCommandHelp(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w, "Commands (run without one for the interactive menu):")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s %s\n      %s\n", name, commands[name].usage, commands[name].about)
+	}
+}
+
+// runCommand runs a subcommand as the signed-in user, prints its result as text or JSON, saves the bank and
+// returns the process exit code: 0 on success, 1 if the bank refused the operation and 2 for a usage error.
+func runCommand(b *bank.Bank, user string, args []string, jsonOutput bool) int {
+	cmd, exists := commands[args[0]]
+	if !exists {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", args[0])
+		printCommandHelp(os.Stderr)
+		return 2
+	}
+	ctx := bank.ContextWithCorrelationID(bank.ContextWithChannel(bank.ContextWithUser(context.Background(), user), bank.ChannelCLI), bank.NewCorrelationID())
+	result, err := cmd.run(b, ctx, user, args[1:])
+	if saveErr := b.Save(); err == nil && saveErr != nil {
+		err = fmt.Errorf("saving bank state: %w", saveErr)
+	}
+	if err != nil {
+		code := 1
+		if errors.Is(err, errUsage) {
+			code = 2
+		}
+		if jsonOutput {
+			out := struct {
+				Error     string `json:"error"`
+				Reason    string `json:"reason,omitempty"`
+				Reference string `json:"reference"`
+			}{Error: strings.TrimPrefix(err.Error(), "usage: "), Reference: bank.CorrelationIDFromContext(ctx)}
+			if code == 1 {
+				out.Reason = string(bank.ReasonOf(err))
+			}
+			printJSON(out)
+		} else if code == 2 {
+			fmt.Fprintf(os.Stderr, "%v\n%s %s\n", err, args[0], cmd.usage)
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %v [%s]\nReference: %s\n", err, bank.ReasonOf(err), bank.CorrelationIDFromContext(ctx))
+		}
+		return code
+	}
+	if jsonOutput {
+		printJSON(result.value)
+	} else {
+		fmt.Print(result.text)
+	}
+	return 0
+}
+
+// printJSON writes a value to standard output as one line of JSON.
+func printJSON(v any) {
+	if err := json.NewEncoder(os.Stdout).Encode(v); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+}
+
+// usageError reports a mistake in a command's arguments.
+func usageError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, args...))
+}
+
+// parseCommandFlags parses a command's flags, which may be written with one dash or two, and rejects stray
+// arguments and missing required flags.
+func parseCommandFlags(fs *flag.FlagSet, args []string, required ...string) error {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return usageError("%v", err)
+	}
+	if fs.NArg() > 0 {
+		return usageError("unexpected argument %q", fs.Arg(0))
+	}
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, name := range required {
+		if !set[name] {
+			return usageError("--%s is required", name)
+		}
+	}
+	return nil
+}
+
+// amountFlag parses an amount flag in an account's currency, which must not be negative.
+func amountFlag(b *bank.Bank, name, text, accountID string) (account.Money, error) {
+	amount, err := bank.ParseAmount(text, currencyOf(b, accountID))
+	if err != nil {
+		return 0, usageError("--%s: %v", name, err)
+	}
+	if amount < 0 {
+		return 0, usageError("--%s must not be negative", name)
+	}
+	return amount, nil
+}
+
+// operationAmountFlag parses the --amount flag of a deposit, withdrawal or transfer from an account, which must be
+// valid for the operation under the bank's amount policy.
+func operationAmountFlag(b *bank.Bank, op transaction.OperationType, text, accountID string) (account.Money, error) {
+	amount, err := amountFlag(b, "amount", text, accountID)
+	if err != nil {
+		return 0, err
+	}
+	if err := b.ValidateAmount(op, accountID, amount); err != nil {
+		return 0, usageError("--amount: %v", err)
+	}
+	return amount, nil
+}
+
+// accountReply describes an account in JSON output. Amounts are in minor units.
+type accountReply struct {
+	AccountID      string `json:"accountId"`
+	Currency       string `json:"currency"`
+	BalanceMinor   int64  `json:"balanceMinor"`
+	AvailableMinor int64  `json:"availableMinor"`
+	HeldMinor      int64  `json:"heldMinor"`
+	State          string `json:"state"`
+	TransactionID  string `json:"transactionId,omitempty"` // Of a deposit or withdrawal recorded with a memo
+	Reference      string `json:"reference,omitempty"`
+}
+
+// metadataReply describes an account's metadata in JSON output.
+type metadataReply struct {
+	OwnerName string    `json:"ownerName,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	Created   time.Time `json:"created,omitzero"`
+	Tags      []string  `json:"tags,omitempty"`
+}
+
+// describeMetadata writes the owner name, email address, opening date and tags of an account for a report line, each preceded by
+// ", ", or "" if it has none.
+func describeMetadata(b *bank.Bank, accountID string) string {
+	meta, err := b.AccountMetadataOf(accountID)
+	if err != nil {
+		return ""
+	}
+	var sb strings.Builder
+	if meta.OwnerName != "" {
+		sb.WriteString(", Owner: " + meta.OwnerName)
+	}
+	if meta.Email != "" {
+		sb.WriteString(", Email: " + meta.Email)
+	}
+	if !meta.CreatedAt.IsZero() {
+		sb.WriteString(", Opened: " + meta.CreatedAt.Local().Format("2006-01-02"))
+	}
+	if len(meta.Tags) > 0 {
+		sb.WriteString(", Tags: " + strings.Join(meta.Tags, " "))
+	}
+	return sb.String()
+}
+
+// describeAccount reports an account's balances and state.
+func describeAccount(b *bank.Bank, accountID string) (accountReply, error) {
+	balances, err := b.Balances(accountID)
+	if err != nil {
+		return accountReply{}, err
+	}
+	state, err := b.AccountStateOf(accountID)
+	if err != nil {
+		return accountReply{}, err
+	}
+	return accountReply{
+		AccountID:      accountID,
+		Currency:       currencyOf(b, accountID),
+		BalanceMinor:   int64(balances.Ledger),
+		AvailableMinor: int64(balances.Available),
+		HeldMinor:      int64(balances.Held),
+		State:          string(state),
+	}, nil
+}
+
+// runCreateAccount opens a savings, checking or registered custom account.
+func runCreateAccount(b *bank.Bank, ctx context.Context, user string, args []string) (commandResult, error) {
+	fs := flag.NewFlagSet("create-account", flag.ContinueOnError)
+	id := fs.String("id", "", "account ID; a new account number if not given")
+	accountType := fs.String("type", "savings", "savings, checking or a registered custom type")
+	balanceText := fs.String("balance", "", "opening balance")
+	rate := fs.Float64("rate", 0, "interest rate, or overdraft interest rate for checking accounts")
+	overdraftText := fs.String("overdraft", "0", "overdraft limit of a checking account")
+	params := fs.String("params", "", "parameters of a custom account type, as JSON")
+	if err := parseCommandFlags(fs, args, "balance"); err != nil {
+		return commandResult{}, err
+	}
+	if *id != "" {
+		if err := bank.ValidateAccountID(*id); err != nil {
+			return commandResult{}, usageError("--id: %v", err)
+		}
+	}
+	custom := *accountType != "savings" && *accountType != "checking"
+	if custom && !slices.Contains(bank.AccountTypes(), *accountType) {
+		return commandResult{}, usageError("--type must be savings, checking or a registered type (%s)", strings.Join(bank.AccountTypes(), ", "))
+	}
+	if !custom && *params != "" {
+		return commandResult{}, usageError("--params only applies to custom account types")
+	}
+	if *params != "" && !json.Valid([]byte(*params)) {
+		return commandResult{}, usageError("--params must be a JSON document")
+	}
+	if *rate < 0 {
+		return commandResult{}, usageError("--rate must not be negative")
+	}
+	balance, err := amountFlag(b, "balance", *balanceText, "")
+	if err != nil {
+		return commandResult{}, err
+	}
+	overdraft, err := amountFlag(b, "overdraft", *overdraftText, "")
+	if err != nil {
+		return commandResult{}, err
+	}
+	if *accountType != "checking" && overdraft != 0 {
+		return commandResult{}, usageError("--overdraft only applies to checking accounts")
+	}
+	if custom && *rate != 0 {
+		return commandResult{}, usageError("--rate does not apply to custom account types; use --params")
+	}
+	if err := b.AuthorizeOpen(user, balance); err != nil {
+		return commandResult{}, err
+	}
+	if *id == "" {
+		*id = b.NewAccountID()
+	}
+	err = b.RunCorrelated(ctx, []string{*id}, func() error {
+		switch {
+		case custom:
+			var raw json.RawMessage
+			if *params != "" {
+				raw = json.RawMessage(*params)
+			}
+			_, err := b.NewCustomAccount(*accountType, *id, balance, raw)
+			return err
+		case *accountType == "checking":
+			_, err := b.NewCheckingAccount(*id, balance, overdraft, *rate)
+			return err
+		default:
+			_, err := b.NewSavingsAccount(*id, balance, *rate)
+			return err
+		}
+	})
+	if err != nil {
+		return commandResult{}, err
+	}
+	if err := b.OpenedBy(user, *id); err != nil {
+		return commandResult{}, err
+	}
+	reply, err := describeAccount(b, *id)
+	if err != nil {
+		return commandResult{}, err
+	}
+	reply.Reference = bank.CorrelationIDFromContext(ctx)
+	return commandResult{reply, fmt.Sprintf("%s%s account created with ID %s\n", strings.ToUpper((*accountType)[:1]), (*accountType)[1:], *id)}, nil
+}
+
+// runDeposit deposits into an account.
+func runDeposit(b *bank.Bank, ctx context.Context, user string, args []string) (commandResult, error) {
+	return runCash(b, ctx, user, args, "deposit", bank.ActionDeposit, b.DepositWithMemo)
+}
+
+// runWithdraw withdraws from an account.
+func runWithdraw(b *bank.Bank, ctx context.Context, user string, args []string) (commandResult, error) {
+	return runCash(b, ctx, user, args, "withdraw", bank.ActionWithdraw, b.WithdrawWithMemo)
+}
+
+// withdrawalReply describes a withdrawal waiting for approval in JSON output.
+type withdrawalReply struct {
+	ID          string `json:"id"`
+	AccountID   string `json:"accountId"`
+	AmountMinor int64  `json:"amountMinor"`
+	Channel     string `json:"channel"`
+	Status      string `json:"status"`
+	Reference   string `json:"reference"`
+}
+
+// memoFlags registers the flags that put a memo on a transaction.
+func memoFlags(fs *flag.FlagSet) *transaction.Memo {
+	var memo transaction.Memo
+	fs.StringVar(&memo.Text, "memo", "", "memo recorded with the transaction")
+	fs.StringVar(&memo.Reference, "external-ref", "", "reference number of the payment outside the bank, such as an invoice number")
+	fs.StringVar(&memo.Category, "category", "", "category tag recorded with the transaction")
+	return &memo
+}
+
+// runCash moves money into or out of an account over a channel with apply, reporting the balance afterwards.
+// Withdrawals over channels that need approval are requested instead.
+func runCash(b *bank.Bank, ctx context.Context, user string, args []string, name string, action bank.Action, apply func(string, account.Money, bank.Rail, transaction.Memo) (string, error)) (commandResult, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	accountID := fs.String("account", "", "account ID")
+	amountText := fs.String("amount", "", "amount")
+	via := fs.String("via", string(bank.RailCash), "channel: cash, atm, wire, ach or internal")
+	memo := memoFlags(fs)
+	if err := parseCommandFlags(fs, args, "account", "amount"); err != nil {
+		return commandResult{}, err
+	}
+	op := transaction.OpDeposit
+	if action == bank.ActionWithdraw {
+		op = transaction.OpWithdrawal
+	}
+	amount, err := operationAmountFlag(b, op, *amountText, *accountID)
+	if err != nil {
+		return commandResult{}, err
+	}
+	rail, err := bank.ParseRail(*via)
+	if err != nil {
+		return commandResult{}, usageError("%v", err)
+	}
+	if err := memo.Validate(); err != nil {
+		return commandResult{}, usageError("%v", err)
+	}
+	if err := b.Authorize(user, action, *accountID); err != nil {
+		return commandResult{}, err
+	}
+	if action == bank.ActionWithdraw && b.NeedsApproval(rail) {
+		if !memo.IsZero() {
+			return commandResult{}, usageError("withdrawals needing approval cannot carry a memo")
+		}
+		var w bank.WithdrawalApproval
+		err := b.RunCorrelated(ctx, []string{*accountID}, func() error {
+			var err error
+			w, err = b.RequestWithdrawal(*accountID, amount, rail)
+			return err
+		})
+		if err != nil {
+			return commandResult{}, err
+		}
+		reply := withdrawalReply{ID: w.ID, AccountID: w.AccountID, AmountMinor: int64(w.Amount), Channel: string(w.Rail), Status: string(w.Status), Reference: bank.CorrelationIDFromContext(ctx)}
+		return commandResult{reply, fmt.Sprintf("Withdrawal %s of %s by %s is waiting for a manager's approval; the money is taken once it is approved\nReference: %s\n", w.ID, w.Amount, w.Rail, reply.Reference)}, nil
+	}
+	var txnID string
+	err = b.RunCorrelated(ctx, []string{*accountID}, func() error {
+		var err error
+		txnID, err = apply(*accountID, amount, rail, *memo)
+		return err
+	})
+	if err != nil {
+		return commandResult{}, err
+	}
+	reply, err := describeAccount(b, *accountID)
+	if err != nil {
+		return commandResult{}, err
+	}
+	reply.TransactionID = txnID
+	reply.Reference = bank.CorrelationIDFromContext(ctx)
+	text := fmt.Sprintf("Balance for %s is now %s\nReference: %s\n", *accountID, account.Money(reply.BalanceMinor), reply.Reference)
+	if txnID != "" {
+		text = fmt.Sprintf("Recorded as %s\n", txnID) + text
+	}
+	return commandResult{reply, text}, nil
+}
+
+// runBalance shows an account's balances.
+func runBalance(b *bank.Bank, ctx context.Context, user string, args []string) (commandResult, error) {
+	fs := flag.NewFlagSet("balance", flag.ContinueOnError)
+	accountID := fs.String("account", "", "account ID")
+	if err := parseCommandFlags(fs, args, "account"); err != nil {
+		return commandResult{}, err
+	}
+	if err := b.Authorize(user, bank.ActionView, *accountID); err != nil {
+		return commandResult{}, err
+	}
+	reply, err := describeAccount(b, *accountID)
+	if err != nil {
+		return commandResult{}, err
+	}
+	text := fmt.Sprintf("Balance for %s is %s (%s)\n", *accountID, account.Money(reply.BalanceMinor), reply.State)
+	if reply.HeldMinor > 0 {
+		text += fmt.Sprintf("Available: %s (%s on hold)\n", account.Money(reply.AvailableMinor), account.Money(reply.HeldMinor))
+	}
+	return commandResult{reply, text}, nil
+}
+
+// transferReply describes a transfer in JSON output.
+type transferReply struct {
+	TransactionID string `json:"transactionId"`
+	From          string `json:"from"`
+	To            string `json:"to"`
+	AmountMinor   int64  `json:"amountMinor"`
+	DuplicateOf   string `json:"duplicateOf,omitempty"`
+	Replayed      bool   `json:"replayed,omitempty"`
+	Reference     string `json:"reference"`
+}
+
+// runTransfer moves money between accounts. Transfers the interactive menu would confirm need --yes, and those
+// held back as likely duplicates need --confirm-duplicate.
+func runTransfer(b *bank.Bank, ctx context.Context, user string, args []string) (commandResult, error) {
+	fs := flag.NewFlagSet("transfer", flag.ContinueOnError)
+	fromID := fs.String("from", "", "source account ID")
+	toID := fs.String("to", "", "destination account ID or payee nickname")
+	amountText := fs.String("amount", "", "amount, in the source account's currency")
+	country := fs.String("country", "", "destination country, blank if domestic")
+	yes := fs.Bool("yes", false, "confirm a large transfer")
+	confirmDuplicate := fs.Bool("confirm-duplicate", false, "send a transfer that looks like a duplicate")
+	memo := memoFlags(fs)
+	var travelRule bank.TravelRuleData
+	fs.StringVar(&travelRule.Originator.Name, "originator-name", "", "originator name, for transfers the travel rule covers")
+	fs.StringVar(&travelRule.Originator.Address, "originator-address", "", "originator address, for transfers the travel rule covers")
+	fs.StringVar(&travelRule.Beneficiary.Name, "beneficiary-name", "", "beneficiary name, for transfers the travel rule covers")
+	if err := parseCommandFlags(fs, args, "from", "to", "amount"); err != nil {
+		return commandResult{}, err
+	}
+	if err := countryCode(*country); err != nil {
+		return commandResult{}, usageError("--country: %v", err)
+	}
+	if payeeAccount, ok := b.PayeeAccount(user, *toID); ok {
+		*toID = payeeAccount
+	}
+	amount, err := operationAmountFlag(b, transaction.OpTransfer, *amountText, *fromID)
+	if err != nil {
+		return commandResult{}, err
+	}
+	if err := memo.Validate(); err != nil {
+		return commandResult{}, usageError("%v", err)
+	}
+	opts := bank.TransferOptions{Country: *country, ConfirmDuplicate: *confirmDuplicate, Memo: *memo}
+	if b.TravelRuleRequired(amount) {
+		opts.TravelRule = &travelRule
+	}
+	if err := b.Authorize(user, bank.ActionTransfer, *fromID); err != nil {
+		return commandResult{}, err
+	}
+	if amount >= largeTransfer && !*yes {
+		return commandResult{}, usageError("transfers of %s or more need --yes", largeTransfer)
+	}
+	var result bank.TransferResult
+	err = b.RunCorrelated(ctx, []string{*fromID, *toID}, func() error {
+		var err error
+		result, err = b.TransferChecked(*fromID, *toID, amount, opts)
+		return err
+	})
+	var dupErr *bank.DuplicateTransferError
+	if errors.As(err, &dupErr) && dupErr.NeedsConfirmation {
+		return commandResult{}, fmt.Errorf("%w; pass --confirm-duplicate to send it anyway", err)
+	}
+	if err != nil {
+		return commandResult{}, err
+	}
+	reply := transferReply{
+		TransactionID: result.TxnID,
+		From:          *fromID,
+		To:            *toID,
+		AmountMinor:   int64(amount),
+		DuplicateOf:   result.DuplicateOf,
+		Replayed:      result.Replayed,
+		Reference:     bank.CorrelationIDFromContext(ctx),
+	}
+	text := fmt.Sprintf("Transferred %s from %s to %s as %s\nReference: %s\n", amount, *fromID, *toID, result.TxnID, reply.Reference)
+	if result.DuplicateOf != "" {
+		text = fmt.Sprintf("Warning: possible duplicate of %s.\n", result.DuplicateOf) + text
+	}
+	return commandResult{reply, text}, nil
+}
+
+// runPay transfers money to the default account of a customer addressed by customer ID or alias.
+func runPay(b *bank.Bank, ctx context.Context, user string, args []string) (commandResult, error) {
+	fs := flag.NewFlagSet("pay", flag.ContinueOnError)
+	fromID := fs.String("from", "", "source account ID")
+	addressee := fs.String("to", "", "customer ID or alias of the customer paid")
+	amountText := fs.String("amount", "", "amount, in the source account's currency")
+	yes := fs.Bool("yes", false, "confirm a large transfer")
+	if err := parseCommandFlags(fs, args, "from", "to", "amount"); err != nil {
+		return commandResult{}, err
+	}
+	amount, err := operationAmountFlag(b, transaction.OpTransfer, *amountText, *fromID)
+	if err != nil {
+		return commandResult{}, err
+	}
+	if err := b.Authorize(user, bank.ActionTransfer, *fromID); err != nil {
+		return commandResult{}, err
+	}
+	if amount >= largeTransfer && !*yes {
+		return commandResult{}, usageError("transfers of %s or more need --yes", largeTransfer)
+	}
+	var toID, txnID string
+	err = b.RunCorrelated(ctx, []string{*fromID}, func() error {
+		var err error
+		toID, txnID, err = b.TransferToCustomer(*fromID, *addressee, amount)
+		return err
+	})
+	if err != nil {
+		return commandResult{}, err
+	}
+	reply := transferReply{TransactionID: txnID, From: *fromID, To: toID, AmountMinor: int64(amount), Reference: bank.CorrelationIDFromContext(ctx)}
+	text := fmt.Sprintf("Paid %s from %s to %s as %s\nReference: %s\n", amount, *fromID, *addressee, txnID, reply.Reference)
+	return commandResult{reply, text}, nil
+}
+
+// runAlias registers a payment alias for the signed-in customer, or for a customer a manager names.
+func runAlias(b *bank.Bank, ctx context.Context, user string, args []string) (commandResult, error) {
+	fs := flag.NewFlagSet("alias", flag.ContinueOnError)
+	alias := fs.String("alias", "", "alias, such as an email address, phone number or handle")
+	customerID := fs.String("customer", user, "customer the alias addresses")
+	if err := parseCommandFlags(fs, args, "alias"); err != nil {
+		return commandResult{}, err
+	}
+	if err := b.RegisterAlias(user, *alias, *customerID); err != nil {
+		return commandResult{}, err
+	}
+	reply := struct {
+		Alias    string `json:"alias"`
+		Customer string `json:"customer"`
+	}{*alias, *customerID}
+	return commandResult{reply, fmt.Sprintf("Alias %s now addresses %s\n", *alias, *customerID)}, nil
+}
+
+// runDefaultAccount chooses the account that receives payments addressed to the signed-in customer, or to a
+// customer a manager names.
+func runDefaultAccount(b *bank.Bank, ctx context.Context, user string, args []string) (commandResult, error) {
+	fs := flag.NewFlagSet("default-account", flag.ContinueOnError)
+	accountID := fs.String("account", "", "account ID")
+	customerID := fs.String("customer", user, "customer whose default account it becomes")
+	if err := parseCommandFlags(fs, args, "account"); err != nil {
+		return commandResult{}, err
+	}
+	if err := b.SetDefaultAccount(user, *customerID, *accountID); err != nil {
+		return commandResult{}, err
+	}
+	reply := struct {
+		Customer  string `json:"customer"`
+		AccountID string `json:"accountId"`
+	}{*customerID, *accountID}
+	return commandResult{reply, fmt.Sprintf("Payments addressed to %s now go to %s\n", *customerID, *accountID)}, nil
+}
+
+// runCloseAccount closes an account. It needs --yes, since closing cannot be undone.
+func runCloseAccount(b *bank.Bank, ctx context.Context, user string, args []string) (commandResult, error) {
+	fs := flag.NewFlagSet("close-account", flag.ContinueOnError)
+	id := fs.String("id", "", "account ID")
+	sweepTo := fs.String("sweep-to", "", "account the remaining balance moves to; the owner's default account if not given")
+	yes := fs.Bool("yes", false, "confirm closing the account")
+	if err := parseCommandFlags(fs, args, "id"); err != nil {
+		return commandResult{}, err
+	}
+	if !*yes {
+		return commandResult{}, usageError("closing an account cannot be undone; pass --yes")
+	}
+	if err := b.Authorize(user, bank.ActionClose, *id); err != nil {
+		return commandResult{}, err
+	}
+	if !b.IsAccountActive(*id) {
+		return commandResult{}, errors.New("account is already inactive")
+	}
+	var sweepID string
+	err := b.RunCorrelated(ctx, []string{*id, *sweepTo}, func() error {
+		var err error
+		sweepID, err = b.CloseTo(*id, *sweepTo)
+		return err
+	})
+	if err != nil {
+		return commandResult{}, err
+	}
+	reply, err := describeAccount(b, *id)
+	if err != nil {
+		return commandResult{}, err
+	}
+	reply.Reference = bank.CorrelationIDFromContext(ctx)
+	text := fmt.Sprintf("Account %s closed\nReference: %s\n", *id, reply.Reference)
+	if sweepID != "" {
+		text = fmt.Sprintf("Remaining balance swept as %s\n", sweepID) + text
+	}
+	return commandResult{reply, text}, nil
+}
+
+// historyEntryReply describes a transaction in JSON output.
+type historyEntryReply struct {
+	TransactionID string `json:"transactionId"`
+	Recorded      string `json:"recorded,omitempty"`
+	Type          string `json:"type"`
+	From          string `json:"from,omitempty"`
+	To            string `json:"to,omitempty"`
+	Account       string `json:"account,omitempty"`
+	AmountMinor   int64  `json:"amountMinor"`
+	Status        string `json:"status,omitempty"`
+	Channel       string `json:"channel,omitempty"`
+	ReversedBy    string `json:"reversedBy,omitempty"`
+	ReversalOf    string `json:"reversalOf,omitempty"`
+	Memo          string `json:"memo,omitempty"`
+	ExternalRef   string `json:"externalRef,omitempty"`
+	Category      string `json:"category,omitempty"`
+}
+
+// runHistory lists one page of transactions.
+func runHistory(b *bank.Bank, ctx context.Context, user string, args []string) (commandResult, error) {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	var q bank.HistoryQuery
+	var historyType string
+	fs.StringVar(&q.AccountID, "account", "", "only transactions touching this account")
+	fs.StringVar(&q.Status, "status", "", "only transactions with this status")
+	fs.StringVar(&historyType, "type", "", "only transactions of this type")
+	fs.StringVar(&q.Memo, "memo", "", "only transactions whose memo contains this text")
+	fs.StringVar(&q.Reference, "external-ref", "", "only transactions with this external reference")
+	fs.StringVar(&q.Category, "category", "", "only transactions tagged with this category")
+	fs.IntVar(&q.Limit, "limit", 20, "transactions per page")
+	fs.IntVar(&q.Offset, "offset", 0, "transactions to skip")
+	if err := parseCommandFlags(fs, args); err != nil {
+		return commandResult{}, err
+	}
+	if q.Limit < 1 || q.Offset < 0 {
+		return commandResult{}, usageError("--limit must be at least 1 and --offset not negative")
+	}
+	q.Type = bank.HistoryType(historyType)
+	action := bank.ActionReport
+	if q.AccountID != "" {
+		action = bank.ActionView
+	}
+	if err := b.Authorize(user, action, q.AccountID); err != nil {
+		return commandResult{}, err
+	}
+	page, err := b.History(q)
+	if err != nil {
+		return commandResult{}, err
+	}
+	reply := struct {
+		Entries    []historyEntryReply `json:"entries"`
+		Total      int                 `json:"total"`
+		NextOffset int                 `json:"nextOffset,omitempty"`
+	}{Entries: make([]historyEntryReply, 0, len(page.Entries)), Total: page.Total, NextOffset: page.NextOffset}
+	var text strings.Builder
+	for _, h := range page.Entries {
+		e := historyEntryReply{TransactionID: h.TransactionID, Type: string(h.Type), From: h.From, To: h.To,
+			Account: h.Account, AmountMinor: int64(h.Amount), Status: h.Status, Channel: string(h.Channel), ReversedBy: h.ReversedBy, ReversalOf: h.ReversalOf,
+			Memo: h.Memo, ExternalRef: h.Reference, Category: h.Category}
+		if !h.Recorded.IsZero() {
+			e.Recorded = h.Recorded.Format(time.RFC3339Nano)
+		}
+		reply.Entries = append(reply.Entries, e)
+		text.WriteString(h.Entry)
+	}
+	if page.NextOffset == 0 {
+		fmt.Fprintf(&text, "END (%d transactions)\n", page.Total)
+	} else {
+		fmt.Fprintf(&text, "Showing %d of %d; run again with --offset %d for more\n", page.NextOffset, page.Total, page.NextOffset)
+	}
+	return commandResult{reply, text.String()}, nil
+}
+
+// runReport shows every active account's balance and the totals.
+func runReport(b *bank.Bank, ctx context.Context, user string, args []string) (commandResult, error) {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	if err := parseCommandFlags(fs, args); err != nil {
+		return commandResult{}, err
+	}
+	if err := b.Authorize(user, bank.ActionReport, ""); err != nil {
+		return commandResult{}, err
+	}
+	balances, totals := b.Report()
+	reply := struct {
+		Accounts     map[string]int64         `json:"accountsMinor"` // Map of account ID to balance
+		Metadata     map[string]metadataReply `json:"metadata"`      // Map of account ID to owner details, opening time and tags
+		ByTypeMinor  map[string]int64         `json:"byTypeMinor"`
+		AccountCount int                      `json:"accountCount"`
+		TotalMinor   int64                    `json:"totalMinor"`
+	}{Accounts: make(map[string]int64, len(balances)), Metadata: make(map[string]metadataReply), ByTypeMinor: make(map[string]int64, len(totals.ByType)),
+		AccountCount: totals.Accounts, TotalMinor: int64(totals.Total)}
+	ids := make([]string, 0, len(balances))
+	for id, balance := range balances {
+		reply.Accounts[id] = int64(balance)
+		if meta, err := b.AccountMetadataOf(id); err == nil {
+			reply.Metadata[id] = metadataReply{OwnerName: meta.OwnerName, Email: meta.Email, Created: meta.CreatedAt, Tags: meta.Tags}
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var text strings.Builder
+	for _, id := range ids {
+		fmt.Fprintf(&text, "Account ID: %s, Balance: %s%s\n", id, balances[id], describeMetadata(b, id))
+	}
+	for accountType, balance := range totals.ByType {
+		reply.ByTypeMinor[accountType] = int64(balance)
+		fmt.Fprintf(&text, "Type: %s, Balance: %s\n", accountType, balance)
+	}
+	fmt.Fprintf(&text, "Accounts: %d\nTotal Balance: %s\n", totals.Accounts, totals.Total)
+	return commandResult{reply, text.String()}, nil
+}
+
+// trialBalanceLineReply is one line of a trial balance in JSON output.
+type trialBalanceLineReply struct {
+	Account     string `json:"account"`
+	GL          bool   `json:"gl,omitempty"`
+	Currency    string `json:"currency"`
+	DebitMinor  int64  `json:"debitMinor"`
+	CreditMinor int64  `json:"creditMinor"`
+}
+
+// runVerify prints the trial balance and checks the ledger.
+func runVerify(b *bank.Bank, ctx context.Context, user string, args []string) (commandResult, error) {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	if err := parseCommandFlags(fs, args); err != nil {
+		return commandResult{}, err
+	}
+	if err := b.Authorize(user, bank.ActionReport, ""); err != nil {
+		return commandResult{}, err
+	}
+	tb, err := b.TrialBalance()
+	if err != nil {
+		return commandResult{}, err
+	}
+	reply := struct {
+		At     time.Time               `json:"at"`
+		Lines  []trialBalanceLineReply `json:"lines"`
+		Totals []trialBalanceLineReply `json:"totals"`
+	}{At: tb.At}
+	var text strings.Builder
+	fmt.Fprintf(&text, "Trial balance at %s\n", tb.At.Format(time.RFC1123))
+	for _, line := range tb.Lines {
+		reply.Lines = append(reply.Lines, trialBalanceLineReply{Account: line.AccountID, GL: line.GL, Currency: line.Currency, DebitMinor: int64(line.Debit), CreditMinor: int64(line.Credit)})
+		fmt.Fprintf(&text, "Account: %s, Currency: %s, Debit: %s, Credit: %s\n", line.AccountID, line.Currency, line.Debit, line.Credit)
+	}
+	for _, total := range tb.Totals {
+		reply.Totals = append(reply.Totals, trialBalanceLineReply{Account: "total", Currency: total.Currency, DebitMinor: int64(total.Debits), CreditMinor: int64(total.Credits)})
+		fmt.Fprintf(&text, "Total %s, Debits: %s, Credits: %s\n", total.Currency, total.Debits, total.Credits)
+	}
+	return commandResult{reply, text.String()}, nil
+}
+
+// payeeReply describes a payee in JSON output. Times are RFC 3339.
+type payeeReply struct {
+	Nickname  string    `json:"nickname"`
+	AccountID string    `json:"accountId"`
+	Added     time.Time `json:"added"`
+	Cleared   time.Time `json:"cleared"` // When transfers to the payee may first be made
+}
+
+// runPayees lists the signed-in customer's payees, after adding or removing one if asked to.
+func runPayees(b *bank.Bank, ctx context.Context, user string, args []string) (commandResult, error) {
+	fs := flag.NewFlagSet("payees", flag.ContinueOnError)
+	add := fs.String("add", "", "nickname of a payee to add")
+	accountID := fs.String("account", "", "account ID of the payee to add")
+	remove := fs.String("remove", "", "nickname of a payee to remove")
+	if err := parseCommandFlags(fs, args); err != nil {
+		return commandResult{}, err
+	}
+	var text strings.Builder
+	switch {
+	case *add != "" && *remove != "":
+		return commandResult{}, usageError("give --add or --remove, not both")
+	case *add != "":
+		if *accountID == "" {
+			return commandResult{}, usageError("--add needs --account")
+		}
+		p, err := b.AddPayee(user, *add, *accountID)
+		if err != nil {
+			return commandResult{}, err
+		}
+		fmt.Fprintf(&text, "Added payee %s; transfers to it can be made from %s\n", p.Nickname, b.PayeeCleared(p).Format(time.RFC1123))
+	case *remove != "":
+		if err := b.RemovePayee(user, *remove); err != nil {
+			return commandResult{}, err
+		}
+		fmt.Fprintf(&text, "Removed payee %s\n", *remove)
+	case *accountID != "":
+		return commandResult{}, usageError("--account is only used with --add")
+	}
+	payees := b.Payees(user)
+	reply := make([]payeeReply, 0, len(payees))
+	for _, p := range payees {
+		reply = append(reply, payeeReply{Nickname: p.Nickname, AccountID: p.AccountID, Added: p.AddedAt, Cleared: b.PayeeCleared(p)})
+	}
+	writePayees(&text, reply)
+	return commandResult{reply, text.String()}, nil
+}
+
+// writePayees lists payees as a table, saying which are still cooling off.
+func writePayees(w io.Writer, payees []payeeReply) {
+	if len(payees) == 0 {
+		fmt.Fprintln(w, "No payees.")
+		return
+	}
+	fmt.Fprintf(w, "%-20s  %-14s  %s\n", "Payee", "Account", "Transfers")
+	for _, p := range payees {
+		status := "allowed"
+		if p.Cleared.After(time.Now()) {
+			status = "from " + p.Cleared.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%-20s  %-14s  %s\n", p.Nickname, p.AccountID, status)
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/ashwinl12/go-banking-system
+
+go 1.24
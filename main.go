@@ -1,428 +1,971 @@
+// Command go-banking-system is a command-line front end to the bank package. Run without a command it shows an
+// interactive menu; run with one, such as "transfer --from A1 --to A2 --amount 50", it does that and exits, printing
+// JSON with -json. Run it with -help for the list of commands.
 package main
 
 import (
+	"context"
 	"errors"
+	"flag"
 	"fmt"
-	"math/rand"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
 	"strconv"
-	"sync"
-)
-
-// Account defines the basic behavior of a bank account.
-type Account interface {
-	ID() string
-	Balance() float64
-	Deposit(amount float64) error
-	Withdraw(amount float64) error
-}
-
-// Transaction defines the common behavior of a transaction.
-type Transaction interface {
-	Execute() error
-}
+	"strings"
+	"syscall"
+	"time"
 
-// Bank defines the bank structure that holds accounts and performs operations.
-type Bank struct {
-	accounts        map[string]Account
-	accountStatus   map[string]bool // Map to store account active status
-	transactionHist map[string]string
-	mutex           *sync.Mutex
-}
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/bank"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
 
-// NewBank initializes a new Bank instance.
-func NewBank() *Bank {
-	return &Bank{
-		accounts:      make(map[string]Account),
-		accountStatus: make(map[string]bool),
-		transactionHist: make(map[string]string),
-		mutex:         &sync.Mutex{},
+func main() {
+	dataDir := flag.String("data", "bank-data", "directory where bank state is persisted")
+	importPath := flag.String("import", "", "legacy export to migrate before starting")
+	mappingPath := flag.String("mapping", "", "mapping file describing the legacy export layout")
+	controlCount := flag.Int("control-count", 0, "number of accounts reported by the legacy system")
+	controlTotal := flag.String("control-total", "0", "total balance reported by the legacy system")
+	snapshotEncoding := flag.String("snapshot-encoding", "json", "encoding of saved snapshots: json or binary")
+	snapshotCompression := flag.String("snapshot-compression", "none", "compression of saved snapshots: none or gzip")
+	flagsPath := flag.String("flags", "", "feature flags file (default flags.json in the data directory)")
+	pausePath := flag.String("pause", "", "transfer kill switch file, set with bankadmin pause (default pause.json in the data directory)")
+	configPath := flag.String("config", "", "fees, limits, benchmarks and holidays, reloaded on SIGHUP (default config.json in the data directory)")
+	usersPath := flag.String("users", "", "user directory file (default staff.json in the data directory)")
+	userID := flag.String("user", "", "user ID to sign in as, prompted for if not given; customers sign in with their customer ID")
+	sandbox := flag.Bool("sandbox", false, "run with test money, stubbing external integrations; the data directory remembers the mode")
+	seed := flag.Bool("seed", false, "open demo accounts with test money for the signed-in user (sandbox only)")
+	jsonOutput := flag.Bool("json", false, "print a command's result as JSON")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [-user ID] [command [command flags]]\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+		printCommandHelp(flag.CommandLine.Output())
 	}
-}
-
-// CreateAccount creates a new bank account and adds it to the bank.
-func (b *Bank) CreateAccount(account Account) {
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
-	accountID := account.ID()
-	b.accounts[accountID] = account
-	b.accountStatus[accountID] = true // Set account status to active
-}
-
-// CloseAccount sets the account status to false, marking it as deleted.
-func (b *Bank) CloseAccount(accountID string) error {
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
-	if _, exists := b.accounts[accountID]; !exists {
-		return errors.New("account does not exist")
+	flag.Parse()
+	if flag.NArg() > 0 && (*seed || *importPath != "") {
+		fmt.Println("Error: -seed and -import cannot be combined with a command")
+		os.Exit(2)
 	}
-	b.accountStatus[accountID] = false // Mark account as deleted
-	return nil
-}
-
-// GetAccount retrieves an account from the bank.
-func (b *Bank) GetAccount(accountID string) (Account, error) {
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
-	account, exists := b.accounts[accountID]
-	if !exists {
-		return nil, errors.New("account does not exist")
+	if flag.NArg() > 0 && *userID == "" {
+		fmt.Println("Error: -user is required to run a command")
+		os.Exit(2)
 	}
-	return account, nil
-}
-
-// IsAccountActive checks if an account is active.
-func (b *Bank) IsAccountActive(accountID string) bool {
-	status, exists := b.accountStatus[accountID]
-	if !exists {
-		return false // If account doesn't exist, consider it inactive
+	if *usersPath == "" {
+		*usersPath = filepath.Join(*dataDir, "staff.json")
 	}
-	return status
-}
-
-// Report generates a report of all active accounts along with their balances.
-func (b *Bank) Report() map[string]float64 {
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
-	report := make(map[string]float64)
-	for id, acc := range b.accounts {
-		if b.IsAccountActive(id) {
-			report[id] = acc.Balance()
-		}
+	if *flagsPath == "" {
+		*flagsPath = filepath.Join(*dataDir, "flags.json")
 	}
-	return report
-}
-
-// TotalBalance calculates and returns the total balance of all active accounts in the bank.
-func (b *Bank) TotalBalance() float64 {
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
-	total := 0.0
-	for id, acc := range b.accounts {
-		if b.IsAccountActive(id) {
-			total += acc.Balance()
-		}
+	if *pausePath == "" {
+		*pausePath = filepath.Join(*dataDir, "pause.json")
 	}
-	return total
-}
-
-// SavingsAccount represents a savings account with interest calculation.
-type SavingsAccount struct {
-	id           string
-	balance      float64
-	interestRate float64
-	mutex        *sync.Mutex // Mutex for synchronization
-}
-
-// ID returns the ID of the savings account.
-func (sa *SavingsAccount) ID() string {
-	return sa.id
-}
-
-// Balance returns the balance of the savings account.
-func (sa *SavingsAccount) Balance() float64 {
-	sa.mutex.Lock()
-	defer sa.mutex.Unlock()
-	return sa.balance
-}
-
-// Deposit adds funds to the savings account.
-func (sa *SavingsAccount) Deposit(amount float64) error {
-	if amount < 0 {
-		return errors.New("deposit amount must be positive")
+	if *configPath == "" {
+		*configPath = filepath.Join(*dataDir, "config.json")
 	}
-	sa.mutex.Lock()
-	defer sa.mutex.Unlock()
-	sa.balance += amount
-	return nil
-}
 
-// Withdraw subtracts funds from the savings account.
-func (sa *SavingsAccount) Withdraw(amount float64) error {
-	if amount < 0 {
-		return errors.New("withdrawal amount must be positive")
+	storage := bank.NewJSONFileStorage(*dataDir)
+	if err := storage.SetSnapshotFormat(bank.FormatOptions{Encoding: bank.Encoding(*snapshotEncoding), Compression: bank.Compression(*snapshotCompression)}); err != nil {
+		fmt.Println("Error:", err)
+		return
 	}
-	sa.mutex.Lock()
-	defer sa.mutex.Unlock()
-	if sa.balance < amount {
-		return errors.New("insufficient funds")
-	}
-	sa.balance -= amount
-	return nil
-}
-
-// CalculateInterest calculates and applies interest on the savings account.
-func (sa *SavingsAccount) CalculateInterest() {
-	sa.mutex.Lock()
-	defer sa.mutex.Unlock()
-	interest := sa.balance * sa.interestRate
-	sa.balance += interest
-}
-
-// TransferTransaction represents a transfer transaction between accounts.
-type TransferTransaction struct {
-	transactionID string // Unique transaction ID
-	from          Account
-	to            Account
-	amount        float64
-	isSuccess     bool // Indicates whether the transaction was successful
-}
 
-// NewTransferTransaction initializes a new TransferTransaction instance with a random transaction ID.
-func NewTransferTransaction(txnID string, from, to Account, amount float64) *TransferTransaction {
-	return &TransferTransaction{
-		transactionID: txnID,
-		from:          from,
-		to:            to,
-		amount:        amount,
+	// Create a new bank
+	b, err := bank.New(storage)
+	if err != nil {
+		fmt.Println("Error loading bank state:", err)
+		return
 	}
-}
-
-// generateTransactionID generates a random transaction ID string.
-func generateTransactionID() string {
-	// Generate a random transaction ID using any desired method
-	// For simplicity, you can use a UUID library or generate a unique string manually
-	// Here, we generate a simple random string as an example
-	return "txn-" + strconv.Itoa(rand.Intn(10000))
-}
-
-// transferFunds transfers funds from one account to another.
-func (b *Bank) transferFunds(fromID, toID string, amount float64) error {
-	// Lock the mutex to ensure exclusive access to accounts during transfer
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
-
-	txnID := generateTransactionID()
-
-	// Check if the source account exists
-	fromAcc, exists := b.accounts[fromID]
-	if !exists || (exists && !b.IsAccountActive(fromID)) {
-		b.transactionHist[txnID] = fmt.Sprintf("Transaction ID: %s, From: %s, To: %s, Amount: %.2f, Status: %s\n", txnID, fromID, toID, amount, "failed")
-		return errors.New("source account does not exist")
+	if *sandbox {
+		if err := b.SetMode(bank.ModeSandbox); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
 	}
-
-	// Check if the destination account exists
-	toAcc, exists := b.accounts[toID]
-	if !exists || (exists && !b.IsAccountActive(fromID)) {
-		b.transactionHist[txnID] = fmt.Sprintf("Transaction ID: %s, From: %s, To: %s, Amount: %.2f, Status: %s\n", txnID, fromID, toID, amount, "failed")
-		return errors.New("destination account does not exist")
+	features, err := bank.LoadFeatureFlags(*flagsPath)
+	if err != nil {
+		fmt.Println("Error loading feature flags:", err)
+		return
 	}
-
-	// Create a new transfer transaction with a random transaction ID
-	transaction := NewTransferTransaction(txnID, fromAcc, toAcc, amount)
-
-	// Execute the transfer transaction
-	if err := transaction.Execute(); err != nil {
-		transaction.isSuccess = false
-		return err
+	b.SetFeatureFlags(features)
+	pause, err := bank.LoadTransferSwitch(*pausePath)
+	if err != nil {
+		fmt.Println("Error loading transfer kill switch:", err)
+		return
+	}
+	b.SetTransferSwitch(pause)
+	if err := b.ReloadConfig(*configPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Println("Error loading config:", err)
+		return
 	}
 
-	// Mark the transaction as successful
-	transaction.isSuccess = true
-
-	// Add the transaction to the transaction history
-	b.transactionHist[(*transaction).transactionID] = fmt.Sprintf("Transaction ID: %s, From: %s, To: %s, Amount: %.2f, Status: %s\n", transaction.transactionID, transaction.from.ID(), transaction.to.ID(), transaction.amount, "success")
-
-	return nil
-}
-
-// Execute executes the transfer transaction.
-func (tt *TransferTransaction) Execute() error {
-	if tt.from == nil || tt.to == nil {
-		return errors.New("invalid accounts for transfer")
+	users, err := bank.LoadStaffDirectory(*usersPath)
+	if err != nil {
+		fmt.Println("Error loading users:", err)
+		return
 	}
-	if tt.amount <= 0 {
-		return errors.New("transfer amount must be positive")
+	user, err := signIn(users, *userID, os.Getenv("BANK_PASSWORD"))
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
 	}
-
-	// Perform withdrawal from source account
-	if err := tt.from.Withdraw(tt.amount); err != nil {
-		return err
+	users.RegisterWith(b)
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(b, user, flag.Args(), *jsonOutput))
 	}
-
-	// Perform deposit into destination account
-	if err := tt.to.Deposit(tt.amount); err != nil {
-		// Rollback withdrawal if deposit fails
-		_ = tt.from.Deposit(tt.amount)
-		return err
+	if b.Mode() == bank.ModeSandbox {
+		fmt.Println("Sandbox mode: balances are test money and external integrations are stubbed.")
 	}
-
-	return nil
-}
-
-func (b *Bank) NewSavingsAccount(id string, balance float64, interestRate float64) *SavingsAccount {
-
-	newAcc := SavingsAccount{
-		id:           id,
-		balance:      balance,
-		interestRate: interestRate,
-		mutex:        &sync.Mutex{},
+	if *seed {
+		opened, err := b.SeedDemoData(user)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		for _, id := range opened {
+			fmt.Println("Demo account opened:", id)
+		}
+		if err := b.Save(); err != nil {
+			fmt.Println("Error saving bank state:", err)
+			return
+		}
 	}
 
-	(*b).accounts[id] = &newAcc
-	(*b).accountStatus[id] = true // Set account status to active
-
-	return &newAcc
-}
+	// Reload the config on SIGHUP, keeping the current one if the new one is invalid
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	go func() {
+		for range hangups {
+			if err := b.ReloadConfig(*configPath); err != nil {
+				fmt.Println("\nConfig reload failed, keeping the current config:", err)
+			} else {
+				fmt.Println("\nConfig reloaded from", *configPath)
+			}
+		}
+	}()
 
-// DisplayTransactionHistory prints the transaction history.
-func (b *Bank) DisplayTransactionHistory() {
-	fmt.Println("Transaction History:")
-	for _, txn := range b.transactionHist {
-		fmt.Println(txn)
+	if *importPath != "" {
+		if err := b.Authorize(user, bank.ActionAdminister, ""); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		total, err := bank.ParseLegacyAmount(*controlTotal, 0)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		report, err := b.ImportLegacyFile(*importPath, *mappingPath, bank.ControlTotals{RecordCount: *controlCount, TotalBalance: total})
+		report.Print(os.Stdout)
+		if err != nil {
+			fmt.Println("Migration failed:", err)
+			return
+		}
+		if err := b.Save(); err != nil {
+			fmt.Println("Error saving bank state:", err)
+		}
+		return
 	}
-	fmt.Println("END")
-}
 
-func main() {
-	// Create a new bank
-	bank := NewBank()
+	// The receipt of the session's last operation, for reprinting
+	var lastReceipt *receipt
 
 	// Loop to continuously prompt the user for actions
 	for {
-		fmt.Println("\n1. Create Savings Account")
-		fmt.Println("2. Deposit")
-		fmt.Println("3. Withdraw")
-		fmt.Println("4. Balance")
-		fmt.Println("5. Transfer Funds")
-		fmt.Println("6. Report")
-		fmt.Println("7. Close Account")
-		fmt.Println("8. Transaction History")
-		fmt.Println("9. Exit")
+		// Operators change flags with bankadmin while this runs
+		if err := features.Reload(); err != nil {
+			fmt.Println("Error reloading feature flags:", err)
+		}
+		if pause, err := b.TransferPauseState(); err != nil {
+			fmt.Println("Error reloading transfer kill switch:", err)
+		} else if pause.Paused {
+			fmt.Println("\nOutgoing transfers are paused bank-wide.", pause.Reason)
+		}
+
+		printMenu(b, user)
+		fmt.Println("(Type back or cancel at any prompt to return to this menu.)")
 		fmt.Print("Enter your choice: ")
 
-		var choice int
-		fmt.Scanln(&choice)
+		line, err := scanLine()
+		if err != nil {
+			fmt.Println()
+			return
+		}
+		choice, err := strconv.Atoi(line)
+		if err != nil || !offered(b, user, choice) {
+			choice = 0
+		}
+		ctx := bank.ContextWithCorrelationID(bank.ContextWithChannel(bank.ContextWithUser(context.Background(), user), bank.ChannelCLI), bank.NewCorrelationID())
 
 		switch choice {
 		case 1:
 			fmt.Println("Creating Savings Account...")
-			var id string
-			var balance, interestRate float64
-			fmt.Print("Enter account ID: ")
-			fmt.Scanln(&id)
-			fmt.Print("Enter initial balance: ")
-			fmt.Scanln(&balance)
-			fmt.Print("Enter interest rate: ")
-			fmt.Scanln(&interestRate)
-			savingsAcc := bank.NewSavingsAccount(id, balance, interestRate)
-			fmt.Printf("Savings Account created successfully with ID %s\n", savingsAcc.id)
+			id, ok := ask("Enter account ID (blank for a new account number): ", newAccountID)
+			if !ok {
+				break
+			}
+			balance, ok := readAmount("Enter initial balance: ", bank.DefaultCurrency, false)
+			if !ok {
+				break
+			}
+			interestRate, ok := askRate("Enter interest rate: ")
+			if !ok {
+				break
+			}
+			if denied(b.AuthorizeOpen(user, balance)) {
+				break
+			}
+			if id == "" {
+				id = b.NewAccountID()
+			}
+			var savingsAcc *account.Savings
+			if denied(b.RunCorrelated(ctx, []string{id}, func() error {
+				var err error
+				savingsAcc, err = b.NewSavingsAccount(id, balance, interestRate)
+				return err
+			})) {
+				break
+			}
+			if denied(b.OpenedBy(user, id)) {
+				break
+			}
+			fmt.Printf("Savings Account created successfully with ID %s\n", savingsAcc.ID())
 
 		case 2:
 			fmt.Println("Depositing Funds...")
-			var accountID string
-			var amount float64
-			fmt.Print("Enter account ID: ")
-			fmt.Scanln(&accountID)
-			fmt.Print("Enter amount to deposit: ")
-			fmt.Scanln(&amount)
-			account, err := bank.GetAccount(accountID)
-			if err != nil {
-				fmt.Println("Error:", err)
-				continue
+			accountID, ok := askAccount("Enter account ID: ")
+			if !ok {
+				break
 			}
-			if bank.IsAccountActive(accountID) {
-				err = account.Deposit(amount)
-				if err != nil {
-					fmt.Println("Error:", err)
-				} else {
-					fmt.Println("Deposit successful.")
-				}
+			amount, ok := readOperationAmount(b, "Enter amount to deposit: ", transaction.OpDeposit, accountID)
+			if !ok {
+				break
+			}
+			rail, ok := askRail("Channel (cash, atm, wire, ach, internal; blank for cash): ")
+			if !ok {
+				break
+			}
+			if denied(b.Authorize(user, bank.ActionDeposit, accountID)) {
+				break
+			}
+			err := b.RunCorrelated(ctx, []string{accountID}, func() error {
+				return b.DepositVia(accountID, amount, rail)
+			})
+			if err != nil {
+				printError(err)
 			} else {
-				fmt.Println("Error: Account is inactive.")
+				fmt.Println("Deposit successful.")
 			}
+			printReference(ctx)
+			lastReceipt = newReceipt(ctx, "Deposit", err, "Account", accountID, "Amount", amount.String(), "Channel", string(rail), "Balance", balanceAfter(b, accountID))
 
 		case 3:
 			fmt.Println("Withdrawing Funds...")
-			var accountID string
-			var amount float64
-			fmt.Print("Enter account ID: ")
-			fmt.Scanln(&accountID)
-			fmt.Print("Enter amount to withdraw: ")
-			fmt.Scanln(&amount)
-			account, err := bank.GetAccount(accountID)
-			if err != nil {
-				fmt.Println("Error:", err)
-				continue
+			accountID, ok := askAccount("Enter account ID: ")
+			if !ok {
+				break
+			}
+			amount, ok := readOperationAmount(b, "Enter amount to withdraw: ", transaction.OpWithdrawal, accountID)
+			if !ok {
+				break
+			}
+			rail, ok := askRail("Channel (cash, atm, wire, ach, internal; blank for cash): ")
+			if !ok {
+				break
 			}
-			if bank.IsAccountActive(accountID) {
-				err = account.Withdraw(amount)
+			if denied(b.Authorize(user, bank.ActionWithdraw, accountID)) {
+				break
+			}
+			if b.NeedsApproval(rail) {
+				var w bank.WithdrawalApproval
+				err := b.RunCorrelated(ctx, []string{accountID}, func() error {
+					var err error
+					w, err = b.RequestWithdrawal(accountID, amount, rail)
+					return err
+				})
 				if err != nil {
-					fmt.Println("Error:", err)
+					printError(err)
 				} else {
-					fmt.Println("Withdrawal successful.")
+					fmt.Printf("Withdrawal %s is waiting for a manager's approval; the money is taken once it is approved.\n", w.ID)
 				}
+				printReference(ctx)
+				lastReceipt = newReceipt(ctx, "Withdrawal Request", err, "Account", accountID, "Amount", amount.String(), "Channel", string(rail), "Request", w.ID)
+				break
+			}
+			err := b.RunCorrelated(ctx, []string{accountID}, func() error {
+				return b.WithdrawVia(accountID, amount, rail)
+			})
+			if err != nil {
+				printError(err)
 			} else {
-				fmt.Println("Error: Account is inactive.")
+				fmt.Println("Withdrawal successful.")
 			}
+			printReference(ctx)
+			lastReceipt = newReceipt(ctx, "Withdrawal", err, "Account", accountID, "Amount", amount.String(), "Channel", string(rail), "Balance", balanceAfter(b, accountID))
 		case 4:
 			fmt.Println("Balance...")
-			var accountID string
-			var amount float64
-			fmt.Print("Enter account ID: ")
-			fmt.Scanln(&accountID)
-			account, err := bank.GetAccount(accountID)
+			accountID, ok := askAccount("Enter account ID: ")
+			if !ok {
+				break
+			}
+			if denied(b.Authorize(user, bank.ActionView, accountID)) {
+				break
+			}
+			acc, err := b.GetAccount(accountID)
 			if err != nil {
 				fmt.Println("Error:", err)
 				continue
 			}
-			if bank.IsAccountActive(accountID) {
-				amount = account.Balance()
-				fmt.Printf("Balance for %s is %.2f\n", accountID, amount)
+			if b.IsAccountActive(accountID) {
+				fmt.Printf("Balance for %s is %s\n", accountID, acc.Balance())
+				if balances, err := b.Balances(accountID); err == nil && balances.Held > 0 {
+					fmt.Printf("Available: %s (%s on hold)\n", balances.Available, balances.Held)
+				}
 			} else {
 				fmt.Println("Error: Account is inactive.")
 			}
 
 		case 5:
 			fmt.Println("Transferring Funds...")
-			var fromID, toID string
-			var amount float64
-			fmt.Print("Enter source account ID: ")
-			fmt.Scanln(&fromID)
-			fmt.Print("Enter destination account ID: ")
-			fmt.Scanln(&toID)
-			fmt.Print("Enter amount to transfer: ")
-			fmt.Scanln(&amount)
-			err := bank.transferFunds(fromID, toID, amount)
+			fromID, ok := askAccount("Enter source account ID: ")
+			if !ok {
+				break
+			}
+			toID, ok := ask("Enter destination account ID or payee: ", func(s string) error {
+				if _, exists := b.PayeeAccount(user, s); exists {
+					return nil
+				}
+				return accountID(s)
+			})
+			if !ok {
+				break
+			}
+			if payeeAccount, exists := b.PayeeAccount(user, toID); exists {
+				toID = payeeAccount
+			}
+			amount, ok := readOperationAmount(b, "Enter amount to transfer: ", transaction.OpTransfer, fromID)
+			if !ok {
+				break
+			}
+			country, ok := ask("Enter destination country (e.g. DE, blank if domestic): ", countryCode)
+			if !ok {
+				break
+			}
+			var travelRule *bank.TravelRuleData
+			if b.TravelRuleRequired(amount) {
+				travelRule = &bank.TravelRuleData{}
+				for _, field := range []struct {
+					prompt string
+					value  *string
+				}{
+					{"Enter originator name: ", &travelRule.Originator.Name},
+					{"Enter originator address: ", &travelRule.Originator.Address},
+					{"Enter beneficiary name: ", &travelRule.Beneficiary.Name},
+				} {
+					if *field.value, ok = ask(field.prompt, required); !ok {
+						break
+					}
+				}
+				if !ok {
+					break
+				}
+			}
+			if denied(b.Authorize(user, bank.ActionTransfer, fromID)) {
+				break
+			}
+			if amount >= largeTransfer && !confirm(fmt.Sprintf("Transfer %s from %s to %s?", amount, fromID, toID)) {
+				fmt.Println("Transfer cancelled.")
+				break
+			}
+			var result bank.TransferResult
+			transfer := func(opts bank.TransferOptions) error {
+				var err error
+				opts.Country, opts.TravelRule = country, travelRule
+				result, err = b.TransferChecked(fromID, toID, amount, opts)
+				return err
+			}
+			err := b.RunCorrelated(ctx, []string{fromID, toID}, func() error { return transfer(bank.TransferOptions{}) })
+			var dupErr *bank.DuplicateTransferError
+			if errors.As(err, &dupErr) && dupErr.NeedsConfirmation {
+				if !confirm(fmt.Sprintf("This looks like a duplicate of %s. Transfer anyway?", dupErr.OriginalTxnID)) {
+					fmt.Println("Transfer cancelled.")
+					break
+				}
+				err = b.RunCorrelated(ctx, []string{fromID, toID}, func() error {
+					return transfer(bank.TransferOptions{ConfirmDuplicate: true})
+				})
+			}
 			if err != nil {
-				fmt.Println("Error:", err)
+				printError(err)
 			} else {
+				if result.DuplicateOf != "" {
+					fmt.Printf("Warning: possible duplicate of %s.\n", result.DuplicateOf)
+				}
 				fmt.Println("Funds transferred successfully.")
 			}
+			printReference(ctx)
+			lastReceipt = newReceipt(ctx, "Transfer", err, "From", fromID, "To", toID, "Amount", amount.String(), "Transaction", result.TxnID)
 
 		case 6:
 			fmt.Println("Generating Report...")
-			report := bank.Report()
+			if denied(b.Authorize(user, bank.ActionReport, "")) {
+				break
+			}
+			report, totals := b.Report()
 			for id, balance := range report {
-				fmt.Printf("Account ID: %s, Balance: %.2f\n", id, balance)
+				fmt.Printf("Account ID: %s, Balance: %s%s\n", id, balance, describeMetadata(b, id))
+			}
+			for accountType, balance := range totals.ByType {
+				fmt.Printf("Type: %s, Balance: %s\n", accountType, balance)
+			}
+			for branch, balance := range totals.ByBranch {
+				if branch != "" {
+					fmt.Printf("Branch: %s, Balance: %s\n", branch, balance)
+				}
 			}
-			totalBalance := bank.TotalBalance()
-			fmt.Printf("Total Balance: %.2f\n", totalBalance)
+			fmt.Printf("Accounts: %d\n", totals.Accounts)
+			fmt.Printf("Total Balance: %s\n", totals.Total)
 
 		case 7:
 			fmt.Println("Closing Account...")
-			var accountID string
-			fmt.Print("Enter account ID: ")
-			fmt.Scanln(&accountID)
-			if bank.IsAccountActive(accountID) {
-				err := bank.CloseAccount(accountID)
+			accountID, ok := askAccount("Enter account ID: ")
+			if !ok {
+				break
+			}
+			if denied(b.Authorize(user, bank.ActionClose, accountID)) {
+				break
+			}
+			if b.IsAccountActive(accountID) {
+				if !confirm(fmt.Sprintf("Close account %s? This cannot be undone.", accountID)) {
+					fmt.Println("Account left open.")
+					break
+				}
+				sweepTo := ""
+				if balances, err := b.Balances(accountID); err == nil && balances.Ledger > 0 && b.Config().ClosurePolicy == bank.ClosureSweep {
+					if sweepTo, ok = ask(fmt.Sprintf("Enter account to move the remaining %s to (blank for the default account): ", balances.Ledger), optionalAccountID); !ok {
+						break
+					}
+				}
+				var sweepID string
+				err := b.RunCorrelated(ctx, []string{accountID, sweepTo}, func() error {
+					var err error
+					sweepID, err = b.CloseTo(accountID, sweepTo)
+					return err
+				})
 				if err != nil {
-					fmt.Println("Error:", err)
+					printError(err)
+				} else if sweepID != "" {
+					fmt.Printf("Account closed successfully; the remaining balance was moved as %s.\n", sweepID)
 				} else {
 					fmt.Println("Account closed successfully.")
 				}
+				printReference(ctx)
+				lastReceipt = newReceipt(ctx, "Close Account", err, "Account", accountID)
 			} else {
 				fmt.Println("Error: Account is already inactive.")
 			}
 
 		case 8:
 			fmt.Println("Displaying Transaction History...")
-			bank.DisplayTransactionHistory()
-			return
+			var q bank.HistoryQuery
+			var status, historyType string
+			var ok bool
+			if q.AccountID, ok = ask(scopedAccountPrompt(b, user)); !ok {
+				break
+			}
+			if status, ok = ask("Enter status (success, failed, compensation-pending; blank for all): ",
+				oneOf("success", "failed", "compensation-pending", "")); !ok {
+				break
+			}
+			if historyType, ok = ask("Enter type (transfer, reversal, split, scheduled, fee, interest, state; blank for all): ",
+				oneOf("transfer", "reversal", "split", "scheduled", "fee", "interest", "state", "")); !ok {
+				break
+			}
+			q.Status, q.Type = status, bank.HistoryType(historyType)
+			action := bank.ActionReport
+			if q.AccountID != "" {
+				action = bank.ActionView
+			}
+			if denied(b.Authorize(user, action, q.AccountID)) {
+				break
+			}
+			q.Limit = 20
+			for {
+				page, err := b.History(q)
+				if err != nil {
+					fmt.Println("Error:", err)
+					break
+				}
+				for _, h := range page.Entries {
+					fmt.Print(h.Entry)
+				}
+				if page.NextOffset == 0 {
+					fmt.Printf("END (%d transactions)\n", page.Total)
+					break
+				}
+				if !confirm(fmt.Sprintf("Showing %d of %d. Show more?", page.NextOffset, page.Total)) {
+					break
+				}
+				q.Offset = page.NextOffset
+			}
 		case 9:
+			fmt.Println("Creating Checking Account...")
+			id, ok := ask("Enter account ID (blank for a new account number): ", newAccountID)
+			if !ok {
+				break
+			}
+			balance, ok := readAmount("Enter initial balance: ", bank.DefaultCurrency, false)
+			if !ok {
+				break
+			}
+			overdraftLimit, ok := readAmount("Enter overdraft limit: ", bank.DefaultCurrency, false)
+			if !ok {
+				break
+			}
+			overdraftRate, ok := askRate("Enter overdraft interest rate: ")
+			if !ok {
+				break
+			}
+			if denied(b.AuthorizeOpen(user, balance)) {
+				break
+			}
+			if id == "" {
+				id = b.NewAccountID()
+			}
+			var checkingAcc *account.Checking
+			if denied(b.RunCorrelated(ctx, []string{id}, func() error {
+				var err error
+				checkingAcc, err = b.NewCheckingAccount(id, balance, overdraftLimit, overdraftRate)
+				return err
+			})) {
+				break
+			}
+			if denied(b.OpenedBy(user, id)) {
+				break
+			}
+			fmt.Printf("Checking Account created successfully with ID %s\n", checkingAcc.ID())
+
+		case 10:
+			fmt.Println("Scheduling Transfer...")
+			fromID, ok := askAccount("Enter source account ID: ")
+			if !ok {
+				break
+			}
+			toID, ok := ask("Enter destination account ID or payee: ", func(s string) error {
+				if _, exists := b.PayeeAccount(user, s); exists {
+					return nil
+				}
+				return accountID(s)
+			})
+			if !ok {
+				break
+			}
+			if payeeAccount, exists := b.PayeeAccount(user, toID); exists {
+				toID = payeeAccount
+			}
+			amount, ok := readOperationAmount(b, "Enter amount to transfer: ", transaction.OpTransfer, fromID)
+			if !ok {
+				break
+			}
+			start, ok := askDate("Enter start date (YYYY-MM-DD): ", false)
+			if !ok {
+				break
+			}
+			frequency, ok := ask("Enter frequency (once/daily/weekly/monthly): ", oneOf("once", "daily", "weekly", "monthly"))
+			if !ok {
+				break
+			}
+			until, ok := askDate("Enter end date (YYYY-MM-DD, blank for none): ", true)
+			if !ok {
+				break
+			}
+			if denied(b.Authorize(user, bank.ActionTransfer, fromID)) {
+				break
+			}
+			if amount >= largeTransfer && !confirm(fmt.Sprintf("Schedule %s %s from %s to %s?", frequency, amount, fromID, toID)) {
+				fmt.Println("Transfer not scheduled.")
+				break
+			}
+			st, err := b.ScheduleTransfer(fromID, toID, amount, start, bank.ScheduleFrequency(frequency), until)
+			if err != nil {
+				fmt.Println("Error:", err)
+			} else {
+				fmt.Printf("Transfer scheduled with ID %s, first run %s\n", st.ID, st.NextRun.Format("2006-01-02"))
+			}
+
+		case 11:
+			fmt.Println("Scheduled Transfers...")
+			accountID, ok := ask(scopedAccountPrompt(b, user))
+			if !ok {
+				break
+			}
+			action := bank.ActionView
+			if accountID == "" {
+				action = bank.ActionReport
+			}
+			if denied(b.Authorize(user, action, accountID)) {
+				break
+			}
+			for _, st := range b.ScheduledTransfers(accountID) {
+				fmt.Printf("Schedule ID: %s, From: %s, To: %s, Amount: %s, Frequency: %s, Next Run: %s\n",
+					st.ID, st.FromID, st.ToID, st.Amount, st.Frequency, st.NextRun.Format("2006-01-02"))
+			}
+
+		case 12:
+			fmt.Println("Cancelling Scheduled Transfer...")
+			scheduleID, ok := ask("Enter schedule ID: ", required)
+			if !ok {
+				break
+			}
+			var fromID string
+			for _, st := range b.ScheduledTransfers("") {
+				if st.ID == scheduleID {
+					fromID = st.FromID
+				}
+			}
+			if denied(b.Authorize(user, bank.ActionTransfer, fromID)) {
+				break
+			}
+			if err := b.CancelScheduledTransfer(scheduleID); err != nil {
+				fmt.Println("Error:", err)
+			} else {
+				fmt.Println("Scheduled transfer cancelled.")
+			}
+
+		case 13:
+			fmt.Println("Changing Account State...")
+			accountID, ok := askAccount("Enter account ID: ")
+			if !ok {
+				break
+			}
+			action, ok := ask("Enter action (freeze/unfreeze/dormant/reopen): ", oneOf("freeze", "unfreeze", "dormant", "reopen"))
+			if !ok {
+				break
+			}
+			if denied(b.Authorize(user, bank.ActionChangeState, accountID)) {
+				break
+			}
+			err := b.RunCorrelated(ctx, []string{accountID}, func() error {
+				switch action {
+				case "freeze":
+					return b.Freeze(accountID)
+				case "unfreeze":
+					return b.Unfreeze(accountID)
+				case "dormant":
+					return b.MarkDormant(accountID)
+				case "reopen":
+					return b.Reopen(accountID)
+				}
+				return errors.New("unknown action " + action)
+			})
+			if err != nil {
+				printError(err)
+			} else {
+				state, _ := b.AccountStateOf(accountID)
+				fmt.Printf("Account %s is now %s.\n", accountID, state)
+			}
+			printReference(ctx)
+			lastReceipt = newReceipt(ctx, "Change Account State", err, "Account", accountID, "Action", action)
+
+		case 14:
+			fmt.Println("Account Calendar...")
+			accountID, ok := askAccount("Enter account ID: ")
+			if !ok {
+				break
+			}
+			days, ok := askCount("Enter number of days ahead: ", 0)
+			if !ok {
+				break
+			}
+			if denied(b.Authorize(user, bank.ActionView, accountID)) {
+				break
+			}
+			from := time.Now()
+			to := from.AddDate(0, 0, days)
+			entries, err := b.AccountCalendar(accountID, from, to)
+			if err != nil {
+				fmt.Println("Error:", err)
+				break
+			}
+			for _, e := range entries {
+				fmt.Printf("%s  %-18s %10s  %s\n", e.Date.Format("2006-01-02"), e.Kind, e.Amount, e.Summary)
+			}
+			icsPath, ok := ask("Enter file to export as iCalendar (blank to skip): ", anything)
+			if ok && icsPath != "" {
+				f, err := os.Create(icsPath)
+				if err == nil {
+					err = b.ExportCalendar(f, accountID, from, to)
+					if closeErr := f.Close(); err == nil {
+						err = closeErr
+					}
+				}
+				if err != nil {
+					fmt.Println("Error:", err)
+				} else {
+					fmt.Println("Calendar exported to", icsPath)
+				}
+			}
+
+		case 15:
+			fmt.Println("Account Statement...")
+			accountID, ok := askAccount("Enter account ID: ")
+			if !ok {
+				break
+			}
+			from, ok := askDate("Enter start date (YYYY-MM-DD): ", false)
+			if !ok {
+				break
+			}
+			last, ok := askDate("Enter end date (YYYY-MM-DD): ", false)
+			if !ok {
+				break
+			}
+			if last.Before(from) {
+				fmt.Println("Error: end date is before start date.")
+				break
+			}
+			if denied(b.Authorize(user, bank.ActionView, accountID)) {
+				break
+			}
+			st, err := b.Statement(accountID, from, last.AddDate(0, 0, 1))
+			if err != nil {
+				fmt.Println("Error:", err)
+				break
+			}
+			fmt.Printf("Statement for %s, %s to %s\n", accountID, from.Format("2006-01-02"), last.Format("2006-01-02"))
+			fmt.Printf("%-10s  %-30s %12s %12s\n", "", "Opening balance", "", st.OpeningBalance)
+			for _, line := range st.Lines {
+				fmt.Printf("%s  %-30s %12s %12s\n", line.Date.Format("2006-01-02"), line.Description, line.Amount, line.Balance)
+			}
+			fmt.Printf("%-10s  %-30s %12s %12s\n", "", "Closing balance", "", st.ClosingBalance)
+			fmt.Printf("Total credits: %s, total debits: %s\n", st.TotalCredits, st.TotalDebits)
+			if st.TaxWithheld > 0 {
+				fmt.Printf("Tax withheld from interest: %s\n", st.TaxWithheld)
+			}
+
+		case 16:
+			fmt.Println("Failure Report...")
+			if denied(b.Authorize(user, bank.ActionReport, "")) {
+				break
+			}
+			hours, ok := askCount("Enter hours to cover (default 24): ", 24)
+			if !ok {
+				break
+			}
+			to := time.Now()
+			report := b.FailureReport(to.Add(-time.Duration(hours)*time.Hour), to, time.Hour)
+			fmt.Printf("%d failed operations in the last %d hours\n", report.Total, hours)
+			for _, group := range []struct {
+				name   string
+				counts map[string]int
+			}{{"Reason", stringCounts(report.ByReason)}, {"Channel", stringCounts(report.ByChannel)}, {"Account", report.ByAccount}} {
+				keys := make([]string, 0, len(group.counts))
+				for k := range group.counts {
+					keys = append(keys, k)
+				}
+				sort.Slice(keys, func(i, j int) bool { return group.counts[keys[i]] > group.counts[keys[j]] })
+				for _, k := range keys {
+					fmt.Printf("%s: %-24s %d\n", group.name, k, group.counts[k])
+				}
+			}
+			for _, fb := range report.Buckets {
+				fmt.Printf("%s  %d\n", fb.Start.Format("2006-01-02 15:04"), fb.Count)
+			}
+
+		case 17:
+			fmt.Println("Daily Limits...")
+			accountID, ok := askAccount("Enter account ID: ")
+			if !ok {
+				break
+			}
+			if denied(b.Authorize(user, bank.ActionView, accountID)) {
+				break
+			}
+			limits, usage, err := b.DailyLimitsOf(accountID)
+			if err != nil {
+				printError(err)
+				break
+			}
+			fmt.Printf("Withdrawal limit: %s (%s used today)\n", limits.Withdrawal, usage.Withdrawal)
+			fmt.Printf("Transfer limit: %s (%s used today)\n", limits.Transfer, usage.Transfer)
+			if pending, exists := b.PendingDailyLimits(accountID); exists {
+				fmt.Printf("Raised to withdrawal %s, transfer %s from %s\n", pending.Limits.Withdrawal, pending.Limits.Transfer, pending.EffectiveAt.Format(time.RFC1123))
+			}
+			withdrawal, ok := readAmount("Enter new daily withdrawal limit (0 for none, blank to keep): ", currencyOf(b, accountID), true)
+			if !ok {
+				break
+			}
+			transfer, ok := readAmount("Enter new daily transfer limit (0 for none): ", currencyOf(b, accountID), false)
+			if !ok {
+				break
+			}
+			effective, err := b.ChangeOwnDailyLimits(user, accountID, bank.DailyLimits{Withdrawal: withdrawal, Transfer: transfer})
+			if err != nil {
+				printError(err)
+			} else if effective.After(time.Now()) {
+				fmt.Println("Raised limits take effect at", effective.Format(time.RFC1123))
+			} else {
+				fmt.Println("Daily limits updated.")
+			}
+
+		case 18:
+			if lastReceipt == nil {
+				fmt.Println("No operation to print a receipt for yet.")
+				break
+			}
+			lastReceipt.print()
+
+		case 19:
+			fmt.Println("Recent Transactions...")
+			accountID, ok := askAccount("Enter account ID: ")
+			if !ok {
+				break
+			}
+			n, ok := askCount("Enter number of transactions (default 10): ", 10)
+			if !ok {
+				break
+			}
+			if denied(b.Authorize(user, bank.ActionView, accountID)) {
+				break
+			}
+			if err := printRecentTransactions(b, accountID, n); err != nil {
+				fmt.Println("Error:", err)
+			}
+
+		case 20:
+			fmt.Println("Payees...")
+			payees := b.Payees(user)
+			listed := make([]payeeReply, 0, len(payees))
+			for _, p := range payees {
+				listed = append(listed, payeeReply{Nickname: p.Nickname, AccountID: p.AccountID, Added: p.AddedAt, Cleared: b.PayeeCleared(p)})
+			}
+			writePayees(os.Stdout, listed)
+			action, ok := ask("Enter action (add, remove, blank to go back): ", oneOf("add", "remove", ""))
+			if !ok {
+				break
+			}
+			switch action {
+			case "add":
+				nickname, ok := ask("Enter payee nickname: ", required)
+				if !ok {
+					break
+				}
+				payeeAccount, ok := askAccount("Enter payee account ID: ")
+				if !ok {
+					break
+				}
+				p, err := b.AddPayee(user, nickname, payeeAccount)
+				if err != nil {
+					printError(err)
+					break
+				}
+				fmt.Printf("Payee %s added. Transfers to it can be made from %s.\n", p.Nickname, b.PayeeCleared(p).Format(time.RFC1123))
+			case "remove":
+				nickname, ok := ask("Enter payee nickname: ", required)
+				if !ok {
+					break
+				}
+				if err := b.RemovePayee(user, nickname); err != nil {
+					printError(err)
+					break
+				}
+				fmt.Println("Payee removed.")
+			}
+
+		case 21:
+			fmt.Println("Interest Payout...")
+			accountID, ok := askAccount("Enter savings or fixed deposit account ID: ")
+			if !ok {
+				break
+			}
+			if denied(b.Authorize(user, bank.ActionView, accountID)) {
+				break
+			}
+			if payoutID := b.InterestPayoutOf(accountID); payoutID != "" {
+				fmt.Printf("Interest on %s is paid out to %s.\n", accountID, payoutID)
+			} else {
+				fmt.Printf("Interest on %s is added to the account.\n", accountID)
+			}
+			payoutID, ok := ask("Enter account to pay interest out to (blank to add it to the account): ", optionalAccountID)
+			if !ok {
+				break
+			}
+			if err := b.SetInterestPayout(user, accountID, payoutID); err != nil {
+				printError(err)
+			} else if payoutID != "" {
+				fmt.Printf("Interest on %s will be paid out to %s.\n", accountID, payoutID)
+			} else {
+				fmt.Printf("Interest on %s will be added to the account.\n", accountID)
+			}
+
+		case choiceSwitchUser:
+			user = switchUser(b, *usersPath, user)
+			lastReceipt = nil
+
+		case choiceExit:
 			fmt.Println("Exiting...")
 			return
 		default:
 			fmt.Println("Invalid choice. Please try again.")
 		}
+
+		// Persist after every operation so state survives restarts
+		if err := b.Save(); err != nil {
+			fmt.Println("Error saving bank state:", err)
+		}
+	}
+}
+
+// denied reports whether an authorization check failed, printing why.
+func denied(err error) bool {
+	if err != nil {
+		printError(err)
+		return true
+	}
+	return false
+}
+
+// printError prints why the bank rejected an operation, with its reason code.
+func printError(err error) {
+	fmt.Printf("Error: %v [%s]\n", err, bank.ReasonOf(err))
+}
+
+// stringCounts converts counts keyed by a string type to counts keyed by string.
+func stringCounts[K ~string](counts map[K]int) map[string]int {
+	converted := make(map[string]int, len(counts))
+	for k, n := range counts {
+		converted[string(k)] = n
+	}
+	return converted
+}
+
+// scanLine reads a whole line, spaces included, from standard input, returning an error once input runs out.
+func scanLine() (string, error) {
+	var sb strings.Builder
+	for {
+		var r rune
+		if _, err := fmt.Scanf("%c", &r); err != nil {
+			if sb.Len() == 0 {
+				return "", err
+			}
+			return strings.TrimSpace(sb.String()), nil
+		}
+		if r == '\n' {
+			return strings.TrimSpace(sb.String()), nil
+		}
+		sb.WriteRune(r)
 	}
 }
+
+// currencyOf returns the currency amounts for an account are entered in, DefaultCurrency if it does not exist.
+func currencyOf(b *bank.Bank, accountID string) string {
+	if currency, err := b.CurrencyOf(accountID); err == nil {
+		return currency
+	}
+	return bank.DefaultCurrency
+}
+
+// printReference prints the correlation ID a menu operation was recorded under, which bankadmin trace looks up.
+func printReference(ctx context.Context) {
+	fmt.Println("Reference:", bank.CorrelationIDFromContext(ctx))
+}
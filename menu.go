@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ashwinl12/go-banking-system/bank"
+)
+
+// Menu choices that do not act on accounts.
+const (
+	choiceSwitchUser = 22
+	choiceExit       = 23
+)
+
+// menuItem is one entry of the interactive menu.
+type menuItem struct {
+	choice int
+	label  string
+	action bank.Action // What the user's role must allow for the entry to be offered; "" offers it to everyone
+}
+
+// menuItems are the interactive menu's entries. Their numbers do not change with the user, so a choice means the
+// same thing whoever is signed in.
+var menuItems = []menuItem{
+	{1, "Create Savings Account", bank.ActionOpen},
+	{2, "Deposit", bank.ActionDeposit},
+	{3, "Withdraw", bank.ActionWithdraw},
+	{4, "Balance", bank.ActionView},
+	{5, "Transfer Funds", bank.ActionTransfer},
+	{6, "Report", bank.ActionReport},
+	{7, "Close Account", bank.ActionClose},
+	{8, "Transaction History", bank.ActionView},
+	{9, "Create Checking Account", bank.ActionOpen},
+	{10, "Schedule Transfer", bank.ActionTransfer},
+	{11, "List Scheduled Transfers", bank.ActionView},
+	{12, "Cancel Scheduled Transfer", bank.ActionTransfer},
+	{13, "Change Account State", bank.ActionChangeState},
+	{14, "Account Calendar", bank.ActionView},
+	{15, "Account Statement", bank.ActionView},
+	{16, "Failure Report", bank.ActionReport},
+	{17, "Daily Limits", bank.ActionView},
+	{18, "Reprint Last Receipt", ""},
+	{19, "Recent Transactions", bank.ActionView},
+	{20, "Payees", bank.ActionTransfer},
+	{21, "Interest Payout", bank.ActionView},
+	{choiceSwitchUser, "Switch User", ""},
+	{choiceExit, "Exit", ""},
+}
+
+// offered reports whether a menu choice is on the signed-in user's menu.
+func offered(b *bank.Bank, user string, choice int) bool {
+	for _, item := range menuItems {
+		if item.choice == choice {
+			return item.action == "" || b.Permits(user, item.action)
+		}
+	}
+	return false
+}
+
+// printMenu says who is signed in, and which accounts are theirs if they are a customer, and lists the entries of
+// the menu their role allows.
+func printMenu(b *bank.Bank, user string) {
+	role, _ := b.UserRole(user)
+	fmt.Printf("\nSigned in as %s (%s)\n", user, role)
+	if role == bank.StaffRoleCustomer {
+		if ids := b.AccountsOf(user); len(ids) > 0 {
+			fmt.Println("Your accounts:", strings.Join(ids, ", "))
+		} else {
+			fmt.Println("You have no accounts yet.")
+		}
+	}
+	for _, item := range menuItems {
+		if offered(b, user, item.choice) {
+			fmt.Printf("%d. %s\n", item.choice, item.label)
+		}
+	}
+}
+
+// scopedAccountPrompt returns the prompt and validator for the account a listing covers. Users who may see
+// bank-wide reports may leave it blank for every account; customers must name one of theirs.
+func scopedAccountPrompt(b *bank.Bank, user string) (string, func(string) error) {
+	if b.Permits(user, bank.ActionReport) {
+		return "Enter account ID (blank for all): ", optionalAccountID
+	}
+	return "Enter account ID: ", accountID
+}
+
+// signIn asks for a user ID, unless one is given, and checks the user's password, prompting for it unless one is
+// given. It returns the signed-in user.
+func signIn(users *bank.StaffDirectory, userID, password string) (string, error) {
+	if users.Empty() {
+		return "", errors.New("no users exist yet; add them with bankadmin")
+	}
+	if userID == "" {
+		fmt.Print("User ID: ")
+		line, err := scanLine()
+		if err != nil || line == "" {
+			return "", errors.New("a user ID is required")
+		}
+		userID = line
+	}
+	if password == "" {
+		fmt.Printf("Password for %s: ", userID)
+		fmt.Scanln(&password)
+	}
+	if _, err := users.Authenticate(userID, password); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
+
+// switchUser signs another user in, re-reading the user directory so users added since the session began can sign
+// in. It returns the signed-in user: the new one, or the current one if signing in failed.
+func switchUser(b *bank.Bank, usersPath, current string) string {
+	users, err := bank.LoadStaffDirectory(usersPath)
+	if err != nil {
+		fmt.Println("Error loading users:", err)
+		return current
+	}
+	users.RegisterWith(b)
+	user, err := signIn(users, "", "")
+	if err != nil {
+		fmt.Println("Error:", err)
+		fmt.Println("Still signed in as", current)
+		return current
+	}
+	return user
+}
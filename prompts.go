@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ashwinl12/go-banking-system/account"
+	"github.com/ashwinl12/go-banking-system/bank"
+	"github.com/ashwinl12/go-banking-system/transaction"
+)
+
+// largeTransfer is the amount from which a transfer must be confirmed before it is sent.
+const largeTransfer = account.Money(10000 * account.MinorUnits)
+
+// cancelWords abandon the operation under way from any prompt and go back to the menu.
+var cancelWords = map[string]bool{"back": true, "cancel": true}
+
+// ask prompts for a line until valid accepts it, saying what was wrong with each rejected entry. It reports false
+// if the user types back or cancel, or once input runs out.
+func ask(prompt string, valid func(string) error) (string, bool) {
+	for {
+		fmt.Print(prompt)
+		line, err := scanLine()
+		if err != nil {
+			fmt.Println()
+			return "", false
+		}
+		if cancelWords[strings.ToLower(line)] {
+			fmt.Println("Cancelled.")
+			return "", false
+		}
+		if err := valid(line); err != nil {
+			fmt.Println("Error:", err, "(type back to cancel)")
+			continue
+		}
+		return line, true
+	}
+}
+
+// anything accepts any input, including none.
+func anything(string) error {
+	return nil
+}
+
+// required accepts anything but a blank entry.
+func required(s string) error {
+	if s == "" {
+		return errors.New("this cannot be blank")
+	}
+	return nil
+}
+
+// countryCode accepts a two-letter country code, or nothing for a domestic transfer.
+func countryCode(s string) error {
+	if s == "" {
+		return nil
+	}
+	if len(s) != 2 || !unicode.IsLetter(rune(s[0])) || !unicode.IsLetter(rune(s[1])) {
+		return errors.New("enter a two-letter country code such as DE")
+	}
+	return nil
+}
+
+// accountID accepts an account ID: not blank and without spaces.
+func accountID(s string) error {
+	if s == "" {
+		return errors.New("enter an account ID")
+	}
+	if strings.ContainsAny(s, " \t") {
+		return errors.New("account IDs do not contain spaces")
+	}
+	return nil
+}
+
+// newAccountID accepts the ID of an account to open, or nothing for a new account number.
+func newAccountID(s string) error {
+	if s == "" {
+		return nil
+	}
+	return bank.ValidateAccountID(s)
+}
+
+// optionalAccountID accepts an account ID or nothing.
+func optionalAccountID(s string) error {
+	if s == "" {
+		return nil
+	}
+	return accountID(s)
+}
+
+// oneOf accepts one of the options; "" among them allows a blank entry.
+func oneOf(options ...string) func(string) error {
+	return func(s string) error {
+		var named []string
+		blank := ""
+		for _, option := range options {
+			if s == option {
+				return nil
+			}
+			if option == "" {
+				blank = " or leave it blank"
+			} else {
+				named = append(named, option)
+			}
+		}
+		return fmt.Errorf("enter one of %s%s", strings.Join(named, ", "), blank)
+	}
+}
+
+// askAccount prompts for an account ID.
+func askAccount(prompt string) (string, bool) {
+	return ask(prompt, accountID)
+}
+
+// readAmount prompts for an amount in a currency until one parses, saying what was wrong with each rejected entry.
+// Amounts must not be negative. It reports false if the user cancels, once input runs out, or for a blank entry if
+// blank is allowed.
+func readAmount(prompt, currency string, blank bool) (account.Money, bool) {
+	var amount account.Money
+	line, ok := ask(prompt, func(s string) error {
+		if blank && s == "" {
+			return nil
+		}
+		var err error
+		if amount, err = bank.ParseAmount(s, currency); err == nil && amount < 0 {
+			err = errors.New("amount must not be negative")
+		}
+		return err
+	})
+	return amount, ok && line != ""
+}
+
+// readOperationAmount prompts for the amount of a deposit, withdrawal or transfer from an account until one parses
+// and is valid for the operation under the bank's amount policy, saying what was wrong with each rejected entry. It
+// reports false if the user cancels or once input runs out.
+func readOperationAmount(b *bank.Bank, prompt string, op transaction.OperationType, accountID string) (account.Money, bool) {
+	var amount account.Money
+	_, ok := ask(prompt, func(s string) error {
+		var err error
+		if amount, err = bank.ParseAmount(s, currencyOf(b, accountID)); err == nil {
+			err = b.ValidateAmount(op, accountID, amount)
+		}
+		return err
+	})
+	return amount, ok
+}
+
+// askRail prompts for the channel money comes in or leaves by, with a blank entry meaning cash.
+func askRail(prompt string) (bank.Rail, bool) {
+	rail := bank.RailCash
+	_, ok := ask(prompt, func(s string) error {
+		if s == "" {
+			rail = bank.RailCash
+			return nil
+		}
+		var err error
+		rail, err = bank.ParseRail(s)
+		return err
+	})
+	return rail, ok
+}
+
+// askRate prompts for a rate, such as 0.02 for two percent, that must not be negative.
+func askRate(prompt string) (float64, bool) {
+	var rate float64
+	_, ok := ask(prompt, func(s string) error {
+		var err error
+		if rate, err = strconv.ParseFloat(s, 64); err != nil || rate < 0 || rate != rate {
+			return errors.New("enter a rate such as 0.02 for two percent")
+		}
+		return nil
+	})
+	return rate, ok
+}
+
+// askCount prompts for a whole number of at least one, or nothing if a default is given (0 means none).
+func askCount(prompt string, def int) (int, bool) {
+	n := def
+	_, ok := ask(prompt, func(s string) error {
+		if s == "" && def > 0 {
+			return nil
+		}
+		var err error
+		if n, err = strconv.Atoi(s); err != nil || n < 1 {
+			return errors.New("enter a whole number of at least 1")
+		}
+		return nil
+	})
+	return n, ok
+}
+
+// askDate prompts for a YYYY-MM-DD date in local time, or nothing if optional, which gives the zero time.
+func askDate(prompt string, optional bool) (time.Time, bool) {
+	var day time.Time
+	_, ok := ask(prompt, func(s string) error {
+		if s == "" && optional {
+			day = time.Time{}
+			return nil
+		}
+		var err error
+		if day, err = time.ParseInLocation("2006-01-02", s, time.Local); err != nil {
+			return errors.New("enter a date as YYYY-MM-DD")
+		}
+		return nil
+	})
+	return day, ok
+}
+
+// confirm asks a yes or no question, reporting true only for yes.
+func confirm(question string) bool {
+	answer, ok := ask(question+" (y/n): ", func(s string) error {
+		return oneOf("y", "n", "yes", "no")(strings.ToLower(s))
+	})
+	return ok && strings.ToLower(answer)[0] == 'y'
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ashwinl12/go-banking-system/bank"
+)
+
+// receipt is what an operation of the session did, kept so the last one can be reprinted.
+type receipt struct {
+	operation string
+	at        time.Time
+	fields    []string // Alternating labels and values, in the order printed
+	err       error    // Why the operation failed, nil if it went through
+	reference string
+}
+
+// newReceipt writes a receipt for an operation run under ctx that ended with err. Fields alternate labels and
+// values, e.g. "Account", "A1", "Amount", "50.00".
+func newReceipt(ctx context.Context, operation string, err error, fields ...string) *receipt {
+	return &receipt{operation: operation, at: time.Now(), fields: fields, err: err, reference: bank.CorrelationIDFromContext(ctx)}
+}
+
+// print writes the receipt to standard output.
+func (r *receipt) print() {
+	rule := strings.Repeat("-", 44)
+	fmt.Println(rule)
+	fmt.Printf("%-12s %s\n", "Operation:", r.operation)
+	fmt.Printf("%-12s %s\n", "Date:", r.at.Format("2006-01-02 15:04:05 MST"))
+	for i := 0; i+1 < len(r.fields); i += 2 {
+		fmt.Printf("%-12s %s\n", r.fields[i]+":", r.fields[i+1])
+	}
+	if r.err != nil {
+		fmt.Printf("%-12s Failed: %v [%s]\n", "Status:", r.err, bank.ReasonOf(r.err))
+	} else {
+		fmt.Printf("%-12s Completed\n", "Status:")
+	}
+	fmt.Printf("%-12s %s\n", "Reference:", r.reference)
+	fmt.Println(rule)
+}
+
+// balanceAfter returns an account's balance for a receipt, or "" if it cannot be read.
+func balanceAfter(b *bank.Bank, accountID string) string {
+	acc, err := b.GetAccount(accountID)
+	if err != nil {
+		return ""
+	}
+	return acc.Balance().String()
+}
+
+// printRecentTransactions prints an account's last n transactions as a table, newest first, with amounts signed
+// as they affected the account.
+func printRecentTransactions(b *bank.Bank, accountID string, n int) error {
+	page, err := b.History(bank.HistoryQuery{AccountID: accountID, Newest: true, Limit: n})
+	if err != nil {
+		return err
+	}
+	if len(page.Entries) == 0 {
+		fmt.Println("No transactions for", accountID)
+		return nil
+	}
+	fmt.Printf("%-16s  %-10s  %-30s  %-14s  %13s  %s\n", "Date", "Type", "Transaction", "Counterparty", "Amount", "Status")
+	for _, h := range page.Entries {
+		date := ""
+		if !h.Recorded.IsZero() {
+			date = h.Recorded.Local().Format("2006-01-02 15:04")
+		}
+		counterparty, amount := "", h.Amount.String()
+		switch accountID {
+		case h.From:
+			counterparty, amount = h.To, (-h.Amount).String()
+		case h.To:
+			counterparty, amount = h.From, "+"+h.Amount.String()
+		}
+		if h.Amount == 0 && h.Type == bank.HistoryState {
+			amount = ""
+		}
+		fmt.Printf("%-16s  %-10s  %-30s  %-14s  %13s  %s\n", date, h.Type, h.TransactionID, counterparty, amount, h.Status)
+	}
+	fmt.Printf("Showing %d of %d transactions\n", len(page.Entries), page.Total)
+	return nil
+}
@@ -0,0 +1,93 @@
+package transaction
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Description keeps the description as submitted alongside its enriched form.
+type Description struct {
+	Raw          string
+	Counterparty string // Normalized counterparty name
+	Merchant     string
+	Category     string
+	LogoURL      string
+}
+
+// Display returns the best description to show a customer.
+func (td Description) Display() string {
+	if td.Merchant != "" {
+		return td.Merchant
+	}
+	if td.Counterparty != "" {
+		return td.Counterparty
+	}
+	return td.Raw
+}
+
+// Enricher is one stage of the description enrichment pipeline.
+type Enricher interface {
+	Enrich(desc *Description)
+}
+
+var (
+	// Payment rail prefixes that carry no information about the counterparty
+	railPrefix = regexp.MustCompile(`(?i)^(pos|ach|card|dd|sq\s*\*|tst\s*\*|paypal\s*\*)\s*`)
+	// Trailing store numbers and terminal references, e.g. "#1234" or "0042"
+	storeSuffix = regexp.MustCompile(`\s*(#\s*\d+|\d{3,})$`)
+	whitespace  = regexp.MustCompile(`\s+`)
+)
+
+// NormalizeCounterparty cleans up raw descriptions into a readable counterparty name.
+type NormalizeCounterparty struct{}
+
+// Enrich sets the normalized counterparty name.
+func (NormalizeCounterparty) Enrich(desc *Description) {
+	name := whitespace.ReplaceAllString(strings.TrimSpace(desc.Raw), " ")
+	name = railPrefix.ReplaceAllString(name, "")
+	name = storeSuffix.ReplaceAllString(name, "")
+	words := strings.Fields(strings.ToLower(name))
+	for i, w := range words {
+		r := []rune(w)
+		words[i] = strings.ToUpper(string(r[0])) + string(r[1:])
+	}
+	desc.Counterparty = strings.Join(words, " ")
+}
+
+// MerchantInfo is what a merchant directory knows about a counterparty.
+type MerchantInfo struct {
+	Name     string
+	Category string
+	LogoURL  string
+}
+
+// MerchantDirectory looks up merchant details by normalized counterparty name.
+type MerchantDirectory interface {
+	Lookup(counterparty string) (MerchantInfo, bool)
+}
+
+// StaticMerchantDirectory is an in-memory merchant directory keyed by lower-case counterparty name.
+type StaticMerchantDirectory map[string]MerchantInfo
+
+// Lookup finds a merchant by counterparty name, ignoring case.
+func (sd StaticMerchantDirectory) Lookup(counterparty string) (MerchantInfo, bool) {
+	info, ok := sd[strings.ToLower(counterparty)]
+	return info, ok
+}
+
+// MerchantLookup attaches merchant name, category, and logo from a directory.
+type MerchantLookup struct {
+	Directory MerchantDirectory
+}
+
+// Enrich fills in merchant details when the directory knows the counterparty.
+func (ml MerchantLookup) Enrich(desc *Description) {
+	if ml.Directory == nil || desc.Counterparty == "" {
+		return
+	}
+	if info, ok := ml.Directory.Lookup(desc.Counterparty); ok {
+		desc.Merchant = info.Name
+		desc.Category = info.Category
+		desc.LogoURL = info.LogoURL
+	}
+}
@@ -0,0 +1,76 @@
+package transaction
+
+import (
+	"fmt"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// OperationType identifies the kind of customer operation a fee or limit applies to.
+type OperationType string
+
+const (
+	OpDeposit    OperationType = "deposit"
+	OpWithdrawal OperationType = "withdrawal"
+	OpTransfer   OperationType = "transfer"
+)
+
+// FeeRule charges a fixed and/or percentage fee on an operation type.
+type FeeRule struct {
+	Name         string
+	Operation    OperationType
+	Fixed        account.Money
+	Percent      float64       // Share of the operation amount, e.g. 0.5 for 0.5%
+	MinAmount    account.Money // Only applies to operations of at least this amount
+	FreePerMonth int           // Number of operations each month that are exempt from this fee
+	Feature      string        // Feature flag that must be on for the account for this fee to apply; empty means always
+	When         string        // Rule expression the operation must match for this fee to apply; empty means always
+}
+
+// AppliedFee explains a fee that was, or would be, charged.
+type AppliedFee struct {
+	Rule   string
+	Amount account.Money
+	Reason string
+}
+
+// Fees is the set of fees for a single operation.
+type Fees []AppliedFee
+
+// Total returns the sum of the fees.
+func (fs Fees) Total() account.Money {
+	total := account.Money(0)
+	for _, f := range fs {
+		total += f.Amount
+	}
+	return total
+}
+
+// FeesFor works out the fees for an operation given how many of that type came before it this month.
+func FeesFor(schedule []FeeRule, op OperationType, amount account.Money, priorCount int) (Fees, []string) {
+	var fees Fees
+	var waived []string
+	for _, r := range schedule {
+		if r.Operation != op {
+			continue
+		}
+		if amount < r.MinAmount {
+			waived = append(waived, fmt.Sprintf("%s: amount %s is below the %s threshold", r.Name, amount, r.MinAmount))
+			continue
+		}
+		if priorCount < r.FreePerMonth {
+			waived = append(waived, fmt.Sprintf("%s: free %s %d of %d this month", r.Name, op, priorCount+1, r.FreePerMonth))
+			continue
+		}
+		fee := r.Fixed + amount.MulRate(r.Percent/100)
+		if fee == 0 {
+			continue
+		}
+		reason := fmt.Sprintf("%s of %s", op, amount)
+		if r.FreePerMonth > 0 {
+			reason += fmt.Sprintf(" exceeds %d free per month", r.FreePerMonth)
+		}
+		fees = append(fees, AppliedFee{Rule: r.Name, Amount: fee, Reason: reason})
+	}
+	return fees, waived
+}
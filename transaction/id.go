@@ -0,0 +1,89 @@
+package transaction
+
+import (
+	"crypto/rand"
+	"sync"
+	"time"
+)
+
+// IDGenerator creates transaction IDs. IDs must never repeat, since a repeated ID overwrites the history entry of
+// the transaction that had it first. Generators must be safe for concurrent use.
+type IDGenerator interface {
+	NewID() string
+}
+
+// IDGeneratorFunc adapts a function to an IDGenerator, e.g. a counter that makes IDs deterministic in tests.
+type IDGeneratorFunc func() string
+
+// NewID calls f.
+func (f IDGeneratorFunc) NewID() string {
+	return f()
+}
+
+// crockford is the Crockford base32 alphabet ULIDs are written in.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULIDGenerator creates IDs of the form "txn-" followed by a ULID: a 48-bit millisecond timestamp and 80 random
+// bits, so IDs sort by the time they were made. IDs made in the same millisecond increment the random part, so they
+// stay in order and cannot collide. The zero value is ready to use.
+type ULIDGenerator struct {
+	mutex   sync.Mutex
+	last    uint64   // Timestamp of the last ID, in milliseconds since the Unix epoch
+	entropy [10]byte // Random part of the last ID
+}
+
+// NewID returns a new ULID-based transaction ID.
+func (g *ULIDGenerator) NewID() string {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	ms := uint64(time.Now().UnixMilli())
+	if ms > g.last {
+		g.last = ms
+		rand.Read(g.entropy[:])
+	} else if !increment(g.entropy[:]) {
+		// The random part overflowed; borrow the next millisecond
+		g.last++
+		rand.Read(g.entropy[:])
+	}
+	var id [16]byte
+	for i := 0; i < 6; i++ {
+		id[i] = byte(g.last >> (40 - 8*i))
+	}
+	copy(id[6:], g.entropy[:])
+	return "txn-" + encodeULID(id)
+}
+
+// increment adds one to a big-endian number, reporting false if it overflowed.
+func increment(n []byte) bool {
+	for i := len(n) - 1; i >= 0; i-- {
+		n[i]++
+		if n[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeULID writes 128 bits as 26 Crockford base32 digits, the first of which carries only 3 bits.
+func encodeULID(id [16]byte) string {
+	var out [26]byte
+	for i := range out {
+		var v byte
+		for j := 0; j < 5; j++ {
+			v <<= 1
+			if bit := i*5 + j - 2; bit >= 0 && id[bit/8]&(0x80>>(bit%8)) != 0 {
+				v |= 1
+			}
+		}
+		out[i] = crockford[v]
+	}
+	return string(out[:])
+}
+
+// defaultIDs generates the IDs NewID returns.
+var defaultIDs = &ULIDGenerator{}
+
+// NewID generates a unique transaction ID with the default ULIDGenerator.
+func NewID() string {
+	return defaultIDs.NewID()
+}
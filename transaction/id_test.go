@@ -0,0 +1,41 @@
+package transaction
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestULIDGeneratorIDsAreUniqueAndOrdered(t *testing.T) {
+	var g ULIDGenerator
+	previous := ""
+	for i := 0; i < 10000; i++ {
+		id := g.NewID()
+		if !strings.HasPrefix(id, "txn-") || len(id) != len("txn-")+26 {
+			t.Fatalf("ID %q is not txn- and a ULID", id)
+		}
+		if id <= previous {
+			t.Fatalf("ID %q does not sort after %q", id, previous)
+		}
+		previous = id
+	}
+}
+
+func TestIncrement(t *testing.T) {
+	tests := []struct {
+		name   string
+		n      []byte
+		want   []byte
+		wantOK bool
+	}{
+		{name: "last byte", n: []byte{0, 1}, want: []byte{0, 2}, wantOK: true},
+		{name: "carry", n: []byte{0, 0xff}, want: []byte{1, 0}, wantOK: true},
+		{name: "overflow", n: []byte{0xff, 0xff}, want: []byte{0, 0}, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ok := increment(tt.n); ok != tt.wantOK || string(tt.n) != string(tt.want) {
+				t.Errorf("increment = %v, %t; want %v, %t", tt.n, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
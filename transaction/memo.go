@@ -0,0 +1,47 @@
+package transaction
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Longest parts of a memo, in bytes.
+const (
+	MaxMemoText      = 140
+	MaxMemoReference = 35
+	MaxMemoCategory  = 40
+)
+
+// Memo is what a customer notes on a deposit, withdrawal or transfer: free text, the reference number the payment
+// carries outside the bank, such as an invoice number, and a category tag of their choosing.
+type Memo struct {
+	Text      string
+	Reference string
+	Category  string
+}
+
+// IsZero reports whether the memo notes nothing.
+func (m Memo) IsZero() bool {
+	return m.Text == "" && m.Reference == "" && m.Category == ""
+}
+
+// Validate checks that each part of the memo fits on one line of the transaction history. Parts may not span lines
+// or contain ": ", which would read as a field of its own.
+func (m Memo) Validate() error {
+	for _, part := range []struct {
+		name  string
+		value string
+		max   int
+	}{{"memo", m.Text, MaxMemoText}, {"reference", m.Reference, MaxMemoReference}, {"category", m.Category, MaxMemoCategory}} {
+		if len(part.value) > part.max {
+			return fmt.Errorf("%s is longer than %d characters", part.name, part.max)
+		}
+		if strings.ContainsAny(part.value, "\r\n") || strings.Contains(part.value, ": ") {
+			return fmt.Errorf("%s must be one line without \": \"", part.name)
+		}
+		if strings.TrimSpace(part.value) != part.value {
+			return fmt.Errorf("%s must not start or end with spaces", part.name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package transaction
+
+import (
+	"errors"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// Split describes one leg of a split transfer. Exactly one of Amount or Percent should be set;
+// Percent is a share of the total being split, e.g. 25 for a quarter.
+type Split struct {
+	ToID    string
+	Amount  account.Money
+	Percent float64
+}
+
+// SplitAmounts resolves each split into a concrete amount that together add up to the total.
+func SplitAmounts(total account.Money, splits []Split) ([]account.Money, error) {
+	if total <= 0 {
+		return nil, errors.New("transfer amount must be positive")
+	}
+	if len(splits) == 0 {
+		return nil, errors.New("at least one split is required")
+	}
+	amounts := make([]account.Money, len(splits))
+	lastPercent := -1
+	sum := account.Money(0)
+	for i, split := range splits {
+		switch {
+		case split.Amount > 0 && split.Percent > 0:
+			return nil, errors.New("split must set either an amount or a percentage, not both")
+		case split.Amount > 0:
+			amounts[i] = split.Amount
+		case split.Percent > 0:
+			amounts[i] = total.MulRate(split.Percent / 100)
+			lastPercent = i
+		default:
+			return nil, errors.New("split amount must be positive")
+		}
+		sum += amounts[i]
+	}
+	// Let the last percentage leg absorb rounding so the legs add up to the total exactly
+	diff := total - sum
+	if diff != 0 && lastPercent >= 0 && diff >= -account.Money(len(splits)) && diff <= account.Money(len(splits)) {
+		amounts[lastPercent] += diff
+		diff = 0
+	}
+	if diff != 0 {
+		return nil, errors.New("splits do not add up to the transfer amount")
+	}
+	return amounts, nil
+}
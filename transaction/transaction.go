@@ -0,0 +1,127 @@
+// Package transaction defines how money moves between accounts: transfers, fees, splits and the descriptions
+// attached to transactions.
+package transaction
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ashwinl12/go-banking-system/account"
+)
+
+// Transaction defines the common behavior of a transaction.
+type Transaction interface {
+	Execute() error
+}
+
+// Transfer represents a transfer transaction between accounts.
+type Transfer struct {
+	transactionID string // Unique transaction ID
+	from          account.Account
+	to            account.Account
+	amount        account.Money
+	credit        account.Money // Amount deposited, which differs from amount when converting between currencies
+	status        Status
+}
+
+// Status is where a transfer stands. Once Execute returns, a transfer is completed, failed or compensation-pending.
+type Status string
+
+const (
+	StatusPending             Status = "pending"              // Not yet executed
+	StatusCompleted           Status = "completed"            // Debited and credited
+	StatusFailed              Status = "failed"               // Nothing moved, or the debit was refunded
+	StatusCompensationPending Status = "compensation-pending" // Debited, but neither credited nor refunded
+)
+
+// CompensationError is returned when a transfer debited the source, could not credit the destination and could not
+// refund the source either. The debited amount is held until someone resolves the transfer by hand.
+type CompensationError struct {
+	TransactionID string
+	Amount        account.Money // Debited from the source
+	CreditErr     error         // Why the destination could not be credited
+	RefundErr     error         // Why the source could not be refunded
+}
+
+func (e *CompensationError) Error() string {
+	return fmt.Sprintf("transfer %s debited %s but could not credit the destination (%v) or refund the source (%v); compensation pending",
+		e.TransactionID, e.Amount, e.CreditErr, e.RefundErr)
+}
+
+// Unwrap returns the credit and refund errors.
+func (e *CompensationError) Unwrap() []error {
+	return []error{e.CreditErr, e.RefundErr}
+}
+
+// NewTransfer prepares a transfer of amount between two accounts under the given transaction ID.
+func NewTransfer(txnID string, from, to account.Account, amount account.Money) *Transfer {
+	return &Transfer{
+		transactionID: txnID,
+		from:          from,
+		to:            to,
+		amount:        amount,
+		credit:        amount,
+		status:        StatusPending,
+	}
+}
+
+// NewConversion prepares a transfer that debits amount from one account and credits the converted amount,
+// in the destination account's currency, to the other.
+func NewConversion(txnID string, from, to account.Account, amount, converted account.Money) *Transfer {
+	return &Transfer{
+		transactionID: txnID,
+		from:          from,
+		to:            to,
+		amount:        amount,
+		credit:        converted,
+		status:        StatusPending,
+	}
+}
+
+// Execute executes the transfer transaction, leaving it completed, failed or compensation-pending. If the
+// destination cannot be credited the source is refunded; if that fails too, Execute returns a *CompensationError.
+func (tt *Transfer) Execute() error {
+	if tt.status != StatusPending {
+		return errors.New("transfer has already been executed")
+	}
+	tt.status = StatusFailed
+	if tt.from == nil || tt.to == nil {
+		return errors.New("invalid accounts for transfer")
+	}
+	if tt.amount <= 0 || tt.credit <= 0 {
+		return errors.New("transfer amount must be positive")
+	}
+
+	// Perform withdrawal from source account
+	if err := tt.from.Withdraw(tt.amount); err != nil {
+		return err
+	}
+
+	// Perform deposit into destination account
+	if err := tt.to.Deposit(tt.credit); err != nil {
+		// Refund the source; if that fails the money is held until the transfer is resolved
+		if refundErr := tt.from.Deposit(tt.amount); refundErr != nil {
+			tt.status = StatusCompensationPending
+			return &CompensationError{TransactionID: tt.transactionID, Amount: tt.amount, CreditErr: err, RefundErr: refundErr}
+		}
+		return err
+	}
+
+	tt.status = StatusCompleted
+	return nil
+}
+
+// ID returns the transfer's transaction ID.
+func (tt *Transfer) ID() string {
+	return tt.transactionID
+}
+
+// Succeeded reports whether the transfer has executed successfully.
+func (tt *Transfer) Succeeded() bool {
+	return tt.status == StatusCompleted
+}
+
+// Status returns where the transfer stands.
+func (tt *Transfer) Status() Status {
+	return tt.status
+}